@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+// Bet sizing the autoplay bot supports
+const (
+	sizingFlat       = "flat"       // Always the base bet
+	sizingMartingale = "martingale" // Double after each loss, back to the base bet after a win
+)
+
+// Counts from an autoplay session, reported when it ends
+type autoplaySummary struct {
+	Hands      int     `json:"hands"`
+	Wins       int     `json:"wins"`
+	Blackjacks int     `json:"blackjacks"`
+	Losses     int     `json:"losses"`
+	Pushes     int     `json:"pushes"`
+	Surrenders int     `json:"surrenders"`
+	Wagered    float64 `json:"wagered"`
+	Net        float64 `json:"net"`
+}
+
+// Plays hands solo with basic strategy until it has played hands of them or
+// a command fails, then prints a summary and returns the batch exit code
+func autoplay(s *session, hands int, base, sizing string) int {
+	baseCents, err := parseDollars(base)
+	if err != nil || baseCents <= 0 {
+		s.notice("ERROR " + tr("Autoplay needs a bet: use --bet or set bet in the config file"))
+		return exitUsage
+	}
+	if sizing != sizingFlat && sizing != sizingMartingale {
+		s.notice("ERROR " + tr("Unknown bet sizing %q (use %s or %s)", sizing, sizingFlat, sizingMartingale))
+		return exitUsage
+	}
+
+	var summary autoplaySummary
+	status := exitOK
+	bet := baseCents
+	for summary.Hands < hands {
+		reply, err := s.paced(fmt.Sprintf("BET %.2f", float64(bet)/100))
+		for err == nil && reply.ok() && reply.game() != nil && reply.game().Phase != game.PhaseGameOver {
+			reply, err = s.paced(nextPlay(reply.game()))
+		}
+		if code := replyStatus(reply.text(), err); code != exitOK {
+			status = code
+			break
+		}
+		hand := reply.game()
+		if hand == nil {
+			status = exitCommandError
+			break
+		}
+
+		wagered, payout := hand.Bet+hand.SideBets, hand.Payout
+		summary.Hands++
+		summary.Wagered += float64(wagered) / 100
+		summary.Net += float64(payout-wagered) / 100
+		switch hand.Result {
+		case "Blackjack":
+			summary.Wins++
+			summary.Blackjacks++
+		case "Win":
+			summary.Wins++
+		case "Push":
+			summary.Pushes++
+		case "Surrendered":
+			summary.Surrenders++
+		default:
+			summary.Losses++
+		}
+
+		if sizing == sizingMartingale {
+			switch {
+			case payout < wagered:
+				bet *= 2
+			case payout > wagered:
+				bet = baseCents
+			}
+		}
+	}
+
+	s.printSummary(summary)
+	return status
+}
+
+// The wait the server asks for in a RATE_LIMITED error
+var retryPattern = regexp.MustCompile(`\(retry in (\S+)\)`)
+
+// Sends a command, waiting and sending it again for as long as the server
+// says commands are coming too fast
+func (s *session) paced(line string) (serverMessage, error) {
+	for {
+		reply, err := s.request(line, false)
+		if err != nil {
+			return reply, err
+		}
+		m := retryPattern.FindStringSubmatch(reply.Message)
+		if reply.Code != "RATE_LIMITED" || m == nil {
+			return reply, nil
+		}
+		wait, err := time.ParseDuration(m[1])
+		if err != nil {
+			return reply, nil
+		}
+		time.Sleep(wait)
+	}
+}
+
+// Returns the exit code for how a command went: its error, or an ERROR reply
+func replyStatus(reply string, err error) int {
+	switch {
+	case err == errNoReply:
+		return exitTimeout
+	case err != nil:
+		return exitConnection
+	case !strings.HasPrefix(reply, "OK"):
+		return exitCommandError
+	}
+	return exitOK
+}
+
+// Picks the basic strategy play for a hand in progress
+func nextPlay(hand *gameState) string {
+	if hand == nil || len(hand.Player.Cards) == 0 || len(hand.Dealer.Cards) == 0 {
+		return "STAND"
+	}
+	up := hand.Dealer.Cards[0]
+	return game.BasicStrategy(hand.Player.hand(), game.NewCard(up.Rank, up.Suit), game.Rules{}, hand.Actions)
+}
+
+// Parses an amount such as "$12.50" or "12.5" into cents
+func parseDollars(amount string) (int64, error) {
+	dollars, err := strconv.ParseFloat(strings.TrimPrefix(strings.TrimSpace(amount), "$"), 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(math.Round(dollars * 100)), nil
+}
+
+func (s *session) printSummary(summary autoplaySummary) {
+	if s.json {
+		data, _ := json.Marshal(struct {
+			Type string `json:"type"`
+			autoplaySummary
+		}{"summary", summary})
+		s.mu.Lock()
+		fmt.Println(string(data))
+		s.mu.Unlock()
+		return
+	}
+
+	rate := 0.0
+	if summary.Hands > 0 {
+		rate = float64(summary.Wins) / float64(summary.Hands) * 100
+	}
+	s.print("\n" + tr("Autoplay: %d hands, %d won (%.1f%%, %d blackjacks), %d lost, %d pushed, %d surrendered",
+		summary.Hands, summary.Wins, rate, summary.Blackjacks, summary.Losses, summary.Pushes, summary.Surrenders))
+	sign := "+"
+	if summary.Net < 0 {
+		sign = "-"
+	}
+	s.print(tr("Wagered $%.2f, net %s$%.2f", summary.Wagered, sign, math.Abs(summary.Net)))
+}
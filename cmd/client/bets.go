@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A stake line in the TABLES reply: the marker, ID, name and "$min - $max"
+var limitsPattern = regexp.MustCompile(`^ \* .*?\$([0-9.]+) - \$([0-9.]+)`)
+
+var balancePattern = regexp.MustCompile(`Balance: \$(-?[0-9.]+)`)
+
+// Expands the bet shortcuts into a BET with a concrete amount: REBET repeats
+// the last bet, BET MIN and BET MAX use the limits of the table the player is
+// at, and BET 10% a share of their balance. Other commands come back as typed.
+func (s *session) expandBet(input string) (string, error) {
+	parts := strings.Fields(input)
+	switch command := strings.ToUpper(parts[0]); {
+	case command == "REBET" && len(parts) == 1:
+		if s.lastBet == "" {
+			return "", errors.New(tr("No bet to repeat yet"))
+		}
+		return "BET " + s.lastBet, nil
+	case command != "BET" || len(parts) != 2:
+		return input, nil
+	}
+
+	var cents int64
+	switch arg := strings.ToUpper(parts[1]); {
+	case arg == "MIN" || arg == "MAX":
+		lo, hi, err := s.tableLimits()
+		if err != nil {
+			return "", err
+		}
+		cents = lo
+		if arg == "MAX" {
+			balance, err := s.balance()
+			if err != nil {
+				return "", err
+			}
+			cents = max(lo, min(hi, balance))
+		}
+	case strings.HasSuffix(arg, "%"):
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(arg, "%"), 64)
+		if err != nil || percent <= 0 || percent > 100 {
+			return "", errors.New(tr("Percentage bets must be between 0%% and 100%%"))
+		}
+		balance, err := s.balance()
+		if err != nil {
+			return "", err
+		}
+		cents = int64(float64(balance) * percent / 100)
+		if cents <= 0 {
+			return "", errors.New(tr("%s of your balance is less than a cent", parts[1]))
+		}
+	default:
+		return input, nil
+	}
+	return fmt.Sprintf("BET %.2f", float64(cents)/100), nil
+}
+
+// Asks the server for the bet limits of the table the player is at: the
+// marked multiplayer table when seated at one, otherwise their solo table
+func (s *session) tableLimits() (lo, hi int64, err error) {
+	reply, err := s.command("TABLES", true)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !strings.HasPrefix(reply, "OK") {
+		return 0, 0, fmt.Errorf("%s", strings.TrimPrefix(reply, "ERROR "))
+	}
+
+	found := false
+	for _, line := range strings.Split(reply, "\n") {
+		// The multiplayer listing comes last, so a seat there wins
+		if m := limitsPattern.FindStringSubmatch(line); m != nil {
+			lo, _ = parseDollars(m[1])
+			hi, _ = parseDollars(m[2])
+			found = true
+		}
+	}
+	if !found {
+		return 0, 0, errors.New(tr("Couldn't find your table's limits in TABLES"))
+	}
+	return lo, hi, nil
+}
+
+// Asks the server for the player's balance
+func (s *session) balance() (int64, error) {
+	reply, err := s.command("BALANCE", true)
+	if err != nil {
+		return 0, err
+	}
+	m := balancePattern.FindStringSubmatch(reply)
+	if m == nil {
+		return 0, fmt.Errorf("%s", strings.TrimPrefix(reply, "ERROR "))
+	}
+	return parseDollars(m[1])
+}
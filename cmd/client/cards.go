@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+// A card as a reply's data gives it
+type jsonCard struct {
+	Rank string `json:"rank"`
+	Suit string `json:"suit"`
+}
+
+type jsonHand struct {
+	Cards []jsonCard `json:"cards"`
+	Value int        `json:"value"`
+}
+
+// A solo hand as a reply's data.game gives it. During the player's turn the
+// dealer shows only the up card; the hole card is face down.
+type gameState struct {
+	Phase    game.GamePhase `json:"phase"`
+	Player   jsonHand       `json:"player"`
+	Dealer   jsonHand       `json:"dealer"`
+	Bet      int64          `json:"bet"`
+	SideBets int64          `json:"side_bets"`
+	Result   string         `json:"result"`
+	Payout   int64          `json:"payout"`
+	Actions  []string       `json:"actions"`
+}
+
+func (h jsonHand) hand() *game.Hand {
+	hand := game.NewHand()
+	for _, c := range h.Cards {
+		hand.AddCard(game.NewCard(c.Rank, c.Suit))
+	}
+	return hand
+}
+
+// Splits a hand line of a solo game into its text without the cards and the
+// cards' art rows, drawn from the hand in g. Other lines, and any line when
+// g is nil, come back unchanged with no rows.
+func cardArt(line string, g *gameState) (label string, rows []string) {
+	if g == nil {
+		return line, nil
+	}
+	var hand jsonHand
+	hidden := false
+	switch {
+	case strings.HasPrefix(line, "Player Hand:"):
+		label, hand = "Player Hand:", g.Player
+	case strings.HasPrefix(line, "Dealer Hand:"):
+		label, hand = "Dealer Hand:", g.Dealer
+		hidden = g.Phase == game.PhasePlayerTurn
+	default:
+		return line, nil
+	}
+	if len(hand.Cards) == 0 {
+		return line, nil
+	}
+	if !hidden {
+		label += fmt.Sprintf(" (Value: %d)", hand.Value)
+	}
+
+	var boxes [][]string
+	for _, c := range hand.Cards {
+		boxes = append(boxes, cardBox(c.Rank, c.Suit))
+	}
+	if hidden {
+		boxes = append(boxes, hiddenBox)
+	}
+	rows = make([]string, 5)
+	for i, box := range boxes {
+		for r := range rows {
+			if i > 0 {
+				rows[r] += " "
+			}
+			rows[r] += box[r]
+		}
+	}
+	return label, rows
+}
+
+// The dealer's face-down card
+var hiddenBox = []string{
+	"+-----+",
+	"|/////|",
+	"|/////|",
+	"|/////|",
+	"+-----+",
+}
+
+// Draws one face-up card with its rank in the corners and suit in the middle
+func cardBox(rank, suit string) []string {
+	pad := strings.Repeat(" ", 3-len(rank))
+	return []string{
+		"+-----+",
+		"|" + rank + " " + pad + " |",
+		"|  " + suit + "  |",
+		"| " + pad + " " + rank + "|",
+		"+-----+",
+	}
+}
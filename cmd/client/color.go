@@ -0,0 +1,52 @@
+package main
+
+import "strings"
+
+const (
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiReset  = "\x1b[0m"
+)
+
+// How multiplayer table lines mark a seat's result, and its color
+var seatResultColors = []struct{ marker, color string }{
+	{" - Blackjack,", ansiGreen},
+	{" - Win,", ansiGreen},
+	{" - Loss,", ansiRed},
+	{" - Bust,", ansiRed},
+	{" - Surrendered,", ansiRed},
+}
+
+// Returns the color a whole line is shown in: yellow for errors and
+// warnings, green for a win, red for a loss, or empty for none
+func lineColor(line string) string {
+	switch {
+	case strings.HasPrefix(line, "ERROR"), strings.HasPrefix(line, "EVENT SECURITY"), strings.Contains(line, "seconds left to act"):
+		return ansiYellow
+	case strings.HasPrefix(line, "Result: Blackjack!"), strings.HasPrefix(line, "Result: You win"):
+		return ansiGreen
+	case strings.HasPrefix(line, "Result: Dealer wins"), strings.HasPrefix(line, "Result: Bust"):
+		return ansiRed
+	}
+	return ""
+}
+
+// Colors hearts and diamonds red, a seat's result by how it went and the rest
+// of the line in base. Colors already in the line, such as a card back, go
+// back to base where they end.
+func paint(line, base string) string {
+	line = strings.NewReplacer("♥", ansiRed+"♥"+ansiReset, "♦", ansiRed+"♦"+ansiReset).Replace(line)
+
+	for _, r := range seatResultColors {
+		if i := strings.Index(line, r.marker); i >= 0 {
+			line = line[:i] + r.color + line[i:] + ansiReset
+			break
+		}
+	}
+
+	if base == "" {
+		return line
+	}
+	return base + strings.ReplaceAll(line, ansiReset, ansiReset+base) + ansiReset
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+// Commands offered when completing the first word
+var commandNames = []string{
+	"ACHIEVEMENTS", "ADMIN", "ALLOWIP", "APIKEY", "AUTH", "BACCARAT", "BALANCE", "BET",
+	"BLOCK", "BUY", "CASHBACK", "CHAT", "DAILY", "DEPOSIT", "DOUBLE", "DRAW", "EQUIP", "EVENTS",
+	"EXIT", "FEED", "GAMES", "GUEST", "HELP", "HILO", "HISTORY", "HIT", "HOLDEM", "HOST", "INVITE", "JACKPOT", "JOIN",
+	"LEADERBOARD", "LEAVE", "LIMITS", "LOGIN", "LOGOUT", "MSG", "MUTE", "PICK", "PLAY", "PROFILE", "PROFIT",
+	"QUIT", "REACT", "REBET", "REBUY", "REDEEM", "REFER", "REFERRAL", "REMEMBER", "RESUME", "REVIEW", "SHOP",
+	"SIGNUP", "SIT", "STAND", "STATS", "SURRENDER", "TABLE", "TABLES",
+	"TRANSFER", "UNBLOCK", "UNMUTE", "WHOAMI", "WITHDRAW",
+}
+
+// Reactions REACT accepts
+var emoteNames = []string{"nice", "ouch", "gg", "gl", "wow"}
+
+// Most player names remembered for completion
+const maxCompletionUsers = 200
+
+// completer suggests commands and their arguments, learning stake levels and
+// table IDs from the last TABLES reply and player names from chat, messages
+// and the block and mute lists. It is guarded by the session's output lock.
+type completer struct {
+	stakes []string
+	tables []string
+	users  []string
+}
+
+// Picks up table IDs and player names from a message from the server
+func (c *completer) observe(message string) {
+	if strings.HasPrefix(message, "OK Tables:") {
+		c.observeTables(message)
+		return
+	}
+
+	for _, prefix := range []string{"OK Blocked: ", "OK Muted: "} {
+		if list, ok := strings.CutPrefix(message, prefix); ok {
+			for _, name := range strings.Split(list, ", ") {
+				c.addUser(name)
+			}
+			return
+		}
+	}
+
+	// Chat and reactions are "[table] name: text", direct messages "name: text"
+	// or "name (sent at): text" for ones held while offline
+	for _, kind := range []string{"EVENT CHAT ", "EVENT REACT ", "EVENT MSG "} {
+		line, ok := strings.CutPrefix(message, kind)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			_, line, _ = strings.Cut(line, "] ")
+		}
+		if name, _, ok := strings.Cut(line, ":"); ok {
+			if fields := strings.Fields(name); len(fields) > 0 {
+				c.addUser(fields[0])
+			}
+		}
+		return
+	}
+}
+
+// Reads the stake levels and multiplayer table IDs from a TABLES reply, where
+// each table is on a line of its own after a one-character marker
+func (c *completer) observeTables(message string) {
+	c.stakes, c.tables = nil, nil
+	multiplayer := false
+	for _, line := range strings.Split(message, "\n") {
+		if strings.HasPrefix(line, "Multiplayer tables:") {
+			multiplayer = true
+			continue
+		}
+		if len(line) < 4 || line[0] != ' ' || line[2] != ' ' {
+			continue
+		}
+		fields := strings.Fields(line[3:])
+		if len(fields) == 0 {
+			continue
+		}
+		if multiplayer {
+			c.tables = append(c.tables, fields[0])
+		} else {
+			c.stakes = append(c.stakes, fields[0])
+		}
+	}
+}
+
+func (c *completer) addUser(name string) {
+	if name == "" {
+		return
+	}
+	for i, known := range c.users {
+		if strings.EqualFold(known, name) {
+			c.users = append(c.users[:i], c.users[i+1:]...)
+			break
+		}
+	}
+	c.users = append(c.users, name)
+	if len(c.users) > maxCompletionUsers {
+		c.users = c.users[1:]
+	}
+}
+
+// Returns the completions of the last word of head, the text before the cursor
+func (c *completer) complete(head string) []string {
+	words := strings.Fields(head)
+	if len(words) == 0 || strings.HasSuffix(head, " ") {
+		words = append(words, "")
+	}
+	if len(words) == 1 {
+		return matching(commandNames, words[0], true)
+	}
+
+	word := words[len(words)-1]
+	arg := len(words) - 1
+	switch strings.ToUpper(words[0]) {
+	case "SIT", "HOST":
+		if arg == 1 {
+			return matching(c.stakes, word, false)
+		}
+	case "JOIN":
+		if arg == 1 {
+			return matching(append(append([]string{}, c.tables...), c.stakes...), word, false)
+		}
+	case "MSG", "TRANSFER", "BLOCK", "UNBLOCK", "MUTE", "UNMUTE":
+		if arg == 1 {
+			return matching(c.users, word, false)
+		}
+	case "BET":
+		if arg == 1 {
+			return matching([]string{"MAX", "MIN"}, word, true)
+		}
+	case "BACCARAT":
+		if arg == 1 {
+			return matching([]string{"PLAYER", "BANKER", "TIE"}, word, true)
+		}
+	case "PLAY":
+		if arg == 1 {
+			var names []string
+			for _, info := range game.Engines() {
+				names = append(names, info.Name)
+			}
+			return matching(names, word, false)
+		}
+	case "LEADERBOARD":
+		if arg == 1 {
+			return matching([]string{"balance", "net", "winrate", "biggest"}, word, false)
+		}
+	case "HILO":
+		if arg == 1 {
+			return matching([]string{"HIGHER", "LOWER", "CASHOUT"}, word, true)
+		}
+	case "HOLDEM":
+		if arg == 1 {
+			return matching([]string{"BET", "CHECK", "FOLD"}, word, true)
+		}
+	case "REACT":
+		if arg == 1 {
+			return matching(emoteNames, word, false)
+		}
+	case "CHAT":
+		if arg == 1 {
+			return matching([]string{"LOBBY"}, word, true)
+		}
+	case "FEED":
+		if arg == 1 {
+			return matching([]string{"ON", "OFF", "ANON"}, word, true)
+		}
+		if arg == 2 && strings.EqualFold(words[1], "ANON") {
+			return matching([]string{"ON", "OFF"}, word, true)
+		}
+	}
+	return nil
+}
+
+// Returns the candidates starting with word, ignoring case, sorted. Keywords
+// follow the case the word is typed in.
+func matching(candidates []string, word string, keyword bool) []string {
+	lower := keyword && word != "" && word == strings.ToLower(word)
+
+	var matches []string
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if !strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(word)) {
+			continue
+		}
+		if lower {
+			candidate = strings.ToLower(candidate)
+		}
+		if !seen[candidate] {
+			seen[candidate] = true
+			matches = append(matches, candidate)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
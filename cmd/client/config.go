@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// config is what the client reads from client.toml, before flags override it
+type config struct {
+	Server    string // host:port of the casino server, or tls://host:port
+	Color     bool
+	ArtCards  bool
+	Bet       string        // Amount a bare BET uses, in dollars
+	BetSizing string        // How autoplay sizes bets: flat or martingale
+	Token     string        // API key sent with AUTH on connect
+	Notify    string        // How to say it's the player's turn: off, bell, desktop or all
+	Keepalive time.Duration // Idle time before pinging the server; 0 turns pings off
+	Lang      string        // Language of the client's own text; empty follows LANG
+	Remember  bool          // Resume the last login on the next start
+
+	TLS           bool
+	TLSCAFile     string // PEM certificates to trust instead of the system's
+	TLSServerName string // Name to verify the certificate against, if not the host
+	TLSInsecure   bool   // Skip certificate verification, for testing only
+}
+
+func defaultConfig() config {
+	return config{Server: "127.0.0.1:9090", Color: true, BetSizing: sizingFlat, Notify: notifyBell, Keepalive: defaultKeepalive}
+}
+
+// Returns ~/.config/casino/client.toml, or under $XDG_CONFIG_HOME when set
+func defaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "casino", "client.toml")
+}
+
+// Reads a config file over the defaults. A missing file isn't an error unless
+// it was asked for by name.
+func loadConfig(path string, required bool) (config, error) {
+	cfg := defaultConfig()
+	if path == "" {
+		return cfg, nil
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) && !required {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf(translate("failed to open config: %w"), err)
+	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return cfg, fmt.Errorf(translate("%s:%d: expected key = value"), path, n)
+		}
+		key = strings.TrimSpace(key)
+		if section != "" {
+			key = section + "." + key
+		}
+		if err := cfg.set(key, strings.TrimSpace(raw)); err != nil {
+			return cfg, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return cfg, fmt.Errorf(translate("failed to read config: %w"), err)
+	}
+	return cfg, nil
+}
+
+// Sets a key from the file, checking its value has the type the key needs
+func (c *config) set(key, raw string) error {
+	switch key {
+	case "server":
+		return parseString(raw, &c.Server)
+	case "color":
+		return parseBool(raw, &c.Color)
+	case "art_cards":
+		return parseBool(raw, &c.ArtCards)
+	case "bet":
+		// Either a number or a string, as it would be typed after BET
+		if _, err := strconv.ParseFloat(raw, 64); err == nil {
+			c.Bet = raw
+			return nil
+		}
+		return parseString(raw, &c.Bet)
+	case "bet_sizing":
+		return parseString(raw, &c.BetSizing)
+	case "notify":
+		return parseString(raw, &c.Notify)
+	case "token":
+		return parseString(raw, &c.Token)
+	case "remember":
+		return parseBool(raw, &c.Remember)
+	case "lang":
+		return parseString(raw, &c.Lang)
+	case "keepalive":
+		return parseDuration(raw, &c.Keepalive)
+	case "tls.enabled":
+		return parseBool(raw, &c.TLS)
+	case "tls.ca_file":
+		return parseString(raw, &c.TLSCAFile)
+	case "tls.server_name":
+		return parseString(raw, &c.TLSServerName)
+	case "tls.insecure_skip_verify":
+		return parseBool(raw, &c.TLSInsecure)
+	}
+	return fmt.Errorf(translate("unknown setting %s"), key)
+}
+
+// Drops a # comment, unless the # is inside a quoted string. As in TOML,
+// backslash escapes only apply within "double" quotes, not 'single' ones.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && quote == '"':
+			i++
+		case c == '"' || c == '\'':
+			if quote == 0 {
+				quote = c
+			} else if quote == c {
+				quote = 0
+			}
+		case c == '#' && quote == 0:
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseString(raw string, dst *string) error {
+	if strings.HasPrefix(raw, "'") && strings.HasSuffix(raw, "'") && len(raw) >= 2 {
+		*dst = raw[1 : len(raw)-1]
+		return nil
+	}
+	s, err := strconv.Unquote(raw)
+	if err != nil || !strings.HasPrefix(raw, `"`) {
+		return fmt.Errorf(translate("expected a quoted string, got %s"), raw)
+	}
+	*dst = s
+	return nil
+}
+
+// Reads a duration such as "90s" or "2m", or 0 for none
+func parseDuration(raw string, dst *time.Duration) error {
+	text := raw
+	if raw != "0" {
+		if err := parseString(raw, &text); err != nil {
+			return fmt.Errorf(translate("expected a duration such as \"90s\", got %s"), raw)
+		}
+	}
+	d, err := time.ParseDuration(text)
+	if err != nil || d < 0 {
+		return fmt.Errorf(translate("expected a duration such as \"90s\", got %s"), raw)
+	}
+	*dst = d
+	return nil
+}
+
+func parseBool(raw string, dst *bool) error {
+	switch raw {
+	case "true":
+		*dst = true
+	case "false":
+		*dst = false
+	default:
+		return fmt.Errorf(translate("expected true or false, got %s"), raw)
+	}
+	return nil
+}
+
+// A tls:// server address turns TLS on, as if enabled was set
+func (c *config) applyScheme() {
+	if addr, ok := strings.CutPrefix(c.Server, "tls://"); ok {
+		c.Server, c.TLS = addr, true
+	}
+}
+
+// Connects to the configured server, over TLS when it is enabled
+func (c config) dial() (net.Conn, error) {
+	if !c.TLS {
+		return net.Dial("tcp", c.Server)
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         c.TLSServerName,
+		InsecureSkipVerify: c.TLSInsecure,
+	}
+	if c.TLSCAFile != "" {
+		pem, err := os.ReadFile(c.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf(translate("failed to read CA file: %w"), err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf(translate("no certificates found in %s"), c.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tls.Dial("tcp", c.Server, tlsConfig)
+}
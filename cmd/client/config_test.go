@@ -0,0 +1,150 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Writes contents to a client.toml in a temporary directory and returns its path
+func writeTestConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "client.toml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeTestConfig(t, `# Casino client settings
+server = "casino.example.com:9090"  # the main server
+color = false
+bet = 25
+bet_sizing = 'martingale'
+token = 'ab#cd'
+notify = "desk\"top#1"
+keepalive = "90s"
+
+[tls]
+enabled = true
+server_name = "casino.example.com"
+`)
+
+	cfg, err := loadConfig(path, true)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	want := defaultConfig()
+	want.Server = "casino.example.com:9090"
+	want.Color = false
+	want.Bet = "25"
+	want.BetSizing = sizingMartingale
+	want.Token = "ab#cd"
+	want.Notify = `desk"top#1`
+	want.Keepalive = 90 * time.Second
+	want.TLS = true
+	want.TLSServerName = "casino.example.com"
+	if cfg != want {
+		t.Errorf("loadConfig() = %+v, want %+v", cfg, want)
+	}
+}
+
+func TestLoadConfigMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "client.toml")
+
+	cfg, err := loadConfig(path, false)
+	if err != nil || cfg != defaultConfig() {
+		t.Errorf("loadConfig() of a missing default file = %+v, %v, want the defaults", cfg, err)
+	}
+	if _, err := loadConfig(path, true); err == nil {
+		t.Error("loadConfig() should fail for a missing file asked for by name")
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		contents string
+		want     string // Expected in the error, after the file name
+	}{
+		{"server\n", ":1: expected key = value"},
+		{"# comment\nvolume = 11\n", ":2: unknown setting volume"},
+		{"[tls]\nserver = \"x\"\n", ":2: unknown setting tls.server"},
+		{"color = yes\n", ":1: expected true or false"},
+		{"token = secret\n", ":1: expected a quoted string"},
+		{"token = 'ab#cd\n", ":1: expected a quoted string"},
+	}
+	for _, tt := range tests {
+		path := writeTestConfig(t, tt.contents)
+		_, err := loadConfig(path, true)
+		if err == nil || !strings.Contains(err.Error(), path+tt.want) {
+			t.Errorf("loadConfig(%q) error = %v, want %q", tt.contents, err, tt.want)
+		}
+	}
+}
+
+func TestConfigSet(t *testing.T) {
+	var cfg config
+	if err := cfg.set("bet", `"max"`); err != nil || cfg.Bet != "max" {
+		t.Errorf("set(bet, \"max\") = %v, Bet = %q", err, cfg.Bet)
+	}
+	if err := cfg.set("bet", "12.50"); err != nil || cfg.Bet != "12.50" {
+		t.Errorf("set(bet, 12.50) = %v, Bet = %q", err, cfg.Bet)
+	}
+	if err := cfg.set("tls.insecure_skip_verify", "true"); err != nil || !cfg.TLSInsecure {
+		t.Errorf("set(tls.insecure_skip_verify, true) = %v, TLSInsecure = %v", err, cfg.TLSInsecure)
+	}
+	if err := cfg.set("lang", `"es"`); err != nil || cfg.Lang != "es" {
+		t.Errorf("set(lang, \"es\") = %v, Lang = %q", err, cfg.Lang)
+	}
+	if err := cfg.set("tls_enabled", "true"); err == nil {
+		t.Error("set() should reject an unknown key")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want time.Duration
+		ok   bool
+	}{
+		{`"90s"`, 90 * time.Second, true},
+		{`'2m'`, 2 * time.Minute, true},
+		{"0", 0, true},
+		{`"0"`, 0, true},
+		{"90s", 0, false},
+		{"5", 0, false},
+		{`"-1m"`, 0, false},
+		{`"soon"`, 0, false},
+	}
+	for _, tt := range tests {
+		d := time.Hour
+		err := parseDuration(tt.raw, &d)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseDuration(%s) error = %v, want ok %v", tt.raw, err, tt.ok)
+			continue
+		}
+		if tt.ok && d != tt.want {
+			t.Errorf("parseDuration(%s) = %v, want %v", tt.raw, d, tt.want)
+		}
+	}
+}
+
+func TestStripComment(t *testing.T) {
+	tests := []struct{ line, want string }{
+		{`color = true # on`, `color = true `},
+		{`token = "ab#cd" # key`, `token = "ab#cd" `},
+		{`token = 'ab#cd'`, `token = 'ab#cd'`},
+		{`token = 'a"b#c' # key`, `token = 'a"b#c' `},
+		{`token = "a'b#c" # key`, `token = "a'b#c" `},
+		{`token = "a\"#b"`, `token = "a\"#b"`},
+		{`path = 'C:\dir\' # windows`, `path = 'C:\dir\' `},
+	}
+	for _, tt := range tests {
+		if got := stripComment(tt.line); got != tt.want {
+			t.Errorf("stripComment(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// How each card back draws the dealer's hidden card
+var cardBacks = map[string]string{
+	"card_back_classic": "[▒▒]",
+	"card_back_red":     "[\x1b[31m♦♦\x1b[0m]",
+	"card_back_gold":    "[\x1b[33m★★\x1b[0m]",
+}
+
+// ANSI colors used for hand lines on each table theme
+var tableThemes = map[string]string{
+	"table_green":  "\x1b[32m",
+	"table_blue":   "\x1b[34m",
+	"table_red":    "\x1b[31m",
+	"table_purple": "\x1b[35m",
+}
+
+// cosmetics holds the equipped look, read from PROFILE replies. The client
+// asks for a profile itself after logging in or equipping.
+type cosmetics struct {
+	mu         sync.Mutex
+	color      bool
+	art        bool // Draw cards as ASCII-art boxes
+	cardBack   string
+	tableTheme string
+}
+
+func newCosmetics(color, art bool) *cosmetics {
+	return &cosmetics{
+		color:      color,
+		art:        art,
+		cardBack:   "card_back_classic",
+		tableTheme: "table_green",
+	}
+}
+
+// Picks up the equipped look from a PROFILE reply
+func (c *cosmetics) update(profile string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, line := range strings.Split(profile, "\n") {
+		c.noteLocked(line)
+	}
+}
+
+func (c *cosmetics) noteLocked(line string) {
+	if v, found := strings.CutPrefix(line, "  Card Back: "); found {
+		c.cardBack = v
+	}
+	if v, found := strings.CutPrefix(line, "  Table Theme: "); found {
+		c.tableTheme = v
+	}
+}
+
+// Returns how to print a line from the server in the equipped look. The hand
+// lines of a reply showing the solo hand g are drawn from g's cards.
+func (c *cosmetics) render(line string, g *gameState) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.noteLocked(line)
+
+	hand := strings.HasPrefix(line, "Player Hand:") || strings.HasPrefix(line, "Dealer Hand:")
+	if c.art {
+		if label, rows := cardArt(line, g); rows != nil {
+			out := []string{c.decorate(label, hand)}
+			for _, row := range rows {
+				out = append(out, c.decorate(row, hand))
+			}
+			return strings.Join(out, "\n")
+		}
+	}
+	return c.decorate(line, hand)
+}
+
+// Draws the equipped card back and colors a line; hand lines take the table
+// theme's color
+func (c *cosmetics) decorate(line string, hand bool) string {
+	if strings.Contains(line, "[Hidden]") {
+		back := cardBacks[c.cardBack]
+		if back == "" {
+			back = cardBacks["card_back_classic"]
+		}
+		if !c.color {
+			back = stripANSI(back)
+		}
+		line = strings.ReplaceAll(line, "[Hidden]", back)
+	}
+
+	if !c.color {
+		return line
+	}
+
+	base := lineColor(line)
+	if hand {
+		base = tableThemes[c.tableTheme]
+	}
+	return paint(line, base)
+}
+
+func stripANSI(s string) string {
+	for {
+		start := strings.Index(s, "\x1b[")
+		if start < 0 {
+			return s
+		}
+		end := strings.IndexByte(s[start:], 'm')
+		if end < 0 {
+			return s
+		}
+		s = s[:start] + s[start+end+1:]
+	}
+}
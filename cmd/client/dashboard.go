@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Days of profit the dashboard charts; PROFIT accepts 7 or 30
+const dashboardDays = 30
+
+// Rows in the cumulative profit chart
+const chartHeight = 8
+
+// Blocks for the daily sparkline, lowest first
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// "Level: 3 (Silver VIP) - 120/300 XP to level 4"
+var levelPattern = regexp.MustCompile(`^(\d+) \((.+)\) - (\d+)/(\d+) XP`)
+
+// Fetches STATS and PROFIT quietly and shows them as a dashboard. Returns the
+// STATS reply so the caller can tell whether it worked.
+func (s *session) statsDashboard() (string, error) {
+	stats, err := s.command("STATS", true)
+	if err != nil || !strings.HasPrefix(stats, "OK") {
+		s.print(stats)
+		return stats, err
+	}
+	profit, err := s.command(fmt.Sprintf("PROFIT %d", dashboardDays), true)
+	if err != nil {
+		return "", err
+	}
+
+	s.print(renderDashboard(stats, profit))
+	return stats, nil
+}
+
+// Lays out the STATS reply as two columns, games and money, followed by the
+// daily profit as a sparkline and the running total as a chart
+func renderDashboard(stats, profit string) string {
+	lines := strings.Split(stats, "\n")
+	title := strings.TrimSuffix(strings.TrimPrefix(lines[0], "OK "), ":")
+	values := make(map[string]string)
+	for _, line := range lines[1:] {
+		if key, value, ok := strings.Cut(strings.TrimSpace(line), ": "); ok {
+			values[key] = value
+		}
+	}
+
+	out := title
+	if m := levelPattern.FindStringSubmatch(values["Level"]); m != nil {
+		var xp, next float64
+		fmt.Sscan(m[3], &xp)
+		fmt.Sscan(m[4], &next)
+		out += "\n  " + tr("Level %s, %s", m[1], m[2]) + fmt.Sprintf("  %s %s/%s XP", progressBar(xp, next, 20), m[3], m[4])
+	}
+	if points, ok := values["Comp Points"]; ok {
+		out += "\n  " + tr("Comp points: %s", points)
+	}
+
+	rows := [][2][2]string{
+		{{tr("Played"), values["Games Played"]}, {tr("Total bet"), values["Total Bet"]}},
+		{{tr("Won"), values["Games Won"]}, {tr("Total won"), values["Total Won"]}},
+		{{tr("Lost"), values["Games Lost"]}, {tr("Net"), values["Net"]}},
+		{{tr("Win rate"), values["Win Rate"]}, {tr("Avg bet"), values["Avg Bet"]}},
+		{{tr("Win streak"), values["Win Streak"]}, {tr("Biggest win"), values["Biggest Win"]}},
+		{{tr("Loss streak"), values["Loss Streak"]}, {tr("Biggest loss"), values["Biggest Loss"]}},
+	}
+	// Labels widen to fit the longest in the language they're shown in
+	games, money := 12, 13
+	for _, row := range rows {
+		games = max(games, utf8.RuneCountInString(row[0][0]))
+		money = max(money, utf8.RuneCountInString(row[1][0]))
+	}
+	out += fmt.Sprintf("\n\n  %-*s %s", games+14, tr("Games"), tr("Money"))
+	for _, row := range rows {
+		out += fmt.Sprintf("\n  %-*s %-13s %-*s %12s", games, row[0][0], row[0][1], money, row[1][0], row[1][1])
+	}
+	if rebuys, ok := values["Rebuys"]; ok {
+		out += "\n  " + tr("Rebuys: %s", rebuys)
+	}
+
+	days, nets := parseProfit(profit)
+	if len(days) == 0 {
+		return out
+	}
+	var total float64
+	running := make([]float64, len(nets))
+	for i, net := range nets {
+		total += net
+		running[i] = total
+	}
+	out += "\n\n  " + tr("Profit over the last %d days: %s", len(days), signed(total))
+	out += fmt.Sprintf("\n  %10s  %s", tr("Daily"), sparkline(nets))
+	out += "\n" + chart(running, days[0][5:], days[len(days)-1][5:])
+	return out
+}
+
+// Reads the day and net of each line of a PROFIT reply, "  2024-05-01  +$1.00  +$3.00"
+func parseProfit(reply string) (days []string, nets []float64) {
+	if !strings.HasPrefix(reply, "OK") {
+		return nil, nil
+	}
+	for _, line := range strings.Split(reply, "\n")[1:] {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		cents, err := parseDollars(strings.Replace(fields[1], "$", "", 1))
+		if err != nil {
+			continue
+		}
+		days = append(days, fields[0])
+		nets = append(nets, float64(cents)/100)
+	}
+	return days, nets
+}
+
+// Draws a bar width characters wide, filled in proportion to done/total
+func progressBar(done, total float64, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = min(width, int(done/total*float64(width)))
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", width-filled) + "]"
+}
+
+// Draws one block per value, scaled between the lowest and highest, and a
+// space for days without play
+func sparkline(values []float64) string {
+	lo, hi := 0.0, 0.0
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+
+	var line strings.Builder
+	for _, v := range values {
+		if v == 0 || hi == lo {
+			line.WriteRune(' ')
+			continue
+		}
+		line.WriteRune(sparks[int((v-lo)/(hi-lo)*float64(len(sparks)-1))])
+	}
+	return strings.TrimRight(line.String(), " ")
+}
+
+// Draws values as a chart of chartHeight rows, one column per value, with
+// the highest, zero and lowest marked on the axis
+func chart(values []float64, first, last string) string {
+	lo, hi := 0.0, 0.0
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	if hi == lo {
+		hi = lo + 1
+	}
+	row := func(v float64) int {
+		return int(math.Round((hi - v) / (hi - lo) * (chartHeight - 1)))
+	}
+
+	zero := row(0)
+	var out []string
+	for r := 0; r < chartHeight; r++ {
+		label := ""
+		switch r {
+		case 0:
+			label = signed(hi)
+		case zero:
+			label = "$0"
+		case chartHeight - 1:
+			label = signed(lo)
+		}
+
+		line := fmt.Sprintf("  %10s |", label)
+		for _, v := range values {
+			switch {
+			case row(v) == r:
+				line += "*"
+			case r == zero:
+				line += "-"
+			default:
+				line += " "
+			}
+		}
+		out = append(out, strings.TrimRight(line, " "))
+	}
+
+	axis := fmt.Sprintf("  %10s +%s", "", strings.Repeat("-", len(values)))
+	dates := fmt.Sprintf("  %10s  %s", "", first)
+	if gap := len(values) - len(first) - len(last); gap > 0 {
+		dates += strings.Repeat(" ", gap) + last
+	}
+	return strings.Join(append(out, axis, dates), "\n")
+}
+
+func signed(dollars float64) string {
+	if dollars < 0 {
+		return fmt.Sprintf("-$%.2f", -dollars)
+	}
+	return fmt.Sprintf("+$%.2f", dollars)
+}
@@ -0,0 +1,59 @@
+package main
+
+import "encoding/json"
+
+// serverMessage is a reply or event as the server sends it after MODE JSON,
+// one object per line
+type serverMessage struct {
+	Type    string          `json:"type"`   // "reply" or "event"
+	Status  string          `json:"status"` // OK or ERROR, for replies
+	Code    string          `json:"code"`   // What went wrong, for ERROR replies
+	Kind    string          `json:"kind"`   // CHAT, MSG, TABLE... for events
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+
+	// The line as received, which --json prints unchanged
+	line string
+}
+
+// Parses a line from the server. A line that isn't JSON is kept as a plain
+// message, so nothing the server says is lost.
+func parseServerMessage(line string) serverMessage {
+	var m serverMessage
+	if err := json.Unmarshal([]byte(line), &m); err != nil {
+		m = serverMessage{Message: line}
+	}
+	m.line = line
+	return m
+}
+
+// The message as the text protocol words it, which the rest of the client
+// reads: "OK ...", "ERROR ..." or "EVENT <kind> ..."
+func (m serverMessage) text() string {
+	prefix := m.Status
+	if m.Type == "event" {
+		prefix = "EVENT " + m.Kind
+	}
+	switch {
+	case prefix == "":
+		return m.Message
+	case m.Message == "":
+		return prefix
+	}
+	return prefix + " " + m.Message
+}
+
+func (m serverMessage) ok() bool {
+	return m.Status == "OK"
+}
+
+// The solo hand a reply shows, from its data; nil when it has none
+func (m serverMessage) game() *gameState {
+	var data struct {
+		Game *gameState `json:"game"`
+	}
+	if len(m.Data) == 0 || json.Unmarshal(m.Data, &data) != nil {
+		return nil
+	}
+	return data.Game
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// How long the connection sits idle before the client pings the server. NAT
+// and firewalls often drop quiet connections after a few minutes, and the
+// server hangs up after 30.
+const defaultKeepalive = time.Minute
+
+// Round trips slower than this show in yellow in the prompt
+const slowLatency = 250 * time.Millisecond
+
+// Sends a PING whenever nothing has been sent for interval, and shows how
+// long the reply took in the prompt. Stops when the session quits.
+func (s *session) keepalive(interval time.Duration) {
+	ticker := time.NewTicker(interval / 4)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		idle, quitting := time.Since(s.lastSent), s.quitting
+		s.mu.Unlock()
+		if quitting {
+			return
+		}
+		if idle < interval {
+			continue
+		}
+
+		sent := time.Now()
+		reply, err := s.command("PING", true)
+		switch {
+		case err == errNoReply:
+			s.showLatency(tr("no reply"), ansiRed)
+		case err != nil:
+			return
+		case reply == "OK PONG":
+			latency := time.Since(sent)
+			color := ""
+			if latency >= slowLatency {
+				color = ansiYellow
+			}
+			s.showLatency(fmt.Sprintf("%dms", max(latency.Milliseconds(), 1)), color)
+		}
+	}
+}
+
+// Puts the latest round trip before the prompt, redrawing it if the player
+// is typing
+func (s *session) showLatency(text, color string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.editor == nil {
+		return
+	}
+
+	if s.look.color && color != "" {
+		text = color + text + ansiReset
+	}
+	s.editor.prompt = "[" + text + "] $ "
+	if s.editor.active {
+		s.editor.drawLocked()
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Service the resume tokens are filed under in the OS keyring
+const keyringService = "casino"
+
+// How long to wait on the keyring, which may be asking the user to unlock it
+const keyringTimeout = 5 * time.Second
+
+// Logs back in with the token remembered for the server, if there is one,
+// and forgets it if the server no longer accepts it
+func (s *session) resume() {
+	token := loadResumeToken(s.remember)
+	if token == "" {
+		return
+	}
+
+	reply, err := s.command("RESUME "+token, s.batch)
+	if err != nil {
+		return
+	}
+	if !strings.HasPrefix(reply, "OK") {
+		forgetResumeToken(s.remember)
+		s.notice(tr("Your remembered login has expired, LOGIN again"))
+		return
+	}
+	if profile, err := s.command("PROFILE", true); err == nil {
+		s.look.update(profile)
+	}
+}
+
+// Asks the server for a token to resume the login with and keeps it
+func (s *session) rememberLogin() {
+	reply, err := s.command("REMEMBER", true)
+	token, ok := strings.CutPrefix(reply, "OK Resume token: ")
+	if err != nil || !ok {
+		return
+	}
+	if err := saveResumeToken(s.remember, strings.TrimSpace(token)); err != nil {
+		s.notice(tr("Couldn't remember your login: %v", err))
+	}
+}
+
+// Returns the token remembered for a server, from the keyring or else the
+// sessions file, or "" for none
+func loadResumeToken(server string) string {
+	if token, err := keyring("lookup", server, ""); err == nil && token != "" {
+		return token
+	}
+	return readSessions()[server]
+}
+
+// Keeps a server's token in the keyring, or in the sessions file when there
+// is no keyring to use
+func saveResumeToken(server, token string) error {
+	sessions := readSessions()
+	if _, err := keyring("store", server, token); err == nil {
+		if _, ok := sessions[server]; ok {
+			delete(sessions, server)
+			return writeSessions(sessions)
+		}
+		return nil
+	}
+
+	sessions[server] = token
+	return writeSessions(sessions)
+}
+
+func forgetResumeToken(server string) {
+	keyring("clear", server, "")
+	if sessions := readSessions(); sessions[server] != "" {
+		delete(sessions, server)
+		writeSessions(sessions)
+	}
+}
+
+// Runs the platform's keyring tool: secret-tool from libsecret, or security
+// on macOS. Windows has no command line for reading credentials back, so it
+// always uses the sessions file.
+func keyring(action, server, token string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keyringTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		args := map[string][]string{
+			"lookup": {"find-generic-password", "-s", keyringService, "-a", server, "-w"},
+			// -w last with no value reads the password from stdin, keeping it
+			// out of the process list
+			"store": {"add-generic-password", "-U", "-s", keyringService, "-a", server, "-w"},
+			"clear": {"delete-generic-password", "-s", keyringService, "-a", server},
+		}[action]
+		cmd = exec.CommandContext(ctx, "security", args...)
+		if action == "store" {
+			// Asked for twice to confirm it
+			cmd.Stdin = strings.NewReader(token + "\n" + token + "\n")
+		}
+	case "windows":
+		return "", exec.ErrNotFound
+	default:
+		attributes := []string{"service", keyringService, "server", server}
+		switch action {
+		case "store":
+			cmd = exec.CommandContext(ctx, "secret-tool", append([]string{"store", "--label=Casino login for " + server}, attributes...)...)
+			cmd.Stdin = strings.NewReader(token)
+		default:
+			cmd = exec.CommandContext(ctx, "secret-tool", append([]string{action}, attributes...)...)
+		}
+	}
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := cmd.Run()
+	return strings.TrimSpace(out.String()), err
+}
+
+// Returns ~/.config/casino/sessions, next to the config file
+func sessionsPath() string {
+	return filepath.Join(filepath.Dir(defaultConfigPath()), "sessions")
+}
+
+// Reads the sessions file, a "server token" pair on each line
+func readSessions() map[string]string {
+	sessions := make(map[string]string)
+	data, err := os.ReadFile(sessionsPath())
+	if err != nil {
+		return sessions
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if server, token, ok := strings.Cut(strings.TrimSpace(line), " "); ok {
+			sessions[server] = token
+		}
+	}
+	return sessions
+}
+
+// Rewrites the sessions file readable by the user alone, or removes it once
+// it's empty
+func writeSessions(sessions map[string]string) error {
+	path := sessionsPath()
+	if len(sessions) == 0 {
+		err := os.Remove(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var data strings.Builder
+	for server, token := range sessions {
+		data.WriteString(server + " " + token + "\n")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(data.String()), 0600)
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Translations of the client's own text, keyed by the English. Replies and
+// events from the server are shown as the server sends them.
+var translations = map[string]map[string]string{
+	"es": {
+		// Connecting and the prompt
+		"Failed to load config: %v":                                         "No se pudo cargar la configuración: %v",
+		"Failed to connect to server at %s: %v":                             "No se pudo conectar al servidor en %s: %v",
+		"Is the server running? Try `make run-server` in another terminal.": "¿Está el servidor en marcha? Prueba `make run-server` en otra terminal.",
+		"Connected to Casino server at %s":                                  "Conectado al servidor del Casino en %s",
+		"Type 'help' for available commands or 'quit' to exit.":             "Escribe 'help' para ver los comandos o 'quit' para salir.",
+		"Failed to open log file: %v":                                       "No se pudo abrir el archivo de registro: %v",
+		"Failed to start session: %v":                                       "No se pudo iniciar la sesión: %v",
+		"server doesn't support framed replies (%s)":                        "el servidor no admite respuestas delimitadas (%s)",
+		"unexpected reply to FRAMING: %s":                                   "respuesta inesperada a FRAMING: %s",
+		"server doesn't support JSON replies (%s)":                          "el servidor no admite respuestas JSON (%s)",
+		"server refused this client: %s":                                    "el servidor rechazó este cliente: %s",
+		"Connection to server lost: %v":                                     "Se perdió la conexión con el servidor: %v",
+		"No reply from the server yet; it will be shown when it arrives":    "El servidor aún no responde; la respuesta se mostrará cuando llegue",
+		"Input error: %v":                                                   "Error de entrada: %v",
+		"Failed to send command: %v":                                        "No se pudo enviar el comando: %v",
+		"no reply":                                                          "sin respuesta",
+		"Password: ":                                                        "Contraseña: ",
+		"Failed to read password":                                           "No se pudo leer la contraseña",
+		"Your remembered login has expired, LOGIN again":                    "Tu sesión recordada ha caducado, vuelve a hacer LOGIN",
+		"Couldn't remember your login: %v":                                  "No se pudo recordar tu sesión: %v",
+		"Warning: stdin is not a terminal, so the password will be read as plain text": "Aviso: la entrada no es una terminal, así que la contraseña se leerá como texto visible",
+
+		// Config file
+		"failed to open config: %w":                     "no se pudo abrir la configuración: %w",
+		"failed to read config: %w":                     "no se pudo leer la configuración: %w",
+		"%s:%d: expected key = value":                   "%s:%d: se esperaba clave = valor",
+		"unknown setting %s":                            "ajuste desconocido %s",
+		"expected a quoted string, got %s":              "se esperaba un texto entre comillas, no %s",
+		"expected a duration such as \"90s\", got %s":   "se esperaba una duración como \"90s\", no %s",
+		"expected true or false, got %s":                "se esperaba true o false, no %s",
+		"failed to read CA file: %w":                    "no se pudo leer el archivo de CA: %w",
+		"no certificates found in %s":                   "no hay certificados en %s",
+		"unknown language %q (use %s)":                  "idioma desconocido %q (usa %s)",
+		"unknown notify mode %q (use %s, %s, %s or %s)": "modo de aviso desconocido %q (usa %s, %s, %s o %s)",
+
+		// Bets and autoplay
+		"No bet to repeat yet":                                                                   "Aún no hay apuesta que repetir",
+		"Percentage bets must be between 0%% and 100%%":                                          "Las apuestas en porcentaje deben estar entre 0%% y 100%%",
+		"%s of your balance is less than a cent":                                                 "El %s de tu saldo es menos de un centavo",
+		"Couldn't find your table's limits in TABLES":                                            "No se encontraron los límites de tu mesa en TABLES",
+		"Autoplay needs a bet: use --bet or set bet in the config file":                          "El juego automático necesita una apuesta: usa --bet o define bet en el archivo de configuración",
+		"Unknown bet sizing %q (use %s or %s)":                                                   "Tamaño de apuesta desconocido %q (usa %s o %s)",
+		"Autoplay: %d hands, %d won (%.1f%%, %d blackjacks), %d lost, %d pushed, %d surrendered": "Juego automático: %d manos, %d ganadas (%.1f%%, %d blackjacks), %d perdidas, %d empatadas, %d rendidas",
+		"Wagered $%.2f, net %s$%.2f":                                                             "Apostado $%.2f, neto %s$%.2f",
+
+		// loadtest
+		"loadtest needs at least one client and a ramp shorter than the duration": "loadtest necesita al menos un cliente y una rampa más corta que la duración",
+		"Invalid bet %q":                                                   "Apuesta no válida %q",
+		"Running %d bots against %s for %s...":                             "Ejecutando %d bots contra %s durante %s...",
+		"Commands: %d (%.1f/s), %d errors (%.2f%%)":                        "Comandos: %d (%.1f/s), %d errores (%.2f%%)",
+		"Hands: %d (%.1f/s)":                                               "Manos: %d (%.1f/s)",
+		"Connections: %d, %d failed, %d dropped; %d bots ran out of chips": "Conexiones: %d, %d fallidas, %d perdidas; %d bots se quedaron sin fichas",
+
+		// Notifications
+		"Your turn": "Tu turno",
+		"Hurry up":  "Date prisa",
+
+		// STATS dashboard
+		"Level %s, %s":                     "Nivel %s, %s",
+		"Comp points: %s":                  "Puntos de cortesía: %s",
+		"Games":                            "Partidas",
+		"Money":                            "Dinero",
+		"Played":                           "Jugadas",
+		"Won":                              "Ganadas",
+		"Lost":                             "Perdidas",
+		"Win rate":                         "Efectividad",
+		"Win streak":                       "Racha ganadora",
+		"Loss streak":                      "Racha perdedora",
+		"Total bet":                        "Total apostado",
+		"Total won":                        "Total ganado",
+		"Net":                              "Neto",
+		"Avg bet":                          "Apuesta media",
+		"Biggest win":                      "Mayor ganancia",
+		"Biggest loss":                     "Mayor pérdida",
+		"Rebuys: %s":                       "Recompras: %s",
+		"Profit over the last %d days: %s": "Ganancia de los últimos %d días: %s",
+		"Daily":                            "Diaria",
+	},
+	"fr": {
+		// Connecting and the prompt
+		"Failed to load config: %v":                                         "Impossible de charger la configuration : %v",
+		"Failed to connect to server at %s: %v":                             "Impossible de se connecter au serveur %s : %v",
+		"Is the server running? Try `make run-server` in another terminal.": "Le serveur est-il lancé ? Essayez `make run-server` dans un autre terminal.",
+		"Connected to Casino server at %s":                                  "Connecté au serveur du Casino %s",
+		"Type 'help' for available commands or 'quit' to exit.":             "Tapez 'help' pour voir les commandes ou 'quit' pour quitter.",
+		"Failed to open log file: %v":                                       "Impossible d'ouvrir le fichier journal : %v",
+		"Failed to start session: %v":                                       "Impossible de démarrer la session : %v",
+		"server doesn't support framed replies (%s)":                        "le serveur ne gère pas les réponses délimitées (%s)",
+		"unexpected reply to FRAMING: %s":                                   "réponse inattendue à FRAMING : %s",
+		"server doesn't support JSON replies (%s)":                          "le serveur ne gère pas les réponses JSON (%s)",
+		"server refused this client: %s":                                    "le serveur a refusé ce client : %s",
+		"Connection to server lost: %v":                                     "Connexion au serveur perdue : %v",
+		"No reply from the server yet; it will be shown when it arrives":    "Pas encore de réponse du serveur ; elle s'affichera à son arrivée",
+		"Input error: %v":                                                   "Erreur de saisie : %v",
+		"Failed to send command: %v":                                        "Impossible d'envoyer la commande : %v",
+		"no reply":                                                          "pas de réponse",
+		"Password: ":                                                        "Mot de passe : ",
+		"Failed to read password":                                           "Impossible de lire le mot de passe",
+		"Your remembered login has expired, LOGIN again":                    "Votre session mémorisée a expiré, refaites LOGIN",
+		"Couldn't remember your login: %v":                                  "Impossible de mémoriser votre session : %v",
+		"Warning: stdin is not a terminal, so the password will be read as plain text": "Attention : l'entrée n'est pas un terminal, le mot de passe sera lu en clair",
+
+		// Config file
+		"failed to open config: %w":                     "impossible d'ouvrir la configuration : %w",
+		"failed to read config: %w":                     "impossible de lire la configuration : %w",
+		"%s:%d: expected key = value":                   "%s:%d : clé = valeur attendu",
+		"unknown setting %s":                            "réglage inconnu %s",
+		"expected a quoted string, got %s":              "texte entre guillemets attendu, reçu %s",
+		"expected a duration such as \"90s\", got %s":   "durée comme \"90s\" attendue, reçu %s",
+		"expected true or false, got %s":                "true ou false attendu, reçu %s",
+		"failed to read CA file: %w":                    "impossible de lire le fichier CA : %w",
+		"no certificates found in %s":                   "aucun certificat dans %s",
+		"unknown language %q (use %s)":                  "langue inconnue %q (utilisez %s)",
+		"unknown notify mode %q (use %s, %s, %s or %s)": "mode d'alerte inconnu %q (utilisez %s, %s, %s ou %s)",
+
+		// Bets and autoplay
+		"No bet to repeat yet":                                                                   "Aucune mise à répéter pour l'instant",
+		"Percentage bets must be between 0%% and 100%%":                                          "Les mises en pourcentage doivent être entre 0 %% et 100 %%",
+		"%s of your balance is less than a cent":                                                 "%s de votre solde fait moins d'un centime",
+		"Couldn't find your table's limits in TABLES":                                            "Limites de votre table introuvables dans TABLES",
+		"Autoplay needs a bet: use --bet or set bet in the config file":                          "Le jeu automatique a besoin d'une mise : utilisez --bet ou réglez bet dans le fichier de configuration",
+		"Unknown bet sizing %q (use %s or %s)":                                                   "Progression de mise inconnue %q (utilisez %s ou %s)",
+		"Autoplay: %d hands, %d won (%.1f%%, %d blackjacks), %d lost, %d pushed, %d surrendered": "Jeu automatique : %d mains, %d gagnées (%.1f %%, %d blackjacks), %d perdues, %d égalités, %d abandonnées",
+		"Wagered $%.2f, net %s$%.2f":                                                             "Misé $%.2f, net %s$%.2f",
+
+		// loadtest
+		"loadtest needs at least one client and a ramp shorter than the duration": "loadtest a besoin d'au moins un client et d'une montée plus courte que la durée",
+		"Invalid bet %q":                                                   "Mise invalide %q",
+		"Running %d bots against %s for %s...":                             "Lancement de %d bots contre %s pendant %s...",
+		"Commands: %d (%.1f/s), %d errors (%.2f%%)":                        "Commandes : %d (%.1f/s), %d erreurs (%.2f%%)",
+		"Hands: %d (%.1f/s)":                                               "Mains : %d (%.1f/s)",
+		"Connections: %d, %d failed, %d dropped; %d bots ran out of chips": "Connexions : %d, %d échouées, %d perdues ; %d bots à court de jetons",
+
+		// Notifications
+		"Your turn": "À vous de jouer",
+		"Hurry up":  "Dépêchez-vous",
+
+		// STATS dashboard
+		"Level %s, %s":                     "Niveau %s, %s",
+		"Comp points: %s":                  "Points fidélité : %s",
+		"Games":                            "Parties",
+		"Money":                            "Argent",
+		"Played":                           "Jouées",
+		"Won":                              "Gagnées",
+		"Lost":                             "Perdues",
+		"Win rate":                         "Taux de gain",
+		"Win streak":                       "Série gagnante",
+		"Loss streak":                      "Série perdante",
+		"Total bet":                        "Total misé",
+		"Total won":                        "Total gagné",
+		"Net":                              "Net",
+		"Avg bet":                          "Mise moyenne",
+		"Biggest win":                      "Plus gros gain",
+		"Biggest loss":                     "Plus grosse perte",
+		"Rebuys: %s":                       "Recharges : %s",
+		"Profit over the last %d days: %s": "Gains des %d derniers jours : %s",
+		"Daily":                            "Par jour",
+	},
+}
+
+// The chosen language's translations; nil for English
+var catalog map[string]string
+
+// Returns the text in the chosen language, or as given when it has no
+// translation
+func translate(text string) string {
+	if translated, ok := catalog[text]; ok {
+		return translated
+	}
+	return text
+}
+
+// Formats a message in the chosen language
+func tr(format string, args ...any) string {
+	return fmt.Sprintf(translate(format), args...)
+}
+
+// Switches the client's text to a language, "en" or "" for English
+func setLanguage(lang string) error {
+	if lang == "" || lang == "en" {
+		catalog = nil
+		return nil
+	}
+	if translated, ok := translations[lang]; ok {
+		catalog = translated
+		return nil
+	}
+	return fmt.Errorf(translate("unknown language %q (use %s)"), lang, strings.Join(languages(), ", "))
+}
+
+// Returns the codes of the languages the client speaks
+func languages() []string {
+	codes := []string{"en"}
+	for code := range translations {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes[1:])
+	return codes
+}
+
+// Returns the language the environment asks for, such as "es" for
+// LANG=es_MX.UTF-8, if the client speaks it
+func envLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		code := strings.ToLower(value)
+		if i := strings.IndexAny(code, "_.@"); i >= 0 {
+			code = code[:i]
+		}
+		if _, ok := translations[code]; ok {
+			return code
+		}
+		return ""
+	}
+	return ""
+}
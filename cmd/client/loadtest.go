@@ -0,0 +1,395 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+// Settings for "loadtest", the bot swarm for measuring a server under load
+type loadtestOptions struct {
+	cfg      config
+	clients  int
+	duration time.Duration
+	ramp     time.Duration // Spread over which the bots connect
+	bet      string
+	prefix   string // Bots log in as <prefix><n>, signing up when needed
+	password string
+	guests   bool // Play as GUEST accounts instead
+}
+
+// Runs "loadtest [flags]" and returns the exit code
+func loadtestCommand(args []string) int {
+	flags := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	server := flags.String("server", defaultConfig().Server, "casino server host:port, or tls://host:port")
+	useTLS := flags.Bool("tls", false, "connect over TLS")
+	tlsCA := flags.String("tls-ca", "", "PEM file of certificates to trust for TLS")
+	clients := flags.Int("clients", 10, "bots to run at once")
+	duration := flags.Duration("duration", 30*time.Second, "how long to run")
+	ramp := flags.Duration("ramp", 0, "spread the bots' connections over this long")
+	bet := flags.String("bet", "1", "dollars each hand bets")
+	prefix := flags.String("prefix", "loadbot", "bots log in as <prefix><n>, signing up if needed")
+	password := flags.String("password", "loadtest_password", "password of the bots' accounts")
+	guests := flags.Bool("guests", false, "play as GUEST accounts instead of signing up")
+	jsonOut := flags.Bool("json", false, "print the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *clients < 1 || *duration <= 0 || *ramp < 0 || *ramp >= *duration {
+		fmt.Fprintln(os.Stderr, tr("loadtest needs at least one client and a ramp shorter than the duration"))
+		return exitUsage
+	}
+	if cents, err := parseDollars(*bet); err != nil || cents <= 0 {
+		fmt.Fprintln(os.Stderr, tr("Invalid bet %q", *bet))
+		return exitUsage
+	}
+
+	cfg := defaultConfig()
+	cfg.Server, cfg.TLS, cfg.TLSCAFile = *server, *useTLS, *tlsCA
+	cfg.applyScheme()
+	opts := loadtestOptions{cfg: cfg, clients: *clients, duration: *duration, ramp: *ramp,
+		bet: *bet, prefix: *prefix, password: *password, guests: *guests}
+
+	if !*jsonOut {
+		fmt.Println(tr("Running %d bots against %s for %s...", opts.clients, opts.cfg.Server, opts.duration))
+	}
+	report := runLoadtest(opts)
+	if *jsonOut {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		report.print()
+	}
+
+	if report.Commands == 0 {
+		return exitConnection
+	}
+	return exitOK
+}
+
+// What one bot saw, merged into the report when it finishes
+type botStats struct {
+	latencies   map[string][]time.Duration // Reply times by command
+	errors      map[string]int             // ERROR replies by command
+	hands       int
+	connects    int
+	connectErrs int
+	dropped     int // Connections lost or timed out mid-run
+	broke       bool
+}
+
+func newBotStats() *botStats {
+	return &botStats{latencies: make(map[string][]time.Duration), errors: make(map[string]int)}
+}
+
+// A bot's connection, with framing on so multi-line replies can be told apart
+type botConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialBot(cfg config) (*botConn, error) {
+	conn, err := cfg.dial()
+	if err != nil {
+		return nil, err
+	}
+	b := &botConn{conn: conn, reader: bufio.NewReader(conn)}
+
+	conn.SetDeadline(time.Now().Add(replyTimeout))
+	welcome, err := b.reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if reason, full := strings.CutPrefix(welcome, "ERROR "); full {
+		conn.Close()
+		return nil, errors.New(strings.TrimSpace(reason))
+	}
+	if _, err := conn.Write([]byte("FRAMING ON\n")); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if reply, err := b.readMessage(); err != nil || !strings.HasPrefix(reply, "OK") {
+		conn.Close()
+		return nil, fmt.Errorf("no framing: %q, %v", reply, err)
+	}
+	// Bots read the hand from the reply's data, as the client does
+	if _, err := conn.Write([]byte("MODE JSON\n")); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if reply, err := b.readMessage(); err != nil || !parseServerMessage(reply).ok() {
+		conn.Close()
+		return nil, fmt.Errorf("no JSON mode: %q, %v", reply, err)
+	}
+	return b, nil
+}
+
+// Reads lines up to the next frameEnd
+func (b *botConn) readMessage() (string, error) {
+	var lines []string
+	for {
+		line, err := b.reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == frameEnd {
+			return strings.Join(lines, "\n"), nil
+		}
+		lines = append(lines, line)
+	}
+}
+
+// Sends a command and waits for its reply, skipping events, recording how
+// long it took and whether it failed
+func (b *botConn) command(stats *botStats, line string) (serverMessage, error) {
+	name := strings.ToUpper(strings.Fields(line)[0])
+	start := time.Now()
+	b.conn.SetDeadline(start.Add(replyTimeout))
+	if _, err := b.conn.Write([]byte(line + "\n")); err != nil {
+		return serverMessage{}, err
+	}
+	for {
+		raw, err := b.readMessage()
+		if err != nil {
+			return serverMessage{}, err
+		}
+		message := parseServerMessage(raw)
+		if message.Type == "event" {
+			continue
+		}
+		stats.latencies[name] = append(stats.latencies[name], time.Since(start))
+		if !message.ok() {
+			stats.errors[name]++
+		}
+		return message, nil
+	}
+}
+
+// Starts the bots, waits for them to finish and sums up what they saw
+func runLoadtest(opts loadtestOptions) *loadtestReport {
+	start := time.Now()
+	deadline := start.Add(opts.duration)
+
+	results := make([]*botStats, opts.clients)
+	var wg sync.WaitGroup
+	for i := range results {
+		results[i] = newBotStats()
+		delay := time.Duration(0)
+		if opts.clients > 1 {
+			delay = opts.ramp * time.Duration(i) / time.Duration(opts.clients-1)
+		}
+		wg.Add(1)
+		go func(n int, stats *botStats) {
+			defer wg.Done()
+			time.Sleep(delay)
+			runBot(opts, n, deadline, stats)
+		}(i+1, results[i])
+	}
+	wg.Wait()
+
+	return newLoadtestReport(opts, time.Since(start), results)
+}
+
+// Plays hands with basic strategy until the deadline, reconnecting whenever
+// the connection is lost
+func runBot(opts loadtestOptions, n int, deadline time.Time, stats *botStats) {
+	for time.Now().Before(deadline) && !stats.broke {
+		b, err := dialBot(opts.cfg)
+		stats.connects++
+		if err != nil {
+			stats.connectErrs++
+			time.Sleep(time.Second)
+			continue
+		}
+		err = playBot(b, opts, n, deadline, stats)
+		if err == nil {
+			b.command(stats, "QUIT")
+		} else {
+			stats.dropped++
+		}
+		b.conn.Close()
+	}
+}
+
+// Logs in (or signs up) and plays until the deadline. Returns an error only
+// when the connection fails.
+func playBot(b *botConn, opts loadtestOptions, n int, deadline time.Time, stats *botStats) error {
+	var reply serverMessage
+	var err error
+	if opts.guests {
+		reply, err = b.command(stats, "GUEST")
+	} else {
+		username := fmt.Sprintf("%s%d", opts.prefix, n)
+		reply, err = b.command(stats, fmt.Sprintf("LOGIN %s %s", username, opts.password))
+		if err == nil && !reply.ok() {
+			reply, err = b.command(stats, fmt.Sprintf("SIGNUP %s %s", username, opts.password))
+			if err == nil && reply.ok() {
+				reply, err = b.command(stats, fmt.Sprintf("LOGIN %s %s", username, opts.password))
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if !reply.ok() {
+		// Without an account there is nothing to do but wait and try again
+		time.Sleep(time.Second)
+		return nil
+	}
+
+	for time.Now().Before(deadline) {
+		reply, err := b.command(stats, "BET "+opts.bet)
+		if err != nil {
+			return err
+		}
+		if !reply.ok() {
+			switch lower := strings.ToLower(reply.Message); {
+			case strings.Contains(lower, "login"):
+				// Reconnect and log in again
+				return nil
+			case strings.Contains(lower, "finish your current hand"):
+				// A hand cut off by a reconnect came back on login
+				if _, err := b.command(stats, "STAND"); err != nil {
+					return err
+				}
+				continue
+			case !strings.Contains(lower, "insufficient balance"):
+				// Limits and the like; don't spin on them
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			// Out of chips: top up if the server allows it, otherwise retire
+			if topUp, err := b.command(stats, "REBUY"); err != nil {
+				return err
+			} else if !topUp.ok() {
+				stats.broke = true
+				return nil
+			}
+			continue
+		}
+		for reply.game() != nil && reply.game().Phase != game.PhaseGameOver {
+			if reply, err = b.command(stats, nextPlay(reply.game())); err != nil {
+				return err
+			}
+			if !reply.ok() {
+				break
+			}
+		}
+		if hand := reply.game(); hand != nil && hand.Phase == game.PhaseGameOver {
+			stats.hands++
+		}
+	}
+	return nil
+}
+
+// Latency percentiles of one command, in milliseconds
+type loadtestCommandStats struct {
+	Command string  `json:"command"`
+	Count   int     `json:"count"`
+	Errors  int     `json:"errors"`
+	P50     float64 `json:"p50_ms"`
+	P90     float64 `json:"p90_ms"`
+	P99     float64 `json:"p99_ms"`
+	Max     float64 `json:"max_ms"`
+}
+
+// What a load test measured
+type loadtestReport struct {
+	Server         string                  `json:"server"`
+	Clients        int                     `json:"clients"`
+	Seconds        float64                 `json:"seconds"`
+	Commands       int                     `json:"commands"`
+	CommandsPerSec float64                 `json:"commands_per_second"`
+	Errors         int                     `json:"errors"`
+	Hands          int                     `json:"hands"`
+	HandsPerSec    float64                 `json:"hands_per_second"`
+	Connects       int                     `json:"connects"`
+	ConnectErrors  int                     `json:"connect_errors"`
+	Dropped        int                     `json:"dropped"`
+	Broke          int                     `json:"broke"` // Bots that ran out of chips
+	ByCommand      []*loadtestCommandStats `json:"by_command"`
+	All            *loadtestCommandStats   `json:"all"`
+}
+
+func newLoadtestReport(opts loadtestOptions, elapsed time.Duration, results []*botStats) *loadtestReport {
+	r := &loadtestReport{Server: opts.cfg.Server, Clients: opts.clients, Seconds: elapsed.Seconds()}
+
+	latencies := make(map[string][]time.Duration)
+	errors := make(map[string]int)
+	var all []time.Duration
+	for _, st := range results {
+		for name, l := range st.latencies {
+			latencies[name] = append(latencies[name], l...)
+			all = append(all, l...)
+		}
+		for name, n := range st.errors {
+			errors[name] += n
+			r.Errors += n
+		}
+		r.Hands += st.hands
+		r.Connects += st.connects
+		r.ConnectErrors += st.connectErrs
+		r.Dropped += st.dropped
+		if st.broke {
+			r.Broke++
+		}
+	}
+
+	names := make([]string, 0, len(latencies))
+	for name := range latencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r.ByCommand = append(r.ByCommand, commandStats(name, latencies[name], errors[name]))
+	}
+	r.All = commandStats("all", all, r.Errors)
+	r.Commands = len(all)
+	r.CommandsPerSec = float64(r.Commands) / r.Seconds
+	r.HandsPerSec = float64(r.Hands) / r.Seconds
+	return r
+}
+
+func commandStats(name string, latencies []time.Duration, errors int) *loadtestCommandStats {
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	percentile := func(p float64) float64 {
+		if len(latencies) == 0 {
+			return 0
+		}
+		return ms(latencies[int(p*float64(len(latencies)-1))])
+	}
+	stats := &loadtestCommandStats{Command: name, Count: len(latencies), Errors: errors,
+		P50: percentile(0.5), P90: percentile(0.9), P99: percentile(0.99)}
+	if len(latencies) > 0 {
+		stats.Max = ms(latencies[len(latencies)-1])
+	}
+	return stats
+}
+
+func (r *loadtestReport) print() {
+	errorRate := 0.0
+	if r.Commands > 0 {
+		errorRate = float64(r.Errors) / float64(r.Commands) * 100
+	}
+	fmt.Println(tr("Commands: %d (%.1f/s), %d errors (%.2f%%)", r.Commands, r.CommandsPerSec, r.Errors, errorRate))
+	fmt.Println(tr("Hands: %d (%.1f/s)", r.Hands, r.HandsPerSec))
+	fmt.Println(tr("Connections: %d, %d failed, %d dropped; %d bots ran out of chips", r.Connects, r.ConnectErrors, r.Dropped, r.Broke))
+	fmt.Println()
+	fmt.Printf("%-10s %8s %7s %9s %9s %9s %9s\n", "", "count", "errors", "p50 ms", "p90 ms", "p99 ms", "max ms")
+	for _, c := range append(r.ByCommand, r.All) {
+		fmt.Printf("%-10s %8d %7d %9.2f %9.2f %9.2f %9.2f\n", c.Command, c.Count, c.Errors, c.P50, c.P90, c.P99, c.Max)
+	}
+}
@@ -1,121 +1,268 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
-	"net"
+	"io"
 	"os"
 	"strings"
-	"syscall"
-	"time"
 
 	"golang.org/x/term"
 )
 
+// Exit codes in batch mode, for scripts driving the client
+const (
+	exitOK           = 0 // Every command succeeded
+	exitCommandError = 1 // The server answered a command with ERROR
+	exitUsage        = 2 // Bad flags or config file
+	exitConnection   = 3 // Couldn't connect, or the connection was lost
+	exitTimeout      = 4 // A command got no reply in time
+)
+
 func main() {
-	// Get server address from environment in the future or use default
-	serverAddr := os.Getenv("CASINO_SERVER")
-	if serverAddr == "" {
-		serverAddr = "127.0.0.1:9090"
+	// "loadtest ..." runs a swarm of bots against a server instead
+	if len(os.Args) > 1 && os.Args[1] == "loadtest" {
+		setLanguage(envLanguage())
+		os.Exit(loadtestCommand(os.Args[2:]))
+	}
+
+	configPath := flag.String("config", defaultConfigPath(), "client config file")
+	server := flag.String("server", "", "casino server host:port, or tls://host:port")
+	useTLS := flag.Bool("tls", false, "connect over TLS")
+	tlsCA := flag.String("tls-ca", "", "PEM file of certificates to trust for TLS")
+	noColor := flag.Bool("no-color", false, "print plain text without ANSI colors")
+	artCards := flag.Bool("art-cards", false, "draw cards as ASCII-art boxes")
+	bet := flag.String("bet", "", "amount a bare BET uses, in dollars")
+	token := flag.String("token", "", "API key to AUTH with on connect")
+	execute := flag.String("exec", "", "run commands separated by ; and exit")
+	keepGoing := flag.Bool("keep-going", false, "in batch mode, carry on after a command fails")
+	jsonOut := flag.Bool("json", false, "print each server message as a JSON line")
+	autoplayHands := flag.Int("autoplay", 0, "play this many hands with basic strategy, then exit")
+	betSizing := flag.String("bet-sizing", "", "autoplay bet sizing: flat or martingale")
+	notifyMode := flag.String("notify", "", "when it's your turn: off, bell, desktop or all")
+	verbose := flag.Bool("verbose", false, "log the raw protocol, credentials hidden, to --log-file")
+	logFile := flag.String("log-file", defaultTracePath(), "file --verbose logs to")
+	remember := flag.Bool("remember", false, "stay logged in across restarts, keeping the session in the OS keyring")
+	lang := flag.String("lang", "", "language for the client's own text: en, es or fr")
+	keepalive := flag.Duration("keepalive", defaultKeepalive, "ping the server after this long idle, 0 for never")
+	flag.Parse()
+
+	// Commands come from --exec or a pipe instead of a player at a terminal
+	batch := *execute != "" || *autoplayHands > 0 || !term.IsTerminal(int(os.Stdin.Fd()))
+
+	// Flags given on the command line override the config file
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	// Until the config file says otherwise, speak the language asked for on
+	// the command line or by the environment, so config errors are in it too
+	if explicit["lang"] {
+		setLanguage(*lang)
+	} else {
+		setLanguage(envLanguage())
 	}
 
-	conn, err := net.Dial("tcp", serverAddr)
+	cfg, err := loadConfig(*configPath, explicit["config"])
 	if err != nil {
-		fmt.Printf("Failed to connect to server at %s\n", serverAddr)
-		fmt.Println("Is the server running? Try `make run-server` in another terminal.")
-		os.Exit(1)
+		fmt.Println(tr("Failed to load config: %v", err))
+		os.Exit(exitUsage)
+	}
+	if explicit["server"] {
+		cfg.Server = *server
+	}
+	if explicit["tls"] {
+		cfg.TLS = *useTLS
+	}
+	if explicit["tls-ca"] {
+		cfg.TLSCAFile = *tlsCA
+	}
+	cfg.applyScheme()
+	if explicit["no-color"] {
+		cfg.Color = !*noColor
+	}
+	if explicit["art-cards"] {
+		cfg.ArtCards = *artCards
+	}
+	if explicit["bet"] {
+		cfg.Bet = *bet
+	}
+	if explicit["bet-sizing"] {
+		cfg.BetSizing = *betSizing
+	}
+	if explicit["notify"] {
+		cfg.Notify = *notifyMode
+	}
+	if explicit["token"] {
+		cfg.Token = *token
+	}
+	if explicit["keepalive"] {
+		cfg.Keepalive = *keepalive
+	}
+	if explicit["remember"] {
+		cfg.Remember = *remember
+	}
+	if explicit["lang"] {
+		cfg.Lang = *lang
+	} else if cfg.Lang == "" {
+		cfg.Lang = envLanguage()
+	}
+	if err := setLanguage(cfg.Lang); err != nil {
+		fmt.Println(tr("Failed to load config: %v", err))
+		os.Exit(exitUsage)
 	}
-	defer conn.Close()
 
-	fmt.Printf("Connected to Casino server at %s\n", serverAddr)
-	fmt.Println("Type 'help' for available commands or 'quit' to exit.")
-	fmt.Println()
+	notify, err := newNotifier(cfg.Notify)
+	if err != nil {
+		fmt.Println(tr("Failed to load config: %v", err))
+		os.Exit(exitUsage)
+	}
 
-	go readFromServer(conn)
-	writeToServer(conn)
-}
+	// Colors need a terminal, and NO_COLOR (https://no-color.org) turns them off
+	color := cfg.Color && !*jsonOut && os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(os.Stdout.Fd()))
 
-func readFromServer(conn net.Conn) {
-	scanner := bufio.NewScanner(conn)
+	conn, err := cfg.dial()
+	if err != nil {
+		fmt.Println(tr("Failed to connect to server at %s: %v", cfg.Server, err))
+		fmt.Println(tr("Is the server running? Try `make run-server` in another terminal."))
+		os.Exit(exitConnection)
+	}
+	defer conn.Close()
 
-	for scanner.Scan() {
-		response := scanner.Text()
+	if !batch && !*jsonOut {
+		fmt.Println(tr("Connected to Casino server at %s", cfg.Server))
+		fmt.Println(tr("Type 'help' for available commands or 'quit' to exit."))
+		fmt.Println()
+	}
 
-		// Don't print the prompt itself if server sends it
-		if response == "$" || response == ">" {
-			continue
+	s := newSession(conn, newCosmetics(color, cfg.ArtCards && !*jsonOut), batch, *jsonOut)
+	s.notify = notify
+	if *verbose {
+		trace, f, err := openTrace(*logFile)
+		if err != nil {
+			fmt.Println(tr("Failed to open log file: %v", err))
+			os.Exit(exitUsage)
 		}
-
-		fmt.Println(response)
+		defer f.Close()
+		s.trace = trace
+		s.trace.Printf("Connected to %s (TLS %v)", cfg.Server, cfg.TLS)
+	}
+	if err := s.start(); err != nil {
+		fmt.Println(tr("Failed to start session: %v", err))
+		os.Exit(exitConnection)
+	}
+	// An API key logs in anyway, so there's nothing to resume
+	if cfg.Remember && cfg.Token == "" {
+		s.remember = cfg.Server
+		s.resume()
+	}
+	if !batch && cfg.Keepalive > 0 {
+		go s.keepalive(cfg.Keepalive)
 	}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Println("Connection to server lost:", err)
-		os.Exit(1)
+	var commands []string
+	if cfg.Token != "" {
+		commands = append(commands, "AUTH "+cfg.Token)
+	}
+	next := s.readCommand
+	if *execute != "" || *autoplayHands > 0 {
+		commands = append(commands, strings.Split(*execute, ";")...)
+		next = func() (string, error) { return "", io.EOF }
+	}
+	status := run(s, cfg, commands, next, batch && !*keepGoing)
+	if *autoplayHands > 0 && status == exitOK {
+		status = autoplay(s, *autoplayHands, cfg.Bet, cfg.BetSizing)
+	}
+	s.close()
+	if batch {
+		os.Exit(status)
 	}
 }
 
-func writeToServer(conn net.Conn) {
-	scanner := bufio.NewScanner(os.Stdin)
+// Runs the given commands and then those next returns until io.EOF or QUIT,
+// and returns the batch exit code. stopOnError gives up at the first failure.
+func run(s *session, cfg config, commands []string, next func() (string, error), stopOnError bool) int {
+	status := exitOK
+	fail := func(code int) bool {
+		if status == exitOK {
+			status = code
+		}
+		return stopOnError
+	}
 
-	// Wait for welcome message before showing first prompt
-	time.Sleep(100 * time.Millisecond)
-	fmt.Print("\n$ ")
+	for {
+		var input string
+		if len(commands) > 0 {
+			input, commands = commands[0], commands[1:]
+		} else {
+			var err error
+			if input, err = next(); err == io.EOF {
+				return status
+			} else if err != nil {
+				fmt.Fprintln(os.Stderr, tr("Input error: %v", err))
+				return exitUsage
+			}
+		}
 
-	for scanner.Scan() {
-		input := strings.TrimSpace(scanner.Text())
+		input = strings.TrimSpace(input)
 		if input == "" {
-			fmt.Print("$ ")
 			continue
 		}
 
 		if strings.ToUpper(input) == "QUIT" || strings.ToUpper(input) == "EXIT" {
-			conn.Write([]byte("QUIT\n"))
-			return
+			s.quit()
+			return status
 		}
 
 		parts := strings.Fields(input)
-		if len(parts) >= 1 {
-			command := strings.ToUpper(parts[0])
-			if (command == "LOGIN" || command == "SIGNUP") && len(parts) == 2 {
-				username := parts[1]
-				password, err := getPassword("Password: ")
-				if err != nil {
-					fmt.Println("ERROR: Failed to read password")
-					fmt.Print("\n$ ")
-					continue
+		command := strings.ToUpper(parts[0])
+		if (command == "LOGIN" || command == "SIGNUP") && len(parts) == 2 {
+			username := parts[1]
+			password, err := s.readPassword(tr("Password: "))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "ERROR: "+tr("Failed to read password"))
+				if fail(exitCommandError) {
+					return status
 				}
-				input = fmt.Sprintf("%s %s %s", command, username, password)
+				continue
 			}
+			input = fmt.Sprintf("%s %s %s", command, username, password)
 		}
 
-		// Send command to server
-		_, err := conn.Write([]byte(input + "\n"))
+		if command == "BET" && len(parts) == 1 && cfg.Bet != "" {
+			input = "BET " + cfg.Bet
+		}
+		expanded, err := s.expandBet(input)
 		if err != nil {
-			fmt.Println("Failed to send command:", err)
-			return
+			s.notice("ERROR " + err.Error())
+			if fail(exitCommandError) {
+				return status
+			}
+			continue
 		}
+		input = expanded
 
-		// Wait a bit for server response to complete, then show next prompt with blank line
-		time.Sleep(100 * time.Millisecond)
-		fmt.Print("\n$ ")
-	}
-
-	if err := scanner.Err(); err != nil {
-		fmt.Println("Input error:", err)
-	}
-}
-
-func getPassword(prompt string) (string, error) {
-	fmt.Print(prompt)
-
-	// Read password without echoing
-	password, err := term.ReadPassword(int(syscall.Stdin))
-	fmt.Println()
-
-	if err != nil {
-		return "", err
+		var reply string
+		if command == "STATS" && len(parts) == 1 && !s.json {
+			reply, err = s.statsDashboard()
+		} else {
+			reply, err = s.send(input)
+		}
+		switch {
+		case err == errNoReply:
+			if fail(exitTimeout) {
+				return status
+			}
+		case err != nil:
+			fmt.Fprintln(os.Stderr, tr("Failed to send command: %v", err))
+			return exitConnection
+		case strings.HasPrefix(reply, "ERROR"):
+			if fail(exitCommandError) {
+				return status
+			}
+		default:
+			if sent := strings.Fields(input); strings.EqualFold(sent[0], "BET") && len(sent) == 2 {
+				s.lastBet = sent[1]
+			}
+		}
 	}
-
-	return string(password), nil
 }
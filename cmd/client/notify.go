@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Ways --notify can get the player's attention
+const (
+	notifyOff     = "off"
+	notifyBell    = "bell"    // Ring the terminal bell
+	notifyDesktop = "desktop" // Pop up a desktop notification
+	notifyAll     = "all"     // Both
+)
+
+// notifier tells the player about events that need them, such as their turn
+// coming up at a multiplayer table
+type notifier struct {
+	bell    bool
+	desktop bool
+}
+
+func newNotifier(mode string) (notifier, error) {
+	switch mode {
+	case notifyOff:
+		return notifier{}, nil
+	case notifyBell:
+		return notifier{bell: true}, nil
+	case notifyDesktop:
+		return notifier{desktop: true}, nil
+	case notifyAll:
+		return notifier{bell: true, desktop: true}, nil
+	}
+	return notifier{}, fmt.Errorf(translate("unknown notify mode %q (use %s, %s, %s or %s)"), mode, notifyOff, notifyBell, notifyDesktop, notifyAll)
+}
+
+// Returns the notification for a server message, if it needs the player
+func notification(message string) (title, body string, ok bool) {
+	event, ok := strings.CutPrefix(message, "EVENT TABLE ")
+	if !ok {
+		return "", "", false
+	}
+	first, _, _ := strings.Cut(event, "\n")
+	switch {
+	case strings.HasPrefix(first, "Your turn"):
+		return tr("Your turn"), first, true
+	case strings.Contains(first, "seconds left to act"):
+		return tr("Hurry up"), first, true
+	}
+	return "", "", false
+}
+
+// Notifies the player. Callers hold the session's output lock, as the bell
+// goes to stdout.
+func (n notifier) sendLocked(title, body string) {
+	if n.bell {
+		fmt.Print("\a")
+	}
+	if n.desktop {
+		go desktopNotify(title, body)
+	}
+}
+
+// Shows a desktop notification with notify-send, or osascript on macOS
+func desktopNotify(title, body string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e",
+			fmt.Sprintf("display notification %q with title %q", body, "Casino: "+title))
+	default:
+		cmd = exec.Command("notify-send", "--app-name=Casino", "Casino: "+title, body)
+	}
+	// Nothing to do if there's no notification daemon; the bell still rings
+	cmd.Run()
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// Line the server ends every message with once FRAMING is on
+const frameEnd = "."
+
+// Version of the server's text protocol this client speaks, sent in HELLO
+const protocolVersion = 1
+
+// How long a command waits for its reply before the prompt comes back anyway
+const replyTimeout = 10 * time.Second
+
+// Returned by command when the reply doesn't arrive within replyTimeout
+var errNoReply = errors.New("no reply from the server")
+
+// session reads whole messages from the server and prints them in the order
+// they arrive. A reply is also handed to the command waiting for it; an event
+// that arrives while the player is typing redraws the prompt.
+type session struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	look    *cosmetics
+	replies chan serverMessage
+
+	// Reads commands with line editing; nil when stdin isn't a terminal
+	editor *lineEditor
+	stdin  *bufio.Scanner
+	words  completer
+
+	// Gets the player's attention when it's their turn
+	notify notifier
+
+	// Logs every line sent and received, for --verbose; nil when off
+	trace *log.Logger
+
+	// Amount of the last bet placed, for REBET
+	lastBet string
+
+	// Server to remember the login for across restarts, for --remember;
+	// empty when off
+	remember string
+
+	// Lets one command at a time wait for a reply, so keepalive pings don't
+	// take the reply to a command the player typed
+	commandMu sync.Mutex
+
+	// Commands come from a script: no prompt or welcome, only replies and events
+	batch bool
+	// Print the server's JSON lines as they come; the client's own notices go
+	// to stderr
+	json bool
+
+	// Guards stdout so replies, events and the prompt don't interleave
+	mu       sync.Mutex
+	waiting  bool // A command is waiting for its reply
+	quiet    bool // ...and doesn't want it printed
+	quitting bool // The server closing the connection is expected
+	lastSent time.Time
+}
+
+func newSession(conn net.Conn, look *cosmetics, batch, json bool) *session {
+	s := &session{
+		conn:    conn,
+		reader:  bufio.NewReader(conn),
+		look:    look,
+		replies: make(chan serverMessage, 1),
+		batch:   batch || json,
+		json:    json,
+	}
+	if !s.batch {
+		s.editor = newLineEditor(&s.mu, "$ ")
+	}
+	if s.editor == nil {
+		s.stdin = bufio.NewScanner(os.Stdin)
+	} else {
+		s.editor.complete = s.words.complete
+	}
+	return s
+}
+
+// Shows the server's welcome, turns on framing, says HELLO, switches to JSON
+// replies and starts reading messages
+func (s *session) start() error {
+	welcome, err := s.readLine()
+	if err != nil {
+		return err
+	}
+	// A server that is full says so instead of welcoming us, then hangs up
+	if reason, full := strings.CutPrefix(welcome, "ERROR "); full {
+		return errors.New(reason)
+	}
+	if !s.batch {
+		s.print(welcome)
+	}
+
+	if err := s.write("FRAMING ON"); err != nil {
+		return err
+	}
+	reply, err := s.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(reply, "OK") {
+		return fmt.Errorf(translate("server doesn't support framed replies (%s)"), reply)
+	}
+	if end, err := s.readLine(); err != nil {
+		return err
+	} else if end != frameEnd {
+		return fmt.Errorf(translate("unexpected reply to FRAMING: %s"), end)
+	}
+
+	// Servers from before HELLO don't know it and speak version 1 anyway
+	if err := s.write(fmt.Sprintf("HELLO %d casino-client", protocolVersion)); err != nil {
+		return err
+	}
+	reply, err = s.readMessage()
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(reply, "ERROR") && !strings.HasPrefix(reply, "ERROR Unknown command") {
+		return fmt.Errorf(translate("server refused this client: %s"), strings.TrimPrefix(reply, "ERROR "))
+	}
+
+	// Replies come as JSON from here on: --json prints them as they are, and
+	// the client reads their data rather than the text meant for people
+	if err := s.write("MODE JSON"); err != nil {
+		return err
+	}
+	reply, err = s.readMessage()
+	if err != nil {
+		return err
+	}
+	if m := parseServerMessage(reply); !m.ok() {
+		return fmt.Errorf(translate("server doesn't support JSON replies (%s)"), m.text())
+	}
+
+	go s.readLoop()
+	return nil
+}
+
+func (s *session) readLine() (string, error) {
+	line, err := s.reader.ReadString('\n')
+	if err != nil {
+		s.traceLine("!!", err.Error())
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	s.traceLine("<<", line)
+	return line, nil
+}
+
+func (s *session) write(line string) error {
+	s.traceLine(">>", line)
+	s.mu.Lock()
+	s.lastSent = time.Now()
+	s.mu.Unlock()
+	_, err := s.conn.Write([]byte(line + "\n"))
+	return err
+}
+
+// Reads lines up to the next frameEnd and returns them as one message
+func (s *session) readMessage() (string, error) {
+	var lines []string
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return "", err
+		}
+		if line == frameEnd {
+			return strings.Join(lines, "\n"), nil
+		}
+		lines = append(lines, line)
+	}
+}
+
+func (s *session) readLoop() {
+	for {
+		message, err := s.readMessage()
+		if err != nil {
+			s.mu.Lock()
+			if s.quitting {
+				s.mu.Unlock()
+				return
+			}
+			s.noticeLocked("\n" + tr("Connection to server lost: %v", err))
+			os.Exit(exitConnection)
+		}
+
+		if m := parseServerMessage(message); s.show(m) {
+			s.replies <- m
+		}
+	}
+}
+
+// Prints a message and reports whether it is the reply a command is waiting
+// for. While the player is at the prompt the message replaces the prompt
+// line, which is drawn again underneath.
+func (s *session) show(m serverMessage) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	message, hand := m.text(), m.game()
+	s.words.observe(message)
+	if title, body, ok := notification(message); ok && !s.json {
+		s.notify.sendLocked(title, body)
+	}
+	if s.waiting && m.Type != "event" {
+		s.waiting = false
+		switch {
+		case s.quiet:
+		case s.json:
+			fmt.Println(m.line)
+		default:
+			s.printGameLocked(message, hand)
+		}
+		return true
+	}
+
+	switch {
+	case s.json:
+		fmt.Println(m.line)
+	case s.editor != nil && s.editor.active:
+		s.editor.hideLocked()
+		s.printGameLocked(message+"\n", hand)
+		s.editor.drawLocked()
+	case s.waiting, s.batch:
+		s.printGameLocked(message, hand)
+	default:
+		fmt.Print("\r")
+		if s.look.color {
+			fmt.Print("\x1b[K")
+		}
+		s.printGameLocked(message, hand)
+		fmt.Print("\n$ ")
+	}
+	return false
+}
+
+// Sends a command and waits for its reply, which is printed unless quiet,
+// returning the reply as text
+func (s *session) command(line string, quiet bool) (string, error) {
+	reply, err := s.request(line, quiet)
+	if err != nil {
+		return "", err
+	}
+	return reply.text(), nil
+}
+
+// Sends a command and waits for its reply, which is printed unless quiet
+func (s *session) request(line string, quiet bool) (serverMessage, error) {
+	s.commandMu.Lock()
+	defer s.commandMu.Unlock()
+
+	s.mu.Lock()
+	s.waiting, s.quiet = true, quiet
+	s.mu.Unlock()
+
+	if err := s.write(line); err != nil {
+		return serverMessage{}, err
+	}
+
+	select {
+	case reply := <-s.replies:
+		return reply, nil
+	case <-time.After(replyTimeout):
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.waiting {
+			// The reply came in just as time ran out
+			return <-s.replies, nil
+		}
+		s.waiting = false
+		s.noticeLocked(tr("No reply from the server yet; it will be shown when it arrives"))
+		return serverMessage{}, errNoReply
+	}
+}
+
+// Sends a command the player typed and prints its reply. After logging in or
+// equipping an item it picks up the card back and table theme for rendering,
+// and with --remember it keeps or forgets the login.
+func (s *session) send(line string) (string, error) {
+	reply, err := s.command(line, false)
+	if err != nil {
+		return "", err
+	}
+
+	command := strings.ToUpper(strings.Fields(line)[0])
+	switch command {
+	case "LOGIN", "AUTH", "RESUME", "EQUIP":
+		if strings.HasPrefix(reply, "OK") {
+			if profile, err := s.command("PROFILE", true); err == nil {
+				s.look.update(profile)
+			}
+		}
+	}
+	if s.remember != "" && strings.HasPrefix(reply, "OK") {
+		switch command {
+		case "LOGIN":
+			s.rememberLogin()
+		case "LOGOUT":
+			forgetResumeToken(s.remember)
+		}
+	}
+	return reply, nil
+}
+
+// Reads a password: from CASINO_PASSWORD when it is set, without echo at a
+// terminal, or as a visible line from piped input after a warning. The prompt
+// and warning go to stderr so they stay out of --json output.
+func (s *session) readPassword(prompt string) (string, error) {
+	if password := os.Getenv("CASINO_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return string(password), err
+	}
+
+	fmt.Fprintln(os.Stderr, tr("Warning: stdin is not a terminal, so the password will be read as plain text"))
+	fmt.Fprint(os.Stderr, prompt)
+	if s.stdin == nil {
+		s.stdin = bufio.NewScanner(os.Stdin)
+	}
+	defer fmt.Fprintln(os.Stderr)
+	if !s.stdin.Scan() {
+		if err := s.stdin.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return strings.TrimSpace(s.stdin.Text()), nil
+}
+
+// Hangs up without QUIT, once there's nothing more to send
+func (s *session) close() {
+	s.mu.Lock()
+	s.quitting = true
+	s.mu.Unlock()
+
+	s.conn.Close()
+}
+
+// Says goodbye to the server, which then closes the connection
+func (s *session) quit() {
+	s.mu.Lock()
+	s.quitting = true
+	s.mu.Unlock()
+
+	s.command("QUIT", false)
+}
+
+// Shows the prompt and reads the next command. Events redraw the prompt
+// until the command is entered.
+func (s *session) readCommand() (string, error) {
+	s.mu.Lock()
+	if !s.batch {
+		fmt.Println()
+		if s.editor == nil {
+			fmt.Print("$ ")
+		}
+	}
+	s.mu.Unlock()
+
+	if s.editor != nil {
+		return s.editor.readLine()
+	}
+	if !s.stdin.Scan() {
+		if err := s.stdin.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return s.stdin.Text(), nil
+}
+
+func (s *session) print(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.printLocked(message)
+}
+
+func (s *session) notice(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.noticeLocked(message)
+}
+
+// Prints a message from the client itself, kept out of the way of --json output
+func (s *session) noticeLocked(message string) {
+	if s.json {
+		fmt.Fprintln(os.Stderr, strings.TrimPrefix(message, "\n"))
+		return
+	}
+	s.printLocked(message)
+}
+
+func (s *session) printLocked(message string) {
+	s.printGameLocked(message, nil)
+}
+
+// Prints a message, drawing the cards of the solo hand it shows, if any
+func (s *session) printGameLocked(message string, hand *gameState) {
+	// The terminal is raw while a line is being edited, so lines need a
+	// carriage return too
+	newline := "\n"
+	if s.editor != nil && s.editor.active {
+		newline = "\r\n"
+	}
+
+	for _, line := range strings.Split(message, "\n") {
+		// Don't print the prompt itself if server sends it
+		if line == "$" || line == ">" {
+			continue
+		}
+		fmt.Print(strings.ReplaceAll(s.look.render(line, hand), "\n", newline) + newline)
+	}
+}
@@ -0,0 +1,469 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+// Commands kept in history, in memory and in the history file
+const maxHistory = 500
+
+// Keys the line editor handles
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyBackspace = 8
+	keyTab       = 9
+	keyNewline   = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// lineEditor reads commands from a terminal with line editing, up/down
+// history, Ctrl+R search and Tab completion. Its state is guarded by the session's output
+// lock so events can be printed above the line being typed.
+type lineEditor struct {
+	mu     *sync.Mutex
+	fd     int
+	in     *bufio.Reader
+	prompt string
+
+	history     []string
+	historyFile string
+
+	// Returns completions for the last word of the text before the cursor
+	complete func(head string) []string
+
+	// Set while reading a line, when the terminal is in raw mode
+	active bool
+	line   []rune
+	pos    int
+
+	// Index into history while browsing it with up and down; len(history)
+	// is the line being typed, saved in pending
+	browse  int
+	pending []rune
+
+	// Ctrl+R state: the text searched for and the history entry it matched
+	searching bool
+	query     []rune
+	match     int
+}
+
+// Returns a line editor for stdin, or nil when stdin or stdout isn't a terminal
+func newLineEditor(mu *sync.Mutex, prompt string) *lineEditor {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return nil
+	}
+
+	e := &lineEditor{mu: mu, fd: fd, in: bufio.NewReader(os.Stdin), prompt: prompt}
+	if home, err := os.UserHomeDir(); err == nil {
+		e.historyFile = filepath.Join(home, ".casino_history")
+		e.loadHistory()
+	}
+	return e
+}
+
+func (e *lineEditor) loadHistory() {
+	data, err := os.ReadFile(e.historyFile)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			e.history = append(e.history, line)
+		}
+	}
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+		e.saveHistory()
+	}
+}
+
+// Rewrites the history file, trimmed to maxHistory entries
+func (e *lineEditor) saveHistory() {
+	data := strings.Join(e.history, "\n") + "\n"
+	os.WriteFile(e.historyFile, []byte(data), 0600)
+}
+
+// Adds a command to history, leaving out passwords and API keys
+func (e *lineEditor) remember(line string) {
+	line = historyEntry(line)
+	if line == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistory {
+		e.history = e.history[1:]
+		e.saveHistory()
+		return
+	}
+
+	if e.historyFile == "" {
+		return
+	}
+	f, err := os.OpenFile(e.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+// Returns what to keep of a command in history: LOGIN and SIGNUP without the
+// password, nothing of AUTH or RESUME and their keys
+func historyEntry(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	switch strings.ToUpper(fields[0]) {
+	case "AUTH", "RESUME":
+		return ""
+	case "LOGIN", "SIGNUP", "REGISTER":
+		if len(fields) > 2 {
+			return strings.Join(fields[:2], " ")
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// Reads a line, returning io.EOF on Ctrl+D at an empty prompt
+func (e *lineEditor) readLine() (string, error) {
+	state, err := term.MakeRaw(e.fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(e.fd, state)
+
+	e.mu.Lock()
+	e.active = true
+	e.line, e.pos = nil, 0
+	e.browse, e.pending = len(e.history), nil
+	e.searching = false
+	e.drawLocked()
+	e.mu.Unlock()
+
+	for {
+		key, err := e.readKey()
+		if err != nil {
+			e.finish()
+			return "", err
+		}
+
+		e.mu.Lock()
+		line, done, err := e.handleKeyLocked(key)
+		if done || err != nil {
+			// Leave the entered line behind as typed, not as a search
+			e.drawLocked()
+			e.active = false
+			fmt.Print("\r\n")
+			if done {
+				e.remember(line)
+			}
+			e.mu.Unlock()
+			return line, err
+		}
+		e.drawLocked()
+		e.mu.Unlock()
+	}
+}
+
+func (e *lineEditor) finish() {
+	e.mu.Lock()
+	e.active = false
+	fmt.Print("\r\n")
+	e.mu.Unlock()
+}
+
+// Special keys read from escape sequences
+const (
+	keyUp rune = unicode.MaxRune + 1 + iota
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyForwardDelete
+	keyUnknown
+)
+
+// Reads one key, turning arrow, home, end and delete escape sequences into
+// single keys
+func (e *lineEditor) readKey() (rune, error) {
+	r, _, err := e.in.ReadRune()
+	if err != nil || r != keyEscape {
+		return r, err
+	}
+
+	// A lone Escape isn't followed by anything already waiting
+	if e.in.Buffered() == 0 {
+		return keyEscape, nil
+	}
+	next, _, err := e.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if next != '[' && next != 'O' {
+		return keyUnknown, nil
+	}
+
+	code, _, err := e.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	switch code {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	case 'H':
+		return keyHome, nil
+	case 'F':
+		return keyEnd, nil
+	}
+
+	// Sequences such as ESC [ 3 ~ end with a tilde
+	seq := string(code)
+	for code >= '0' && code <= '9' || code == ';' {
+		if code, _, err = e.in.ReadRune(); err != nil {
+			return 0, err
+		}
+		seq += string(code)
+	}
+	switch seq {
+	case "1~", "7~":
+		return keyHome, nil
+	case "4~", "8~":
+		return keyEnd, nil
+	case "3~":
+		return keyForwardDelete, nil
+	}
+	return keyUnknown, nil
+}
+
+// Applies a key to the line; done is set when the line is entered
+func (e *lineEditor) handleKeyLocked(key rune) (line string, done bool, err error) {
+	if e.searching {
+		if !e.searchKeyLocked(key) {
+			return "", false, nil
+		}
+	}
+
+	switch key {
+	case keyEnter, keyNewline:
+		return string(e.line), true, nil
+	case keyCtrlD:
+		if len(e.line) == 0 {
+			return "", false, io.EOF
+		}
+		e.deleteLocked(e.pos, e.pos+1)
+	case keyCtrlC:
+		e.line, e.pos = nil, 0
+		e.browse = len(e.history)
+	case keyBackspace, keyDelete:
+		e.deleteLocked(e.pos-1, e.pos)
+	case keyForwardDelete:
+		e.deleteLocked(e.pos, e.pos+1)
+	case keyLeft, keyCtrlB:
+		if e.pos > 0 {
+			e.pos--
+		}
+	case keyRight, keyCtrlF:
+		if e.pos < len(e.line) {
+			e.pos++
+		}
+	case keyHome, keyCtrlA:
+		e.pos = 0
+	case keyEnd, keyCtrlE:
+		e.pos = len(e.line)
+	case keyCtrlU:
+		e.deleteLocked(0, e.pos)
+	case keyCtrlK:
+		e.deleteLocked(e.pos, len(e.line))
+	case keyCtrlW:
+		start := e.pos
+		for start > 0 && e.line[start-1] == ' ' {
+			start--
+		}
+		for start > 0 && e.line[start-1] != ' ' {
+			start--
+		}
+		e.deleteLocked(start, e.pos)
+	case keyUp, keyCtrlP:
+		e.browseLocked(e.browse - 1)
+	case keyDown, keyCtrlN:
+		e.browseLocked(e.browse + 1)
+	case keyCtrlR:
+		e.searching, e.query, e.match = true, nil, len(e.history)
+	case keyCtrlL:
+		fmt.Print("\x1b[2J\x1b[H")
+	case keyTab:
+		e.completeLocked()
+	default:
+		if unicode.IsPrint(key) {
+			e.line = append(e.line[:e.pos], append([]rune{key}, e.line[e.pos:]...)...)
+			e.pos++
+		}
+	}
+	return "", false, nil
+}
+
+// Handles a key during Ctrl+R search. Returns true when the search ends and
+// the key should also be handled as usual, e.g. Enter running the match.
+func (e *lineEditor) searchKeyLocked(key rune) bool {
+	switch key {
+	case keyCtrlR:
+		e.searchLocked(e.match - 1)
+		return false
+	case keyBackspace, keyDelete:
+		if len(e.query) > 0 {
+			e.query = e.query[:len(e.query)-1]
+		}
+		e.searchLocked(len(e.history) - 1)
+		return false
+	case keyCtrlG, keyCtrlC, keyEscape:
+		e.searching = false
+		e.line, e.pos = nil, 0
+		return false
+	}
+
+	if unicode.IsPrint(key) {
+		e.query = append(e.query, key)
+		e.searchLocked(min(e.match, len(e.history)-1))
+		return false
+	}
+
+	// Any other key keeps the match on the line and is handled normally
+	e.searching = false
+	return true
+}
+
+// Finds the newest history entry at or before index containing the query
+// and puts it on the line
+func (e *lineEditor) searchLocked(index int) {
+	query := strings.ToLower(string(e.query))
+	for i := index; i >= 0; i-- {
+		if strings.Contains(strings.ToLower(e.history[i]), query) {
+			e.match = i
+			e.line = []rune(e.history[i])
+			e.pos = len(e.line)
+			return
+		}
+	}
+}
+
+func (e *lineEditor) browseLocked(index int) {
+	if index < 0 || index > len(e.history) {
+		return
+	}
+	if e.browse == len(e.history) {
+		e.pending = e.line
+	}
+
+	e.browse = index
+	if index == len(e.history) {
+		e.line = e.pending
+	} else {
+		e.line = []rune(e.history[index])
+	}
+	e.pos = len(e.line)
+}
+
+// Completes the word before the cursor as far as the completions agree, or
+// lists them when they don't agree any further
+func (e *lineEditor) completeLocked() {
+	if e.complete == nil {
+		return
+	}
+	head := string(e.line[:e.pos])
+	matches := e.complete(head)
+	if len(matches) == 0 {
+		return
+	}
+
+	start := strings.LastIndex(head, " ") + 1
+	word := []rune(head[start:])
+	completion := []rune(matches[0])
+	if len(matches) == 1 {
+		completion = append(completion, ' ')
+	} else {
+		for _, m := range matches[1:] {
+			completion = commonPrefix(completion, []rune(m))
+		}
+	}
+
+	if len(completion) <= len(word) {
+		fmt.Print("\r\n" + strings.Join(matches, "  ") + "\r\n")
+		return
+	}
+	tail := e.line[e.pos:]
+	e.line = append(append([]rune(head[:start]), completion...), tail...)
+	e.pos = len(e.line) - len(tail)
+}
+
+// Returns the longest prefix a and b share, ignoring case
+func commonPrefix(a, b []rune) []rune {
+	n := 0
+	for n < len(a) && n < len(b) && unicode.ToLower(a[n]) == unicode.ToLower(b[n]) {
+		n++
+	}
+	return a[:n]
+}
+
+// Removes line[from:to], clamped to the line, leaving the cursor at from
+func (e *lineEditor) deleteLocked(from, to int) {
+	from, to = max(from, 0), min(to, len(e.line))
+	if from >= to {
+		return
+	}
+	e.line = append(e.line[:from:from], e.line[to:]...)
+	e.pos = from
+}
+
+// Draws the prompt and line, or the search prompt, over the current line
+func (e *lineEditor) drawLocked() {
+	fmt.Print("\r\x1b[K")
+	if e.searching {
+		fmt.Printf("(reverse-i-search)`%s': %s", string(e.query), string(e.line))
+		return
+	}
+
+	fmt.Print(e.prompt + string(e.line))
+	if back := len(e.line) - e.pos; back > 0 {
+		fmt.Printf("\x1b[%dD", back)
+	}
+}
+
+// Clears the line being typed so output can be printed in its place
+func (e *lineEditor) hideLocked() {
+	fmt.Print("\r\x1b[K")
+}
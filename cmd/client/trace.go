@@ -0,0 +1,64 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// An API key as the server hands it out, ck_<id>_<secret>
+var apiKeyPattern = regexp.MustCompile(`\bck_([0-9a-f]+)_[0-9a-f]+\b`)
+
+// The token in a REMEMBER reply
+var resumeTokenPattern = regexp.MustCompile(`(Resume token: )\S+`)
+
+// Returns ~/.casino_client.log, where --verbose writes by default
+func defaultTracePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "casino_client.log"
+	}
+	return filepath.Join(home, ".casino_client.log")
+}
+
+// Opens the protocol trace file for appending
+func openTrace(path string) (*log.Logger, *os.File, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+	return log.New(f, "", log.LstdFlags|log.Lmicroseconds), f, nil
+}
+
+// Logs a raw protocol line in one direction, ">>" sent or "<<" received
+func (s *session) traceLine(direction, line string) {
+	if s.trace != nil {
+		s.trace.Printf("%s %q", direction, redact(line))
+	}
+}
+
+// Hides the secrets in a protocol line: the password of LOGIN and SIGNUP, the
+// key given to AUTH, resume tokens and the secret part of any API key
+func redact(line string) string {
+	line = apiKeyPattern.ReplaceAllString(line, "ck_${1}_***")
+	line = resumeTokenPattern.ReplaceAllString(line, "${1}***")
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return line
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "LOGIN", "SIGNUP", "REGISTER":
+		if len(fields) > 2 {
+			fields[2] = "***"
+			return strings.Join(fields, " ")
+		}
+	case "AUTH", "RESUME":
+		if len(fields) > 1 {
+			return fields[0] + " ***"
+		}
+	}
+	return line
+}
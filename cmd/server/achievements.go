@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/alessandrosisniegas/casino/core/security"
+)
+
+func (s *Server) handleAchievements(client *ClientState, _ []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	unlocked, err := s.authService.ListAchievements(client.user.ID)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	response := fmt.Sprintf("OK Achievements (%d/%d):", len(unlocked), len(security.Achievements))
+	for _, a := range security.Achievements {
+		if at, ok := unlocked[a.ID]; ok {
+			response += fmt.Sprintf("\n  [x] %-16s %s (%s)", a.Name, a.Description, at.Format("2006-01-02"))
+		} else {
+			response += fmt.Sprintf("\n  [ ] %-16s %s", a.Name, a.Description)
+		}
+	}
+	s.writeResponse(client, response)
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const adminUsage = "ADMIN GRANT|DEDUCT <user> <amount> <reason> | ADMIN TRANSFERS ON|OFF | ADMIN FEATURES | ADMIN FEATURE <name> ON|OFF | ADMIN HOUSE | ADMIN PROMO ... | ADMIN EVENT ... | ADMIN MUTE|UNMUTE <user> ... | ADMIN TABLES | ADMIN STATS | ADMIN FREEZE|UNFREEZE <table> ... | ADMIN CONNECTIONS | ADMIN KICK <user|#id> <reason> | ADMIN BAN <user> [duration] [reason] | ADMIN UNBAN <user> | ADMIN BANS | ADMIN LOGLEVEL [subsystem] [level] | ADMIN EXPORT AUDIT|LEDGER <from> <to> [user] [csv|json] | ADMIN REPORT [day]"
+
+const promoUsage = "ADMIN PROMO CREATE <amount> [uses] [days] [code] | ADMIN PROMO LIST"
+
+// ADMIN commands are only available to admin accounts logged in with a password
+func (s *Server) handleAdmin(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	if client.apiKeyID != "" {
+		s.writeResponse(client, "ERROR Admin commands can only be used after LOGIN")
+		return
+	}
+
+	// Reload so a demotion takes effect without waiting for a new login
+	user, err := s.refreshUser(client)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+	if !user.IsAdmin {
+		s.writeError(client, "FORBIDDEN", "Admin privileges required")
+		return
+	}
+
+	result, err := s.runAdmin(user.Username, client.ip, args)
+	if err != nil {
+		s.writeError(client, errorCode(err), err.Error())
+		return
+	}
+
+	s.writeResponse(client, "OK "+result)
+}
+
+// Runs an admin subcommand for the ADMIN protocol command and the server console
+func (s *Server) runAdmin(actor, ip string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", usageErrorf("Usage: %s", adminUsage)
+	}
+	if ip != "" {
+		// Console commands are typed by whoever is reading the log
+		s.log.server.Info("Admin command", "actor", actor, "ip", ip, "command", strings.ToUpper(args[0]))
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "GRANT", "DEDUCT":
+		if len(args) < 4 {
+			return "", usageErrorf("Usage: ADMIN %s <user> <amount> <reason>", strings.ToUpper(args[0]))
+		}
+
+		target, err := s.db.GetUserByUsername(args[1])
+		if err != nil {
+			return "", err
+		}
+
+		amount, err := parseDollars(args[2])
+		if err != nil {
+			return "", err
+		}
+
+		delta, verb, summary := amount, "credited", fmt.Sprintf("Granted $%.2f to %s", float64(amount)/100, target.Username)
+		if strings.EqualFold(args[0], "DEDUCT") {
+			delta, verb, summary = -amount, "debited", fmt.Sprintf("Deducted $%.2f from %s", float64(amount)/100, target.Username)
+		}
+
+		reason := strings.Join(args[3:], " ")
+		balance, err := s.authService.AdminAdjustBalance(actor, target.ID, delta, reason, ip)
+		if err != nil {
+			return "", err
+		}
+		s.checkBalanceAlert(target.Username, balance-delta, balance)
+
+		for _, c := range s.hub.clientsForUser(target.ID, nil) {
+			s.pushEvent(c, "BALANCE", fmt.Sprintf("An administrator %s $%.2f (%s). New balance: $%.2f",
+				verb, float64(amount)/100, reason, float64(balance)/100))
+		}
+
+		return fmt.Sprintf("%s. New balance: $%.2f", summary, float64(balance)/100), nil
+
+	case "TRANSFERS":
+		if len(args) != 2 || (!strings.EqualFold(args[1], "on") && !strings.EqualFold(args[1], "off")) {
+			return "", usageErrorf("Usage: ADMIN TRANSFERS ON|OFF")
+		}
+
+		enabled := strings.EqualFold(args[1], "on")
+		if err := s.authService.SetTransfersEnabled(actor, enabled); err != nil {
+			return "", err
+		}
+
+		if enabled {
+			return "Transfers enabled", nil
+		}
+		return "Transfers disabled", nil
+
+	case "FEATURES":
+		return s.adminFeatures()
+
+	case "FEATURE":
+		return s.adminFeature(actor, args[1:])
+
+	case "HOUSE":
+		return s.houseReport()
+
+	case "PROMO":
+		return s.adminPromo(actor, args[1:])
+
+	case "EVENT":
+		return s.adminEvent(actor, args[1:])
+
+	case "MUTE":
+		if len(args) != 3 {
+			return "", usageErrorf("Usage: ADMIN MUTE <user> <minutes>")
+		}
+
+		target, err := s.db.GetUserByUsername(args[1])
+		if err != nil {
+			return "", err
+		}
+		minutes, err := strconv.Atoi(args[2])
+		if err != nil {
+			return "", fmt.Errorf("minutes must be a number")
+		}
+
+		until, err := s.authService.MuteChat(actor, target.ID, time.Duration(minutes)*time.Minute, ip)
+		if err != nil {
+			return "", err
+		}
+
+		for _, c := range s.hub.clientsForUser(target.ID, nil) {
+			s.pushEvent(c, "CHAT", fmt.Sprintf("An administrator muted you in chat until %s", until.Format("2006-01-02 15:04")))
+		}
+		return fmt.Sprintf("Muted %s in chat until %s", target.Username, until.Format("2006-01-02 15:04")), nil
+
+	case "UNMUTE":
+		if len(args) != 2 {
+			return "", usageErrorf("Usage: ADMIN UNMUTE <user>")
+		}
+
+		target, err := s.db.GetUserByUsername(args[1])
+		if err != nil {
+			return "", err
+		}
+		if err := s.authService.UnmuteChat(actor, target.ID, ip); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Unmuted %s in chat", target.Username), nil
+
+	case "TABLES":
+		return s.adminTables(), nil
+
+	case "STATS":
+		return s.statsReport(), nil
+
+	case "CONNECTIONS":
+		return s.adminConnections(), nil
+
+	case "KICK":
+		if len(args) < 3 {
+			return "", usageErrorf("Usage: ADMIN KICK <user|#id> <reason>")
+		}
+		return s.kick(actor, ip, args[1], strings.Join(args[2:], " "))
+
+	case "BAN":
+		if len(args) < 2 {
+			return "", usageErrorf("Usage: %s", banUsage)
+		}
+		return s.ban(actor, ip, args[1], args[2:])
+
+	case "UNBAN":
+		if len(args) != 2 {
+			return "", usageErrorf("Usage: ADMIN UNBAN <user>")
+		}
+		return s.unban(actor, ip, args[1])
+
+	case "BANS":
+		return s.adminBans()
+
+	case "REPORT":
+		return s.adminReport(args[1:])
+
+	case "EXPORT":
+		return s.adminExport(actor, ip, args[1:])
+
+	case "LOGLEVEL":
+		switch len(args) {
+		case 1:
+		case 2:
+			if err := s.log.setLevel("all", args[1]); err != nil {
+				return "", err
+			}
+		case 3:
+			if err := s.log.setLevel(args[1], args[2]); err != nil {
+				return "", err
+			}
+		default:
+			return "", usageErrorf("Usage: ADMIN LOGLEVEL [subsystem] [level]")
+		}
+		return s.log.report(), nil
+
+	case "FREEZE":
+		if len(args) < 3 {
+			return "", usageErrorf("Usage: ADMIN FREEZE <table> <reason>")
+		}
+		return s.freezeTable(actor, ip, args[1], strings.Join(args[2:], " "))
+
+	case "UNFREEZE":
+		if len(args) != 2 {
+			return "", usageErrorf("Usage: ADMIN UNFREEZE <table>")
+		}
+		return s.thawTable(actor, ip, args[1])
+
+	default:
+		return "", usageErrorf("Usage: %s", adminUsage)
+	}
+}
+
+func (s *Server) houseReport() (string, error) {
+	games, total, err := s.authService.HouseReport()
+	if err != nil {
+		return "", err
+	}
+
+	report := "House bankroll:"
+	for _, h := range append(games, total) {
+		report += fmt.Sprintf("\n  %-10s rounds: %-6d wagered: $%-10.2f paid: $%-10.2f hold: $%.2f (%.2f%%)",
+			h.Game, h.Rounds, float64(h.Wagered)/100, float64(h.Paid)/100, float64(h.Hold())/100, h.HoldPercent())
+	}
+	return report + s.rtpReport(), nil
+}
+
+func (s *Server) adminPromo(actor string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", usageErrorf("Usage: %s", promoUsage)
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "CREATE":
+		if len(args) < 2 || len(args) > 5 {
+			return "", usageErrorf("Usage: ADMIN PROMO CREATE <amount> [uses] [days] [code]")
+		}
+
+		value, err := parseDollars(args[1])
+		if err != nil {
+			return "", err
+		}
+
+		uses, days, code := 1, 0, ""
+		if len(args) >= 3 {
+			if uses, err = strconv.Atoi(args[2]); err != nil {
+				return "", fmt.Errorf("uses must be a number")
+			}
+		}
+		if len(args) >= 4 {
+			if days, err = strconv.Atoi(args[3]); err != nil || days < 0 {
+				return "", fmt.Errorf("days must be a non-negative number")
+			}
+		}
+		if len(args) == 5 {
+			code = args[4]
+		}
+
+		promo, err := s.authService.CreatePromoCode(actor, code, value, uses, time.Duration(days)*24*time.Hour)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Created promo code %s worth $%.2f (%d uses)", promo.Code, float64(promo.Value)/100, promo.MaxUses), nil
+
+	case "LIST":
+		promos, err := s.authService.ListPromoCodes()
+		if err != nil {
+			return "", err
+		}
+		if len(promos) == 0 {
+			return "No promo codes", nil
+		}
+
+		report := "Promo codes:"
+		for _, p := range promos {
+			expires := "never"
+			if p.ExpiresAt.Valid {
+				expires = p.ExpiresAt.Time.Format("2006-01-02 15:04")
+			}
+			report += fmt.Sprintf("\n  %-20s $%-9.2f used %d/%d  expires %s  by %s",
+				p.Code, float64(p.Value)/100, p.Uses, p.MaxUses, expires, p.CreatedBy)
+		}
+		return report, nil
+
+	default:
+		return "", usageErrorf("Usage: %s", promoUsage)
+	}
+}
+
+// Parses a positive dollar amount into cents, rounding to the nearest cent
+func parseDollars(arg string) (int64, error) {
+	dollars, err := strconv.ParseFloat(arg, 64)
+	if err != nil || !(dollars > 0) || math.IsInf(dollars, 0) {
+		return 0, fmt.Errorf("invalid amount")
+	}
+
+	return int64(math.Round(dollars * 100)), nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/security"
+)
+
+func (s *Server) handleAllowIP(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	// Only a password login may change where the account can be used from
+	if client.apiKeyID != "" {
+		s.writeResponse(client, "ERROR The IP allowlist can only be managed after LOGIN")
+		return
+	}
+
+	usage := "Usage: ALLOWIP LIST | ALLOWIP ADD <ip|cidr> | ALLOWIP REMOVE <ip|cidr> | ALLOWIP CLEAR"
+	if len(args) == 0 {
+		s.writeError(client, "USAGE", usage)
+		return
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "LIST":
+		cidrs, err := s.authService.ListAllowedIPs(client.user.ID)
+		if err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return
+		}
+		if len(cidrs) == 0 {
+			s.writeResponse(client, fmt.Sprintf("OK No IP restrictions (current IP: %s)", client.ip))
+			return
+		}
+		s.writeResponse(client, fmt.Sprintf("OK Logins allowed from (current IP: %s):\n  %s", client.ip, strings.Join(cidrs, "\n  ")))
+
+	case "ADD":
+		if len(args) != 2 {
+			s.writeError(client, "USAGE", "Usage: ALLOWIP ADD <ip|cidr>")
+			return
+		}
+		cidr, err := s.authService.AddAllowedIP(client.user.ID, args[1])
+		if err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return
+		}
+		response := fmt.Sprintf("OK Added %s to your allowlist", cidr)
+		if cidrs, err := s.authService.ListAllowedIPs(client.user.ID); err == nil && !security.IPAllowed(cidrs, client.ip) {
+			response += fmt.Sprintf("\nWarning: your current IP %s is not allowed, you won't be able to log in from here again", client.ip)
+		}
+		s.writeResponse(client, response)
+
+	case "REMOVE":
+		if len(args) != 2 {
+			s.writeError(client, "USAGE", "Usage: ALLOWIP REMOVE <ip|cidr>")
+			return
+		}
+		cidr, err := s.authService.RemoveAllowedIP(client.user.ID, args[1])
+		if err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return
+		}
+		s.writeResponse(client, fmt.Sprintf("OK Removed %s from your allowlist", cidr))
+
+	case "CLEAR":
+		if err := s.authService.ClearAllowedIPs(client.user.ID); err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return
+		}
+		s.writeResponse(client, "OK Cleared your allowlist, logins are allowed from any IP")
+
+	default:
+		s.writeError(client, "USAGE", usage)
+	}
+}
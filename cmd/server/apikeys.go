@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/security"
+)
+
+func (s *Server) handleAuth(client *ClientState, args []string) {
+	if len(args) != 1 {
+		s.writeError(client, "USAGE", "Usage: AUTH <api key>")
+		return
+	}
+
+	user, apiKey, err := s.authService.AuthenticateAPIKeyFromIP(args[0], client.ip)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	s.clearAuth(client)
+	client.user = user
+	client.apiKeyID = apiKey.ID
+	client.scope = apiKey.Scope
+	s.hub.bind(client, user.ID)
+
+	s.writeResponse(client, fmt.Sprintf("OK Authenticated as %s with %s key %s", user.Username, apiKey.Scope, apiKey.ID))
+	s.deliverStoredMessages(client)
+	s.restoreGame(client)
+}
+
+// Writes an error and returns false when an API key connection lacks the scope
+func (s *Server) requireScope(client *ClientState, required string) bool {
+	if client.apiKeyID == "" || security.ScopeAllows(client.scope, required) {
+		return true
+	}
+
+	s.writeError(client, "FORBIDDEN", fmt.Sprintf("API key scope %q does not allow this command", client.scope))
+	return false
+}
+
+func (s *Server) handleAPIKey(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	// Keys can only be managed from a password login so a leaked key can't mint more keys
+	if client.apiKeyID != "" {
+		s.writeResponse(client, "ERROR API keys can only be managed after LOGIN")
+		return
+	}
+
+	if len(args) == 0 {
+		s.writeError(client, "USAGE", "Usage: APIKEY CREATE <name> <read|play> | APIKEY LIST | APIKEY REVOKE <id>")
+		return
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "CREATE":
+		if len(args) != 3 {
+			s.writeError(client, "USAGE", "Usage: APIKEY CREATE <name> <read|play>")
+			return
+		}
+		key, apiKey, err := s.authService.CreateAPIKey(client.user.ID, args[1], strings.ToLower(args[2]))
+		if err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return
+		}
+		response := fmt.Sprintf("OK Created %s key %s (%s)\n", apiKey.Scope, apiKey.ID, apiKey.Name)
+		response += fmt.Sprintf("Key: %s\n", key)
+		response += "Store this key now, it will not be shown again."
+		s.writeResponse(client, response)
+
+	case "LIST":
+		keys, err := s.authService.ListAPIKeys(client.user.ID)
+		if err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return
+		}
+		if len(keys) == 0 {
+			s.writeResponse(client, "OK No API keys")
+			return
+		}
+		response := "OK API keys:"
+		for _, k := range keys {
+			status := "active"
+			if k.IsRevoked() {
+				status = "revoked"
+			}
+			lastUsed := "never"
+			if k.LastUsedAt.Valid {
+				lastUsed = k.LastUsedAt.Time.Format("2006-01-02 15:04")
+			}
+			response += fmt.Sprintf("\n  %s  %-30s %-4s %-7s last used: %s", k.ID, k.Name, k.Scope, status, lastUsed)
+		}
+		s.writeResponse(client, response)
+
+	case "REVOKE":
+		if len(args) != 2 {
+			s.writeError(client, "USAGE", "Usage: APIKEY REVOKE <id>")
+			return
+		}
+		if err := s.authService.RevokeAPIKey(client.user.ID, args[1]); err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return
+		}
+		s.writeResponse(client, fmt.Sprintf("OK Revoked API key %s", args[1]))
+
+	default:
+		s.writeError(client, "USAGE", "Usage: APIKEY CREATE <name> <read|play> | APIKEY LIST | APIKEY REVOKE <id>")
+	}
+}
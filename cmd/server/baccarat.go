@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+const baccaratUsage = "Usage: BACCARAT <PLAYER|BANKER|TIE> <amount> (e.g., BACCARAT BANKER 10)"
+
+// BACCARAT <side> <amount> deals one punto banco coup from a fresh shoe and
+// settles it at once, within the limits of the player's table
+func (s *Server) handleBaccarat(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	if len(args) != 2 {
+		s.writeError(client, "USAGE", baccaratUsage)
+		return
+	}
+	if _, ok := game.ParseBaccaratBet(args[0]); !ok {
+		s.writeError(client, "USAGE", baccaratUsage)
+		return
+	}
+
+	s.playOnce(client, "baccarat", args[1], args[0], nil)
+}
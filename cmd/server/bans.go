@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const banUsage = "ADMIN BAN <user> [duration] [reason]"
+
+// Bans a user for ADMIN BAN and disconnects them. args follow the username:
+// an optional duration such as 30m, 12h or 7d, permanent when left out, then
+// an optional reason.
+func (s *Server) ban(actor, ip, username string, args []string) (string, error) {
+	target, err := s.db.GetUserByUsername(username)
+	if err != nil {
+		return "", err
+	}
+
+	var duration time.Duration
+	if len(args) > 0 {
+		if d, ok := parseBanDuration(args[0]); ok {
+			duration, args = d, args[1:]
+		}
+	}
+	reason := strings.Join(args, " ")
+
+	until, err := s.authService.BanUser(actor, target.ID, duration, reason, ip)
+	if err != nil {
+		return "", err
+	}
+
+	summary := "permanently"
+	if !until.IsZero() {
+		summary = "until " + until.Format("2006-01-02 15:04")
+	}
+	message := "Your account has been suspended " + summary
+	if reason != "" {
+		message += ": " + reason
+	}
+
+	clients := s.hub.clientsForUser(target.ID, nil)
+	s.disconnect(clients, message)
+
+	result := fmt.Sprintf("Banned %s %s", target.Username, summary)
+	if len(clients) > 0 {
+		result += fmt.Sprintf(" and disconnected %d connection(s)", len(clients))
+	}
+	return result, nil
+}
+
+func (s *Server) unban(actor, ip, username string) (string, error) {
+	target, err := s.db.GetUserByUsername(username)
+	if err != nil {
+		return "", err
+	}
+	if err := s.authService.UnbanUser(actor, target.ID, ip); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Unbanned %s", target.Username), nil
+}
+
+// Lists the bans in force for ADMIN BANS
+func (s *Server) adminBans() (string, error) {
+	bans, err := s.authService.ListBans()
+	if err != nil {
+		return "", err
+	}
+	if len(bans) == 0 {
+		return "No banned users", nil
+	}
+
+	report := fmt.Sprintf("Banned users (%d):", len(bans))
+	for _, b := range bans {
+		until := "permanent"
+		if b.Until.Valid {
+			until = "until " + b.Until.Time.Local().Format("2006-01-02 15:04")
+		}
+		report += fmt.Sprintf("\n  %-20s %-22s by %-12s since %s", b.Username, until, b.BannedBy, b.CreatedAt.Local().Format("2006-01-02 15:04"))
+		if b.Reason != "" {
+			report += "  " + b.Reason
+		}
+	}
+	return report, nil
+}
+
+// Parses a ban length such as 30m, 12h or 7d
+func parseBanDuration(arg string) (time.Duration, bool) {
+	if days, ok := strings.CutSuffix(strings.ToLower(arg), "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, false
+		}
+		return time.Duration(n) * 24 * time.Hour, true
+	}
+
+	d, err := time.ParseDuration(arg)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d, true
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+	"github.com/alessandrosisniegas/casino/core/security"
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func (s *Server) handleDaily(client *ClientState, _ []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	amount, balance, err := s.authService.ClaimDailyBonus(client.user.ID)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	client.user.Balance = balance
+	s.writeResponse(client, fmt.Sprintf("OK Claimed daily bonus of $%.2f. New balance: $%.2f",
+		float64(amount)/100, float64(balance)/100))
+}
+
+func (s *Server) handleRebuy(client *ClientState, _ []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	balance, err := s.authService.Rebuy(client.user.ID)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	client.user.Balance = balance
+	s.writeResponse(client, fmt.Sprintf("OK Rebuy complete. New balance: $%.2f", float64(balance)/100))
+}
+
+// Pays the streak bonus when a win extends the streak to a milestone
+func (s *Server) awardStreakBonus(client *ClientState, streak int64) {
+	if !s.featureOn(security.FeatureStreaks) {
+		return
+	}
+	amount, balance, err := s.authService.AwardStreakBonus(client.user.ID, streak)
+	if err != nil {
+		s.log.game.Error("Failed to award streak bonus", "user", client.user.Username, "streak", streak, "err", err)
+		return
+	}
+	if amount == 0 {
+		return
+	}
+
+	client.user.Balance = balance
+	s.pushEvent(client, "STREAK", fmt.Sprintf("%d wins in a row! Streak bonus of $%.2f credited. New balance: $%.2f",
+		streak, float64(amount)/100, float64(balance)/100))
+}
+
+// Rebuys for a player a round left unable to cover the lowest table minimum,
+// when REBUY_AUTO is on and they have rebuys left today
+func (s *Server) autoRebuy(client *ClientState) {
+	if !s.config.RebuyAuto || !s.featureOn(security.FeatureRebuy) || client.user.Balance >= game.LowestMinBet() {
+		return
+	}
+	balance, err := s.authService.Rebuy(client.user.ID)
+	if err != nil {
+		// Out of rebuys, or a balance still above the rebuy amount
+		s.log.game.Debug("No automatic rebuy", "user", client.user.Username, "err", err)
+		return
+	}
+
+	client.user.Balance = balance
+	s.pushEvent(client, "REBUY", fmt.Sprintf("Out of chips: your balance was topped up to $%.2f", float64(balance)/100))
+}
+
+// Suggests REBUY to players who can no longer cover the table minimum
+func (s *Server) rebuyHint(user *vault.User) string {
+	minBet := game.LowestMinBet()
+	if s.config.RebuyAmount <= 0 || s.config.RebuyLimit <= 0 || user.Balance >= minBet {
+		return ""
+	}
+	return fmt.Sprintf(" (below the $%.2f table minimum, type REBUY for $%d)", float64(minBet)/100, s.config.RebuyAmount)
+}
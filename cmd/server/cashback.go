@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/security"
+)
+
+func (s *Server) handleCashback(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	if len(args) > 0 {
+		if len(args) != 1 || (!strings.EqualFold(args[0], "on") && !strings.EqualFold(args[0], "off")) {
+			s.writeError(client, "USAGE", "Usage: CASHBACK [ON|OFF]")
+			return
+		}
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+
+		optIn := strings.EqualFold(args[0], "on")
+		if err := s.authService.SetCashbackOptIn(client.user.ID, optIn); err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return
+		}
+		if !optIn {
+			s.writeResponse(client, "OK You left the cashback promotion")
+			return
+		}
+	}
+
+	status, err := s.authService.GetCashbackStatus(client.user.ID)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	ends := status.End.Local().Format("2006-01-02 15:04")
+	if !status.OptedIn {
+		s.writeResponse(client, fmt.Sprintf("OK Get %d%% of your net losses back each %s period. Type CASHBACK ON to join.",
+			status.Percent, s.config.CashbackPeriod))
+		return
+	}
+
+	s.writeResponse(client, fmt.Sprintf("OK Cashback %d%%: net loss this period $%.2f, rebate so far $%.2f (paid after %s)",
+		status.Percent, float64(status.NetLoss)/100, float64(status.Estimate)/100, ends))
+}
+
+// Pays any due cashback and tells connected players about their rebate
+func (s *Server) settleCashback() {
+	// Periods that end while it is off are paid once it is back on
+	if !s.featureOn(security.FeatureCashback) {
+		return
+	}
+	payouts, err := s.authService.SettleCashback(time.Now())
+	if err != nil {
+		s.log.game.Error("Failed to settle cashback", "err", err)
+	}
+
+	for _, p := range payouts {
+		for _, c := range s.hub.clientsForUser(p.UserID, nil) {
+			s.pushEvent(c, "PROMOTION", fmt.Sprintf("Cashback of $%.2f credited. New balance: $%.2f",
+				float64(p.Amount)/100, float64(p.Balance)/100))
+		}
+	}
+}
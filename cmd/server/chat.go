@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Window CHAT_LIMIT counts messages over
+const chatWindow = 10 * time.Second
+
+// Recent messages per user for flood control
+type chatFlood struct {
+	mu   sync.Mutex
+	sent map[int][]time.Time
+}
+
+func newChatFlood() *chatFlood {
+	return &chatFlood{sent: make(map[int][]time.Time)}
+}
+
+// Records a message from the user and reports whether it is within limit
+// messages per chatWindow; a refused message doesn't count against them
+func (f *chatFlood) allow(userID, limit int, now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	recent := f.sent[userID][:0]
+	for _, t := range f.sent[userID] {
+		if now.Sub(t) < chatWindow {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= limit {
+		f.sent[userID] = recent
+		return false
+	}
+	f.sent[userID] = append(recent, now)
+	return true
+}
+
+// CHAT <message> talks to the player's table; CHAT LOBBY <message> to everyone online
+func (s *Server) handleChat(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	lobby := len(args) > 0 && strings.EqualFold(args[0], "LOBBY")
+	if lobby {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		s.writeError(client, "USAGE", "Usage: CHAT <message> | CHAT LOBBY <message>")
+		return
+	}
+
+	if lobby && !s.config.LobbyChat {
+		s.writeResponse(client, "ERROR Lobby chat is disabled")
+		return
+	}
+	if !lobby && client.seatedAt == nil {
+		s.writeResponse(client, "ERROR You are not seated at a table. Use CHAT LOBBY <message> to talk to everyone")
+		return
+	}
+
+	message, err := s.authService.PrepareChat(client.user.ID, strings.Join(args, " "))
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	if s.config.ChatLimit > 0 && !s.chat.allow(client.user.ID, s.config.ChatLimit, time.Now()) {
+		s.writeResponse(client, "ERROR You are sending messages too quickly, slow down")
+		return
+	}
+
+	ignoring, err := s.authService.IgnoredBy(client.user.ID)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	if lobby {
+		line := fmt.Sprintf("[lobby] %s: %s", client.user.Username, message)
+		for _, id := range s.hub.userIDs() {
+			if ignoring[id] {
+				continue
+			}
+			for _, c := range s.hub.clientsForUser(id, client) {
+				s.pushEvent(c, "CHAT", line)
+			}
+		}
+		s.writeResponse(client, "OK Sent to the lobby")
+		return
+	}
+
+	st := client.seatedAt
+	s.tellTable(st, client, ignoring, "CHAT", message)
+	s.writeResponse(client, fmt.Sprintf("OK Sent to %s", st.id))
+}
+
+// Pushes a line from the sender to everyone else seated at st, skipping
+// players who blocked or muted them
+func (s *Server) tellTable(st *sharedTable, sender *ClientState, ignoring map[int]bool, kind, message string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	line := fmt.Sprintf("[%s] %s: %s", st.id, sender.user.Username, message)
+	for i, c := range st.clients {
+		seat := st.table.Seats[i]
+		if c == nil || c == sender || seat == nil || seat.Away || ignoring[seat.UserID] {
+			continue
+		}
+		s.pushEvent(c, kind, line)
+	}
+}
+
+// Reactions a seated player can send with REACT, in the order HELP lists them
+var emotes = []struct{ name, text string }{
+	{"nice", "Nice hand!"},
+	{"ouch", "Ouch!"},
+	{"gg", "Good game!"},
+	{"gl", "Good luck!"},
+	{"wow", "Wow!"},
+}
+
+// Reactions a player can send in any 10 seconds
+const reactLimit = 3
+
+// REACT <emote> sends one of a fixed set of reactions to the player's table
+func (s *Server) handleReact(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	var names []string
+	for _, e := range emotes {
+		names = append(names, e.name)
+	}
+	if len(args) != 1 {
+		s.writeError(client, "USAGE", "Usage: REACT <"+strings.Join(names, "|")+">")
+		return
+	}
+
+	text := ""
+	for _, e := range emotes {
+		if strings.EqualFold(e.name, args[0]) {
+			text = e.text
+		}
+	}
+	if text == "" {
+		s.writeResponse(client, "ERROR Unknown reaction. Try one of: "+strings.Join(names, ", "))
+		return
+	}
+
+	st := client.seatedAt
+	if st == nil {
+		s.writeResponse(client, "ERROR You are not seated at a table")
+		return
+	}
+
+	if !s.reactions.allow(client.user.ID, reactLimit, time.Now()) {
+		s.writeResponse(client, "ERROR You are reacting too quickly, slow down")
+		return
+	}
+
+	ignoring, err := s.authService.IgnoredBy(client.user.ID)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	s.tellTable(st, client, ignoring, "REACT", text)
+	s.writeResponse(client, "OK "+text)
+}
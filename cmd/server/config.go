@@ -0,0 +1,327 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Config holds server settings read from the environment
+type Config struct {
+	Addr   string
+	DBPath string
+
+	// TLS_CERT and TLS_KEY are PEM files; with them set the server only
+	// accepts TLS connections
+	TLSCert string
+	TLSKey  string
+
+	// DB_WAL=0 turns off write-ahead logging, DB_BUSY_TIMEOUT is how many
+	// milliseconds a query waits on a locked database and DB_FOREIGN_KEYS=0
+	// stops foreign keys being enforced
+	DBWAL         bool
+	DBBusyTimeout int
+	DBForeignKeys bool
+
+	// HIDE_USERNAMES=1 makes signup errors identical for taken usernames
+	HideUsernames bool
+
+	// SESSION_TOKENS=1 issues signed tokens instead of database sessions,
+	// signed with SESSION_TOKEN_SECRET, which is required so tokens stay
+	// valid across restarts and servers
+	SessionTokens      bool
+	SessionTokenSecret string
+
+	// REDIS_URL keeps login sessions in Redis (redis:// or rediss:// for TLS)
+	// so servers behind a load balancer share them; empty keeps them in the
+	// database
+	RedisURL string
+
+	// SIGNUP_LIMIT caps signups per IP per day (0 disables);
+	// REQUIRE_INVITE=1 makes SIGNUP need an invite code from the console
+	SignupLimit   int
+	RequireInvite bool
+
+	// PASSWORD_PEPPER_FILE holds "<version> <secret>" lines for rotation;
+	// PASSWORD_PEPPER (with PASSWORD_PEPPER_VERSION) sets a single pepper
+	PepperFile    string
+	Pepper        string
+	PepperVersion int
+
+	// DAILY_BONUS is the free-chip amount in dollars DAILY grants once a day (0 disables)
+	DailyBonus int
+
+	// Players who can't cover the lowest table minimum can REBUY up to
+	// REBUY_AMOUNT dollars, REBUY_LIMIT times a day (0 disables).
+	// REBUY_AUTO=1 rebuys for them when a round leaves them short.
+	RebuyAmount int
+	RebuyLimit  int
+	RebuyAuto   bool
+
+	// TRANSFER_LIMIT caps dollars a player can TRANSFER per day (0 = unlimited)
+	TransferLimit int
+
+	// DEPOSIT and WITHDRAW move between their _MIN and _MAX dollars at a time
+	// (a 0 max disables the command)
+	DepositMin  int
+	DepositMax  int
+	WithdrawMin int
+	WithdrawMax int
+
+	// JACKPOT_PERCENT of every wager feeds a jackpot that starts at JACKPOT_SEED
+	// dollars and is won by the opening hand in JACKPOT_TRIGGER (0% disables)
+	JackpotPercent int
+	JackpotSeed    int
+	JackpotTrigger string
+
+	// Opted-in players get CASHBACK_PERCENT of their net losses back at the end
+	// of each CASHBACK_PERIOD, daily or weekly (0% disables)
+	CashbackPercent int
+	CashbackPeriod  string
+
+	// A player who signs up with a referral code and wagers REFERRAL_WAGER
+	// dollars earns REFERRAL_BONUS dollars for themselves and the referrer (0 disables)
+	ReferralBonus int
+	ReferralWager int
+
+	// STREAK_BONUS dollars are paid when a win streak reaches one of the
+	// comma-separated STREAK_MILESTONES (0 disables)
+	StreakBonus      int
+	StreakMilestones string
+
+	// GUEST accounts start with GUEST_BALANCE dollars and are deleted after
+	// GUEST_IDLE_HOURS without activity (0 balance disables guests)
+	GuestBalance   int
+	GuestIdleHours int
+
+	// Seconds a shared table takes bets after the first one before dealing.
+	// TABLE_TURN_SECONDS is how long a player has to act before they stand
+	// automatically, unless a hosted table sets its own (0 disables).
+	TableBetSeconds  int
+	TableTurnSeconds int
+
+	// Seconds a player has to act on a solo hand before it stands and is
+	// settled automatically (0 disables)
+	SoloTurnSeconds int
+
+	// DEALER_HITS_SOFT17=1 makes the dealer hit soft 17 at the house tables;
+	// hosted tables choose with h17 or s17
+	DealerHitsSoft17 bool
+
+	// KENO_PAY_TABLE replaces rows of the house keno pay table with
+	// comma-separated picks/hits=pays entries, e.g. "1/1=3,2/2=12"
+	KenoPayTable string
+
+	// MAX_CONNECTIONS caps live connections and MAX_CONNECTIONS_PER_IP those
+	// from one address; more are told the server is full and closed (0 for
+	// no cap). The console's maxconns changes them while the server runs.
+	MaxConnections      int
+	MaxConnectionsPerIP int
+
+	// A connection, and all the connections of an account together, can send
+	// RATE_LIMIT commands a second in bursts of up to RATE_BURST. Auth commands
+	// (LOGIN, SIGNUP, RESUME, GUEST, AUTH) are held to AUTH_RATE_LIMIT a minute
+	// per address in bursts of AUTH_RATE_BURST. 0 disables either limit.
+	RateLimit     int
+	RateBurst     int
+	AuthRateLimit int
+	AuthRateBurst int
+
+	// LOBBY_CHAT=0 turns off the server-wide chat channel. CHAT_LIMIT caps
+	// messages per player in any 10 seconds (0 disables) and CHAT_BLOCKLIST is a
+	// comma-separated list of words masked in chat.
+	LobbyChat     bool
+	ChatLimit     int
+	ChatBlocklist string
+
+	// Wins of BIG_WIN dollars or more, and blackjacks on bets of at least
+	// BIG_WIN_BET dollars, are announced to everyone online (0 disables)
+	BigWin    int
+	BigWinBet int
+
+	// METRICS_ADDR serves the server statistics for Prometheus at /metrics on
+	// this address, e.g. 127.0.0.1:9100 (empty disables)
+	MetricsAddr string
+
+	// GRPC_ADDR serves the gRPC API on this address, e.g. 127.0.0.1:9091
+	// (empty disables)
+	GRPCAddr string
+
+	// LOG_FORMAT is text or json. LOG_LEVEL sets the level of every subsystem
+	// and/or of single ones, e.g. "info,vault=debug"; ADMIN LOGLEVEL changes
+	// them while the server runs.
+	LogFormat string
+	LogLevel  string
+
+	// WEBHOOK_URLS is a comma-separated list of URLs that alerts are POSTed to
+	// as JSON, limited to the comma-separated WEBHOOK_EVENTS (empty for all).
+	// ALERT_BALANCE dollars is the balance that alerts when a player reaches
+	// it, and ALERT_FAILED_LOGINS the failed logins from one IP or for one
+	// username within 10 minutes that alert (0 disables either).
+	WebhookURLs       string
+	WebhookEvents     string
+	AlertBalance      int
+	AlertFailedLogins int
+
+	// A game whose return to player since start strays RTP_ALERT_SIGMA
+	// standard errors from the expected one alerts, once it has RTP_MIN_ROUNDS
+	// rounds (0 disables)
+	RTPAlertSigma int
+	RTPMinRounds  int
+
+	// Solo hands are saved as they are played so players can finish them after
+	// a reconnect or restart. One untouched for ABANDONED_HAND_HOURS is closed
+	// per ABANDONED_HAND_POLICY: stand (the dealer plays it out) or refund.
+	AbandonedHandHours  int
+	AbandonedHandPolicy string
+}
+
+func loadConfig() Config {
+	cfg := Config{
+		Addr:        "127.0.0.1:9090",
+		DBPath:      filepath.Join("..", "..", "data", "casino.db"),
+		SignupLimit: 10,
+		DailyBonus:  100,
+		RebuyAmount: 100,
+		RebuyLimit:  3,
+
+		DBBusyTimeout: 5000,
+
+		TransferLimit: 1000,
+
+		DepositMin:  1,
+		DepositMax:  1000,
+		WithdrawMin: 1,
+		WithdrawMax: 1000,
+
+		JackpotPercent: 1,
+		JackpotSeed:    1000,
+		JackpotTrigger: "AS,JS",
+
+		CashbackPercent: 10,
+		CashbackPeriod:  "weekly",
+
+		ReferralBonus: 25,
+		ReferralWager: 100,
+
+		StreakBonus:      5,
+		StreakMilestones: "3,5,10",
+
+		GuestBalance:   1000,
+		GuestIdleHours: 24,
+
+		TableBetSeconds:  15,
+		TableTurnSeconds: 30,
+		SoloTurnSeconds:  60,
+
+		MaxConnections:      1000,
+		MaxConnectionsPerIP: 20,
+
+		RateLimit:     20,
+		RateBurst:     40,
+		AuthRateLimit: 10,
+		AuthRateBurst: 5,
+
+		ChatLimit: 5,
+
+		BigWin:    500,
+		BigWinBet: 100,
+
+		AlertBalance:      100000,
+		AlertFailedLogins: 5,
+
+		RTPAlertSigma: 4,
+		RTPMinRounds:  1000,
+
+		AbandonedHandHours:  24,
+		AbandonedHandPolicy: abandonStand,
+	}
+
+	// Bind address:
+	// - Default is local only where we bind 127.0.0.1
+	// - If user wants to run it on LAN we bind 0.0.0.0
+	if os.Getenv("LAN") == "1" {
+		cfg.Addr = "0.0.0.0:9090"
+	}
+
+	cfg.TLSCert = os.Getenv("TLS_CERT")
+	cfg.TLSKey = os.Getenv("TLS_KEY")
+	cfg.DBWAL = os.Getenv("DB_WAL") != "0"
+	cfg.DBBusyTimeout = envInt("DB_BUSY_TIMEOUT", cfg.DBBusyTimeout)
+	cfg.DBForeignKeys = os.Getenv("DB_FOREIGN_KEYS") != "0"
+	cfg.HideUsernames = os.Getenv("HIDE_USERNAMES") == "1"
+	cfg.SessionTokenSecret = os.Getenv("SESSION_TOKEN_SECRET")
+	cfg.SessionTokens = os.Getenv("SESSION_TOKENS") == "1" || cfg.SessionTokenSecret != ""
+	cfg.RedisURL = os.Getenv("REDIS_URL")
+	cfg.SignupLimit = envInt("SIGNUP_LIMIT", cfg.SignupLimit)
+	cfg.RequireInvite = os.Getenv("REQUIRE_INVITE") == "1"
+	cfg.PepperFile = os.Getenv("PASSWORD_PEPPER_FILE")
+	cfg.Pepper = os.Getenv("PASSWORD_PEPPER")
+	cfg.PepperVersion = envInt("PASSWORD_PEPPER_VERSION", 1)
+	cfg.DailyBonus = envInt("DAILY_BONUS", cfg.DailyBonus)
+	cfg.RebuyAmount = envInt("REBUY_AMOUNT", cfg.RebuyAmount)
+	cfg.RebuyLimit = envInt("REBUY_LIMIT", cfg.RebuyLimit)
+	cfg.RebuyAuto = os.Getenv("REBUY_AUTO") == "1"
+	cfg.TransferLimit = envInt("TRANSFER_LIMIT", cfg.TransferLimit)
+	cfg.DepositMin = envInt("DEPOSIT_MIN", cfg.DepositMin)
+	cfg.DepositMax = envInt("DEPOSIT_MAX", cfg.DepositMax)
+	cfg.WithdrawMin = envInt("WITHDRAW_MIN", cfg.WithdrawMin)
+	cfg.WithdrawMax = envInt("WITHDRAW_MAX", cfg.WithdrawMax)
+	cfg.JackpotPercent = envInt("JACKPOT_PERCENT", cfg.JackpotPercent)
+	cfg.JackpotSeed = envInt("JACKPOT_SEED", cfg.JackpotSeed)
+	if v := os.Getenv("JACKPOT_TRIGGER"); v != "" {
+		cfg.JackpotTrigger = v
+	}
+	cfg.CashbackPercent = envInt("CASHBACK_PERCENT", cfg.CashbackPercent)
+	if v := os.Getenv("CASHBACK_PERIOD"); v != "" {
+		cfg.CashbackPeriod = v
+	}
+	cfg.ReferralBonus = envInt("REFERRAL_BONUS", cfg.ReferralBonus)
+	cfg.ReferralWager = envInt("REFERRAL_WAGER", cfg.ReferralWager)
+	cfg.StreakBonus = envInt("STREAK_BONUS", cfg.StreakBonus)
+	cfg.GuestBalance = envInt("GUEST_BALANCE", cfg.GuestBalance)
+	cfg.GuestIdleHours = envInt("GUEST_IDLE_HOURS", cfg.GuestIdleHours)
+	cfg.TableBetSeconds = envInt("TABLE_BET_SECONDS", cfg.TableBetSeconds)
+	cfg.TableTurnSeconds = envInt("TABLE_TURN_SECONDS", cfg.TableTurnSeconds)
+	cfg.SoloTurnSeconds = envInt("SOLO_TURN_SECONDS", cfg.SoloTurnSeconds)
+	cfg.DealerHitsSoft17 = os.Getenv("DEALER_HITS_SOFT17") == "1"
+	cfg.KenoPayTable = os.Getenv("KENO_PAY_TABLE")
+	cfg.MaxConnections = envInt("MAX_CONNECTIONS", cfg.MaxConnections)
+	cfg.MaxConnectionsPerIP = envInt("MAX_CONNECTIONS_PER_IP", cfg.MaxConnectionsPerIP)
+	cfg.RateLimit = envInt("RATE_LIMIT", cfg.RateLimit)
+	cfg.RateBurst = envInt("RATE_BURST", cfg.RateBurst)
+	cfg.AuthRateLimit = envInt("AUTH_RATE_LIMIT", cfg.AuthRateLimit)
+	cfg.AuthRateBurst = envInt("AUTH_RATE_BURST", cfg.AuthRateBurst)
+	cfg.LobbyChat = os.Getenv("LOBBY_CHAT") != "0"
+	cfg.ChatLimit = envInt("CHAT_LIMIT", cfg.ChatLimit)
+	cfg.ChatBlocklist = os.Getenv("CHAT_BLOCKLIST")
+	cfg.BigWin = envInt("BIG_WIN", cfg.BigWin)
+	cfg.BigWinBet = envInt("BIG_WIN_BET", cfg.BigWinBet)
+	cfg.MetricsAddr = os.Getenv("METRICS_ADDR")
+	cfg.GRPCAddr = os.Getenv("GRPC_ADDR")
+	cfg.LogFormat = os.Getenv("LOG_FORMAT")
+	cfg.LogLevel = os.Getenv("LOG_LEVEL")
+	cfg.WebhookURLs = os.Getenv("WEBHOOK_URLS")
+	cfg.WebhookEvents = os.Getenv("WEBHOOK_EVENTS")
+	cfg.AlertBalance = envInt("ALERT_BALANCE", cfg.AlertBalance)
+	cfg.AlertFailedLogins = envInt("ALERT_FAILED_LOGINS", cfg.AlertFailedLogins)
+	cfg.RTPAlertSigma = envInt("RTP_ALERT_SIGMA", cfg.RTPAlertSigma)
+	cfg.RTPMinRounds = envInt("RTP_MIN_ROUNDS", cfg.RTPMinRounds)
+	cfg.AbandonedHandHours = envInt("ABANDONED_HAND_HOURS", cfg.AbandonedHandHours)
+	if v := os.Getenv("ABANDONED_HAND_POLICY"); v != "" {
+		cfg.AbandonedHandPolicy = v
+	}
+	if v, ok := os.LookupEnv("STREAK_MILESTONES"); ok {
+		cfg.StreakMilestones = v
+	}
+
+	return cfg
+}
+
+// Reads an integer env var, falling back to def when unset or invalid
+func envInt(name string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(name)); err == nil {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Lists every live connection for ADMIN CONNECTIONS: its number, user, IP,
+// how long it has been connected and what it is playing
+func (s *Server) adminConnections() string {
+	clients := s.hub.clients()
+	if len(clients) == 0 {
+		return "No live connections"
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].id < clients[j].id })
+
+	// Which table each seated connection is at
+	tables := make(map[*ClientState]string)
+	s.lobby.mu.Lock()
+	for _, st := range s.lobby.tables {
+		st.mu.Lock()
+		for _, c := range st.clients {
+			if c != nil {
+				tables[c] = st.id
+			}
+		}
+		st.mu.Unlock()
+	}
+	s.lobby.mu.Unlock()
+
+	names := make(map[int]string)
+	report := fmt.Sprintf("Live connections (%d):", len(clients))
+	for _, c := range clients {
+		name := "(not logged in)"
+		if userID := s.hub.userOfClient(c); userID != 0 {
+			if _, ok := names[userID]; !ok {
+				names[userID] = fmt.Sprintf("user %d", userID)
+				if user, err := s.db.GetUserByID(userID); err == nil {
+					names[userID] = user.Username
+				}
+			}
+			name = names[userID]
+		}
+
+		activity := "idle"
+		switch {
+		case tables[c] != "":
+			activity = "at table " + tables[c]
+		case c.playing.Load():
+			activity = "playing solo"
+		}
+
+		report += fmt.Sprintf("\n  #%-4d %-20s %-15s since %s (%s)  %s", c.id, name, c.ip,
+			c.connectedAt.Format("2006-01-02 15:04"), time.Since(c.connectedAt).Truncate(time.Second), activity)
+	}
+	return report
+}
+
+// Disconnects one connection, given as #<number>, or every connection of a
+// user, telling them why first
+func (s *Server) kick(actor, ip, target, reason string) (string, error) {
+	var clients []*ClientState
+	userID := 0
+	if number, ok := strings.CutPrefix(target, "#"); ok {
+		id, err := strconv.Atoi(number)
+		if err != nil {
+			return "", fmt.Errorf("connections are numbered like #12, see ADMIN CONNECTIONS")
+		}
+		c := s.hub.client(id)
+		if c == nil {
+			return "", fmt.Errorf("no live connection #%d", id)
+		}
+		clients = []*ClientState{c}
+		userID = s.hub.userOfClient(c)
+	} else {
+		user, err := s.db.GetUserByUsername(target)
+		if err != nil {
+			return "", err
+		}
+		userID = user.ID
+		clients = s.hub.clientsForUser(user.ID, nil)
+		if len(clients) == 0 {
+			return "", fmt.Errorf("%s is not connected", user.Username)
+		}
+	}
+
+	s.authService.LogKick(actor, userID, reason, ip)
+	s.disconnect(clients, "An administrator disconnected you: "+reason)
+
+	if len(clients) == 1 {
+		return fmt.Sprintf("Kicked connection #%d", clients[0].id), nil
+	}
+	return fmt.Sprintf("Kicked %d connections of %s", len(clients), target), nil
+}
+
+// Sends each connection a KICK event saying why, then hangs up on it
+func (s *Server) disconnect(clients []*ClientState, message string) {
+	for _, c := range clients {
+		s.pushEvent(c, "KICK", message)
+		// Ends the connection's read loop, which cleans up as for any disconnect
+		c.conn.Close()
+	}
+}
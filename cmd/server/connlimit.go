@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Caps the live TCP connections in all and from any one IP. gRPC sessions
+// aren't counted.
+type connLimits struct {
+	mu    sync.Mutex
+	max   int // 0 for no cap
+	perIP int // 0 for no cap
+	total int
+	byIP  map[string]int
+}
+
+func newConnLimits(max, perIP int) *connLimits {
+	return &connLimits{max: max, perIP: perIP, byIP: make(map[string]int)}
+}
+
+// Counts a connection from ip in, or returns why it is turned away
+func (c *connLimits) admit(ip string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.max > 0 && c.total >= c.max {
+		return "Server full, please try again later"
+	}
+	if c.perIP > 0 && c.byIP[ip] >= c.perIP {
+		return fmt.Sprintf("Too many connections from your address (limit %d), please close one and try again", c.perIP)
+	}
+	c.total++
+	c.byIP[ip]++
+	return ""
+}
+
+// Counts a connection admitted from ip out again
+func (c *connLimits) release(ip string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.total--
+	if c.byIP[ip]--; c.byIP[ip] <= 0 {
+		delete(c.byIP, ip)
+	}
+}
+
+func (c *connLimits) caps() (total, perIP int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.max, c.perIP
+}
+
+// Changes the caps; connections already in stay even when over them
+func (c *connLimits) set(max, perIP int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.max, c.perIP = max, perIP
+}
+
+func (c *connLimits) report() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	limit := func(n int) string {
+		if n == 0 {
+			return "unlimited"
+		}
+		return strconv.Itoa(n)
+	}
+	report := fmt.Sprintf("Connections: %d (max %s, per IP %s)", c.total, limit(c.max), limit(c.perIP))
+
+	busiest, most := "", 0
+	for ip, n := range c.byIP {
+		if n > most || (n == most && ip < busiest) {
+			busiest, most = ip, n
+		}
+	}
+	if most > 1 {
+		report += fmt.Sprintf("\nBusiest address: %s (%d)", busiest, most)
+	}
+	return report
+}
+
+// Lets a new connection in, or tells it why not and closes it. The reply
+// gets a deadline so a client that never reads can't hold on to it.
+func (s *Server) admitConn(conn net.Conn) bool {
+	ip := remoteIP(conn)
+	reason := s.conns.admit(ip)
+	if reason == "" {
+		return true
+	}
+
+	s.log.server.Warn("Connection refused", "ip", ip, "reason", reason)
+	conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	conn.Write([]byte("ERROR SERVER_FULL " + reason + "\n"))
+	conn.Close()
+	return false
+}
+
+// maxconns shows the connection caps, and maxconns <max> [per-ip] changes
+// them until the server restarts
+func (s *Server) consoleMaxConns(args []string) {
+	if len(args) == 0 {
+		fmt.Println(s.conns.report())
+		return
+	}
+	if len(args) > 2 {
+		fmt.Println("Usage: maxconns [max|off] [per-ip|off]")
+		return
+	}
+
+	total, perIP := s.conns.caps()
+	caps := []int{total, perIP}
+	for i, arg := range args {
+		if strings.EqualFold(arg, "off") {
+			caps[i] = 0
+			continue
+		}
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			fmt.Println("Usage: maxconns [max|off] [per-ip|off]")
+			return
+		}
+		caps[i] = n
+	}
+
+	s.conns.set(caps[0], caps[1])
+	s.log.server.Info("Connection limits changed", "max", caps[0], "per_ip", caps[1])
+	fmt.Println(s.conns.report())
+}
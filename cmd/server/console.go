@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reads operator commands from stdin until quit
+func (s *Server) runConsole(shutdown chan bool) {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		command := ""
+		if len(fields) > 0 {
+			command = strings.ToUpper(fields[0])
+		}
+
+		switch command {
+		case "QUIT", "EXIT", "STOP":
+			fmt.Println("Shutting down server...")
+			shutdown <- true
+			return
+		case "HELP":
+			fmt.Println("Server commands:")
+			fmt.Println("  help                 - Show this help")
+			fmt.Println("  stats                - Show server statistics")
+			fmt.Println("  users                - List all users")
+			fmt.Println("  limits <user>        - Show a user's loss/wager limits")
+			fmt.Println("  setlimit <user> <daily|weekly> <loss|wager> <amount|off>")
+			fmt.Println("                       - Set or remove a user's limit")
+			fmt.Println("  allowlist <user> [clear] - Show or clear a user's IP allowlist")
+			fmt.Println("  invite [uses] [days] - Create an invite code (default 1 use, no expiry)")
+			fmt.Println("  invites              - List invite codes")
+			fmt.Println("  admin grant|deduct <user> <amount> <reason>")
+			fmt.Println("                       - Adjust a user's balance with a reason")
+			fmt.Println("  admin transfers on|off - Enable or disable player transfers")
+			fmt.Println("  admin features       - List feature flags")
+			fmt.Println("  admin feature <name> on|off - Switch a feature on or off")
+			fmt.Println("  house                - Show the house bankroll report")
+			fmt.Println("  admin promo create <amount> [uses] [days] [code]")
+			fmt.Println("                       - Create a promo code (default 1 use, no expiry)")
+			fmt.Println("  admin promo list     - List promo codes")
+			fmt.Println("  admin event create <xp|points|blackjack> <multiplier> <hours> <now|YYYY-MM-DDTHH:MM> <name>")
+			fmt.Println("                       - Schedule an event boosting XP, comp points or blackjack payouts")
+			fmt.Println("  admin event list     - List running and upcoming events")
+			fmt.Println("  admin event cancel <id> - End or drop an event")
+			fmt.Println("  admin mute <user> <minutes> - Stop a player chatting")
+			fmt.Println("  admin unmute <user>  - Lift a chat mute")
+			fmt.Println("  admin tables         - Show every table in use with its players and pot")
+			fmt.Println("  admin freeze <table> <reason> - Stop all play at a table")
+			fmt.Println("  admin unfreeze <table> - Reopen a frozen table")
+			fmt.Println("  admin connections    - List live connections")
+			fmt.Println("  maxconns [max|off] [per-ip|off] - Show or change the connection caps")
+			fmt.Println("  admin kick <user|#id> <reason> - Disconnect a user or one connection")
+			fmt.Println("  admin ban <user> [duration] [reason] - Suspend an account and disconnect it")
+			fmt.Println("  admin unban <user>   - Lift a ban")
+			fmt.Println("  admin bans           - List banned users")
+			fmt.Println("  admin loglevel [subsystem] [level] - Show or change log levels")
+			fmt.Println("  admin export audit|ledger <from> <to> [user] [csv|json] - Export records")
+			fmt.Println("  admin report [YYYY-MM-DD|today|yesterday] - Show a day's digest")
+			fmt.Println("  promote <user>       - Give a user admin rights")
+			fmt.Println("  demote <user>        - Remove a user's admin rights")
+			fmt.Println("  quit                 - Shutdown server")
+		case "STATS":
+			s.showStats()
+		case "USERS":
+			s.showUsers()
+		case "LIMITS":
+			s.consoleLimits(fields[1:])
+		case "SETLIMIT":
+			s.consoleSetLimit(fields[1:])
+		case "ALLOWLIST":
+			s.consoleAllowlist(fields[1:])
+		case "INVITE":
+			s.consoleInvite(fields[1:])
+		case "INVITES":
+			s.consoleInvites()
+		case "ADMIN":
+			s.consoleAdmin(fields[1:])
+		case "HOUSE":
+			s.consoleAdmin([]string{"house"})
+		case "MAXCONNS":
+			s.consoleMaxConns(fields[1:])
+		case "PROMOTE", "DEMOTE":
+			s.consoleSetAdmin(fields[1:], command == "PROMOTE")
+		case "":
+		default:
+			fmt.Printf("Unknown command: %s (type 'help' for commands)\n", command)
+		}
+		fmt.Print("server> ")
+	}
+}
+
+func (s *Server) showStats() {
+	fmt.Println(s.statsReport())
+}
+
+func (s *Server) showUsers() {
+	fmt.Println("Use SQLite to view users:")
+	fmt.Println("  sqlite3 data/casino.db \"SELECT id, username, balance/100.0, created_at FROM users;\"")
+}
+
+func (s *Server) consoleLimits(args []string) {
+	if len(args) != 1 {
+		fmt.Println("Usage: limits <user>")
+		return
+	}
+
+	user, err := s.db.GetUserByUsername(args[0])
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	limits, err := s.authService.GetLimits(user.ID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	fmt.Printf("Limits for %s:\n%s\n", user.Username, formatLimits(limits))
+}
+
+func (s *Server) consoleSetLimit(args []string) {
+	if len(args) != 4 {
+		fmt.Println("Usage: setlimit <user> <daily|weekly> <loss|wager> <amount|off>")
+		return
+	}
+
+	user, err := s.db.GetUserByUsername(args[0])
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	amount, err := parseLimitAmount(args[3])
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	period, kind := strings.ToLower(args[1]), strings.ToLower(args[2])
+	if err := s.authService.SetLimit(user.ID, period, kind, amount, true); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	fmt.Printf("Set %s %s limit for %s\n", period, kind, user.Username)
+}
+
+func (s *Server) consoleAllowlist(args []string) {
+	if len(args) < 1 || len(args) > 2 || (len(args) == 2 && !strings.EqualFold(args[1], "clear")) {
+		fmt.Println("Usage: allowlist <user> [clear]")
+		return
+	}
+
+	user, err := s.db.GetUserByUsername(args[0])
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	if len(args) == 2 {
+		if err := s.authService.ClearAllowedIPs(user.ID); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Printf("Cleared IP allowlist for %s\n", user.Username)
+		return
+	}
+
+	cidrs, err := s.authService.ListAllowedIPs(user.ID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	if len(cidrs) == 0 {
+		fmt.Printf("%s can log in from any IP\n", user.Username)
+		return
+	}
+	fmt.Printf("%s can log in from:\n  %s\n", user.Username, strings.Join(cidrs, "\n  "))
+}
+
+func (s *Server) consoleInvite(args []string) {
+	if len(args) > 2 {
+		fmt.Println("Usage: invite [uses] [days]")
+		return
+	}
+
+	uses, days := 1, 0
+	var err error
+	if len(args) >= 1 {
+		if uses, err = strconv.Atoi(args[0]); err != nil {
+			fmt.Println("Error: uses must be a number")
+			return
+		}
+	}
+	if len(args) == 2 {
+		if days, err = strconv.Atoi(args[1]); err != nil || days < 0 {
+			fmt.Println("Error: days must be a non-negative number")
+			return
+		}
+	}
+
+	invite, err := s.authService.CreateInviteCode("console", uses, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	fmt.Printf("Created invite code %s (%d uses)\n", invite.Code, invite.MaxUses)
+}
+
+func (s *Server) consoleInvites() {
+	invites, err := s.authService.ListInviteCodes()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	if len(invites) == 0 {
+		fmt.Println("No invite codes")
+		return
+	}
+
+	for _, invite := range invites {
+		expires := "never"
+		if invite.ExpiresAt.Valid {
+			expires = invite.ExpiresAt.Time.Format("2006-01-02 15:04")
+		}
+		fmt.Printf("  %s  used %d/%d  expires %s\n", invite.Code, invite.Uses, invite.MaxUses, expires)
+	}
+}
+
+func (s *Server) consoleAdmin(args []string) {
+	result, err := s.runAdmin("console", "", args)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Println(result)
+}
+
+func (s *Server) consoleSetAdmin(args []string, isAdmin bool) {
+	if len(args) != 1 {
+		fmt.Println("Usage: promote|demote <user>")
+		return
+	}
+
+	user, err := s.db.GetUserByUsername(args[0])
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	if err := s.authService.SetAdmin("console", user.ID, isAdmin); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	if isAdmin {
+		fmt.Printf("%s is now an admin\n", user.Username)
+	} else {
+		fmt.Printf("%s is no longer an admin\n", user.Username)
+	}
+}
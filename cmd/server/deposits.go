@@ -0,0 +1,42 @@
+package main
+
+import "fmt"
+
+// DEPOSIT <amount> adds play money to the balance
+func (s *Server) handleDeposit(client *ClientState, args []string) {
+	s.moveFunds(client, args, "DEPOSIT", "Deposited", s.authService.Deposit)
+}
+
+// WITHDRAW <amount> takes play money off the balance
+func (s *Server) handleWithdraw(client *ClientState, args []string) {
+	s.moveFunds(client, args, "WITHDRAW", "Withdrew", s.authService.Withdraw)
+}
+
+// Moves an amount in or out of the balance under the player's lock, so it
+// can't race a bet being checked against the balance
+func (s *Server) moveFunds(client *ClientState, args []string, command, done string, move func(int, int64) (int64, error)) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+	if len(args) != 1 {
+		s.writeError(client, "USAGE", fmt.Sprintf("Usage: %s <amount>", command))
+		return
+	}
+	amount, err := parseDollars(args[0])
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	defer s.userLocks.lock(client.user.ID)()
+	balance, err := move(client.user.ID, amount)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	client.user.Balance = balance
+	s.log.vault.Info("Balance moved", "command", command, "user", client.user.Username, "amount", amount, "balance", balance)
+	s.writeData(client, fmt.Sprintf("OK %s $%.2f. New balance: $%.2f", done, float64(amount)/100, float64(balance)/100), balanceData(balance))
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+	"github.com/alessandrosisniegas/casino/core/security"
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// A round of an engine game in play on a connection and the escrow of its
+// stake
+type engineRound struct {
+	info   game.EngineInfo
+	engine game.Engine
+	hold   int64
+}
+
+// GAMES lists the games PLAY offers
+func (s *Server) handleGames(client *ClientState, _ []string) {
+	var b strings.Builder
+	b.WriteString("OK Games:\n")
+	fmt.Fprintf(&b, "  %-10s Blackjack: BET <amount> for a solo hand, or JOIN a table\n", "blackjack")
+	for _, info := range game.Engines() {
+		fmt.Fprintf(&b, "  %-10s %s: %s\n", info.Name, info.Title, info.About)
+	}
+	b.WriteString("Use PLAY <game> <amount> to start a round, then PLAY <game> <action> to play it")
+	s.writeResponse(client, b.String())
+}
+
+// PLAY <game> <amount> starts a round of a game, PLAY <game> <action> [args]
+// plays it and PLAY <game> alone shows it
+func (s *Server) handlePlay(client *ClientState, args []string) {
+	if len(args) == 0 {
+		s.writeError(client, "USAGE", "Usage: PLAY <game> <amount> | PLAY <game> <action> [args] | PLAY <game> (see GAMES)")
+		return
+	}
+	s.playEngine(client, args[0], args[1:])
+}
+
+// Plays a command for an engine game: an amount starts a round, anything
+// else is an action on the round in play, and nothing shows it
+func (s *Server) playEngine(client *ClientState, name string, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+	info, ok := game.LookupEngine(name)
+	if !ok {
+		s.writeResponse(client, fmt.Sprintf("ERROR Unknown game %q. Use GAMES to list them", name))
+		return
+	}
+	r := client.rounds[info.Name]
+
+	if len(args) == 0 {
+		if r == nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR No %s round in play. Use PLAY %s <amount> to start one", info.Title, info.Name))
+			return
+		}
+		s.writeData(client, fmt.Sprintf("OK %s\n%s", info.Title, r.engine.State()), engineData(r))
+		return
+	}
+
+	if _, err := strconv.ParseFloat(args[0], 64); err == nil {
+		if len(args) != 1 {
+			s.writeError(client, "USAGE", fmt.Sprintf("Usage: PLAY %s <amount>", info.Name))
+			return
+		}
+		if r != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR Finish your %s round first (%s)", info.Title, strings.Join(r.engine.ValidActions(), ", ")))
+			return
+		}
+		if r, ok = s.startEngine(client, info, args[0]); ok {
+			s.writeData(client, fmt.Sprintf("OK %s for $%.2f\n%s", info.Title, float64(r.engine.Wagered())/100, r.engine.State()), engineData(r))
+		}
+		return
+	}
+
+	if r == nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR No %s round in play. Use PLAY %s <amount> to start one", info.Title, info.Name))
+		return
+	}
+	if s.actEngine(client, r, args[0], args[1:]) {
+		s.writeData(client, "OK\n"+r.engine.State(), engineData(r))
+	}
+}
+
+// Starts a round and takes an action on it in one command, for the games
+// with a command of their own that deals and settles at once
+func (s *Server) playOnce(client *ClientState, name, amount, action string, args []string) {
+	info, _ := game.LookupEngine(name)
+	if r := client.rounds[info.Name]; r != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR Finish your %s round first (%s)", info.Title, strings.Join(r.engine.ValidActions(), ", ")))
+		return
+	}
+	r, ok := s.startEngine(client, info, amount)
+	if ok && s.actEngine(client, r, action, args) {
+		s.writeData(client, "OK "+r.engine.State(), engineData(r))
+	}
+}
+
+// Starts a round for a bet, settling it at once if the deal ends it
+func (s *Server) startEngine(client *ClientState, info game.EngineInfo, amount string) (*engineRound, bool) {
+	r, ok := s.openEngine(client, info, amount)
+	if ok && game.RoundOver(r.engine) && !s.settleEngine(client, r) {
+		s.writeResponse(client, "ERROR Failed to pay out the round; your bet will be refunded")
+		return nil, false
+	}
+	return r, ok
+}
+
+// Starts a round and takes its stake into escrow under the player's lock
+func (s *Server) openEngine(client *ClientState, info game.EngineInfo, amount string) (*engineRound, bool) {
+	defer s.userLocks.lock(client.user.ID)()
+
+	bet, _, ok := s.checkBet(client, []string{amount}, s.tableRules(client))
+	if !ok {
+		return nil, false
+	}
+
+	e := info.New()
+	if err := e.StartRound(bet); err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return nil, false
+	}
+	// Some games stake more than the bet from the start, like a blind
+	wagered := e.Wagered()
+	if wagered > bet {
+		if client.user.Balance < wagered {
+			s.writeError(client, "INSUFFICIENT_FUNDS", fmt.Sprintf("Insufficient balance. A $%.2f bet stakes $%.2f in %s. You have $%.2f%s",
+				float64(bet)/100, float64(wagered)/100, info.Title, float64(client.user.Balance)/100, s.rebuyHint(client.user)))
+			return nil, false
+		}
+		if err := s.authService.CheckBetLimits(client.user.ID, wagered); err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return nil, false
+		}
+	}
+
+	hold, newBalance, err := s.authService.HoldBet(client.user.ID, info.Name, client.table.ID, wagered)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR Failed to place bet: %s", err.Error()))
+		return nil, false
+	}
+	client.user.Balance = newBalance
+
+	r := &engineRound{info: info, engine: e, hold: hold}
+	if client.rounds == nil {
+		client.rounds = map[string]*engineRound{}
+	}
+	client.rounds[info.Name] = r
+	return r, true
+}
+
+// Takes an action on a round, moving any extra stake it adds into escrow, and
+// settles the round once it's over
+func (s *Server) actEngine(client *ClientState, r *engineRound, action string, args []string) bool {
+	extra, err := r.engine.ActionStake(action, args)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return false
+	}
+	if extra > 0 && !s.raiseEngine(client, r, extra) {
+		return false
+	}
+
+	if err := r.engine.Action(action, args); err != nil {
+		if extra > 0 {
+			if refunded, rerr := s.authService.AdjustHold(r.hold, -extra); rerr != nil {
+				s.log.game.Error("Failed to refund raise", "game", r.info.Name, "user", client.user.Username, "amount", extra, "err", rerr)
+			} else {
+				client.user.Balance = refunded
+			}
+		}
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return false
+	}
+
+	if game.RoundOver(r.engine) && !s.settleEngine(client, r) {
+		s.writeResponse(client, "ERROR Failed to pay out the round; your bet will be refunded")
+		return false
+	}
+	return true
+}
+
+// Checks and moves the extra stake of an action into the round's escrow
+// under the player's lock
+func (s *Server) raiseEngine(client *ClientState, r *engineRound, extra int64) bool {
+	defer s.userLocks.lock(client.user.ID)()
+
+	if client.user.Balance < extra {
+		s.writeError(client, "INSUFFICIENT_FUNDS", fmt.Sprintf("Insufficient balance. You need $%.2f more", float64(extra-client.user.Balance)/100))
+		return false
+	}
+	if err := s.authService.CheckBetLimits(client.user.ID, extra); err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return false
+	}
+
+	newBalance, err := s.authService.AdjustHold(r.hold, extra)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR Failed to update balance: %s", err.Error()))
+		return false
+	}
+	client.user.Balance = newBalance
+	return true
+}
+
+// Pays out a finished round from escrow and awards it
+func (s *Server) settleEngine(client *ClientState, r *engineRound) bool {
+	delete(client.rounds, r.info.Name)
+	wagered, payout := r.engine.Wagered(), r.engine.Payout()
+	if !s.payHold(client, r.info.Name, r.hold, wagered, payout, r.engine.ExpectedRTP(), r.engine.Hands(), true) {
+		return false
+	}
+	s.rewardRound(client, security.RoundResult{Game: r.info.Name, Wagered: wagered, Payout: payout, Won: payout > wagered})
+	return true
+}
+
+// Closes the rounds left in play when their player logs out or disconnects,
+// as each game's rules say, and pays them out. Like an abandoned blackjack
+// hand they earn nothing else, and this doesn't take the player's lock since
+// it can run under it.
+func (s *Server) closeEngines(client *ClientState) {
+	for name, r := range client.rounds {
+		delete(client.rounds, name)
+		r.engine.Abandon()
+		s.log.game.Info("Closed round left in play", "game", name, "user", client.user.Username,
+			"bet", r.engine.Wagered(), "payout", r.engine.Payout())
+		s.payHold(client, name, r.hold, r.engine.Wagered(), r.engine.Payout(), r.engine.ExpectedRTP(), r.engine.Hands(), false)
+	}
+}
+
+// Settles the escrow of a round into the history with the hands it ended on,
+// saving the player's stats with it when withStats is set (which takes their
+// lock). A failed payout stays in escrow, to be refunded at the next start.
+func (s *Server) payHold(client *ClientState, gameName string, hold, wagered, payout int64, rtp float64, hands string, withStats bool) bool {
+	var stats *vault.UserStats
+	if withStats {
+		defer s.userLocks.lock(client.user.ID)()
+		var err error
+		if stats, err = s.authService.GetUserStats(client.user.ID); err != nil {
+			s.log.vault.Error("Failed to get user stats", "user", client.user.Username, "err", err)
+			stats = nil
+		} else {
+			recordPayoutStats(stats, wagered, payout)
+		}
+	}
+
+	newBalance, err := s.authService.SettleHold(hold, payout, payoutResult(wagered, payout), hands, stats)
+	if err != nil {
+		s.log.game.Error("Failed to settle game", "game", gameName, "user", client.user.Username, "bet", wagered, "payout", payout, "err", err)
+		return false
+	}
+	client.user.Balance = newBalance
+	s.stats.wagered.Add(wagered)
+	s.stats.paid.Add(payout)
+	if !client.user.IsGuest {
+		s.recordRTP(gameName, rtp, wagered, payout)
+	}
+	s.checkBalanceAlert(client.user.Username, newBalance-payout, newBalance)
+	return true
+}
+
+// Names a settled round for the history by what it paid against the wager
+func payoutResult(wagered, payout int64) string {
+	switch {
+	case payout > wagered:
+		return "Win"
+	case payout == wagered:
+		return "Push"
+	default:
+		return "Loss"
+	}
+}
+
+// Adds a settled round of a game without blackjack's results to the
+// player's stats, judged by what it paid against the wager
+func recordPayoutStats(stats *vault.UserStats, wagered, payout int64) {
+	stats.GamesPlayed++
+	stats.TotalBet += wagered
+	stats.TotalWon += payout
+
+	switch {
+	case payout > wagered:
+		stats.GamesWon++
+		stats.RecordWin()
+		if payout-wagered > stats.BiggestWin {
+			stats.BiggestWin = payout - wagered
+		}
+	case payout < wagered:
+		stats.GamesLost++
+		stats.RecordLoss()
+		if wagered-payout > stats.BiggestLoss {
+			stats.BiggestLoss = wagered - payout
+		}
+	}
+}
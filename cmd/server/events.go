@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/security"
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+const eventUsage = "ADMIN EVENT CREATE <xp|points|blackjack> <multiplier> <hours> <now|YYYY-MM-DDTHH:MM> <name> | ADMIN EVENT LIST | ADMIN EVENT CANCEL <id>"
+
+// EVENTS lists running and upcoming events
+func (s *Server) handleEvents(client *ClientState, _ []string) {
+	events, err := s.authService.ListEvents()
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+	if len(events) == 0 {
+		s.writeResponse(client, "OK No events running or scheduled")
+		return
+	}
+
+	s.writeResponse(client, "OK Events:"+formatEvents(events, false))
+}
+
+func (s *Server) adminEvent(actor string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", usageErrorf("Usage: %s", eventUsage)
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "CREATE":
+		if len(args) < 6 {
+			return "", usageErrorf("Usage: ADMIN EVENT CREATE <xp|points|blackjack> <multiplier> <hours> <now|YYYY-MM-DDTHH:MM> <name>")
+		}
+
+		multiplier, err := parseMultiplier(args[2])
+		if err != nil {
+			return "", err
+		}
+
+		hours, err := strconv.Atoi(args[3])
+		if err != nil || hours <= 0 {
+			return "", fmt.Errorf("hours must be a positive number")
+		}
+
+		start := time.Now()
+		if !strings.EqualFold(args[4], "now") {
+			if start, err = time.Parse("2006-01-02T15:04", args[4]); err != nil {
+				return "", fmt.Errorf("start must be now or a UTC time like 2006-01-02T15:04")
+			}
+		}
+
+		event, err := s.authService.CreateEvent(actor, strings.Join(args[5:], " "), args[1], multiplier, start, time.Duration(hours)*time.Hour)
+		if err != nil {
+			return "", err
+		}
+
+		// Announce a start of "now" right away rather than on the next tick
+		go s.announceEvents()
+
+		return fmt.Sprintf("Scheduled event #%d %s: %s from %s to %s", event.ID, event.Name, eventBoost(event),
+			event.StartsAt.Local().Format("2006-01-02 15:04"), event.EndsAt.Local().Format("2006-01-02 15:04")), nil
+
+	case "LIST":
+		events, err := s.authService.ListEvents()
+		if err != nil {
+			return "", err
+		}
+		if len(events) == 0 {
+			return "No events running or scheduled", nil
+		}
+		return "Events:" + formatEvents(events, true), nil
+
+	case "CANCEL":
+		if len(args) != 2 {
+			return "", usageErrorf("Usage: ADMIN EVENT CANCEL <id>")
+		}
+		id, err := strconv.ParseInt(strings.TrimPrefix(args[1], "#"), 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid event id")
+		}
+		if err := s.authService.CancelEvent(actor, id); err != nil {
+			return "", err
+		}
+
+		go s.announceEvents()
+
+		return fmt.Sprintf("Cancelled event #%d", id), nil
+
+	default:
+		return "", usageErrorf("Usage: %s", eventUsage)
+	}
+}
+
+func formatEvents(events []*vault.Event, withAdmin bool) string {
+	now := time.Now()
+	report := ""
+	for _, e := range events {
+		when := "starts " + e.StartsAt.Local().Format("2006-01-02 15:04")
+		if e.ActiveAt(now) {
+			when = "running"
+		}
+		report += fmt.Sprintf("\n  %-20s %-26s %s, ends %s", e.Name, eventBoost(e),
+			when, e.EndsAt.Local().Format("2006-01-02 15:04"))
+		if withAdmin {
+			report += fmt.Sprintf("  #%d by %s", e.ID, e.CreatedBy)
+		}
+	}
+	return report
+}
+
+// Describes what an event boosts, e.g. "2x XP"
+func eventBoost(e *vault.Event) string {
+	multiplier := strconv.FormatFloat(float64(e.Multiplier)/100, 'f', -1, 64) + "x"
+	switch e.Kind {
+	case security.EventXP:
+		return multiplier + " XP"
+	case security.EventPoints:
+		return multiplier + " comp points"
+	case security.EventBlackjack:
+		return multiplier + " blackjack winnings"
+	default:
+		return multiplier + " " + e.Kind
+	}
+}
+
+// Parses a multiplier like 2, 1.5 or 2x into a percentage
+func parseMultiplier(arg string) (int, error) {
+	value, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(arg), "x"), 64)
+	if err != nil || !(value > 0) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("invalid multiplier")
+	}
+	return int(math.Round(value * 100)), nil
+}
+
+// Pays the blackjack event's extra share of a natural's winnings
+func (s *Server) awardEventBonus(client *ClientState, winnings int64) {
+	if !s.featureOn(security.FeatureEvents) {
+		return
+	}
+	bonus, event, balance, err := s.authService.AwardEventBlackjackBonus(client.user.ID, winnings)
+	if err != nil {
+		s.log.game.Error("Failed to award event bonus", "user", client.user.Username, "err", err)
+		return
+	}
+	if bonus == 0 {
+		return
+	}
+
+	client.user.Balance = balance
+	s.pushEvent(client, "ANNOUNCE", fmt.Sprintf("%s: blackjack bonus of $%.2f credited. New balance: $%.2f",
+		event.Name, float64(bonus)/100, float64(balance)/100))
+}
+
+// Tells every connected player about events that just started or ended
+func (s *Server) announceEvents() {
+	started, ended, err := s.authService.DueEventAnnouncements(time.Now())
+	if err != nil {
+		s.log.vault.Error("Failed to check event announcements", "err", err)
+	}
+
+	var messages []string
+	for _, e := range started {
+		messages = append(messages, fmt.Sprintf("%s has started: %s until %s!", e.Name, eventBoost(e),
+			e.EndsAt.Local().Format("2006-01-02 15:04")))
+	}
+	for _, e := range ended {
+		messages = append(messages, fmt.Sprintf("%s has ended. Thanks for playing!", e.Name))
+	}
+
+	for _, c := range s.hub.clients() {
+		for _, m := range messages {
+			s.pushEvent(c, "ANNOUNCE", m)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+const exportUsage = "ADMIN EXPORT AUDIT|LEDGER <from> <to> [user] [csv|json]"
+
+// Most rows ADMIN EXPORT sends over a connection; the export subcommand has
+// no limit
+const maxExportRows = 10000
+
+// An export of audit events or ledger entries made in [from, to)
+type exportRequest struct {
+	kind     string // "audit" or "ledger"
+	from, to time.Time
+	user     *vault.User // nil for every user
+	format   string      // "csv" or "json"
+}
+
+// Describes the export for its reply and the audit log
+func (req *exportRequest) String() string {
+	who := "all users"
+	if req.user != nil {
+		who = req.user.Username
+	}
+	return fmt.Sprintf("%s for %s from %s until %s as %s", req.kind, who,
+		req.from.Format("2006-01-02 15:04"), req.to.Format("2006-01-02 15:04"), req.format)
+}
+
+// Parses "audit|ledger <from> <to> [user] [csv|json]". Times are UTC, as
+// 2006-01-02 or 2006-01-02T15:04; a date alone as the end includes that day.
+func (s *Server) parseExport(args []string) (*exportRequest, error) {
+	if len(args) < 3 || len(args) > 5 {
+		return nil, usageErrorf("Usage: %s", exportUsage)
+	}
+
+	req := &exportRequest{kind: strings.ToLower(args[0]), format: "csv"}
+	if req.kind != "audit" && req.kind != "ledger" {
+		return nil, usageErrorf("Usage: %s", exportUsage)
+	}
+
+	var err error
+	if req.from, _, err = parseExportTime(args[1]); err != nil {
+		return nil, err
+	}
+	to, dateOnly, err := parseExportTime(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if dateOnly {
+		to = to.AddDate(0, 0, 1)
+	}
+	if !to.After(req.from) {
+		return nil, fmt.Errorf("the end of the range must be after its start")
+	}
+	req.to = to
+
+	rest := args[3:]
+	if n := len(rest); n > 0 && (strings.EqualFold(rest[n-1], "csv") || strings.EqualFold(rest[n-1], "json")) {
+		req.format, rest = strings.ToLower(rest[n-1]), rest[:n-1]
+	}
+	switch len(rest) {
+	case 0:
+	case 1:
+		if req.user, err = s.db.GetUserByUsername(rest[0]); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, usageErrorf("Usage: %s", exportUsage)
+	}
+
+	return req, nil
+}
+
+// Parses a UTC date or date and time, reporting whether it was a date alone
+func parseExportTime(arg string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", arg); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.Parse("2006-01-02T15:04", arg); err == nil {
+		return t, false, nil
+	}
+	return time.Time{}, false, fmt.Errorf("times must be UTC, like 2006-01-02 or 2006-01-02T15:04")
+}
+
+// Exports for ADMIN EXPORT: the rows follow a summary line, and ranges
+// holding more than maxExportRows are cut short
+func (s *Server) adminExport(actor, ip string, args []string) (string, error) {
+	req, err := s.parseExport(args)
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	rows, truncated, err := s.writeExport(&out, req, maxExportRows)
+	if err != nil {
+		return "", err
+	}
+	s.logExport(actor, ip, req, rows)
+
+	summary := fmt.Sprintf("Exported %d rows of %s", rows, req)
+	if truncated {
+		summary += fmt.Sprintf(" (the first %d; run the server's export command for all of them)", maxExportRows)
+	}
+	return summary + "\n" + strings.TrimSuffix(out.String(), "\n"), nil
+}
+
+// Runs "export audit|ledger <from> <to> [user] [csv|json]" from the command
+// line, writing every row to stdout
+func (s *Server) exportCommand(args []string) error {
+	req, err := s.parseExport(args)
+	if err != nil {
+		return errors.New(strings.Replace(err.Error(), "ADMIN EXPORT AUDIT|LEDGER", "export audit|ledger", 1))
+	}
+
+	rows, _, err := s.writeExport(os.Stdout, req, 0)
+	if err != nil {
+		return err
+	}
+	s.logExport("command line", "", req, rows)
+	return nil
+}
+
+func (s *Server) logExport(actor, ip string, req *exportRequest, rows int) {
+	userID := 0
+	if req.user != nil {
+		userID = req.user.ID
+	}
+	s.authService.LogExport(actor, userID, fmt.Sprintf("%d rows of %s", rows, req), ip)
+	s.log.server.Info("Exported records", "actor", actor, "kind", req.kind, "rows", rows)
+}
+
+// An audit event as exported
+type auditRecord struct {
+	ID       int64  `json:"id"`
+	Time     string `json:"time"`
+	UserID   int    `json:"user_id,omitempty"`
+	Username string `json:"username,omitempty"`
+	Type     string `json:"type"`
+	IP       string `json:"ip,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+var auditHeader = []string{"id", "time", "user_id", "username", "type", "ip", "detail"}
+
+func (r auditRecord) fields() []string {
+	return []string{fmt.Sprint(r.ID), r.Time, fmt.Sprint(r.UserID), r.Username, r.Type, r.IP, r.Detail}
+}
+
+// A ledger entry as exported, with amounts in dollars
+type ledgerRecord struct {
+	ID           int64       `json:"id"`
+	Time         string      `json:"time"`
+	UserID       int         `json:"user_id"`
+	Username     string      `json:"username,omitempty"`
+	Type         string      `json:"type"`
+	Game         string      `json:"game,omitempty"`
+	Amount       json.Number `json:"amount"`
+	BalanceAfter json.Number `json:"balance_after"`
+	Metadata     string      `json:"metadata,omitempty"`
+}
+
+var ledgerHeader = []string{"id", "time", "user_id", "username", "type", "game", "amount", "balance_after", "metadata"}
+
+func (r ledgerRecord) fields() []string {
+	return []string{fmt.Sprint(r.ID), r.Time, fmt.Sprint(r.UserID), r.Username, r.Type, r.Game, r.Amount.String(), r.BalanceAfter.String(), r.Metadata}
+}
+
+// Writes the requested rows, oldest first, and returns how many were written.
+// A positive limit stops after that many and reports whether any were left out.
+func (s *Server) writeExport(w io.Writer, req *exportRequest, limit int) (int, bool, error) {
+	userID, query := 0, limit
+	if req.user != nil {
+		userID = req.user.ID
+	}
+	if limit > 0 {
+		query = limit + 1
+	}
+
+	names := make(map[int]string)
+	username := func(id int) string {
+		if id == 0 {
+			return ""
+		}
+		if _, ok := names[id]; !ok {
+			// Deleted guests no longer have a name
+			if user, err := s.db.GetUserByID(id); err == nil {
+				names[id] = user.Username
+			}
+		}
+		return names[id]
+	}
+	stamp := func(t time.Time) string { return t.UTC().Format(time.RFC3339) }
+	dollars := func(cents int64) json.Number { return json.Number(fmt.Sprintf("%.2f", float64(cents)/100)) }
+
+	var header []string
+	var records []interface{ fields() []string }
+	switch req.kind {
+	case "audit":
+		events, err := s.db.AuditEventsBetween(userID, req.from, req.to, query)
+		if err != nil {
+			return 0, false, err
+		}
+		header = auditHeader
+		for _, e := range events {
+			records = append(records, auditRecord{e.ID, stamp(e.CreatedAt), e.UserID, username(e.UserID), e.Type, e.IP, e.Detail})
+		}
+	default:
+		txs, err := s.db.TransactionsBetween(userID, req.from, req.to, query)
+		if err != nil {
+			return 0, false, err
+		}
+		header = ledgerHeader
+		for _, t := range txs {
+			records = append(records, ledgerRecord{t.ID, stamp(t.CreatedAt), t.UserID, username(t.UserID), t.Type, t.GameID,
+				dollars(t.Amount), dollars(t.BalanceAfter), t.Metadata})
+		}
+	}
+
+	truncated := limit > 0 && len(records) > limit
+	if truncated {
+		records = records[:limit]
+	}
+
+	if req.format == "json" {
+		// An array with one record per line
+		if _, err := io.WriteString(w, "["); err != nil {
+			return 0, false, err
+		}
+		for i, r := range records {
+			line, err := json.Marshal(r)
+			if err != nil {
+				return 0, false, err
+			}
+			sep := ",\n"
+			if i == 0 {
+				sep = "\n"
+			}
+			if _, err := io.WriteString(w, sep+string(line)); err != nil {
+				return 0, false, err
+			}
+		}
+		_, err := io.WriteString(w, "\n]\n")
+		return len(records), truncated, err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	for _, r := range records {
+		cw.Write(r.fields())
+	}
+	cw.Flush()
+	return len(records), truncated, cw.Error()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/security"
+)
+
+// Commands that can't be used while their feature is switched off. Transfers
+// are refused by the auth service itself.
+var featureCommands = map[string]string{
+	"SIT":      security.FeatureTables,
+	"JOIN":     security.FeatureTables,
+	"HOST":     security.FeatureTables,
+	"CASHBACK": security.FeatureCashback,
+	"DAILY":    security.FeatureDaily,
+	"REBUY":    security.FeatureRebuy,
+	"REDEEM":   security.FeaturePromos,
+	"BUY":      security.FeatureShop,
+	"REFER":    security.FeatureReferrals,
+	"REFERRAL": security.FeatureReferrals,
+}
+
+// Reports whether a feature is on, treating a failure to tell as off
+func (s *Server) featureOn(name string) bool {
+	enabled, err := s.authService.FeatureEnabled(name)
+	if err != nil {
+		s.log.vault.Error("Failed to read feature flag", "feature", name, "err", err)
+		return false
+	}
+	return enabled
+}
+
+// Writes an error and returns false when the command's feature is switched off
+func (s *Server) requireFeature(client *ClientState, command string) bool {
+	name, ok := featureCommands[command]
+	if !ok || s.featureOn(name) {
+		return true
+	}
+
+	s.writeError(client, "FEATURE_DISABLED", "This feature is switched off for now")
+	return false
+}
+
+// Lists every feature flag for ADMIN FEATURES
+func (s *Server) adminFeatures() (string, error) {
+	report := "Features:"
+	for _, f := range security.Features {
+		enabled, err := s.authService.FeatureEnabled(f.Name)
+		if err != nil {
+			return "", err
+		}
+		state := "off"
+		if enabled {
+			state = "on"
+		}
+		report += fmt.Sprintf("\n  %-10s %-3s  %s", f.Name, state, f.Description)
+	}
+	return report, nil
+}
+
+// ADMIN FEATURE <name> ON|OFF
+func (s *Server) adminFeature(actor string, args []string) (string, error) {
+	if len(args) != 2 || (!strings.EqualFold(args[1], "on") && !strings.EqualFold(args[1], "off")) {
+		return "", usageErrorf("Usage: ADMIN FEATURE <name> ON|OFF")
+	}
+
+	enabled := strings.EqualFold(args[1], "on")
+	if err := s.authService.SetFeature(actor, args[0], enabled); err != nil {
+		return "", err
+	}
+
+	name := strings.ToLower(args[0])
+	s.log.server.Info("Feature switched", "feature", name, "enabled", enabled, "actor", actor)
+	if enabled {
+		return fmt.Sprintf("Feature %s switched on", name), nil
+	}
+	return fmt.Sprintf("Feature %s switched off", name), nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+// FEED shows or changes how the player takes part in the big-win feed
+func (s *Server) handleFeed(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	usage := "Usage: FEED [ON|OFF] | FEED ANON ON|OFF"
+	switch {
+	case len(args) == 1 && isOnOff(args[0]):
+		if err := s.authService.SetFeedReceive(client.user.ID, strings.EqualFold(args[0], "on")); err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return
+		}
+	case len(args) == 2 && strings.EqualFold(args[0], "anon") && isOnOff(args[1]):
+		if err := s.authService.SetFeedAnonymous(client.user.ID, strings.EqualFold(args[1], "on")); err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return
+		}
+	case len(args) != 0:
+		s.writeError(client, "USAGE", usage)
+		return
+	}
+
+	settings, err := s.authService.GetFeedSettings(client.user.ID)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	receive := "You see other players' big wins (FEED OFF to hide them)"
+	if !settings.Receive {
+		receive = "Big wins are hidden (FEED ON to see them)"
+	}
+	shown := "your wins show your name (FEED ANON ON to hide it)"
+	if settings.Anonymous {
+		shown = "your wins show as \"A player\" (FEED ANON OFF to show your name)"
+	}
+	s.writeResponse(client, fmt.Sprintf("OK %s; %s", receive, shown))
+}
+
+func isOnOff(arg string) bool {
+	return strings.EqualFold(arg, "on") || strings.EqualFold(arg, "off")
+}
+
+// Tells everyone online about a win over BIG_WIN dollars or a blackjack on a
+// bet of at least BIG_WIN_BET, except players who turned the feed off
+func (s *Server) announceBigWin(client *ClientState, g *game.Game, payout int64) {
+	if s.config.BigWin <= 0 {
+		return
+	}
+
+	profit := payout - g.Bet
+	var message string
+	switch {
+	case profit >= int64(s.config.BigWin)*100:
+		message = fmt.Sprintf("just won $%.2f at blackjack!", float64(profit)/100)
+	case g.Result == game.ResultPlayerBlackjack && s.config.BigWinBet > 0 && g.Bet >= int64(s.config.BigWinBet)*100:
+		message = fmt.Sprintf("hit a blackjack on a $%.2f bet!", float64(g.Bet)/100)
+	default:
+		return
+	}
+
+	name := client.user.Username
+	if settings, err := s.authService.GetFeedSettings(client.user.ID); err != nil {
+		s.log.vault.Error("Failed to get feed settings", "user", name, "err", err)
+		return
+	} else if settings.Anonymous {
+		name = "A player"
+	}
+
+	for _, id := range s.hub.userIDs() {
+		if id == client.user.ID {
+			continue
+		}
+		if settings, err := s.authService.GetFeedSettings(id); err != nil || !settings.Receive {
+			continue
+		}
+		for _, c := range s.hub.clientsForUser(id, nil) {
+			s.pushEvent(c, "WIN", name+" "+message)
+		}
+	}
+}
@@ -0,0 +1,534 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/alessandrosisniegas/casino/casinopb"
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+// gRPC sessions untouched this long are closed, like idle connections
+const rpcIdleTimeout = 30 * time.Minute
+
+// Transactions ListTransactions returns when no limit is given, and the most
+// it returns
+const (
+	defaultRPCTransactions = 20
+	maxRPCTransactions     = 500
+)
+
+// Serves the gRPC API on addr, with the same certificate as the text
+// protocol when TLS is on
+func (s *Server) serveGRPC(addr string) {
+	var opts []grpc.ServerOption
+	tlsConfig, err := loadTLSConfig(s.config)
+	if err != nil {
+		s.log.server.Error("gRPC endpoint stopped", "addr", addr, "err", err)
+		return
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	srv := s.newGRPCServer(opts...)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		s.log.server.Error("gRPC endpoint stopped", "addr", addr, "err", err)
+		return
+	}
+	s.log.server.Info("Serving gRPC", "addr", addr, "tls", tlsConfig != nil)
+	if err := srv.Serve(ln); err != nil {
+		s.log.server.Error("gRPC endpoint stopped", "addr", addr, "err", err)
+	}
+}
+
+// A gRPC server with the casino's services registered
+func (s *Server) newGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	srv := grpc.NewServer(opts...)
+	casinopb.RegisterAuthServer(srv, &rpcAuth{s: s})
+	casinopb.RegisterWalletServer(srv, &rpcWallet{s: s})
+	casinopb.RegisterBlackjackServer(srv, &rpcBlackjack{s: s})
+	return srv
+}
+
+// Stands in for the network connection of a gRPC session, keeping what the
+// command handlers write so each call can read back its reply and events
+type rpcConn struct {
+	mu     sync.Mutex
+	out    bytes.Buffer
+	addr   net.Addr
+	closed bool
+}
+
+func (c *rpcConn) Read([]byte) (int, error) { return 0, io.EOF }
+
+func (c *rpcConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	return c.out.Write(p)
+}
+
+// Closing, e.g. by ADMIN KICK, ends the session at its next call
+func (c *rpcConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *rpcConn) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// Returns and forgets everything written so far
+func (c *rpcConn) take() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := c.out.String()
+	c.out.Reset()
+	return out
+}
+
+func (c *rpcConn) LocalAddr() net.Addr              { return c.addr }
+func (c *rpcConn) RemoteAddr() net.Addr             { return c.addr }
+func (c *rpcConn) SetDeadline(time.Time) error      { return nil }
+func (c *rpcConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *rpcConn) SetWriteDeadline(time.Time) error { return nil }
+
+// A logged in gRPC client, kept by its session token between calls
+type rpcSession struct {
+	client   *ClientState
+	conn     *rpcConn
+	lastUsed time.Time
+}
+
+type rpcSessions struct {
+	mu      sync.Mutex
+	byToken map[string]*rpcSession
+}
+
+func newRPCSessions() *rpcSessions {
+	return &rpcSessions{byToken: make(map[string]*rpcSession)}
+}
+
+// Opens a session for a call's peer. It shows in ADMIN CONNECTIONS like any
+// connection until it is closed.
+func (s *Server) newRPCSession(ctx context.Context) *rpcSession {
+	conn := &rpcConn{addr: &net.TCPAddr{}}
+	if p, ok := peer.FromContext(ctx); ok {
+		conn.addr = p.Addr
+	}
+	client := &ClientState{conn: conn, ip: remoteIP(conn), connectedAt: time.Now(), table: game.Tables[0]}
+	s.hub.add(client)
+	return &rpcSession{client: client, conn: conn, lastUsed: time.Now()}
+}
+
+// Finds the session for the call's bearer token, resuming it as RESUME would
+// when this server hasn't seen it yet
+func (s *Server) rpcSession(ctx context.Context) (*rpcSession, error) {
+	token := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get("authorization") {
+			token, _ = strings.CutPrefix(v, "Bearer ")
+		}
+	}
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated, "Missing authorization: Bearer <token>")
+	}
+
+	s.rpc.mu.Lock()
+	sess := s.rpc.byToken[token]
+	if sess != nil && sess.conn.isClosed() {
+		delete(s.rpc.byToken, token)
+		s.rpc.mu.Unlock()
+		s.closeRPCSession(sess)
+		return nil, status.Error(codes.Unauthenticated, "Session closed, please login again")
+	}
+	if sess != nil {
+		sess.lastUsed = time.Now()
+		s.rpc.mu.Unlock()
+		return sess, nil
+	}
+	s.rpc.mu.Unlock()
+
+	sess = s.newRPCSession(ctx)
+	sess.client.cmdMu.Lock()
+	_, err := s.rpcCommand(sess.client, codes.Unauthenticated, "RESUME", token)
+	sess.client.cmdMu.Unlock()
+	if err != nil {
+		s.closeRPCSession(sess)
+		return nil, err
+	}
+
+	s.rpc.mu.Lock()
+	defer s.rpc.mu.Unlock()
+	if existing := s.rpc.byToken[token]; existing != nil {
+		// Another call resumed it first
+		s.closeRPCSession(sess)
+		return existing, nil
+	}
+	s.rpc.byToken[token] = sess
+	return sess, nil
+}
+
+// Ends a session as a hang-up ends a connection: a hand in play stays saved
+// for the player's next login
+func (s *Server) closeRPCSession(sess *rpcSession) {
+	client := sess.client
+	client.cmdMu.Lock()
+	defer client.cmdMu.Unlock()
+	s.shelveGame(client)
+	s.closeEngines(client)
+	s.hub.remove(client)
+	sess.conn.Close()
+}
+
+// Closes gRPC sessions idle past rpcIdleTimeout. Runs hourly.
+func (s *Server) pruneRPCSessions() {
+	var idle []*rpcSession
+	s.rpc.mu.Lock()
+	for token, sess := range s.rpc.byToken {
+		if time.Since(sess.lastUsed) > rpcIdleTimeout || sess.conn.isClosed() {
+			delete(s.rpc.byToken, token)
+			idle = append(idle, sess)
+		}
+	}
+	s.rpc.mu.Unlock()
+
+	for _, sess := range idle {
+		s.closeRPCSession(sess)
+	}
+}
+
+// Runs a text protocol command for a gRPC client through handleCommand, so
+// it gets the same checks, and returns the events it pushed. An ERROR reply
+// becomes a status with code, or Unauthenticated when the command logged the
+// client out. Must be called with the client's cmdMu held.
+func (s *Server) rpcCommand(client *ClientState, code codes.Code, command string, args ...string) ([]*casinopb.Event, error) {
+	conn := client.conn.(*rpcConn)
+	conn.take()
+	loggedIn := client.user != nil
+	s.stats.countCommand()
+	s.handleCommand(client, command, args)
+
+	var events []*casinopb.Event
+	var failure string
+	for _, line := range strings.Split(conn.take(), "\n") {
+		if event, ok := strings.CutPrefix(line, "EVENT "); ok {
+			kind, message, _ := strings.Cut(event, " ")
+			events = append(events, &casinopb.Event{Type: kind, Message: message})
+		} else if msg, ok := strings.CutPrefix(line, "ERROR "); ok && failure == "" {
+			failure = msg
+		}
+	}
+	if failure != "" {
+		if loggedIn && client.user == nil {
+			code = codes.Unauthenticated
+		}
+		if strings.HasPrefix(failure, "RATE_LIMITED ") {
+			code = codes.ResourceExhausted
+		}
+		return events, status.Error(code, failure)
+	}
+	return events, nil
+}
+
+// Runs fn on the call's session with its commands serialized like a
+// connection's
+func (s *Server) withRPCSession(ctx context.Context, fn func(client *ClientState) error) error {
+	sess, err := s.rpcSession(ctx)
+	if err != nil {
+		return err
+	}
+	sess.client.cmdMu.Lock()
+	defer sess.client.cmdMu.Unlock()
+	return fn(sess.client)
+}
+
+func rpcUser(client *ClientState) *casinopb.User {
+	return &casinopb.User{Id: int64(client.user.ID), Username: client.user.Username, Balance: client.user.Balance}
+}
+
+// Formats cents as the dollar amount text commands take
+func rpcDollars(cents int64) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
+
+type rpcAuth struct {
+	casinopb.UnimplementedAuthServer
+	s *Server
+}
+
+func (a *rpcAuth) Signup(ctx context.Context, req *casinopb.SignupRequest) (*casinopb.SignupResponse, error) {
+	sess := a.s.newRPCSession(ctx)
+	defer a.s.closeRPCSession(sess)
+
+	args := []string{req.Username, req.Password}
+	if req.InviteCode != "" {
+		args = append(args, req.InviteCode)
+	}
+	sess.client.cmdMu.Lock()
+	sess.client.referralCode = req.ReferralCode
+	_, err := a.s.rpcCommand(sess.client, codes.InvalidArgument, "SIGNUP", args...)
+	sess.client.cmdMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.s.db.GetUserByUsername(req.Username)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &casinopb.SignupResponse{User: &casinopb.User{Id: int64(user.ID), Username: user.Username, Balance: user.Balance}}, nil
+}
+
+func (a *rpcAuth) Login(ctx context.Context, req *casinopb.LoginRequest) (*casinopb.LoginResponse, error) {
+	sess := a.s.newRPCSession(ctx)
+	sess.client.cmdMu.Lock()
+	_, err := a.s.rpcCommand(sess.client, codes.Unauthenticated, "LOGIN", req.Username, req.Password)
+	sess.client.cmdMu.Unlock()
+	if err != nil {
+		a.s.closeRPCSession(sess)
+		return nil, err
+	}
+
+	token := sess.client.sessionID
+	a.s.rpc.mu.Lock()
+	a.s.rpc.byToken[token] = sess
+	a.s.rpc.mu.Unlock()
+	return &casinopb.LoginResponse{Token: token, User: rpcUser(sess.client)}, nil
+}
+
+func (a *rpcAuth) Logout(ctx context.Context, _ *casinopb.LogoutRequest) (*casinopb.LogoutResponse, error) {
+	sess, err := a.s.rpcSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token := sess.client.sessionID
+
+	sess.client.cmdMu.Lock()
+	_, err = a.s.rpcCommand(sess.client, codes.FailedPrecondition, "LOGOUT")
+	sess.client.cmdMu.Unlock()
+
+	a.s.rpc.mu.Lock()
+	delete(a.s.rpc.byToken, token)
+	a.s.rpc.mu.Unlock()
+	a.s.closeRPCSession(sess)
+	if err != nil {
+		return nil, err
+	}
+	return &casinopb.LogoutResponse{}, nil
+}
+
+type rpcWallet struct {
+	casinopb.UnimplementedWalletServer
+	s *Server
+}
+
+func (w *rpcWallet) GetBalance(ctx context.Context, _ *casinopb.GetBalanceRequest) (*casinopb.Balance, error) {
+	var balance *casinopb.Balance
+	err := w.s.withRPCSession(ctx, func(client *ClientState) error {
+		if _, err := w.s.rpcCommand(client, codes.FailedPrecondition, "BALANCE"); err != nil {
+			return err
+		}
+		balance = &casinopb.Balance{Balance: client.user.Balance}
+		return nil
+	})
+	return balance, err
+}
+
+func (w *rpcWallet) Deposit(ctx context.Context, req *casinopb.AmountRequest) (*casinopb.Balance, error) {
+	return w.move(ctx, "DEPOSIT", req.Amount)
+}
+
+func (w *rpcWallet) Withdraw(ctx context.Context, req *casinopb.AmountRequest) (*casinopb.Balance, error) {
+	return w.move(ctx, "WITHDRAW", req.Amount)
+}
+
+func (w *rpcWallet) move(ctx context.Context, command string, amount int64) (*casinopb.Balance, error) {
+	if amount <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "Amount must be positive")
+	}
+	var balance *casinopb.Balance
+	err := w.s.withRPCSession(ctx, func(client *ClientState) error {
+		if _, err := w.s.rpcCommand(client, codes.FailedPrecondition, command, rpcDollars(amount)); err != nil {
+			return err
+		}
+		balance = &casinopb.Balance{Balance: client.user.Balance}
+		return nil
+	})
+	return balance, err
+}
+
+func (w *rpcWallet) ListTransactions(ctx context.Context, req *casinopb.ListTransactionsRequest) (*casinopb.ListTransactionsResponse, error) {
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultRPCTransactions
+	}
+	limit = min(limit, maxRPCTransactions)
+
+	var resp casinopb.ListTransactionsResponse
+	err := w.s.withRPCSession(ctx, func(client *ClientState) error {
+		if _, err := w.s.refreshUser(client); err != nil {
+			return status.Error(codes.Unauthenticated, "Session expired, please login again")
+		}
+		txs, err := w.s.db.ListTransactions(client.user.ID, limit)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		for _, tx := range txs {
+			resp.Transactions = append(resp.Transactions, &casinopb.Transaction{
+				Id:           tx.ID,
+				Type:         tx.Type,
+				Amount:       tx.Amount,
+				BalanceAfter: tx.BalanceAfter,
+				GameId:       tx.GameID,
+				Metadata:     tx.Metadata,
+				CreatedAt:    timestamppb.New(tx.CreatedAt),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+type rpcBlackjack struct {
+	casinopb.UnimplementedBlackjackServer
+	s *Server
+}
+
+func (b *rpcBlackjack) Deal(ctx context.Context, req *casinopb.DealRequest) (*casinopb.GameState, error) {
+	if req.Bet <= 0 || req.PerfectPairs < 0 || req.TwentyOnePlusThree < 0 {
+		return nil, status.Error(codes.InvalidArgument, "Bets must be positive")
+	}
+	args := []string{rpcDollars(req.Bet)}
+	if req.PerfectPairs > 0 {
+		args = append(args, "PP", rpcDollars(req.PerfectPairs))
+	}
+	if req.TwentyOnePlusThree > 0 {
+		args = append(args, "21+3", rpcDollars(req.TwentyOnePlusThree))
+	}
+	return b.play(ctx, "BET", args...)
+}
+
+func (b *rpcBlackjack) Hit(ctx context.Context, _ *casinopb.ActionRequest) (*casinopb.GameState, error) {
+	return b.play(ctx, "HIT")
+}
+
+func (b *rpcBlackjack) Stand(ctx context.Context, _ *casinopb.ActionRequest) (*casinopb.GameState, error) {
+	return b.play(ctx, "STAND")
+}
+
+func (b *rpcBlackjack) DoubleDown(ctx context.Context, _ *casinopb.ActionRequest) (*casinopb.GameState, error) {
+	return b.play(ctx, "DOUBLEDOWN")
+}
+
+func (b *rpcBlackjack) Surrender(ctx context.Context, _ *casinopb.ActionRequest) (*casinopb.GameState, error) {
+	return b.play(ctx, "SURRENDER")
+}
+
+func (b *rpcBlackjack) GetState(ctx context.Context, _ *casinopb.ActionRequest) (*casinopb.GameState, error) {
+	var state *casinopb.GameState
+	err := b.s.withRPCSession(ctx, func(client *ClientState) error {
+		if client.game == nil {
+			return status.Error(codes.NotFound, "No active game")
+		}
+		state = rpcGameState(client.game, client.user.Balance, nil)
+		return nil
+	})
+	return state, err
+}
+
+// Plays a blackjack command on the session's solo hand and reports the hand
+// after it, including one it just settled
+func (b *rpcBlackjack) play(ctx context.Context, command string, args ...string) (*casinopb.GameState, error) {
+	var state *casinopb.GameState
+	err := b.s.withRPCSession(ctx, func(client *ClientState) error {
+		if client.seatedAt != nil {
+			return status.Error(codes.FailedPrecondition, "Leave the shared table first")
+		}
+		client.settled = nil
+		events, err := b.s.rpcCommand(client, codes.FailedPrecondition, command, args...)
+		if err != nil {
+			return err
+		}
+
+		g := client.game
+		if g == nil {
+			g = client.settled
+		}
+		if g == nil {
+			return status.Error(codes.Internal, "The hand was lost")
+		}
+		state = rpcGameState(g, client.user.Balance, events)
+		return nil
+	})
+	return state, err
+}
+
+var rpcResults = map[game.GameResult]casinopb.Result{
+	game.ResultPlayerWin:       casinopb.Result_RESULT_PLAYER_WIN,
+	game.ResultDealerWin:       casinopb.Result_RESULT_DEALER_WIN,
+	game.ResultPush:            casinopb.Result_RESULT_PUSH,
+	game.ResultPlayerBlackjack: casinopb.Result_RESULT_PLAYER_BLACKJACK,
+	game.ResultSurrender:       casinopb.Result_RESULT_SURRENDER,
+}
+
+// A solo hand as the API shows it, with the dealer's hole card hidden until
+// the hand is over
+func rpcGameState(g *game.Game, balance int64, events []*casinopb.Event) *casinopb.GameState {
+	state := &casinopb.GameState{
+		Player:   rpcHand(g.PlayerHand),
+		Bet:      g.Bet,
+		SideBets: g.SideStake(),
+		Actions:  g.GetValidActions(),
+		Balance:  balance,
+		Events:   events,
+	}
+
+	dealer := g.DealerHand
+	switch g.Phase {
+	case game.PhaseGameOver:
+		state.Phase = casinopb.Phase_PHASE_GAME_OVER
+		state.Result = rpcResults[g.Result]
+		state.Payout = g.CalculatePayout() + g.SidePayout()
+	case game.PhasePlayerTurn:
+		state.Phase = casinopb.Phase_PHASE_PLAYER_TURN
+		if len(dealer.Cards) > 1 {
+			dealer = &game.Hand{Cards: dealer.Cards[:1]}
+		}
+	}
+	state.Dealer = rpcHand(dealer)
+	return state
+}
+
+func rpcHand(h *game.Hand) *casinopb.Hand {
+	hand := &casinopb.Hand{Value: int32(h.Value())}
+	for _, c := range h.Cards {
+		hand.Cards = append(hand.Cards, &casinopb.Card{Rank: c.Rank, Suit: c.Suit})
+	}
+	return hand
+}
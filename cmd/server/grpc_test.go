@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/alessandrosisniegas/casino/casinopb"
+)
+
+// Serves the gRPC API of a test server over an in-memory listener and
+// returns a connection to it
+func dialTestGRPC(t *testing.T, s *Server) *grpc.ClientConn {
+	t.Helper()
+	ln := bufconn.Listen(1 << 20)
+	srv := s.newGRPCServer()
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return ln.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// Signs up username and logs it in over gRPC, returning a context that
+// carries its token
+func loginTestGRPC(t *testing.T, s *Server, conn *grpc.ClientConn, username string) context.Context {
+	t.Helper()
+	if _, err := s.authService.RegisterUser(username, "testpassword456"); err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+	resp, err := casinopb.NewAuthClient(conn).Login(context.Background(),
+		&casinopb.LoginRequest{Username: username, Password: "testpassword456"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+resp.Token)
+}
+
+func TestGRPCLogin(t *testing.T) {
+	s := newTestServer(t)
+	auth := casinopb.NewAuthClient(dialTestGRPC(t, s))
+	if _, err := s.authService.RegisterUser("grpcuser", "testpassword456"); err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+
+	_, err := auth.Login(context.Background(), &casinopb.LoginRequest{Username: "grpcuser", Password: "wrongpassword"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Login() with wrong password error = %v, want Unauthenticated", err)
+	}
+
+	resp, err := auth.Login(context.Background(), &casinopb.LoginRequest{Username: "grpcuser", Password: "testpassword456"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if resp.Token == "" {
+		t.Error("Login() returned no token")
+	}
+	user, _ := s.db.GetUserByUsername("grpcuser")
+	if resp.User.GetUsername() != "grpcuser" || resp.User.GetId() != int64(user.ID) || resp.User.GetBalance() != user.Balance {
+		t.Errorf("Login() user = %v, want %s (%d) with %d", resp.User, user.Username, user.ID, user.Balance)
+	}
+}
+
+func TestGRPCBalance(t *testing.T) {
+	s := newTestServer(t)
+	conn := dialTestGRPC(t, s)
+	wallet := casinopb.NewWalletClient(conn)
+
+	if _, err := wallet.GetBalance(context.Background(), &casinopb.GetBalanceRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("GetBalance() without a token error = %v, want Unauthenticated", err)
+	}
+
+	ctx := loginTestGRPC(t, s, conn, "grpcwallet")
+	user, _ := s.db.GetUserByUsername("grpcwallet")
+	setTestBalance(t, s, user.ID, 50000)
+
+	balance, err := wallet.GetBalance(ctx, &casinopb.GetBalanceRequest{})
+	if err != nil {
+		t.Fatalf("GetBalance() error = %v", err)
+	}
+	if balance.Balance != 50000 {
+		t.Errorf("GetBalance() = %d, want 50000", balance.Balance)
+	}
+}
+
+func TestGRPCBlackjackRound(t *testing.T) {
+	s := newTestServer(t)
+	conn := dialTestGRPC(t, s)
+	ctx := loginTestGRPC(t, s, conn, "grpcplayer")
+	user, _ := s.db.GetUserByUsername("grpcplayer")
+	setTestBalance(t, s, user.ID, 50000)
+	blackjack := casinopb.NewBlackjackClient(conn)
+
+	state, err := blackjack.Deal(ctx, &casinopb.DealRequest{Bet: 1000})
+	if err != nil {
+		t.Fatalf("Deal() error = %v", err)
+	}
+	if len(state.Player.GetCards()) != 2 {
+		t.Errorf("Deal() player cards = %v, want 2", state.Player.GetCards())
+	}
+	if state.Phase == casinopb.Phase_PHASE_PLAYER_TURN && len(state.Dealer.GetCards()) != 1 {
+		t.Errorf("Deal() shows %d dealer cards during the player's turn, want only the up card", len(state.Dealer.GetCards()))
+	}
+	for state.Phase == casinopb.Phase_PHASE_PLAYER_TURN {
+		if state, err = blackjack.Stand(ctx, &casinopb.ActionRequest{}); err != nil {
+			t.Fatalf("Stand() error = %v", err)
+		}
+	}
+
+	if state.Phase != casinopb.Phase_PHASE_GAME_OVER || state.Result == casinopb.Result_RESULT_UNSPECIFIED {
+		t.Fatalf("hand ended in phase %v with result %v", state.Phase, state.Result)
+	}
+	if want := 50000 - state.Bet + state.Payout; state.Balance != want {
+		t.Errorf("balance after the hand = %d, want %d (bet %d, payout %d)", state.Balance, want, state.Bet, state.Payout)
+	}
+	balance, err := casinopb.NewWalletClient(conn).GetBalance(ctx, &casinopb.GetBalanceRequest{})
+	if err != nil {
+		t.Fatalf("GetBalance() error = %v", err)
+	}
+	if balance.Balance != state.Balance {
+		t.Errorf("GetBalance() = %d, want %d", balance.Balance, state.Balance)
+	}
+	if _, err := blackjack.GetState(ctx, &casinopb.ActionRequest{}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetState() after the hand error = %v, want NotFound", err)
+	}
+}
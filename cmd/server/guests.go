@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Commands that only make sense for (or could be abused from) a real account
+var guestBlockedCommands = map[string]bool{
+	"APIKEY":   true,
+	"ALLOWIP":  true,
+	"DAILY":    true,
+	"REBUY":    true,
+	"CASHBACK": true,
+	"REFERRAL": true,
+	"MSG":      true,
+	"REMEMBER": true,
+}
+
+// GUEST logs the connection into a new temporary account
+func (s *Server) handleGuest(client *ClientState, _ []string) {
+	sessionID, user, err := s.authService.LoginGuest(client.ip)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	s.clearAuth(client)
+	client.sessionID = sessionID
+	client.user = user
+	s.hub.bind(client, user.ID)
+
+	s.writeResponse(client, fmt.Sprintf("OK Playing as guest %s with $%.2f in play money. "+
+		"Guest accounts are deleted after %d hours idle; SIGNUP for a full account.",
+		user.Username, float64(user.Balance)/100, s.config.GuestIdleHours))
+}
+
+// Writes an error and returns false when a guest tries a members-only command
+func (s *Server) requireMember(client *ClientState, command string) bool {
+	if client.user == nil || !client.user.IsGuest || !guestBlockedCommands[command] {
+		return true
+	}
+
+	s.writeError(client, "FORBIDDEN", "Guest accounts can't use this command, SIGNUP for a full account")
+	return false
+}
+
+// Purges idle guest accounts, keeping any that are still connected
+func (s *Server) purgeGuests() {
+	purged, err := s.authService.PurgeIdleGuests(s.hub.userIDs())
+	if err != nil {
+		s.log.vault.Error("Failed to purge guest accounts", "err", err)
+		return
+	}
+	if purged > 0 {
+		s.log.vault.Info("Purged idle guest accounts", "count", purged)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+	"github.com/alessandrosisniegas/casino/core/security"
+)
+
+// Settles an opening J♠ A♠, which hits the jackpot trigger, for a $10 bet
+func settleJackpotHand(t *testing.T, s *Server, client *ClientState) {
+	t.Helper()
+	g := game.NewGameWithDeck([]game.Card{
+		{Suit: "♠", Rank: "J", Value: 10},
+		{Suit: "♥", Rank: "5", Value: 5},
+		{Suit: "♠", Rank: "A", Value: 11},
+		{Suit: "♥", Rank: "9", Value: 9},
+	})
+	if err := g.PlaceBetNoShuffle(1000); err != nil {
+		t.Fatalf("PlaceBetNoShuffle() error = %v", err)
+	}
+	if _, err := s.db.StartActiveGame(client.user.ID, security.GameBlackjack, 1000, "{}"); err != nil {
+		t.Fatalf("StartActiveGame() error = %v", err)
+	}
+	client.cmdMu.Lock()
+	s.settleGame(client, g, 0)
+	client.cmdMu.Unlock()
+}
+
+func TestGuestRoundsSkipJackpotAndHouse(t *testing.T) {
+	s := newTestServer(t)
+	s.authService = security.NewAuthServiceWithConfig(s.db, security.AuthConfig{
+		GuestBalance:   100000,
+		JackpotPercent: 10,
+		JackpotSeed:    100000,
+	})
+	var err error
+	if s.jackpotTrigger, err = game.ParseJackpotTrigger("AS,JS"); err != nil {
+		t.Fatalf("ParseJackpotTrigger() error = %v", err)
+	}
+
+	guest := s.newRPCSession(context.Background()).client
+	if reply := runCommand(s, guest, "GUEST"); !strings.HasPrefix(reply, "OK") {
+		t.Fatalf("GUEST = %q", reply)
+	}
+	settleJackpotHand(t, s, guest)
+
+	if guest.user.Balance != 101500 {
+		t.Errorf("Guest balance = %d, want 101500 for the blackjack alone", guest.user.Balance)
+	}
+	if amount, _ := s.authService.Jackpot(); amount != 100000 {
+		t.Errorf("Jackpot() = %d after a guest's hand, want the 100000 seed untouched", amount)
+	}
+	if stats, _ := s.db.ListHouseStats(); len(stats) != 0 {
+		t.Errorf("ListHouseStats() = %d games after a guest's hand, want none", len(stats))
+	}
+	if names, _ := s.rtp.stats(); len(names) != 0 {
+		t.Errorf("RTP monitor tracks %v after a guest's hand, want nothing", names)
+	}
+
+	// The same hand for a registered player feeds and wins the jackpot
+	player := loginTestClient(t, s, "jackpotplayer")
+	setTestBalance(t, s, player.user.ID, 100000)
+	settleJackpotHand(t, s, player)
+
+	if want := int64(101500 + 100000 + 100); player.user.Balance != want {
+		t.Errorf("Player balance = %d, want %d with the jackpot", player.user.Balance, want)
+	}
+	if stats, _ := s.db.ListHouseStats(); len(stats) == 0 {
+		t.Error("ListHouseStats() is empty after a registered player's hand")
+	}
+	if names, _ := s.rtp.stats(); len(names) != 1 {
+		t.Errorf("RTP monitor tracks %v, want the player's blackjack hand", names)
+	}
+}
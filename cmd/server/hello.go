@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+// Version of the text protocol, for clients to tell servers apart. The server
+// speaks it to every client; nothing is negotiated.
+const protocolVersion = 1
+
+// Protocol extensions a client can turn on, advertised by HELLO
+var protocolCapabilities = []string{"FRAMING", "JSON"}
+
+// HELLO [version] [client] advertises what the server offers: its protocol
+// version, capabilities, games and commands. It only advertises; every client
+// is spoken to the same way whatever version it gives, which is just logged.
+// VERSION is the same.
+func (s *Server) handleHello(client *ClientState, args []string) {
+	if len(args) > 0 {
+		s.log.server.Debug("Client hello", "client", client.id, "protocol", args[0], "agent", strings.Join(args[1:], " "))
+	}
+	s.writeHello(client)
+}
+
+// Writes the HELLO reply: the protocol version, then what the server offers
+// on one line each
+func (s *Server) writeHello(client *ClientState) {
+	games := []string{"blackjack"}
+	for _, info := range game.Engines() {
+		games = append(games, info.Name)
+	}
+
+	// Commands whose feature is switched off aren't offered
+	var commands []string
+	for name := range commandDocs {
+		if feature, ok := featureCommands[name]; !ok || s.featureOn(feature) {
+			commands = append(commands, name)
+		}
+	}
+	sort.Strings(commands)
+
+	reply := fmt.Sprintf("OK Protocol %d", protocolVersion)
+	reply += "\nCapabilities: " + strings.Join(protocolCapabilities, " ")
+	reply += "\nGames: " + strings.Join(games, " ")
+	reply += "\nCommands: " + strings.Join(commands, " ")
+	s.writeData(client, reply, map[string]any{
+		"protocol":     protocolVersion,
+		"capabilities": protocolCapabilities,
+		"games":        games,
+		"commands":     commands,
+	})
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Short forms the dispatcher accepts for common commands
+var commandAliases = map[string]string{
+	"H":   "HIT",
+	"S":   "STAND",
+	"DD":  "DOUBLEDOWN",
+	"SUR": "SURRENDER",
+	"BAL": "BALANCE",
+	"?":   "HELP",
+}
+
+// Spells out a command given by its alias
+func resolveAlias(command string) string {
+	if full, ok := commandAliases[command]; ok {
+		return full
+	}
+	return command
+}
+
+// What HELP <command> shows for one command
+type commandDoc struct {
+	usage    []string // One line per form of the command
+	about    string
+	examples []string
+	limits   bool // Show the bet limits of the player's table
+}
+
+var commandDocs = map[string]commandDoc{
+	"SIGNUP":       {usage: []string{"SIGNUP <username> <password> [invite code]"}, about: "Create a new account. Usernames and passwords are 2-30 letters, numbers or underscores, and the password can't be the username", examples: []string{"SIGNUP alice s3cretpw"}},
+	"INVITE":       {usage: []string{"INVITE <code>"}, about: "Use an invite code for the next SIGNUP", examples: []string{"INVITE 7F3K9Q"}},
+	"REFER":        {usage: []string{"REFER <code>"}, about: "Use a friend's referral code for the next SIGNUP", examples: []string{"REFER ALICE42"}},
+	"GUEST":        {usage: []string{"GUEST"}, about: "Play with a temporary account and play money"},
+	"LOGIN":        {usage: []string{"LOGIN <username> <password>"}, about: "Log in to your account", examples: []string{"LOGIN alice s3cretpw"}},
+	"LOGOUT":       {usage: []string{"LOGOUT"}, about: "Log out of your account"},
+	"REMEMBER":     {usage: []string{"REMEMBER"}, about: "Get a token to RESUME this login later"},
+	"RESUME":       {usage: []string{"RESUME <token>"}, about: "Log back in with a REMEMBER token"},
+	"BALANCE":      {usage: []string{"BALANCE"}, about: "Check your current balance"},
+	"STATS":        {usage: []string{"STATS"}, about: "View your game statistics"},
+	"PROFIT":       {usage: []string{"PROFIT [7|30]"}, about: "Daily net profit for the last 7 or 30 days", examples: []string{"PROFIT 30"}},
+	"HISTORY":      {usage: []string{"HISTORY [rounds] [page]"}, about: "List your settled rounds newest first with their bet, result, payout and hands, 10 to a page unless you say", examples: []string{"HISTORY", "HISTORY 20 2"}},
+	"EVENTS":       {usage: []string{"EVENTS"}, about: "List running and upcoming events"},
+	"FEED":         {usage: []string{"FEED [ON|OFF]", "FEED ANON ON|OFF"}, about: "Show or hide other players' big wins, or hide your name when you win big", examples: []string{"FEED OFF", "FEED ANON ON"}},
+	"WHOAMI":       {usage: []string{"WHOAMI"}, about: "Show current login status"},
+	"ACHIEVEMENTS": {usage: []string{"ACHIEVEMENTS"}, about: "List achievements and your progress"},
+	"SHOP":         {usage: []string{"SHOP"}, about: "Show your comp points and the shop"},
+	"REDEEM":       {usage: []string{"REDEEM <item|promo code>"}, about: "Exchange comp points for a shop item, or redeem a promo code for free chips"},
+	"BUY":          {usage: []string{"BUY <item>"}, about: "Buy a cosmetic from the shop with chips"},
+	"EQUIP":        {usage: []string{"EQUIP <item>"}, about: "Use a card back, table theme or title you own"},
+	"PROFILE":      {usage: []string{"PROFILE"}, about: "Show your level and equipped cosmetics"},
+	"DAILY":        {usage: []string{"DAILY"}, about: "Claim your free daily bonus"},
+	"REBUY":        {usage: []string{"REBUY"}, about: "Top up when broke (limited per day)"},
+	"CASHBACK":     {usage: []string{"CASHBACK [ON|OFF]"}, about: "Show or join the loss cashback promotion"},
+	"REFERRAL":     {usage: []string{"REFERRAL"}, about: "Show your referral code and referred friends"},
+	"DEPOSIT":      {usage: []string{"DEPOSIT <amount>"}, about: "Add play money to your balance, within the server's limits", examples: []string{"DEPOSIT 50"}},
+	"WITHDRAW":     {usage: []string{"WITHDRAW <amount>"}, about: "Take play money off your balance, within the server's limits", examples: []string{"WITHDRAW 50"}},
+	"TRANSFER":     {usage: []string{"TRANSFER <user> <amount>", "TRANSFER CONFIRM|CANCEL"}, about: "Send chips to another player, confirming before they move", examples: []string{"TRANSFER bob 25", "TRANSFER CONFIRM"}},
+	"LIMITS":       {usage: []string{"LIMITS", "LIMITS SET <daily|weekly> <loss|wager> <amount>"}, about: "Show your loss and wager limits, or lower one", examples: []string{"LIMITS SET daily loss 100"}},
+	"ALLOWIP":      {usage: []string{"ALLOWIP LIST", "ALLOWIP ADD|REMOVE <ip|cidr>", "ALLOWIP CLEAR"}, about: "Restrict logins to your account to some IPs", examples: []string{"ALLOWIP ADD 203.0.113.0/24"}},
+	"AUTH":         {usage: []string{"AUTH <api key>"}, about: "Authenticate with an API key"},
+	"APIKEY":       {usage: []string{"APIKEY CREATE <name> <read|play>", "APIKEY LIST", "APIKEY REVOKE <id>"}, about: "Manage API keys for bots and tools", examples: []string{"APIKEY CREATE mybot play"}},
+	"TABLES":       {usage: []string{"TABLES"}, about: "List tables, bet limits and multiplayer tables"},
+	"LEADERBOARD":  {usage: []string{"LEADERBOARD <balance|net|winrate|biggest> [players] [page]"}, about: "Rank players by balance, net winnings, win rate (20 rounds or more) or biggest single win, 10 to a page unless you say", examples: []string{"LEADERBOARD net", "LEADERBOARD winrate 20 2"}},
+	"JACKPOT":      {usage: []string{"JACKPOT"}, about: "Show the progressive jackpot and what wins it"},
+	"SIT":          {usage: []string{"SIT <table>"}, about: "Move to another table (low, mid or high)", examples: []string{"SIT high"}, limits: true},
+	"BET":          {usage: []string{"BET <amount> [PP <amount>] [21+3 <amount>]"}, about: "Start a game and place a bet in dollars, or bet on the next round when seated at a shared table. Solo hands take Perfect Pairs and 21+3 side bets of up to the main bet, paid on the opening cards", examples: []string{"BET 10", "BET 2.50", "BET 10 PP 1 21+3 1"}, limits: true},
+	"HIT":          {usage: []string{"HIT"}, about: "Draw another card"},
+	"STAND":        {usage: []string{"STAND"}, about: "End your turn"},
+	"DOUBLEDOWN":   {usage: []string{"DOUBLEDOWN"}, about: "Double your bet, draw one card and end your turn", limits: true},
+	"SURRENDER":    {usage: []string{"SURRENDER"}, about: "Forfeit the hand and get half your bet back"},
+	"BACCARAT":     {usage: []string{"BACCARAT <PLAYER|BANKER|TIE> <amount>"}, about: "Bet on a coup of punto banco baccarat dealt from an 8-deck shoe. Player pays 1:1, banker 1:1 less 5% commission and tie 8:1; player and banker bets push on a tie", examples: []string{"BACCARAT BANKER 10", "BACCARAT TIE 1"}, limits: true},
+	"HILO":         {usage: []string{"HILO <amount>", "HILO HIGHER|LOWER", "HILO CASHOUT", "HILO"}, about: "Play Hi-Lo: call whether each card is higher or lower than the last, aces low. A correct call multiplies the pot by 97% of its fair odds; a wrong call or a card of the same rank loses it. Cash out any time", examples: []string{"HILO 5", "HILO HIGHER", "HILO CASHOUT"}, limits: true},
+	"HOLDEM":       {usage: []string{"HOLDEM <ante>", "HOLDEM BET <multiple>", "HOLDEM CHECK", "HOLDEM FOLD", "HOLDEM"}, about: "Play Ultimate Texas Hold'em against the dealer with equal ante and blind bets. Make one play bet of 4x or 3x the ante before the flop, 2x on the flop or 1x on the river, or fold on the river. The ante pushes unless the dealer has a pair or better; the blind pays on a straight or better", examples: []string{"HOLDEM 5", "HOLDEM CHECK", "HOLDEM BET 2"}, limits: true},
+	"PICK":         {usage: []string{"PICK <numbers>", "PICK"}, about: "Pick 1 to 10 keno numbers from 1 to 80 for DRAW, or show your picks and what they can pay", examples: []string{"PICK 7 21 42 63"}},
+	"DRAW":         {usage: []string{"DRAW <amount>"}, about: "Bet on your keno picks and draw 20 of the 80 numbers. The ticket pays by how many picks are drawn", examples: []string{"DRAW 1"}, limits: true},
+	"GAMES":        {usage: []string{"GAMES"}, about: "List the games besides blackjack that PLAY offers"},
+	"PLAY":         {usage: []string{"PLAY <game> <amount>", "PLAY <game> <action> [args]", "PLAY <game>"}, about: "Start a round of a game from GAMES, take an action in it, or show it with the actions you can take", examples: []string{"PLAY hilo 5", "PLAY hilo HIGHER", "PLAY keno DRAW 7 21 42"}, limits: true},
+	"JOIN":         {usage: []string{"JOIN <table>"}, about: "Sit at a shared table (see TABLES) with other players", examples: []string{"JOIN low", "JOIN low-2"}},
+	"HOST":         {usage: []string{"HOST <stake> [3:2|6:5] [s17|h17] [surrender|nosurrender] [decks=1-8] [pen=50-90] [turn=10-120]"}, about: "Open a shared table with your own rules and sit at it", examples: []string{"HOST mid 6:5 h17 decks=2"}},
+	"TABLE":        {usage: []string{"TABLE"}, about: "Show the shared table you are seated at"},
+	"LEAVE":        {usage: []string{"LEAVE"}, about: "Stand up from the shared table"},
+	"REVIEW":       {usage: []string{"REVIEW [hands]"}, about: "Replay your last multiplayer hands (default 5)", examples: []string{"REVIEW 10"}},
+	"CHAT":         {usage: []string{"CHAT <message>", "CHAT LOBBY <message>"}, about: "Talk to the players at your table, or to everyone online", examples: []string{"CHAT good luck all"}},
+	"REACT":        {usage: []string{"REACT <nice|ouch|gg|gl|wow>"}, about: "Send a quick reaction to your table", examples: []string{"REACT gg"}},
+	"MSG":          {usage: []string{"MSG <user> <message>"}, about: "Send a private message, held if they're offline", examples: []string{"MSG bob see you at low-1"}},
+	"BLOCK":        {usage: []string{"BLOCK [user]"}, about: "Stop a player messaging you and hide their chat, or list blocked players"},
+	"UNBLOCK":      {usage: []string{"UNBLOCK <user>"}, about: "Allow a blocked player to message you again"},
+	"MUTE":         {usage: []string{"MUTE [user]"}, about: "Hide a player's chat and reactions, or list muted players"},
+	"UNMUTE":       {usage: []string{"UNMUTE <user>"}, about: "Show a muted player's chat again"},
+	"ADMIN":        {usage: []string{"ADMIN <subcommand> [arguments]"}, about: "Run an admin command (admin accounts only). HELP lists the subcommands"},
+	"HELP":         {usage: []string{"HELP [command]"}, about: "Show every command, or the details of one", examples: []string{"HELP BET", "HELP DD"}},
+	"HELLO":        {usage: []string{"HELLO [version] [client name]"}, about: "Show the server's protocol version, capabilities, games and commands", examples: []string{"HELLO 1 casino-client", "VERSION"}},
+	"FRAMING":      {usage: []string{"FRAMING ON|OFF"}, about: "End every message with a line holding just \".\""},
+	"MODE":         {usage: []string{"MODE JSON|TEXT"}, about: "Send replies and events as single-line JSON objects, or as text again", examples: []string{"MODE JSON"}},
+	"PING":         {usage: []string{"PING"}, about: "Check the connection is alive"},
+	"QUIT":         {usage: []string{"QUIT"}, about: "Disconnect from server"},
+}
+
+// Names the dispatcher also accepts for a documented command, besides aliases
+var commandSynonyms = map[string][]string{
+	"SIGNUP":     {"REGISTER"},
+	"DOUBLEDOWN": {"DOUBLE"},
+	"QUIT":       {"EXIT"},
+	"HELLO":      {"VERSION"},
+}
+
+// How HELP groups the commands of commandDocs. Every documented command is
+// in exactly one section.
+var helpSections = []struct {
+	title    string
+	commands []string
+}{
+	{"Account Management", []string{"SIGNUP", "INVITE", "REFER", "GUEST", "LOGIN", "LOGOUT", "REMEMBER", "RESUME", "BALANCE", "STATS", "PROFIT", "HISTORY", "LEADERBOARD", "EVENTS", "FEED", "WHOAMI", "ACHIEVEMENTS", "SHOP", "REDEEM", "BUY", "EQUIP", "PROFILE", "DAILY", "REBUY", "CASHBACK", "REFERRAL", "DEPOSIT", "WITHDRAW", "TRANSFER", "LIMITS", "ALLOWIP"}},
+	{"API Keys", []string{"AUTH", "APIKEY"}},
+	{"Blackjack Game", []string{"TABLES", "JACKPOT", "SIT", "BET", "HIT", "STAND", "DOUBLEDOWN", "SURRENDER"}},
+	{"More Games", []string{"GAMES", "PLAY"}},
+	{"Baccarat", []string{"BACCARAT"}},
+	{"Hi-Lo", []string{"HILO"}},
+	{"Ultimate Texas Hold'em", []string{"HOLDEM"}},
+	{"Keno", []string{"PICK", "DRAW"}},
+	{"Multiplayer Tables", []string{"JOIN", "HOST", "TABLE", "LEAVE", "REVIEW", "CHAT", "REACT"}},
+	{"Messages", []string{"MSG", "BLOCK", "UNBLOCK", "MUTE", "UNMUTE"}},
+	{"Admin (admin accounts only)", []string{"ADMIN"}},
+	{"Other", []string{"HELP", "HELLO", "FRAMING", "MODE", "PING", "QUIT"}},
+}
+
+// The ADMIN subcommands HELP lists under ADMIN
+var adminDocs = []commandDoc{
+	{usage: []string{"ADMIN GRANT <user> <amount> <reason>"}, about: "Credit a user's balance"},
+	{usage: []string{"ADMIN DEDUCT <user> <amount> <reason>"}, about: "Debit a user's balance"},
+	{usage: []string{"ADMIN TRANSFERS ON|OFF"}, about: "Enable or disable transfers"},
+	{usage: []string{"ADMIN FEATURES"}, about: "List feature flags and whether each is on"},
+	{usage: []string{"ADMIN FEATURE <name> ON|OFF"}, about: "Switch a feature on or off without a restart"},
+	{usage: []string{"ADMIN HOUSE"}, about: "Show the house bankroll report"},
+	{usage: []string{"ADMIN PROMO CREATE <amount> [uses] [days] [code]", "ADMIN PROMO LIST"}, about: "Create promo codes, or list them and their uses"},
+	{usage: []string{"ADMIN EVENT CREATE <kind> <multiplier> <hours> <start> <name>", "ADMIN EVENT LIST|CANCEL <id>"}, about: "Schedule, list or cancel events"},
+	{usage: []string{"ADMIN MUTE <user> <minutes>"}, about: "Stop a player chatting"},
+	{usage: []string{"ADMIN UNMUTE <user>"}, about: "Lift a chat mute"},
+	{usage: []string{"ADMIN TABLES"}, about: "Show every table in use with its players and pot"},
+	{usage: []string{"ADMIN STATS"}, about: "Show connections, load and money moved since start"},
+	{usage: []string{"ADMIN CONNECTIONS"}, about: "List live connections with their user, IP and game"},
+	{usage: []string{"ADMIN KICK <user|#id> <reason>"}, about: "Disconnect a user, or one connection by number"},
+	{usage: []string{"ADMIN BAN <user> [duration] [reason]"}, about: "Suspend an account for a duration like 7d or 12h, or for good if it is left out"},
+	{usage: []string{"ADMIN UNBAN <user>"}, about: "Lift a ban"},
+	{usage: []string{"ADMIN BANS"}, about: "List banned users"},
+	{usage: []string{"ADMIN LOGLEVEL [subsystem] [level]"}, about: "Show or change log levels (server, vault, game, auth)"},
+	{usage: []string{"ADMIN EXPORT AUDIT|LEDGER <from> <to> [user] [csv|json]"}, about: "Export audit events or the ledger (UTC dates)"},
+	{usage: []string{"ADMIN REPORT [YYYY-MM-DD|today|yesterday]"}, about: "Show a day's signups, players, wagers, hold and biggest win"},
+	{usage: []string{"ADMIN FREEZE <table> <reason>"}, about: "Stop all play at a table"},
+	{usage: []string{"ADMIN UNFREEZE <table>"}, about: "Reopen a frozen table"},
+}
+
+// Width of the usage column in HELP; longer usages put their summary on the
+// next line
+const helpUsageWidth = 28
+
+// HELP lists every command by section with the first sentence of its about,
+// all taken from commandDocs. HELP <command> shows one command in full.
+func (s *Server) handleHelp(client *ClientState, args []string) {
+	if len(args) > 0 {
+		s.handleCommandHelp(client, args[0])
+		return
+	}
+
+	help := "OK Available commands:\n"
+	for _, section := range helpSections {
+		help += "\n" + section.title + ":\n"
+		for _, name := range section.commands {
+			help += helpLines(commandDocs[name])
+			if name == "ADMIN" {
+				for _, doc := range adminDocs {
+					help += helpLines(doc)
+				}
+			}
+		}
+	}
+
+	var short []string
+	for alias, full := range commandAliases {
+		short = append(short, fmt.Sprintf("%s (%s)", alias, full))
+	}
+	sort.Strings(short)
+	help += "\nShort forms: " + strings.Join(short, ", ")
+
+	s.writeResponse(client, help)
+}
+
+// One line per usage form of doc, the first with the summary of what it does
+func helpLines(doc commandDoc) string {
+	summary, _, _ := strings.Cut(doc.about, ". ")
+	lines := ""
+	for i, usage := range doc.usage {
+		switch {
+		case i > 0:
+			lines += "  " + usage + "\n"
+		case len(usage) > helpUsageWidth:
+			lines += "  " + usage + "\n" + strings.Repeat(" ", helpUsageWidth+5) + summary + "\n"
+		default:
+			lines += fmt.Sprintf("  %-*s - %s\n", helpUsageWidth, usage, summary)
+		}
+	}
+	return lines
+}
+
+// HELP <command> shows the usage, examples and short forms of one command,
+// and for betting commands the limits of the player's current table
+func (s *Server) handleCommandHelp(client *ClientState, name string) {
+	command := resolveAlias(strings.ToUpper(name))
+	for full, synonyms := range commandSynonyms {
+		for _, synonym := range synonyms {
+			if command == synonym {
+				command = full
+			}
+		}
+	}
+
+	doc, ok := commandDocs[command]
+	if !ok {
+		s.writeError(client, "UNKNOWN_COMMAND", fmt.Sprintf("Unknown command %s. Type HELP for available commands.", strings.ToUpper(name)))
+		return
+	}
+
+	help := fmt.Sprintf("OK %s - %s", command, doc.about)
+	help += "\nUsage: " + strings.Join(doc.usage, " | ")
+	if len(doc.examples) > 0 {
+		help += "\nExamples: " + strings.Join(doc.examples, ", ")
+	}
+
+	other := append([]string(nil), commandSynonyms[command]...)
+	for alias, full := range commandAliases {
+		if full == command {
+			other = append(other, alias)
+		}
+	}
+	if len(other) > 0 {
+		sort.Strings(other)
+		help += "\nAlso: " + strings.Join(other, ", ")
+	}
+
+	if doc.limits {
+		table := client.table
+		if client.seatedAt != nil {
+			table = client.seatedAt.table.Table
+		}
+		rules := s.rulesFor(client, table)
+		help += fmt.Sprintf("\nLimits at %s: $%.2f - $%.2f", table.Name, float64(rules.MinBet)/100, float64(rules.MaxBet)/100)
+	}
+
+	s.writeResponse(client, help)
+}
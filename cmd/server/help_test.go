@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestHelpSectionsCoverCommandDocs(t *testing.T) {
+	seen := make(map[string]string)
+	for _, section := range helpSections {
+		for _, name := range section.commands {
+			if _, ok := commandDocs[name]; !ok {
+				t.Errorf("section %q lists %s, which has no commandDocs entry", section.title, name)
+			}
+			if other, ok := seen[name]; ok {
+				t.Errorf("%s is in both %q and %q", name, other, section.title)
+			}
+			seen[name] = section.title
+		}
+	}
+	for name := range commandDocs {
+		if _, ok := seen[name]; !ok {
+			t.Errorf("%s is documented but in no HELP section", name)
+		}
+	}
+}
+
+func TestHelpListsEveryUsage(t *testing.T) {
+	s := newTestServer(t)
+	client := s.newRPCSession(context.Background()).client
+	help := runCommand(s, client, "HELP")
+	if !strings.HasPrefix(help, "OK Available commands:") {
+		t.Fatalf("HELP = %q", help)
+	}
+
+	docs := append([]commandDoc(nil), adminDocs...)
+	for _, doc := range commandDocs {
+		docs = append(docs, doc)
+	}
+	for _, doc := range docs {
+		for _, usage := range doc.usage {
+			if !strings.Contains(help, "\n  "+usage) {
+				t.Errorf("HELP doesn't list %q", usage)
+			}
+		}
+	}
+	if !strings.Contains(help, "DD (DOUBLEDOWN)") {
+		t.Error("HELP doesn't list the short forms")
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Rounds HISTORY shows when no count is given
+const defaultHistoryRounds = 10
+
+// HISTORY [n] [page] lists the player's settled rounds, newest first, n to a page
+func (s *Server) handleHistory(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	if len(args) > 2 {
+		s.writeError(client, "USAGE", "Usage: HISTORY [rounds] [page]")
+		return
+	}
+	perPage, page := defaultHistoryRounds, 1
+	for i, arg := range args {
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			s.writeError(client, "USAGE", "Usage: HISTORY [rounds] [page]")
+			return
+		}
+		if i == 0 {
+			perPage = n
+		} else {
+			page = n
+		}
+	}
+
+	rounds, pages, err := s.authService.GameHistory(client.user.ID, perPage, page)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+	if pages == 0 {
+		s.writeData(client, "OK You haven't played any rounds yet", historyData(rounds, page, pages))
+		return
+	}
+	if len(rounds) == 0 {
+		s.writeResponse(client, fmt.Sprintf("ERROR There are only %d pages", pages))
+		return
+	}
+
+	history := fmt.Sprintf("OK Your rounds, newest first (page %d of %d):", page, pages)
+	for _, r := range rounds {
+		history += fmt.Sprintf("\n  %s  %-9s  Bet $%.2f  %-11s  Paid $%.2f",
+			r.CreatedAt.Local().Format("2006-01-02 15:04"), r.Game, float64(r.Bet)/100, r.Result, float64(r.Payout)/100)
+		if r.Hands != "" {
+			history += "\n    " + r.Hands
+		}
+	}
+	if page < pages {
+		history += fmt.Sprintf("\nOlder rounds: HISTORY %d %d", perPage, page+1)
+	}
+	s.writeData(client, history, historyData(rounds, page, pages))
+}
+
+func historyData(rounds []*vault.GameRound, page, pages int) map[string]any {
+	if rounds == nil {
+		rounds = []*vault.GameRound{}
+	}
+	return map[string]any{"rounds": rounds, "page": page, "pages": pages}
+}
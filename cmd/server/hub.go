@@ -0,0 +1,125 @@
+package main
+
+import "sync"
+
+// Hub tracks live connections so events can be pushed outside the request/response flow
+type Hub struct {
+	mu     sync.RWMutex
+	all    map[*ClientState]struct{}
+	byUser map[int]map[*ClientState]struct{}
+	userOf map[*ClientState]int
+
+	// Last connection number handed out
+	lastID int
+}
+
+func newHub() *Hub {
+	return &Hub{
+		all:    make(map[*ClientState]struct{}),
+		byUser: make(map[int]map[*ClientState]struct{}),
+		userOf: make(map[*ClientState]int),
+	}
+}
+
+func (h *Hub) add(client *ClientState) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastID++
+	client.id = h.lastID
+	h.all[client] = struct{}{}
+}
+
+func (h *Hub) remove(client *ClientState) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unbindLocked(client)
+	delete(h.all, client)
+}
+
+// Associates a connection with the user it authenticated as
+func (h *Hub) bind(client *ClientState, userID int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unbindLocked(client)
+
+	if h.byUser[userID] == nil {
+		h.byUser[userID] = make(map[*ClientState]struct{})
+	}
+	h.byUser[userID][client] = struct{}{}
+	h.userOf[client] = userID
+}
+
+func (h *Hub) unbind(client *ClientState) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unbindLocked(client)
+}
+
+func (h *Hub) unbindLocked(client *ClientState) {
+	userID, ok := h.userOf[client]
+	if !ok {
+		return
+	}
+	delete(h.userOf, client)
+	delete(h.byUser[userID], client)
+	if len(h.byUser[userID]) == 0 {
+		delete(h.byUser, userID)
+	}
+}
+
+// Returns the user's live connections, skipping except when it is non-nil
+func (h *Hub) clientsForUser(userID int, except *ClientState) []*ClientState {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var clients []*ClientState
+	for client := range h.byUser[userID] {
+		if client != except {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
+
+func (h *Hub) clients() []*ClientState {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	clients := make([]*ClientState, 0, len(h.all))
+	for client := range h.all {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// Returns the IDs of users with at least one live connection
+func (h *Hub) userIDs() []int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	ids := make([]int, 0, len(h.byUser))
+	for id := range h.byUser {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Returns the live connection numbered id, or nil
+func (h *Hub) client(id int) *ClientState {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.all {
+		if client.id == id {
+			return client
+		}
+	}
+	return nil
+}
+
+// Returns the ID of the user a connection is logged in as, or 0
+func (h *Hub) userOfClient(client *ClientState) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.userOf[client]
+}
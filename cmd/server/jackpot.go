@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+	"github.com/alessandrosisniegas/casino/core/security"
+)
+
+func (s *Server) handleJackpot(client *ClientState, _ []string) {
+	amount, err := s.authService.Jackpot()
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	s.writeResponse(client, fmt.Sprintf("OK Progressive jackpot: $%.2f (won by an opening %s)",
+		float64(amount)/100, s.jackpotTrigger))
+}
+
+// Feeds the jackpot from a finished hand and pays it out if the hand hit the
+// trigger. Guests play for play money, which neither feeds nor wins it.
+func (s *Server) settleJackpot(client *ClientState, g *game.Game) {
+	if s.jackpotTrigger == nil || client.user.IsGuest || !s.featureOn(security.FeatureJackpot) {
+		return
+	}
+
+	if _, err := s.authService.ContributeJackpot(g.Bet); err != nil {
+		s.log.game.Error("Failed to contribute to jackpot", "bet", g.Bet, "err", err)
+	}
+
+	if !s.jackpotTrigger.Matches(g) {
+		return
+	}
+
+	won, balance, err := s.authService.AwardJackpot(client.user.ID)
+	if err != nil {
+		s.log.game.Error("Failed to award jackpot", "user", client.user.Username, "err", err)
+		return
+	}
+	client.user.Balance = balance
+	s.log.game.Info("Jackpot won", "user", client.user.Username, "amount", won)
+	s.alert(alertJackpot, fmt.Sprintf("%s hit the progressive jackpot and won $%.2f", client.user.Username, float64(won)/100),
+		map[string]any{"user": client.user.Username, "amount": float64(won) / 100})
+	s.checkBalanceAlert(client.user.Username, balance-won, balance)
+
+	for _, c := range s.hub.clients() {
+		s.pushEvent(c, "JACKPOT", fmt.Sprintf("%s hit the progressive jackpot with %s and won $%.2f!",
+			client.user.Username, s.jackpotTrigger, float64(won)/100))
+	}
+}
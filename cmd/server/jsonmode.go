@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// A reply or event as sent after MODE JSON, one object per line
+type jsonMessage struct {
+	// reply or event
+	Type string `json:"type"`
+	// OK or ERROR, for replies
+	Status string `json:"status,omitempty"`
+	// OK, or what went wrong for an ERROR, e.g. USAGE or INSUFFICIENT_FUNDS
+	Code string `json:"code,omitempty"`
+	// Event type, e.g. LEVEL
+	Kind    string `json:"kind,omitempty"`
+	Message string `json:"message"`
+	// Structured payload of replies that have one; amounts are in cents
+	Data any `json:"data,omitempty"`
+}
+
+// Codes an error gives as the first word of its message, e.g.
+// "ERROR RATE_LIMITED Too many commands", for text clients to act on too.
+// Other errors give their code to writeError.
+var errorCodes = map[string]bool{
+	"RATE_LIMITED": true,
+}
+
+// Turns a text protocol message into its JSON line. code is the ERROR reply's
+// code from writeError; without one it's FAILED.
+func encodeJSONMessage(message, code string, data any) string {
+	word, rest, _ := strings.Cut(message, " ")
+	if w, r, found := strings.Cut(message, "\n"); found && !strings.Contains(w, " ") {
+		word, rest = w, r
+	}
+
+	m := jsonMessage{Type: "reply", Message: message, Data: data}
+	switch word {
+	case "OK":
+		m.Status, m.Code, m.Message = "OK", "OK", rest
+	case "ERROR":
+		m.Status, m.Code, m.Message = "ERROR", "FAILED", rest
+		if given, text, _ := strings.Cut(rest, " "); errorCodes[given] {
+			m.Code, m.Message = given, text
+		}
+		if code != "" {
+			m.Code = code
+		}
+	case "EVENT":
+		m.Type = "event"
+		m.Kind, m.Message, _ = strings.Cut(rest, " ")
+	}
+
+	// Usage lines are full of <placeholders>, which read better unescaped
+	var line strings.Builder
+	enc := json.NewEncoder(&line)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(m); err != nil {
+		line.Reset()
+		enc.Encode(jsonMessage{Type: m.Type, Status: "ERROR", Code: "FAILED", Message: err.Error()})
+	}
+	return strings.TrimSuffix(line.String(), "\n")
+}
+
+// An error that is a usage line, which writeError sends as USAGE
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+func usageErrorf(format string, args ...any) error {
+	return usageError(fmt.Sprintf(format, args...))
+}
+
+// The code to write a helper's error with: USAGE for a usage line, or none
+func errorCode(err error) string {
+	var usage usageError
+	if errors.As(err, &usage) {
+		return "USAGE"
+	}
+	return ""
+}
+
+// MODE JSON switches replies and events to single-line JSON objects for bots
+// and tests; MODE TEXT switches back
+func (s *Server) handleMode(client *ClientState, args []string) {
+	if len(args) != 1 || (!strings.EqualFold(args[0], "JSON") && !strings.EqualFold(args[0], "TEXT")) {
+		s.writeError(client, "USAGE", "Usage: MODE JSON|TEXT")
+		return
+	}
+
+	client.writeMu.Lock()
+	client.json = strings.EqualFold(args[0], "JSON")
+	client.writeMu.Unlock()
+
+	if client.json {
+		s.writeResponse(client, "OK JSON mode on")
+	} else {
+		s.writeResponse(client, "OK Text mode on")
+	}
+}
+
+type jsonHand struct {
+	Cards []game.CardData `json:"cards"`
+	Value int             `json:"value"`
+}
+
+// A solo hand as MODE JSON shows it, with the dealer's hole card hidden
+// during the player's turn
+type jsonGame struct {
+	Phase    game.GamePhase `json:"phase"`
+	Player   jsonHand       `json:"player"`
+	Dealer   jsonHand       `json:"dealer"`
+	Bet      int64          `json:"bet"`
+	SideBets int64          `json:"side_bets"`
+	// Set once the hand is over: Blackjack, Win, Loss, Bust, Push or Surrendered
+	Result  string   `json:"result,omitempty"`
+	Payout  int64    `json:"payout"`
+	Actions []string `json:"actions"`
+}
+
+func gameData(g *game.Game) map[string]any {
+	state := jsonGame{
+		Phase:    g.Phase,
+		Player:   handData(g.PlayerHand),
+		Bet:      g.Bet,
+		SideBets: g.SideStake(),
+		Actions:  g.GetValidActions(),
+	}
+	if state.Actions == nil {
+		state.Actions = []string{}
+	}
+
+	dealer := g.DealerHand
+	switch g.Phase {
+	case game.PhaseGameOver:
+		state.Result = game.SeatResult(g)
+		state.Payout = g.CalculatePayout() + g.SidePayout()
+	case game.PhasePlayerTurn:
+		if len(dealer.Cards) > 1 {
+			dealer = &game.Hand{Cards: dealer.Cards[:1]}
+		}
+	}
+	state.Dealer = handData(dealer)
+	return map[string]any{"game": state}
+}
+
+func handData(h *game.Hand) jsonHand {
+	return jsonHand{Cards: game.CardsData(h.Cards), Value: h.Value()}
+}
+
+// A round of an engine game as MODE JSON shows it: the game's own Data,
+// plus what it has riding and, once over, what it paid
+func engineData(r *engineRound) map[string]any {
+	round := r.engine.Data()
+	round["game"] = r.info.Name
+	round["wagered"] = r.engine.Wagered()
+	round["over"] = game.RoundOver(r.engine)
+	if game.RoundOver(r.engine) {
+		round["payout"] = r.engine.Payout()
+	}
+	round["actions"] = append([]string{}, r.engine.ValidActions()...)
+	return map[string]any{"round": round}
+}
+
+// A seat at a shared table as MODE JSON shows it
+type jsonSeat struct {
+	Seat int    `json:"seat"` // From 1
+	Name string `json:"name"`
+	You  bool   `json:"you,omitempty"`
+	Turn bool   `json:"turn,omitempty"`
+	Away bool   `json:"away,omitempty"`
+	Bet  int64  `json:"bet"`
+	// Set once the cards are dealt
+	Hand *jsonHand `json:"hand,omitempty"`
+	// Set once the round is over
+	Result string `json:"result,omitempty"`
+	Payout int64  `json:"payout"`
+}
+
+// A shared table as the player in seat viewer sees it, with the dealer's
+// hole card hidden during the players' turns. Callers hold st.mu.
+func tableData(st *sharedTable, viewer int) map[string]any {
+	t := st.table
+	dealer := t.Dealer
+	if t.Phase == game.PhasePlayerTurn && len(dealer.Cards) > 1 {
+		dealer = &game.Hand{Cards: dealer.Cards[:1]}
+	}
+
+	seats := []jsonSeat{}
+	for i, seat := range t.Seats {
+		if seat == nil {
+			continue
+		}
+		js := jsonSeat{Seat: i + 1, Name: seat.Name, You: i == viewer, Turn: i == t.Turn, Away: seat.Away}
+		if g := seat.Game; g != nil {
+			js.Bet = g.Bet
+			if len(g.PlayerHand.Cards) > 0 {
+				hand := handData(g.PlayerHand)
+				js.Hand = &hand
+			}
+			if g.Phase == game.PhaseGameOver {
+				js.Result, js.Payout = game.SeatResult(g), g.CalculatePayout()
+			}
+		}
+		seats = append(seats, js)
+	}
+
+	return map[string]any{"table": map[string]any{
+		"id":     st.id,
+		"name":   t.Table.Name,
+		"phase":  t.Phase,
+		"dealer": handData(dealer),
+		"seats":  seats,
+	}}
+}
+
+func balanceData(balance int64) map[string]any {
+	return map[string]any{"balance": balance}
+}
+
+func userData(user *vault.User) map[string]any {
+	return map[string]any{"user": map[string]any{
+		"id":       user.ID,
+		"username": user.Username,
+		"balance":  user.Balance,
+		"guest":    user.IsGuest,
+	}}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEncodeJSONMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		code    string
+		data    any
+		want    string
+	}{
+		{
+			name:    "ok with data",
+			message: "OK Balance: $10.00",
+			data:    balanceData(1000),
+			want:    `{"type":"reply","status":"OK","code":"OK","message":"Balance: $10.00","data":{"balance":1000}}`,
+		},
+		{
+			name:    "ok on its own line",
+			message: "OK\nBet: $1.00\nPlayer Hand: [K♠] [7♥] (Value: 17)",
+			want:    `{"type":"reply","status":"OK","code":"OK","message":"Bet: $1.00\nPlayer Hand: [K♠] [7♥] (Value: 17)"}`,
+		},
+		{
+			name:    "error with a code",
+			message: "ERROR Please login first",
+			code:    "NOT_LOGGED_IN",
+			want:    `{"type":"reply","status":"ERROR","code":"NOT_LOGGED_IN","message":"Please login first"}`,
+		},
+		{
+			name:    "error without a code",
+			message: "ERROR Failed to save hand",
+			want:    `{"type":"reply","status":"ERROR","code":"FAILED","message":"Failed to save hand"}`,
+		},
+		{
+			name:    "code given in the text",
+			message: "ERROR RATE_LIMITED Too many commands, slow down (retry in 1s)",
+			want:    `{"type":"reply","status":"ERROR","code":"RATE_LIMITED","message":"Too many commands, slow down (retry in 1s)"}`,
+		},
+		{
+			name:    "usage isn't escaped",
+			message: "ERROR Usage: BET <amount>",
+			code:    "USAGE",
+			want:    `{"type":"reply","status":"ERROR","code":"USAGE","message":"Usage: BET <amount>"}`,
+		},
+		{
+			name:    "event",
+			message: "EVENT LEVEL You reached level 2! VIP tier: Bronze",
+			want:    `{"type":"event","kind":"LEVEL","message":"You reached level 2! VIP tier: Bronze"}`,
+		},
+		{
+			name:    "plain text",
+			message: "Welcome to the casino",
+			want:    `{"type":"reply","message":"Welcome to the casino"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := encodeJSONMessage(tt.message, tt.code, tt.data); got != tt.want {
+				t.Errorf("encodeJSONMessage() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	if got := errorCode(usageErrorf("Usage: ADMIN UNBAN <user>")); got != "USAGE" {
+		t.Errorf("errorCode(usage) = %q, want USAGE", got)
+	}
+	if got := errorCode(fmt.Errorf("ban: %w", usageErrorf("Usage: ADMIN BAN"))); got != "USAGE" {
+		t.Errorf("errorCode(wrapped usage) = %q, want USAGE", got)
+	}
+	if got := errorCode(errors.New("user not found")); got != "" {
+		t.Errorf("errorCode(other) = %q, want none", got)
+	}
+}
+
+// A message as a MODE JSON session reads it, with the data it is checked on
+type testJSONMessage struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	Data    struct {
+		Round map[string]any `json:"round"`
+		Table *struct {
+			ID     string `json:"id"`
+			Dealer struct {
+				Cards []map[string]string `json:"cards"`
+			} `json:"dealer"`
+			Seats []struct {
+				Seat int    `json:"seat"`
+				Name string `json:"name"`
+				You  bool   `json:"you"`
+				Bet  int64  `json:"bet"`
+				Hand *struct {
+					Cards []map[string]string `json:"cards"`
+					Value int                 `json:"value"`
+				} `json:"hand"`
+			} `json:"seats"`
+		} `json:"table"`
+	} `json:"data"`
+}
+
+func parseTestJSON(t *testing.T, out string) []testJSONMessage {
+	t.Helper()
+	var messages []testJSONMessage
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var m testJSONMessage
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("line %q isn't JSON: %v", line, err)
+		}
+		messages = append(messages, m)
+	}
+	return messages
+}
+
+func TestJSONEngineRound(t *testing.T) {
+	s := newTestServer(t)
+	client := loginTestClient(t, s, "jsonhilo")
+	runCommand(s, client, "MODE JSON")
+
+	messages := parseTestJSON(t, runCommand(s, client, "PLAY hilo 5"))
+	reply := messages[len(messages)-1]
+	round := reply.Data.Round
+	if reply.Status != "OK" || round == nil {
+		t.Fatalf("PLAY hilo 5 = %+v, want OK with a round", reply)
+	}
+	if round["game"] != "hilo" || round["wagered"] != float64(500) || round["over"] != false {
+		t.Errorf("round = %v, want a hilo round staking 500 still in play", round)
+	}
+	if card, ok := round["card"].(map[string]any); !ok || card["rank"] == "" || card["suit"] == "" {
+		t.Errorf("round card = %v, want its rank and suit", round["card"])
+	}
+	if dealt, ok := round["dealt"].([]any); !ok || len(dealt) != 1 {
+		t.Errorf("round dealt = %v, want the first card as an array", round["dealt"])
+	}
+
+	messages = parseTestJSON(t, runCommand(s, client, "PLAY hilo CASHOUT"))
+	round = messages[len(messages)-1].Data.Round
+	if round["over"] != true || round["payout"] != float64(500) {
+		t.Errorf("round after CASHOUT = %v, want over paying 500", round)
+	}
+}
+
+func TestJSONTableEvent(t *testing.T) {
+	s := newTestServer(t)
+	watcher := loginTestClient(t, s, "jsonwatcher")
+	bettor := loginTestClient(t, s, "jsonbettor")
+	setTestBalance(t, s, bettor.user.ID, 50000)
+	runCommand(s, watcher, "MODE JSON")
+	runCommand(s, watcher, "JOIN low")
+	runCommand(s, bettor, "JOIN low")
+	watcher.conn.(*rpcConn).take()
+
+	runCommand(s, bettor, "BET 10")
+	messages := parseTestJSON(t, watcher.conn.(*rpcConn).take())
+	event := messages[0]
+	if event.Type != "event" || event.Kind != "TABLE" || event.Data.Table == nil {
+		t.Fatalf("watcher got %+v, want a TABLE event with the table", event)
+	}
+	table := event.Data.Table
+	if table.ID != bettor.seatedAt.id || len(table.Seats) != 2 {
+		t.Fatalf("table = %+v, want %s with both seats", table, bettor.seatedAt.id)
+	}
+	for _, seat := range table.Seats {
+		if seat.You != (seat.Name == "jsonwatcher") {
+			t.Errorf("seat %d (%s) you = %v", seat.Seat, seat.Name, seat.You)
+		}
+		if seat.Name == "jsonbettor" && seat.Bet != 1000 {
+			t.Errorf("bettor's seat bet = %d, want 1000", seat.Bet)
+		}
+		if seat.Hand != nil {
+			t.Errorf("seat %d has a hand before the deal", seat.Seat)
+		}
+	}
+	if len(table.Dealer.Cards) != 0 {
+		t.Errorf("dealer has %d cards before the deal", len(table.Dealer.Cards))
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+// PICK <numbers> chooses the keno numbers DRAW plays, and PICK alone shows
+// them with what they can pay
+func (s *Server) handlePick(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	if len(args) == 0 {
+		if client.kenoPicks == nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR No numbers picked. Use PICK <numbers> with 1 to %d numbers from 1 to %d", game.KenoMaxPicks, game.KenoNumbers))
+			return
+		}
+		s.writeData(client, describePicks(client.kenoPicks), map[string]any{"picks": client.kenoPicks})
+		return
+	}
+
+	picks, err := game.ParseKenoPicks(args)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+	client.kenoPicks = picks
+	s.writeData(client, describePicks(picks), map[string]any{"picks": picks})
+}
+
+func describePicks(picks []int) string {
+	return fmt.Sprintf("OK Keno picks: %v\nPays: %s\nUse DRAW <amount> to play them",
+		picks, game.KenoPays.Describe(len(picks)))
+}
+
+// DRAW <amount> plays a keno ticket on the picked numbers and settles it at
+// once, within the limits of the player's table
+func (s *Server) handleDraw(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+	if len(args) != 1 {
+		s.writeError(client, "USAGE", "Usage: DRAW <amount> (e.g., DRAW 1 after PICK 7 21 42)")
+		return
+	}
+	if client.kenoPicks == nil {
+		s.writeResponse(client, "ERROR Pick your numbers first with PICK <numbers>")
+		return
+	}
+
+	picks := make([]string, len(client.kenoPicks))
+	for i, n := range client.kenoPicks {
+		picks[i] = strconv.Itoa(n)
+	}
+	s.playOnce(client, "keno", args[0], "DRAW", picks)
+}
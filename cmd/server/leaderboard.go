@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/security"
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Players LEADERBOARD shows when no count is given
+const defaultLeaderboardPlayers = 10
+
+var leaderboardTitles = map[string]string{
+	vault.LeaderboardBalance:    "Top balances",
+	vault.LeaderboardNet:        "Top net winnings",
+	vault.LeaderboardWinRate:    fmt.Sprintf("Top win rates over %d+ rounds", vault.LeaderboardMinRounds),
+	vault.LeaderboardBiggestWin: "Biggest single wins",
+}
+
+// LEADERBOARD <category> [n] [page] ranks the players in a category, n to a page
+func (s *Server) handleLeaderboard(client *ClientState, args []string) {
+	usage := fmt.Sprintf("Usage: LEADERBOARD <%s> [players] [page]", strings.Join(security.LeaderboardCategories, "|"))
+	if len(args) == 0 || len(args) > 3 {
+		s.writeError(client, "USAGE", usage)
+		return
+	}
+	category := strings.ToLower(args[0])
+	perPage, page := defaultLeaderboardPlayers, 1
+	for i, arg := range args[1:] {
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			s.writeError(client, "USAGE", usage)
+			return
+		}
+		if i == 0 {
+			perPage = n
+		} else {
+			page = n
+		}
+	}
+
+	entries, pages, err := s.authService.Leaderboard(category, perPage, page)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+	if pages == 0 {
+		s.writeData(client, "OK Nobody is on this leaderboard yet", leaderboardData(category, entries, page, pages))
+		return
+	}
+	if len(entries) == 0 {
+		s.writeResponse(client, fmt.Sprintf("ERROR There are only %d pages", pages))
+		return
+	}
+
+	board := fmt.Sprintf("OK %s (page %d of %d):", leaderboardTitles[category], page, pages)
+	for _, e := range entries {
+		board += fmt.Sprintf("\n  %3d. %-16s %s", e.Rank, e.Username, leaderboardValue(category, e))
+		if client.user != nil && e.UserID == client.user.ID {
+			board += "  (you)"
+		}
+	}
+	if page < pages {
+		board += fmt.Sprintf("\nNext: LEADERBOARD %s %d %d", category, perPage, page+1)
+	}
+	s.writeData(client, board, leaderboardData(category, entries, page, pages))
+}
+
+func leaderboardData(category string, entries []*vault.LeaderboardEntry, page, pages int) map[string]any {
+	if entries == nil {
+		entries = []*vault.LeaderboardEntry{}
+	}
+	return map[string]any{"category": category, "entries": entries, "page": page, "pages": pages}
+}
+
+// The figure a category ranks a player by
+func leaderboardValue(category string, e *vault.LeaderboardEntry) string {
+	switch category {
+	case vault.LeaderboardNet:
+		return fmt.Sprintf("$%.2f", float64(e.Net)/100)
+	case vault.LeaderboardWinRate:
+		return fmt.Sprintf("%.1f%% of %d rounds", e.WinRate(), e.GamesPlayed)
+	case vault.LeaderboardBiggestWin:
+		return fmt.Sprintf("$%.2f", float64(e.BiggestWin)/100)
+	default:
+		return fmt.Sprintf("$%.2f", float64(e.Balance)/100)
+	}
+}
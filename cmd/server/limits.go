@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func (s *Server) handleLimits(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	if len(args) == 0 {
+		limits, err := s.authService.GetLimits(client.user.ID)
+		if err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return
+		}
+		s.writeResponse(client, "OK Your limits:\n"+formatLimits(limits))
+		return
+	}
+
+	if len(args) != 4 || strings.ToUpper(args[0]) != "SET" {
+		s.writeError(client, "USAGE", "Usage: LIMITS | LIMITS SET <daily|weekly> <loss|wager> <amount>")
+		return
+	}
+
+	amount, err := parseLimitAmount(args[3])
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	period, kind := strings.ToLower(args[1]), strings.ToLower(args[2])
+	if err := s.authService.SetLimit(client.user.ID, period, kind, amount, false); err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	s.writeResponse(client, fmt.Sprintf("OK Set %s %s limit to $%.2f", period, kind, float64(amount)/100))
+}
+
+// Parses a dollar amount for a limit; "off" or 0 clears it
+func parseLimitAmount(arg string) (int64, error) {
+	if strings.EqualFold(arg, "off") {
+		return 0, nil
+	}
+
+	dollars, err := strconv.ParseFloat(arg, 64)
+	if err != nil || dollars < 0 {
+		return 0, fmt.Errorf("invalid limit amount")
+	}
+
+	return int64(dollars * 100), nil
+}
+
+func formatLimits(limits *vault.UserLimits) string {
+	format := func(cents int64) string {
+		if cents == 0 {
+			return "none"
+		}
+		return fmt.Sprintf("$%.2f", float64(cents)/100)
+	}
+
+	out := fmt.Sprintf("  Daily Loss: %s\n", format(limits.DailyLoss))
+	out += fmt.Sprintf("  Weekly Loss: %s\n", format(limits.WeeklyLoss))
+	out += fmt.Sprintf("  Daily Wager: %s\n", format(limits.DailyWager))
+	out += fmt.Sprintf("  Weekly Wager: %s", format(limits.WeeklyWager))
+	return out
+}
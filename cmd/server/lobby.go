@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+// Most shared tables open at once for one stake level, and hosted by players
+const (
+	maxTablesPerStake = 8
+	maxHostedTables   = 10
+)
+
+// The shared tables currently open. There is always at least one house table
+// per stake level; another opens when JOIN finds them all full, players can
+// HOST tables with their own rules, and extra tables close once they empty.
+// Lock order is lobby.mu before a table's mu.
+type lobby struct {
+	mu     sync.Mutex
+	tables []*sharedTable
+
+	// Tables ever opened per stake, so a new table never reuses an old ID
+	opened map[string]int
+}
+
+func newLobby() *lobby {
+	l := &lobby{opened: make(map[string]int)}
+	for _, t := range game.Tables {
+		l.open(t)
+	}
+	return l
+}
+
+// Opens a new shared table for a stake level. Callers hold l.mu.
+func (l *lobby) open(stake game.Table) *sharedTable {
+	l.opened[stake.ID]++
+	st := &sharedTable{
+		id:    fmt.Sprintf("%s-%d", stake.ID, l.opened[stake.ID]),
+		table: game.NewSharedTable(stake),
+	}
+	l.tables = append(l.tables, st)
+	return st
+}
+
+// Opens a table at a stake level with a player's own rules. Callers hold l.mu.
+func (l *lobby) host(stake game.Table, rules game.Rules, host string) (*sharedTable, error) {
+	hosted := 0
+	for _, st := range l.tables {
+		if st.host == "" {
+			continue
+		}
+		if st.host == host {
+			return nil, fmt.Errorf("You already host %s", st.id)
+		}
+		hosted++
+	}
+	if hosted >= maxHostedTables {
+		return nil, fmt.Errorf("Too many hosted tables are open, try again shortly")
+	}
+
+	stake.Rules = rules
+	st := l.open(stake)
+	st.host = host
+	return st, nil
+}
+
+// Finds the table JOIN should seat a player at: a table by its ID, or for a
+// stake level the first one with a free seat, opening a new one when they're
+// all full. Callers hold l.mu.
+func (l *lobby) pick(id string) (*sharedTable, error) {
+	if st := l.find(id); st != nil {
+		return st, nil
+	}
+
+	stake, ok := game.FindTable(id)
+	if !ok {
+		return nil, fmt.Errorf("Unknown table. Use TABLES to see the list")
+	}
+
+	count := 0
+	for _, st := range l.tables {
+		if st.table.Table.ID != stake.ID || st.host != "" {
+			continue
+		}
+		count++
+		st.mu.Lock()
+		free := st.table.Occupied() < game.MaxSeats && st.frozen == ""
+		st.mu.Unlock()
+		if free {
+			return st, nil
+		}
+	}
+
+	if count >= maxTablesPerStake {
+		return nil, fmt.Errorf("All %s tables are full, try again shortly", stake.Name)
+	}
+	return l.open(stake), nil
+}
+
+// Finds an open table by its ID. Callers hold l.mu.
+func (l *lobby) find(id string) *sharedTable {
+	for _, st := range l.tables {
+		if strings.EqualFold(st.id, id) {
+			return st
+		}
+	}
+	return nil
+}
+
+// Closes empty tables, keeping one house table per stake level open and
+// frozen tables until an admin reopens them
+func (s *Server) pruneTables() {
+	l := s.lobby
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	kept := make(map[string]bool)
+	open := l.tables[:0]
+	for _, st := range l.tables {
+		stake := st.table.Table.ID
+
+		st.mu.Lock()
+		empty := st.table.Occupied() == 0 && st.frozen == ""
+		if empty && (st.host != "" || kept[stake]) {
+			s.stopBetTimer(st)
+			s.stopTurnTimer(st)
+			st.mu.Unlock()
+			continue
+		}
+		st.mu.Unlock()
+
+		if st.host == "" {
+			kept[stake] = true
+		}
+		open = append(open, st)
+	}
+	for i := len(open); i < len(l.tables); i++ {
+		l.tables[i] = nil
+	}
+	l.tables = open
+}
+
+// Lists the shared tables for TABLES, marking the one the player is seated
+// at, and returns them as MODE JSON shows them too
+func (s *Server) lobbyListing(client *ClientState) (string, []map[string]any) {
+	l := s.lobby
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	listing := "\nMultiplayer tables:"
+	data := []map[string]any{}
+	for _, st := range l.tables {
+		st.mu.Lock()
+		free := game.MaxSeats - st.table.Occupied()
+		status := "betting"
+		if st.table.Phase != game.PhaseWaitingForBet {
+			status = "in play"
+		}
+		if st.frozen != "" {
+			status = "frozen"
+		}
+		st.mu.Unlock()
+
+		marker := " "
+		if client.seatedAt == st {
+			marker = "*"
+		}
+		rules := s.rulesFor(client, st.table.Table)
+		seats := fmt.Sprintf("%d/%d seats free", free, game.MaxSeats)
+		if free == 0 {
+			seats = "full"
+		}
+		stakes := fmt.Sprintf("$%.2f - $%.2f", float64(rules.MinBet)/100, float64(rules.MaxBet)/100)
+		listing += fmt.Sprintf("\n %s %-7s Blackjack  %-14s %-20s %-15s %-8s %s", marker, st.id, st.table.Table.Name, stakes, seats, status,
+			s.describeRules(st))
+		if st.host != "" {
+			listing += ", hosted by " + st.host
+		}
+		data = append(data, map[string]any{
+			"id":         st.id,
+			"name":       st.table.Table.Name,
+			"min_bet":    rules.MinBet,
+			"max_bet":    rules.MaxBet,
+			"free_seats": free,
+			"status":     status,
+			"rules":      s.describeRules(st),
+			"host":       st.host,
+			"seated":     client.seatedAt == st,
+		})
+	}
+	listing += "\nUse JOIN <table> to sit with other players; JOIN <stake> picks a house table with a free seat."
+	listing += "\nUse HOST <stake> [options] to open a table with your own rules."
+	return listing, data
+}
+
+// Lists every table with players or a freeze for ADMIN TABLES: its phase, the
+// money bet this round and who is seated
+func (s *Server) adminTables() string {
+	l := s.lobby
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	report := "Active tables:"
+	active := 0
+	for _, st := range l.tables {
+		st.mu.Lock()
+		if st.table.Occupied() == 0 && st.frozen == "" {
+			st.mu.Unlock()
+			continue
+		}
+		active++
+
+		var pot int64
+		var players []string
+		for i, seat := range st.table.Seats {
+			if seat == nil {
+				continue
+			}
+			player := seat.Name
+			if seat.Game != nil {
+				pot += seat.Game.Bet
+				player += fmt.Sprintf(" $%.2f", float64(seat.Game.Bet)/100)
+			}
+			if i == st.table.Turn {
+				player += " (to act)"
+			}
+			if seat.Away {
+				player += " (left)"
+			}
+			players = append(players, player)
+		}
+
+		phase := "betting"
+		switch st.table.Phase {
+		case game.PhasePlayerTurn:
+			phase = "in play"
+		case game.PhaseGameOver:
+			phase = "settling"
+		}
+		report += fmt.Sprintf("\n  %-7s %-14s %-9s pot $%-10.2f %s", st.id, st.table.Table.Name, phase, float64(pot)/100, strings.Join(players, ", "))
+		if st.frozen != "" {
+			report += " [FROZEN: " + st.frozen + "]"
+		}
+		st.mu.Unlock()
+	}
+
+	if active == 0 {
+		return "No tables are in use"
+	}
+	return report
+}
+
+// Stops all play at a table until an admin reopens it, keeping the round as it
+// stands for investigation
+func (s *Server) freezeTable(actor, ip, id, reason string) (string, error) {
+	l := s.lobby
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	st := l.find(id)
+	if st == nil {
+		return "", fmt.Errorf("no open table %s", id)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.frozen != "" {
+		return "", fmt.Errorf("%s is already frozen", st.id)
+	}
+	st.frozen = reason
+	s.stopBetTimer(st)
+	s.stopTurnTimer(st)
+
+	s.authService.LogTableFreeze(actor, st.id, reason, ip)
+	s.showTable(st, nil, "An administrator froze this table: "+reason)
+	return fmt.Sprintf("Froze %s", st.id), nil
+}
+
+// Reopens a frozen table, restarting its betting window or turn timer
+func (s *Server) thawTable(actor, ip, id string) (string, error) {
+	l := s.lobby
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	st := l.find(id)
+	if st == nil {
+		return "", fmt.Errorf("no open table %s", id)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.frozen == "" {
+		return "", fmt.Errorf("%s is not frozen", st.id)
+	}
+	st.frozen = ""
+
+	s.authService.LogTableThaw(actor, st.id, ip)
+	s.showTable(st, nil, "An administrator reopened this table")
+	if st.table.Phase == game.PhaseWaitingForBet && st.table.Bettors() > 0 {
+		s.startBetTimer(st)
+	}
+	s.progressTable(st)
+	return fmt.Sprintf("Reopened %s", st.id), nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Subsystems with a logger and level of their own
+var logSubsystems = []string{"server", "vault", "game", "auth"}
+
+// loggers writes the server's log as text or JSON through one slog.Logger per
+// subsystem, each with a level that can be changed while the server runs
+type loggers struct {
+	server *slog.Logger // Connections, the console and admin commands
+	vault  *slog.Logger // Database upkeep and failures
+	game   *slog.Logger // Dealing, settling and payouts
+	auth   *slog.Logger // Logins, sessions and account security
+
+	levels map[string]*slog.LevelVar
+}
+
+// Builds the loggers from LOG_FORMAT and LOG_LEVEL, which is a level for every
+// subsystem and/or subsystem=level pairs, e.g. "info,vault=debug"
+func newLoggers(w io.Writer, format, levels string) (*loggers, error) {
+	l := &loggers{levels: make(map[string]*slog.LevelVar)}
+	made := make(map[string]*slog.Logger)
+	for _, name := range logSubsystems {
+		level := new(slog.LevelVar)
+		opts := &slog.HandlerOptions{Level: level}
+
+		var handler slog.Handler
+		switch strings.ToLower(format) {
+		case "", "text":
+			handler = slog.NewTextHandler(w, opts)
+		case "json":
+			handler = slog.NewJSONHandler(w, opts)
+		default:
+			return nil, fmt.Errorf("invalid LOG_FORMAT %q: must be text or json", format)
+		}
+
+		l.levels[name] = level
+		made[name] = slog.New(handler).With("subsystem", name)
+	}
+	l.server, l.vault, l.game, l.auth = made["server"], made["vault"], made["game"], made["auth"]
+
+	for _, setting := range strings.Split(levels, ",") {
+		setting = strings.TrimSpace(setting)
+		if setting == "" {
+			continue
+		}
+		subsystem, level, ok := strings.Cut(setting, "=")
+		if !ok {
+			subsystem, level = "", setting
+		}
+		if err := l.setLevel(subsystem, level); err != nil {
+			return nil, fmt.Errorf("invalid LOG_LEVEL: %w", err)
+		}
+	}
+	return l, nil
+}
+
+// Sets one subsystem's level, or every subsystem's when it is empty or "all"
+func (l *loggers) setLevel(subsystem, level string) error {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("unknown level %q: use debug, info, warn or error", level)
+	}
+
+	subsystem = strings.ToLower(subsystem)
+	if subsystem == "" || subsystem == "all" {
+		for _, v := range l.levels {
+			v.Set(parsed)
+		}
+		return nil
+	}
+
+	v, ok := l.levels[subsystem]
+	if !ok {
+		return fmt.Errorf("unknown subsystem %q: use %s or all", subsystem, strings.Join(logSubsystems, ", "))
+	}
+	v.Set(parsed)
+	return nil
+}
+
+// Lists each subsystem's level for ADMIN LOGLEVEL
+func (l *loggers) report() string {
+	report := "Log levels:"
+	for _, name := range logSubsystems {
+		report += fmt.Sprintf("\n  %-7s %s", name, l.levels[name].Level())
+	}
+	return report
+}
+
+// Logs a startup failure and exits, as log.Fatal would
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "err", err)
+	os.Exit(1)
+}
@@ -2,13 +2,16 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/alessandrosisniegas/casino/core/game"
@@ -17,53 +20,229 @@ import (
 )
 
 type ClientState struct {
-	conn      net.Conn
-	sessionID string
-	user      *vault.User
-	game      *game.Game
+	id          int // Numbered by the hub for ADMIN CONNECTIONS and KICK
+	conn        net.Conn
+	ip          string
+	connectedAt time.Time
+	sessionID   string
+	user        *vault.User
+	game        *game.Game
+	table       game.Table
+
+	// Set when the connection authenticated with AUTH <api key> instead of LOGIN
+	apiKeyID string
+	scope    string
+
+	// Invite code supplied with INVITE ahead of SIGNUP
+	inviteCode   string
+	referralCode string
+
+	// Transfer waiting for TRANSFER CONFIRM
+	pendingTransfer *pendingTransfer
+
+	// Shared table the connection is seated at with JOIN, and the seat index
+	seatedAt *sharedTable
+	seat     int
+
+	// Serializes writes since events can be pushed from other goroutines
+	writeMu sync.Mutex
+
+	// Set by FRAMING ON: every message is followed by a frameEnd line.
+	// Guarded by writeMu.
+	framed bool
+
+	// Set by MODE JSON: messages are sent as single-line JSON objects.
+	// Guarded by writeMu.
+	json bool
+
+	// Set while a solo hand is in play, for ADMIN CONNECTIONS
+	playing atomic.Bool
+
+	// Held while a command runs, so the solo turn timer never acts mid-command
+	cmdMu sync.Mutex
+
+	// Rate limit of the connection's commands besides auth ones, which are
+	// limited by address. Guarded by cmdMu.
+	cmdBucket tokenBucket
+
+	// Countdown for the solo hand in play. turnSeq is bumped every time it
+	// starts so one that was replaced does nothing. Guarded by cmdMu.
+	turnTimer *time.Timer
+	turnSeq   int
+
+	// Rounds of engine games in play, by game. Guarded by cmdMu.
+	rounds map[string]*engineRound
+
+	// Keno numbers DRAW plays. Guarded by cmdMu.
+	kenoPicks []int
+
+	// Solo hand settled last, so the gRPC API can report a hand that is
+	// already over. Guarded by cmdMu.
+	settled *game.Game
 }
 
+// Line ending each message for a connection that turned on FRAMING
+const frameEnd = "."
+
 type Server struct {
-	authService *security.AuthService
-	db          *vault.DB
+	authService    *security.AuthService
+	db             *vault.DB
+	config         Config
+	hub            *Hub
+	lobby          *lobby
+	chat           *chatFlood
+	reactions      *chatFlood
+	userLocks      *userLocks
+	jackpotTrigger *game.JackpotTrigger
+	stats          *serverStats
+	rtp            *rtpMonitor
+	log            *loggers
+	webhooks       *webhooks
+	loginFailures  *loginFailures
+	mismatches     map[int]int64 // Balances already alerted as not matching the ledger
+	rpc            *rpcSessions
+	accountLimits  *bucketMap[int]    // Command buckets by user ID
+	authLimits     *bucketMap[string] // Auth command buckets by IP
+	conns          *connLimits
+	soloTurn       time.Duration // Time to act on a solo hand, from SOLO_TURN_SECONDS; 0 for no limit
 }
 
 func main() {
+	// "rngaudit [shuffles]" tests the shuffle and exits, without a database
+	if len(os.Args) > 1 && os.Args[1] == "rngaudit" {
+		os.Exit(rngAuditCommand(os.Args[2:]))
+	}
+
+	cfg := loadConfig()
+	// House tables take the operator's soft 17 rule
+	for i := range game.Tables {
+		game.Tables[i].Rules.DealerHitsSoft17 = cfg.DealerHitsSoft17
+	}
+
+	logs, err := newLoggers(os.Stderr, cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	// Anything still written with the log package goes to the server logger
+	slog.SetDefault(logs.server)
+
 	// Initialize database (use absolute path from project root)
-	dbPath := filepath.Join("..", "..", "data", "casino.db")
-	if err := os.MkdirAll(filepath.Join("..", "..", "data"), 0755); err != nil {
-		log.Fatal("Failed to create data directory:", err)
+	dbPath := cfg.DBPath
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		fatal(logs.vault, "Failed to create data directory", err)
 	}
 
-	db, err := vault.NewDB(dbPath)
+	db, err := vault.NewDBWithOptions(dbPath, vault.DBOptions{
+		WAL:         cfg.DBWAL,
+		BusyTimeout: time.Duration(cfg.DBBusyTimeout) * time.Millisecond,
+		ForeignKeys: cfg.DBForeignKeys,
+	})
 	if err != nil {
-		log.Fatal("Failed to initialize database:", err)
+		fatal(logs.vault, "Failed to initialize database", err)
 	}
 	defer db.Close()
 
+	// "migrate [version]" shows or changes the schema version instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		code := migrateCommand(db, os.Args[2:])
+		db.Close()
+		os.Exit(code)
+	}
+
 	// Initialize auth service
-	authService := security.NewAuthService(db)
+	authConfig := security.AuthConfig{
+		HideUsernameExistence: cfg.HideUsernames,
+		SignupsPerIPPerDay:    cfg.SignupLimit,
+		RequireInviteCode:     cfg.RequireInvite,
+		DailyBonus:            int64(cfg.DailyBonus) * 100,
+		RebuyAmount:           int64(cfg.RebuyAmount) * 100,
+		RebuyBelow:            game.LowestMinBet(),
+		RebuysPerDay:          cfg.RebuyLimit,
+		TransferDailyLimit:    int64(cfg.TransferLimit) * 100,
+		DepositMin:            int64(cfg.DepositMin) * 100,
+		DepositMax:            int64(cfg.DepositMax) * 100,
+		WithdrawMin:           int64(cfg.WithdrawMin) * 100,
+		WithdrawMax:           int64(cfg.WithdrawMax) * 100,
+		JackpotPercent:        cfg.JackpotPercent,
+		JackpotSeed:           int64(cfg.JackpotSeed) * 100,
+		CashbackPercent:       cfg.CashbackPercent,
+		CashbackPeriod:        cfg.CashbackPeriod,
+		ReferralBonus:         int64(cfg.ReferralBonus) * 100,
+		ReferralWager:         int64(cfg.ReferralWager) * 100,
+		StreakBonus:           int64(cfg.StreakBonus) * 100,
+		GuestBalance:          int64(cfg.GuestBalance) * 100,
+		GuestIdleTimeout:      time.Duration(cfg.GuestIdleHours) * time.Hour,
+	}
+	if authConfig.StreakMilestones, err = security.ParseStreakMilestones(cfg.StreakMilestones); err != nil {
+		fatal(logs.server, "Invalid STREAK_MILESTONES", err)
+	}
+	if game.KenoPays, err = game.ParseKenoPayTable(cfg.KenoPayTable); err != nil {
+		fatal(logs.server, "Invalid KENO_PAY_TABLE", err)
+	}
+	if cfg.CashbackPeriod != security.LimitDaily && cfg.CashbackPeriod != security.LimitWeekly {
+		fatal(logs.server, "Invalid CASHBACK_PERIOD", fmt.Errorf("must be daily or weekly"))
+	}
+	if cfg.AbandonedHandPolicy != abandonStand && cfg.AbandonedHandPolicy != abandonRefund {
+		fatal(logs.server, "Invalid ABANDONED_HAND_POLICY", fmt.Errorf("must be stand or refund"))
+	}
+	switch {
+	case cfg.PepperFile != "":
+		if authConfig.Peppers, err = security.LoadPepperFile(cfg.PepperFile); err != nil {
+			fatal(logs.auth, "Failed to load password pepper", err)
+		}
+	case cfg.Pepper != "":
+		authConfig.Peppers = security.NewPepperSet(cfg.PepperVersion, []byte(cfg.Pepper))
+	}
+	if cfg.SessionTokens {
+		// A secret made up at startup would log everyone out on a restart and
+		// not match the other servers sharing the sessions
+		if cfg.SessionTokenSecret == "" {
+			fatal(logs.auth, "Invalid session token settings", fmt.Errorf("SESSION_TOKENS=1 needs SESSION_TOKEN_SECRET"))
+		}
+		authConfig.TokenSecret = []byte(cfg.SessionTokenSecret)
+	}
+	authService := security.NewAuthServiceWithConfig(db, authConfig)
+	if cfg.RedisURL != "" {
+		store, err := vault.NewRedisSessionStore(cfg.RedisURL)
+		if err != nil {
+			fatal(logs.auth, "Failed to open Redis session store", err)
+		}
+		defer store.Close()
+		authService.SetSessionStore(store)
+	}
 
-	server := &Server{
-		authService: authService,
-		db:          db,
+	server, err := newServer(cfg, db, authService, logs)
+	if err != nil {
+		fatal(logs.server, "Invalid server settings", err)
 	}
 
-	// Bind address:
-	// - Default is local only where we bind 127.0.0.1
-	// - If user wants to run it on LAN we bind 0.0.0.0
-	addr := "127.0.0.1:9090"
-	if os.Getenv("LAN") == "1" {
-		addr = "0.0.0.0:9090"
+	// "export ..." writes audit events or the ledger to stdout instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := server.exportCommand(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	ln, err := net.Listen("tcp", addr)
+	// Hands left unfinished by a crash wait for their players until they're
+	// abandoned
+	server.sweepAbandonedGames()
+	// Tables don't survive a restart, so bets held at them go back
+	server.releaseHeldBets()
+
+	ln, err := listen(cfg, logs.server)
 	if err != nil {
-		log.Fatal(err)
+		fatal(logs.server, "Failed to listen", err)
 	}
 	defer ln.Close()
 
-	fmt.Println("Casino Server listening on", ln.Addr().String())
+	if cfg.TLSCert != "" {
+		fmt.Println("Casino Server listening on", ln.Addr().String(), "(TLS)")
+	} else {
+		fmt.Println("Casino Server listening on", ln.Addr().String())
+	}
 	fmt.Println("Database initialized at", dbPath)
 	fmt.Println("Type 'help' for server commands, 'quit' to shutdown")
 	fmt.Print("server> ")
@@ -76,40 +255,53 @@ func main() {
 		ticker := time.NewTicker(1 * time.Hour)
 		defer ticker.Stop()
 		for range ticker.C {
-			if err := db.CleanupExpiredSessions(); err != nil {
-				log.Println("Failed to cleanup expired sessions:", err)
+			if err := authService.CleanupExpiredSessions(); err != nil {
+				logs.vault.Error("Failed to cleanup expired sessions", "err", err)
+			}
+			if err := authService.PruneRevokedTokens(); err != nil {
+				logs.auth.Error("Failed to cleanup revoked tokens", "err", err)
 			}
+			server.pruneRPCSessions()
+			server.pruneRateLimits()
+			server.purgeGuests()
+			server.sweepAbandonedGames()
+			server.reconcileBalances()
 		}
 	}()
 
-	// Handle server commands from stdin
+	// Pay cashback and file daily reports for finished periods, catching up on
+	// any missed while stopped
 	go func() {
-		scanner := bufio.NewScanner(os.Stdin)
-		for scanner.Scan() {
-			command := strings.TrimSpace(strings.ToUpper(scanner.Text()))
-			switch command {
-			case "QUIT", "EXIT", "STOP":
-				fmt.Println("Shutting down server...")
-				shutdown <- true
-				return
-			case "HELP":
-				fmt.Println("Server commands:")
-				fmt.Println("  help  - Show this help")
-				fmt.Println("  stats - Show server statistics")
-				fmt.Println("  users - List all users")
-				fmt.Println("  quit  - Shutdown server")
-			case "STATS":
-				server.showStats()
-			case "USERS":
-				server.showUsers()
-			case "":
-			default:
-				fmt.Printf("Unknown command: %s (type 'help' for commands)\n", command)
-			}
-			fmt.Print("server> ")
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+		for {
+			server.settleCashback()
+			server.fileDailyReports()
+			<-ticker.C
+		}
+	}()
+
+	// Announce events as they start and end
+	go func() {
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
+		for {
+			server.announceEvents()
+			server.pruneTables()
+			<-ticker.C
 		}
 	}()
 
+	if cfg.MetricsAddr != "" {
+		go server.serveMetrics(cfg.MetricsAddr)
+	}
+	if cfg.GRPCAddr != "" {
+		go server.serveGRPC(cfg.GRPCAddr)
+	}
+
+	// Handle server commands from stdin
+	go server.runConsole(shutdown)
+
 	// Accept connections until shutdown signal
 	go func() {
 		for {
@@ -120,13 +312,18 @@ func main() {
 				case <-shutdown:
 					return
 				default:
-					log.Println("Accept error:", err)
+					logs.server.Error("Accept error", "err", err)
 					continue
 				}
 			}
 
 			// Handle each client concurrently so slow clients do not block others
-			go server.handleClient(conn)
+			go func() {
+				if server.admitConn(conn) {
+					defer server.conns.release(remoteIP(conn))
+					server.handleClient(conn)
+				}
+			}()
 		}
 	}()
 
@@ -141,7 +338,11 @@ func (s *Server) handleClient(conn net.Conn) {
 	// Set connection timeout
 	conn.SetReadDeadline(time.Now().Add(30 * time.Minute))
 
-	client := &ClientState{conn: conn}
+	client := &ClientState{conn: conn, ip: remoteIP(conn), connectedAt: time.Now(), table: game.Tables[0]}
+	s.hub.add(client)
+	defer s.hub.remove(client)
+	s.log.server.Debug("Client connected", "client", client.id, "ip", client.ip)
+	defer s.leaveTable(client)
 	scanner := bufio.NewScanner(conn)
 
 	s.writeResponse(client, "OK Welcome to Casino! Use SIGNUP <username> <password> or LOGIN <username> <password>")
@@ -161,28 +362,239 @@ func (s *Server) handleClient(conn net.Conn) {
 		}
 
 		command := strings.ToUpper(parts[0])
+		s.stats.countCommand()
+		client.cmdMu.Lock()
 		s.handleCommand(client, command, parts[1:])
+		client.cmdMu.Unlock()
+	}
+
+	client.cmdMu.Lock()
+	defer client.cmdMu.Unlock()
+	s.stopSoloTurn(client)
+	s.closeEngines(client)
+	if client.game != nil {
+		// The player left in the middle of a solo hand, which stays saved
+		// for when they log back in
+		s.stats.soloGames.Add(-1)
 	}
 
 	if err := scanner.Err(); err != nil {
-		log.Println("Client connection error:", err)
+		if errors.Is(err, net.ErrClosed) {
+			// Hung up on by the server, e.g. ADMIN KICK
+			s.log.server.Debug("Client connection closed", "client", client.id, "ip", client.ip)
+		} else {
+			s.log.server.Warn("Client connection error", "client", client.id, "ip", client.ip, "err", err)
+		}
+	}
+	s.log.server.Debug("Client disconnected", "client", client.id, "ip", client.ip)
+}
+
+func remoteIP(conn net.Conn) string {
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return conn.RemoteAddr().String()
 	}
+	return host
 }
 
 func (s *Server) handleCommand(client *ClientState, command string, args []string) {
+	command = resolveAlias(command)
+	if !s.allowCommand(client, command) {
+		return
+	}
+	if !s.requireMember(client, command) || !s.requireUnseated(client, command) || !s.requireFeature(client, command) {
+		return
+	}
+
 	switch command {
 	case "SIGNUP", "REGISTER":
 		s.handleSignup(client, args)
+	case "INVITE":
+		s.handleInvite(client, args)
+	case "GUEST":
+		s.handleGuest(client, args)
+	case "REFER":
+		s.handleRefer(client, args)
+	case "REFERRAL":
+		s.handleReferral(client, args)
 	case "LOGIN":
 		s.handleLogin(client, args)
 	case "LOGOUT":
 		s.handleLogout(client, args)
+	case "REMEMBER":
+		s.handleRemember(client, args)
+	case "RESUME":
+		s.handleResume(client, args)
 	case "BALANCE":
 		s.handleBalance(client, args)
 	case "STATS":
 		s.handleStats(client, args)
+	case "PROFIT":
+		s.handleProfit(client, args)
+	case "HISTORY":
+		s.handleHistory(client, args)
+	case "EVENTS":
+		s.handleEvents(client, args)
 	case "WHOAMI":
 		s.handleWhoami(client, args)
+	case "AUTH":
+		s.handleAuth(client, args)
+	case "APIKEY":
+		s.handleAPIKey(client, args)
+	case "LIMITS":
+		s.handleLimits(client, args)
+	case "ALLOWIP":
+		s.handleAllowIP(client, args)
+	case "DAILY":
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+		s.handleDaily(client, args)
+	case "REBUY":
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+		s.handleRebuy(client, args)
+	case "DEPOSIT":
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+		s.handleDeposit(client, args)
+	case "WITHDRAW":
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+		s.handleWithdraw(client, args)
+	case "TRANSFER":
+		s.handleTransfer(client, args)
+	case "ACHIEVEMENTS":
+		s.handleAchievements(client, args)
+	case "CASHBACK":
+		s.handleCashback(client, args)
+	case "SHOP":
+		s.handleShop(client, args)
+	case "PROFILE":
+		s.handleProfile(client, args)
+	case "EQUIP":
+		s.handleEquip(client, args)
+	case "BUY":
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+		s.handleBuy(client, args)
+	case "REDEEM":
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+		s.handleRedeem(client, args)
+	case "JACKPOT":
+		s.handleJackpot(client, args)
+	case "LEADERBOARD":
+		s.handleLeaderboard(client, args)
+	case "TABLES":
+		s.handleTables(client, args)
+	case "SIT":
+		s.handleSit(client, args)
+	case "JOIN":
+		s.handleJoin(client, args)
+	case "HOST":
+		s.handleHost(client, args)
+	case "LEAVE":
+		s.handleLeave(client, args)
+	case "TABLE":
+		s.handleTable(client, args)
+	case "REVIEW":
+		s.handleReview(client, args)
+	case "CHAT":
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+		s.handleChat(client, args)
+	case "REACT":
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+		s.handleReact(client, args)
+	case "MSG":
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+		s.handleMsg(client, args)
+	case "FEED":
+		s.handleFeed(client, args)
+	case "BLOCK":
+		s.handleBlock(client, args)
+	case "UNBLOCK":
+		s.handleUnblock(client, args)
+	case "MUTE":
+		s.handleMute(client, args)
+	case "UNMUTE":
+		s.handleUnmute(client, args)
+	case "ADMIN":
+		s.handleAdmin(client, args)
+	case "BET", "HIT", "STAND", "DOUBLEDOWN", "DOUBLE", "SURRENDER":
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+		s.handleGameCommand(client, command, args)
+	case "BACCARAT":
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+		s.handleBaccarat(client, args)
+	case "GAMES":
+		s.handleGames(client, args)
+	case "PLAY":
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+		s.handlePlay(client, args)
+	case "HILO":
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+		s.playEngine(client, "hilo", args)
+	case "HOLDEM":
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+		s.playEngine(client, "holdem", args)
+	case "PICK":
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+		s.handlePick(client, args)
+	case "DRAW":
+		if !s.requireScope(client, security.ScopePlay) {
+			return
+		}
+		s.handleDraw(client, args)
+	case "QUIT", "EXIT":
+		s.writeResponse(client, "OK Goodbye!")
+		client.conn.Close()
+	case "HELP":
+		s.handleHelp(client, args)
+	case "FRAMING":
+		s.handleFraming(client, args)
+	case "MODE":
+		s.handleMode(client, args)
+	case "HELLO", "VERSION":
+		s.handleHello(client, args)
+	case "PING":
+		// Any command resets the idle timeout; this one does nothing else
+		s.writeResponse(client, "OK PONG")
+	default:
+		s.writeError(client, "UNKNOWN_COMMAND", "Unknown command. Type HELP for available commands.")
+	}
+}
+
+func (s *Server) handleGameCommand(client *ClientState, command string, args []string) {
+	if client.seatedAt != nil {
+		s.handleTableCommand(client, command, args)
+		return
+	}
+
+	switch command {
 	case "BET":
 		s.handleBet(client, args)
 	case "HIT":
@@ -193,88 +605,191 @@ func (s *Server) handleCommand(client *ClientState, command string, args []strin
 		s.handleDoubleDown(client, args)
 	case "SURRENDER":
 		s.handleSurrender(client, args)
-	case "QUIT", "EXIT":
-		s.writeResponse(client, "OK Goodbye!")
-		client.conn.Close()
-	case "HELP":
-		s.handleHelp(client, args)
-	default:
-		s.writeResponse(client, "ERROR Unknown command. Type HELP for available commands.")
 	}
 }
 
 func (s *Server) handleSignup(client *ClientState, args []string) {
-	if len(args) != 2 {
-		s.writeResponse(client, "ERROR Usage: SIGNUP <username> <password>")
+	if len(args) != 2 && len(args) != 3 {
+		s.writeError(client, "USAGE", "Usage: SIGNUP <username> <password> [invite code]")
 		return
 	}
 
 	username, password := args[0], args[1]
-	user, err := s.authService.RegisterUser(username, password)
+	invite := client.inviteCode
+	if len(args) == 3 {
+		invite = args[2]
+	}
+
+	user, err := s.authService.RegisterUserWithOptions(username, password, security.RegisterOptions{
+		IP:           client.ip,
+		InviteCode:   strings.ToUpper(invite),
+		ReferralCode: client.referralCode,
+	})
 	if err != nil {
 		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
 		return
 	}
 
-	s.writeResponse(client, fmt.Sprintf("OK Account created for %s with balance $%.2f", user.Username, float64(user.Balance)/100))
+	client.inviteCode = ""
+	client.referralCode = ""
+	s.writeData(client, fmt.Sprintf("OK Account created for %s with balance $%.2f", user.Username, float64(user.Balance)/100), userData(user))
+}
+
+// Remembers an invite code for the next SIGNUP so the client can still prompt for the password
+func (s *Server) handleInvite(client *ClientState, args []string) {
+	if len(args) != 1 {
+		s.writeError(client, "USAGE", "Usage: INVITE <code>")
+		return
+	}
+
+	client.inviteCode = args[0]
+	s.writeResponse(client, "OK Invite code saved, now use SIGNUP <username> <password>")
+}
+
+// Remembers a referral code for the next SIGNUP
+func (s *Server) handleRefer(client *ClientState, args []string) {
+	if len(args) != 1 {
+		s.writeError(client, "USAGE", "Usage: REFER <code>")
+		return
+	}
+
+	client.referralCode = args[0]
+	s.writeResponse(client, "OK Referral code saved, now use SIGNUP <username> <password>")
 }
 
 func (s *Server) handleLogin(client *ClientState, args []string) {
 	if len(args) != 2 {
-		s.writeResponse(client, "ERROR Usage: LOGIN <username> <password>")
+		s.writeError(client, "USAGE", "Usage: LOGIN <username> <password>")
 		return
 	}
 
 	username, password := args[0], args[1]
-	sessionID, user, err := s.authService.LoginUser(username, password)
+	sessionID, user, err := s.authService.LoginUserFromIP(username, password, client.ip)
 	if err != nil {
+		s.log.auth.Info("Login failed", "user", username, "ip", client.ip, "err", err)
+		s.recordLoginFailure(username, client.ip)
 		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
 		return
 	}
+	s.log.auth.Debug("Logged in", "user", user.Username, "ip", client.ip, "client", client.id)
 
+	s.clearAuth(client)
 	client.sessionID = sessionID
 	client.user = user
+	s.hub.bind(client, user.ID)
 
-	s.writeResponse(client, fmt.Sprintf("OK Welcome back, %s! Balance: $%.2f", user.Username, float64(user.Balance)/100))
+	s.writeData(client, fmt.Sprintf("OK Welcome back, %s! Balance: $%.2f", user.Username, float64(user.Balance)/100), userData(user))
+	s.deliverStoredMessages(client)
+	s.restoreGame(client)
 }
 
-func (s *Server) handleLogout(client *ClientState, _ []string) {
+// REMEMBER hands out the connection's session so a client can RESUME it
+// after reconnecting, until it expires or the player logs out
+func (s *Server) handleRemember(client *ClientState, _ []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
 	if client.sessionID == "" {
-		s.writeResponse(client, "ERROR Not logged in")
+		s.writeResponse(client, "ERROR Only a LOGIN session can be remembered")
+		return
+	}
+
+	s.writeResponse(client, "OK Resume token: "+client.sessionID)
+}
+
+func (s *Server) handleResume(client *ClientState, args []string) {
+	if len(args) != 1 {
+		s.writeError(client, "USAGE", "Usage: RESUME <token>")
+		return
+	}
+
+	user, err := s.authService.ResumeSessionFromIP(args[0], client.ip)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	s.clearAuth(client)
+	client.sessionID = args[0]
+	client.user = user
+	s.hub.bind(client, user.ID)
+
+	s.writeData(client, fmt.Sprintf("OK Welcome back, %s! Balance: $%.2f", user.Username, float64(user.Balance)/100), userData(user))
+	s.deliverStoredMessages(client)
+	s.restoreGame(client)
+}
+
+func (s *Server) handleLogout(client *ClientState, _ []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Not logged in")
 		return
 	}
 
-	if err := s.authService.LogoutUser(client.sessionID); err != nil {
-		log.Println("Failed to logout user:", err)
+	if client.sessionID != "" {
+		if err := s.authService.LogoutUser(client.sessionID); err != nil {
+			s.log.auth.Error("Failed to logout user", "user", client.user.Username, "err", err)
+		}
+	}
+
+	s.clearAuth(client)
+	s.writeResponse(client, "OK Logged out successfully")
+}
+
+// Reloads the connection's user from the database, re-validating its credentials
+func (s *Server) refreshUser(client *ClientState) (*vault.User, error) {
+	var user *vault.User
+	var err error
+	if client.apiKeyID != "" {
+		user, err = s.authService.ValidateAPIKey(client.apiKeyID)
+	} else {
+		user, err = s.authService.ValidateSession(client.sessionID)
+		// A signed token is validated from a cached account, whose balance
+		// may be behind
+		if err == nil && security.IsSignedToken(client.sessionID) {
+			user, err = s.db.GetUserByID(user.ID)
+		}
 	}
+	if err != nil {
+		s.clearAuth(client)
+		return nil, err
+	}
+
+	client.user = user
+	return user, nil
+}
 
+func (s *Server) clearAuth(client *ClientState) {
+	s.shelveGame(client)
+	s.closeEngines(client)
+	s.hub.unbind(client)
 	client.sessionID = ""
+	client.apiKeyID = ""
+	client.scope = ""
 	client.user = nil
-	s.writeResponse(client, "OK Logged out successfully")
+	client.pendingTransfer = nil
+	client.kenoPicks = nil
 }
 
 func (s *Server) handleBalance(client *ClientState, _ []string) {
 	if client.user == nil {
-		s.writeResponse(client, "ERROR Please login first")
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
 		return
 	}
 
 	// Refresh user data from database
-	user, err := s.authService.ValidateSession(client.sessionID)
+	user, err := s.refreshUser(client)
 	if err != nil {
-		s.writeResponse(client, "ERROR Session expired, please login again")
-		client.sessionID = ""
-		client.user = nil
+		s.writeError(client, "SESSION_EXPIRED", "Session expired, please login again")
 		return
 	}
 
-	client.user = user
-	s.writeResponse(client, fmt.Sprintf("OK Balance: $%.2f", float64(user.Balance)/100))
+	s.writeData(client, fmt.Sprintf("OK Balance: $%.2f%s", float64(user.Balance)/100, s.rebuyHint(user)), balanceData(user.Balance))
 }
 
 func (s *Server) handleStats(client *ClientState, _ []string) {
 	if client.user == nil {
-		s.writeResponse(client, "ERROR Please login first")
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
 		return
 	}
 
@@ -291,7 +806,16 @@ func (s *Server) handleStats(client *ClientState, _ []string) {
 		avgBet = float64(stats.TotalBet) / float64(stats.GamesPlayed) / 100
 	}
 
+	data := map[string]any{"stats": stats}
 	response := fmt.Sprintf("OK Stats for %s:\n", client.user.Username)
+	if progress, err := s.authService.GetProgress(client.user.ID); err == nil {
+		response += fmt.Sprintf("  Level: %d (%s VIP) - %d/%d XP to level %d\n",
+			progress.Level, progress.Tier.Name, progress.XP, progress.NextLevel, progress.Level+1)
+	}
+	if user, err := s.db.GetUserByID(client.user.ID); err == nil {
+		response += fmt.Sprintf("  Comp Points: %d\n", user.Points)
+		data["comp_points"] = user.Points
+	}
 	response += fmt.Sprintf("  Games Played: %d\n", stats.GamesPlayed)
 	response += fmt.Sprintf("  Games Won: %d\n", stats.GamesWon)
 	response += fmt.Sprintf("  Games Lost: %d\n", stats.GamesLost)
@@ -301,116 +825,156 @@ func (s *Server) handleStats(client *ClientState, _ []string) {
 	response += fmt.Sprintf("  Net: $%.2f\n", float64(stats.TotalWon-stats.TotalBet)/100)
 	response += fmt.Sprintf("  Avg Bet: $%.2f\n", avgBet)
 	response += fmt.Sprintf("  Biggest Win: $%.2f\n", float64(stats.BiggestWin)/100)
-	response += fmt.Sprintf("  Biggest Loss: $%.2f", float64(stats.BiggestLoss)/100)
+	response += fmt.Sprintf("  Biggest Loss: $%.2f\n", float64(stats.BiggestLoss)/100)
+	response += fmt.Sprintf("  Win Streak: %d (best %d)\n", stats.WinStreak, stats.BestWinStreak)
+	response += fmt.Sprintf("  Loss Streak: %d (best %d)", stats.LossStreak, stats.BestLossStreak)
+	if stats.Rebuys > 0 {
+		response += fmt.Sprintf("\n  Rebuys: %d ($%.2f, not counted in Net)", stats.Rebuys, float64(stats.RebuyTotal)/100)
+	}
 
-	s.writeResponse(client, response)
+	s.writeData(client, response, data)
 }
 
 func (s *Server) handleWhoami(client *ClientState, _ []string) {
 	if client.user == nil {
-		s.writeResponse(client, "ERROR Not logged in")
+		s.writeError(client, "NOT_LOGGED_IN", "Not logged in")
 		return
 	}
 
-	s.writeResponse(client, fmt.Sprintf("OK Logged in as: %s (ID: %d, Balance: $%.2f)",
-		client.user.Username, client.user.ID, float64(client.user.Balance)/100))
-}
-
-func (s *Server) handleHelp(client *ClientState, _ []string) {
-	help := "OK Available commands:\n"
-	help += "\nAccount Management:\n"
-	help += "  SIGNUP <username> <password> - Create a new account\n"
-	help += "  LOGIN <username> <password>  - Login to your account\n"
-	help += "  LOGOUT                       - Logout from your account\n"
-	help += "  BALANCE                      - Check your current balance\n"
-	help += "  STATS                        - View your game statistics\n"
-	help += "  WHOAMI                       - Show current login status\n"
-	help += "\nBlackjack Game:\n"
-	help += "  BET <amount>                 - Start a game and place bet (in dollars)\n"
-	help += "  HIT                          - Draw another card\n"
-	help += "  STAND                        - End your turn\n"
-	help += "  DOUBLEDOWN                   - Double bet, draw one card, end turn\n"
-	help += "  SURRENDER                    - Forfeit hand, get half bet back\n"
-	help += "\nOther:\n"
-	help += "  HELP                         - Show this help message\n"
-	help += "  QUIT                         - Disconnect from server\n"
-	help += "\nUsername & Password requirements:\n"
-	help += "  - 2-30 characters long\n"
-	help += "  - Letters, numbers, and underscores only\n"
-	help += "  - No whitespace allowed\n"
-	help += "  - Password cannot be the same as username"
+	guest := ""
+	if client.user.IsGuest {
+		guest = ", guest"
+	}
 
-	s.writeResponse(client, help)
+	s.writeData(client, fmt.Sprintf("OK Logged in as: %s (ID: %d, Balance: $%.2f%s)",
+		client.user.Username, client.user.ID, float64(client.user.Balance)/100, guest), userData(client.user))
 }
 
 func (s *Server) writeResponse(client *ClientState, message string) {
-	client.conn.Write([]byte(message + "\n"))
+	s.writeData(client, message, nil)
 }
 
-func (s *Server) showStats() {
-	fmt.Println("Server Statistics:")
-	fmt.Println("  Server: Running")
-	fmt.Println("  Database: Connected")
-	fmt.Printf("  Address: %s\n", "127.0.0.1:9090")
+// Writes a reply along with the structured payload MODE JSON sends for it
+func (s *Server) writeData(client *ClientState, message string, data any) {
+	s.writeMessage(client, message, "", data)
 }
 
-func (s *Server) showUsers() {
-	fmt.Println("Use SQLite to view users:")
-	fmt.Println("  sqlite3 data/casino.db \"SELECT id, username, balance/100.0, created_at FROM users;\"")
+// Writes an ERROR reply. Text clients get just the message; MODE JSON gives
+// code with it, e.g. USAGE or NOT_LOGGED_IN, so bots needn't read the text.
+func (s *Server) writeError(client *ClientState, code, message string) {
+	s.writeMessage(client, "ERROR "+message, code, nil)
 }
 
-// Blackjack game handlers
+func (s *Server) writeMessage(client *ClientState, message, code string, data any) {
+	client.writeMu.Lock()
+	defer client.writeMu.Unlock()
+	if client.json {
+		message = encodeJSONMessage(message, code, data)
+	}
+	if client.framed {
+		message += "\n" + frameEnd
+	}
+	client.conn.Write([]byte(message + "\n"))
+}
 
-func (s *Server) handleBet(client *ClientState, args []string) {
-	if client.user == nil {
-		s.writeResponse(client, "ERROR Please login first")
+// FRAMING ON ends every reply and event with a line holding just a period, so
+// clients can tell where multi-line messages stop
+func (s *Server) handleFraming(client *ClientState, args []string) {
+	if len(args) != 1 || !isOnOff(args[0]) {
+		s.writeError(client, "USAGE", "Usage: FRAMING ON|OFF")
 		return
 	}
 
-	if len(args) != 1 {
-		s.writeResponse(client, "ERROR Usage: BET <amount> (e.g., BET 10 for $10)")
-		return
+	client.writeMu.Lock()
+	client.framed = strings.EqualFold(args[0], "ON")
+	client.writeMu.Unlock()
+
+	if client.framed {
+		s.writeResponse(client, "OK Framing on")
+	} else {
+		s.writeResponse(client, "OK Framing off")
 	}
+}
 
-	// Parse bet amount in dollars
-	betDollars, err := strconv.ParseFloat(args[0], 64)
-	if err != nil || betDollars <= 0 {
-		s.writeResponse(client, "ERROR Invalid bet amount")
-		return
+// Pushes an unsolicited event line; clients tell these apart by the EVENT prefix
+func (s *Server) pushEvent(client *ClientState, kind, message string) {
+	s.pushEventData(client, kind, message, nil)
+}
+
+// Pushes an event along with the structured payload MODE JSON sends for it
+func (s *Server) pushEventData(client *ClientState, kind, message string, data any) {
+	s.writeData(client, fmt.Sprintf("EVENT %s %s", kind, message), data)
+}
+
+// Warns every other live connection of the user about a login from a new address
+func (s *Server) alertNewIPLogin(user *vault.User, ip string) {
+	s.log.auth.Info("Login from a new IP", "user", user.Username, "ip", ip)
+	for _, other := range s.hub.clientsForUser(user.ID, nil) {
+		s.pushEvent(other, "SECURITY", fmt.Sprintf("New login to your account from unfamiliar IP %s. If this wasn't you, change your password and review ALLOWIP.", ip))
 	}
+}
 
-	betCents := int64(betDollars * 100)
+// Sets up the server around its database and auth service, without starting
+// to serve
+func newServer(cfg Config, db *vault.DB, authService *security.AuthService, logs *loggers) (*Server, error) {
+	server := &Server{
+		authService:   authService,
+		db:            db,
+		config:        cfg,
+		hub:           newHub(),
+		lobby:         newLobby(),
+		chat:          newChatFlood(),
+		reactions:     newChatFlood(),
+		userLocks:     newUserLocks(),
+		stats:         newServerStats(),
+		rtp:           newRTPMonitor(),
+		log:           logs,
+		loginFailures: newLoginFailures(),
+		rpc:           newRPCSessions(),
+		accountLimits: newBucketMap[int](),
+		authLimits:    newBucketMap[string](),
+		conns:         newConnLimits(cfg.MaxConnections, cfg.MaxConnectionsPerIP),
+		soloTurn:      time.Duration(cfg.SoloTurnSeconds) * time.Second,
+	}
+	var err error
+	if server.webhooks, err = newWebhooks(cfg.WebhookURLs, cfg.WebhookEvents, logs.server); err != nil {
+		return nil, fmt.Errorf("webhooks: %w", err)
+	}
+	authService.SetNewIPLoginHandler(server.alertNewIPLogin)
+	if cfg.ChatBlocklist != "" {
+		authService.SetChatFilter(security.WordFilter(strings.Split(cfg.ChatBlocklist, ",")))
+	}
 
-	// Refresh user balance from database
-	user, err := s.authService.ValidateSession(client.sessionID)
-	if err != nil {
-		s.writeResponse(client, "ERROR Session expired, please login again")
-		client.sessionID = ""
-		client.user = nil
-		return
+	if authService.JackpotEnabled() {
+		if server.jackpotTrigger, err = game.ParseJackpotTrigger(cfg.JackpotTrigger); err != nil {
+			return nil, fmt.Errorf("JACKPOT_TRIGGER: %w", err)
+		}
 	}
-	client.user = user
+	return server, nil
+}
 
-	if client.user.Balance < betCents {
-		s.writeResponse(client, fmt.Sprintf("ERROR Insufficient balance. You have $%.2f", float64(client.user.Balance)/100))
+// Blackjack game handlers
+
+func (s *Server) handleBet(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
 		return
 	}
 
-	client.game = game.NewGame()
-	if err := client.game.PlaceBet(betCents); err != nil {
-		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+	// A hand saved but not yet picked up (e.g. one whose payout failed) comes first
+	s.restoreGame(client)
+	if client.game != nil {
+		s.writeError(client, "GAME_IN_PROGRESS", "Finish your current hand first")
 		return
 	}
 
-	newBalance := client.user.Balance - betCents
-	if err := s.authService.UpdateBalance(client.user.ID, newBalance); err != nil {
-		s.writeResponse(client, fmt.Sprintf("ERROR Failed to update balance: %s", err.Error()))
+	if !s.startSoloGame(client, args) {
 		return
 	}
-	client.user.Balance = newBalance
 
 	// Send game state
 	response := fmt.Sprintf("OK Game started!\n%s", client.game.GetGameState(true))
+	data := gameData(client.game)
 
 	// If game is over (blackjack), handle payout immediately
 	if client.game.Phase == game.PhaseGameOver {
@@ -421,19 +985,139 @@ func (s *Server) handleBet(client *ClientState, args []string) {
 		if len(validActions) > 0 {
 			response += "\nActions: " + strings.Join(validActions, ", ")
 		}
+		s.startSoloTurn(client)
+	}
+
+	s.writeData(client, response, data)
+}
+
+// Checks and takes the bet of a new solo hand under the player's lock, saving
+// the hand with it so it can be finished after a restart
+func (s *Server) startSoloGame(client *ClientState, args []string) bool {
+	defer s.userLocks.lock(client.user.ID)()
+
+	rules := s.tableRules(client)
+	betCents, sides, ok := s.checkBet(client, args, rules)
+	if !ok {
+		return false
+	}
+
+	g := game.NewGameWithRules(rules)
+	for bet, stake := range sides {
+		if err := g.PlaceSideBet(bet, stake); err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return false
+		}
+	}
+	if err := g.PlaceBet(betCents); err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return false
+	}
+
+	state, err := g.Snapshot()
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return false
+	}
+	newBalance, err := s.authService.StartGame(client.user.ID, security.GameBlackjack, g.TotalWager(), state)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR Failed to place bet: %s", err.Error()))
+		return false
+	}
+	client.game = g
+	client.user.Balance = newBalance
+	s.stats.soloGames.Add(1)
+	client.playing.Store(true)
+	return true
+}
+
+// Validates a BET against the rules, the player's balance and their limits,
+// writing the error and returning false when it can't be placed. Side bets
+// follow the amount, e.g. BET 10 PP 1 21+3 1, and count towards the balance
+// and limits checks.
+func (s *Server) checkBet(client *ClientState, args []string, rules game.Rules) (int64, map[game.SideBet]int64, bool) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return 0, nil, false
+	}
+
+	if len(args) == 0 || len(args)%2 == 0 {
+		s.writeError(client, "USAGE", "Usage: BET <amount> [PP <amount>] [21+3 <amount>] (e.g., BET 10 for $10)")
+		return 0, nil, false
+	}
+
+	// Parse bet amount in dollars
+	betDollars, err := strconv.ParseFloat(args[0], 64)
+	if err != nil || betDollars <= 0 {
+		s.writeError(client, "INVALID_ARGUMENT", "Invalid bet amount")
+		return 0, nil, false
+	}
+
+	betCents := int64(betDollars * 100)
+
+	if err := rules.CheckBet(betCents); err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return 0, nil, false
+	}
+
+	sides, err := parseSideBets(args[1:])
+	if err != nil {
+		s.writeError(client, "INVALID_ARGUMENT", err.Error())
+		return 0, nil, false
+	}
+	total := betCents
+	for _, stake := range sides {
+		total += stake
+	}
+
+	// Refresh user balance from database
+	if _, err := s.refreshUser(client); err != nil {
+		s.writeError(client, "SESSION_EXPIRED", "Session expired, please login again")
+		return 0, nil, false
+	}
+
+	if client.user.Balance < total {
+		s.writeError(client, "INSUFFICIENT_FUNDS", fmt.Sprintf("Insufficient balance. You have $%.2f%s",
+			float64(client.user.Balance)/100, s.rebuyHint(client.user)))
+		return 0, nil, false
 	}
 
-	s.writeResponse(client, response)
+	if err := s.authService.CheckBetLimits(client.user.ID, total); err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return 0, nil, false
+	}
+
+	return betCents, sides, true
+}
+
+// Parses side bets given as name and dollar amount pairs
+func parseSideBets(args []string) (map[game.SideBet]int64, error) {
+	sides := map[game.SideBet]int64{}
+	for i := 0; i+1 < len(args); i += 2 {
+		bet, ok := game.ParseSideBet(args[i])
+		if !ok {
+			return nil, fmt.Errorf("Unknown side bet %s (use PP or 21+3)", args[i])
+		}
+		if _, dup := sides[bet]; dup {
+			return nil, fmt.Errorf("%s bet given twice", bet.Name())
+		}
+		stake, err := parseDollars(args[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s bet amount", bet.Name())
+		}
+		sides[bet] = stake
+	}
+	return sides, nil
 }
 
 func (s *Server) handleHit(client *ClientState, _ []string) {
 	if client.user == nil {
-		s.writeResponse(client, "ERROR Please login first")
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
 		return
 	}
 
 	if client.game == nil {
-		s.writeResponse(client, "ERROR No active game. Use BET <amount> to start a game")
+		s.writeError(client, "NO_GAME", "No active game. Use BET <amount> to start a game")
 		return
 	}
 
@@ -441,8 +1125,14 @@ func (s *Server) handleHit(client *ClientState, _ []string) {
 		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
 		return
 	}
+	if client.game.Phase != game.PhaseGameOver {
+		if err := s.saveGame(client, 0); err != nil {
+			s.log.vault.Error("Failed to save hand", "user", client.user.Username, "err", err)
+		}
+	}
 
 	response := fmt.Sprintf("OK\n%s", client.game.GetGameState(true))
+	data := gameData(client.game)
 
 	if client.game.Phase == game.PhaseGameOver {
 		s.handleGameOver(client)
@@ -452,19 +1142,20 @@ func (s *Server) handleHit(client *ClientState, _ []string) {
 		if len(validActions) > 0 {
 			response += "\nActions: " + strings.Join(validActions, ", ")
 		}
+		s.startSoloTurn(client)
 	}
 
-	s.writeResponse(client, response)
+	s.writeData(client, response, data)
 }
 
 func (s *Server) handleStand(client *ClientState, _ []string) {
 	if client.user == nil {
-		s.writeResponse(client, "ERROR Please login first")
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
 		return
 	}
 
 	if client.game == nil {
-		s.writeResponse(client, "ERROR No active game. Use BET <amount> to start a game")
+		s.writeError(client, "NO_GAME", "No active game. Use BET <amount> to start a game")
 		return
 	}
 
@@ -474,55 +1165,87 @@ func (s *Server) handleStand(client *ClientState, _ []string) {
 	}
 
 	response := fmt.Sprintf("OK\n%s", client.game.GetGameState(false))
-	s.writeResponse(client, response)
+	s.writeData(client, response, gameData(client.game))
 
 	s.handleGameOver(client)
 }
 
 func (s *Server) handleDoubleDown(client *ClientState, _ []string) {
 	if client.user == nil {
-		s.writeResponse(client, "ERROR Please login first")
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
 		return
 	}
 
 	if client.game == nil {
-		s.writeResponse(client, "ERROR No active game. Use BET <amount> to start a game")
+		s.writeError(client, "NO_GAME", "No active game. Use BET <amount> to start a game")
 		return
 	}
 
-	if client.user.Balance < client.game.Bet {
-		s.writeResponse(client, fmt.Sprintf("ERROR Insufficient balance to double down. You need $%.2f more", float64(client.game.Bet)/100))
+	if !s.doubleSoloGame(client) {
 		return
 	}
 
-	newBalance := client.user.Balance - client.game.Bet
-	if err := s.authService.UpdateBalance(client.user.ID, newBalance); err != nil {
-		s.writeResponse(client, fmt.Sprintf("ERROR Failed to update balance: %s", err.Error()))
-		return
+	response := fmt.Sprintf("OK Doubled down!\n%s", client.game.GetGameState(false))
+	s.writeData(client, response, gameData(client.game))
+
+	s.handleGameOver(client)
+}
+
+// Checks and takes the extra stake of a solo double down under the player's
+// lock and plays the double
+func (s *Server) doubleSoloGame(client *ClientState) bool {
+	defer s.userLocks.lock(client.user.ID)()
+
+	if _, err := s.refreshUser(client); err != nil {
+		s.writeError(client, "SESSION_EXPIRED", "Session expired, please login again")
+		return false
 	}
-	client.user.Balance = newBalance
 
-	if err := client.game.DoubleDown(); err != nil {
-		s.authService.UpdateBalance(client.user.ID, client.user.Balance+client.game.Bet/2)
-		client.user.Balance += client.game.Bet / 2
+	extra := client.game.Bet
+	if client.user.Balance < extra {
+		s.writeError(client, "INSUFFICIENT_FUNDS", fmt.Sprintf("Insufficient balance to double down. You need $%.2f more", float64(extra)/100))
+		return false
+	}
+
+	if err := s.authService.CheckBetLimits(client.user.ID, extra); err != nil {
 		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
-		return
+		return false
 	}
 
-	response := fmt.Sprintf("OK Doubled down!\n%s", client.game.GetGameState(false))
-	s.writeResponse(client, response)
+	// Double a copy, so the hand is left as it was if the stake can't be taken
+	state, err := client.game.Snapshot()
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return false
+	}
+	doubled, err := game.RestoreGame(state)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return false
+	}
+	if err := doubled.DoubleDown(); err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return false
+	}
 
-	s.handleGameOver(client)
+	played := client.game
+	client.game = doubled
+	if err := s.saveGame(client, extra); err != nil {
+		client.game = played
+		s.writeResponse(client, fmt.Sprintf("ERROR Failed to update balance: %s", err.Error()))
+		return false
+	}
+	return true
 }
 
 func (s *Server) handleSurrender(client *ClientState, _ []string) {
 	if client.user == nil {
-		s.writeResponse(client, "ERROR Please login first")
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
 		return
 	}
 
 	if client.game == nil {
-		s.writeResponse(client, "ERROR No active game. Use BET <amount> to start a game")
+		s.writeError(client, "NO_GAME", "No active game. Use BET <amount> to start a game")
 		return
 	}
 
@@ -532,63 +1255,152 @@ func (s *Server) handleSurrender(client *ClientState, _ []string) {
 	}
 
 	response := fmt.Sprintf("OK Surrendered!\n%s", client.game.GetGameState(false))
-	s.writeResponse(client, response)
+	s.writeData(client, response, gameData(client.game))
 
 	s.handleGameOver(client)
 }
 
 func (s *Server) handleGameOver(client *ClientState) {
-	payout := client.game.CalculatePayout()
+	s.stopSoloTurn(client)
+	s.settleGame(client, client.game, 0)
+	s.stats.soloGames.Add(-1)
+	client.playing.Store(false)
 
-	newBalance := client.user.Balance + payout
-	if err := s.authService.UpdateBalance(client.user.ID, newBalance); err != nil {
-		log.Printf("Failed to update balance after game: %v", err)
-	}
-	client.user.Balance = newBalance
+	// Clear the game
+	client.settled = client.game
+	client.game = nil
+}
 
+// Pays out a finished hand from escrow and runs everything that follows a
+// round: stats, bonuses, the jackpot, the big win feed and rewardRound. hold
+// is the escrow of a table hand's bet; a solo hand's bet is held with the
+// saved hand.
+func (s *Server) settleGame(client *ClientState, g *game.Game, hold int64) {
+	defer s.userLocks.lock(client.user.ID)()
+
+	payout := g.CalculatePayout()
+	result := game.SeatResult(g)
+	// Side bets were settled on the deal and are paid along with the hand
+	wagered, paid := g.TotalWager(), payout+g.SidePayout()
+
+	// The player's stats are saved with the payout, so one is never recorded
+	// without the other
 	stats, err := s.authService.GetUserStats(client.user.ID)
 	if err != nil {
-		log.Printf("Failed to get user stats: %v", err)
+		s.log.vault.Error("Failed to get user stats", "user", client.user.Username, "err", err)
+		stats = nil
+	} else {
+		recordRoundStats(stats, g, payout)
+	}
+
+	var newBalance int64
+	if hold == 0 {
+		newBalance, err = s.authService.SettleGame(client.user.ID, wagered, paid, result, g.Hands(), stats)
+	} else {
+		newBalance, err = s.authService.SettleHold(hold, paid, result, g.Hands(), stats)
+	}
+	if err != nil {
+		// Either closed already (e.g. a solo hand swept while the player was
+		// away) or still in escrow, to be paid when the hand is restored or
+		// refunded at the next start
+		s.log.game.Error("Failed to settle game", "user", client.user.Username, "bet", wagered, "payout", paid, "err", err)
 		return
 	}
+	client.user.Balance = newBalance
+	s.stats.wagered.Add(wagered)
+	s.stats.paid.Add(paid)
+	// The RTP monitor follows the main hand, whose return the rules predict.
+	// Guests' play money is left out of it as it is of the house's totals.
+	if !client.user.IsGuest {
+		s.recordRTP(security.GameBlackjack, g.ExpectedRTP(), g.Bet, payout)
+	}
+	s.checkBalanceAlert(client.user.Username, newBalance-paid, newBalance)
+
+	if stats != nil && (g.Result == game.ResultPlayerWin || g.Result == game.ResultPlayerBlackjack) {
+		s.awardStreakBonus(client, stats.WinStreak)
+	}
+
+	if g.Result == game.ResultPlayerBlackjack {
+		s.awardEventBonus(client, payout-g.Bet)
+	}
+
+	s.settleJackpot(client, g)
+
+	s.announceBigWin(client, g, payout)
+
+	s.rewardRound(client, security.RoundResult{
+		Game:      security.GameBlackjack,
+		Wagered:   wagered,
+		Payout:    paid,
+		Won:       g.Result == game.ResultPlayerWin || g.Result == game.ResultPlayerBlackjack,
+		Blackjack: g.Result == game.ResultPlayerBlackjack,
+	})
+}
+
+// Awards what every settled round earns whatever the game: XP and comp
+// points on the wager, a pending referral bonus and achievements
+func (s *Server) rewardRound(client *ClientState, round security.RoundResult) {
+	progress, leveledUp, err := s.authService.AwardWagerXP(client.user.ID, round.Wagered)
+	if err != nil {
+		s.log.game.Error("Failed to award XP", "user", client.user.Username, "err", err)
+	} else if leveledUp {
+		s.pushEvent(client, "LEVEL", fmt.Sprintf("You reached level %d! VIP tier: %s", progress.Level, progress.Tier.Name))
+	}
+
+	if _, err := s.authService.AwardWagerPoints(client.user.ID, round.Wagered); err != nil {
+		s.log.game.Error("Failed to award comp points", "user", client.user.Username, "err", err)
+	}
+
+	s.settleReferral(client)
+
+	earned, err := s.authService.EvaluateAchievements(client.user.ID, round)
+	if err != nil {
+		s.log.game.Error("Failed to evaluate achievements", "user", client.user.Username, "err", err)
+	}
+	for _, a := range earned {
+		s.pushEvent(client, "ACHIEVEMENT", fmt.Sprintf("Unlocked %s: %s", a.Name, a.Description))
+	}
+
+	s.autoRebuy(client)
+}
 
+// Adds a settled hand to the player's stats. Side bets count towards the
+// amounts bet and won but not the hand's result.
+func recordRoundStats(stats *vault.UserStats, g *game.Game, payout int64) {
 	stats.GamesPlayed++
-	stats.TotalBet += client.game.Bet
+	stats.TotalBet += g.TotalWager()
+	stats.TotalWon += g.SidePayout()
 
-	switch client.game.Result {
+	switch g.Result {
 	case game.ResultPlayerWin, game.ResultPlayerBlackjack:
 		stats.GamesWon++
+		stats.RecordWin()
 		// Add full payout to TotalWon (includes returned bet + profit)
 		stats.TotalWon += payout
 		// BiggestWin tracks the profit amount only
-		winAmount := payout - client.game.Bet
+		winAmount := payout - g.Bet
 		if winAmount > stats.BiggestWin {
 			stats.BiggestWin = winAmount
 		}
 	case game.ResultDealerWin:
 		stats.GamesLost++
-		lossAmount := client.game.Bet
+		stats.RecordLoss()
+		lossAmount := g.Bet
 		if lossAmount > stats.BiggestLoss {
 			stats.BiggestLoss = lossAmount
 		}
 	case game.ResultSurrender:
 		stats.GamesLost++
+		stats.RecordLoss()
 		// Add the half-bet payout to TotalWon
 		stats.TotalWon += payout
 		// Loss is half the bet
-		lossAmount := client.game.Bet / 2
+		lossAmount := g.Bet / 2
 		if lossAmount > stats.BiggestLoss {
 			stats.BiggestLoss = lossAmount
 		}
 	case game.ResultPush:
 		// Push returns the bet, add to TotalWon
-		stats.TotalWon += client.game.Bet
+		stats.TotalWon += g.Bet
 	}
-
-	if err := s.db.UpdateUserStats(stats); err != nil {
-		log.Printf("Failed to update user stats: %v", err)
-	}
-
-	// Clear the game
-	client.game = nil
 }
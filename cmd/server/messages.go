@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MSG <user> <message> sends a direct message, held for the recipient if they're offline
+func (s *Server) handleMsg(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	if len(args) < 2 {
+		s.writeError(client, "USAGE", "Usage: MSG <user> <message>")
+		return
+	}
+
+	recipient, message, err := s.authService.PrepareMessage(client.user.ID, args[0], strings.Join(args[1:], " "))
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	// Direct messages share the chat flood limit
+	if s.config.ChatLimit > 0 && !s.chat.allow(client.user.ID, s.config.ChatLimit, time.Now()) {
+		s.writeResponse(client, "ERROR You are sending messages too quickly, slow down")
+		return
+	}
+
+	conns := s.hub.clientsForUser(recipient.ID, nil)
+	if len(conns) == 0 {
+		if err := s.authService.StoreMessage(client.user.ID, recipient.ID, message); err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return
+		}
+		s.writeResponse(client, fmt.Sprintf("OK %s is offline and will get your message when they log in", recipient.Username))
+		return
+	}
+
+	for _, c := range conns {
+		s.pushEvent(c, "MSG", fmt.Sprintf("%s: %s", client.user.Username, message))
+	}
+	s.writeResponse(client, fmt.Sprintf("OK Message sent to %s", recipient.Username))
+}
+
+// Pushes messages that arrived while the user was offline to a connection that just logged in
+func (s *Server) deliverStoredMessages(client *ClientState) {
+	messages, err := s.authService.TakeStoredMessages(client.user.ID)
+	if err != nil {
+		s.log.vault.Error("Failed to load stored messages", "user", client.user.Username, "err", err)
+		return
+	}
+
+	for _, m := range messages {
+		s.pushEvent(client, "MSG", fmt.Sprintf("%s (%s): %s", m.SenderName, m.CreatedAt.Local().Format("2006-01-02 15:04"), m.Body))
+	}
+}
+
+// BLOCK <user> stops a player messaging you and hides their chat; BLOCK alone
+// lists blocked players
+func (s *Server) handleBlock(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	if len(args) == 0 {
+		names, err := s.authService.ListBlockedUsers(client.user.ID)
+		if err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return
+		}
+		if len(names) == 0 {
+			s.writeResponse(client, "OK You haven't blocked anyone")
+			return
+		}
+		s.writeResponse(client, "OK Blocked: "+strings.Join(names, ", "))
+		return
+	}
+
+	if len(args) != 1 {
+		s.writeError(client, "USAGE", "Usage: BLOCK [user]")
+		return
+	}
+
+	blocked, err := s.authService.BlockUser(client.user.ID, args[0])
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	s.writeResponse(client, fmt.Sprintf("OK Blocked %s. They can no longer message you and you won't see their chat", blocked.Username))
+}
+
+func (s *Server) handleUnblock(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	if len(args) != 1 {
+		s.writeError(client, "USAGE", "Usage: UNBLOCK <user>")
+		return
+	}
+
+	unblocked, err := s.authService.UnblockUser(client.user.ID, args[0])
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	s.writeResponse(client, fmt.Sprintf("OK Unblocked %s", unblocked.Username))
+}
+
+// MUTE <user> hides a player's chat and reactions but still lets them message
+// you; MUTE alone lists muted players
+func (s *Server) handleMute(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	if len(args) == 0 {
+		names, err := s.authService.ListMutedUsers(client.user.ID)
+		if err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return
+		}
+		if len(names) == 0 {
+			s.writeResponse(client, "OK You haven't muted anyone")
+			return
+		}
+		s.writeResponse(client, "OK Muted: "+strings.Join(names, ", "))
+		return
+	}
+
+	if len(args) != 1 {
+		s.writeError(client, "USAGE", "Usage: MUTE [user]")
+		return
+	}
+
+	muted, err := s.authService.MuteUser(client.user.ID, args[0])
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	s.writeResponse(client, fmt.Sprintf("OK Muted %s. You won't see their chat or reactions", muted.Username))
+}
+
+func (s *Server) handleUnmute(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	if len(args) != 1 {
+		s.writeError(client, "USAGE", "Usage: UNMUTE <user>")
+		return
+	}
+
+	unmuted, err := s.authService.UnmuteUser(client.user.ID, args[0])
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	s.writeResponse(client, fmt.Sprintf("OK Unmuted %s", unmuted.Username))
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Runs "migrate [version]": shows the database's schema version, or moves it
+// to version, e.g. to roll back before going back to an older build
+func migrateCommand(db *vault.DB, args []string) int {
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "Usage: migrate [version]")
+		return 2
+	}
+	if len(args) == 1 {
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: version must be a number")
+			return 2
+		}
+		if err := db.MigrateTo(version); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
+	}
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	fmt.Printf("Schema version %d (latest %d)\n", version, vault.LatestSchemaVersion())
+	return 0
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// PROFIT [days] lists the daily net game result and running total so clients
+// can draw a bankroll graph; one "  <date> <net> <total>" line per day
+func (s *Server) handleProfit(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	if len(args) > 1 {
+		s.writeError(client, "USAGE", "Usage: PROFIT [7|30]")
+		return
+	}
+
+	days := 7
+	if len(args) == 1 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			s.writeError(client, "USAGE", "Usage: PROFIT [7|30]")
+			return
+		}
+		days = n
+	}
+
+	history, err := s.authService.ProfitHistory(client.user.ID, days)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	var total int64
+	lines := ""
+	for _, d := range history {
+		total += d.Net
+		lines += fmt.Sprintf("\n  %s  %s  %s", d.Day, signedDollars(d.Net), signedDollars(total))
+	}
+
+	s.writeResponse(client, fmt.Sprintf("OK Net profit over the last %d days: %s%s", days, signedDollars(total), lines))
+}
+
+func signedDollars(cents int64) string {
+	if cents < 0 {
+		return fmt.Sprintf("-$%.2f", float64(-cents)/100)
+	}
+	return fmt.Sprintf("+$%.2f", float64(cents)/100)
+}
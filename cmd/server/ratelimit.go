@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Commands that log in or create accounts, held to AUTH_RATE_LIMIT since a
+// flood of them is password guessing or signup spam
+var authCommands = map[string]bool{
+	"SIGNUP":   true,
+	"REGISTER": true,
+	"LOGIN":    true,
+	"RESUME":   true,
+	"GUEST":    true,
+	"AUTH":     true,
+}
+
+// Holds up to burst tokens, refilled at rate tokens a second
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Takes a token, returning 0 or how long until one is free when there is none.
+// A new bucket starts full, and holds at least one token.
+func (b *tokenBucket) take(rate float64, burst int, now time.Time) time.Duration {
+	burst = max(burst, 1)
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else {
+		b.tokens = min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// Buckets shared by every connection with the same key, e.g. the account
+// they're logged in to or the address they come from
+type bucketMap[K comparable] struct {
+	mu      sync.Mutex
+	buckets map[K]*tokenBucket
+}
+
+func newBucketMap[K comparable]() *bucketMap[K] {
+	return &bucketMap[K]{buckets: make(map[K]*tokenBucket)}
+}
+
+func (m *bucketMap[K]) take(key K, rate float64, burst int, now time.Time) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &tokenBucket{}
+		m.buckets[key] = b
+	}
+	return b.take(rate, burst, now)
+}
+
+// Drops buckets that have been idle long enough to be full again, which a new
+// bucket would be anyway
+func (m *bucketMap[K]) prune(rate float64, burst int, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	refill := time.Duration(float64(max(burst, 1)) / rate * float64(time.Second))
+	for key, b := range m.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+// Writes ERROR RATE_LIMITED and returns false when the connection, or the
+// account it is logged in to, has used up its commands for now. Auth
+// commands draw on a slower bucket of the client's address, so a gRPC caller
+// that gets a new session for every Login is held to it too.
+func (s *Server) allowCommand(client *ClientState, command string) bool {
+	now := time.Now()
+	var wait time.Duration
+	switch {
+	case authCommands[command]:
+		if s.config.AuthRateLimit > 0 {
+			wait = s.authLimits.take(client.ip, float64(s.config.AuthRateLimit)/60, s.config.AuthRateBurst, now)
+		}
+	case s.config.RateLimit > 0:
+		rate := float64(s.config.RateLimit)
+		wait = client.cmdBucket.take(rate, s.config.RateBurst, now)
+		if wait == 0 && client.user != nil {
+			wait = s.accountLimits.take(client.user.ID, rate, s.config.RateBurst, now)
+		}
+	}
+	if wait == 0 {
+		return true
+	}
+
+	s.log.server.Debug("Rate limited", "client", client.id, "ip", client.ip, "command", command)
+	retry := max(wait.Round(time.Millisecond), time.Millisecond)
+	s.writeResponse(client, fmt.Sprintf("ERROR RATE_LIMITED Too many commands, slow down (retry in %s)", retry))
+	return false
+}
+
+// Forgets the buckets of accounts and addresses that have gone quiet
+func (s *Server) pruneRateLimits() {
+	now := time.Now()
+	if s.config.RateLimit > 0 {
+		s.accountLimits.prune(float64(s.config.RateLimit), s.config.RateBurst, now)
+	}
+	if s.config.AuthRateLimit > 0 {
+		s.authLimits.prune(float64(s.config.AuthRateLimit)/60, s.config.AuthRateBurst, now)
+	}
+}
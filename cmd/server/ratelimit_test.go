@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var b tokenBucket
+
+	// A new bucket holds a whole burst
+	for i := 0; i < 3; i++ {
+		if wait := b.take(2, 3, start); wait != 0 {
+			t.Fatalf("take() %d of the burst waited %s", i+1, wait)
+		}
+	}
+	if wait := b.take(2, 3, start); wait != 500*time.Millisecond {
+		t.Errorf("take() past the burst = %s, want 500ms", wait)
+	}
+
+	// Refills at the rate, never past the burst
+	if wait := b.take(2, 3, start.Add(500*time.Millisecond)); wait != 0 {
+		t.Errorf("take() after a refill waited %s", wait)
+	}
+	later := start.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if wait := b.take(2, 3, later); wait != 0 {
+			t.Fatalf("take() %d after an hour waited %s", i+1, wait)
+		}
+	}
+	if wait := b.take(2, 3, later); wait == 0 {
+		t.Error("take() should refuse once the refilled burst is used up")
+	}
+
+	// A burst of 0 still lets one command through
+	var one tokenBucket
+	if wait := one.take(1, 0, start); wait != 0 {
+		t.Errorf("take() with no burst waited %s", wait)
+	}
+}
+
+func TestBucketMap(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := newBucketMap[string]()
+
+	if wait := m.take("10.0.0.1", 1, 1, now); wait != 0 {
+		t.Fatalf("take() first = %s", wait)
+	}
+	if wait := m.take("10.0.0.1", 1, 1, now); wait == 0 {
+		t.Error("take() should refuse a second command from the same key")
+	}
+	if wait := m.take("10.0.0.2", 1, 1, now); wait != 0 {
+		t.Errorf("take() from another key waited %s", wait)
+	}
+
+	m.prune(1, 1, now.Add(500*time.Millisecond))
+	if len(m.buckets) != 2 {
+		t.Errorf("prune() dropped buckets still refilling: %d left", len(m.buckets))
+	}
+	m.prune(1, 1, now.Add(time.Second))
+	if len(m.buckets) != 0 {
+		t.Errorf("prune() kept %d full buckets", len(m.buckets))
+	}
+}
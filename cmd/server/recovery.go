@@ -0,0 +1,179 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+	"github.com/alessandrosisniegas/casino/core/security"
+)
+
+// ABANDONED_HAND_POLICY values
+const (
+	abandonStand  = "stand"  // The player stands and the dealer plays the hand out
+	abandonRefund = "refund" // The bet is returned without playing the hand out
+)
+
+// Saves the connection's solo hand after a move, taking stake from the
+// balance into its bet (for a double down)
+func (s *Server) saveGame(client *ClientState, stake int64) error {
+	state, err := client.game.Snapshot()
+	if err != nil {
+		return err
+	}
+	balance, err := s.authService.SaveGame(client.user.ID, stake, state)
+	if err != nil {
+		return err
+	}
+	client.user.Balance = balance
+	return nil
+}
+
+// Picks up the player's unfinished solo hand after they log in, so a hand
+// cut off by a disconnect or a restart can be finished
+func (s *Server) restoreGame(client *ClientState) {
+	g := s.loadGame(client)
+	if g == nil {
+		return
+	}
+	s.log.game.Info("Restored unfinished hand", "user", client.user.Username, "bet", g.Bet)
+
+	if g.Phase == game.PhaseGameOver {
+		// Played out, but the server stopped before paying it
+		s.pushEvent(client, "GAME", "Your unfinished hand has been settled\n"+g.GetGameState(false))
+		s.handleGameOver(client)
+		return
+	}
+
+	message := "Your unfinished hand was restored\n" + g.GetGameState(true)
+	if actions := g.GetValidActions(); len(actions) > 0 {
+		message += "\nActions: " + strings.Join(actions, ", ")
+	}
+	s.pushEvent(client, "GAME", message)
+	s.startSoloTurn(client)
+}
+
+// Puts the player's saved hand on the connection and returns it, or nil if
+// there's none or another of their connections is playing it. Runs under
+// the player's lock so two connections can't both pick it up.
+func (s *Server) loadGame(client *ClientState) *game.Game {
+	defer s.userLocks.lock(client.user.ID)()
+
+	if client.game != nil {
+		return nil
+	}
+	for _, other := range s.hub.clientsForUser(client.user.ID, client) {
+		if other.playing.Load() {
+			// Still being played on another connection
+			return nil
+		}
+	}
+
+	saved, err := s.authService.ActiveGame(client.user.ID)
+	if err != nil {
+		s.log.vault.Error("Failed to load unfinished hand", "user", client.user.Username, "err", err)
+		return nil
+	}
+	if saved == nil {
+		return nil
+	}
+	g, err := game.RestoreGame(saved.State)
+	if err != nil {
+		s.log.game.Error("Failed to restore unfinished hand", "user", client.user.Username, "err", err)
+		return nil
+	}
+
+	client.game = g
+	s.stats.soloGames.Add(1)
+	client.playing.Store(true)
+	return g
+}
+
+// Drops the connection's solo hand from memory when its player logs out or
+// switches account; it stays saved for when they log back in
+func (s *Server) shelveGame(client *ClientState) {
+	if client.game == nil {
+		return
+	}
+	s.stopSoloTurn(client)
+	client.game = nil
+	s.stats.soloGames.Add(-1)
+	client.playing.Store(false)
+}
+
+// Closes solo hands nobody has touched for ABANDONED_HAND_HOURS, per
+// ABANDONED_HAND_POLICY. Runs at startup and hourly.
+func (s *Server) sweepAbandonedGames() {
+	if s.config.AbandonedHandHours <= 0 {
+		return
+	}
+	games, err := s.authService.AbandonedGames(time.Duration(s.config.AbandonedHandHours) * time.Hour)
+	if err != nil {
+		s.log.vault.Error("Failed to list abandoned hands", "err", err)
+		return
+	}
+
+	for _, saved := range games {
+		playing := false
+		for _, c := range s.hub.clientsForUser(saved.UserID, nil) {
+			playing = playing || c.playing.Load()
+		}
+		if playing {
+			continue
+		}
+
+		if err := s.closeAbandonedGame(saved.UserID, saved.State); err != nil {
+			s.log.game.Error("Failed to close abandoned hand", "user_id", saved.UserID, "bet", saved.Bet, "err", err)
+		}
+	}
+}
+
+func (s *Server) closeAbandonedGame(userID int, state string) error {
+	defer s.userLocks.lock(userID)()
+
+	g, err := game.RestoreGame(state)
+	if err != nil || s.config.AbandonedHandPolicy == abandonRefund {
+		// A hand that can't be read back is refunded whatever the policy
+		_, refunded, rerr := s.authService.RefundGame(userID)
+		if rerr != nil {
+			return rerr
+		}
+		s.log.game.Info("Refunded abandoned hand", "user_id", userID, "amount", refunded)
+		return nil
+	}
+
+	if g.Phase == game.PhasePlayerTurn {
+		if err := g.Stand(); err != nil {
+			return err
+		}
+	}
+	payout := g.CalculatePayout()
+	if _, err := s.authService.SettleGame(userID, g.TotalWager(), payout+g.SidePayout(), game.SeatResult(g), g.Hands(), nil); err != nil {
+		return err
+	}
+	s.stats.wagered.Add(g.TotalWager())
+	s.stats.paid.Add(payout + g.SidePayout())
+	if user, err := s.db.GetUserByID(userID); err == nil && !user.IsGuest {
+		s.recordRTP(security.GameBlackjack, g.ExpectedRTP(), g.Bet, payout)
+	}
+	s.log.game.Info("Settled abandoned hand", "user_id", userID, "bet", g.Bet, "payout", payout, "result", g.Result)
+	return nil
+}
+
+// Refunds the table bets still in escrow when the server starts: the rounds
+// they were placed in ended with the process that ran them
+func (s *Server) releaseHeldBets() {
+	holds, err := s.authService.HeldBets()
+	if err != nil {
+		s.log.vault.Error("Failed to list held bets", "err", err)
+		return
+	}
+
+	for _, h := range holds {
+		if _, refunded, err := s.authService.ReleaseHold(h.ID); err != nil {
+			s.log.game.Error("Failed to refund held bet", "table", h.Ref, "user_id", h.UserID, "amount", h.Amount, "err", err)
+		} else {
+			s.log.game.Info("Refunded bet from an unfinished round", "table", h.Ref, "user_id", h.UserID, "amount", refunded)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/alessandrosisniegas/casino/core/security"
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func (s *Server) handleReferral(client *ClientState, _ []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	code, err := s.authService.ReferralCode(client.user.ID)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	referrals, err := s.authService.ListReferrals(client.user.ID)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	counts := map[string]int{}
+	for _, r := range referrals {
+		counts[r.Status]++
+	}
+
+	response := fmt.Sprintf("OK Your referral code: %s\n", code)
+	response += fmt.Sprintf("  Friends who sign up with REFER %s and wager $%d each get $%d, and so do you\n",
+		code, s.config.ReferralWager, s.config.ReferralBonus)
+	response += fmt.Sprintf("  Referred: %d (paid: %d, pending: %d, rejected: %d)", len(referrals),
+		counts[vault.ReferralPaid], counts[vault.ReferralPending], counts[vault.ReferralRejected])
+	s.writeResponse(client, response)
+}
+
+// Pays a pending referral bonus once the player has wagered enough
+func (s *Server) settleReferral(client *ClientState) {
+	if !s.featureOn(security.FeatureReferrals) {
+		return
+	}
+	payout, err := s.authService.CheckReferral(client.user.ID)
+	if err != nil {
+		s.log.game.Error("Failed to check referral", "user", client.user.Username, "err", err)
+		return
+	}
+	if payout == nil {
+		return
+	}
+
+	client.user.Balance = payout.ReferredBalance
+	amount := float64(payout.Amount) / 100
+	for _, c := range s.hub.clientsForUser(payout.ReferredID, nil) {
+		s.pushEvent(c, "REFERRAL", fmt.Sprintf("Referral bonus of $%.2f credited. New balance: $%.2f",
+			amount, float64(payout.ReferredBalance)/100))
+	}
+	for _, c := range s.hub.clientsForUser(payout.ReferrerID, nil) {
+		s.pushEvent(c, "REFERRAL", fmt.Sprintf("%s played through your referral, $%.2f credited. New balance: $%.2f",
+			client.user.Username, amount, float64(payout.ReferrerBalance)/100))
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Files the digest of every day finished since the last one and writes each
+// to the log
+func (s *Server) fileDailyReports() {
+	reports, err := s.authService.FileDailyReports(time.Now())
+	if err != nil {
+		s.log.vault.Error("Failed to file daily report", "err", err)
+	}
+	for _, r := range reports {
+		s.log.server.Info("Daily report", "day", r.Day, "signups", r.Signups, "guests", r.Guests,
+			"active_players", r.ActivePlayers, "wagered", r.Wagered, "paid", r.Paid, "hold", r.Hold(),
+			"biggest_win", r.BiggestWin, "biggest_winner", r.BiggestWinner)
+	}
+}
+
+// ADMIN REPORT [day] shows a day's digest: yesterday's by default, or today's
+// so far
+func (s *Server) adminReport(args []string) (string, error) {
+	if len(args) > 1 {
+		return "", usageErrorf("Usage: ADMIN REPORT [YYYY-MM-DD|today|yesterday]")
+	}
+
+	now := time.Now().UTC()
+	day := now.AddDate(0, 0, -1)
+	if len(args) == 1 {
+		switch strings.ToLower(args[0]) {
+		case "today":
+			day = now
+		case "yesterday":
+		default:
+			var err error
+			if day, err = time.Parse("2006-01-02", args[0]); err != nil {
+				return "", fmt.Errorf("day must be today, yesterday or a UTC date like 2006-01-02")
+			}
+			if day.After(now) {
+				return "", fmt.Errorf("that day hasn't happened yet")
+			}
+		}
+	}
+
+	report, err := s.authService.DailyReport(day)
+	if err != nil {
+		return "", err
+	}
+	return formatDailyReport(report), nil
+}
+
+func formatDailyReport(r *vault.DailyReport) string {
+	title := fmt.Sprintf("Daily report for %s (UTC)", r.Day)
+	if r.CreatedAt.IsZero() {
+		title += ", not filed yet:"
+	} else {
+		title += fmt.Sprintf(", filed %s:", r.CreatedAt.Format("2006-01-02 15:04"))
+	}
+
+	report := title
+	report += fmt.Sprintf("\n  New signups: %d (and %d guests)", r.Signups, r.Guests)
+	report += fmt.Sprintf("\n  Active players: %d", r.ActivePlayers)
+	report += fmt.Sprintf("\n  Wagered: $%.2f, paid: $%.2f, hold: $%.2f", float64(r.Wagered)/100, float64(r.Paid)/100, float64(r.Hold())/100)
+	for _, h := range r.Games {
+		report += fmt.Sprintf("\n    %-10s rounds: %-6d wagered: $%-10.2f paid: $%-10.2f hold: $%.2f (%.2f%%)",
+			h.Game, h.Rounds, float64(h.Wagered)/100, float64(h.Paid)/100, float64(h.Hold())/100, h.HoldPercent())
+	}
+	if r.BiggestWin > 0 {
+		winner := r.BiggestWinner
+		if winner == "" {
+			winner = "a deleted guest"
+		}
+		report += fmt.Sprintf("\n  Biggest win: $%.2f by %s at %s", float64(r.BiggestWin)/100, winner, r.BiggestWinGame)
+	} else {
+		report += "\n  Biggest win: none"
+	}
+	return report
+}
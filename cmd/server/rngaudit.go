@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+// Shuffles an RNG audit runs unless told otherwise
+const defaultAuditShuffles = 100000
+
+// Runs "rngaudit [shuffles]": statistical tests of the shuffle the server
+// deals with, printed as a report. Exits 1 when a test fails.
+func rngAuditCommand(args []string) int {
+	shuffles := defaultAuditShuffles
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "Usage: rngaudit [shuffles]")
+		return 2
+	}
+	if len(args) == 1 {
+		n, err := strconv.Atoi(args[0])
+		// Fewer leave too few cards per cell for the 52 x 52 test
+		if err != nil || n < 20000 {
+			fmt.Fprintln(os.Stderr, "Error: shuffles must be a number of at least 20000")
+			return 2
+		}
+		shuffles = n
+	}
+
+	start := time.Now()
+	audit := game.AuditRNG(shuffles, (*game.Deck).Shuffle)
+	fmt.Printf("Casino RNG audit, %s (%s, %s/%s)\n", start.UTC().Format(time.RFC3339), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Println(audit)
+	fmt.Printf("Took %s\n", time.Since(start).Truncate(time.Millisecond))
+
+	if !audit.Passed() {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+// rtpMonitor tracks the return to player of each game since the server
+// started, as a canary for mistakes in payout math
+type rtpMonitor struct {
+	mu      sync.Mutex
+	games   map[string]*game.RTPTracker
+	alerted map[string]bool // Games whose RTP is out of bounds and already alerted
+}
+
+func newRTPMonitor() *rtpMonitor {
+	return &rtpMonitor{games: make(map[string]*game.RTPTracker), alerted: make(map[string]bool)}
+}
+
+func (m *rtpMonitor) tracker(name string) *game.RTPTracker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.games[name]
+	if !ok {
+		t = new(game.RTPTracker)
+		m.games[name] = t
+	}
+	return t
+}
+
+// Returns each game's stats, sorted by game
+func (m *rtpMonitor) stats() ([]string, map[string]game.RTPStats) {
+	m.mu.Lock()
+	names := make([]string, 0, len(m.games))
+	trackers := make(map[string]*game.RTPTracker, len(m.games))
+	for name, t := range m.games {
+		names = append(names, name)
+		trackers[name] = t
+	}
+	m.mu.Unlock()
+
+	sort.Strings(names)
+	stats := make(map[string]game.RTPStats, len(names))
+	for _, name := range names {
+		stats[name] = trackers[name].Stats()
+	}
+	return names, stats
+}
+
+// Adds a settled round to its game's RTP and alerts once it strays
+// RTP_ALERT_SIGMA standard errors from the expected RTP
+func (s *Server) recordRTP(name string, expectedRTP float64, wagered, payout int64) {
+	t := s.rtp.tracker(name)
+	t.Record(expectedRTP, wagered, payout)
+
+	bound := float64(s.config.RTPAlertSigma)
+	if bound <= 0 {
+		return
+	}
+	stats := t.Stats()
+	if stats.Rounds < int64(s.config.RTPMinRounds) {
+		return
+	}
+
+	out := math.Abs(stats.Sigma) >= bound
+	s.rtp.mu.Lock()
+	alert := out && !s.rtp.alerted[name]
+	s.rtp.alerted[name] = out
+	s.rtp.mu.Unlock()
+	if !alert {
+		return
+	}
+
+	direction := "above"
+	if stats.Sigma < 0 {
+		direction = "below"
+	}
+	s.alert(alertRTP, fmt.Sprintf("%s is paying back %.2f%% over %d rounds, %.1f standard errors %s the expected %.2f%%",
+		name, stats.Observed*100, stats.Rounds, math.Abs(stats.Sigma), direction, stats.Expected*100),
+		map[string]any{"game": name, "rounds": stats.Rounds, "observed": stats.Observed, "expected": stats.Expected, "sigma": stats.Sigma})
+}
+
+// Lists each game's RTP since start for ADMIN HOUSE
+func (s *Server) rtpReport() string {
+	names, stats := s.rtp.stats()
+	if len(names) == 0 {
+		return "\nReturn to player since start: no rounds yet"
+	}
+
+	report := "\nReturn to player since start:"
+	for _, name := range names {
+		st := stats[name]
+		report += fmt.Sprintf("\n  %-10s rounds: %-6d observed: %.2f%%  expected: %.2f%%  (%+.1f standard errors)",
+			name, st.Rounds, st.Observed*100, st.Expected*100, st.Sigma)
+	}
+	return report
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alessandrosisniegas/casino/core/security"
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Sets up a server on a fresh database, with the default settings but no
+// rate limits so tests can send commands as fast as they like
+func newTestServer(t testing.TB) *Server {
+	t.Helper()
+	db, err := vault.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cfg := loadConfig()
+	cfg.RateLimit, cfg.AuthRateLimit = 0, 0
+	logs, err := newLoggers(io.Discard, "text", "")
+	if err != nil {
+		t.Fatalf("newLoggers() error = %v", err)
+	}
+	auth := security.NewAuthServiceWithConfig(db, security.AuthConfig{
+		DepositMin:  int64(cfg.DepositMin) * 100,
+		DepositMax:  int64(cfg.DepositMax) * 100,
+		WithdrawMin: int64(cfg.WithdrawMin) * 100,
+		WithdrawMax: int64(cfg.WithdrawMax) * 100,
+	})
+	s, err := newServer(cfg, db, auth, logs)
+	if err != nil {
+		t.Fatalf("newServer() error = %v", err)
+	}
+	return s
+}
+
+// Signs up username the first time it's asked for and returns a new session
+// logged in to it
+func loginTestClient(t testing.TB, s *Server, username string) *ClientState {
+	t.Helper()
+	if _, err := s.db.GetUserByUsername(username); err != nil {
+		if _, err := s.authService.RegisterUser(username, "testpassword456"); err != nil {
+			t.Fatalf("RegisterUser() error = %v", err)
+		}
+	}
+	client := s.newRPCSession(context.Background()).client
+	if reply := runCommand(s, client, "LOGIN "+username+" testpassword456"); !strings.HasPrefix(reply, "OK") {
+		t.Fatalf("LOGIN = %q", reply)
+	}
+	return client
+}
+
+// Runs one command on the session as its connection would and returns what
+// it wrote back
+func runCommand(s *Server, client *ClientState, line string) string {
+	conn := client.conn.(*rpcConn)
+	fields := strings.Fields(line)
+
+	client.cmdMu.Lock()
+	conn.take()
+	s.handleCommand(client, strings.ToUpper(fields[0]), fields[1:])
+	out := conn.take()
+	client.cmdMu.Unlock()
+	return strings.TrimSuffix(out, "\n")
+}
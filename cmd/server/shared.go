@@ -0,0 +1,682 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+	"github.com/alessandrosisniegas/casino/core/security"
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// A shared blackjack table and the connections seated at it. Every change to
+// the table happens under mu, whichever player's goroutine (or the betting
+// timer) makes it.
+type sharedTable struct {
+	id      string
+	host    string // Player who opened the table with HOST; empty for the house's tables
+	mu      sync.Mutex
+	table   *game.SharedTable
+	clients [game.MaxSeats]*ClientState
+
+	// Escrow hold of each seat's bet this round, zero for seats without one
+	holds [game.MaxSeats]int64
+
+	// Why an admin froze the table; while set nobody can bet, act or join
+	// and its timers are stopped
+	frozen string
+
+	// Bumped every round so a betting timer left from an earlier round does nothing
+	round    int
+	betTimer *time.Timer
+
+	// Bumped every time a turn timer starts, so one that was replaced does nothing
+	turnSeq   int
+	turnTimer *time.Timer
+}
+
+// How long before a turn runs out the player is warned
+const turnWarning = 10 * time.Second
+
+// Verbs for the table log, by action
+var actionVerbs = map[string]string{
+	game.ActionHit:        "hits",
+	game.ActionStand:      "stands",
+	game.ActionDoubleDown: "doubles down",
+	game.ActionSurrender:  "surrenders",
+}
+
+func (s *Server) handleJoin(client *ClientState, args []string) {
+	if len(args) != 1 {
+		s.writeError(client, "USAGE", "Usage: JOIN <table> (see TABLES)")
+		return
+	}
+	if !s.canSit(client) {
+		return
+	}
+
+	s.lobby.mu.Lock()
+	defer s.lobby.mu.Unlock()
+
+	st, err := s.lobby.pick(args[0])
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+	s.sitAt(client, st)
+}
+
+// HOST opens a multiplayer table with the player's own rules and seats them at it
+func (s *Server) handleHost(client *ClientState, args []string) {
+	if len(args) == 0 {
+		s.writeError(client, "USAGE", "Usage: HOST <stake> [3:2|6:5] [s17|h17] [surrender|nosurrender] [decks=1-8] [pen=50-90] [turn=10-120]")
+		return
+	}
+	if !s.canSit(client) {
+		return
+	}
+
+	stake, ok := game.FindTable(args[0])
+	if !ok {
+		s.writeResponse(client, "ERROR Unknown stake. Use low, mid or high")
+		return
+	}
+	rules, err := game.ParseRuleOptions(stake.Rules, args[1:])
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	s.lobby.mu.Lock()
+	defer s.lobby.mu.Unlock()
+
+	st, err := s.lobby.host(stake, rules, client.user.Username)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+	s.sitAt(client, st)
+}
+
+// Writes an error and returns false unless the client can take a seat
+func (s *Server) canSit(client *ClientState) bool {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return false
+	}
+
+	if client.seatedAt != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR You are already seated at %s, LEAVE first", client.seatedAt.table.Table.Name))
+		return false
+	}
+
+	if client.game != nil && client.game.Phase != game.PhaseGameOver {
+		s.writeResponse(client, "ERROR Finish your current hand before joining a table")
+		return false
+	}
+
+	return true
+}
+
+// Seats the client at st. Callers hold s.lobby.mu.
+func (s *Server) sitAt(client *ClientState, st *sharedTable) {
+	table := st.table.Table
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.frozen != "" {
+		s.writeResponse(client, "ERROR This table is frozen by an administrator")
+		return
+	}
+
+	seat, err := st.table.Sit(client.user.ID, client.user.Username)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+	st.clients[seat] = client
+	client.seatedAt = st
+	client.seat = seat
+
+	rules := s.rulesFor(client, table)
+	s.writeData(client, fmt.Sprintf("OK Joined %s (%s) in seat %d (bets $%.2f - $%.2f; %s). BET to play the next round, LEAVE to stand up.\n%s",
+		st.id, table.Name, seat+1, float64(rules.MinBet)/100, float64(rules.MaxBet)/100, s.describeRules(st), st.table.State(seat)), tableData(st, seat))
+	s.showTable(st, client, fmt.Sprintf("%s sat down in seat %d", client.user.Username, seat+1))
+}
+
+func (s *Server) handleLeave(client *ClientState, _ []string) {
+	st := client.seatedAt
+	if st == nil {
+		s.writeResponse(client, "ERROR You are not seated at a table")
+		return
+	}
+
+	st.mu.Lock()
+	if st.table.Seats[client.seat].InPlay() {
+		st.mu.Unlock()
+		s.writeResponse(client, "ERROR Finish your hand before leaving the table")
+		return
+	}
+	s.leaveSeat(st, client)
+	st.mu.Unlock()
+
+	s.writeResponse(client, fmt.Sprintf("OK You left %s (%s)", st.id, st.table.Table.Name))
+	s.pruneTables()
+}
+
+// TABLE shows the shared table the player is seated at
+func (s *Server) handleTable(client *ClientState, _ []string) {
+	st := client.seatedAt
+	if st == nil {
+		s.writeResponse(client, "ERROR You are not seated at a table. Use JOIN <table> to sit down")
+		return
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	s.writeData(client, "OK\n"+st.table.State(client.seat), tableData(st, client.seat))
+}
+
+// Gets a disconnecting player up from their table; a hand in play is stood
+// and still settled
+func (s *Server) leaveTable(client *ClientState) {
+	st := client.seatedAt
+	if st == nil {
+		return
+	}
+
+	st.mu.Lock()
+	s.leaveSeat(st, client)
+	st.mu.Unlock()
+
+	s.pruneTables()
+}
+
+// Frees the client's seat, refunding a bet the round hadn't been dealt with
+// yet. Callers hold st.mu.
+func (s *Server) leaveSeat(st *sharedTable, client *ClientState) {
+	seat := client.seat
+	userID, name := st.table.Seats[seat].UserID, st.table.Seats[seat].Name
+
+	if refund := st.table.Leave(seat); refund > 0 {
+		balance, _, err := s.authService.ReleaseHold(st.holds[seat])
+		if err != nil {
+			s.log.game.Error("Failed to refund table bet", "table", st.id, "user_id", userID, "amount", refund, "err", err)
+		} else if client.user != nil {
+			client.user.Balance = balance
+		}
+		st.holds[seat] = 0
+	}
+
+	// A hand still in play keeps its connection until the round is settled
+	if st.table.Seats[seat] == nil {
+		st.clients[seat] = nil
+	}
+	client.seatedAt = nil
+
+	s.showTable(st, client, fmt.Sprintf("%s left the table", name))
+	s.progressTable(st)
+}
+
+// Commands that switch accounts, refused while seated so a hand in play is
+// always settled to the account that bet it
+var seatedBlockedCommands = map[string]bool{
+	"LOGIN":  true,
+	"LOGOUT": true,
+	"AUTH":   true,
+	"GUEST":  true,
+	"RESUME": true,
+}
+
+// Writes an error and returns false when a seated player tries to switch accounts
+func (s *Server) requireUnseated(client *ClientState, command string) bool {
+	if client.seatedAt == nil || !seatedBlockedCommands[command] {
+		return true
+	}
+
+	s.writeResponse(client, "ERROR LEAVE your table before switching accounts")
+	return false
+}
+
+// Routes BET, HIT, STAND, DOUBLEDOWN and SURRENDER for a seated player
+func (s *Server) handleTableCommand(client *ClientState, command string, args []string) {
+	if command == "BET" {
+		s.tableBet(client, args)
+		return
+	}
+
+	action := command
+	if action == "DOUBLE" {
+		action = game.ActionDoubleDown
+	}
+	s.tableAction(client, action)
+}
+
+func (s *Server) tableBet(client *ClientState, args []string) {
+	st := client.seatedAt
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.frozen != "" {
+		s.writeResponse(client, "ERROR This table is frozen by an administrator")
+		return
+	}
+	if st.table.Phase != game.PhaseWaitingForBet {
+		s.writeResponse(client, "ERROR Betting is closed, wait for the next round")
+		return
+	}
+	if st.table.Seats[client.seat].Game != nil {
+		s.writeResponse(client, "ERROR You have already bet this round")
+		return
+	}
+
+	betCents, ok := s.placeTableBet(st, client, args)
+	if !ok {
+		return
+	}
+
+	if st.table.Bettors() == 1 {
+		s.startBetTimer(st)
+	}
+
+	s.writeResponse(client, fmt.Sprintf("OK Bet $%.2f placed. Cards are dealt once everyone has bet or %d seconds after the first bet",
+		float64(betCents)/100, s.config.TableBetSeconds))
+	s.showTable(st, client, fmt.Sprintf("%s bets $%.2f", client.user.Username, float64(betCents)/100))
+	s.progressTable(st)
+}
+
+// Checks and takes a table bet under the player's lock, holding the stake in
+// escrow until the round is settled. Callers hold st.mu.
+func (s *Server) placeTableBet(st *sharedTable, client *ClientState, args []string) (int64, bool) {
+	defer s.userLocks.lock(client.user.ID)()
+
+	rules := s.rulesFor(client, st.table.Table)
+	betCents, sides, ok := s.checkBet(client, args, rules)
+	if !ok {
+		return 0, false
+	}
+	if len(sides) > 0 {
+		s.writeResponse(client, "ERROR Side bets are only offered on solo hands")
+		return 0, false
+	}
+
+	hold, newBalance, err := s.authService.HoldBet(client.user.ID, security.GameBlackjack, st.id, betCents)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR Failed to update balance: %s", err.Error()))
+		return 0, false
+	}
+	client.user.Balance = newBalance
+
+	if err := st.table.PlaceBet(client.seat, betCents, rules); err != nil {
+		if refunded, _, rerr := s.authService.ReleaseHold(hold); rerr != nil {
+			s.log.game.Error("Failed to refund table bet", "table", st.id, "user", client.user.Username, "amount", betCents, "err", rerr)
+		} else {
+			client.user.Balance = refunded
+		}
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return 0, false
+	}
+	st.holds[client.seat] = hold
+	return betCents, true
+}
+
+func (s *Server) tableAction(client *ClientState, action string) {
+	st := client.seatedAt
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.frozen != "" {
+		s.writeResponse(client, "ERROR This table is frozen by an administrator")
+		return
+	}
+	if st.table.Phase != game.PhasePlayerTurn {
+		s.writeResponse(client, "ERROR No hand in play. Use BET <amount> to join the next round")
+		return
+	}
+	if st.table.Turn != client.seat {
+		s.writeResponse(client, "ERROR It's not your turn")
+		return
+	}
+
+	g := st.table.Seats[client.seat].Game
+
+	// Doubling takes the extra stake up front like a single-player double
+	var extra int64
+	if action == game.ActionDoubleDown {
+		extra = g.Bet
+		if !s.raiseTableBet(st, client, extra) {
+			return
+		}
+	}
+
+	if err := st.table.Act(client.seat, action); err != nil {
+		if extra > 0 {
+			if refunded, rerr := s.authService.AdjustHold(st.holds[client.seat], -extra); rerr != nil {
+				s.log.game.Error("Failed to refund double down", "table", st.id, "user", client.user.Username, "amount", extra, "err", rerr)
+			} else {
+				client.user.Balance = refunded
+			}
+		}
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	s.writeData(client, "OK\n"+st.table.State(client.seat), tableData(st, client.seat))
+	s.showTable(st, client, fmt.Sprintf("%s %s", client.user.Username, actionVerbs[action]))
+	s.progressTable(st)
+}
+
+// Checks and moves the extra stake of a table double down into the seat's
+// escrow under the player's lock. Callers hold st.mu.
+func (s *Server) raiseTableBet(st *sharedTable, client *ClientState, extra int64) bool {
+	defer s.userLocks.lock(client.user.ID)()
+
+	if client.user.Balance < extra {
+		s.writeError(client, "INSUFFICIENT_FUNDS", fmt.Sprintf("Insufficient balance to double down. You need $%.2f more", float64(extra)/100))
+		return false
+	}
+	if err := s.authService.CheckBetLimits(client.user.ID, extra); err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return false
+	}
+
+	newBalance, err := s.authService.AdjustHold(st.holds[client.seat], extra)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR Failed to update balance: %s", err.Error()))
+		return false
+	}
+	client.user.Balance = newBalance
+	return true
+}
+
+// Opens the betting window for the current round. Callers hold st.mu.
+func (s *Server) startBetTimer(st *sharedTable) {
+	s.stopBetTimer(st)
+
+	round := st.round
+	wait := time.Duration(s.config.TableBetSeconds) * time.Second
+	st.betTimer = time.AfterFunc(wait, func() { s.closeBetting(st, round) })
+}
+
+// Deals when the betting window of the given round runs out
+func (s *Server) closeBetting(st *sharedTable, round int) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.round != round || st.frozen != "" || st.table.Phase != game.PhaseWaitingForBet || st.table.Bettors() == 0 {
+		return
+	}
+	s.dealTable(st)
+}
+
+// Moves the table along after any change: deals once everyone has bet,
+// prompts the player whose turn it is, and settles a finished round. A frozen
+// table waits until it is reopened. Callers hold st.mu.
+func (s *Server) progressTable(st *sharedTable) {
+	if st.frozen != "" {
+		return
+	}
+
+	switch st.table.Phase {
+	case game.PhaseWaitingForBet:
+		s.stopTurnTimer(st)
+		if st.table.Bettors() == 0 {
+			s.stopBetTimer(st)
+		} else if st.table.AllBet() {
+			s.dealTable(st)
+		}
+
+	case game.PhasePlayerTurn:
+		seat := st.table.Seats[st.table.Turn]
+		prompt := "Your turn: " + strings.Join(seat.Game.GetValidActions(), ", ")
+		if secs := s.turnSeconds(st); secs > 0 {
+			prompt += fmt.Sprintf(" (%d seconds, then you stand)", secs)
+		}
+		if c := st.clients[st.table.Turn]; c != nil {
+			s.pushEventData(c, "TABLE", prompt, tableData(st, st.table.Turn))
+		}
+		s.startTurnTimer(st)
+
+	case game.PhaseGameOver:
+		s.stopTurnTimer(st)
+		s.finishTableRound(st)
+	}
+}
+
+// Seconds a player at st has to act, or 0 when the table has no turn timer
+func (s *Server) turnSeconds(st *sharedTable) int {
+	if secs := st.table.Table.Rules.TurnSeconds; secs > 0 {
+		return secs
+	}
+	return s.config.TableTurnSeconds
+}
+
+// Describes a table's rules, including its turn timer
+func (s *Server) describeRules(st *sharedTable) string {
+	rules := st.table.Table.Rules.Describe(game.ShoeDecks)
+	if secs := s.turnSeconds(st); secs > 0 {
+		rules += fmt.Sprintf(", %ds turns", secs)
+	}
+	return rules
+}
+
+// Starts the countdown for the player whose turn it is, replacing any earlier
+// one. The player is warned shortly before it runs out. Callers hold st.mu.
+func (s *Server) startTurnTimer(st *sharedTable) {
+	s.stopTurnTimer(st)
+
+	secs := s.turnSeconds(st)
+	if secs <= 0 {
+		return
+	}
+
+	st.turnSeq++
+	seq := st.turnSeq
+	wait := time.Duration(secs) * time.Second
+	if wait <= turnWarning {
+		st.turnTimer = time.AfterFunc(wait, func() { s.turnExpired(st, seq) })
+		return
+	}
+	st.turnTimer = time.AfterFunc(wait-turnWarning, func() { s.warnTurn(st, seq) })
+}
+
+// Warns the player on turn that their time is nearly up
+func (s *Server) warnTurn(st *sharedTable, seq int) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.turnSeq != seq || st.frozen != "" || st.table.Phase != game.PhasePlayerTurn {
+		return
+	}
+	if c := st.clients[st.table.Turn]; c != nil {
+		s.pushEvent(c, "TABLE", fmt.Sprintf("%d seconds left to act, then you stand", int(turnWarning/time.Second)))
+	}
+	st.turnTimer = time.AfterFunc(turnWarning, func() { s.turnExpired(st, seq) })
+}
+
+// Stands the hand of a player who ran out of time
+func (s *Server) turnExpired(st *sharedTable, seq int) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.turnSeq != seq || st.frozen != "" || st.table.Phase != game.PhasePlayerTurn {
+		return
+	}
+	st.turnTimer = nil
+
+	turn := st.table.Turn
+	name := st.table.Seats[turn].Name
+	if err := st.table.Act(turn, game.ActionStand); err != nil {
+		s.log.game.Error("Failed to stand timed out hand", "table", st.id, "player", name, "err", err)
+		return
+	}
+
+	c := st.clients[turn]
+	if c != nil {
+		s.pushEventData(c, "TABLE", "Time's up, you stand\n"+st.table.State(turn), tableData(st, turn))
+	}
+	s.showTable(st, c, fmt.Sprintf("%s ran out of time and stands", name))
+	s.progressTable(st)
+}
+
+func (s *Server) stopTurnTimer(st *sharedTable) {
+	if st.turnTimer != nil {
+		st.turnTimer.Stop()
+		st.turnTimer = nil
+	}
+}
+
+func (s *Server) dealTable(st *sharedTable) {
+	s.stopBetTimer(st)
+
+	if err := st.table.Deal(); err != nil {
+		s.log.game.Error("Failed to deal", "table", st.id, "err", err)
+		return
+	}
+
+	s.showTable(st, nil, "Cards are dealt")
+	s.progressTable(st)
+}
+
+// Settles every hand of a finished round through the normal settlement path,
+// shows the results and opens betting for the next round. The round can end
+// on another player's command or a timer, so each seat is settled on its own
+// goroutine under that player's cmdMu rather than here under st.mu.
+func (s *Server) finishTableRound(st *sharedTable) {
+	s.recordTableHand(st)
+
+	for i, c := range st.clients {
+		if c == nil {
+			continue
+		}
+		seat := st.table.Seats[i]
+		go s.settleSeat(c, seat.Game, st.holds[i], !seat.Away, st.table.State(i), tableData(st, i))
+	}
+
+	st.table.NewRound()
+	st.holds = [game.MaxSeats]int64{}
+	st.round++
+	for i := range st.clients {
+		if st.table.Seats[i] == nil {
+			st.clients[i] = nil
+		}
+	}
+}
+
+// Settles a seat's hand of a finished round, if it had one, and shows the
+// player the result unless they have left
+func (s *Server) settleSeat(client *ClientState, g *game.Game, hold int64, show bool, state string, data map[string]any) {
+	client.cmdMu.Lock()
+	defer client.cmdMu.Unlock()
+
+	if client.user == nil {
+		// Logged out by an expired session; the hold stays in escrow
+		s.log.game.Error("Table hand left unsettled", "client", client.id, "hold", hold)
+		return
+	}
+	if g != nil {
+		s.settleGame(client, g, hold)
+	}
+	if show {
+		data["balance"] = client.user.Balance
+		s.pushEventData(client, "TABLE", fmt.Sprintf("Round over. Balance: $%.2f\n%s", float64(client.user.Balance)/100, state), data)
+	}
+}
+
+// Saves a finished round for REVIEW. Callers hold st.mu.
+func (s *Server) recordTableHand(st *sharedTable) {
+	hand := &vault.TableHand{TableID: st.id, Dealer: st.table.Dealer.String(), DealerValue: st.table.Dealer.Value()}
+	for i, seat := range st.table.Seats {
+		if seat == nil || seat.Game == nil || len(seat.Game.PlayerHand.Cards) == 0 {
+			continue
+		}
+		g := seat.Game
+		hand.Seats = append(hand.Seats, &vault.TableHandSeat{
+			Seat:     i,
+			UserID:   seat.UserID,
+			Username: seat.Name,
+			Cards:    g.PlayerHand.String(),
+			Value:    g.PlayerHand.Value(),
+			Bet:      g.Bet,
+			Payout:   g.CalculatePayout(),
+			Result:   game.SeatResult(g),
+		})
+	}
+	if len(hand.Seats) == 0 {
+		return
+	}
+
+	if err := s.authService.RecordTableHand(hand); err != nil {
+		s.log.vault.Error("Failed to record table hand", "table", st.id, "err", err)
+	}
+}
+
+// REVIEW [n] replays the player's last n multiplayer hands. Other players'
+// cards are left out; only their totals and results are shown.
+func (s *Server) handleReview(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	n := 5
+	if len(args) > 1 {
+		s.writeError(client, "USAGE", "Usage: REVIEW [hands]")
+		return
+	}
+	if len(args) == 1 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil {
+			s.writeError(client, "USAGE", "Usage: REVIEW [hands]")
+			return
+		}
+	}
+
+	hands, err := s.authService.ReviewHands(client.user.ID, n)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+	if len(hands) == 0 {
+		s.writeResponse(client, "OK You haven't played any multiplayer hands yet")
+		return
+	}
+
+	review := "OK Your last table hands, newest first:"
+	for _, h := range hands {
+		review += fmt.Sprintf("\n#%d %s %s - Dealer: %s (Value: %d)", h.ID, h.TableID, h.CreatedAt.Local().Format("2006-01-02 15:04"), h.Dealer, h.DealerValue)
+		for _, seat := range h.Seats {
+			if seat.UserID == client.user.ID {
+				review += fmt.Sprintf("\n  Seat %d %s (you): %s (Value: %d) Bet: $%.2f - %s, paid $%.2f",
+					seat.Seat+1, seat.Username, seat.Cards, seat.Value, float64(seat.Bet)/100, seat.Result, float64(seat.Payout)/100)
+				continue
+			}
+			review += fmt.Sprintf("\n  Seat %d %s: %d - %s", seat.Seat+1, seat.Username, seat.Value, seat.Result)
+		}
+	}
+	s.writeResponse(client, review)
+}
+
+func (s *Server) stopBetTimer(st *sharedTable) {
+	if st.betTimer != nil {
+		st.betTimer.Stop()
+		st.betTimer = nil
+	}
+}
+
+// Pushes the table to everyone seated except the player who caused the change
+func (s *Server) showTable(st *sharedTable, except *ClientState, message string) {
+	for i, c := range st.clients {
+		if c == nil || c == except || st.table.Seats[i] == nil || st.table.Seats[i].Away {
+			continue
+		}
+		s.pushEventData(c, "TABLE", message+"\n"+st.table.State(i), tableData(st, i))
+	}
+}
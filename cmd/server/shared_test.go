@@ -0,0 +1,145 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+// Seats two fresh players at the same shared table with $500 each and has
+// both bet $10, so the cards are dealt
+func seatTestPlayers(t *testing.T, s *Server) (*sharedTable, []*ClientState) {
+	t.Helper()
+	var clients []*ClientState
+	for _, name := range []string{"seatone", "seattwo"} {
+		client := loginTestClient(t, s, name)
+		setTestBalance(t, s, client.user.ID, 50000)
+		if reply := runCommand(s, client, "JOIN low"); !strings.HasPrefix(reply, "OK Joined") {
+			t.Fatalf("JOIN = %q", reply)
+		}
+		clients = append(clients, client)
+	}
+	st := clients[0].seatedAt
+	if clients[1].seatedAt != st {
+		t.Fatal("players were seated at different tables")
+	}
+	for _, client := range clients {
+		if reply := runCommand(s, client, "BET 10"); !strings.HasPrefix(reply, "OK Bet") {
+			t.Fatalf("BET = %q", reply)
+		}
+	}
+	return st, clients
+}
+
+// Runs BALANCE on every session over and over until the returned func is
+// called, so the players' own commands race whatever settles the round
+func pollBalances(s *Server, clients []*ClientState) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					runCommand(s, client, "BALANCE")
+				}
+			}
+		}()
+	}
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// Waits until both players' hands are settled, then checks that each
+// connection's balance is the database's and that it paid the hand as
+// REVIEW records it
+func checkTableSettled(t *testing.T, s *Server, clients []*ClientState) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for _, client := range clients {
+		client.cmdMu.Lock()
+		user := client.user
+		client.cmdMu.Unlock()
+		for {
+			rounds, err := s.db.ListGameRounds(user.ID, 1, 0)
+			if err != nil {
+				t.Fatalf("ListGameRounds() error = %v", err)
+			}
+			if len(rounds) > 0 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s's hand was never settled", user.Username)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	for _, client := range clients {
+		client.cmdMu.Lock()
+		user := client.user
+		client.cmdMu.Unlock()
+
+		stored, err := s.db.GetUserByID(user.ID)
+		if err != nil {
+			t.Fatalf("GetUserByID() error = %v", err)
+		}
+		if user.Balance != stored.Balance {
+			t.Errorf("%s's connection has balance %d, database has %d", user.Username, user.Balance, stored.Balance)
+		}
+
+		hands, err := s.authService.ReviewHands(user.ID, 1)
+		if err != nil || len(hands) != 1 {
+			t.Fatalf("ReviewHands() = %v, %v", hands, err)
+		}
+		for _, seat := range hands[0].Seats {
+			if seat.UserID != user.ID {
+				continue
+			}
+			if want := 50000 - seat.Bet + seat.Payout; stored.Balance != want {
+				t.Errorf("%s has balance %d after a $%.2f hand paying %d, want %d", user.Username, stored.Balance, float64(seat.Bet)/100, seat.Payout, want)
+			}
+		}
+	}
+}
+
+func TestTableRoundSettlesEverySeat(t *testing.T) {
+	s := newTestServer(t)
+	st, clients := seatTestPlayers(t, s)
+
+	stop := pollBalances(s, clients[1:])
+	for {
+		st.mu.Lock()
+		var onTurn *ClientState
+		if st.table.Phase == game.PhasePlayerTurn {
+			onTurn = st.clients[st.table.Turn]
+		}
+		st.mu.Unlock()
+		if onTurn == nil {
+			break
+		}
+		runCommand(s, onTurn, "STAND")
+	}
+	checkTableSettled(t, s, clients)
+	stop()
+}
+
+func TestTableTurnTimerSettlesRound(t *testing.T) {
+	s := newTestServer(t)
+	s.config.TableTurnSeconds = 1
+	_, clients := seatTestPlayers(t, s)
+
+	// Nobody acts; the timer stands each hand in turn and settles the round
+	stop := pollBalances(s, clients)
+	checkTableSettled(t, s, clients)
+	stop()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/security"
+)
+
+// Lists what comp points and chips can buy along with the player's points
+func (s *Server) handleShop(client *ClientState, _ []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	user, err := s.refreshUser(client)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	owned, err := s.authService.ListItems(user.ID)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+	have := make(map[string]bool, len(owned))
+	for _, id := range owned {
+		have[id] = true
+	}
+
+	response := fmt.Sprintf("OK Comp points: %d (1 point per $%d wagered)", user.Points, security.CentsPerCompPoint/100)
+	for _, item := range security.ShopItems {
+		points, price, status := "-", "-", ""
+		if item.Cost > 0 {
+			points = fmt.Sprintf("%d pts", item.Cost)
+		}
+		if item.Price > 0 {
+			price = fmt.Sprintf("$%.2f", float64(item.Price)/100)
+		}
+		switch {
+		case have[item.ID]:
+			status = " (owned)"
+		case security.DefaultCosmetics[item.Slot] == item.ID:
+			status = " (free)"
+		}
+		response += fmt.Sprintf("\n  %-18s %-20s %9s %9s%s", item.ID, item.Name, points, price, status)
+	}
+	response += "\nREDEEM <item> pays with points, BUY <item> with chips, EQUIP <item> to use a cosmetic"
+	s.writeResponse(client, response)
+}
+
+func (s *Server) handleBuy(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	if len(args) != 1 {
+		s.writeError(client, "USAGE", "Usage: BUY <item>")
+		return
+	}
+
+	item, balance, err := s.authService.Buy(client.user.ID, args[0])
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	client.user.Balance = balance
+	s.writeResponse(client, fmt.Sprintf("OK Bought %s for $%.2f. New balance: $%.2f. Use EQUIP %s to use it",
+		item.Name, float64(item.Price)/100, float64(balance)/100, item.ID))
+}
+
+func (s *Server) handleEquip(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	if len(args) != 1 {
+		s.writeError(client, "USAGE", "Usage: EQUIP <item>")
+		return
+	}
+
+	item, err := s.authService.Equip(client.user.ID, args[0])
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	s.writeResponse(client, fmt.Sprintf("OK Equipped %s", item.Name))
+}
+
+// PROFILE lists one field per line so clients can pick up equipped cosmetics
+func (s *Server) handleProfile(client *ClientState, _ []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	profile, err := s.authService.GetProfile(client.user.ID)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	response := fmt.Sprintf("OK Profile for %s:\n", profile.Username)
+	response += fmt.Sprintf("  Level: %d (%s VIP)\n", profile.Progress.Level, profile.Progress.Tier.Name)
+	if title, ok := security.FindShopItem(profile.Equipped[security.SlotTitle]); ok {
+		response += fmt.Sprintf("  Title: %s\n", strings.TrimSuffix(title.Name, " title"))
+	}
+	response += fmt.Sprintf("  Card Back: %s\n", profile.Equipped[security.SlotCardBack])
+	response += fmt.Sprintf("  Table Theme: %s", profile.Equipped[security.SlotTableTheme])
+	s.writeResponse(client, response)
+}
+
+func (s *Server) handleRedeem(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	if len(args) != 1 {
+		s.writeError(client, "USAGE", "Usage: REDEEM <item|promo code>")
+		return
+	}
+
+	// Anything that isn't a shop item is treated as a promo code
+	if _, ok := security.FindShopItem(args[0]); !ok {
+		s.redeemPromoCode(client, args[0])
+		return
+	}
+
+	item, points, balance, err := s.authService.Redeem(client.user.ID, args[0])
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	client.user.Points = points
+	client.user.Balance = balance
+	s.writeResponse(client, fmt.Sprintf("OK Redeemed %s for %d points. Points left: %d, balance: $%.2f",
+		item.Name, item.Cost, points, float64(balance)/100))
+}
+
+func (s *Server) redeemPromoCode(client *ClientState, code string) {
+	if client.user.IsGuest {
+		s.writeError(client, "FORBIDDEN", "Guest accounts can't redeem promo codes, SIGNUP for a full account")
+		return
+	}
+
+	value, balance, err := s.authService.RedeemPromoCode(client.user.ID, code, client.ip)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+
+	client.user.Balance = balance
+	s.writeResponse(client, fmt.Sprintf("OK Promo code redeemed for $%.2f. New balance: $%.2f",
+		float64(value)/100, float64(balance)/100))
+}
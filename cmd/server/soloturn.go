@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+// Starts the countdown for the connection's solo hand, replacing any earlier
+// one, so a player who stops acting has their hand stood and settled. The
+// player is warned shortly before it runs out, as at a table. Runs during a
+// command, under client.cmdMu.
+func (s *Server) startSoloTurn(client *ClientState) {
+	s.stopSoloTurn(client)
+
+	wait := s.soloTurn
+	if wait <= 0 || client.game == nil || client.game.Phase != game.PhasePlayerTurn {
+		return
+	}
+
+	client.turnSeq++
+	seq := client.turnSeq
+	if wait <= turnWarning {
+		client.turnTimer = time.AfterFunc(wait, func() { s.soloTurnExpired(client, seq) })
+		return
+	}
+	client.turnTimer = time.AfterFunc(wait-turnWarning, func() { s.warnSoloTurn(client, seq) })
+}
+
+func (s *Server) stopSoloTurn(client *ClientState) {
+	if client.turnTimer != nil {
+		client.turnTimer.Stop()
+		client.turnTimer = nil
+	}
+}
+
+// Warns the player that their time is nearly up
+func (s *Server) warnSoloTurn(client *ClientState, seq int) {
+	client.cmdMu.Lock()
+	defer client.cmdMu.Unlock()
+
+	if client.turnSeq != seq || client.game == nil || client.game.Phase != game.PhasePlayerTurn {
+		return
+	}
+	s.pushEvent(client, "GAME", fmt.Sprintf("%d seconds left to act, then you stand", int(turnWarning/time.Second)))
+	client.turnTimer = time.AfterFunc(turnWarning, func() { s.soloTurnExpired(client, seq) })
+}
+
+// Stands and settles the hand of a player who ran out of time
+func (s *Server) soloTurnExpired(client *ClientState, seq int) {
+	client.cmdMu.Lock()
+	defer client.cmdMu.Unlock()
+
+	if client.turnSeq != seq || client.game == nil || client.game.Phase != game.PhasePlayerTurn {
+		return
+	}
+	client.turnTimer = nil
+
+	if err := client.game.Stand(); err != nil {
+		s.log.game.Error("Failed to stand timed out hand", "user", client.user.Username, "err", err)
+		return
+	}
+	s.log.game.Debug("Stood timed out hand", "user", client.user.Username, "bet", client.game.Bet)
+	s.pushEvent(client, "GAME", "Time's up, you stand\n"+client.game.GetGameState(false))
+	s.handleGameOver(client)
+}
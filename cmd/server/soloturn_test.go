@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+// Bets until the session is dealt a hand still waiting on the player that
+// suits, standing on the others
+func dealTestHand(t *testing.T, s *Server, client *ClientState, suits func(*game.Game) bool) {
+	t.Helper()
+	for i := 0; i < 500; i++ {
+		if reply := runCommand(s, client, "BET 1"); !strings.Contains(reply, "OK Game started!") {
+			t.Fatalf("BET = %q", reply)
+		}
+		if client.game == nil {
+			continue
+		}
+		if client.game.Phase == game.PhasePlayerTurn && suits(client.game) {
+			return
+		}
+		runCommand(s, client, "STAND")
+	}
+	t.Fatal("no suitable hand dealt")
+}
+
+func countRounds(t *testing.T, s *Server, client *ClientState) int {
+	t.Helper()
+	rounds, err := s.db.ListGameRounds(client.user.ID, 1000, 0)
+	if err != nil {
+		t.Fatalf("ListGameRounds() error = %v", err)
+	}
+	return len(rounds)
+}
+
+// Waits for the session's solo hand to be over, reporting whether it was
+// within the time given
+func waitHandOver(client *ClientState, within time.Duration) bool {
+	deadline := time.Now().Add(within)
+	for time.Now().Before(deadline) {
+		client.cmdMu.Lock()
+		over := client.game == nil || client.game.Phase == game.PhaseGameOver
+		client.cmdMu.Unlock()
+		if over {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false
+}
+
+func TestSoloTurnStandsIdleHand(t *testing.T) {
+	s := newTestServer(t)
+	s.soloTurn = 50 * time.Millisecond
+	client := loginTestClient(t, s, "idleplayer")
+
+	dealTestHand(t, s, client, func(*game.Game) bool { return true })
+	before := countRounds(t, s, client)
+	conn := client.conn.(*rpcConn)
+	conn.take()
+
+	if !waitHandOver(client, 2*time.Second) {
+		t.Fatal("idle hand wasn't stood")
+	}
+	// Long enough for a second, stray expiry to show
+	time.Sleep(4 * s.soloTurn)
+
+	if got := countRounds(t, s, client); got != before+1 {
+		t.Errorf("idle hand settled %d times, want once", got-before)
+	}
+	if out := conn.take(); strings.Count(out, "Time's up, you stand") != 1 {
+		t.Errorf("idle hand events = %q, want one time's up", out)
+	}
+	if client.turnTimer != nil {
+		t.Error("turn timer still set after the hand was stood")
+	}
+}
+
+func TestSoloTurnResetByAction(t *testing.T) {
+	s := newTestServer(t)
+	s.soloTurn = 300 * time.Millisecond
+	client := loginTestClient(t, s, "activeplayer")
+
+	// A hand a hit can't end, so the turn goes on after it
+	dealTestHand(t, s, client, func(g *game.Game) bool { return g.PlayerHand.Value() <= 9 })
+	conn := client.conn.(*rpcConn)
+	before := countRounds(t, s, client)
+
+	time.Sleep(200 * time.Millisecond)
+	if reply := runCommand(s, client, "HIT"); !strings.HasPrefix(reply, "OK") {
+		t.Fatalf("HIT = %q", reply)
+	}
+	// Past when the first countdown would have run out
+	time.Sleep(200 * time.Millisecond)
+	client.cmdMu.Lock()
+	playing := client.game != nil && client.game.Phase == game.PhasePlayerTurn
+	client.cmdMu.Unlock()
+	if !playing {
+		t.Fatalf("hand was stood on the countdown from before the hit: %q", conn.take())
+	}
+
+	// A move that ends the hand stops the countdown altogether
+	if reply := runCommand(s, client, "STAND"); !strings.HasPrefix(reply, "OK") {
+		t.Fatalf("STAND = %q", reply)
+	}
+	time.Sleep(2 * s.soloTurn)
+	if out := conn.take(); strings.Contains(out, "Time's up") {
+		t.Errorf("stood hand still timed out: %q", out)
+	}
+	if got := countRounds(t, s, client); got != before+1 {
+		t.Errorf("hand settled %d times, want once", got-before)
+	}
+	if client.turnTimer != nil {
+		t.Error("turn timer still set after STAND")
+	}
+}
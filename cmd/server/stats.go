@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+// Seconds the command rate is averaged over
+const statsWindow = 60
+
+// serverStats counts activity since the server started, for the console's
+// stats, ADMIN STATS and the metrics endpoint
+type serverStats struct {
+	started   time.Time
+	commands  atomic.Int64
+	soloGames atomic.Int64 // Solo hands dealt and not yet settled or abandoned
+	wagered   atomic.Int64 // Cents bet on settled hands
+	paid      atomic.Int64 // Cents paid out on settled hands
+
+	// Commands handled in each of the last statsWindow seconds, indexed by
+	// the Unix second modulo statsWindow
+	mu      sync.Mutex
+	buckets [statsWindow]struct{ second, count int64 }
+}
+
+func newServerStats() *serverStats {
+	return &serverStats{started: time.Now()}
+}
+
+func (st *serverStats) countCommand() {
+	st.commands.Add(1)
+
+	now := time.Now().Unix()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	b := &st.buckets[now%statsWindow]
+	if b.second != now {
+		b.second, b.count = now, 0
+	}
+	b.count++
+}
+
+// Returns the average commands per second over the last statsWindow seconds
+func (st *serverStats) commandRate() float64 {
+	now := time.Now().Unix()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var total int64
+	for _, b := range st.buckets {
+		if now-b.second < statsWindow {
+			total += b.count
+		}
+	}
+	return float64(total) / statsWindow
+}
+
+// A point-in-time view of the server's activity
+type statsSnapshot struct {
+	Uptime      time.Duration
+	Connections int
+	Users       int // Distinct accounts logged in
+	Commands    int64
+	Rate        float64 // Commands per second over the last minute
+	SoloGames   int64
+	TableHands  int // Hands bet or in play at shared tables
+	Wagered     int64
+	Paid        int64
+	DBSize      int64 // Bytes, including the write-ahead log; -1 if unknown
+}
+
+func (s *Server) statsSnapshot() statsSnapshot {
+	snap := statsSnapshot{
+		Uptime:      time.Since(s.stats.started),
+		Connections: len(s.hub.clients()),
+		Users:       len(s.hub.userIDs()),
+		Commands:    s.stats.commands.Load(),
+		Rate:        s.stats.commandRate(),
+		SoloGames:   s.stats.soloGames.Load(),
+		Wagered:     s.stats.wagered.Load(),
+		Paid:        s.stats.paid.Load(),
+		DBSize:      -1,
+	}
+
+	s.lobby.mu.Lock()
+	for _, st := range s.lobby.tables {
+		st.mu.Lock()
+		for _, seat := range st.table.Seats {
+			if seat != nil && seat.Game != nil && seat.Game.Phase != game.PhaseGameOver {
+				snap.TableHands++
+			}
+		}
+		st.mu.Unlock()
+	}
+	s.lobby.mu.Unlock()
+
+	if info, err := os.Stat(s.config.DBPath); err == nil {
+		snap.DBSize = info.Size()
+		if wal, err := os.Stat(s.config.DBPath + "-wal"); err == nil {
+			snap.DBSize += wal.Size()
+		}
+	}
+	return snap
+}
+
+// Formats the snapshot for the console and ADMIN STATS
+func (s *Server) statsReport() string {
+	snap := s.statsSnapshot()
+
+	report := "Server statistics:"
+	report += fmt.Sprintf("\n  Address: %s", s.config.Addr)
+	report += fmt.Sprintf("\n  Uptime: %s", snap.Uptime.Truncate(time.Second))
+	report += fmt.Sprintf("\n  Connections: %d (%d users logged in)", snap.Connections, snap.Users)
+	report += fmt.Sprintf("\n  Commands: %d (%.2f/s over the last minute)", snap.Commands, snap.Rate)
+	report += fmt.Sprintf("\n  Games in progress: %d solo, %d at tables", snap.SoloGames, snap.TableHands)
+	report += fmt.Sprintf("\n  Since start: $%.2f wagered, $%.2f paid", float64(snap.Wagered)/100, float64(snap.Paid)/100)
+	if snap.DBSize >= 0 {
+		report += fmt.Sprintf("\n  Database: %s (%.1f MB)", s.config.DBPath, float64(snap.DBSize)/(1<<20))
+	}
+	return report
+}
+
+// Serves the statistics in the Prometheus text format on METRICS_ADDR
+func (s *Server) serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		snap := s.statsSnapshot()
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		metric := func(name, kind, help string, value any) {
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, kind, name, value)
+		}
+		metric("casino_uptime_seconds", "gauge", "Seconds since the server started.", int64(snap.Uptime.Seconds()))
+		metric("casino_connections", "gauge", "Open client connections.", snap.Connections)
+		metric("casino_users_logged_in", "gauge", "Distinct accounts logged in.", snap.Users)
+		metric("casino_commands_total", "counter", "Commands handled since start.", snap.Commands)
+		metric("casino_commands_per_second", "gauge", "Commands per second over the last minute.", snap.Rate)
+		metric("casino_solo_games_in_progress", "gauge", "Solo hands dealt and not yet settled.", snap.SoloGames)
+		metric("casino_table_hands_in_progress", "gauge", "Hands bet or in play at shared tables.", snap.TableHands)
+		metric("casino_wagered_dollars_total", "counter", "Dollars bet on hands settled since start.", float64(snap.Wagered)/100)
+		metric("casino_paid_dollars_total", "counter", "Dollars paid out on hands settled since start.", float64(snap.Paid)/100)
+		if names, rtp := s.rtp.stats(); len(names) > 0 {
+			series := func(name, help string, value func(game.RTPStats) any) {
+				fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+				for _, g := range names {
+					fmt.Fprintf(w, "%s{game=%q} %v\n", name, g, value(rtp[g]))
+				}
+			}
+			series("casino_rtp_rounds", "Rounds settled since start.", func(st game.RTPStats) any { return st.Rounds })
+			series("casino_rtp_observed", "Share of wagers paid back since start.", func(st game.RTPStats) any { return st.Observed })
+			series("casino_rtp_expected", "Share of wagers expected back under basic strategy.", func(st game.RTPStats) any { return st.Expected })
+			series("casino_rtp_deviation_sigma", "Standard errors the observed RTP lies from the expected one.", func(st game.RTPStats) any { return st.Sigma })
+		}
+		if snap.DBSize >= 0 {
+			metric("casino_database_bytes", "gauge", "Size of the database and its write-ahead log.", snap.DBSize)
+		}
+	})
+
+	s.log.server.Info("Serving metrics", "addr", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		s.log.server.Error("Metrics endpoint stopped", "addr", addr, "err", err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/alessandrosisniegas/casino/core/game"
+)
+
+// Returns the current table's rules with the player's VIP perks applied
+func (s *Server) tableRules(client *ClientState) game.Rules {
+	return s.rulesFor(client, client.table)
+}
+
+func (s *Server) rulesFor(client *ClientState, table game.Table) game.Rules {
+	rules := table.Rules
+	if client.user == nil {
+		return rules
+	}
+	if progress, err := s.authService.GetProgress(client.user.ID); err == nil {
+		rules.MaxBet = progress.Tier.MaxBet(rules.MaxBet)
+	}
+	return rules
+}
+
+func (s *Server) handleTables(client *ClientState, _ []string) {
+	response := "OK Tables:"
+	var tables []map[string]any
+	for _, t := range game.Tables {
+		marker := " "
+		if t.ID == client.table.ID {
+			marker = "*"
+		}
+		rules := s.rulesFor(client, t)
+		response += fmt.Sprintf("\n %s %-6s %-14s $%.2f - $%.2f", marker, t.ID, t.Name,
+			float64(rules.MinBet)/100, float64(rules.MaxBet)/100)
+		if rules.MaxBet != t.Rules.MaxBet {
+			response += " (VIP)"
+		}
+		tables = append(tables, map[string]any{
+			"id":      t.ID,
+			"name":    t.Name,
+			"min_bet": rules.MinBet,
+			"max_bet": rules.MaxBet,
+			"vip":     rules.MaxBet != t.Rules.MaxBet,
+			"current": t.ID == client.table.ID,
+		})
+	}
+	response += "\nUse SIT <table> to change tables."
+	listing, shared := s.lobbyListing(client)
+	response += listing
+	s.writeData(client, response, map[string]any{"tables": tables, "shared": shared})
+}
+
+func (s *Server) handleSit(client *ClientState, args []string) {
+	if len(args) != 1 {
+		s.writeError(client, "USAGE", "Usage: SIT <table> (see TABLES)")
+		return
+	}
+
+	if client.game != nil && client.game.Phase != game.PhaseGameOver {
+		s.writeResponse(client, "ERROR Finish your current hand before changing tables")
+		return
+	}
+
+	table, ok := game.FindTable(args[0])
+	if !ok {
+		s.writeResponse(client, "ERROR Unknown table. Use TABLES to see the list")
+		return
+	}
+
+	client.table = table
+	rules := s.tableRules(client)
+	s.writeResponse(client, fmt.Sprintf("OK Seated at %s (bets $%.2f - $%.2f)", table.Name,
+		float64(rules.MinBet)/100, float64(rules.MaxBet)/100))
+}
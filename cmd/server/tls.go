@@ -0,0 +1,50 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+)
+
+// Listens on the configured address, over TLS when a certificate is set.
+// Serving plain TCP beyond this machine is allowed but warned about, since
+// passwords then cross the network in the clear.
+func listen(cfg Config, log *slog.Logger) (net.Listener, error) {
+	tlsConfig, err := loadTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ln, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		host, _, _ := net.SplitHostPort(cfg.Addr)
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			log.Warn("Serving plain TCP beyond localhost; set TLS_CERT and TLS_KEY to encrypt logins", "addr", cfg.Addr)
+		}
+		return ln, nil
+	}
+	return tls.NewListener(ln, tlsConfig), nil
+}
+
+// The TLS settings for TLS_CERT and TLS_KEY, or nil when they aren't set
+func loadTLSConfig(cfg Config) (*tls.Config, error) {
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		return nil, errors.New("TLS_CERT and TLS_KEY must be set together")
+	}
+	if cfg.TLSCert == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
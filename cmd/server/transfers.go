@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// How long a TRANSFER waits for CONFIRM before it has to be requested again
+const transferConfirmWindow = 60 * time.Second
+
+type pendingTransfer struct {
+	to        string
+	amount    int64
+	expiresAt time.Time
+}
+
+func (s *Server) handleTransfer(client *ClientState, args []string) {
+	if client.user == nil {
+		s.writeError(client, "NOT_LOGGED_IN", "Please login first")
+		return
+	}
+
+	// Moving money to another account needs a password login, like key management
+	if client.apiKeyID != "" {
+		s.writeResponse(client, "ERROR Transfers can only be made after LOGIN")
+		return
+	}
+
+	switch {
+	case len(args) == 1 && strings.EqualFold(args[0], "CONFIRM"):
+		s.confirmTransfer(client)
+
+	case len(args) == 1 && strings.EqualFold(args[0], "CANCEL"):
+		if client.pendingTransfer == nil {
+			s.writeResponse(client, "ERROR No pending transfer")
+			return
+		}
+		client.pendingTransfer = nil
+		s.writeResponse(client, "OK Transfer cancelled")
+
+	case len(args) == 2:
+		amount, err := parseDollars(args[1])
+		if err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return
+		}
+
+		to, err := s.authService.CheckTransfer(client.user.ID, args[0], amount)
+		if err != nil {
+			s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+			return
+		}
+
+		client.pendingTransfer = &pendingTransfer{
+			to:        to.Username,
+			amount:    amount,
+			expiresAt: time.Now().Add(transferConfirmWindow),
+		}
+		s.writeResponse(client, fmt.Sprintf("OK Send $%.2f to %s? Type TRANSFER CONFIRM within %d seconds or TRANSFER CANCEL",
+			float64(amount)/100, to.Username, int(transferConfirmWindow.Seconds())))
+
+	default:
+		s.writeError(client, "USAGE", "Usage: TRANSFER <user> <amount> | TRANSFER CONFIRM | TRANSFER CANCEL")
+	}
+}
+
+func (s *Server) confirmTransfer(client *ClientState) {
+	pending := client.pendingTransfer
+	client.pendingTransfer = nil
+
+	if pending == nil || time.Now().After(pending.expiresAt) {
+		s.writeResponse(client, "ERROR No pending transfer, use TRANSFER <user> <amount> first")
+		return
+	}
+
+	balance, to, err := s.authService.Transfer(client.user.ID, pending.to, pending.amount)
+	if err != nil {
+		s.writeResponse(client, fmt.Sprintf("ERROR %s", err.Error()))
+		return
+	}
+	client.user.Balance = balance
+
+	for _, c := range s.hub.clientsForUser(to.ID, nil) {
+		s.pushEvent(c, "TRANSFER", fmt.Sprintf("%s sent you $%.2f", client.user.Username, float64(pending.amount)/100))
+	}
+
+	s.writeResponse(client, fmt.Sprintf("OK Sent $%.2f to %s. New balance: $%.2f",
+		float64(pending.amount)/100, to.Username, float64(balance)/100))
+}
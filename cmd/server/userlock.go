@@ -0,0 +1,47 @@
+package main
+
+import "sync"
+
+// Per-user locks serializing bets and settlements, so two connections of one
+// account can't both bet the same balance or both get under a bet limit. A
+// lock is held for the checks and database work of one bet or settlement,
+// never while waiting on the player. Table code takes it under the table's
+// own lock, never the other way round. The lock isn't reentrant: code holding
+// it must not call anything that locks the same user again, or it deadlocks.
+type userLocks struct {
+	mu    sync.Mutex
+	locks map[int]*userLock
+}
+
+type userLock struct {
+	mu   sync.Mutex
+	refs int // Goroutines holding or waiting for mu
+}
+
+func newUserLocks() *userLocks {
+	return &userLocks{locks: make(map[int]*userLock)}
+}
+
+// Blocks until the user's lock is free and returns the function that releases it
+func (l *userLocks) lock(userID int) func() {
+	l.mu.Lock()
+	ul := l.locks[userID]
+	if ul == nil {
+		ul = &userLock{}
+		l.locks[userID] = ul
+	}
+	ul.refs++
+	l.mu.Unlock()
+
+	ul.mu.Lock()
+	return func() {
+		ul.mu.Unlock()
+
+		l.mu.Lock()
+		ul.refs--
+		if ul.refs == 0 {
+			delete(l.locks, userID)
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/security"
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func TestUserLocks(t *testing.T) {
+	l := newUserLocks()
+
+	// One holder at a time per user
+	var inside, most atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := l.lock(1)
+			n := inside.Add(1)
+			for {
+				m := most.Load()
+				if n <= m || most.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			inside.Add(-1)
+			unlock()
+		}()
+	}
+	wg.Wait()
+	if most.Load() != 1 {
+		t.Errorf("lock() let %d goroutines in at once, want 1", most.Load())
+	}
+
+	// Other users aren't held up
+	unlock := l.lock(1)
+	done := make(chan struct{})
+	go func() {
+		l.lock(2)()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("lock() of another user waited on a held lock")
+	}
+	unlock()
+
+	if len(l.locks) != 0 {
+		t.Errorf("lock() left %d locks behind once released", len(l.locks))
+	}
+}
+
+// Sets the user's balance to exactly cents
+func setTestBalance(t *testing.T, s *Server, userID int, cents int64) {
+	t.Helper()
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if delta := cents - user.Balance; delta != 0 {
+		if _, err := s.db.AdjustBalance(userID, delta, vault.TxAdjustment); err != nil {
+			t.Fatalf("AdjustBalance() error = %v", err)
+		}
+	}
+}
+
+// Runs the commands at once, one per session, and returns their replies
+func runConcurrently(s *Server, clients []*ClientState, lines []string) []string {
+	replies := make([]string, len(lines))
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range lines {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			replies[i] = runCommand(s, clients[i], lines[i])
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return replies
+}
+
+// The wager limit is checked against the ledger before the stake is held, so
+// without the lock both rounds could pass the check
+func TestConcurrentBetsRespectWagerLimit(t *testing.T) {
+	s := newTestServer(t)
+
+	var staked int64
+	for round := 0; round < 20; round++ {
+		// Fresh sessions, as each keeps its round in play
+		a := loginTestClient(t, s, "twobets")
+		b := loginTestClient(t, s, "twobets")
+
+		// Room for one more $100 bet but not two
+		if err := s.authService.SetLimit(a.user.ID, security.LimitDaily, security.LimitWager, staked+15000, true); err != nil {
+			t.Fatalf("SetLimit() error = %v", err)
+		}
+
+		replies := runConcurrently(s, []*ClientState{a, b}, []string{"PLAY hilo 100", "PLAY hilo 100"})
+		started := 0
+		for _, reply := range replies {
+			if strings.Contains(reply, "OK Hi-Lo for $100.00") {
+				started++
+			}
+		}
+		if started != 1 {
+			t.Fatalf("round %d: two $100 bets with $150 left under the wager limit started %d rounds: %q", round, started, replies)
+		}
+		staked += 10000
+	}
+}
+
+// A Hi-Lo round never settles on its first card, so exactly one of the bet
+// and the withdrawal can take the balance
+func TestBetRacingWithdraw(t *testing.T) {
+	s := newTestServer(t)
+	player := loginTestClient(t, s, "betwithdraw")
+	cashier := loginTestClient(t, s, "betwithdraw")
+
+	for round := 0; round < 20; round++ {
+		setTestBalance(t, s, player.user.ID, 10000)
+
+		replies := runConcurrently(s, []*ClientState{player, cashier}, []string{"PLAY hilo 100", "WITHDRAW 100"})
+		bet, withdrew := strings.Contains(replies[0], "OK Hi-Lo for $100.00"), strings.Contains(replies[1], "OK Withdrew")
+		if bet == withdrew {
+			t.Fatalf("round %d: a $100 bet and a $100 withdrawal of a $100 balance both went %v: %q", round, bet, replies)
+		}
+
+		user, err := s.db.GetUserByID(player.user.ID)
+		if err != nil {
+			t.Fatalf("GetUserByID() error = %v", err)
+		}
+		if user.Balance != 0 {
+			t.Fatalf("round %d: balance = %d, want 0 with the bet or withdrawal taken", round, user.Balance)
+		}
+		if bet {
+			if reply := runCommand(s, player, "PLAY hilo CASHOUT"); !strings.Contains(reply, "Cashed out") {
+				t.Fatalf("round %d: CASHOUT = %q", round, reply)
+			}
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Alerts webhooks can be limited to with WEBHOOK_EVENTS
+const (
+	alertJackpot        = "jackpot"
+	alertBalance        = "balance"
+	alertFailedLogins   = "failed_logins"
+	alertReconciliation = "reconciliation"
+	alertRTP            = "rtp"
+)
+
+var alertEvents = []string{alertJackpot, alertBalance, alertFailedLogins, alertReconciliation, alertRTP}
+
+// Failed logins are counted over this window for ALERT_FAILED_LOGINS
+const loginFailureWindow = 10 * time.Minute
+
+// Alerts waiting to be posted; more are dropped rather than slow down play
+const webhookQueue = 100
+
+// webhookAlert is the JSON body posted for an alert. Slack reads text and
+// Discord content, so either can take it as is.
+type webhookAlert struct {
+	Event   string         `json:"event"`
+	Text    string         `json:"text"`
+	Content string         `json:"content"`
+	Time    string         `json:"time"`
+	Fields  map[string]any `json:"fields,omitempty"`
+}
+
+// webhooks posts alerts to the WEBHOOK_URLS in the background
+type webhooks struct {
+	urls   []string
+	events map[string]bool // nil for every event
+	client *http.Client
+	queue  chan webhookAlert
+	log    *slog.Logger
+}
+
+// Returns the webhooks set by WEBHOOK_URLS and WEBHOOK_EVENTS, or nil when no
+// URL is set
+func newWebhooks(urls, events string, log *slog.Logger) (*webhooks, error) {
+	w := &webhooks{
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan webhookAlert, webhookQueue),
+		log:    log,
+	}
+	for _, u := range strings.Split(urls, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+				return nil, fmt.Errorf("webhook %q must be an http or https URL", u)
+			}
+			w.urls = append(w.urls, u)
+		}
+	}
+	if len(w.urls) == 0 {
+		return nil, nil
+	}
+
+	for _, e := range strings.Split(events, ",") {
+		if e = strings.ToLower(strings.TrimSpace(e)); e == "" {
+			continue
+		}
+		known := false
+		for _, a := range alertEvents {
+			known = known || a == e
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown webhook event %q: use %s", e, strings.Join(alertEvents, ", "))
+		}
+		if w.events == nil {
+			w.events = make(map[string]bool)
+		}
+		w.events[e] = true
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// Posts queued alerts to every URL, one at a time
+func (w *webhooks) run() {
+	for alert := range w.queue {
+		body, err := json.Marshal(alert)
+		if err != nil {
+			w.log.Error("Failed to encode webhook", "event", alert.Event, "err", err)
+			continue
+		}
+		for _, u := range w.urls {
+			resp, err := w.client.Post(u, "application/json", bytes.NewReader(body))
+			if err != nil {
+				w.log.Warn("Webhook failed", "url", u, "event", alert.Event, "err", err)
+				continue
+			}
+			resp.Body.Close()
+			if resp.StatusCode/100 != 2 {
+				w.log.Warn("Webhook refused", "url", u, "event", alert.Event, "status", resp.Status)
+			}
+		}
+	}
+}
+
+// Logs an alert and hands it to the webhooks that want it, without waiting
+// for them
+func (s *Server) alert(event, text string, fields map[string]any) {
+	s.log.server.Warn("Alert", "event", event, "text", text)
+
+	w := s.webhooks
+	if w == nil || (w.events != nil && !w.events[event]) {
+		return
+	}
+	alert := webhookAlert{Event: event, Text: text, Content: text, Time: time.Now().UTC().Format(time.RFC3339), Fields: fields}
+	select {
+	case w.queue <- alert:
+	default:
+		w.log.Warn("Webhook queue full, dropping alert", "event", event)
+	}
+}
+
+// Alerts when a credit lifts a balance to ALERT_BALANCE or beyond
+func (s *Server) checkBalanceAlert(username string, before, after int64) {
+	threshold := int64(s.config.AlertBalance) * 100
+	if threshold <= 0 || before >= threshold || after < threshold {
+		return
+	}
+	s.alert(alertBalance, fmt.Sprintf("%s's balance reached $%.2f", username, float64(after)/100),
+		map[string]any{"user": username, "balance": float64(after) / 100})
+}
+
+// Counts failed logins by IP and by username over loginFailureWindow
+type loginFailures struct {
+	mu     sync.Mutex
+	failed map[string][]time.Time
+}
+
+func newLoginFailures() *loginFailures {
+	return &loginFailures{failed: make(map[string][]time.Time)}
+}
+
+// Records a failure for key and returns how many there have been in the window
+func (f *loginFailures) add(key string, now time.Time) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Guessers can make up any number of usernames, so forget stale keys
+	if len(f.failed) > 1000 {
+		for k, times := range f.failed {
+			if now.Sub(times[len(times)-1]) >= loginFailureWindow {
+				delete(f.failed, k)
+			}
+		}
+	}
+
+	recent := f.failed[key][:0]
+	for _, t := range f.failed[key] {
+		if now.Sub(t) < loginFailureWindow {
+			recent = append(recent, t)
+		}
+	}
+	f.failed[key] = append(recent, now)
+	return len(f.failed[key])
+}
+
+// Alerts once ALERT_FAILED_LOGINS logins have failed from an address or for
+// a username within the window
+func (s *Server) recordLoginFailure(username, ip string) {
+	limit := s.config.AlertFailedLogins
+	if limit <= 0 {
+		return
+	}
+
+	now := time.Now()
+	if s.loginFailures.add("ip "+ip, now) == limit {
+		s.alert(alertFailedLogins, fmt.Sprintf("%d failed logins from %s in %s", limit, ip, loginFailureWindow),
+			map[string]any{"ip": ip, "failures": limit})
+	}
+	if s.loginFailures.add("user "+strings.ToLower(username), now) == limit {
+		s.alert(alertFailedLogins, fmt.Sprintf("%d failed logins for %s in %s", limit, username, loginFailureWindow),
+			map[string]any{"user": username, "failures": limit})
+	}
+}
+
+// Alerts on accounts whose balance no longer matches the ledger, once each
+// until the balance changes
+func (s *Server) reconcileBalances() {
+	mismatches, err := s.authService.ReconcileBalances()
+	if err != nil {
+		s.log.vault.Error("Failed to reconcile balances", "err", err)
+		return
+	}
+
+	seen := make(map[int]int64)
+	for _, m := range mismatches {
+		seen[m.UserID] = m.Balance
+		if reported, ok := s.mismatches[m.UserID]; ok && reported == m.Balance {
+			continue
+		}
+		s.alert(alertReconciliation, fmt.Sprintf("%s's balance is $%.2f but the ledger says $%.2f",
+			m.Username, float64(m.Balance)/100, float64(m.Ledger)/100),
+			map[string]any{"user": m.Username, "balance": float64(m.Balance) / 100, "ledger": float64(m.Ledger) / 100})
+	}
+	s.mismatches = seen
+}
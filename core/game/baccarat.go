@@ -0,0 +1,264 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BaccaratBet is the side of a punto banco coup a player backs
+type BaccaratBet string
+
+const (
+	BaccaratPlayer BaccaratBet = "PLAYER"
+	BaccaratBanker BaccaratBet = "BANKER"
+	BaccaratTie    BaccaratBet = "TIE"
+)
+
+// House rules for baccarat: an 8-deck shoe, 5% commission on winning banker
+// bets and 8:1 on a tie
+const (
+	BaccaratDecks      = 8
+	BaccaratCommission = 5 // Percent of a banker win kept by the house
+	BaccaratTiePays    = 8
+)
+
+// BaccaratRound is one coup: both hands as dealt and which side won
+type BaccaratRound struct {
+	Player *Hand
+	Banker *Hand
+	Winner BaccaratBet
+}
+
+// Looks up a baccarat bet by name, ignoring case
+func ParseBaccaratBet(name string) (BaccaratBet, bool) {
+	for _, b := range []BaccaratBet{BaccaratPlayer, BaccaratBanker, BaccaratTie} {
+		if strings.EqualFold(string(b), name) {
+			return b, true
+		}
+	}
+	return "", false
+}
+
+// A baccarat hand's points: aces count one, tens and faces nothing, and only
+// the last digit of the total counts
+func BaccaratValue(h *Hand) int {
+	total := 0
+	for _, c := range h.Cards {
+		if c.Value < 10 {
+			total += c.Value
+		} else if c.Rank == "A" {
+			total++
+		}
+	}
+	return total % 10
+}
+
+// Deals a coup from the deck following the punto banco tableau: a natural 8
+// or 9 stands both hands, the player draws on 0-5, and the banker draws
+// according to their total and the player's third card
+func PlayBaccarat(deck *Deck) (*BaccaratRound, error) {
+	r := &BaccaratRound{Player: NewHand(), Banker: NewHand()}
+	for i := 0; i < 2; i++ {
+		for _, h := range []*Hand{r.Player, r.Banker} {
+			card, err := deck.Draw()
+			if err != nil {
+				return nil, fmt.Errorf("failed to deal: %w", err)
+			}
+			h.AddCard(card)
+		}
+	}
+
+	player, banker := BaccaratValue(r.Player), BaccaratValue(r.Banker)
+	if player < 8 && banker < 8 {
+		third := -1 // The player's third card's points, -1 if they stood
+		if player <= 5 {
+			card, err := deck.Draw()
+			if err != nil {
+				return nil, fmt.Errorf("failed to deal: %w", err)
+			}
+			r.Player.AddCard(card)
+			third = BaccaratValue(&Hand{Cards: []Card{card}})
+		}
+
+		if bankerDraws(banker, third) {
+			card, err := deck.Draw()
+			if err != nil {
+				return nil, fmt.Errorf("failed to deal: %w", err)
+			}
+			r.Banker.AddCard(card)
+		}
+	}
+
+	player, banker = BaccaratValue(r.Player), BaccaratValue(r.Banker)
+	switch {
+	case player > banker:
+		r.Winner = BaccaratPlayer
+	case banker > player:
+		r.Winner = BaccaratBanker
+	default:
+		r.Winner = BaccaratTie
+	}
+	return r, nil
+}
+
+// Whether the banker takes a third card on total, given the player's third
+// card (-1 when the player stood)
+func bankerDraws(total, third int) bool {
+	switch {
+	case third < 0:
+		return total <= 5
+	case total <= 2:
+		return true
+	case total == 3:
+		return third != 8
+	case total == 4:
+		return third >= 2 && third <= 7
+	case total == 5:
+		return third >= 4 && third <= 7
+	case total == 6:
+		return third == 6 || third == 7
+	default:
+		return false
+	}
+}
+
+// What a bet of amount on the given side pays back, stake included. Player
+// and banker bets push on a tie.
+func (r *BaccaratRound) Payout(bet BaccaratBet, amount int64) int64 {
+	switch {
+	case bet == r.Winner && bet == BaccaratTie:
+		return amount + amount*BaccaratTiePays
+	case bet == r.Winner && bet == BaccaratBanker:
+		return amount + amount*(100-BaccaratCommission)/100
+	case bet == r.Winner:
+		return amount * 2
+	case r.Winner == BaccaratTie:
+		return amount
+	default:
+		return 0
+	}
+}
+
+// Long-run return of each bet with an 8-deck shoe
+func BaccaratRTP(bet BaccaratBet) float64 {
+	switch bet {
+	case BaccaratBanker:
+		return 0.98942
+	case BaccaratPlayer:
+		return 0.98765
+	default:
+		return 0.85640
+	}
+}
+
+func (r *BaccaratRound) String() string {
+	result := "Tie"
+	if r.Winner != BaccaratTie {
+		result = strings.ToUpper(string(r.Winner[:1])) + strings.ToLower(string(r.Winner[1:])) + " wins"
+	}
+	return fmt.Sprintf("Player Hand: %s (Value: %d)\nBanker Hand: %s (Value: %d)\nResult: %s",
+		r.Player.String(), BaccaratValue(r.Player), r.Banker.String(), BaccaratValue(r.Banker), result)
+}
+
+// BaccaratGame plays a coup as an Engine: the round starts with the stake and
+// the side backed deals it
+type BaccaratGame struct {
+	Amount    int64 // in cents
+	Bet       BaccaratBet
+	Round     *BaccaratRound // nil until dealt
+	Abandoned bool           // Walked away from before the deal
+}
+
+func init() {
+	RegisterEngine(EngineInfo{
+		Name:  "baccarat",
+		Title: "Baccarat",
+		About: "Punto banco from an 8-deck shoe: back the player, the banker or a tie",
+		New:   func() Engine { return &BaccaratGame{} },
+	})
+}
+
+func (b *BaccaratGame) StartRound(bet int64) error {
+	*b = BaccaratGame{Amount: bet}
+	return nil
+}
+
+// The sides that can be backed, until the coup is dealt
+func (b *BaccaratGame) ValidActions() []string {
+	if b.Round != nil || b.Abandoned {
+		return nil
+	}
+	return []string{string(BaccaratPlayer), string(BaccaratBanker), string(BaccaratTie)}
+}
+
+// Backs a side and deals the coup from a fresh shoe
+func (b *BaccaratGame) Action(action string, _ []string) error {
+	action, err := matchAction(b, action)
+	if err != nil {
+		return err
+	}
+	round, err := PlayBaccarat(NewShoe(BaccaratDecks, 0))
+	if err != nil {
+		return err
+	}
+	b.Bet, b.Round = BaccaratBet(action), round
+	return nil
+}
+
+func (b *BaccaratGame) ActionStake(action string, _ []string) (int64, error) {
+	_, err := matchAction(b, action)
+	return 0, err
+}
+
+func (b *BaccaratGame) Hands() string {
+	if b.Round == nil {
+		return ""
+	}
+	return fmt.Sprintf("Player %s (%d), banker %s (%d)",
+		b.Round.Player, BaccaratValue(b.Round.Player), b.Round.Banker, BaccaratValue(b.Round.Banker))
+}
+
+func (b *BaccaratGame) Wagered() int64 {
+	return b.Amount
+}
+
+// What the coup pays back; the stake when it was never dealt
+func (b *BaccaratGame) Payout() int64 {
+	if b.Round == nil {
+		return b.Amount
+	}
+	return b.Round.Payout(b.Bet, b.Amount)
+}
+
+// The side backed and, once dealt, both hands with their points
+func (b *BaccaratGame) Data() map[string]any {
+	data := map[string]any{"stake": b.Amount, "bet": string(b.Bet)}
+	if b.Round != nil {
+		data["player"] = map[string]any{"cards": CardsData(b.Round.Player.Cards), "value": BaccaratValue(b.Round.Player)}
+		data["banker"] = map[string]any{"cards": CardsData(b.Round.Banker.Cards), "value": BaccaratValue(b.Round.Banker)}
+		data["winner"] = string(b.Round.Winner)
+	}
+	return data
+}
+
+// A coup walked away from before the deal is called off
+func (b *BaccaratGame) Abandon() {
+	if b.Round == nil {
+		b.Abandoned = true
+	}
+}
+
+func (b *BaccaratGame) ExpectedRTP() float64 {
+	if b.Round == nil {
+		return 1
+	}
+	return BaccaratRTP(b.Bet)
+}
+
+func (b *BaccaratGame) State() string {
+	if b.Round == nil {
+		return fmt.Sprintf("Stake: $%.2f\nBack PLAYER, BANKER or TIE to deal", float64(b.Amount)/100)
+	}
+	return fmt.Sprintf("Baccarat: $%.2f on %s\n%s\nPayout: $%.2f",
+		float64(b.Amount)/100, b.Bet, b.Round, float64(b.Payout())/100)
+}
@@ -0,0 +1,93 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBaccaratValue(t *testing.T) {
+	h := &Hand{Cards: []Card{card("A", "♠"), card("K", "♥"), card("8", "♦")}}
+	if got := BaccaratValue(h); got != 9 {
+		t.Errorf("BaccaratValue(A K 8) = %d, want 9", got)
+	}
+	h = &Hand{Cards: []Card{card("7", "♠"), card("6", "♥")}}
+	if got := BaccaratValue(h); got != 3 {
+		t.Errorf("BaccaratValue(7 6) = %d, want 3", got)
+	}
+}
+
+func TestPlayBaccarat(t *testing.T) {
+	tests := []struct {
+		name           string
+		cards          []Card // Dealt player, banker, player, banker, then draws
+		winner         BaccaratBet
+		player, banker int // Cards in each hand
+	}{
+		{"player natural stands both", []Card{card("9", "♠"), card("3", "♥"), card("K", "♥"), card("4", "♣"), card("5", "♦")}, BaccaratPlayer, 2, 2},
+		{"banker 3 stands on a third 8", []Card{card("2", "♠"), card("K", "♥"), card("3", "♥"), card("3", "♣"), card("8", "♦"), card("5", "♦")}, BaccaratTie, 3, 2},
+		{"banker draws when the player stands", []Card{card("4", "♠"), card("2", "♥"), card("3", "♥"), card("2", "♣"), card("5", "♦")}, BaccaratBanker, 2, 3},
+	}
+	for _, tt := range tests {
+		r, err := PlayBaccarat(&Deck{Cards: tt.cards})
+		if err != nil {
+			t.Fatalf("%s: PlayBaccarat() error = %v", tt.name, err)
+		}
+		if r.Winner != tt.winner || len(r.Player.Cards) != tt.player || len(r.Banker.Cards) != tt.banker {
+			t.Errorf("%s: winner %s with %d and %d cards, want %s with %d and %d", tt.name,
+				r.Winner, len(r.Player.Cards), len(r.Banker.Cards), tt.winner, tt.player, tt.banker)
+		}
+	}
+
+	if _, err := PlayBaccarat(&Deck{Cards: []Card{card("2", "♠")}}); err == nil {
+		t.Error("PlayBaccarat() should fail on an empty deck")
+	}
+}
+
+func TestBankerDraws(t *testing.T) {
+	tests := []struct {
+		total, third int
+		want         bool
+	}{
+		{5, -1, true}, {6, -1, false},
+		{2, 9, true}, {3, 8, false}, {3, 9, true},
+		{4, 1, false}, {4, 7, true}, {5, 3, false}, {5, 4, true},
+		{6, 5, false}, {6, 6, true}, {7, 6, false},
+	}
+	for _, tt := range tests {
+		if got := bankerDraws(tt.total, tt.third); got != tt.want {
+			t.Errorf("bankerDraws(%d, %d) = %v, want %v", tt.total, tt.third, got, tt.want)
+		}
+	}
+}
+
+func TestBaccaratPayout(t *testing.T) {
+	banker := &BaccaratRound{Winner: BaccaratBanker}
+	tie := &BaccaratRound{Winner: BaccaratTie}
+
+	tests := []struct {
+		round  *BaccaratRound
+		bet    BaccaratBet
+		amount int64
+		want   int64
+	}{
+		{banker, BaccaratBanker, 1000, 1950}, // Less 5% commission
+		{banker, BaccaratPlayer, 1000, 0},
+		{banker, BaccaratTie, 1000, 0},
+		{tie, BaccaratTie, 1000, 9000},
+		{tie, BaccaratPlayer, 1000, 1000}, // Push
+		{&BaccaratRound{Winner: BaccaratPlayer}, BaccaratPlayer, 1000, 2000},
+	}
+	for _, tt := range tests {
+		if got := tt.round.Payout(tt.bet, tt.amount); got != tt.want {
+			t.Errorf("Payout(%s, %d) with %s winning = %d, want %d", tt.bet, tt.amount, tt.round.Winner, got, tt.want)
+		}
+	}
+
+	r, _ := PlayBaccarat(&Deck{Cards: []Card{card("4", "♠"), card("2", "♥"), card("3", "♥"), card("2", "♣"), card("5", "♦")}})
+	if s := r.String(); !strings.Contains(s, "Banker Hand: [2♥] [2♣] [5♦] (Value: 9)") || !strings.HasSuffix(s, "Result: Banker wins") {
+		t.Errorf("String() = %q", s)
+	}
+	if b, ok := ParseBaccaratBet("banker"); !ok || b != BaccaratBanker {
+		t.Errorf("ParseBaccaratBet(banker) = %q, %v", b, ok)
+	}
+}
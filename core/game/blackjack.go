@@ -2,9 +2,7 @@ package game
 
 import (
 	"fmt"
-	"math/rand"
 	"strings"
-	"time"
 )
 
 type Card struct {
@@ -14,7 +12,11 @@ type Card struct {
 }
 
 type Deck struct {
-	Cards []Card
+	Cards   []Card
+	CutCard int // Cards left when the cut card comes out; zero for a deck dealt without one
+
+	dealt    []Card // Cards drawn since the last Discard
+	discards []Card // Cards from earlier rounds, reshuffled if the shoe runs out
 }
 
 type Hand struct {
@@ -49,18 +51,29 @@ type Game struct {
 	Result      GameResult
 	IsDoubled   bool
 	PlayerStood bool
+	Rules       Rules
+	SideStakes  map[SideBet]int64 `json:",omitempty"` // in cents, settled on the opening cards
+
+	// Set for hands at a SharedTable, where the dealer plays once for every
+	// seat; standing leaves the hand in PhaseDealerTurn until then
+	sharedDealer bool
+}
+
+// Card values by rank, counting an ace as 11
+var rankValues = map[string]int{
+	"A": 11, "2": 2, "3": 3, "4": 4, "5": 5, "6": 6, "7": 7,
+	"8": 8, "9": 9, "10": 10, "J": 10, "Q": 10, "K": 10,
+}
+
+// NewCard returns the card of a rank and suit, with its value filled in
+func NewCard(rank, suit string) Card {
+	return Card{Suit: suit, Rank: rank, Value: rankValues[rank]}
 }
 
 func NewDeck() *Deck {
 	suits := []string{"♠", "♥", "♦", "♣"}
 	ranks := []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
 
-	// Map ranks to their values
-	rankValues := map[string]int{
-		"A": 11, "2": 2, "3": 3, "4": 4, "5": 5, "6": 6, "7": 7,
-		"8": 8, "9": 9, "10": 10, "J": 10, "Q": 10, "K": 10,
-	}
-
 	deck := &Deck{Cards: make([]Card, 0, 52)}
 
 	for _, suit := range suits {
@@ -76,19 +89,34 @@ func NewDeck() *Deck {
 	return deck
 }
 
+// Shuffles the deck with ShuffleRNG
 func (d *Deck) Shuffle() {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	r.Shuffle(len(d.Cards), func(i, j int) {
+	d.ShuffleWith(ShuffleRNG)
+}
+
+// Shuffles the deck with numbers drawn from rng (Fisher-Yates)
+func (d *Deck) ShuffleWith(rng RNG) {
+	for i := len(d.Cards) - 1; i > 0; i-- {
+		j := rng.Intn(i + 1)
 		d.Cards[i], d.Cards[j] = d.Cards[j], d.Cards[i]
-	})
+	}
 }
 
 func (d *Deck) Draw() (Card, error) {
 	if len(d.Cards) == 0 {
-		return Card{}, fmt.Errorf("deck is empty")
+		if len(d.discards) == 0 {
+			return Card{}, fmt.Errorf("deck is empty")
+		}
+		// Out of cards mid-round: the discards are shuffled back in, leaving
+		// out the cards on the table
+		d.Cards, d.discards = d.discards, nil
+		d.Shuffle()
 	}
 	card := d.Cards[0]
 	d.Cards = d.Cards[1:]
+	if d.CutCard > 0 {
+		d.dealt = append(d.dealt, card)
+	}
 	return card, nil
 }
 
@@ -122,6 +150,21 @@ func (h *Hand) Value() int {
 	return value
 }
 
+// Reports whether the hand counts an ace as 11
+func (h *Hand) IsSoft() bool {
+	hard := 0
+	hasAce := false
+	for _, card := range h.Cards {
+		if card.Rank == "A" {
+			hasAce = true
+			hard++
+		} else {
+			hard += card.Value
+		}
+	}
+	return hasAce && hard+10 <= 21
+}
+
 func (h *Hand) IsBusted() bool {
 	return h.Value() > 21
 }
@@ -151,6 +194,16 @@ func NewGame() *Game {
 	}
 }
 
+// Creates a game that enforces the given table rules
+func NewGameWithRules(rules Rules) *Game {
+	g := NewGame()
+	g.Rules = rules
+	if rules.Decks > 1 {
+		g.Deck = NewShoe(rules.Decks, rules.Penetration)
+	}
+	return g
+}
+
 func NewGameWithDeck(cards []Card) *Game {
 	// Copy cards to avoid modifying the original slice
 	deckCopy := make([]Card, len(cards))
@@ -172,8 +225,11 @@ func (g *Game) PlaceBetNoShuffle(amount int64) error {
 	if g.Phase != PhaseWaitingForBet {
 		return fmt.Errorf("cannot place bet in current phase")
 	}
-	if amount <= 0 {
-		return fmt.Errorf("bet must be positive")
+	if err := g.Rules.CheckBet(amount); err != nil {
+		return err
+	}
+	if err := g.checkSideBets(amount); err != nil {
+		return err
 	}
 
 	g.Bet = amount
@@ -203,22 +259,7 @@ func (g *Game) PlaceBetNoShuffle(amount int64) error {
 	}
 	g.DealerHand.AddCard(card4)
 
-	pBJ := g.PlayerHand.IsBlackjack()
-	dBJ := g.DealerHand.IsBlackjack()
-
-	switch {
-	case pBJ && dBJ:
-		g.Phase = PhaseGameOver
-		g.Result = ResultPush
-	case pBJ:
-		g.Phase = PhaseGameOver
-		g.Result = ResultPlayerBlackjack
-	case dBJ:
-		g.Phase = PhaseGameOver
-		g.Result = ResultDealerWin
-	default:
-		g.Phase = PhasePlayerTurn
-	}
+	g.checkNaturals()
 
 	return nil
 }
@@ -228,8 +269,11 @@ func (g *Game) PlaceBet(amount int64) error {
 	if g.Phase != PhaseWaitingForBet {
 		return fmt.Errorf("cannot place bet in current phase")
 	}
-	if amount <= 0 {
-		return fmt.Errorf("bet must be positive")
+	if err := g.Rules.CheckBet(amount); err != nil {
+		return err
+	}
+	if err := g.checkSideBets(amount); err != nil {
+		return err
 	}
 
 	g.Bet = amount
@@ -260,6 +304,13 @@ func (g *Game) PlaceBet(amount int64) error {
 	}
 	g.DealerHand.AddCard(card4)
 
+	g.checkNaturals()
+
+	return nil
+}
+
+// Ends the hand straight after the deal when either side has a blackjack
+func (g *Game) checkNaturals() {
 	pBJ := g.PlayerHand.IsBlackjack()
 	dBJ := g.DealerHand.IsBlackjack()
 
@@ -276,8 +327,6 @@ func (g *Game) PlaceBet(amount int64) error {
 	default:
 		g.Phase = PhasePlayerTurn
 	}
-
-	return nil
 }
 
 // Draws a card for the player
@@ -309,7 +358,9 @@ func (g *Game) Stand() error {
 
 	g.PlayerStood = true
 	g.Phase = PhaseDealerTurn
-	g.playDealerTurn()
+	if !g.sharedDealer {
+		g.playDealerTurn()
+	}
 
 	return nil
 }
@@ -338,7 +389,9 @@ func (g *Game) DoubleDown() error {
 		g.Result = ResultDealerWin
 	} else {
 		g.Phase = PhaseDealerTurn
-		g.playDealerTurn()
+		if !g.sharedDealer {
+			g.playDealerTurn()
+		}
 	}
 
 	return nil
@@ -349,6 +402,9 @@ func (g *Game) Surrender() error {
 	if g.Phase != PhasePlayerTurn {
 		return fmt.Errorf("cannot surrender in current phase")
 	}
+	if g.Rules.NoSurrender {
+		return fmt.Errorf("surrender is not allowed at this table")
+	}
 	if len(g.PlayerHand.Cards) != 2 {
 		return fmt.Errorf("can only surrender on initial hand")
 	}
@@ -361,22 +417,29 @@ func (g *Game) Surrender() error {
 
 // Plays the dealer's turn according to standard rules
 func (g *Game) playDealerTurn() {
-	// Dealer must hit on 16 or less, stand on 17 or more
-	for g.DealerHand.Value() < 17 {
-		card, err := g.Deck.Draw()
-		if err != nil {
-			// Deck exhausted - treat as a push to avoid corruption
-			g.Phase = PhaseGameOver
-			g.Result = ResultPush
-			return
-		}
-		g.DealerHand.AddCard(card)
+	if err := drawDealerHand(g.Deck, g.DealerHand, g.Rules.DealerHitsSoft17); err != nil {
+		// Deck exhausted - treat as a push to avoid corruption
+		g.Phase = PhaseGameOver
+		g.Result = ResultPush
+		return
 	}
 
 	g.Phase = PhaseGameOver
 	g.determineWinner()
 }
 
+// Dealer must hit on 16 or less, stand on 17 or more (hitting a soft 17 when hitSoft17 is set)
+func drawDealerHand(deck *Deck, dealer *Hand, hitSoft17 bool) error {
+	for dealer.Value() < 17 || (hitSoft17 && dealer.Value() == 17 && dealer.IsSoft()) {
+		card, err := deck.Draw()
+		if err != nil {
+			return err
+		}
+		dealer.AddCard(card)
+	}
+	return nil
+}
+
 func (g *Game) determineWinner() {
 	playerValue := g.PlayerHand.Value()
 	dealerValue := g.DealerHand.Value()
@@ -397,7 +460,8 @@ func (g *Game) determineWinner() {
 func (g *Game) CalculatePayout() int64 {
 	switch g.Result {
 	case ResultPlayerBlackjack:
-		return g.Bet + (g.Bet * 3 / 2) // Blackjack pays 3:2
+		pays := g.Rules.BlackjackPayout()
+		return g.Bet + (g.Bet * pays.Win / pays.Stake) // Blackjack pays 3:2 unless the table says otherwise
 	case ResultPlayerWin:
 		return g.Bet * 2 // Regular win pays 1:1
 	case ResultPush:
@@ -429,6 +493,7 @@ func (g *Game) GetGameState(hideDealer bool) string {
 	} else {
 		state += fmt.Sprintf("Dealer Hand: %s (Value: %d)\n", g.DealerHand.String(), g.DealerHand.Value())
 	}
+	state += g.sideBetState()
 
 	if g.Phase == PhaseGameOver {
 		state += fmt.Sprintf("\nResult: %s\n", g.getResultMessage())
@@ -438,6 +503,15 @@ func (g *Game) GetGameState(hideDealer bool) string {
 	return state
 }
 
+// The player's and dealer's cards in one line, for the history
+func (g *Game) Hands() string {
+	hands := fmt.Sprintf("Player %s (%d)", g.PlayerHand, g.PlayerHand.Value())
+	if len(g.DealerHand.Cards) > 0 {
+		hands += fmt.Sprintf(", dealer %s (%d)", g.DealerHand, g.DealerHand.Value())
+	}
+	return hands
+}
+
 func (g *Game) getResultMessage() string {
 	switch g.Result {
 	case ResultPlayerBlackjack:
@@ -468,7 +542,10 @@ func (g *Game) GetValidActions() []string {
 
 	// DOUBLEDOWN and SURRENDER are only valid on the first action (2 cards)
 	if len(g.PlayerHand.Cards) == 2 && !g.IsDoubled {
-		actions = append(actions, "DOUBLEDOWN", "SURRENDER")
+		actions = append(actions, "DOUBLEDOWN")
+		if !g.Rules.NoSurrender {
+			actions = append(actions, "SURRENDER")
+		}
 	}
 
 	return actions
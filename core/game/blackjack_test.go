@@ -721,6 +721,10 @@ func TestGetGameStateAtGameOver(t *testing.T) {
 	if strings.Contains(state, "[Hidden]") {
 		t.Error("game state at game over should not hide dealer cards")
 	}
+
+	if want := "Player [K♠] [9♠] (19), dealer [7♠] [K♥] (17)"; game.Hands() != want {
+		t.Errorf("Hands() = %q, want %q", game.Hands(), want)
+	}
 }
 
 // Edge case tests
@@ -1563,3 +1567,74 @@ func TestGetValidActionsNoSurrenderAfterHit(t *testing.T) {
 		}
 	}
 }
+
+func TestDealerHitsSoft17WhenRulesSaySo(t *testing.T) {
+	g := NewGameWithRules(Rules{DealerHitsSoft17: true})
+	g.Bet = 1000
+	g.Phase = PhaseDealerTurn
+
+	// Dealer has soft 17 (A + 6)
+	g.DealerHand.AddCard(Card{Rank: "A", Value: 11})
+	g.DealerHand.AddCard(Card{Rank: "6", Value: 6})
+
+	// Player has 18
+	g.PlayerHand.AddCard(Card{Rank: "K", Value: 10})
+	g.PlayerHand.AddCard(Card{Rank: "8", Value: 8})
+
+	// Hitting soft 17 draws the 5 for a hard 12, then the 8 for 20
+	g.Deck = &Deck{Cards: []Card{{Rank: "5", Value: 5}, {Rank: "8", Value: 8}}}
+	g.playDealerTurn()
+
+	if len(g.DealerHand.Cards) != 4 || g.DealerHand.Value() != 20 {
+		t.Fatalf("dealer should hit soft 17 to 20, got %s (%d)", g.DealerHand.String(), g.DealerHand.Value())
+	}
+	if g.Result != ResultDealerWin {
+		t.Errorf("Result = %v, want %v", g.Result, ResultDealerWin)
+	}
+}
+
+func TestHandIsSoft(t *testing.T) {
+	tests := []struct {
+		cards []Card
+		want  bool
+	}{
+		{[]Card{{Rank: "A", Value: 11}, {Rank: "6", Value: 6}}, true},
+		{[]Card{{Rank: "A", Value: 11}, {Rank: "6", Value: 6}, {Rank: "K", Value: 10}}, false},
+		{[]Card{{Rank: "A", Value: 11}, {Rank: "A", Value: 11}, {Rank: "5", Value: 5}}, true},
+		{[]Card{{Rank: "10", Value: 10}, {Rank: "7", Value: 7}}, false},
+	}
+
+	for _, tt := range tests {
+		h := &Hand{Cards: tt.cards}
+		if got := h.IsSoft(); got != tt.want {
+			t.Errorf("IsSoft(%s) = %v, want %v", h.String(), got, tt.want)
+		}
+	}
+}
+
+func TestBlackjackPaysTableRatio(t *testing.T) {
+	g := NewGameWithRules(Rules{BlackjackPays: Pays6to5})
+	g.Bet = 1000
+	g.Phase = PhaseGameOver
+	g.Result = ResultPlayerBlackjack
+
+	if payout := g.CalculatePayout(); payout != 2200 {
+		t.Errorf("CalculatePayout() at 6:5 = %d, want 2200 (1000 + 1200)", payout)
+	}
+}
+
+func TestSurrenderNotAllowed(t *testing.T) {
+	g := NewGameWithRules(Rules{NoSurrender: true})
+	g.Phase = PhasePlayerTurn
+	g.PlayerHand.AddCard(Card{Rank: "10", Value: 10})
+	g.PlayerHand.AddCard(Card{Rank: "6", Value: 6})
+
+	if err := g.Surrender(); err == nil {
+		t.Error("Surrender() should fail when the table doesn't allow it")
+	}
+	for _, action := range g.GetValidActions() {
+		if action == "SURRENDER" {
+			t.Error("GetValidActions() should not offer SURRENDER")
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Engine is a game played in rounds of commands against the house. A round
+// starts with a bet, takes the player's actions until none are left, then
+// pays out. Blackjack has its own richer API (Game and SharedTable).
+type Engine interface {
+	// Deals a round for a bet in cents
+	StartRound(bet int64) error
+	// Takes one of ValidActions with its arguments, ignoring case
+	Action(action string, args []string) error
+	// Describes the round for the player
+	State() string
+	// What the round pays back, stake included, once it's over
+	Payout() int64
+	// Actions the player can take; none once the round is over
+	ValidActions() []string
+
+	// Everything staked on the round so far
+	Wagered() int64
+	// Extra stake an action adds to the round, or why it can't be taken
+	ActionStake(action string, args []string) (int64, error)
+	// Ends a round its player walked away from in the way the game's rules
+	// say, so it can be paid out
+	Abandon()
+	// Expected return of the round as played
+	ExpectedRTP() float64
+	// The cards or numbers the round ended on, in one line for the history;
+	// empty when it ended before any were dealt
+	Hands() string
+	// Describes the round for MODE JSON, with cards and numbers as arrays
+	// and amounts in cents
+	Data() map[string]any
+}
+
+// EngineInfo describes a game offered through an Engine
+type EngineInfo struct {
+	Name  string // What PLAY takes, and how the game is named in history
+	Title string
+	About string // One line for GAMES
+	New   func() Engine
+}
+
+var engines []EngineInfo
+
+// Adds a game to those offered. Games register themselves from init.
+func RegisterEngine(info EngineInfo) {
+	if _, ok := LookupEngine(info.Name); ok {
+		panic(fmt.Sprintf("game %q registered twice", info.Name))
+	}
+	engines = append(engines, info)
+}
+
+// Games offered through engines, in the order they registered
+func Engines() []EngineInfo {
+	return append([]EngineInfo(nil), engines...)
+}
+
+// Looks up a game by name, ignoring case
+func LookupEngine(name string) (EngineInfo, bool) {
+	for _, info := range engines {
+		if strings.EqualFold(info.Name, name) {
+			return info, true
+		}
+	}
+	return EngineInfo{}, false
+}
+
+// A card as an engine's Data gives it
+type CardData struct {
+	Rank string `json:"rank"`
+	Suit string `json:"suit"`
+}
+
+// The cards for Data; never nil, so they encode as an array
+func CardsData(cards []Card) []CardData {
+	data := []CardData{}
+	for _, c := range cards {
+		data = append(data, CardData{Rank: c.Rank, Suit: c.Suit})
+	}
+	return data
+}
+
+// Whether the round has no actions left
+func RoundOver(e Engine) bool {
+	return len(e.ValidActions()) == 0
+}
+
+// Matches an action against the valid ones, ignoring case. Returns the
+// canonical name.
+func matchAction(e Engine, action string) (string, error) {
+	for _, a := range e.ValidActions() {
+		if strings.EqualFold(a, action) {
+			return a, nil
+		}
+	}
+	if RoundOver(e) {
+		return "", fmt.Errorf("round is over")
+	}
+	return "", fmt.Errorf("%s isn't possible now (try %s)", strings.ToUpper(action), strings.Join(e.ValidActions(), ", "))
+}
@@ -0,0 +1,104 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var (
+	_ Engine = (*HiLo)(nil)
+	_ Engine = (*UltimateHoldem)(nil)
+	_ Engine = (*BaccaratGame)(nil)
+	_ Engine = (*KenoGame)(nil)
+)
+
+func TestEngineRegistry(t *testing.T) {
+	for _, name := range []string{"baccarat", "hilo", "holdem", "keno"} {
+		if _, ok := LookupEngine(name); !ok {
+			t.Errorf("%s isn't registered", name)
+		}
+	}
+	if info, ok := LookupEngine("HiLo"); !ok || info.Name != "hilo" {
+		t.Errorf("LookupEngine(HiLo) = %q, %v; want case ignored", info.Name, ok)
+	}
+	if _, ok := LookupEngine("roulette"); ok {
+		t.Error("LookupEngine(roulette) found a game")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a game twice should panic")
+		}
+	}()
+	RegisterEngine(EngineInfo{Name: "keno"})
+}
+
+// Each game's first action with arguments that play it
+var firstActions = map[string][]string{
+	"baccarat": {"BANKER"},
+	"hilo":     {"CASHOUT"},
+	"holdem":   {"BET", "4"},
+	"keno":     {"DRAW", "1", "2", "3"},
+}
+
+func TestEnginesPlayRounds(t *testing.T) {
+	for _, info := range Engines() {
+		e := info.New()
+		if err := e.StartRound(500); err != nil {
+			t.Fatalf("%s: StartRound() error = %v", info.Name, err)
+		}
+		if RoundOver(e) || e.Wagered() < 500 {
+			t.Fatalf("%s: round over at the start or wagered %d", info.Name, e.Wagered())
+		}
+		if err := e.Action("NOPE", nil); err == nil {
+			t.Errorf("%s: unknown action should fail", info.Name)
+		}
+
+		play := firstActions[info.Name]
+		extra, err := e.ActionStake(play[0], play[1:])
+		if err != nil {
+			t.Fatalf("%s: ActionStake(%v) error = %v", info.Name, play, err)
+		}
+		before := e.Wagered()
+		if err := e.Action(play[0], play[1:]); err != nil {
+			t.Fatalf("%s: Action(%v) error = %v", info.Name, play, err)
+		}
+		if e.Wagered() != before+extra {
+			t.Errorf("%s: wagered %d after the action, want %d", info.Name, e.Wagered(), before+extra)
+		}
+		if !RoundOver(e) || e.State() == "" {
+			t.Errorf("%s: round not over after %v", info.Name, play)
+		}
+		if e.Hands() == "" {
+			t.Errorf("%s: no hands after %v", info.Name, play)
+		}
+		if _, err := json.Marshal(e.Data()); err != nil || len(e.Data()) == 0 {
+			t.Errorf("%s: Data() = %v, marshals with error %v", info.Name, e.Data(), err)
+		}
+		if rtp := e.ExpectedRTP(); rtp <= 0 || rtp > 1 {
+			t.Errorf("%s: ExpectedRTP() = %v", info.Name, rtp)
+		}
+	}
+}
+
+func TestEnginesAbandon(t *testing.T) {
+	for _, info := range Engines() {
+		e := info.New()
+		e.StartRound(500)
+		e.Abandon()
+		if !RoundOver(e) {
+			t.Errorf("%s: round not over after Abandon()", info.Name)
+		}
+	}
+
+	// Games that deal nothing before the action give the stake back
+	for _, name := range []string{"baccarat", "keno"} {
+		info, _ := LookupEngine(name)
+		e := info.New()
+		e.StartRound(500)
+		e.Abandon()
+		if e.Payout() != 500 {
+			t.Errorf("%s: Payout() after Abandon() = %d, want the stake", name, e.Payout())
+		}
+	}
+}
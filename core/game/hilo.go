@@ -0,0 +1,248 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// HiLoCall is a guess at whether the next card is higher or lower
+type HiLoCall string
+
+const (
+	HiLoHigher HiLoCall = "HIGHER"
+	HiLoLower  HiLoCall = "LOWER"
+)
+
+// Each correct call multiplies the pot by the fair odds of the call times
+// HiLoReturn; a card of the same rank loses. The pot is cashed out by itself
+// once it reaches HiLoMaxMultiplier times the bet.
+const (
+	HiLoReturn        = 0.97
+	HiLoMaxMultiplier = 1000.0
+)
+
+// HiLo is a game of Hi-Lo: call whether each card is higher or lower than the
+// one before, aces low, and cash out the pot any time before a wrong call.
+// Every card comes from a freshly shuffled deck, so the odds of a call depend
+// only on the card showing.
+type HiLo struct {
+	Bet        int64 // in cents
+	Card       Card  // The card the next call is made against
+	Streak     int   // Correct calls so far
+	Calls      int   // Calls made, including a losing one
+	Multiplier float64
+	Lost       bool
+	CashedOut  bool
+	Dealt      []Card // Every card so far, the first one included
+
+	rng RNG
+}
+
+// Deals the first card of a Hi-Lo game for a bet
+func NewHiLo(bet int64) *HiLo {
+	return newHiLo(bet, ShuffleRNG)
+}
+
+func newHiLo(bet int64, rng RNG) *HiLo {
+	h := &HiLo{rng: rng}
+	h.StartRound(bet)
+	return h
+}
+
+func init() {
+	RegisterEngine(EngineInfo{
+		Name:  "hilo",
+		Title: "Hi-Lo",
+		About: "Call each card higher or lower to grow the pot, and cash out before a wrong call",
+		New:   func() Engine { return &HiLo{rng: ShuffleRNG} },
+	})
+}
+
+// Shorthands Action takes for the calls and cashing out
+var hiLoAliases = map[string]string{"HI": "HIGHER", "H": "HIGHER", "LO": "LOWER", "L": "LOWER", "CASH": "CASHOUT"}
+
+// Deals the first card for a bet
+func (h *HiLo) StartRound(bet int64) error {
+	*h = HiLo{Bet: bet, Multiplier: 1, rng: h.rng}
+	h.Card = h.draw()
+	h.Dealt = []Card{h.Card}
+	return nil
+}
+
+// The calls that can win on the card showing, and CASHOUT
+func (h *HiLo) ValidActions() []string {
+	if h.Over() {
+		return nil
+	}
+	var actions []string
+	for _, call := range []HiLoCall{HiLoHigher, HiLoLower} {
+		if h.Odds(call) > 0 {
+			actions = append(actions, string(call))
+		}
+	}
+	return append(actions, "CASHOUT")
+}
+
+func (h *HiLo) matchAction(action string) (string, error) {
+	if full, ok := hiLoAliases[strings.ToUpper(action)]; ok {
+		action = full
+	}
+	return matchAction(h, action)
+}
+
+// Calls HIGHER or LOWER, or takes the pot with CASHOUT
+func (h *HiLo) Action(action string, _ []string) error {
+	action, err := h.matchAction(action)
+	if err != nil {
+		return err
+	}
+	if action == "CASHOUT" {
+		return h.CashOut()
+	}
+	return h.Call(HiLoCall(action))
+}
+
+func (h *HiLo) ActionStake(action string, _ []string) (int64, error) {
+	_, err := h.matchAction(action)
+	return 0, err
+}
+
+func (h *HiLo) State() string {
+	return h.String()
+}
+
+func (h *HiLo) Hands() string {
+	return (&Hand{Cards: h.Dealt}).String()
+}
+
+func (h *HiLo) Wagered() int64 {
+	return h.Bet
+}
+
+// A game walked away from is cashed out
+func (h *HiLo) Abandon() {
+	if !h.Over() {
+		h.CashOut()
+	}
+}
+
+// The card showing, every card dealt, the pot in cents and what each call
+// would multiply it by
+func (h *HiLo) Data() map[string]any {
+	odds := map[string]float64{}
+	if !h.Over() {
+		for _, call := range []HiLoCall{HiLoHigher, HiLoLower} {
+			if o := h.Odds(call); o > 0 {
+				odds[string(call)] = o
+			}
+		}
+	}
+	return map[string]any{
+		"card":       CardData{Rank: h.Card.Rank, Suit: h.Card.Suit},
+		"dealt":      CardsData(h.Dealt),
+		"streak":     h.Streak,
+		"multiplier": h.Multiplier,
+		"pot":        h.Payout(),
+		"lost":       h.Lost,
+		"cashed_out": h.CashedOut,
+		"odds":       odds,
+	}
+}
+
+// Picks a card from a full deck
+func (h *HiLo) draw() Card {
+	deck := NewDeck()
+	return deck.Cards[h.rng.Intn(len(deck.Cards))]
+}
+
+// What a correct call multiplies the pot by, or zero when it can't win
+// (HIGHER on a king or LOWER on an ace)
+func (h *HiLo) Odds(call HiLoCall) float64 {
+	rank := rankIndex(h.Card.Rank)
+	winners := rank // Ranks below the card
+	if call == HiLoHigher {
+		winners = 12 - rank
+	}
+	if winners == 0 {
+		return 0
+	}
+	return HiLoReturn * 13 / float64(winners)
+}
+
+// Whether the game has ended with a wrong call or a cash out
+func (h *HiLo) Over() bool {
+	return h.Lost || h.CashedOut
+}
+
+// Deals the next card and settles the call against it
+func (h *HiLo) Call(call HiLoCall) error {
+	if h.Over() {
+		return fmt.Errorf("game is over")
+	}
+	if call != HiLoHigher && call != HiLoLower {
+		return fmt.Errorf("call HIGHER or LOWER")
+	}
+	odds := h.Odds(call)
+	if odds == 0 {
+		return fmt.Errorf("no card is %s than %s%s", strings.ToLower(string(call)), h.Card.Rank, h.Card.Suit)
+	}
+
+	next := h.draw()
+	before, after := rankIndex(h.Card.Rank), rankIndex(next.Rank)
+	h.Card = next
+	h.Dealt = append(h.Dealt, next)
+	h.Calls++
+	if call == HiLoHigher && after > before || call == HiLoLower && after < before {
+		h.Streak++
+		h.Multiplier = math.Min(h.Multiplier*odds, HiLoMaxMultiplier)
+		if h.Multiplier == HiLoMaxMultiplier {
+			h.CashedOut = true
+		}
+	} else {
+		h.Lost = true
+	}
+	return nil
+}
+
+// Ends the game taking the pot
+func (h *HiLo) CashOut() error {
+	if h.Over() {
+		return fmt.Errorf("game is over")
+	}
+	h.CashedOut = true
+	return nil
+}
+
+// The pot: the bet times the multiplier, or nothing after a wrong call
+func (h *HiLo) Payout() int64 {
+	if h.Lost {
+		return 0
+	}
+	return int64(float64(h.Bet) * h.Multiplier)
+}
+
+// Expected return of the game as played: HiLoReturn for every call made
+func (h *HiLo) ExpectedRTP() float64 {
+	return math.Pow(HiLoReturn, float64(h.Calls))
+}
+
+func (h *HiLo) String() string {
+	state := fmt.Sprintf("Card: [%s%s]  Streak: %d  Multiplier: %.2fx  Pot: $%.2f",
+		h.Card.Rank, h.Card.Suit, h.Streak, h.Multiplier, float64(h.Payout())/100)
+	switch {
+	case h.Lost:
+		state += "\nResult: Wrong call, you lose the pot"
+	case h.CashedOut:
+		state += fmt.Sprintf("\nResult: Cashed out $%.2f", float64(h.Payout())/100)
+	default:
+		var calls []string
+		for _, call := range []HiLoCall{HiLoHigher, HiLoLower} {
+			if odds := h.Odds(call); odds > 0 {
+				calls = append(calls, fmt.Sprintf("%s %.2fx", call, odds))
+			}
+		}
+		state += "\nCalls: " + strings.Join(calls, ", ")
+	}
+	return state
+}
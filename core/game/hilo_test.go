@@ -0,0 +1,93 @@
+package game
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// Deals cards from a new deck's order: index = suit*13 + rank, aces first
+type fixedRNG []int
+
+func (r *fixedRNG) Intn(int) int {
+	v := (*r)[0]
+	*r = (*r)[1:]
+	return v
+}
+
+func TestHiLoStreakAndCashOut(t *testing.T) {
+	// 7♠, then 9♠ (higher), then 2♥ (lower)
+	rng := fixedRNG{6, 8, 14}
+	h := newHiLo(1000, &rng)
+	if h.Card.Rank != "7" {
+		t.Fatalf("first card = %s, want 7", h.Card.Rank)
+	}
+
+	higher := h.Odds(HiLoHigher) // 6 ranks above a 7
+	if want := HiLoReturn * 13 / 6; math.Abs(higher-want) > 1e-9 {
+		t.Errorf("Odds(HIGHER) on a 7 = %v, want %v", higher, want)
+	}
+	if err := h.Call(HiLoHigher); err != nil || h.Lost || h.Streak != 1 {
+		t.Fatalf("Call(HIGHER) = %v, lost %v, streak %d", err, h.Lost, h.Streak)
+	}
+	lower := h.Odds(HiLoLower) // 8 ranks below a 9
+	if err := h.Call(HiLoLower); err != nil || h.Lost || h.Streak != 2 {
+		t.Fatalf("Call(LOWER) = %v, lost %v, streak %d", err, h.Lost, h.Streak)
+	}
+
+	if err := h.CashOut(); err != nil {
+		t.Fatalf("CashOut() error = %v", err)
+	}
+	if want := int64(1000 * higher * lower); h.Payout() != want {
+		t.Errorf("Payout() = %d, want %d", h.Payout(), want)
+	}
+	if math.Abs(h.ExpectedRTP()-HiLoReturn*HiLoReturn) > 1e-9 {
+		t.Errorf("ExpectedRTP() = %v after two calls", h.ExpectedRTP())
+	}
+	if err := h.Call(HiLoHigher); err == nil {
+		t.Error("Call() should fail after cashing out")
+	}
+	if !strings.Contains(h.String(), "Cashed out") {
+		t.Errorf("String() = %q", h.String())
+	}
+	if h.Hands() != "[7♠] [9♠] [2♥]" {
+		t.Errorf("Hands() = %q, want every card dealt", h.Hands())
+	}
+}
+
+func TestHiLoSameRankLoses(t *testing.T) {
+	rng := fixedRNG{6, 19} // 7♠ then 7♥
+	h := newHiLo(1000, &rng)
+	if err := h.Call(HiLoHigher); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if !h.Lost || h.Payout() != 0 || !h.Over() {
+		t.Errorf("a card of the same rank should lose, lost %v payout %d", h.Lost, h.Payout())
+	}
+}
+
+func TestHiLoImpossibleCalls(t *testing.T) {
+	rng := fixedRNG{12} // K♠
+	h := newHiLo(1000, &rng)
+	if h.Odds(HiLoHigher) != 0 {
+		t.Errorf("Odds(HIGHER) on a king = %v, want 0", h.Odds(HiLoHigher))
+	}
+	if err := h.Call(HiLoHigher); err == nil || h.Calls != 0 {
+		t.Error("Call(HIGHER) on a king should be refused without dealing")
+	}
+	if s := h.String(); !strings.Contains(s, "Calls: LOWER") || strings.Contains(s, "HIGHER") {
+		t.Errorf("String() should only offer LOWER on a king: %q", s)
+	}
+}
+
+func TestHiLoCashesOutAtMaxMultiplier(t *testing.T) {
+	rng := fixedRNG{0, 1}
+	h := newHiLo(100, &rng)
+	h.Multiplier = HiLoMaxMultiplier - 1
+	if err := h.Call(HiLoHigher); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if !h.CashedOut || h.Multiplier != HiLoMaxMultiplier {
+		t.Errorf("reaching the cap should cash out, multiplier %v cashed %v", h.Multiplier, h.CashedOut)
+	}
+}
@@ -0,0 +1,398 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HoldemStreet is how far an Ultimate Texas Hold'em hand has got
+type HoldemStreet int
+
+const (
+	HoldemPreflop  HoldemStreet = iota // Hole cards dealt; play 3x or 4x the ante
+	HoldemFlop                         // Three board cards shown; play 2x
+	HoldemRiver                        // All five shown; play 1x or fold
+	HoldemShowdown                     // Settled
+)
+
+// Long-run return of a hand of Ultimate Texas Hold'em played well, as a share
+// of everything wagered on it
+const UltimateHoldemRTP = 0.9947
+
+// UltimateHoldem is a hand of Ultimate Texas Hold'em against the dealer. The
+// player stakes equal ante and blind bets, then may make one play bet: 3x or
+// 4x the ante before the flop, 2x on the flop, or 1x on the river, where
+// checking is no longer allowed and they must play or fold. The dealer needs
+// a pair or better to qualify; when they don't the ante pushes. The blind
+// only wins on a straight or better and pushes on other winning hands.
+type UltimateHoldem struct {
+	Ante   int64 // in cents; the blind is the same
+	Play   int64 // in cents, zero until the player plays
+	Player []Card
+	Dealer []Card
+	Board  []Card // All five, dealt up front and shown street by street
+	Street HoldemStreet
+	Folded bool
+}
+
+// Deals a hand of Ultimate Texas Hold'em for an ante from the deck
+func NewUltimateHoldem(ante int64, deck *Deck) (*UltimateHoldem, error) {
+	u := &UltimateHoldem{}
+	if err := u.deal(ante, deck); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (u *UltimateHoldem) deal(ante int64, deck *Deck) error {
+	cards := make([]Card, 9)
+	for i := range cards {
+		card, err := deck.Draw()
+		if err != nil {
+			return fmt.Errorf("failed to deal: %w", err)
+		}
+		cards[i] = card
+	}
+	*u = UltimateHoldem{
+		Ante:   ante,
+		Player: []Card{cards[0], cards[2]},
+		Dealer: []Card{cards[1], cards[3]},
+		Board:  cards[4:],
+	}
+	return nil
+}
+
+func init() {
+	RegisterEngine(EngineInfo{
+		Name:  "holdem",
+		Title: "Ultimate Texas Hold'em",
+		About: "Poker against the dealer with ante, blind and play bets",
+		New:   func() Engine { return &UltimateHoldem{} },
+	})
+}
+
+// Deals a hand for an ante, with an equal blind, from a fresh deck
+func (u *UltimateHoldem) StartRound(ante int64) error {
+	deck := NewDeck()
+	deck.Shuffle()
+	return u.deal(ante, deck)
+}
+
+// BET with CHECK before the river, BET with FOLD on it
+func (u *UltimateHoldem) ValidActions() []string {
+	switch u.Street {
+	case HoldemPreflop, HoldemFlop:
+		return []string{"BET", "CHECK"}
+	case HoldemRiver:
+		return []string{"BET", "FOLD"}
+	default:
+		return nil
+	}
+}
+
+// Parses the multiple of a BET action, such as 4 or 4x
+func playMultiple(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("BET takes the multiple of the ante to play, e.g. BET 4")
+	}
+	multiple, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(args[0]), "x"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid multiple %q", args[0])
+	}
+	return multiple, nil
+}
+
+// BET <multiple> makes the play bet, CHECK shows the next cards and FOLD
+// gives up on the river
+func (u *UltimateHoldem) Action(action string, args []string) error {
+	action, err := matchAction(u, action)
+	if err != nil {
+		return err
+	}
+	switch action {
+	case "BET":
+		multiple, err := playMultiple(args)
+		if err != nil {
+			return err
+		}
+		return u.Bet(multiple)
+	case "CHECK":
+		return u.Check()
+	default:
+		return u.Fold()
+	}
+}
+
+// The play bet of a BET action
+func (u *UltimateHoldem) ActionStake(action string, args []string) (int64, error) {
+	action, err := matchAction(u, action)
+	if err != nil || action != "BET" {
+		return 0, err
+	}
+	multiple, err := playMultiple(args)
+	if err != nil {
+		return 0, err
+	}
+	if err := u.CheckPlay(multiple); err != nil {
+		return 0, err
+	}
+	return u.Ante * int64(multiple), nil
+}
+
+func (u *UltimateHoldem) State() string {
+	return u.String()
+}
+
+func (u *UltimateHoldem) Hands() string {
+	hands := fmt.Sprintf("Player %s (%s), dealer %s (%s), board %s",
+		&Hand{Cards: u.Player}, u.PlayerHand().Rank, &Hand{Cards: u.Dealer}, u.DealerHand().Rank, &Hand{Cards: u.Shown()})
+	if u.Folded {
+		hands += ", folded"
+	}
+	return hands
+}
+
+func (u *UltimateHoldem) Wagered() int64 {
+	return u.TotalWager()
+}
+
+// The stakes, the board shown so far and the player's cards. The dealer's
+// cards are left out until the showdown.
+func (u *UltimateHoldem) Data() map[string]any {
+	plays := append([]int{}, u.PlayMultiples()...)
+	data := map[string]any{
+		"ante":   u.Ante,
+		"blind":  u.Ante,
+		"play":   u.Play,
+		"board":  CardsData(u.Shown()),
+		"player": CardsData(u.Player),
+		"plays":  plays,
+		"folded": u.Folded,
+	}
+	if u.Street != HoldemPreflop {
+		data["player_hand"] = u.PlayerHand().Rank.String()
+	}
+	if u.Over() {
+		data["dealer"] = CardsData(u.Dealer)
+		data["dealer_hand"] = u.DealerHand().Rank.String()
+		data["dealer_qualifies"] = u.DealerQualifies()
+	}
+	return data
+}
+
+// A hand walked away from is checked down and folded
+func (u *UltimateHoldem) Abandon() {
+	for u.Check() == nil {
+	}
+	u.Fold()
+}
+
+func (u *UltimateHoldem) ExpectedRTP() float64 {
+	return UltimateHoldemRTP
+}
+
+// Play bet multiples allowed on the current street
+func (u *UltimateHoldem) PlayMultiples() []int {
+	switch u.Street {
+	case HoldemPreflop:
+		return []int{3, 4}
+	case HoldemFlop:
+		return []int{2}
+	case HoldemRiver:
+		return []int{1}
+	default:
+		return nil
+	}
+}
+
+// Checks a play bet of multiple times the ante against the street
+func (u *UltimateHoldem) CheckPlay(multiple int) error {
+	allowed := u.PlayMultiples()
+	if allowed == nil {
+		return fmt.Errorf("hand is over")
+	}
+	for _, m := range allowed {
+		if m == multiple {
+			return nil
+		}
+	}
+	var names []string
+	for _, m := range allowed {
+		names = append(names, fmt.Sprintf("%dx", m))
+	}
+	return fmt.Errorf("play bet on this street must be %s the ante", strings.Join(names, " or "))
+}
+
+// Makes the play bet and settles the hand
+func (u *UltimateHoldem) Bet(multiple int) error {
+	if err := u.CheckPlay(multiple); err != nil {
+		return err
+	}
+	u.Play = u.Ante * int64(multiple)
+	u.Street = HoldemShowdown
+	return nil
+}
+
+// Shows the next cards without a play bet
+func (u *UltimateHoldem) Check() error {
+	switch u.Street {
+	case HoldemPreflop, HoldemFlop:
+		u.Street++
+		return nil
+	case HoldemRiver:
+		return fmt.Errorf("on the river you must play 1x or fold")
+	default:
+		return fmt.Errorf("hand is over")
+	}
+}
+
+// Gives up the ante and blind on the river
+func (u *UltimateHoldem) Fold() error {
+	switch u.Street {
+	case HoldemRiver:
+		u.Folded = true
+		u.Street = HoldemShowdown
+		return nil
+	case HoldemShowdown:
+		return fmt.Errorf("hand is over")
+	default:
+		return fmt.Errorf("you can only fold on the river; check instead")
+	}
+}
+
+func (u *UltimateHoldem) Over() bool {
+	return u.Street == HoldemShowdown
+}
+
+// Board cards shown so far
+func (u *UltimateHoldem) Shown() []Card {
+	switch u.Street {
+	case HoldemPreflop:
+		return nil
+	case HoldemFlop:
+		return u.Board[:3]
+	default:
+		return u.Board
+	}
+}
+
+// Everything the player has riding on the hand
+func (u *UltimateHoldem) TotalWager() int64 {
+	return 2*u.Ante + u.Play
+}
+
+// The player's best hand with the board shown so far (from the flop on)
+func (u *UltimateHoldem) PlayerHand() PokerHand {
+	return BestPokerHand(append(append([]Card(nil), u.Player...), u.Shown()...))
+}
+
+// The dealer's best hand with the board shown so far (from the flop on)
+func (u *UltimateHoldem) DealerHand() PokerHand {
+	return BestPokerHand(append(append([]Card(nil), u.Dealer...), u.Shown()...))
+}
+
+// Whether the dealer has the pair or better needed for the ante to play
+func (u *UltimateHoldem) DealerQualifies() bool {
+	return u.DealerHand().Rank >= OnePair
+}
+
+// What the blind pays to one on a winning hand of the rank, as a fraction
+func blindPays(rank PokerRank) (int64, int64) {
+	switch rank {
+	case RoyalFlush:
+		return 500, 1
+	case StraightFlush:
+		return 50, 1
+	case FourOfAKind:
+		return 10, 1
+	case FullHouse:
+		return 3, 1
+	case PokerFlush:
+		return 3, 2
+	case PokerStraight:
+		return 1, 1
+	default:
+		return 0, 1
+	}
+}
+
+// What the hand pays back, stakes included; zero before the showdown
+func (u *UltimateHoldem) Payout() int64 {
+	if !u.Over() || u.Folded {
+		return 0
+	}
+	player := u.PlayerHand()
+	cmp := player.Compare(u.DealerHand())
+	qualifies := u.DealerQualifies()
+
+	switch {
+	case cmp > 0:
+		payout := 2 * u.Play
+		if qualifies {
+			payout += 2 * u.Ante
+		} else {
+			payout += u.Ante
+		}
+		num, den := blindPays(player.Rank)
+		return payout + u.Ante + u.Ante*num/den
+	case cmp == 0:
+		return u.TotalWager()
+	case !qualifies:
+		return u.Ante // The ante pushes; play and blind lose
+	default:
+		return 0
+	}
+}
+
+func (u *UltimateHoldem) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Ante: $%.2f  Blind: $%.2f", float64(u.Ante)/100, float64(u.Ante)/100)
+	if u.Play > 0 {
+		fmt.Fprintf(&b, "  Play: $%.2f", float64(u.Play)/100)
+	}
+	board := (&Hand{Cards: u.Shown()}).String()
+	if board == "" {
+		board = "-"
+	}
+	fmt.Fprintf(&b, "\nBoard: %s\nYour Hand: %s", board, (&Hand{Cards: u.Player}).String())
+
+	if !u.Over() {
+		if u.Street != HoldemPreflop {
+			fmt.Fprintf(&b, " (%s)", u.PlayerHand().Rank)
+		}
+		b.WriteString("\nDealer Hand: [??] [??]")
+		var plays []string
+		for _, m := range u.PlayMultiples() {
+			plays = append(plays, fmt.Sprintf("BET %d", m))
+		}
+		if u.Street == HoldemRiver {
+			plays = append(plays, "FOLD")
+		} else {
+			plays = append(plays, "CHECK")
+		}
+		fmt.Fprintf(&b, "\nOptions: %s", strings.Join(plays, ", "))
+		return b.String()
+	}
+
+	player, dealer := u.PlayerHand(), u.DealerHand()
+	fmt.Fprintf(&b, "\nDealer Hand: %s", (&Hand{Cards: u.Dealer}).String())
+	fmt.Fprintf(&b, "\nYour Best: %s\nDealer Best: %s", player, dealer)
+	if !u.Folded && !u.DealerQualifies() {
+		b.WriteString(" - dealer doesn't qualify, ante pushes")
+	}
+
+	var result string
+	switch cmp := player.Compare(dealer); {
+	case u.Folded:
+		result = "You fold"
+	case cmp > 0:
+		result = "You win"
+	case cmp == 0:
+		result = "Push"
+	default:
+		result = "Dealer wins"
+	}
+	fmt.Fprintf(&b, "\nResult: %s\nPayout: $%.2f", result, float64(u.Payout())/100)
+	return b.String()
+}
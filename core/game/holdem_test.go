@@ -0,0 +1,113 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+// Deals a hand with the given hole cards and board, dealt player, dealer,
+// player, dealer, then the board
+func holdemHand(t *testing.T, player, dealer, board string) *UltimateHoldem {
+	t.Helper()
+	p, d := cards(player), cards(dealer)
+	deck := &Deck{Cards: append([]Card{p[0], d[0], p[1], d[1]}, cards(board)...)}
+	u, err := NewUltimateHoldem(1000, deck)
+	if err != nil {
+		t.Fatalf("NewUltimateHoldem() error = %v", err)
+	}
+	return u
+}
+
+func TestUltimateHoldemStreets(t *testing.T) {
+	u := holdemHand(t, "A♠ K♠", "2♥ 7♦", "Q♠ J♠ 3♣ 10♠ 4♦")
+	if len(u.Shown()) != 0 {
+		t.Fatalf("board shown before the flop: %v", u.Shown())
+	}
+	if err := u.Bet(2); err == nil {
+		t.Error("Bet(2) before the flop should fail")
+	}
+	if err := u.Fold(); err == nil {
+		t.Error("Fold() before the river should fail")
+	}
+
+	if err := u.Check(); err != nil || u.Street != HoldemFlop || len(u.Shown()) != 3 {
+		t.Fatalf("Check() = %v, street %d with %d cards shown", err, u.Street, len(u.Shown()))
+	}
+	if rank := u.PlayerHand().Rank; rank != HighCard {
+		t.Errorf("hand on the flop = %s, want high card (the turn and river are hidden)", rank)
+	}
+	if err := u.Check(); err != nil || u.Street != HoldemRiver {
+		t.Fatalf("Check() = %v, street %d", err, u.Street)
+	}
+	if err := u.Check(); err == nil {
+		t.Error("Check() on the river should fail")
+	}
+
+	if err := u.Bet(1); err != nil || !u.Over() {
+		t.Fatalf("Bet(1) = %v, over %v", err, u.Over())
+	}
+	// Royal flush beats a dealer who doesn't qualify: play 1:1, ante pushes,
+	// blind 500:1
+	if got, want := u.Payout(), int64(2*1000+1000+1000+500*1000); got != want {
+		t.Errorf("Payout() = %d, want %d", got, want)
+	}
+	if u.TotalWager() != 3000 {
+		t.Errorf("TotalWager() = %d, want 3000", u.TotalWager())
+	}
+	if err := u.Bet(1); err == nil {
+		t.Error("Bet() after the showdown should fail")
+	}
+}
+
+func TestUltimateHoldemPayout(t *testing.T) {
+	tests := []struct {
+		name                  string
+		player, dealer, board string
+		multiple              int // 0 folds on the river
+		want                  int64
+	}{
+		// Pair of aces beats a pair of kings: play 4x and ante win, blind pushes
+		{"win", "A♠ A♥", "K♦ K♣", "2♠ 7♥ 9♦ J♣ 3♥", 4, 8000 + 2000 + 1000},
+		// Flush wins the blind at 3:2
+		{"win blind", "A♥ 5♥", "K♦ K♣", "2♥ 7♥ 9♥ J♣ 3♠", 3, 6000 + 2000 + 1000 + 1500},
+		// Dealer's kings beat the player's queens
+		{"lose", "Q♠ Q♥", "K♦ K♣", "2♠ 7♥ 9♦ J♣ 3♥", 4, 0},
+		// Dealer with no pair doesn't qualify: the ante comes back
+		{"lose unqualified", "A♠ 4♥", "A♦ 8♣", "2♠ 7♥ 9♦ J♣ K♥", 1, 1000},
+		// Both play the board's straight
+		{"tie", "2♠ 3♥", "2♦ 3♣", "8♠ 9♥ 10♦ J♣ Q♥", 2, 2000 + 2000},
+		{"fold", "2♠ 3♥", "K♦ K♣", "8♠ 9♥ 5♦ J♣ A♥", 0, 0},
+	}
+	for _, tt := range tests {
+		u := holdemHand(t, tt.player, tt.dealer, tt.board)
+		for u.Street != HoldemRiver && (tt.multiple == 0 || tt.multiple < u.PlayMultiples()[0]) {
+			u.Check()
+		}
+		var err error
+		if tt.multiple == 0 {
+			err = u.Fold()
+		} else {
+			err = u.Bet(tt.multiple)
+		}
+		if err != nil {
+			t.Fatalf("%s: error = %v", tt.name, err)
+		}
+		if got := u.Payout(); got != tt.want {
+			t.Errorf("%s: Payout() = %d, want %d\n%s", tt.name, got, tt.want, u)
+		}
+	}
+}
+
+func TestUltimateHoldemString(t *testing.T) {
+	u := holdemHand(t, "A♠ K♠", "2♥ 7♦", "Q♠ J♠ 3♣ 10♠ 4♦")
+	if s := u.String(); strings.Contains(s, "2♥") || !strings.Contains(s, "BET 4") {
+		t.Errorf("String() before the showdown = %q, want the dealer hidden and the play options", s)
+	}
+	u.Bet(4)
+	if s := u.String(); !strings.Contains(s, "2♥") || !strings.Contains(s, "Royal flush") {
+		t.Errorf("String() at the showdown = %q", s)
+	}
+	if want := "Player [A♠] [K♠] (Royal flush), dealer [2♥] [7♦] (High card), board [Q♠] [J♠] [3♣] [10♠] [4♦]"; u.Hands() != want {
+		t.Errorf("Hands() = %q, want %q", u.Hands(), want)
+	}
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+var suitLetters = map[byte]string{'S': "♠", 'H': "♥", 'D': "♦", 'C': "♣"}
+
+// JackpotTrigger is the opening hand that wins the progressive jackpot
+type JackpotTrigger struct {
+	Cards []Card // Only Rank and Suit are compared
+}
+
+// Parses a comma-separated card list such as "AS,JS" (ace and jack of spades)
+func ParseJackpotTrigger(spec string) (*JackpotTrigger, error) {
+	var cards []Card
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToUpper(strings.TrimSpace(part))
+		if len(part) < 2 {
+			return nil, fmt.Errorf("invalid card %q", part)
+		}
+
+		suit, ok := suitLetters[part[len(part)-1]]
+		if !ok {
+			return nil, fmt.Errorf("invalid suit in %q (use S, H, D or C)", part)
+		}
+
+		rank := part[:len(part)-1]
+		if !validRank(rank) {
+			return nil, fmt.Errorf("invalid rank in %q", part)
+		}
+
+		cards = append(cards, Card{Rank: rank, Suit: suit})
+	}
+
+	if len(cards) == 0 {
+		return nil, fmt.Errorf("jackpot trigger needs at least one card")
+	}
+
+	return &JackpotTrigger{Cards: cards}, nil
+}
+
+func validRank(rank string) bool {
+	for _, r := range []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"} {
+		if r == rank {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether the player's opening two cards contain all the trigger cards
+func (t *JackpotTrigger) Matches(g *Game) bool {
+	n := max(2, len(t.Cards))
+	if len(g.PlayerHand.Cards) < n {
+		return false
+	}
+
+	opening := g.PlayerHand.Cards[:n]
+	used := make([]bool, len(opening))
+	for _, want := range t.Cards {
+		found := false
+		for i, c := range opening {
+			if !used[i] && c.Rank == want.Rank && c.Suit == want.Suit {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *JackpotTrigger) String() string {
+	parts := make([]string, len(t.Cards))
+	for i, c := range t.Cards {
+		parts[i] = c.Rank + c.Suit
+	}
+	return strings.Join(parts, " ")
+}
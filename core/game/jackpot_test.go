@@ -0,0 +1,53 @@
+package game
+
+import "testing"
+
+func TestParseJackpotTrigger(t *testing.T) {
+	trigger, err := ParseJackpotTrigger("AS, js")
+	if err != nil {
+		t.Fatalf("ParseJackpotTrigger() error = %v", err)
+	}
+	if trigger.String() != "A♠ J♠" {
+		t.Errorf("ParseJackpotTrigger() = %s, want A♠ J♠", trigger)
+	}
+
+	for _, spec := range []string{"", "A", "AX", "1S", "AS,ZZ"} {
+		if _, err := ParseJackpotTrigger(spec); err == nil {
+			t.Errorf("ParseJackpotTrigger(%q) should fail", spec)
+		}
+	}
+}
+
+func TestJackpotTriggerMatches(t *testing.T) {
+	trigger, err := ParseJackpotTrigger("AS,JS")
+	if err != nil {
+		t.Fatalf("ParseJackpotTrigger() error = %v", err)
+	}
+
+	// Deal order is player, dealer, player, dealer
+	g := NewGameWithDeck([]Card{
+		{Suit: "♠", Rank: "J", Value: 10},
+		{Suit: "♥", Rank: "5", Value: 5},
+		{Suit: "♠", Rank: "A", Value: 11},
+		{Suit: "♥", Rank: "9", Value: 9},
+	})
+	if err := g.PlaceBetNoShuffle(100); err != nil {
+		t.Fatalf("PlaceBetNoShuffle() error = %v", err)
+	}
+	if !trigger.Matches(g) {
+		t.Error("Matches() = false for J♠ A♠")
+	}
+
+	g = NewGameWithDeck([]Card{
+		{Suit: "♣", Rank: "J", Value: 10},
+		{Suit: "♥", Rank: "5", Value: 5},
+		{Suit: "♠", Rank: "A", Value: 11},
+		{Suit: "♥", Rank: "9", Value: 9},
+	})
+	if err := g.PlaceBetNoShuffle(100); err != nil {
+		t.Fatalf("PlaceBetNoShuffle() error = %v", err)
+	}
+	if trigger.Matches(g) {
+		t.Error("Matches() = true for an unsuited blackjack")
+	}
+}
@@ -0,0 +1,316 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The house draws KenoDrawn of the numbers 1 to KenoNumbers; players pick
+// up to KenoMaxPicks of them
+const (
+	KenoNumbers  = 80
+	KenoDrawn    = 20
+	KenoMaxPicks = 10
+)
+
+// KenoPayTable holds, for each count of numbers picked, what each count of
+// hits pays for one (stake included). Hit counts missing from a row pay
+// nothing.
+type KenoPayTable map[int]map[int]float64
+
+// House pay table, returning between 90% and 94% depending on the picks
+var DefaultKenoPayTable = KenoPayTable{
+	1:  {1: 3.75},
+	2:  {1: 1, 2: 9},
+	3:  {2: 2, 3: 47},
+	4:  {2: 2, 3: 5, 4: 91},
+	5:  {3: 3, 4: 15, 5: 750},
+	6:  {3: 2, 4: 8, 5: 80, 6: 1600},
+	7:  {3: 1, 4: 6, 5: 25, 6: 160, 7: 5000},
+	8:  {4: 3, 5: 12, 6: 100, 7: 1000, 8: 10000},
+	9:  {4: 2, 5: 5, 6: 50, 7: 250, 8: 3000, 9: 10000},
+	10: {0: 5, 5: 2, 6: 20, 7: 130, 8: 1000, 9: 4000, 10: 10000},
+}
+
+// Parses a comma-separated list of "picks/hits=pays" entries such as
+// "1/1=3,2/2=12" into a pay table. The rows for the pick counts given replace
+// the house rows; the others keep them. An empty spec is the house table.
+func ParseKenoPayTable(spec string) (KenoPayTable, error) {
+	rows := KenoPayTable{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		picksStr, hitsStr, ok2 := strings.Cut(key, "/")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid entry %q (use picks/hits=pays)", part)
+		}
+		picks, err1 := strconv.Atoi(picksStr)
+		hits, err2 := strconv.Atoi(hitsStr)
+		pays, err3 := strconv.ParseFloat(value, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			return nil, fmt.Errorf("invalid entry %q (use picks/hits=pays)", part)
+		}
+		if picks < 1 || picks > KenoMaxPicks || hits < 0 || hits > picks || !(pays >= 0) || math.IsInf(pays, 0) {
+			return nil, fmt.Errorf("entry %q is out of range", part)
+		}
+		if rows[picks] == nil {
+			rows[picks] = map[int]float64{}
+		}
+		rows[picks][hits] = pays
+	}
+
+	table := KenoPayTable{}
+	for picks, row := range DefaultKenoPayTable {
+		table[picks] = row
+	}
+	for picks, row := range rows {
+		table[picks] = row
+	}
+	return table, nil
+}
+
+// What hits out of picks pays for one
+func (t KenoPayTable) Pays(picks, hits int) float64 {
+	return t[picks][hits]
+}
+
+// Long-run return of a ticket with the given count of picks
+func (t KenoPayTable) RTP(picks int) float64 {
+	var rtp float64
+	for hits, pays := range t[picks] {
+		rtp += kenoHitChance(picks, hits) * pays
+	}
+	return rtp
+}
+
+// Chance that exactly hits of picks numbers are among those drawn
+func kenoHitChance(picks, hits int) float64 {
+	return binomial(KenoDrawn, hits) * binomial(KenoNumbers-KenoDrawn, picks-hits) / binomial(KenoNumbers, picks)
+}
+
+func binomial(n, k int) float64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+	return result
+}
+
+// Parses the numbers a player picks: 1 to KenoMaxPicks different numbers
+// from 1 to KenoNumbers. Returns them sorted.
+func ParseKenoPicks(args []string) ([]int, error) {
+	if len(args) == 0 || len(args) > KenoMaxPicks {
+		return nil, fmt.Errorf("pick 1 to %d numbers", KenoMaxPicks)
+	}
+	seen := map[int]bool{}
+	picks := make([]int, 0, len(args))
+	for _, arg := range args {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 || n > KenoNumbers {
+			return nil, fmt.Errorf("%q is not a number from 1 to %d", arg, KenoNumbers)
+		}
+		if seen[n] {
+			return nil, fmt.Errorf("%d is picked twice", n)
+		}
+		seen[n] = true
+		picks = append(picks, n)
+	}
+	sort.Ints(picks)
+	return picks, nil
+}
+
+// KenoDraw is one keno game: the numbers picked, the numbers drawn and the
+// picks among them
+type KenoDraw struct {
+	Picks []int
+	Drawn []int // Sorted
+	Hits  []int
+}
+
+// Draws KenoDrawn numbers with ShuffleRNG against the picks
+func DrawKeno(picks []int) *KenoDraw {
+	return drawKeno(picks, ShuffleRNG)
+}
+
+func drawKeno(picks []int, rng RNG) *KenoDraw {
+	numbers := make([]int, KenoNumbers)
+	for i := range numbers {
+		numbers[i] = i + 1
+	}
+	// Partial Fisher-Yates: the first KenoDrawn numbers end up a random draw
+	for i := 0; i < KenoDrawn; i++ {
+		j := i + rng.Intn(KenoNumbers-i)
+		numbers[i], numbers[j] = numbers[j], numbers[i]
+	}
+	drawn := append([]int(nil), numbers[:KenoDrawn]...)
+	sort.Ints(drawn)
+
+	d := &KenoDraw{Picks: picks, Drawn: drawn}
+	for _, n := range picks {
+		if i := sort.SearchInts(drawn, n); i < len(drawn) && drawn[i] == n {
+			d.Hits = append(d.Hits, n)
+		}
+	}
+	return d
+}
+
+// What a ticket of bet pays back under the pay table, stake included
+func (d *KenoDraw) Payout(table KenoPayTable, bet int64) int64 {
+	return int64(float64(bet) * table.Pays(len(d.Picks), len(d.Hits)))
+}
+
+func (d *KenoDraw) String() string {
+	hit := map[int]bool{}
+	for _, n := range d.Hits {
+		hit[n] = true
+	}
+	drawn := make([]string, len(d.Drawn))
+	for i, n := range d.Drawn {
+		drawn[i] = strconv.Itoa(n)
+		if hit[n] {
+			drawn[i] = "[" + drawn[i] + "]"
+		}
+	}
+	return fmt.Sprintf("Picks: %s\nDrawn: %s\nHits: %d of %d",
+		joinInts(d.Picks), strings.Join(drawn, " "), len(d.Hits), len(d.Picks))
+}
+
+// Lists what each count of hits pays for picks, best first
+func (t KenoPayTable) Describe(picks int) string {
+	var hits []int
+	for h := range t[picks] {
+		hits = append(hits, h)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(hits)))
+	var pays []string
+	for _, h := range hits {
+		pays = append(pays, fmt.Sprintf("%d of %d pays %gx", h, picks, t[picks][h]))
+	}
+	if len(pays) == 0 {
+		return "no payouts"
+	}
+	return strings.Join(pays, ", ")
+}
+
+func joinInts(ns []int) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, " ")
+}
+
+// Pay table keno games are paid by. Operators can replace it at startup.
+var KenoPays = DefaultKenoPayTable
+
+// KenoGame plays a keno ticket as an Engine: the round starts with the stake
+// and DRAW with the numbers picked draws it
+type KenoGame struct {
+	Bet       int64 // in cents
+	Draw      *KenoDraw
+	Abandoned bool // Walked away from before the draw
+}
+
+func init() {
+	RegisterEngine(EngineInfo{
+		Name:  "keno",
+		Title: "Keno",
+		About: fmt.Sprintf("Pick 1 to %d numbers and see how many of the %d drawn of %d they hit", KenoMaxPicks, KenoDrawn, KenoNumbers),
+		New:   func() Engine { return &KenoGame{} },
+	})
+}
+
+func (k *KenoGame) StartRound(bet int64) error {
+	*k = KenoGame{Bet: bet}
+	return nil
+}
+
+func (k *KenoGame) ValidActions() []string {
+	if k.Draw != nil || k.Abandoned {
+		return nil
+	}
+	return []string{"DRAW"}
+}
+
+// DRAW <numbers> draws against the numbers picked
+func (k *KenoGame) Action(action string, args []string) error {
+	if _, err := k.ActionStake(action, args); err != nil {
+		return err
+	}
+	picks, _ := ParseKenoPicks(args)
+	k.Draw = DrawKeno(picks)
+	return nil
+}
+
+func (k *KenoGame) ActionStake(action string, args []string) (int64, error) {
+	if _, err := matchAction(k, action); err != nil {
+		return 0, err
+	}
+	_, err := ParseKenoPicks(args)
+	return 0, err
+}
+
+func (k *KenoGame) Hands() string {
+	if k.Draw == nil {
+		return ""
+	}
+	hits := "no hits"
+	if len(k.Draw.Hits) > 0 {
+		hits = "hits " + joinInts(k.Draw.Hits)
+	}
+	return fmt.Sprintf("Picks %s, %s", joinInts(k.Draw.Picks), hits)
+}
+
+func (k *KenoGame) Wagered() int64 {
+	return k.Bet
+}
+
+// What the ticket pays back; the stake when it was never drawn
+func (k *KenoGame) Payout() int64 {
+	if k.Draw == nil {
+		return k.Bet
+	}
+	return k.Draw.Payout(KenoPays, k.Bet)
+}
+
+// The picks, numbers drawn and hits, once drawn
+func (k *KenoGame) Data() map[string]any {
+	data := map[string]any{"stake": k.Bet}
+	if k.Draw != nil {
+		data["picks"] = k.Draw.Picks
+		data["drawn"] = k.Draw.Drawn
+		data["hits"] = append([]int{}, k.Draw.Hits...)
+	}
+	return data
+}
+
+// A ticket walked away from before the draw is called off
+func (k *KenoGame) Abandon() {
+	if k.Draw == nil {
+		k.Abandoned = true
+	}
+}
+
+func (k *KenoGame) ExpectedRTP() float64 {
+	if k.Draw == nil {
+		return 1
+	}
+	return KenoPays.RTP(len(k.Draw.Picks))
+}
+
+func (k *KenoGame) State() string {
+	if k.Draw == nil {
+		return fmt.Sprintf("Stake: $%.2f\nUse DRAW <numbers> with 1 to %d numbers from 1 to %d", float64(k.Bet)/100, KenoMaxPicks, KenoNumbers)
+	}
+	return fmt.Sprintf("Keno: $%.2f on %d numbers\n%s\nPayout: $%.2f",
+		float64(k.Bet)/100, len(k.Draw.Picks), k.Draw, float64(k.Payout())/100)
+}
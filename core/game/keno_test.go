@@ -0,0 +1,93 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseKenoPicks(t *testing.T) {
+	picks, err := ParseKenoPicks([]string{"80", "7", "1"})
+	if err != nil || len(picks) != 3 || picks[0] != 1 || picks[2] != 80 {
+		t.Fatalf("ParseKenoPicks(80 7 1) = %v, %v", picks, err)
+	}
+	for _, args := range [][]string{
+		{},
+		{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11"},
+		{"0"},
+		{"81"},
+		{"x"},
+		{"5", "5"},
+	} {
+		if _, err := ParseKenoPicks(args); err == nil {
+			t.Errorf("ParseKenoPicks(%v) should fail", args)
+		}
+	}
+}
+
+func TestDrawKeno(t *testing.T) {
+	rng := fixedRNG(make([]int, KenoDrawn)) // Always swaps in the next number: draws 1 to 20
+	d := drawKeno([]int{3, 20, 21, 60}, &rng)
+	if len(d.Drawn) != KenoDrawn || d.Drawn[0] != 1 || d.Drawn[KenoDrawn-1] != 20 {
+		t.Fatalf("Drawn = %v, want 1 to 20", d.Drawn)
+	}
+	if len(d.Hits) != 2 || d.Hits[0] != 3 || d.Hits[1] != 20 {
+		t.Errorf("Hits = %v, want [3 20]", d.Hits)
+	}
+	// 2 of 4 pays 2 for 1
+	if got := d.Payout(DefaultKenoPayTable, 500); got != 1000 {
+		t.Errorf("Payout() = %d, want 1000", got)
+	}
+
+	// Real draws never repeat a number
+	for i := 0; i < 100; i++ {
+		seen := map[int]bool{}
+		for _, n := range DrawKeno([]int{1}).Drawn {
+			if seen[n] || n < 1 || n > KenoNumbers {
+				t.Fatalf("bad draw number %d", n)
+			}
+			seen[n] = true
+		}
+	}
+}
+
+func TestKenoRTP(t *testing.T) {
+	// One pick hits a quarter of the time
+	if got := DefaultKenoPayTable.RTP(1); math.Abs(got-0.25*3.75) > 1e-9 {
+		t.Errorf("RTP(1) = %v, want %v", got, 0.25*3.75)
+	}
+	for picks := 1; picks <= KenoMaxPicks; picks++ {
+		var total float64
+		for hits := 0; hits <= picks; hits++ {
+			total += kenoHitChance(picks, hits)
+		}
+		if math.Abs(total-1) > 1e-9 {
+			t.Errorf("hit chances for %d picks sum to %v", picks, total)
+		}
+		if rtp := DefaultKenoPayTable.RTP(picks); rtp < 0.90 || rtp > 0.95 {
+			t.Errorf("RTP(%d) = %v, want 90%% to 95%%", picks, rtp)
+		}
+	}
+}
+
+func TestParseKenoPayTable(t *testing.T) {
+	table, err := ParseKenoPayTable("1/1=3, 2/2=12,2/1=0.5")
+	if err != nil {
+		t.Fatalf("ParseKenoPayTable() error = %v", err)
+	}
+	if table.Pays(1, 1) != 3 || table.Pays(2, 2) != 12 || table.Pays(2, 1) != 0.5 {
+		t.Errorf("pays = %v, %v, %v", table.Pays(1, 1), table.Pays(2, 2), table.Pays(2, 1))
+	}
+	// Rows not given keep the house pays
+	if table.Pays(5, 5) != DefaultKenoPayTable.Pays(5, 5) {
+		t.Errorf("Pays(5, 5) = %v, want the house %v", table.Pays(5, 5), DefaultKenoPayTable.Pays(5, 5))
+	}
+
+	if table, err := ParseKenoPayTable(""); err != nil || table.Pays(1, 1) != 3.75 {
+		t.Errorf("ParseKenoPayTable(\"\") = %v, %v", table, err)
+	}
+	for _, spec := range []string{"1/1", "1=3", "0/0=1", "11/1=1", "3/4=1", "1/1=-1", "1/1=NaN", "a/1=1"} {
+		if _, err := ParseKenoPayTable(spec); err == nil {
+			t.Errorf("ParseKenoPayTable(%q) should fail", spec)
+		}
+	}
+}
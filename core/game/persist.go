@@ -0,0 +1,31 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Serializes a solo hand, undealt cards included, so it can be restored and
+// finished after a restart exactly as it would have been played
+func (g *Game) Snapshot() (string, error) {
+	if g.sharedDealer {
+		return "", fmt.Errorf("hands at a shared table can't be saved")
+	}
+	data, err := json.Marshal(g)
+	if err != nil {
+		return "", fmt.Errorf("failed to save game: %w", err)
+	}
+	return string(data), nil
+}
+
+// Rebuilds a hand saved with Snapshot
+func RestoreGame(state string) (*Game, error) {
+	var g Game
+	if err := json.Unmarshal([]byte(state), &g); err != nil {
+		return nil, fmt.Errorf("failed to restore game: %w", err)
+	}
+	if g.Deck == nil || g.PlayerHand == nil || g.DealerHand == nil {
+		return nil, fmt.Errorf("failed to restore game: incomplete state")
+	}
+	return &g, nil
+}
@@ -0,0 +1,61 @@
+package game
+
+import "testing"
+
+func TestSnapshotRestore(t *testing.T) {
+	// Player 10+6, dealer 9+8, then a 5 for the player
+	g := NewGameWithDeck([]Card{
+		NewCard("10", "♠"), NewCard("9", "♥"), NewCard("6", "♦"), NewCard("8", "♣"),
+		NewCard("5", "♠"), NewCard("K", "♥"),
+	})
+	g.Rules = Rules{DealerHitsSoft17: true, BlackjackPays: Pays6to5}
+	if err := g.PlaceBetNoShuffle(1000); err != nil {
+		t.Fatalf("PlaceBetNoShuffle() error = %v", err)
+	}
+
+	state, err := g.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	restored, err := RestoreGame(state)
+	if err != nil {
+		t.Fatalf("RestoreGame() error = %v", err)
+	}
+
+	if restored.Bet != 1000 || restored.Phase != PhasePlayerTurn || restored.Rules != g.Rules {
+		t.Errorf("RestoreGame() = bet %d, phase %s, rules %+v", restored.Bet, restored.Phase, restored.Rules)
+	}
+	if restored.PlayerHand.Value() != 16 || restored.DealerHand.Value() != 17 {
+		t.Errorf("RestoreGame() hands = %d vs %d, want 16 vs 17", restored.PlayerHand.Value(), restored.DealerHand.Value())
+	}
+
+	// The restored hand draws the same cards the original would have
+	if err := restored.Hit(); err != nil {
+		t.Fatalf("Hit() error = %v", err)
+	}
+	if err := restored.Stand(); err != nil {
+		t.Fatalf("Stand() error = %v", err)
+	}
+	if restored.PlayerHand.Value() != 21 || restored.Result != ResultPlayerWin {
+		t.Errorf("restored hand = %d, %s, want 21 and a win", restored.PlayerHand.Value(), restored.Result)
+	}
+	if g.PlayerHand.Value() != 16 {
+		t.Error("playing the restored hand should leave the original alone")
+	}
+}
+
+func TestSnapshotShared(t *testing.T) {
+	g := NewGame()
+	g.sharedDealer = true
+	if _, err := g.Snapshot(); err == nil {
+		t.Error("Snapshot() should refuse a shared table hand")
+	}
+}
+
+func TestRestoreGameInvalid(t *testing.T) {
+	for _, state := range []string{"", "not json", `{"Bet": 100}`} {
+		if _, err := RestoreGame(state); err == nil {
+			t.Errorf("RestoreGame(%q) should fail", state)
+		}
+	}
+}
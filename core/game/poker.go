@@ -0,0 +1,160 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PokerRank is the category of a five-card poker hand, worst first
+type PokerRank int
+
+const (
+	HighCard PokerRank = iota
+	OnePair
+	TwoPair
+	ThreeOfAKind
+	PokerStraight
+	PokerFlush
+	FullHouse
+	FourOfAKind
+	StraightFlush
+	RoyalFlush
+)
+
+var pokerRankNames = []string{"High card", "Pair", "Two pair", "Three of a kind", "Straight",
+	"Flush", "Full house", "Four of a kind", "Straight flush", "Royal flush"}
+
+func (r PokerRank) String() string {
+	if r < 0 || int(r) >= len(pokerRankNames) {
+		return fmt.Sprintf("PokerRank(%d)", int(r))
+	}
+	return pokerRankNames[r]
+}
+
+// PokerHand is the best five cards that can be made from a set of cards
+type PokerHand struct {
+	Rank  PokerRank
+	Cards []Card
+
+	// Card ranks in the order they count when comparing hands of the same
+	// Rank, aces high (2 to 14)
+	order []int
+}
+
+// A card's rank for poker, aces high
+func pokerValue(c Card) int {
+	if c.Rank == "A" {
+		return 14
+	}
+	return rankIndex(c.Rank) + 1
+}
+
+// Finds the best poker hand among every five of the given cards (five to seven)
+func BestPokerHand(cards []Card) PokerHand {
+	var best PokerHand
+	found := false
+	five := make([]Card, 5)
+
+	var choose func(start, n int)
+	choose = func(start, n int) {
+		if n == 5 {
+			if h := evalFive(five); !found || h.Compare(best) > 0 {
+				best, found = h, true
+			}
+			return
+		}
+		for i := start; i <= len(cards)-(5-n); i++ {
+			five[n] = cards[i]
+			choose(i+1, n+1)
+		}
+	}
+	choose(0, 0)
+	return best
+}
+
+// Ranks exactly five cards
+func evalFive(cards []Card) PokerHand {
+	counts := map[int]int{}
+	flush := true
+	for _, c := range cards {
+		counts[pokerValue(c)]++
+		if c.Suit != cards[0].Suit {
+			flush = false
+		}
+	}
+
+	// Ranks ordered by how many of each there are, then by rank
+	order := make([]int, 0, len(counts))
+	for v := range counts {
+		order = append(order, v)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if counts[order[i]] != counts[order[j]] {
+			return counts[order[i]] > counts[order[j]]
+		}
+		return order[i] > order[j]
+	})
+
+	straight := len(order) == 5 && order[0]-order[4] == 4
+	if len(order) == 5 && order[0] == 14 && order[1] == 5 {
+		// A-2-3-4-5, where the ace plays low
+		straight = true
+		order = []int{5, 4, 3, 2, 1}
+	}
+
+	var rank PokerRank
+	switch {
+	case straight && flush && order[0] == 14:
+		rank = RoyalFlush
+	case straight && flush:
+		rank = StraightFlush
+	case counts[order[0]] == 4:
+		rank = FourOfAKind
+	case counts[order[0]] == 3 && counts[order[1]] == 2:
+		rank = FullHouse
+	case flush:
+		rank = PokerFlush
+	case straight:
+		rank = PokerStraight
+	case counts[order[0]] == 3:
+		rank = ThreeOfAKind
+	case counts[order[0]] == 2 && counts[order[1]] == 2:
+		rank = TwoPair
+	case counts[order[0]] == 2:
+		rank = OnePair
+	default:
+		rank = HighCard
+	}
+
+	// Show the cards in the order they count
+	place := map[int]int{}
+	for i, v := range order {
+		place[v] = i
+	}
+	if order[0] == 5 && straight {
+		place[14] = 4
+	}
+	sorted := append([]Card(nil), cards...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return place[pokerValue(sorted[i])] < place[pokerValue(sorted[j])]
+	})
+	return PokerHand{Rank: rank, Cards: sorted, order: order}
+}
+
+// Compares two hands: positive when h beats other, negative when it loses
+// and zero when they tie
+func (h PokerHand) Compare(other PokerHand) int {
+	if h.Rank != other.Rank {
+		return int(h.Rank - other.Rank)
+	}
+	for i := 0; i < len(h.order) && i < len(other.order); i++ {
+		if h.order[i] != other.order[i] {
+			return h.order[i] - other.order[i]
+		}
+	}
+	return 0
+}
+
+func (h PokerHand) String() string {
+	return fmt.Sprintf("%s (%s)", (&Hand{Cards: h.Cards}).String(), h.Rank)
+}
@@ -0,0 +1,80 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+// Parses cards written like "A♠ 10♥"
+func cards(s string) []Card {
+	var cs []Card
+	for _, f := range strings.Fields(s) {
+		r := []rune(f)
+		cs = append(cs, card(string(r[:len(r)-1]), string(r[len(r)-1])))
+	}
+	return cs
+}
+
+func TestBestPokerHand(t *testing.T) {
+	tests := []struct {
+		cards string
+		want  PokerRank
+	}{
+		{"A♠ K♠ Q♠ J♠ 10♠ 2♥ 3♦", RoyalFlush},
+		{"9♥ 8♥ 7♥ 6♥ 5♥ A♠ A♦", StraightFlush},
+		{"7♠ 7♥ 7♦ 7♣ K♠ K♥ 2♦", FourOfAKind},
+		{"7♠ 7♥ 7♦ K♣ K♠ 2♥ 3♦", FullHouse},
+		{"2♣ 9♣ J♣ 4♣ 6♣ A♦ A♥", PokerFlush},
+		{"A♠ 2♥ 3♦ 4♣ 5♠ 9♥ J♦", PokerStraight},
+		{"10♠ J♥ Q♦ K♣ A♠ 2♥ 2♦", PokerStraight},
+		{"5♠ 5♥ 5♦ K♣ 2♠ 9♥ J♦", ThreeOfAKind},
+		{"5♠ 5♥ K♦ K♣ 2♠ 2♥ J♦", TwoPair},
+		{"5♠ 5♥ K♦ Q♣ 2♠ 9♥ J♦", OnePair},
+		{"5♠ 7♥ K♦ Q♣ 2♠ 9♥ J♦", HighCard},
+		{"Q♠ K♥ A♦ 2♣ 3♠", HighCard}, // Straights don't wrap round the ace
+	}
+	for _, tt := range tests {
+		h := BestPokerHand(cards(tt.cards))
+		if h.Rank != tt.want || len(h.Cards) != 5 {
+			t.Errorf("BestPokerHand(%s) = %s with %d cards, want %s", tt.cards, h.Rank, len(h.Cards), tt.want)
+		}
+	}
+}
+
+func TestPokerHandCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int // Sign of a.Compare(b)
+	}{
+		{"A♠ A♥ 2♦ 3♣ 4♠ 9♥ J♦", "K♠ K♥ Q♦ J♣ 9♠ 7♥ 5♦", 1},
+		{"5♠ 5♥ K♦ 9♣ 7♠", "5♦ 5♣ Q♦ J♣ 10♠", 1},            // Kicker
+		{"A♠ 2♥ 3♦ 4♣ 5♠", "2♠ 3♥ 4♦ 5♣ 6♠", -1},            // The wheel is the lowest straight
+		{"K♠ K♥ 2♦ 2♣ 9♠", "Q♠ Q♥ J♦ J♣ A♠", 1},             // Top pair first
+		{"3♠ 3♥ 3♦ 2♣ 2♠", "2♠ 2♥ 2♦ A♣ A♠", 1},             // Trips decide a full house
+		{"A♠ K♥ Q♦ J♣ 9♠ 2♥ 3♦", "A♦ K♣ Q♥ J♠ 9♥ 4♣ 5♥", 0}, // Only the best five count
+	}
+	for _, tt := range tests {
+		got := BestPokerHand(cards(tt.a)).Compare(BestPokerHand(cards(tt.b)))
+		if got > 0 {
+			got = 1
+		} else if got < 0 {
+			got = -1
+		}
+		if got != tt.want {
+			t.Errorf("%s vs %s = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPokerHandCards(t *testing.T) {
+	tests := []struct{ cards, want string }{
+		{"2♠ K♥ 2♦ 9♣ K♠ 4♥ 3♦", "[K♥] [K♠] [2♠] [2♦] [9♣]"},
+		{"A♠ 2♥ 3♦ 4♣ 5♠ K♥ Q♦", "[5♠] [4♣] [3♦] [2♥] [A♠]"},
+	}
+	for _, tt := range tests {
+		h := BestPokerHand(cards(tt.cards))
+		if got := (&Hand{Cards: h.Cards}).String(); got != tt.want {
+			t.Errorf("BestPokerHand(%s).Cards = %s, want %s", tt.cards, got, tt.want)
+		}
+	}
+}
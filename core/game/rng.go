@@ -0,0 +1,29 @@
+package game
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// RNG is a source of the random numbers decks are shuffled with. A
+// *math/rand.Rand is one, so tests can shuffle from a fixed seed.
+type RNG interface {
+	// A uniform random number in [0, n)
+	Intn(n int) int
+}
+
+// CryptoRNG draws from crypto/rand, so no one can work out a shuffle from
+// the time it was made or the cards seen before
+type CryptoRNG struct{}
+
+func (CryptoRNG) Intn(n int) int {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		// The system's random source failing leaves nothing safe to deal with
+		panic("game: crypto/rand failed: " + err.Error())
+	}
+	return int(v.Int64())
+}
+
+// The RNG Shuffle uses
+var ShuffleRNG RNG = CryptoRNG{}
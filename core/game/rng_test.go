@@ -0,0 +1,37 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestShuffleWithSeedIsRepeatable(t *testing.T) {
+	a, b := NewDeck(), NewDeck()
+	a.ShuffleWith(rand.New(rand.NewSource(7)))
+	b.ShuffleWith(rand.New(rand.NewSource(7)))
+	for i := range a.Cards {
+		if a.Cards[i] != b.Cards[i] {
+			t.Fatalf("ShuffleWith() with the same seed differs at card %d", i)
+		}
+	}
+}
+
+func TestShuffleKeepsEveryCard(t *testing.T) {
+	deck := NewShoe(2, 0)
+	seen := map[Card]int{}
+	for _, c := range deck.Cards {
+		seen[c]++
+	}
+	if len(seen) != 52 {
+		t.Fatalf("shuffled shoe has %d distinct cards, want 52", len(seen))
+	}
+	for c, n := range seen {
+		if n != 2 {
+			t.Errorf("%s%s appears %d times in a 2-deck shoe", c.Rank, c.Suit, n)
+		}
+	}
+
+	if v := (CryptoRNG{}).Intn(1); v != 0 {
+		t.Errorf("CryptoRNG.Intn(1) = %d, want 0", v)
+	}
+}
@@ -0,0 +1,332 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Each test of an RNG audit fails when its p-value falls below this. It is
+// strict enough that a fair shuffle fails any one test only once in a
+// thousand audits.
+const RNGAuditAlpha = 0.001
+
+// RNGTest is the outcome of one statistical test of the shuffle
+type RNGTest struct {
+	Name      string
+	Detail    string // What was measured and how
+	Statistic float64
+	DF        int // Degrees of freedom, for chi-square tests
+	P         float64
+}
+
+func (t RNGTest) Passed() bool {
+	return t.P >= RNGAuditAlpha
+}
+
+// RNGAudit is a set of statistical tests of the shuffle and the deal
+type RNGAudit struct {
+	Shuffles int
+	Tests    []RNGTest
+}
+
+func (a *RNGAudit) Passed() bool {
+	for _, t := range a.Tests {
+		if !t.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// Formats the audit as a report with a PASS or FAIL line per test
+func (a *RNGAudit) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "RNG audit: %d shuffles, each test must reach p >= %g\n", a.Shuffles, RNGAuditAlpha)
+	for _, t := range a.Tests {
+		result := "PASS"
+		if !t.Passed() {
+			result = "FAIL"
+		}
+		stat := fmt.Sprintf("statistic %.4g", t.Statistic)
+		if t.DF > 0 {
+			stat = fmt.Sprintf("chi-square %.1f, df %d", t.Statistic, t.DF)
+		}
+		fmt.Fprintf(&b, "  %s  %-22s %s, p = %.4f\n        %s\n", result, t.Name, stat, t.P, t.Detail)
+	}
+	if a.Passed() {
+		b.WriteString("Result: PASS")
+	} else {
+		b.WriteString("Result: FAIL")
+	}
+	return b.String()
+}
+
+// Runs every test, shuffling a fresh deck the given number of times with
+// shuffle, normally (*Deck).Shuffle. The engine tests deal as many hands from
+// decks shuffled the same way, and the shoe test shuffles a tenth as many
+// shared table shoes.
+func AuditRNG(shuffles int, shuffle func(*Deck)) *RNGAudit {
+	audit := &RNGAudit{Shuffles: shuffles}
+
+	// Where each card ends up, and which rank follows which
+	positions := make([][]int64, 52)
+	for i := range positions {
+		positions[i] = make([]int64, 52)
+	}
+	pairs := make([]int64, 13*13)
+	aceOfSpades := make([]int64, 52)
+	repeats := 0
+	var last string
+
+	index := cardIndex()
+	for n := 0; n < shuffles; n++ {
+		deck := NewDeck()
+		shuffle(deck)
+		for pos, c := range deck.Cards {
+			positions[index[c.Rank+c.Suit]][pos]++
+		}
+		aceOfSpades[indexOfCard(deck, "A", "♠")]++
+		for i := 0; i+1 < len(deck.Cards); i += 2 {
+			pairs[rankIndex(deck.Cards[i].Rank)*13+rankIndex(deck.Cards[i+1].Rank)]++
+		}
+
+		order := fmt.Sprint(deck.Cards)
+		if order == last {
+			repeats++
+		}
+		last = order
+	}
+
+	// The top card is any of the 52 alike
+	expected := uniform(52, float64(shuffles))
+	top := make([]int64, 52)
+	for card := range positions {
+		top[card] = positions[card][0]
+	}
+	audit.Tests = append(audit.Tests, chiSquareTest("Top card", "Which card is dealt first", top, expected))
+
+	// Every card is as likely to be at every position
+	var all []int64
+	for card := range positions {
+		all = append(all, positions[card]...)
+	}
+	test := chiSquareTest("Card positions", "Where each card lands in the deck (52 x 52)", all, uniform(52*52, float64(shuffles)*52))
+	// Every shuffle fills each row and column once, so only 51 x 51 cells are
+	// free, and the statistic runs 52/51 times a chi-square with that many
+	test.DF = 51 * 51
+	test.P = chiSquareP(test.Statistic*51/52, test.DF)
+	audit.Tests = append(audit.Tests, test)
+
+	// Dealt pairs of cards follow each other as drawing without replacement says
+	pairExpected := make([]float64, 13*13)
+	total := float64(shuffles * 26)
+	for a := 0; a < 13; a++ {
+		for b := 0; b < 13; b++ {
+			p := 4.0 / 52 * 4 / 51
+			if a == b {
+				p = 4.0 / 52 * 3 / 51
+			}
+			pairExpected[a*13+b] = p * total
+		}
+	}
+	audit.Tests = append(audit.Tests, chiSquareTest("Consecutive ranks", "Rank of each card against the one after it", pairs, pairExpected))
+
+	audit.Tests = append(audit.Tests, ksTest("Ace of spades (KS)", "Position of the ace of spades against a uniform spread", aceOfSpades))
+
+	// With 52! orders a fair shuffle never repeats itself
+	repeated := RNGTest{Name: "Repeated shuffles", Detail: "Shuffles that came out in the same order as the one before",
+		Statistic: float64(repeats), P: 1}
+	if repeats > 0 {
+		repeated.P = 0
+	}
+	audit.Tests = append(audit.Tests, repeated)
+
+	audit.Tests = append(audit.Tests, auditShoe(max(shuffles/10, 1), shuffle)...)
+	audit.Tests = append(audit.Tests, auditDeal(shuffles, shuffle)...)
+	return audit
+}
+
+// Checks the first card out of six-deck shoes like those at shared tables
+func auditShoe(shoes int, shuffle func(*Deck)) []RNGTest {
+	index := cardIndex()
+	first := make([]int64, 52)
+	for n := 0; n < shoes; n++ {
+		shoe := &Deck{}
+		for i := 0; i < ShoeDecks; i++ {
+			shoe.Cards = append(shoe.Cards, NewDeck().Cards...)
+		}
+		shuffle(shoe)
+		c := shoe.Cards[0]
+		first[index[c.Rank+c.Suit]]++
+	}
+	return []RNGTest{chiSquareTest("Shoe first card", fmt.Sprintf("First card out of %d %d-deck shoes", shoes, ShoeDecks),
+		first, uniform(52, float64(shoes)))}
+}
+
+// Deals hands through the game engine and checks what comes out: dealer up
+// cards by rank and how often the player is dealt a natural
+func auditDeal(hands int, shuffle func(*Deck)) []RNGTest {
+	upcards := make([]int64, 13)
+	naturals := 0
+	for n := 0; n < hands; n++ {
+		g := NewGame()
+		shuffle(g.Deck)
+		if err := g.PlaceBetNoShuffle(100); err != nil {
+			continue
+		}
+		upcards[rankIndex(g.DealerHand.Cards[0].Rank)]++
+		if g.PlayerHand.IsBlackjack() {
+			naturals++
+		}
+	}
+
+	// An ace and a ten-value card, in either order
+	p := 2 * 4.0 / 52 * 16 / 51
+	mean := p * float64(hands)
+	z := (float64(naturals) - mean) / math.Sqrt(mean*(1-p))
+
+	return []RNGTest{
+		chiSquareTest("Dealer up card", "Rank of the dealer's up card in hands dealt by the engine", upcards, uniform(13, float64(hands))),
+		{
+			Name:      "Player naturals",
+			Detail:    fmt.Sprintf("%d blackjacks dealt to the player in %d hands, %.0f expected", naturals, hands, mean),
+			Statistic: z,
+			P:         math.Erfc(math.Abs(z) / math.Sqrt2),
+		},
+	}
+}
+
+var auditRanks = []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
+
+func rankIndex(rank string) int {
+	for i, r := range auditRanks {
+		if r == rank {
+			return i
+		}
+	}
+	return 0
+}
+
+// Numbers the 52 cards in the order of a new deck
+func cardIndex() map[string]int {
+	index := make(map[string]int, 52)
+	for i, c := range NewDeck().Cards {
+		index[c.Rank+c.Suit] = i
+	}
+	return index
+}
+
+func indexOfCard(d *Deck, rank, suit string) int {
+	for i, c := range d.Cards {
+		if c.Rank == rank && c.Suit == suit {
+			return i
+		}
+	}
+	return 0
+}
+
+func uniform(n int, total float64) []float64 {
+	expected := make([]float64, n)
+	for i := range expected {
+		expected[i] = total / float64(n)
+	}
+	return expected
+}
+
+// Pearson's chi-square test of observed counts against expected ones
+func chiSquareTest(name, detail string, observed []int64, expected []float64) RNGTest {
+	stat := 0.0
+	for i, o := range observed {
+		d := float64(o) - expected[i]
+		stat += d * d / expected[i]
+	}
+	df := len(observed) - 1
+	return RNGTest{Name: name, Detail: detail, Statistic: stat, DF: df, P: chiSquareP(stat, df)}
+}
+
+// The chance of a chi-square statistic at least this large
+func chiSquareP(stat float64, df int) float64 {
+	return gammaQ(float64(df)/2, stat/2)
+}
+
+// Kolmogorov-Smirnov test of positions 0-51 against the discrete uniform
+// distribution. The asymptotic p-value is conservative for discrete data.
+func ksTest(name, detail string, counts []int64) RNGTest {
+	n := 0.0
+	for _, c := range counts {
+		n += float64(c)
+	}
+	d, cum := 0.0, 0.0
+	for i, c := range counts {
+		cum += float64(c)
+		d = math.Max(d, math.Abs(cum/n-float64(i+1)/float64(len(counts))))
+	}
+
+	// Kolmogorov's distribution with Stephens' correction for sample size
+	sqrtN := math.Sqrt(n)
+	lambda := (sqrtN + 0.12 + 0.11/sqrtN) * d
+	p := 0.0
+	for k := 1; k <= 100; k++ {
+		term := 2 * math.Exp(-2*float64(k*k)*lambda*lambda)
+		if k%2 == 0 {
+			term = -term
+		}
+		p += term
+		if math.Abs(term) < 1e-12 {
+			break
+		}
+	}
+	if lambda < 0.2 {
+		// The series doesn't converge this close to zero, where the
+		// distribution is practically 1
+		p = 1
+	}
+	return RNGTest{Name: name, Detail: detail, Statistic: d, P: math.Min(math.Max(p, 0), 1)}
+}
+
+// Upper regularized incomplete gamma function Q(a, x)
+func gammaQ(a, x float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	lg, _ := math.Lgamma(a)
+	if x < a+1 {
+		// Series for the lower function P(a, x)
+		sum, term := 1/a, 1/a
+		for n := 1.0; n < 1000; n++ {
+			term *= x / (a + n)
+			sum += term
+			if term < sum*1e-15 {
+				break
+			}
+		}
+		return 1 - sum*math.Exp(-x+a*math.Log(x)-lg)
+	}
+
+	// Continued fraction (Lentz's method)
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1.0; i < 1000; i++ {
+		an := -i * (i - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-lg) * h
+}
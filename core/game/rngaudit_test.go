@@ -0,0 +1,71 @@
+package game
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// A fair shuffle with a fixed seed, so the test doesn't fail one run in a thousand
+func seededShuffle() func(*Deck) {
+	r := rand.New(rand.NewSource(1))
+	return func(d *Deck) { d.ShuffleWith(r) }
+}
+
+func TestAuditRNGPassesFairShuffle(t *testing.T) {
+	audit := AuditRNG(20000, seededShuffle())
+	if !audit.Passed() {
+		t.Errorf("AuditRNG() failed a fair shuffle:\n%s", audit)
+	}
+	if len(audit.Tests) < 8 {
+		t.Errorf("AuditRNG() ran %d tests, want at least 8", len(audit.Tests))
+	}
+}
+
+func TestAuditRNGCatchesBadShuffles(t *testing.T) {
+	failed := func(audit *RNGAudit, name string) bool {
+		for _, test := range audit.Tests {
+			if test.Name == name {
+				return !test.Passed()
+			}
+		}
+		t.Fatalf("no %q test", name)
+		return false
+	}
+
+	// Not shuffling at all
+	audit := AuditRNG(2000, func(*Deck) {})
+	if audit.Passed() || !failed(audit, "Repeated shuffles") || !failed(audit, "Top card") {
+		t.Errorf("AuditRNG() should fail a deck that is never shuffled:\n%s", audit)
+	}
+
+	// Cutting the deck moves every card evenly but keeps neighbours together
+	r := rand.New(rand.NewSource(1))
+	cut := func(d *Deck) {
+		n := r.Intn(len(d.Cards))
+		d.Cards = append(d.Cards[n:], d.Cards[:n]...)
+	}
+	audit = AuditRNG(5000, cut)
+	if !failed(audit, "Consecutive ranks") {
+		t.Errorf("AuditRNG() should catch a cut that leaves cards in order:\n%s", audit)
+	}
+}
+
+func TestChiSquareP(t *testing.T) {
+	tests := []struct {
+		stat float64
+		df   int
+		want float64
+	}{
+		{3.841, 1, 0.05},
+		{6.635, 1, 0.01},
+		{18.307, 10, 0.05},
+		{10.828, 1, 0.001},
+		{124.342, 100, 0.05},
+	}
+	for _, tt := range tests {
+		if got := chiSquareP(tt.stat, tt.df); math.Abs(got-tt.want) > tt.want*0.01 {
+			t.Errorf("chiSquareP(%v, %d) = %v, want %v", tt.stat, tt.df, got, tt.want)
+		}
+	}
+}
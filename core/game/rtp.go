@@ -0,0 +1,90 @@
+package game
+
+import (
+	"math"
+	"sync"
+)
+
+// House edge under basic strategy for the default rules (a fresh single deck
+// each hand, S17, 3:2, late surrender, doubling on any two cards, no splits)
+const baseHouseEdge = 0.005
+
+// Extra house edge from dealing out of a shoe of 2 to 8 decks
+var deckEdge = map[int]float64{2: 0.0029, 3: 0.0037, 4: 0.0042, 5: 0.0045, 6: 0.0048, 7: 0.0049, 8: 0.0050}
+
+// Returns the share of wagers a player using basic strategy gets back under
+// these rules, dealt from defaultDecks unless they set the decks. Players who
+// stray from basic strategy get back less.
+func (r Rules) ExpectedRTP(defaultDecks int) float64 {
+	decks := r.Decks
+	if decks == 0 {
+		decks = defaultDecks
+	}
+	edge := baseHouseEdge + deckEdge[decks]
+	if r.DealerHitsSoft17 {
+		edge += 0.002
+	}
+	if r.NoSurrender {
+		edge += 0.0005
+	}
+	// A natural comes about 4.8% of the time and pays short of 3:2 by the difference
+	pays := r.BlackjackPayout()
+	edge += 0.048 * (1.5 - float64(pays.Win)/float64(pays.Stake))
+	return 1 - edge
+}
+
+// The share of wagers expected back under the game's rules
+func (g *Game) ExpectedRTP() float64 {
+	if g.sharedDealer {
+		return g.Rules.ExpectedRTP(ShoeDecks)
+	}
+	return g.Rules.ExpectedRTP(1)
+}
+
+// RTPTracker compares the return to player of settled rounds with what the
+// rules lead us to expect. It is safe for concurrent use.
+type RTPTracker struct {
+	mu       sync.Mutex
+	rounds   int64
+	wagered  float64
+	paid     float64
+	expected float64 // Payouts expected from each round's rules
+	variance float64 // Sum of squared differences between payout and expected payout
+}
+
+// Adds a settled round that was expected to pay back expectedRTP of the wager
+func (t *RTPTracker) Record(expectedRTP float64, wagered, payout int64) {
+	expected := expectedRTP * float64(wagered)
+	diff := float64(payout) - expected
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rounds++
+	t.wagered += float64(wagered)
+	t.paid += float64(payout)
+	t.expected += expected
+	t.variance += diff * diff
+}
+
+// RTPStats is a snapshot of an RTPTracker
+type RTPStats struct {
+	Rounds   int64
+	Observed float64 // Share of wagers paid back
+	Expected float64
+	Sigma    float64 // Standard errors the observed RTP lies above (or below, when negative) the expected one
+}
+
+func (t *RTPTracker) Stats() RTPStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := RTPStats{Rounds: t.rounds}
+	if t.wagered > 0 {
+		stats.Observed = t.paid / t.wagered
+		stats.Expected = t.expected / t.wagered
+	}
+	if t.variance > 0 {
+		stats.Sigma = (t.paid - t.expected) / math.Sqrt(t.variance)
+	}
+	return stats
+}
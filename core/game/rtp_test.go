@@ -0,0 +1,62 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExpectedRTP(t *testing.T) {
+	base := Rules{}.ExpectedRTP(1)
+	if base < 0.99 || base > 1 {
+		t.Fatalf("ExpectedRTP() for the default rules = %v, want just under 1", base)
+	}
+
+	worse := []Rules{
+		{DealerHitsSoft17: true},
+		{NoSurrender: true},
+		{BlackjackPays: Pays6to5},
+		{Decks: 6},
+	}
+	for _, r := range worse {
+		if got := r.ExpectedRTP(1); got >= base {
+			t.Errorf("ExpectedRTP() for %s = %v, want less than %v", r.Describe(1), got, base)
+		}
+	}
+
+	if got, want := (Rules{}).ExpectedRTP(ShoeDecks), (Rules{Decks: ShoeDecks}).ExpectedRTP(1); got != want {
+		t.Errorf("ExpectedRTP(%d) = %v, want %v", ShoeDecks, got, want)
+	}
+
+	// 6:5 costs the player 1.5 - 1.2 of every natural
+	if got := base - (Rules{BlackjackPays: Pays6to5}).ExpectedRTP(1); math.Abs(got-0.048*0.3) > 1e-9 {
+		t.Errorf("6:5 costs %v, want %v", got, 0.048*0.3)
+	}
+}
+
+func TestRTPTracker(t *testing.T) {
+	var tracker RTPTracker
+	if stats := tracker.Stats(); stats.Rounds != 0 || stats.Observed != 0 || stats.Sigma != 0 {
+		t.Errorf("Stats() with no rounds = %+v", stats)
+	}
+
+	// Even money wins and losses in turn pay back exactly what was wagered
+	for i := 0; i < 100; i++ {
+		tracker.Record(1, 100, int64(200*(i%2)))
+	}
+	stats := tracker.Stats()
+	if stats.Rounds != 100 || stats.Observed != 1 || stats.Expected != 1 || stats.Sigma != 0 {
+		t.Errorf("Stats() = %+v, want 100 rounds at exactly the expected RTP", stats)
+	}
+
+	// Paying every hand is far beyond chance
+	for i := 0; i < 100; i++ {
+		tracker.Record(1, 100, 200)
+	}
+	stats = tracker.Stats()
+	if stats.Observed != 1.5 {
+		t.Errorf("Observed = %v, want 1.5", stats.Observed)
+	}
+	if stats.Sigma < 5 {
+		t.Errorf("Sigma = %v, want a large positive deviation", stats.Sigma)
+	}
+}
@@ -0,0 +1,400 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Players who can sit at one shared table
+const MaxSeats = 5
+
+// Decks in a shared table's shoe unless its rules say otherwise; it is
+// reshuffled between rounds once fewer than a quarter of the cards are left
+const ShoeDecks = 6
+
+// Actions a seated player can take on their turn, as listed by GetValidActions
+const (
+	ActionHit        = "HIT"
+	ActionStand      = "STAND"
+	ActionDoubleDown = "DOUBLEDOWN"
+	ActionSurrender  = "SURRENDER"
+)
+
+type Seat struct {
+	UserID int
+	Name   string
+	Game   *Game // This round's hand; nil while the player sits the round out
+	Away   bool  // Left mid-hand; the hand is stood when its turn comes
+}
+
+// Whether the seat's hand still needs the player to act
+func (s *Seat) InPlay() bool {
+	return s.Game != nil && s.Game.Phase == PhasePlayerTurn
+}
+
+// SharedTable is a blackjack table where up to MaxSeats players bet into the
+// same round, act in seat order and are all resolved against one dealer hand.
+// Each seated bettor gets a Game sharing the table's shoe and dealer hand, so
+// settlement works the same as for single-player games.
+type SharedTable struct {
+	Table  Table
+	Shoe   *Deck
+	Dealer *Hand
+	Seats  [MaxSeats]*Seat
+
+	// PhaseWaitingForBet while bets are taken, PhasePlayerTurn once dealt and
+	// PhaseGameOver when every hand is resolved, until NewRound
+	Phase GamePhase
+	Turn  int // Index of the seat to act during PhasePlayerTurn, -1 otherwise
+}
+
+func NewSharedTable(table Table) *SharedTable {
+	t := &SharedTable{Table: table, Turn: -1}
+	t.NewRound()
+	return t
+}
+
+// Clears the last round's hands, frees seats of players who left mid-hand and
+// opens betting
+func (t *SharedTable) NewRound() {
+	for i, seat := range t.Seats {
+		if seat == nil {
+			continue
+		}
+		if seat.Away {
+			t.Seats[i] = nil
+			continue
+		}
+		seat.Game = nil
+	}
+
+	decks := t.Table.Rules.Decks
+	if decks == 0 {
+		decks = ShoeDecks
+	}
+	if t.Shoe == nil || t.Shoe.PastCutCard() {
+		t.Shoe = NewShoe(decks, t.Table.Rules.Penetration)
+	} else {
+		t.Shoe.Discard()
+	}
+
+	t.Dealer = NewHand()
+	t.Phase = PhaseWaitingForBet
+	t.Turn = -1
+}
+
+// Seats a player in the first free seat and returns its index
+func (t *SharedTable) Sit(userID int, name string) (int, error) {
+	if t.SeatOf(userID) >= 0 {
+		return 0, fmt.Errorf("you are already seated at this table")
+	}
+
+	for i, seat := range t.Seats {
+		if seat == nil {
+			t.Seats[i] = &Seat{UserID: userID, Name: name}
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("table is full")
+}
+
+// Returns the index of the user's seat, or -1 when they aren't seated
+func (t *SharedTable) SeatOf(userID int) int {
+	for i, seat := range t.Seats {
+		if seat != nil && seat.UserID == userID && !seat.Away {
+			return i
+		}
+	}
+	return -1
+}
+
+// Counts occupied seats, including players who left a hand still in play
+func (t *SharedTable) Occupied() int {
+	n := 0
+	for _, seat := range t.Seats {
+		if seat != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Gets a player up from their seat. A hand still in play stays on the table,
+// marked away, and is stood when its turn comes; the seat is freed by the next
+// NewRound. Returns a bet placed for a round that hasn't been dealt yet, which
+// the caller should refund.
+func (t *SharedTable) Leave(seat int) (unplayedBet int64) {
+	s := t.Seats[seat]
+	if s == nil {
+		return 0
+	}
+
+	if !s.InPlay() {
+		if s.Game != nil && s.Game.Phase == PhaseWaitingForBet {
+			unplayedBet = s.Game.Bet
+		}
+		t.Seats[seat] = nil
+		return unplayedBet
+	}
+
+	s.Away = true
+	if t.Turn == seat {
+		s.Game.Stand()
+		t.advance()
+	}
+	return 0
+}
+
+// Records a bet for the coming round, checked against rules (which may carry
+// the player's own limits)
+func (t *SharedTable) PlaceBet(seat int, amount int64, rules Rules) error {
+	s := t.Seats[seat]
+	if s == nil {
+		return fmt.Errorf("you are not seated at this table")
+	}
+	if t.Phase != PhaseWaitingForBet {
+		return fmt.Errorf("betting is closed, wait for the next round")
+	}
+	if s.Game != nil {
+		return fmt.Errorf("you have already bet this round")
+	}
+	if err := rules.CheckBet(amount); err != nil {
+		return err
+	}
+
+	g := NewGameWithRules(rules)
+	g.Deck = t.Shoe
+	g.DealerHand = t.Dealer
+	g.Bet = amount
+	g.sharedDealer = true
+	s.Game = g
+
+	return nil
+}
+
+// Counts seats with a bet in for the coming round
+func (t *SharedTable) Bettors() int {
+	n := 0
+	for _, seat := range t.Seats {
+		if seat != nil && seat.Game != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Whether every seated player has bet, so the round can be dealt early
+func (t *SharedTable) AllBet() bool {
+	for _, seat := range t.Seats {
+		if seat != nil && seat.Game == nil {
+			return false
+		}
+	}
+	return t.Bettors() > 0
+}
+
+// Closes betting and deals two cards to every bettor and the dealer. Players
+// with a blackjack are done at once; a dealer blackjack ends the round.
+func (t *SharedTable) Deal() error {
+	if t.Phase != PhaseWaitingForBet {
+		return fmt.Errorf("cannot deal in current phase")
+	}
+	if t.Bettors() == 0 {
+		return fmt.Errorf("no bets placed")
+	}
+
+	for pass := 0; pass < 2; pass++ {
+		for _, seat := range t.Seats {
+			if seat == nil || seat.Game == nil {
+				continue
+			}
+			card, err := t.Shoe.Draw()
+			if err != nil {
+				return fmt.Errorf("failed to deal: %w", err)
+			}
+			seat.Game.PlayerHand.AddCard(card)
+		}
+
+		card, err := t.Shoe.Draw()
+		if err != nil {
+			return fmt.Errorf("failed to deal: %w", err)
+		}
+		t.Dealer.AddCard(card)
+	}
+
+	for _, seat := range t.Seats {
+		if seat != nil && seat.Game != nil {
+			seat.Game.checkNaturals()
+		}
+	}
+
+	t.Phase = PhasePlayerTurn
+	t.Turn = -1
+	t.advance()
+
+	return nil
+}
+
+// Plays an action for the seat whose turn it is
+func (t *SharedTable) Act(seat int, action string) error {
+	if t.Phase != PhasePlayerTurn {
+		return fmt.Errorf("no hand in play at this table")
+	}
+	if seat != t.Turn {
+		return fmt.Errorf("it's not your turn")
+	}
+
+	g := t.Seats[seat].Game
+	var err error
+	switch action {
+	case ActionHit:
+		err = g.Hit()
+	case ActionStand:
+		err = g.Stand()
+	case ActionDoubleDown:
+		err = g.DoubleDown()
+	case ActionSurrender:
+		err = g.Surrender()
+	default:
+		err = fmt.Errorf("unknown action %q", action)
+	}
+	if err != nil {
+		return err
+	}
+
+	if g.Phase != PhasePlayerTurn {
+		t.advance()
+	}
+	return nil
+}
+
+// Moves the turn to the next seat with a hand in play, standing hands of
+// players who left, and resolves the round when nobody is left to act
+func (t *SharedTable) advance() {
+	for i := t.Turn + 1; i < MaxSeats; i++ {
+		seat := t.Seats[i]
+		if seat == nil || !seat.InPlay() {
+			continue
+		}
+		if seat.Away {
+			seat.Game.Stand()
+			continue
+		}
+		t.Turn = i
+		return
+	}
+
+	t.finish()
+}
+
+// Plays the dealer's hand once for every seat still standing and settles them
+func (t *SharedTable) finish() {
+	t.Turn = -1
+	t.Phase = PhaseGameOver
+
+	waiting := false
+	for _, seat := range t.Seats {
+		if seat != nil && seat.Game != nil && seat.Game.Phase == PhaseDealerTurn {
+			waiting = true
+		}
+	}
+	if !waiting {
+		return
+	}
+
+	err := drawDealerHand(t.Shoe, t.Dealer, t.Table.Rules.DealerHitsSoft17)
+	for _, seat := range t.Seats {
+		if seat == nil || seat.Game == nil || seat.Game.Phase != PhaseDealerTurn {
+			continue
+		}
+		seat.Game.Phase = PhaseGameOver
+		if err != nil {
+			// Shoe exhausted - treat as a push to avoid corruption
+			seat.Game.Result = ResultPush
+		} else {
+			seat.Game.determineWinner()
+		}
+	}
+}
+
+// Renders the table for the player in viewer's seat (-1 for an onlooker). The
+// dealer's hole card stays hidden until every player has acted.
+func (t *SharedTable) State(viewer int) string {
+	state := fmt.Sprintf("Table: %s\n", t.Table.Name)
+	if t.Shoe != nil {
+		state += fmt.Sprintf("Shoe: %d cards left\n", t.Shoe.Remaining())
+	}
+
+	switch {
+	case len(t.Dealer.Cards) == 0:
+		state += "Dealer Hand: (no cards dealt)\n"
+	case t.Phase == PhasePlayerTurn:
+		first := t.Dealer.Cards[0]
+		state += fmt.Sprintf("Dealer Hand: [%s%s] [Hidden]\n", first.Rank, first.Suit)
+	default:
+		state += fmt.Sprintf("Dealer Hand: %s (Value: %d)\n", t.Dealer.String(), t.Dealer.Value())
+	}
+
+	for i, seat := range t.Seats {
+		if seat == nil {
+			continue
+		}
+
+		marker := " "
+		if i == t.Turn {
+			marker = ">"
+		}
+		name := seat.Name
+		if i == viewer {
+			name += " (you)"
+		}
+		state += fmt.Sprintf("%s Seat %d %s: %s\n", marker, i+1, name, seatSummary(seat, t.Phase))
+	}
+
+	return strings.TrimSuffix(state, "\n")
+}
+
+func seatSummary(seat *Seat, phase GamePhase) string {
+	g := seat.Game
+	switch {
+	case g == nil && phase == PhaseWaitingForBet:
+		return "no bet yet"
+	case g == nil:
+		return "sitting out"
+	case len(g.PlayerHand.Cards) == 0:
+		return fmt.Sprintf("bet $%.2f", float64(g.Bet)/100)
+	}
+
+	summary := fmt.Sprintf("%s (Value: %d) Bet: $%.2f", g.PlayerHand.String(), g.PlayerHand.Value(), float64(g.Bet)/100)
+	if g.Phase == PhaseGameOver {
+		summary += fmt.Sprintf(" - %s, paid $%.2f", SeatResult(g), float64(g.CalculatePayout())/100)
+	} else if g.Phase == PhaseDealerTurn {
+		summary += " - standing"
+	}
+	if seat.Away {
+		summary += " (left)"
+	}
+	return summary
+}
+
+// Short third-person result for one seat's hand, e.g. "Bust"
+func SeatResult(g *Game) string {
+	switch g.Result {
+	case ResultPlayerBlackjack:
+		return "Blackjack"
+	case ResultPlayerWin:
+		return "Win"
+	case ResultDealerWin:
+		if g.PlayerHand.IsBusted() {
+			return "Bust"
+		}
+		return "Loss"
+	case ResultPush:
+		return "Push"
+	case ResultSurrender:
+		return "Surrendered"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,219 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func card(rank, suit string) Card {
+	values := map[string]int{"A": 11, "J": 10, "Q": 10, "K": 10, "10": 10}
+	v, ok := values[rank]
+	if !ok {
+		v = int(rank[0] - '0')
+	}
+	return Card{Rank: rank, Suit: suit, Value: v}
+}
+
+// Seats players and stacks the shoe so the next cards come out in order
+func newStackedTable(t *testing.T, players int, cards ...Card) *SharedTable {
+	t.Helper()
+	table := NewSharedTable(Tables[0])
+	table.Shoe = &Deck{Cards: cards}
+	for i := 0; i < players; i++ {
+		if _, err := table.Sit(i+1, string(rune('a'+i))); err != nil {
+			t.Fatalf("Sit() error = %v", err)
+		}
+	}
+	return table
+}
+
+func TestSharedTableRound(t *testing.T) {
+	// Dealt seat 1, seat 2, dealer, seat 1, seat 2, dealer, then hits
+	table := newStackedTable(t, 2,
+		card("10", "♠"), card("10", "♥"), card("10", "♦"),
+		card("7", "♠"), card("6", "♥"), card("8", "♦"),
+		card("5", "♣"))
+
+	if err := table.Deal(); err == nil {
+		t.Error("Deal() should fail without bets")
+	}
+
+	for seat, bet := range []int64{1000, 2000} {
+		if err := table.PlaceBet(seat, bet, table.Table.Rules); err != nil {
+			t.Fatalf("PlaceBet() error = %v", err)
+		}
+	}
+	if err := table.PlaceBet(0, 1000, table.Table.Rules); err == nil {
+		t.Error("PlaceBet() should reject a second bet in the same round")
+	}
+	if !table.AllBet() {
+		t.Error("AllBet() = false, want true")
+	}
+
+	if err := table.Deal(); err != nil {
+		t.Fatalf("Deal() error = %v", err)
+	}
+	if table.Phase != PhasePlayerTurn || table.Turn != 0 {
+		t.Fatalf("After deal phase = %s, turn = %d, want PLAYER_TURN, 0", table.Phase, table.Turn)
+	}
+
+	if err := table.Act(1, ActionHit); err == nil {
+		t.Error("Act() should reject a player acting out of turn")
+	}
+	if state := table.State(1); !strings.Contains(state, "[Hidden]") {
+		t.Errorf("State() should hide the hole card while players act:\n%s", state)
+	}
+
+	if err := table.Act(0, ActionStand); err != nil {
+		t.Fatalf("Act(stand) error = %v", err)
+	}
+	if table.Turn != 1 || table.Seats[0].Game.Phase != PhaseDealerTurn {
+		t.Fatalf("Standing should pass the turn and wait for the dealer, turn = %d", table.Turn)
+	}
+
+	if err := table.Act(1, ActionHit); err != nil {
+		t.Fatalf("Act(hit) error = %v", err)
+	}
+	if err := table.Act(1, ActionStand); err != nil {
+		t.Fatalf("Act(stand) error = %v", err)
+	}
+
+	if table.Phase != PhaseGameOver {
+		t.Fatalf("Phase = %s, want GAME_OVER once everyone acted", table.Phase)
+	}
+	if table.Dealer.Value() != 18 {
+		t.Errorf("Dealer value = %d, want 18", table.Dealer.Value())
+	}
+	if r := table.Seats[0].Game.Result; r != ResultDealerWin {
+		t.Errorf("Seat 1 result = %s, want DEALER_WIN", r)
+	}
+	if g := table.Seats[1].Game; g.Result != ResultPlayerWin || g.CalculatePayout() != 4000 {
+		t.Errorf("Seat 2 result = %s paying %d, want PLAYER_WIN paying 4000", g.Result, g.CalculatePayout())
+	}
+	if state := table.State(0); strings.Contains(state, "[Hidden]") || !strings.Contains(state, "a (you)") {
+		t.Errorf("State() after the round should show every card and mark the viewer:\n%s", state)
+	}
+
+	table.NewRound()
+	if table.Phase != PhaseWaitingForBet || table.Bettors() != 0 || len(table.Dealer.Cards) != 0 {
+		t.Error("NewRound() should clear hands and reopen betting")
+	}
+}
+
+func TestSharedTableDealerBlackjack(t *testing.T) {
+	table := newStackedTable(t, 2,
+		card("A", "♠"), card("9", "♥"), card("A", "♦"),
+		card("K", "♠"), card("9", "♣"), card("Q", "♦"))
+
+	for seat := range 2 {
+		if err := table.PlaceBet(seat, 1000, table.Table.Rules); err != nil {
+			t.Fatalf("PlaceBet() error = %v", err)
+		}
+	}
+	if err := table.Deal(); err != nil {
+		t.Fatalf("Deal() error = %v", err)
+	}
+
+	if table.Phase != PhaseGameOver {
+		t.Fatalf("A dealer blackjack should end the round, phase = %s", table.Phase)
+	}
+	if r := table.Seats[0].Game.Result; r != ResultPush {
+		t.Errorf("Player blackjack against dealer blackjack = %s, want PUSH", r)
+	}
+	if r := table.Seats[1].Game.Result; r != ResultDealerWin {
+		t.Errorf("Seat 2 result = %s, want DEALER_WIN", r)
+	}
+}
+
+func TestSharedTableSkipsBlackjacksAndNonBettors(t *testing.T) {
+	table := newStackedTable(t, 3,
+		card("A", "♠"), card("10", "♥"), card("10", "♦"),
+		card("K", "♠"), card("7", "♥"), card("7", "♦"))
+
+	// Seat 2 sits this round out
+	for _, seat := range []int{0, 2} {
+		if err := table.PlaceBet(seat, 1000, table.Table.Rules); err != nil {
+			t.Fatalf("PlaceBet() error = %v", err)
+		}
+	}
+	if table.AllBet() {
+		t.Error("AllBet() = true with a seated player yet to bet")
+	}
+	if err := table.Deal(); err != nil {
+		t.Fatalf("Deal() error = %v", err)
+	}
+
+	if table.Seats[0].Game.Result != ResultPlayerBlackjack {
+		t.Errorf("Seat 1 result = %s, want PLAYER_BLACKJACK", table.Seats[0].Game.Result)
+	}
+	if table.Turn != 2 {
+		t.Errorf("Turn = %d, want 2 (seat 1 has blackjack, seat 2 didn't bet)", table.Turn)
+	}
+	if !strings.Contains(table.State(-1), "sitting out") {
+		t.Error("State() should show seat 2 sitting out")
+	}
+}
+
+func TestSharedTableLeave(t *testing.T) {
+	table := newStackedTable(t, 3,
+		card("10", "♠"), card("10", "♥"), card("10", "♦"),
+		card("7", "♠"), card("6", "♥"), card("8", "♦"))
+
+	for seat := range 3 {
+		if err := table.PlaceBet(seat, 1000, table.Table.Rules); err != nil {
+			t.Fatalf("PlaceBet() error = %v", err)
+		}
+	}
+
+	// Leaving before the deal hands the bet back
+	if refund := table.Leave(2); refund != 1000 || table.Seats[2] != nil {
+		t.Errorf("Leave() before the deal = %d, want refund of 1000 and a free seat", refund)
+	}
+
+	if err := table.Deal(); err != nil {
+		t.Fatalf("Deal() error = %v", err)
+	}
+
+	// Leaving on your turn stands the hand and keeps it in play
+	if refund := table.Leave(0); refund != 0 {
+		t.Errorf("Leave() mid-hand refund = %d, want 0", refund)
+	}
+	if table.Turn != 1 || table.Seats[0] == nil || table.Seats[0].Game.Phase != PhaseDealerTurn {
+		t.Fatalf("Leaving on your turn should stand the hand, turn = %d", table.Turn)
+	}
+	if table.SeatOf(1) != -1 {
+		t.Error("SeatOf() should not report a seat the player left")
+	}
+
+	if err := table.Act(1, ActionStand); err != nil {
+		t.Fatalf("Act() error = %v", err)
+	}
+	if table.Phase != PhaseGameOver || table.Seats[0].Game.Result != ResultDealerWin {
+		t.Errorf("The away hand should still be settled, result = %s", table.Seats[0].Game.Result)
+	}
+
+	table.NewRound()
+	if table.Seats[0] != nil || table.Occupied() != 1 {
+		t.Errorf("NewRound() should free the away seat, occupied = %d", table.Occupied())
+	}
+}
+
+func TestSharedTableSeating(t *testing.T) {
+	table := NewSharedTable(Tables[0])
+	if len(table.Shoe.Cards) != 52*ShoeDecks {
+		t.Errorf("Shoe has %d cards, want %d", len(table.Shoe.Cards), 52*ShoeDecks)
+	}
+
+	for i := 0; i < MaxSeats; i++ {
+		seat, err := table.Sit(i+1, "player")
+		if err != nil || seat != i {
+			t.Fatalf("Sit() = %d, %v, want seat %d", seat, err, i)
+		}
+	}
+	if _, err := table.Sit(1, "player"); err == nil {
+		t.Error("Sit() should reject a player who is already seated")
+	}
+	if _, err := table.Sit(99, "late"); err == nil {
+		t.Error("Sit() should reject a full table")
+	}
+}
@@ -0,0 +1,42 @@
+package game
+
+// Range of penetration a table can set: the percent of a shoe dealt before
+// the cut card comes out and it's reshuffled between rounds
+const (
+	MinPenetration     = 50
+	MaxPenetration     = 90
+	DefaultPenetration = 75
+)
+
+// Builds a shuffled shoe of several decks with the cut card placed after
+// penetration percent of it; zero penetration means DefaultPenetration
+func NewShoe(decks, penetration int) *Deck {
+	if penetration == 0 {
+		penetration = DefaultPenetration
+	}
+	shoe := &Deck{Cards: make([]Card, 0, 52*decks)}
+	for i := 0; i < decks; i++ {
+		shoe.Cards = append(shoe.Cards, NewDeck().Cards...)
+	}
+	shoe.CutCard = max(len(shoe.Cards)*(100-penetration)/100, 1)
+	shoe.Shuffle()
+	return shoe
+}
+
+// Cards left to deal before the shoe runs out
+func (d *Deck) Remaining() int {
+	return len(d.Cards)
+}
+
+// Whether the cut card has come out, so the shoe should be reshuffled before
+// the next round
+func (d *Deck) PastCutCard() bool {
+	return d.CutCard > 0 && len(d.Cards) <= d.CutCard
+}
+
+// Moves the cards dealt in the round just played to the discards, once
+// they're off the table
+func (d *Deck) Discard() {
+	d.discards = append(d.discards, d.dealt...)
+	d.dealt = nil
+}
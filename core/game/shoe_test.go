@@ -0,0 +1,68 @@
+package game
+
+import "testing"
+
+func TestNewShoeCutCard(t *testing.T) {
+	shoe := NewShoe(2, 0)
+	if shoe.Remaining() != 104 {
+		t.Fatalf("Remaining() = %d, want 104", shoe.Remaining())
+	}
+	if shoe.CutCard != 26 {
+		t.Errorf("CutCard = %d, want 26 at the default penetration", shoe.CutCard)
+	}
+	if got := NewShoe(1, 90).CutCard; got != 5 {
+		t.Errorf("CutCard at 90%% = %d, want 5", got)
+	}
+
+	for shoe.Remaining() > 27 {
+		shoe.Draw()
+	}
+	if shoe.PastCutCard() {
+		t.Error("PastCutCard() before the cut card came out")
+	}
+	shoe.Draw()
+	if !shoe.PastCutCard() {
+		t.Error("PastCutCard() should be true once the cut card is out")
+	}
+}
+
+func TestShoeReshufflesDiscardsWhenEmpty(t *testing.T) {
+	shoe := NewShoe(1, 0)
+	for i := 0; i < 40; i++ {
+		shoe.Draw()
+	}
+	shoe.Discard()
+
+	// The 12 left and then the 40 discarded, never a card still in play
+	inPlay := map[Card]bool{}
+	for i := 0; i < 52; i++ {
+		card, err := shoe.Draw()
+		if err != nil {
+			t.Fatalf("Draw() %d error = %v", i, err)
+		}
+		if inPlay[card] {
+			t.Fatalf("Draw() dealt %s%s twice", card.Rank, card.Suit)
+		}
+		inPlay[card] = true
+	}
+	if _, err := shoe.Draw(); err == nil {
+		t.Error("Draw() should fail once every card is on the table")
+	}
+}
+
+func TestSharedTableReshufflesAtCutCard(t *testing.T) {
+	table := NewSharedTable(Table{ID: "single", Rules: Rules{Decks: 1, Penetration: 50}})
+	for i := 0; i < 25; i++ {
+		table.Shoe.Draw()
+	}
+	table.NewRound()
+	if table.Shoe.Remaining() != 27 {
+		t.Errorf("NewRound() reshuffled before the cut card, %d cards left", table.Shoe.Remaining())
+	}
+
+	table.Shoe.Draw()
+	table.NewRound()
+	if table.Shoe.Remaining() != 52 {
+		t.Errorf("NewRound() after the cut card left %d cards, want a fresh shoe", table.Shoe.Remaining())
+	}
+}
@@ -0,0 +1,192 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SideBet is a wager on the opening cards alone, staked alongside the main bet
+// and settled as soon as they're dealt
+type SideBet string
+
+const (
+	PerfectPairs   SideBet = "PP"   // The player's first two cards are a pair
+	TwentyOnePlus3 SideBet = "21+3" // They make a poker hand with the dealer's up card
+)
+
+// Side bets in the order they're shown
+var SideBets = []SideBet{PerfectPairs, TwentyOnePlus3}
+
+// A winning side bet combination and what it pays to one
+type sidePay struct {
+	Hand string
+	Pays int64
+}
+
+// Pay tables, best hand first
+var (
+	perfectPairsPays = []sidePay{{"Perfect pair", 25}, {"Coloured pair", 12}, {"Mixed pair", 6}}
+	twentyOne3Pays   = []sidePay{{"Suited trips", 100}, {"Straight flush", 40}, {"Three of a kind", 30}, {"Straight", 10}, {"Flush", 5}}
+)
+
+// Looks up a side bet by its name, ignoring case
+func ParseSideBet(name string) (SideBet, bool) {
+	for _, b := range SideBets {
+		if strings.EqualFold(string(b), name) {
+			return b, true
+		}
+	}
+	return "", false
+}
+
+func (b SideBet) Name() string {
+	if b == PerfectPairs {
+		return "Perfect Pairs"
+	}
+	return string(b)
+}
+
+// SideBetOutcome is how one side bet of a hand did
+type SideBetOutcome struct {
+	Bet    SideBet
+	Stake  int64  // in cents
+	Hand   string // The winning combination, empty when the bet lost
+	Payout int64  // Stake returned with the winnings, zero when the bet lost
+}
+
+// Stakes a side bet on the next deal. Side bets are placed before the main
+// bet and can't be larger than it.
+func (g *Game) PlaceSideBet(bet SideBet, amount int64) error {
+	if g.Phase != PhaseWaitingForBet {
+		return fmt.Errorf("side bets must be placed before the deal")
+	}
+	if _, ok := ParseSideBet(string(bet)); !ok {
+		return fmt.Errorf("unknown side bet %q", bet)
+	}
+	if amount <= 0 {
+		return fmt.Errorf("side bet must be positive")
+	}
+	if g.SideStakes == nil {
+		g.SideStakes = map[SideBet]int64{}
+	}
+	g.SideStakes[bet] = amount
+	return nil
+}
+
+// Checks the side bets against the main bet about to be placed
+func (g *Game) checkSideBets(amount int64) error {
+	for _, b := range SideBets {
+		if g.SideStakes[b] > amount {
+			return fmt.Errorf("%s bet can't be more than the main bet", b.Name())
+		}
+	}
+	return nil
+}
+
+// Total staked on side bets
+func (g *Game) SideStake() int64 {
+	var total int64
+	for _, stake := range g.SideStakes {
+		total += stake
+	}
+	return total
+}
+
+// Everything the player has riding on the hand, side bets included
+func (g *Game) TotalWager() int64 {
+	return g.Bet + g.SideStake()
+}
+
+// Settles the side bets on the opening cards; nil before the deal
+func (g *Game) SideBetOutcomes() []SideBetOutcome {
+	if len(g.SideStakes) == 0 || len(g.PlayerHand.Cards) < 2 || len(g.DealerHand.Cards) == 0 {
+		return nil
+	}
+	first, second := g.PlayerHand.Cards[0], g.PlayerHand.Cards[1]
+
+	var outcomes []SideBetOutcome
+	for _, b := range SideBets {
+		stake, ok := g.SideStakes[b]
+		if !ok {
+			continue
+		}
+		var win *sidePay
+		if b == PerfectPairs {
+			win = perfectPair(first, second)
+		} else {
+			win = pokerHand(first, second, g.DealerHand.Cards[0])
+		}
+		outcome := SideBetOutcome{Bet: b, Stake: stake}
+		if win != nil {
+			outcome.Hand = win.Hand
+			outcome.Payout = stake + stake*win.Pays
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes
+}
+
+// What the side bets pay back in all
+func (g *Game) SidePayout() int64 {
+	var total int64
+	for _, o := range g.SideBetOutcomes() {
+		total += o.Payout
+	}
+	return total
+}
+
+func redSuit(suit string) bool {
+	return suit == "♥" || suit == "♦"
+}
+
+func perfectPair(a, b Card) *sidePay {
+	switch {
+	case a.Rank != b.Rank:
+		return nil
+	case a.Suit == b.Suit:
+		return &perfectPairsPays[0]
+	case redSuit(a.Suit) == redSuit(b.Suit):
+		return &perfectPairsPays[1]
+	default:
+		return &perfectPairsPays[2]
+	}
+}
+
+// The three-card poker hand of the 21+3 bet; aces count high or low in a straight
+func pokerHand(a, b, c Card) *sidePay {
+	flush := a.Suit == b.Suit && b.Suit == c.Suit
+	trips := a.Rank == b.Rank && b.Rank == c.Rank
+
+	ranks := []int{rankIndex(a.Rank), rankIndex(b.Rank), rankIndex(c.Rank)}
+	sort.Ints(ranks)
+	straight := ranks[0]+1 == ranks[1] && ranks[1]+1 == ranks[2] ||
+		ranks[0] == 0 && ranks[1] == 11 && ranks[2] == 12 // Q-K-A
+
+	switch {
+	case trips && flush:
+		return &twentyOne3Pays[0]
+	case straight && flush:
+		return &twentyOne3Pays[1]
+	case trips:
+		return &twentyOne3Pays[2]
+	case straight:
+		return &twentyOne3Pays[3]
+	case flush:
+		return &twentyOne3Pays[4]
+	}
+	return nil
+}
+
+// Lines for GetGameState, one per side bet
+func (g *Game) sideBetState() string {
+	var state string
+	for _, o := range g.SideBetOutcomes() {
+		result := "lost"
+		if o.Payout > 0 {
+			result = fmt.Sprintf("%s, pays $%.2f", o.Hand, float64(o.Payout)/100)
+		}
+		state += fmt.Sprintf("Side Bet %s ($%.2f): %s\n", o.Bet.Name(), float64(o.Stake)/100, result)
+	}
+	return state
+}
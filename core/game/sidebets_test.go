@@ -0,0 +1,122 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPerfectPairsPayTable(t *testing.T) {
+	tests := []struct {
+		a, b Card
+		want string
+	}{
+		{card("8", "♠"), card("8", "♠"), "Perfect pair"},
+		{card("8", "♥"), card("8", "♦"), "Coloured pair"},
+		{card("8", "♠"), card("8", "♥"), "Mixed pair"},
+		{card("8", "♠"), card("9", "♠"), ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if win := perfectPair(tt.a, tt.b); win != nil {
+			got = win.Hand
+		}
+		if got != tt.want {
+			t.Errorf("perfectPair(%v, %v) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTwentyOnePlus3PayTable(t *testing.T) {
+	tests := []struct {
+		cards [3]Card
+		want  string
+	}{
+		{[3]Card{card("7", "♣"), card("7", "♣"), card("7", "♣")}, "Suited trips"},
+		{[3]Card{card("9", "♥"), card("J", "♥"), card("10", "♥")}, "Straight flush"},
+		{[3]Card{card("7", "♣"), card("7", "♥"), card("7", "♠")}, "Three of a kind"},
+		{[3]Card{card("Q", "♣"), card("A", "♥"), card("K", "♠")}, "Straight"},
+		{[3]Card{card("A", "♣"), card("2", "♥"), card("3", "♠")}, "Straight"},
+		{[3]Card{card("2", "♦"), card("9", "♦"), card("K", "♦")}, "Flush"},
+		{[3]Card{card("K", "♣"), card("A", "♥"), card("2", "♠")}, ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if win := pokerHand(tt.cards[0], tt.cards[1], tt.cards[2]); win != nil {
+			got = win.Hand
+		}
+		if got != tt.want {
+			t.Errorf("pokerHand(%v) = %q, want %q", tt.cards, got, tt.want)
+		}
+	}
+}
+
+func TestSideBetsSettleOnTheDeal(t *testing.T) {
+	// Player 8♥ 8♦ against a dealer 5♠ up: a coloured pair but no 21+3 hand
+	g := NewGameWithDeck([]Card{card("8", "♥"), card("5", "♠"), card("8", "♦"), card("10", "♣")})
+	if err := g.PlaceSideBet(PerfectPairs, 100); err != nil {
+		t.Fatalf("PlaceSideBet() error = %v", err)
+	}
+	if err := g.PlaceSideBet(TwentyOnePlus3, 200); err != nil {
+		t.Fatalf("PlaceSideBet() error = %v", err)
+	}
+	if err := g.PlaceBetNoShuffle(1000); err != nil {
+		t.Fatalf("PlaceBetNoShuffle() error = %v", err)
+	}
+
+	outcomes := g.SideBetOutcomes()
+	if len(outcomes) != 2 {
+		t.Fatalf("SideBetOutcomes() = %+v, want both bets", outcomes)
+	}
+	if o := outcomes[0]; o.Bet != PerfectPairs || o.Hand != "Coloured pair" || o.Payout != 1300 {
+		t.Errorf("Perfect Pairs outcome = %+v, want a coloured pair paying $13", o)
+	}
+	if o := outcomes[1]; o.Bet != TwentyOnePlus3 || o.Payout != 0 {
+		t.Errorf("21+3 outcome = %+v, want a loss", o)
+	}
+	if g.TotalWager() != 1300 || g.SidePayout() != 1300 {
+		t.Errorf("TotalWager() = %d, SidePayout() = %d, want 1300 each", g.TotalWager(), g.SidePayout())
+	}
+
+	if shown := g.GetGameState(true); !strings.Contains(shown, "Side Bet Perfect Pairs ($1.00): Coloured pair, pays $13.00") || !strings.Contains(shown, "Side Bet 21+3 ($2.00): lost") {
+		t.Errorf("GetGameState() should show the side bets:\n%s", shown)
+	}
+
+	// The main hand plays on as usual and the side bets survive a save
+	state, err := g.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	restored, err := RestoreGame(state)
+	if err != nil {
+		t.Fatalf("RestoreGame() error = %v", err)
+	}
+	if restored.SidePayout() != 1300 {
+		t.Errorf("restored SidePayout() = %d, want 1300", restored.SidePayout())
+	}
+}
+
+func TestSideBetLimits(t *testing.T) {
+	g := NewGame()
+	if err := g.PlaceSideBet("LUCKY", 100); err == nil {
+		t.Error("PlaceSideBet() should reject an unknown side bet")
+	}
+	if err := g.PlaceSideBet(PerfectPairs, 0); err == nil {
+		t.Error("PlaceSideBet() should reject a zero stake")
+	}
+	if err := g.PlaceSideBet(PerfectPairs, 2000); err != nil {
+		t.Fatalf("PlaceSideBet() error = %v", err)
+	}
+	if err := g.PlaceBet(1000); err == nil {
+		t.Error("PlaceBet() should refuse a side bet larger than the main bet")
+	}
+	if err := g.PlaceBet(2000); err != nil {
+		t.Fatalf("PlaceBet() error = %v", err)
+	}
+	if err := g.PlaceSideBet(TwentyOnePlus3, 100); err == nil {
+		t.Error("PlaceSideBet() should fail after the deal")
+	}
+
+	if b, ok := ParseSideBet("pp"); !ok || b != PerfectPairs {
+		t.Errorf("ParseSideBet(pp) = %q, %v", b, ok)
+	}
+}
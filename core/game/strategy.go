@@ -0,0 +1,88 @@
+package game
+
+import "slices"
+
+// BasicStrategy returns the basic strategy play for a hand against the
+// dealer's up card, choosing among the offered actions: HIT, STAND,
+// DOUBLEDOWN or SURRENDER. It follows the usual multi-deck chart; there are no
+// splits to consider.
+func BasicStrategy(player *Hand, up Card, rules Rules, actions []string) string {
+	can := func(action string) bool { return slices.Contains(actions, action) }
+
+	total := player.Value()
+	dealer := up.Value
+	if up.Rank == "A" {
+		dealer = 11
+	}
+	between := func(lo, hi int) bool { return dealer >= lo && dealer <= hi }
+
+	if !player.IsSoft() && can("SURRENDER") {
+		if total == 16 && dealer >= 9 || total == 15 && dealer == 10 ||
+			rules.DealerHitsSoft17 && (total == 15 || total == 17) && dealer == 11 {
+			return "SURRENDER"
+		}
+	}
+
+	// Doubling falls back to hitting when it isn't offered, except soft 18,
+	// which is strong enough to stand
+	double := func(fallback string) string {
+		if can("DOUBLEDOWN") {
+			return "DOUBLEDOWN"
+		}
+		return fallback
+	}
+
+	if player.IsSoft() {
+		switch {
+		case total >= 19:
+			if total == 19 && dealer == 6 && rules.DealerHitsSoft17 {
+				return double("STAND")
+			}
+			return "STAND"
+		case total == 18:
+			if between(3, 6) || dealer == 2 && rules.DealerHitsSoft17 {
+				return double("STAND")
+			}
+			if between(2, 8) {
+				return "STAND"
+			}
+			return "HIT"
+		case total == 17 && between(3, 6),
+			total >= 15 && between(4, 6),
+			total >= 13 && between(5, 6):
+			return double("HIT")
+		}
+		return "HIT"
+	}
+
+	switch {
+	case total >= 17:
+		return "STAND"
+	case total >= 13:
+		if between(2, 6) {
+			return "STAND"
+		}
+		return "HIT"
+	case total == 12:
+		if between(4, 6) {
+			return "STAND"
+		}
+		return "HIT"
+	case total == 11:
+		if dealer <= 10 || rules.DealerHitsSoft17 {
+			return double("HIT")
+		}
+		return "HIT"
+	case total == 10:
+		if between(2, 9) {
+			return double("HIT")
+		}
+		return "HIT"
+	case total == 9:
+		if between(3, 6) {
+			return double("HIT")
+		}
+		return "HIT"
+	}
+	return "HIT"
+}
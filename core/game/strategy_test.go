@@ -0,0 +1,55 @@
+package game
+
+import "testing"
+
+func hand(ranks ...string) *Hand {
+	h := NewHand()
+	for _, r := range ranks {
+		h.AddCard(NewCard(r, "♠"))
+	}
+	return h
+}
+
+func TestBasicStrategy(t *testing.T) {
+	all := []string{"HIT", "STAND", "DOUBLEDOWN", "SURRENDER"}
+	noDouble := []string{"HIT", "STAND"}
+
+	tests := []struct {
+		name    string
+		player  *Hand
+		up      string
+		h17     bool
+		actions []string
+		want    string
+	}{
+		{"hard 8 hits", hand("5", "3"), "6", false, all, "HIT"},
+		{"11 doubles", hand("6", "5"), "10", false, all, "DOUBLEDOWN"},
+		{"11 hits an ace at S17", hand("6", "5"), "A", false, all, "HIT"},
+		{"11 doubles an ace at H17", hand("6", "5"), "A", true, all, "DOUBLEDOWN"},
+		{"10 hits without double", hand("6", "4"), "5", false, noDouble, "HIT"},
+		{"12 stands on 4", hand("10", "2"), "4", false, all, "STAND"},
+		{"12 hits 2", hand("10", "2"), "2", false, all, "HIT"},
+		{"16 stands on 6", hand("10", "6"), "6", false, all, "STAND"},
+		{"16 surrenders to 10", hand("10", "6"), "K", false, all, "SURRENDER"},
+		{"16 hits 10 without surrender", hand("10", "6"), "10", false, noDouble, "HIT"},
+		{"15 surrenders to 10", hand("9", "6"), "Q", false, all, "SURRENDER"},
+		{"three-card 16 hits 7", hand("5", "5", "6"), "7", false, noDouble, "HIT"},
+		{"hard 17 stands", hand("10", "7"), "A", false, all, "STAND"},
+		{"soft 13 doubles 5", hand("A", "2"), "5", false, all, "DOUBLEDOWN"},
+		{"soft 17 doubles 3", hand("A", "6"), "3", false, all, "DOUBLEDOWN"},
+		{"soft 18 stands on 7", hand("A", "7"), "7", false, all, "STAND"},
+		{"soft 18 hits 9", hand("A", "7"), "9", false, all, "HIT"},
+		{"soft 18 stands when it can't double", hand("A", "7"), "4", false, noDouble, "STAND"},
+		{"soft 19 stands", hand("A", "8"), "6", false, all, "STAND"},
+		{"soft 19 doubles 6 at H17", hand("A", "8"), "6", true, all, "DOUBLEDOWN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BasicStrategy(tt.player, NewCard(tt.up, "♥"), Rules{DealerHitsSoft17: tt.h17}, tt.actions)
+			if got != tt.want {
+				t.Errorf("BasicStrategy(%s vs %s) = %s, want %s", tt.player, tt.up, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rules holds per-table settings; zero limits mean no limit and the other
+// zero values give the house's standard game
+type Rules struct {
+	MinBet int64 // in cents
+	MaxBet int64 // in cents
+
+	BlackjackPays    Payout // What a natural blackjack pays; zero means 3:2
+	DealerHitsSoft17 bool
+	NoSurrender      bool
+	Decks            int // Decks shuffled together; zero means the table's default
+	Penetration      int // Percent of the shoe dealt before a reshuffle; zero means DefaultPenetration
+	TurnSeconds      int // Time a player has to act at a shared table; zero means the server's default
+}
+
+// Payout is a win:stake ratio such as 3:2
+type Payout struct {
+	Win, Stake int64
+}
+
+// Blackjack payouts a table can choose
+var (
+	Pays3to2 = Payout{3, 2}
+	Pays6to5 = Payout{6, 5}
+)
+
+// Most decks a table can deal from
+const MaxDecks = 8
+
+// Range a table can set its turn timer to
+const (
+	MinTurnSeconds = 10
+	MaxTurnSeconds = 120
+)
+
+func (p Payout) String() string {
+	return fmt.Sprintf("%d:%d", p.Win, p.Stake)
+}
+
+// What a natural blackjack pays at this table
+func (r Rules) BlackjackPayout() Payout {
+	if r.BlackjackPays.Stake == 0 {
+		return Pays3to2
+	}
+	return r.BlackjackPays
+}
+
+// Describes the rules that differ between tables, e.g. "BJ 3:2, S17,
+// surrender, 6 decks", adding the penetration when it isn't the default
+func (r Rules) Describe(defaultDecks int) string {
+	soft17 := "S17"
+	if r.DealerHitsSoft17 {
+		soft17 = "H17"
+	}
+	surrender := "surrender"
+	if r.NoSurrender {
+		surrender = "no surrender"
+	}
+	decks := r.Decks
+	if decks == 0 {
+		decks = defaultDecks
+	}
+	plural := "s"
+	if decks == 1 {
+		plural = ""
+	}
+	desc := fmt.Sprintf("BJ %s, %s, %s, %d deck%s", r.BlackjackPayout(), soft17, surrender, decks, plural)
+	if r.Penetration != 0 && r.Penetration != DefaultPenetration {
+		desc += fmt.Sprintf(", %d%% dealt", r.Penetration)
+	}
+	return desc
+}
+
+// Applies table options such as "6:5", "h17", "nosurrender", "decks=2",
+// "pen=80" or "turn=20" on top of base rules
+func ParseRuleOptions(base Rules, options []string) (Rules, error) {
+	rules := base
+	for _, opt := range options {
+		switch o := strings.ToLower(opt); {
+		case o == Pays3to2.String():
+			rules.BlackjackPays = Pays3to2
+		case o == Pays6to5.String():
+			rules.BlackjackPays = Pays6to5
+		case o == "h17":
+			rules.DealerHitsSoft17 = true
+		case o == "s17":
+			rules.DealerHitsSoft17 = false
+		case o == "surrender":
+			rules.NoSurrender = false
+		case o == "nosurrender":
+			rules.NoSurrender = true
+		case strings.HasPrefix(o, "decks="):
+			decks, err := strconv.Atoi(strings.TrimPrefix(o, "decks="))
+			if err != nil || decks < 1 || decks > MaxDecks {
+				return Rules{}, fmt.Errorf("decks must be between 1 and %d", MaxDecks)
+			}
+			rules.Decks = decks
+		case strings.HasPrefix(o, "pen="):
+			pen, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(o, "pen="), "%"))
+			if err != nil || pen < MinPenetration || pen > MaxPenetration {
+				return Rules{}, fmt.Errorf("pen must be between %d and %d percent", MinPenetration, MaxPenetration)
+			}
+			rules.Penetration = pen
+		case strings.HasPrefix(o, "turn="):
+			seconds, err := strconv.Atoi(strings.TrimPrefix(o, "turn="))
+			if err != nil || seconds < MinTurnSeconds || seconds > MaxTurnSeconds {
+				return Rules{}, fmt.Errorf("turn must be between %d and %d seconds", MinTurnSeconds, MaxTurnSeconds)
+			}
+			rules.TurnSeconds = seconds
+		default:
+			return Rules{}, fmt.Errorf("unknown table option %q (use 3:2, 6:5, h17, s17, surrender, nosurrender, decks=N, pen=N or turn=N)", opt)
+		}
+	}
+	return rules, nil
+}
+
+// Checks a bet against the table limits
+func (r Rules) CheckBet(amount int64) error {
+	if amount <= 0 {
+		return fmt.Errorf("bet must be positive")
+	}
+	if r.MinBet > 0 && amount < r.MinBet {
+		return fmt.Errorf("minimum bet at this table is $%.2f", float64(r.MinBet)/100)
+	}
+	if r.MaxBet > 0 && amount > r.MaxBet {
+		return fmt.Errorf("maximum bet at this table is $%.2f", float64(r.MaxBet)/100)
+	}
+	return nil
+}
+
+type Table struct {
+	ID    string
+	Name  string
+	Rules Rules
+}
+
+// Tables players can SIT at; the first one is where new connections start
+var Tables = []Table{
+	{ID: "low", Name: "Low Stakes", Rules: Rules{MinBet: 100, MaxBet: 10000}},
+	{ID: "mid", Name: "Main Floor", Rules: Rules{MinBet: 1000, MaxBet: 100000}},
+	{ID: "high", Name: "High Rollers", Rules: Rules{MinBet: 10000, MaxBet: 1000000}},
+}
+
+// Looks up a table by ID, ignoring case
+func FindTable(id string) (Table, bool) {
+	for _, t := range Tables {
+		if strings.EqualFold(t.ID, id) {
+			return t, true
+		}
+	}
+	return Table{}, false
+}
+
+// Returns the smallest minimum bet across all tables
+func LowestMinBet() int64 {
+	lowest := int64(0)
+	for i, t := range Tables {
+		if i == 0 || t.Rules.MinBet < lowest {
+			lowest = t.Rules.MinBet
+		}
+	}
+	return lowest
+}
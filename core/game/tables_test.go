@@ -0,0 +1,93 @@
+package game
+
+import "testing"
+
+func TestRulesCheckBet(t *testing.T) {
+	rules := Rules{MinBet: 100, MaxBet: 10000}
+
+	tests := []struct {
+		amount  int64
+		wantErr bool
+	}{
+		{0, true},
+		{99, true},
+		{100, false},
+		{10000, false},
+		{10001, true},
+	}
+
+	for _, tt := range tests {
+		err := rules.CheckBet(tt.amount)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckBet(%d) error = %v, wantErr %v", tt.amount, err, tt.wantErr)
+		}
+	}
+
+	if err := (Rules{}).CheckBet(1); err != nil {
+		t.Errorf("CheckBet() with no limits error = %v", err)
+	}
+}
+
+func TestPlaceBetEnforcesTableRules(t *testing.T) {
+	g := NewGameWithRules(Rules{MinBet: 1000, MaxBet: 5000})
+
+	if err := g.PlaceBet(500); err == nil {
+		t.Error("PlaceBet() should reject a bet under the table minimum")
+	}
+	if err := g.PlaceBet(6000); err == nil {
+		t.Error("PlaceBet() should reject a bet over the table maximum")
+	}
+	if g.Phase != PhaseWaitingForBet {
+		t.Errorf("Phase = %v after rejected bets, want %v", g.Phase, PhaseWaitingForBet)
+	}
+	if err := g.PlaceBet(2000); err != nil {
+		t.Errorf("PlaceBet() within limits error = %v", err)
+	}
+}
+
+func TestFindTable(t *testing.T) {
+	table, ok := FindTable("HIGH")
+	if !ok || table.ID != "high" {
+		t.Errorf("FindTable(HIGH) = %+v, %v, want the high table", table, ok)
+	}
+
+	if _, ok := FindTable("nope"); ok {
+		t.Error("FindTable() found a table that doesn't exist")
+	}
+
+	if got := LowestMinBet(); got != 100 {
+		t.Errorf("LowestMinBet() = %d, want 100", got)
+	}
+}
+
+func TestParseRuleOptions(t *testing.T) {
+	base := Rules{MinBet: 100, MaxBet: 10000}
+
+	rules, err := ParseRuleOptions(base, []string{"6:5", "H17", "nosurrender", "decks=2", "pen=80", "turn=20"})
+	if err != nil {
+		t.Fatalf("ParseRuleOptions() error = %v", err)
+	}
+	want := Rules{MinBet: 100, MaxBet: 10000, BlackjackPays: Pays6to5, DealerHitsSoft17: true, NoSurrender: true, Decks: 2, Penetration: 80, TurnSeconds: 20}
+	if rules != want {
+		t.Errorf("ParseRuleOptions() = %+v, want %+v", rules, want)
+	}
+	if got := rules.Describe(ShoeDecks); got != "BJ 6:5, H17, no surrender, 2 decks, 80% dealt" {
+		t.Errorf("Describe() = %q", got)
+	}
+	if got := base.Describe(1); got != "BJ 3:2, S17, surrender, 1 deck" {
+		t.Errorf("Describe() of the default rules = %q", got)
+	}
+
+	for _, opt := range []string{"7:5", "decks=0", "decks=9", "pen=40", "pen=95", "turn=5", "turn=121", "fast"} {
+		if _, err := ParseRuleOptions(base, []string{opt}); err == nil {
+			t.Errorf("ParseRuleOptions(%q) should fail", opt)
+		}
+	}
+}
+
+func TestSharedTableUsesRuleDecks(t *testing.T) {
+	table := NewSharedTable(Table{ID: "custom", Rules: Rules{Decks: 2}})
+	if len(table.Shoe.Cards) != 104 {
+		t.Errorf("shoe has %d cards, want 104", len(table.Shoe.Cards))
+	}
+}
@@ -744,3 +744,16 @@ func TestSurrenderIntegration(t *testing.T) {
 		t.Errorf("Final balance = %d, want %d", user.Balance, finalExpectedBalance)
 	}
 }
+
+// Every game the server offers needs to be known to achievements
+func TestEnginesAreKnownGames(t *testing.T) {
+	known := map[string]bool{}
+	for _, g := range security.AllGames {
+		known[g] = true
+	}
+	for _, info := range game.Engines() {
+		if !known[info.Name] {
+			t.Errorf("game %q is missing from security.AllGames", info.Name)
+		}
+	}
+}
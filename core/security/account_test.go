@@ -170,3 +170,12 @@ func TestIsSessionExpired(t *testing.T) {
 		t.Error("IsSessionExpired() should return false for future time")
 	}
 }
+
+func TestVerifyDummyPassword(t *testing.T) {
+	// Should never panic and must initialize the shared dummy hash
+	VerifyDummyPassword("anything")
+
+	if len(dummyHash) == 0 {
+		t.Error("VerifyDummyPassword() did not initialize dummy hash")
+	}
+}
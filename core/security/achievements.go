@@ -0,0 +1,126 @@
+package security
+
+import (
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Games a player needs to try for the Explorer achievement
+var AllGames = []string{GameBlackjack, GameBaccarat, GameHiLo, GameHoldem, GameKeno}
+
+// RoundResult describes a settled round for achievement checks
+type RoundResult struct {
+	Game      string
+	Wagered   int64
+	Payout    int64
+	Won       bool
+	Blackjack bool
+}
+
+// achievementContext is what an achievement check can look at
+type achievementContext struct {
+	as     *AuthService
+	userID int
+	round  RoundResult
+	stats  *vault.UserStats
+}
+
+type Achievement struct {
+	ID          string
+	Name        string
+	Description string
+
+	check func(ctx *achievementContext) (bool, error)
+}
+
+// Achievements in the order they are listed to players
+var Achievements = []Achievement{
+	{
+		ID: "first_win", Name: "Beginner's Luck", Description: "Win a hand",
+		check: func(ctx *achievementContext) (bool, error) { return ctx.round.Won, nil },
+	},
+	{
+		ID: "first_blackjack", Name: "Natural", Description: "Get a blackjack",
+		check: func(ctx *achievementContext) (bool, error) { return ctx.round.Blackjack, nil },
+	},
+	{
+		ID: "win_streak_10", Name: "On Fire", Description: "Win 10 hands in a row",
+		check: func(ctx *achievementContext) (bool, error) { return ctx.stats.WinStreak >= 10, nil },
+	},
+	{
+		ID: "high_roller", Name: "High Roller", Description: "Wager $1,000 on a single hand",
+		check: func(ctx *achievementContext) (bool, error) { return ctx.round.Wagered >= 100000, nil },
+	},
+	{
+		ID: "big_day", Name: "Big Day", Description: "Win $1,000 within 24 hours",
+		check: func(ctx *achievementContext) (bool, error) {
+			if !ctx.round.Won {
+				return false, nil
+			}
+			net, err := ctx.as.db.SumTransactions(ctx.userID, time.Now().Add(-24*time.Hour),
+				vault.TxBet, vault.TxRefund, vault.TxPayout)
+			return net >= 100000, err
+		},
+	},
+	{
+		ID: "explorer", Name: "Explorer", Description: "Play every game",
+		check: func(ctx *achievementContext) (bool, error) {
+			played, err := ctx.as.db.GetUserGames(ctx.userID)
+			if err != nil {
+				return false, err
+			}
+			for _, game := range AllGames {
+				if played[game] == 0 {
+					return false, nil
+				}
+			}
+			return true, nil
+		},
+	},
+}
+
+// Checks every locked achievement after a settled round and returns the ones just unlocked
+func (as *AuthService) EvaluateAchievements(userID int, round RoundResult) ([]Achievement, error) {
+	unlocked, err := as.db.ListAchievements(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := as.db.GetUserStats(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := &achievementContext{as: as, userID: userID, round: round, stats: stats}
+
+	var earned []Achievement
+	for _, a := range Achievements {
+		if _, ok := unlocked[a.ID]; ok {
+			continue
+		}
+
+		ok, err := a.check(ctx)
+		if err != nil {
+			return earned, err
+		}
+		if !ok {
+			continue
+		}
+
+		isNew, err := as.db.UnlockAchievement(userID, a.ID)
+		if err != nil {
+			return earned, err
+		}
+		if isNew {
+			earned = append(earned, a)
+		}
+	}
+
+	return earned, nil
+}
+
+// Returns the user's unlocked achievements keyed by ID with their unlock times
+func (as *AuthService) ListAchievements(userID int) (map[string]time.Time, error) {
+	return as.db.ListAchievements(userID)
+}
@@ -0,0 +1,95 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func TestEvaluateAchievements(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("achiever", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	// Record a settled round of every game so the Explorer check sees them
+	for _, game := range AllGames {
+		if _, err := auth.AdjustBalance(user.ID, -1000, vault.TxBet); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+		if _, err := auth.SettleRound(user.ID, game, 1000, 2500, "", "", nil); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+	}
+
+	round := RoundResult{Game: GameBlackjack, Wagered: 1000, Payout: 2500, Won: true, Blackjack: true}
+	earned, err := auth.EvaluateAchievements(user.ID, round)
+	if err != nil {
+		t.Fatalf("EvaluateAchievements() error = %v", err)
+	}
+
+	got := make(map[string]bool)
+	for _, a := range earned {
+		got[a.ID] = true
+	}
+	for _, id := range []string{"first_win", "first_blackjack", "explorer"} {
+		if !got[id] {
+			t.Errorf("EvaluateAchievements() did not unlock %s", id)
+		}
+	}
+	if got["high_roller"] || got["big_day"] || got["win_streak_10"] {
+		t.Errorf("EvaluateAchievements() unlocked too much: %v", got)
+	}
+
+	// Already unlocked achievements aren't reported again
+	earned, err = auth.EvaluateAchievements(user.ID, round)
+	if err != nil {
+		t.Fatalf("EvaluateAchievements() error = %v", err)
+	}
+	if len(earned) != 0 {
+		t.Errorf("EvaluateAchievements() repeated %d achievements", len(earned))
+	}
+
+	unlocked, err := auth.ListAchievements(user.ID)
+	if err != nil {
+		t.Fatalf("ListAchievements() error = %v", err)
+	}
+	if len(unlocked) != 3 {
+		t.Errorf("ListAchievements() returned %d, want 3", len(unlocked))
+	}
+}
+
+func TestHighRollerAndStreakAchievements(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("achiever", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	stats, err := auth.GetUserStats(user.ID)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	stats.WinStreak = 10
+	if err := auth.db.UpdateUserStats(stats); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	earned, err := auth.EvaluateAchievements(user.ID, RoundResult{Game: GameBlackjack, Wagered: 100000})
+	if err != nil {
+		t.Fatalf("EvaluateAchievements() error = %v", err)
+	}
+
+	got := make(map[string]bool)
+	for _, a := range earned {
+		got[a.ID] = true
+	}
+	if !got["high_roller"] || !got["win_streak_10"] {
+		t.Errorf("EvaluateAchievements() = %v, want high_roller and win_streak_10", got)
+	}
+}
@@ -0,0 +1,65 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Longest operator note stored with a manual adjustment
+const MaxAdjustmentReasonLength = 200
+
+// Credits (positive delta) or debits a user's balance on behalf of an operator.
+// The reason is mandatory and is kept on the ledger entry and in the audit log.
+func (as *AuthService) AdminAdjustBalance(actor string, userID int, delta int64, reason, ip string) (int64, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return 0, fmt.Errorf("a reason is required for balance adjustments")
+	}
+	if len(reason) > MaxAdjustmentReasonLength {
+		return 0, fmt.Errorf("reason must be at most %d characters", MaxAdjustmentReasonLength)
+	}
+	if delta == 0 {
+		return 0, fmt.Errorf("amount must be greater than zero")
+	}
+
+	note := fmt.Sprintf("%s: %s", actor, reason)
+	balance, err := as.db.AdjustBalanceWithMetadata(userID, delta, vault.TxAdjustment, note)
+	if err != nil {
+		return 0, err
+	}
+
+	detail := fmt.Sprintf("%+.2f by %s: %s", float64(delta)/100, actor, reason)
+	as.db.RecordAuditEvent(userID, vault.AuditAdminAdjust, ip, detail)
+
+	return balance, nil
+}
+
+// Grants or removes admin rights; actor is recorded in the audit log
+func (as *AuthService) SetAdmin(actor string, userID int, isAdmin bool) error {
+	if err := as.db.SetUserAdmin(userID, isAdmin); err != nil {
+		return err
+	}
+	as.forgetSessionUser(userID)
+
+	detail := "revoked admin by " + actor
+	if isAdmin {
+		detail = "granted admin by " + actor
+	}
+	as.db.RecordAuditEvent(userID, vault.AuditRoleChange, "", detail)
+
+	return nil
+}
+
+// Audits an admin disconnecting a user's connection; userID is 0 for one
+// that wasn't logged in
+func (as *AuthService) LogKick(actor string, userID int, reason, ip string) {
+	as.db.RecordAuditEvent(userID, vault.AuditKick, ip, fmt.Sprintf("kicked by %s: %s", actor, reason))
+}
+
+// Audits an export of audit events or the ledger; userID is 0 when it
+// covered every user
+func (as *AuthService) LogExport(actor string, userID int, detail, ip string) {
+	as.db.RecordAuditEvent(userID, vault.AuditExport, ip, fmt.Sprintf("exported by %s: %s", actor, detail))
+}
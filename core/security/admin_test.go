@@ -0,0 +1,121 @@
+package security
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func TestAdminAdjustBalance(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("adjustuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := auth.AdminAdjustBalance("console", user.ID, 5000, "  ", ""); err == nil {
+		t.Error("AdminAdjustBalance() should require a reason")
+	}
+
+	balance, err := auth.AdminAdjustBalance("console", user.ID, 5000, "refund for disconnect", "")
+	if err != nil {
+		t.Fatalf("AdminAdjustBalance() error = %v", err)
+	}
+	if balance != 1005000 {
+		t.Errorf("AdminAdjustBalance() balance = %d, want 1005000", balance)
+	}
+
+	if _, err := auth.AdminAdjustBalance("console", user.ID, -2000000, "chargeback", ""); err == nil {
+		t.Error("AdminAdjustBalance() should not overdraw the account")
+	}
+
+	txs, err := auth.db.ListTransactions(user.ID, 10)
+	if err != nil {
+		t.Fatalf("ListTransactions() error = %v", err)
+	}
+	if len(txs) != 1 || txs[0].Type != vault.TxAdjustment || !strings.Contains(txs[0].Metadata, "refund for disconnect") {
+		t.Errorf("Ledger = %+v, want one adjustment carrying the reason", txs)
+	}
+
+	events, err := auth.db.ListAuditEvents(user.ID, 10)
+	if err != nil {
+		t.Fatalf("ListAuditEvents() error = %v", err)
+	}
+	found := false
+	for _, e := range events {
+		if e.Type == vault.AuditAdminAdjust && strings.Contains(e.Detail, "console") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("AdminAdjustBalance() did not record an audit event")
+	}
+}
+
+func TestSetAdmin(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("promoteuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := auth.SetAdmin("console", user.ID, true); err != nil {
+		t.Fatalf("SetAdmin() error = %v", err)
+	}
+
+	_, loggedIn, err := auth.LoginUser("promoteuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("LoginUser() error = %v", err)
+	}
+	if !loggedIn.IsAdmin {
+		t.Error("LoginUser() user should be an admin after SetAdmin(true)")
+	}
+}
+
+func TestLogKick(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("kickeduser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	auth.LogKick("admin", user.ID, "spamming the lobby", "10.0.0.1")
+
+	events, err := auth.db.ListAuditEvents(user.ID, 10)
+	if err != nil {
+		t.Fatalf("ListAuditEvents() error = %v", err)
+	}
+	found := false
+	for _, e := range events {
+		if e.Type == vault.AuditKick && strings.Contains(e.Detail, "spamming the lobby") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("LogKick() did not record an audit event")
+	}
+}
+
+func TestLogExport(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	auth.LogExport("console", 0, "ledger 2026-10-01 to 2026-11-01", "")
+
+	events, err := auth.db.AuditEventsBetween(0, time.Now().Add(-time.Hour), time.Now().Add(time.Hour), 0)
+	if err != nil {
+		t.Fatalf("AuditEventsBetween() error = %v", err)
+	}
+	if len(events) != 1 || events[0].Type != vault.AuditExport || events[0].UserID != 0 ||
+		!strings.Contains(events[0].Detail, "console") || !strings.Contains(events[0].Detail, "ledger") {
+		t.Errorf("Audit log = %+v, want one export by console", events)
+	}
+}
@@ -0,0 +1,101 @@
+package security
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+const MaxAllowedIPsPerUser = 20
+
+// Normalizes an IP or CIDR into CIDR form; single IPs become /32 or /128
+func ParseAllowEntry(entry string) (string, error) {
+	if strings.Contains(entry, "/") {
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return "", fmt.Errorf("invalid CIDR %q", entry)
+		}
+		return network.String(), nil
+	}
+
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return "", fmt.Errorf("invalid IP address %q", entry)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.String() + "/32", nil
+	}
+	return ip.String() + "/128", nil
+}
+
+// Reports whether ip falls inside any entry; an empty allowlist allows everything
+func IPAllowed(cidrs []string, ip string) bool {
+	if len(cidrs) == 0 {
+		return true
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err == nil && network.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (as *AuthService) ListAllowedIPs(userID int) ([]string, error) {
+	return as.db.ListAllowedIPs(userID)
+}
+
+func (as *AuthService) AddAllowedIP(userID int, entry string) (string, error) {
+	cidr, err := ParseAllowEntry(entry)
+	if err != nil {
+		return "", err
+	}
+
+	existing, err := as.db.ListAllowedIPs(userID)
+	if err != nil {
+		return "", err
+	}
+	if len(existing) >= MaxAllowedIPsPerUser {
+		return "", fmt.Errorf("allowlist is limited to %d entries", MaxAllowedIPsPerUser)
+	}
+
+	return cidr, as.db.AddAllowedIP(userID, cidr)
+}
+
+func (as *AuthService) RemoveAllowedIP(userID int, entry string) (string, error) {
+	cidr, err := ParseAllowEntry(entry)
+	if err != nil {
+		return "", err
+	}
+	return cidr, as.db.RemoveAllowedIP(userID, cidr)
+}
+
+// Rejects logins from addresses outside the user's allowlist; an empty ip skips the check
+func (as *AuthService) CheckIPAllowed(userID int, ip string) error {
+	if ip == "" {
+		return nil
+	}
+
+	cidrs, err := as.db.ListAllowedIPs(userID)
+	if err != nil {
+		return err
+	}
+
+	if !IPAllowed(cidrs, ip) {
+		return fmt.Errorf("login from %s is not allowed for this account", ip)
+	}
+
+	return nil
+}
+
+func (as *AuthService) ClearAllowedIPs(userID int) error {
+	return as.db.ClearAllowedIPs(userID)
+}
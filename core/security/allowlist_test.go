@@ -0,0 +1,78 @@
+package security
+
+import "testing"
+
+func TestParseAllowEntry(t *testing.T) {
+	tests := []struct {
+		entry   string
+		want    string
+		wantErr bool
+	}{
+		{"192.168.1.10", "192.168.1.10/32", false},
+		{"10.1.2.3/8", "10.0.0.0/8", false},
+		{"::1", "::1/128", false},
+		{"fd00::/8", "fd00::/8", false},
+		{"not-an-ip", "", true},
+		{"10.0.0.0/33", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAllowEntry(tt.entry)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAllowEntry(%q) error = %v, wantErr %v", tt.entry, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAllowEntry(%q) = %q, want %q", tt.entry, got, tt.want)
+		}
+	}
+}
+
+func TestIPAllowed(t *testing.T) {
+	if !IPAllowed(nil, "1.2.3.4") {
+		t.Error("IPAllowed() with empty allowlist should allow everything")
+	}
+
+	cidrs := []string{"10.0.0.0/8", "192.168.1.5/32"}
+	tests := map[string]bool{
+		"10.20.30.40": true,
+		"192.168.1.5": true,
+		"192.168.1.6": false,
+		"garbage":     false,
+	}
+	for ip, want := range tests {
+		if got := IPAllowed(cidrs, ip); got != want {
+			t.Errorf("IPAllowed(%s) = %v, want %v", ip, got, want)
+		}
+	}
+}
+
+func TestLoginUserFromIPEnforcesAllowlist(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("allowuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := auth.AddAllowedIP(user.ID, "10.0.0.0/24"); err != nil {
+		t.Fatalf("AddAllowedIP() error = %v", err)
+	}
+
+	if _, _, err := auth.LoginUserFromIP("allowuser", "testpassword456", "10.0.0.7"); err != nil {
+		t.Errorf("LoginUserFromIP() from allowed IP error = %v", err)
+	}
+
+	if _, _, err := auth.LoginUserFromIP("allowuser", "testpassword456", "10.0.1.7"); err == nil {
+		t.Error("LoginUserFromIP() should reject an IP outside the allowlist")
+	}
+
+	key, _, err := auth.CreateAPIKey(user.ID, "bot", ScopeRead)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, _, err := auth.AuthenticateAPIKeyFromIP(key, "172.16.0.1"); err == nil {
+		t.Error("AuthenticateAPIKeyFromIP() should reject an IP outside the allowlist")
+	}
+}
@@ -0,0 +1,176 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+const (
+	// ScopeRead allows balance and stats lookups only
+	ScopeRead = "read"
+	// ScopePlay additionally allows placing bets and playing hands
+	ScopePlay = "play"
+
+	APIKeyPrefix      = "ck"
+	MaxAPIKeysPerUser = 10
+	MaxAPIKeyNameLen  = 30
+)
+
+func ValidateScope(scope string) error {
+	switch scope {
+	case ScopeRead, ScopePlay:
+		return nil
+	default:
+		return fmt.Errorf("scope must be %q or %q", ScopeRead, ScopePlay)
+	}
+}
+
+// ScopeAllows reports whether a key with scope may perform an action needing required
+func ScopeAllows(scope, required string) bool {
+	if scope == ScopePlay {
+		return true
+	}
+	return scope == required
+}
+
+// Generates a key of the form ck_<id>_<secret>; only the secret's hash is stored
+func GenerateAPIKey() (id, secret, key string, err error) {
+	idBytes := make([]byte, 6)
+	secretBytes := make([]byte, 24)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	id = hex.EncodeToString(idBytes)
+	secret = hex.EncodeToString(secretBytes)
+	key = fmt.Sprintf("%s_%s_%s", APIKeyPrefix, id, secret)
+	return id, secret, key, nil
+}
+
+func ParseAPIKey(key string) (id, secret string, err error) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != APIKeyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", "", fmt.Errorf("malformed api key")
+	}
+	return parts[1], parts[2], nil
+}
+
+// API key secrets are high-entropy random values, so a fast hash is sufficient
+func HashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func (as *AuthService) CreateAPIKey(userID int, name, scope string) (string, *vault.APIKey, error) {
+	if err := ValidateScope(scope); err != nil {
+		return "", nil, err
+	}
+	if name == "" || len(name) > MaxAPIKeyNameLen {
+		return "", nil, fmt.Errorf("key name must be 1-%d characters long", MaxAPIKeyNameLen)
+	}
+
+	existing, err := as.db.ListAPIKeys(userID)
+	if err != nil {
+		return "", nil, err
+	}
+	active := 0
+	for _, k := range existing {
+		if !k.IsRevoked() {
+			active++
+		}
+	}
+	if active >= MaxAPIKeysPerUser {
+		return "", nil, fmt.Errorf("maximum of %d active api keys reached", MaxAPIKeysPerUser)
+	}
+
+	id, secret, key, err := GenerateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	apiKey, err := as.db.CreateAPIKey(id, userID, name, HashAPIKeySecret(secret), scope)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return key, apiKey, nil
+}
+
+func (as *AuthService) ListAPIKeys(userID int) ([]*vault.APIKey, error) {
+	return as.db.ListAPIKeys(userID)
+}
+
+func (as *AuthService) RevokeAPIKey(userID int, id string) error {
+	return as.db.RevokeAPIKey(userID, id)
+}
+
+// Authenticates a full API key and returns its owner and the key record
+func (as *AuthService) AuthenticateAPIKey(key string) (*vault.User, *vault.APIKey, error) {
+	return as.AuthenticateAPIKeyFromIP(key, "")
+}
+
+// Authenticates like AuthenticateAPIKey and also enforces the owner's IP allowlist
+func (as *AuthService) AuthenticateAPIKeyFromIP(key, ip string) (*vault.User, *vault.APIKey, error) {
+	id, secret, err := ParseAPIKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid api key")
+	}
+
+	apiKey, err := as.db.GetAPIKey(id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid api key")
+	}
+
+	hash := HashAPIKeySecret(secret)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(apiKey.KeyHash)) != 1 || apiKey.IsRevoked() {
+		return nil, nil, fmt.Errorf("invalid api key")
+	}
+
+	user, err := as.db.GetUserByID(apiKey.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("user not found")
+	}
+
+	if err := as.CheckIPAllowed(user.ID, ip); err != nil {
+		return nil, nil, err
+	}
+	if err := as.checkBanned(user.ID); err != nil {
+		return nil, nil, err
+	}
+
+	if err := as.db.TouchAPIKey(id); err != nil {
+		return nil, nil, err
+	}
+
+	as.recordLogin(user, ip, "api key "+apiKey.ID)
+
+	return user, apiKey, nil
+}
+
+// Re-checks an already authenticated key by ID so revocation takes effect mid-connection
+func (as *AuthService) ValidateAPIKey(id string) (*vault.User, error) {
+	apiKey, err := as.db.GetAPIKey(id)
+	if err != nil || apiKey.IsRevoked() {
+		return nil, fmt.Errorf("api key revoked")
+	}
+
+	user, err := as.db.GetUserByID(apiKey.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	if err := as.checkBanned(user.ID); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,97 @@
+package security
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateAndParseAPIKey(t *testing.T) {
+	id, secret, key, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey() error = %v", err)
+	}
+
+	if !strings.HasPrefix(key, APIKeyPrefix+"_") {
+		t.Errorf("GenerateAPIKey() key = %s, want prefix %s_", key, APIKeyPrefix)
+	}
+
+	parsedID, parsedSecret, err := ParseAPIKey(key)
+	if err != nil {
+		t.Fatalf("ParseAPIKey() error = %v", err)
+	}
+	if parsedID != id || parsedSecret != secret {
+		t.Errorf("ParseAPIKey() = (%s, %s), want (%s, %s)", parsedID, parsedSecret, id, secret)
+	}
+
+	for _, bad := range []string{"", "ck_only", "xx_a_b", "ck__b", "ck_a_b_c"} {
+		if _, _, err := ParseAPIKey(bad); err == nil {
+			t.Errorf("ParseAPIKey(%q) should fail", bad)
+		}
+	}
+}
+
+func TestScopeAllows(t *testing.T) {
+	tests := []struct {
+		scope, required string
+		want            bool
+	}{
+		{ScopeRead, ScopeRead, true},
+		{ScopeRead, ScopePlay, false},
+		{ScopePlay, ScopeRead, true},
+		{ScopePlay, ScopePlay, true},
+	}
+
+	for _, tt := range tests {
+		if got := ScopeAllows(tt.scope, tt.required); got != tt.want {
+			t.Errorf("ScopeAllows(%s, %s) = %v, want %v", tt.scope, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("botowner", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, _, err := auth.CreateAPIKey(user.ID, "bot", "admin"); err == nil {
+		t.Error("CreateAPIKey() should reject unknown scope")
+	}
+
+	key, apiKey, err := auth.CreateAPIKey(user.ID, "bot", ScopePlay)
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+
+	if apiKey.KeyHash == key || strings.Contains(key, apiKey.KeyHash) {
+		t.Error("CreateAPIKey() stored the plaintext key")
+	}
+
+	authUser, authKey, err := auth.AuthenticateAPIKey(key)
+	if err != nil {
+		t.Fatalf("AuthenticateAPIKey() error = %v", err)
+	}
+	if authUser.ID != user.ID || authKey.Scope != ScopePlay {
+		t.Errorf("AuthenticateAPIKey() = user %d scope %s, want user %d scope %s", authUser.ID, authKey.Scope, user.ID, ScopePlay)
+	}
+
+	// Right ID with the wrong secret must fail
+	if _, _, err := auth.AuthenticateAPIKey(APIKeyPrefix + "_" + apiKey.ID + "_deadbeef"); err == nil {
+		t.Error("AuthenticateAPIKey() should fail for wrong secret")
+	}
+
+	if err := auth.RevokeAPIKey(user.ID, apiKey.ID); err != nil {
+		t.Fatalf("RevokeAPIKey() error = %v", err)
+	}
+
+	if _, _, err := auth.AuthenticateAPIKey(key); err == nil {
+		t.Error("AuthenticateAPIKey() should fail for revoked key")
+	}
+
+	if _, err := auth.ValidateAPIKey(apiKey.ID); err == nil {
+		t.Error("ValidateAPIKey() should fail for revoked key")
+	}
+}
@@ -2,19 +2,134 @@ package security
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/alessandrosisniegas/casino/core/vault"
 )
 
+// Generic signup error used when HideUsernameExistence is enabled
+const errSignupFailed = "unable to create account with those credentials"
+
+// How long ValidateSession trusts the account and ban status it read for a
+// signed token before reading them again. Bans and admin changes made
+// through this service drop the cached copy at once; ones made by another
+// server take up to this long.
+const sessionCacheTTL = 10 * time.Second
+
+type AuthConfig struct {
+	// Hides whether a username is taken during signup and performs the same
+	// hashing work for taken and free usernames
+	HideUsernameExistence bool
+
+	// When set, logins issue HMAC-signed tokens instead of database sessions
+	TokenSecret []byte
+
+	// Maximum signups from one IP in 24 hours; zero disables the limit
+	SignupsPerIPPerDay int
+
+	// Requires an admin-issued invite code for every signup
+	RequireInviteCode bool
+
+	// Secret peppers mixed into password hashes; nil keeps plain bcrypt
+	Peppers *PepperSet
+
+	// Free chips in cents granted by ClaimDailyBonus; zero disables the bonus
+	DailyBonus int64
+
+	// Rebuy tops a balance under RebuyBelow (normally the table minimum) up to
+	// RebuyAmount, at most RebuysPerDay times a day; zero amount disables it
+	RebuyAmount  int64
+	RebuyBelow   int64
+	RebuysPerDay int
+
+	// Most a player may send with TRANSFER in 24 hours; zero means no limit
+	TransferDailyLimit int64
+
+	// DEPOSIT and WITHDRAW move between the Min and Max cents each time; zero
+	// max disables the command
+	DepositMin  int64
+	DepositMax  int64
+	WithdrawMin int64
+	WithdrawMax int64
+
+	// Percentage of each wager paid into the progressive jackpot, which
+	// restarts at JackpotSeed cents after a win; zero percent disables it
+	JackpotPercent int
+	JackpotSeed    int64
+
+	// Opted-in players get CashbackPercent of their net losses back after each
+	// CashbackPeriod (LimitDaily or LimitWeekly); zero percent disables it
+	CashbackPercent int
+	CashbackPeriod  string
+
+	// A signup with a referral code earns ReferralBonus cents for both players
+	// once the new account has wagered ReferralWager; zero bonus disables it
+	ReferralBonus int64
+	ReferralWager int64
+
+	// GUEST creates a temporary account with GuestBalance cents that is purged
+	// after GuestIdleTimeout without activity; zero balance disables guests
+	GuestBalance     int64
+	GuestIdleTimeout time.Duration
+
+	// StreakBonus cents are credited when a win streak reaches one of
+	// StreakMilestones; zero disables it
+	StreakBonus      int64
+	StreakMilestones []int64
+}
+
 type AuthService struct {
-	db *vault.DB
+	db       *vault.DB
+	config   AuthConfig
+	sessions SessionStore
+
+	tokens    *TokenSigner
+	revokedMu sync.RWMutex
+	revoked   map[string]time.Time
+
+	// Accounts behind signed tokens, by user ID, so validating one doesn't
+	// read the database on every command
+	sessionUsersMu sync.Mutex
+	sessionUsers   map[int]sessionUser
+
+	onNewIPLogin func(user *vault.User, ip string)
+	chatFilter   ChatFilter
+
+	featuresMu sync.RWMutex
+	features   map[string]bool // Cached feature flags
 }
 
 func NewAuthService(db *vault.DB) *AuthService {
-	return &AuthService{db: db}
+	return &AuthService{db: db, sessions: db}
+}
+
+func NewAuthServiceWithConfig(db *vault.DB, config AuthConfig) *AuthService {
+	as := &AuthService{db: db, config: config, sessions: db}
+	if len(config.TokenSecret) > 0 {
+		as.tokens = NewTokenSigner(config.TokenSecret)
+		as.revoked = make(map[string]time.Time)
+		as.sessionUsers = make(map[int]sessionUser)
+		// Revocations are persisted so logged-out tokens stay dead across restarts
+		if revoked, err := db.ListRevokedTokens(); err == nil {
+			as.revoked = revoked
+		}
+	}
+	return as
+}
+
+// RegisterOptions carries connection details used by signup abuse checks
+type RegisterOptions struct {
+	IP           string
+	InviteCode   string
+	ReferralCode string
 }
 
 func (as *AuthService) RegisterUser(username, password string) (*vault.User, error) {
+	return as.RegisterUserWithOptions(username, password, RegisterOptions{})
+}
+
+func (as *AuthService) RegisterUserWithOptions(username, password string, opts RegisterOptions) (*vault.User, error) {
 	if err := ValidateUsername(username); err != nil {
 		return nil, err
 	}
@@ -23,59 +138,333 @@ func (as *AuthService) RegisterUser(username, password string) (*vault.User, err
 		return nil, err
 	}
 
+	if isGuestName(username) {
+		return nil, fmt.Errorf("usernames starting with %q are reserved for guests", GuestPrefix)
+	}
+
+	if err := as.checkSignupRate(opts.IP); err != nil {
+		return nil, err
+	}
+
+	if as.config.RequireInviteCode && opts.InviteCode == "" {
+		return nil, fmt.Errorf("an invite code is required to sign up")
+	}
+
+	var referrerID int
+	if opts.ReferralCode != "" {
+		id, err := as.referrer(opts.ReferralCode)
+		if err != nil {
+			return nil, err
+		}
+		referrerID = id
+	}
+
+	hide := as.config.HideUsernameExistence
+	var hashedPassword string
+	var err error
+
+	if hide {
+		// Hash before the lookup so taken and free usernames cost the same
+		if hashedPassword, err = as.hashPassword(password); err != nil {
+			return nil, err
+		}
+	}
+
 	if _, err := as.db.GetUserByUsername(username); err == nil {
+		if hide {
+			return nil, fmt.Errorf(errSignupFailed)
+		}
 		return nil, fmt.Errorf("username already exists")
 	}
 
-	hashedPassword, err := HashPassword(password)
-	if err != nil {
-		return nil, err
+	if !hide {
+		if hashedPassword, err = as.hashPassword(password); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.InviteCode != "" {
+		if err := as.db.UseInviteCode(opts.InviteCode); err != nil {
+			return nil, err
+		}
 	}
 
 	user, err := as.db.CreateUser(username, hashedPassword)
 	if err != nil {
+		if opts.InviteCode != "" {
+			as.db.ReleaseInviteCode(opts.InviteCode)
+		}
+		if hide {
+			return nil, fmt.Errorf(errSignupFailed)
+		}
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	detail := ""
+	if opts.InviteCode != "" {
+		detail = "invite " + opts.InviteCode
+	}
+	as.db.RecordAuditEvent(user.ID, vault.AuditSignup, opts.IP, detail)
+
+	if referrerID != 0 {
+		as.recordReferral(referrerID, user.ID, opts.ReferralCode, opts.IP)
+	}
+
 	return user, nil
 }
 
+// Caps how many accounts one address can create per day; an empty ip skips the check
+func (as *AuthService) checkSignupRate(ip string) error {
+	if ip == "" || as.config.SignupsPerIPPerDay <= 0 {
+		return nil
+	}
+
+	count, err := as.db.CountAuditEventsByIP(vault.AuditSignup, ip, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	if count >= as.config.SignupsPerIPPerDay {
+		return fmt.Errorf("too many accounts created from this address, try again later")
+	}
+
+	return nil
+}
+
 func (as *AuthService) LoginUser(username, password string) (string, *vault.User, error) {
+	return as.LoginUserFromIP(username, password, "")
+}
+
+// Logs in like LoginUser and also enforces the account's IP allowlist
+func (as *AuthService) LoginUserFromIP(username, password, ip string) (string, *vault.User, error) {
 	user, err := as.db.GetUserByUsername(username)
 	if err != nil {
+		// Still run bcrypt so unknown usernames aren't distinguishable by timing
+		VerifyDummyPassword(password)
 		return "", nil, fmt.Errorf("invalid username or password")
 	}
 
-	if err := VerifyPassword(password, user.Password); err != nil {
+	if err := as.verifyPassword(user.ID, password, user.Password); err != nil {
 		return "", nil, fmt.Errorf("invalid username or password")
 	}
 
-	sessionID := GenerateSessionID()
-	expiresAt := GetSessionExpiry()
+	// Checked after the password so the allowlist and bans aren't revealed to guessers
+	if err := as.CheckIPAllowed(user.ID, ip); err != nil {
+		return "", nil, err
+	}
+	if err := as.checkBanned(user.ID); err != nil {
+		return "", nil, err
+	}
 
-	if err := as.db.CreateSession(sessionID, user.ID, expiresAt); err != nil {
-		return "", nil, fmt.Errorf("failed to create session: %w", err)
+	as.recordLogin(user, ip, "password")
+
+	sessionID, err := as.startSession(user.ID)
+	if err != nil {
+		return "", nil, err
 	}
 
 	return sessionID, user, nil
 }
 
+// Logs back in with the session of an earlier connection, enforcing the
+// account's IP allowlist as a fresh login would
+func (as *AuthService) ResumeSessionFromIP(sessionID, ip string) (*vault.User, error) {
+	user, err := as.ValidateSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := as.CheckIPAllowed(user.ID, ip); err != nil {
+		return nil, err
+	}
+
+	as.recordLogin(user, ip, "resume")
+
+	return user, nil
+}
+
+// Issues a signed token or database session for a user who has authenticated
+func (as *AuthService) startSession(userID int) (string, error) {
+	expiresAt := GetSessionExpiry()
+
+	if as.tokens != nil {
+		token, _, err := as.tokens.Issue(userID, expiresAt)
+		if err != nil {
+			return "", fmt.Errorf("failed to create session: %w", err)
+		}
+		return token, nil
+	}
+
+	sessionID := GenerateSessionID()
+
+	if err := as.sessions.CreateSession(sessionID, userID, expiresAt); err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return sessionID, nil
+}
+
+// Registers a callback run when an account logs in from an address it hasn't used before
+func (as *AuthService) SetNewIPLoginHandler(handler func(user *vault.User, ip string)) {
+	as.onNewIPLogin = handler
+}
+
+// Audits a successful login and raises a new-IP alert when the address is unfamiliar
+func (as *AuthService) recordLogin(user *vault.User, ip, method string) {
+	if ip == "" {
+		return
+	}
+
+	as.db.RecordAuditEvent(user.ID, vault.AuditLogin, ip, method)
+
+	seenBefore, hadPrevious, err := as.db.RecordLoginIP(user.ID, ip)
+	if err != nil || seenBefore || !hadPrevious {
+		// The very first login establishes the baseline rather than alerting
+		return
+	}
+
+	as.db.RecordAuditEvent(user.ID, vault.AuditNewIPLogin, ip, method)
+	if as.onNewIPLogin != nil {
+		as.onNewIPLogin(user, ip)
+	}
+}
+
+// Resolves a session to its user. A signed token is checked from its claims
+// and a cached copy of the account no older than sessionCacheTTL, so its
+// balance may be that old; a database session reads the account every time.
 func (as *AuthService) ValidateSession(sessionID string) (*vault.User, error) {
-	session, err := as.db.GetSession(sessionID)
+	userID, err := as.SessionUserID(sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid or expired session")
+		return nil, err
+	}
+	if as.tokens != nil && IsSignedToken(sessionID) {
+		return as.sessionUser(userID)
 	}
 
-	user, err := as.db.GetUserByID(session.UserID)
+	user, err := as.db.GetUserByID(userID)
 	if err != nil {
 		return nil, fmt.Errorf("user not found")
 	}
 
+	// Checked again in case a session store missed the ban's cleanup
+	if err := as.checkBanned(user.ID); err != nil {
+		return nil, err
+	}
+
 	return user, nil
 }
 
+// An account read for a signed token and whether it was banned then
+type sessionUser struct {
+	user   vault.User
+	banErr error
+	read   time.Time
+}
+
+// Returns the account behind a signed token, reading it and its ban again
+// once the cached copy is older than sessionCacheTTL
+func (as *AuthService) sessionUser(userID int) (*vault.User, error) {
+	as.sessionUsersMu.Lock()
+	cached, ok := as.sessionUsers[userID]
+	as.sessionUsersMu.Unlock()
+
+	if !ok || time.Since(cached.read) >= sessionCacheTTL {
+		user, err := as.db.GetUserByID(userID)
+		if err != nil {
+			return nil, fmt.Errorf("user not found")
+		}
+		// Signed tokens can't be revoked one user at a time, so a ban ends them here
+		banErr := as.checkBanned(userID)
+		cached = sessionUser{user: *user, banErr: banErr, read: time.Now()}
+
+		as.sessionUsersMu.Lock()
+		as.sessionUsers[userID] = cached
+		as.sessionUsersMu.Unlock()
+	}
+
+	if cached.banErr != nil {
+		return nil, cached.banErr
+	}
+	user := cached.user
+	return &user, nil
+}
+
+// Drops the cached account of a user whose ban or rights just changed
+func (as *AuthService) forgetSessionUser(userID int) {
+	if as.tokens == nil {
+		return
+	}
+	as.sessionUsersMu.Lock()
+	delete(as.sessionUsers, userID)
+	as.sessionUsersMu.Unlock()
+}
+
+// Resolves a session to its user ID; signed tokens are checked without a database query
+func (as *AuthService) SessionUserID(sessionID string) (int, error) {
+	if as.tokens != nil && IsSignedToken(sessionID) {
+		claims, err := as.tokens.Verify(sessionID)
+		if err != nil || as.isTokenRevoked(claims.ID) {
+			return 0, fmt.Errorf("invalid or expired session")
+		}
+		return claims.UserID, nil
+	}
+
+	session, err := as.sessions.GetSession(sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("invalid or expired session")
+	}
+
+	return session.UserID, nil
+}
+
 func (as *AuthService) LogoutUser(sessionID string) error {
-	return as.db.DeleteSession(sessionID)
+	if as.tokens != nil && IsSignedToken(sessionID) {
+		claims, err := as.tokens.Verify(sessionID)
+		if err != nil {
+			// Already expired or forged, nothing to revoke
+			return nil
+		}
+
+		as.revokedMu.Lock()
+		as.revoked[claims.ID] = claims.ExpiresAt
+		as.revokedMu.Unlock()
+
+		return as.db.RevokeToken(claims.ID, claims.ExpiresAt)
+	}
+
+	return as.sessions.DeleteSession(sessionID)
+}
+
+// Drops revocation entries for tokens that have expired on their own
+func (as *AuthService) PruneRevokedTokens() error {
+	if as.tokens == nil {
+		return nil
+	}
+
+	as.revokedMu.Lock()
+	for id, expiresAt := range as.revoked {
+		if IsSessionExpired(expiresAt) {
+			delete(as.revoked, id)
+		}
+	}
+	as.revokedMu.Unlock()
+
+	as.sessionUsersMu.Lock()
+	for id, cached := range as.sessionUsers {
+		if time.Since(cached.read) >= sessionCacheTTL {
+			delete(as.sessionUsers, id)
+		}
+	}
+	as.sessionUsersMu.Unlock()
+
+	return as.db.CleanupRevokedTokens()
+}
+
+func (as *AuthService) isTokenRevoked(tokenID string) bool {
+	as.revokedMu.RLock()
+	defer as.revokedMu.RUnlock()
+	_, revoked := as.revoked[tokenID]
+	return revoked
 }
 
 func (as *AuthService) GetUserStats(userID int) (*vault.UserStats, error) {
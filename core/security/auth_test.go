@@ -210,6 +210,43 @@ func TestLogoutUser(t *testing.T) {
 	}
 }
 
+func TestResumeSession(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("testuser123", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	sessionID, _, err := auth.LoginUser("testuser123", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	resumed, err := auth.ResumeSessionFromIP(sessionID, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("ResumeSessionFromIP() error = %v", err)
+	}
+	if resumed.ID != user.ID {
+		t.Errorf("ResumeSessionFromIP() user ID = %v, want %v", resumed.ID, user.ID)
+	}
+
+	if _, err := auth.AddAllowedIP(user.ID, "192.168.0.0/16"); err != nil {
+		t.Fatalf("AddAllowedIP() error = %v", err)
+	}
+	if _, err := auth.ResumeSessionFromIP(sessionID, "10.0.0.1"); err == nil {
+		t.Error("ResumeSessionFromIP() should refuse an address outside the allowlist")
+	}
+
+	if err := auth.LogoutUser(sessionID); err != nil {
+		t.Fatalf("LogoutUser() error = %v", err)
+	}
+	if _, err := auth.ResumeSessionFromIP(sessionID, "192.168.1.1"); err == nil {
+		t.Error("ResumeSessionFromIP() should fail after logout")
+	}
+}
+
 func TestUpdateBalance(t *testing.T) {
 	auth, cleanup := setupTestAuthService(t)
 	defer cleanup()
@@ -242,3 +279,51 @@ func TestUpdateBalance(t *testing.T) {
 		t.Errorf("UpdateBalance() balance = %v, want %v", updatedUser.Balance, newBalance)
 	}
 }
+
+func TestRegisterDuplicateUserHidden(t *testing.T) {
+	db, err := vault.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	auth := NewAuthServiceWithConfig(db, AuthConfig{HideUsernameExistence: true})
+
+	if _, err := auth.RegisterUser("testuser123", "testpassword456"); err != nil {
+		t.Fatalf("First RegisterUser() error = %v", err)
+	}
+
+	_, err = auth.RegisterUser("testuser123", "otherpassword789")
+	if err == nil {
+		t.Fatal("RegisterUser() should fail for duplicate username")
+	}
+
+	if err.Error() != errSignupFailed {
+		t.Errorf("RegisterUser() error = %q, want %q", err.Error(), errSignupFailed)
+	}
+}
+
+func TestNewIPLoginAlert(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	var alerts []string
+	auth.SetNewIPLoginHandler(func(user *vault.User, ip string) {
+		alerts = append(alerts, user.Username+"@"+ip)
+	})
+
+	if _, err := auth.RegisterUser("testuser123", "testpassword456"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	for _, ip := range []string{"10.0.0.1", "10.0.0.1", "10.0.0.2", "10.0.0.2", ""} {
+		if _, _, err := auth.LoginUserFromIP("testuser123", "testpassword456", ip); err != nil {
+			t.Fatalf("LoginUserFromIP() error = %v", err)
+		}
+	}
+
+	// The first address sets the baseline, only the switch to 10.0.0.2 alerts
+	if len(alerts) != 1 || alerts[0] != "testuser123@10.0.0.2" {
+		t.Errorf("alerts = %v, want [testuser123@10.0.0.2]", alerts)
+	}
+}
@@ -0,0 +1,97 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Longest operator note stored with a ban
+const MaxBanReasonLength = 200
+
+// Suspends a user for duration, or for good when it is zero. Their database
+// sessions end at once and logins are refused until the ban runs out; actor
+// is recorded in the audit log. Returns when the ban ends, zero if never.
+func (as *AuthService) BanUser(actor string, userID int, duration time.Duration, reason, ip string) (time.Time, error) {
+	reason = strings.TrimSpace(reason)
+	if len(reason) > MaxBanReasonLength {
+		return time.Time{}, fmt.Errorf("reason must be at most %d characters", MaxBanReasonLength)
+	}
+	if duration < 0 {
+		return time.Time{}, fmt.Errorf("ban duration must be positive")
+	}
+
+	user, err := as.db.GetUserByID(userID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("user not found")
+	}
+	if user.IsAdmin {
+		return time.Time{}, fmt.Errorf("%s is an admin, revoke their admin rights first", user.Username)
+	}
+
+	var until time.Time
+	if duration > 0 {
+		until = time.Now().Add(duration)
+	}
+	if err := as.db.BanUser(userID, until, reason, actor); err != nil {
+		return time.Time{}, err
+	}
+	as.forgetSessionUser(userID)
+	if err := as.sessions.DeleteUserSessions(userID); err != nil {
+		return time.Time{}, err
+	}
+
+	detail := "permanently by " + actor
+	if duration > 0 {
+		detail = fmt.Sprintf("for %s by %s", duration, actor)
+	}
+	if reason != "" {
+		detail += ": " + reason
+	}
+	as.db.RecordAuditEvent(userID, vault.AuditBan, ip, detail)
+
+	return until, nil
+}
+
+func (as *AuthService) UnbanUser(actor string, userID int, ip string) error {
+	removed, err := as.db.UnbanUser(userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("user is not banned")
+	}
+	as.forgetSessionUser(userID)
+
+	as.db.RecordAuditEvent(userID, vault.AuditUnban, ip, "by "+actor)
+
+	return nil
+}
+
+// Lists the bans in force
+func (as *AuthService) ListBans() ([]*vault.Ban, error) {
+	return as.db.ListBans()
+}
+
+// Refuses a banned user, telling them until when and why
+func (as *AuthService) checkBanned(userID int) error {
+	ban, err := as.db.GetBan(userID)
+	if err != nil {
+		return err
+	}
+	if ban == nil || !ban.Active(time.Now()) {
+		return nil
+	}
+
+	message := "account suspended"
+	if ban.Until.Valid {
+		message += " until " + ban.Until.Time.Local().Format("2006-01-02 15:04")
+	}
+	if ban.Reason != "" {
+		message += ": " + ban.Reason
+	}
+	return errors.New(message)
+}
@@ -0,0 +1,111 @@
+package security
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func TestBanUser(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("banneduser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	sessionID, _, err := auth.LoginUser("banneduser", "testpassword456")
+	if err != nil {
+		t.Fatalf("LoginUser() error = %v", err)
+	}
+	key, _, err := auth.CreateAPIKey(user.ID, "bot", "play")
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+
+	until, err := auth.BanUser("console", user.ID, 24*time.Hour, "chargeback fraud", "")
+	if err != nil {
+		t.Fatalf("BanUser() error = %v", err)
+	}
+	if until.Before(time.Now().Add(23 * time.Hour)) {
+		t.Errorf("BanUser() until = %v, want about a day from now", until)
+	}
+
+	if _, err := auth.ValidateSession(sessionID); err == nil {
+		t.Error("ValidateSession() should fail once the user is banned")
+	}
+	_, _, err = auth.LoginUser("banneduser", "testpassword456")
+	if err == nil || !strings.Contains(err.Error(), "chargeback fraud") {
+		t.Errorf("LoginUser() error = %v, want the ban's reason", err)
+	}
+	if _, _, err := auth.AuthenticateAPIKey(key); err == nil {
+		t.Error("AuthenticateAPIKey() should fail once the user is banned")
+	}
+	// The ban isn't revealed without the password
+	if _, _, err := auth.LoginUser("banneduser", "wrongpassword"); err == nil || strings.Contains(err.Error(), "suspended") {
+		t.Errorf("LoginUser() with a wrong password error = %v, want invalid username or password", err)
+	}
+
+	bans, err := auth.ListBans()
+	if err != nil || len(bans) != 1 || bans[0].UserID != user.ID {
+		t.Errorf("ListBans() = %+v, %v, want banneduser", bans, err)
+	}
+
+	if err := auth.UnbanUser("console", user.ID, ""); err != nil {
+		t.Fatalf("UnbanUser() error = %v", err)
+	}
+	if err := auth.UnbanUser("console", user.ID, ""); err == nil {
+		t.Error("UnbanUser() should fail when the user isn't banned")
+	}
+	if _, _, err := auth.LoginUser("banneduser", "testpassword456"); err != nil {
+		t.Errorf("LoginUser() after UnbanUser() error = %v", err)
+	}
+
+	events, err := auth.db.ListAuditEvents(user.ID, 10)
+	if err != nil {
+		t.Fatalf("ListAuditEvents() error = %v", err)
+	}
+	var banned, unbanned bool
+	for _, e := range events {
+		banned = banned || (e.Type == vault.AuditBan && strings.Contains(e.Detail, "chargeback fraud"))
+		unbanned = unbanned || e.Type == vault.AuditUnban
+	}
+	if !banned || !unbanned {
+		t.Errorf("Audit log = %+v, want ban and unban events", events)
+	}
+}
+
+func TestBanUserPermanent(t *testing.T) {
+	auth, db := setupTokenAuthService(t)
+
+	user, err := auth.RegisterUser("foreveruser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	token, _, err := auth.LoginUser("foreveruser", "testpassword456")
+	if err != nil {
+		t.Fatalf("LoginUser() error = %v", err)
+	}
+
+	until, err := auth.BanUser("console", user.ID, 0, "", "")
+	if err != nil || !until.IsZero() {
+		t.Fatalf("BanUser() = %v, %v, want a permanent ban", until, err)
+	}
+	// Signed tokens can't be deleted, so they are refused instead
+	if _, err := auth.ValidateSession(token); err == nil || err.Error() != "account suspended" {
+		t.Errorf("ValidateSession() error = %v, want account suspended", err)
+	}
+
+	admin, err := auth.RegisterUser("adminuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := db.SetUserAdmin(admin.ID, true); err != nil {
+		t.Fatalf("SetUserAdmin() error = %v", err)
+	}
+	if _, err := auth.BanUser("console", admin.ID, time.Hour, "", ""); err == nil {
+		t.Error("BanUser() should refuse to ban an admin")
+	}
+}
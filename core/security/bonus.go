@@ -0,0 +1,84 @@
+package security
+
+import (
+	"fmt"
+	"time"
+)
+
+// How long a player waits between daily bonus claims
+const DailyBonusCooldown = 24 * time.Hour
+
+// Credits the daily bonus, once per DailyBonusCooldown, and returns the amount and new balance
+func (as *AuthService) ClaimDailyBonus(userID int) (amount, balance int64, err error) {
+	if as.config.DailyBonus <= 0 {
+		return 0, 0, fmt.Errorf("the daily bonus is not available")
+	}
+
+	// Higher VIP tiers get a bigger bonus
+	tier, err := as.userTier(userID)
+	if err != nil {
+		return 0, 0, err
+	}
+	amount = as.config.DailyBonus * int64(tier.DailyBonusPercent) / 100
+
+	balance, claimed, err := as.db.ClaimDailyBonus(userID, amount, time.Now().Add(-DailyBonusCooldown))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if !claimed {
+		next, err := as.NextDailyBonus(userID)
+		if err != nil {
+			return 0, 0, err
+		}
+		wait := time.Until(next).Round(time.Minute)
+		return 0, 0, fmt.Errorf("daily bonus already claimed, next claim in %s", formatWait(wait))
+	}
+
+	return amount, balance, nil
+}
+
+// Returns when the daily bonus can next be claimed; the zero time means now
+func (as *AuthService) NextDailyBonus(userID int) (time.Time, error) {
+	last, ok, err := as.db.GetLastBonusClaim(userID)
+	if err != nil || !ok {
+		return time.Time{}, err
+	}
+
+	next := last.Add(DailyBonusCooldown)
+	if next.Before(time.Now()) {
+		return time.Time{}, nil
+	}
+	return next, nil
+}
+
+// Tops up a broke player's balance to the configured rebuy amount
+func (as *AuthService) Rebuy(userID int) (int64, error) {
+	if as.config.RebuyAmount <= 0 || as.config.RebuysPerDay <= 0 {
+		return 0, fmt.Errorf("rebuys are not available")
+	}
+
+	user, err := as.db.GetUserByID(userID)
+	if err != nil {
+		return 0, err
+	}
+	if user.Balance >= as.config.RebuyBelow || user.Balance >= as.config.RebuyAmount {
+		return 0, fmt.Errorf("rebuy is only available when your balance is below $%.2f",
+			float64(min(as.config.RebuyBelow, as.config.RebuyAmount))/100)
+	}
+
+	return as.db.Rebuy(userID, as.config.RebuyAmount, as.config.RebuysPerDay, time.Now().Add(-24*time.Hour))
+}
+
+// Formats a wait as "5h 3m", never shorter than a minute
+func formatWait(d time.Duration) string {
+	if d < time.Minute {
+		d = time.Minute
+	}
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
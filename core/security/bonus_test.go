@@ -0,0 +1,102 @@
+package security
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func TestClaimDailyBonus(t *testing.T) {
+	auth := setupConfiguredAuthService(t, AuthConfig{DailyBonus: 10000})
+
+	user, err := auth.RegisterUser("bonususer", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	amount, balance, err := auth.ClaimDailyBonus(user.ID)
+	if err != nil {
+		t.Fatalf("ClaimDailyBonus() error = %v", err)
+	}
+	if amount != 10000 || balance != 1010000 {
+		t.Errorf("ClaimDailyBonus() = %d, %d, want 10000 leaving 1010000", amount, balance)
+	}
+
+	_, _, err = auth.ClaimDailyBonus(user.ID)
+	if err == nil || !strings.Contains(err.Error(), "next claim in") {
+		t.Errorf("Second ClaimDailyBonus() error = %v, want cooldown message", err)
+	}
+
+	next, err := auth.NextDailyBonus(user.ID)
+	if err != nil {
+		t.Fatalf("NextDailyBonus() error = %v", err)
+	}
+	if until := time.Until(next); until < 23*time.Hour || until > DailyBonusCooldown {
+		t.Errorf("NextDailyBonus() in %v, want about 24h", until)
+	}
+}
+
+func TestDailyBonusDisabled(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("bonususer", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, _, err := auth.ClaimDailyBonus(user.ID); err == nil {
+		t.Error("ClaimDailyBonus() should fail when no bonus is configured")
+	}
+}
+
+func TestFormatWait(t *testing.T) {
+	tests := []struct {
+		wait time.Duration
+		want string
+	}{
+		{10 * time.Second, "1m"},
+		{45 * time.Minute, "45m"},
+		{5*time.Hour + 3*time.Minute, "5h 3m"},
+	}
+
+	for _, tt := range tests {
+		if got := formatWait(tt.wait); got != tt.want {
+			t.Errorf("formatWait(%v) = %q, want %q", tt.wait, got, tt.want)
+		}
+	}
+}
+
+func TestRebuy(t *testing.T) {
+	auth := setupConfiguredAuthService(t, AuthConfig{RebuyAmount: 10000, RebuyBelow: 100, RebuysPerDay: 1})
+
+	user, err := auth.RegisterUser("rebuyuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := auth.Rebuy(user.ID); err == nil {
+		t.Error("Rebuy() should refuse a player who can still bet")
+	}
+
+	if _, err := auth.AdjustBalance(user.ID, -999950, vault.TxBet); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	balance, err := auth.Rebuy(user.ID)
+	if err != nil {
+		t.Fatalf("Rebuy() error = %v", err)
+	}
+	if balance != 10000 {
+		t.Errorf("Rebuy() balance = %d, want 10000", balance)
+	}
+
+	if _, err := auth.AdjustBalance(user.ID, -10000, vault.TxBet); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := auth.Rebuy(user.ID); err == nil || !strings.Contains(err.Error(), "limit of 1") {
+		t.Errorf("Rebuy() error = %v, want daily limit", err)
+	}
+}
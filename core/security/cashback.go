@@ -0,0 +1,142 @@
+package security
+
+import (
+	"fmt"
+	"time"
+)
+
+// Promotion name used for opt-ins and payouts
+const PromoCashback = "cashback"
+
+// CashbackPayout is a rebate credited when a cashback period is settled
+type CashbackPayout struct {
+	UserID  int
+	Amount  int64
+	Balance int64
+}
+
+// CashbackStatus describes a player's standing in the current cashback period
+type CashbackStatus struct {
+	OptedIn  bool
+	Percent  int
+	Start    time.Time
+	End      time.Time
+	NetLoss  int64
+	Estimate int64
+}
+
+func (as *AuthService) CashbackEnabled() bool {
+	return as.config.CashbackPercent > 0
+}
+
+// Returns the start of the cashback period containing t. Daily periods start
+// at midnight UTC and weekly ones on Monday.
+func (as *AuthService) CashbackPeriodStart(t time.Time) time.Time {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if as.config.CashbackPeriod == LimitWeekly {
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	}
+	return start
+}
+
+func (as *AuthService) cashbackPeriodEnd(start time.Time) time.Time {
+	if as.config.CashbackPeriod == LimitWeekly {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+func (as *AuthService) SetCashbackOptIn(userID int, optIn bool) error {
+	if !as.CashbackEnabled() {
+		return fmt.Errorf("cashback is not available")
+	}
+	if optIn {
+		return as.db.OptInPromotion(userID, PromoCashback)
+	}
+	return as.db.OptOutPromotion(userID, PromoCashback)
+}
+
+func (as *AuthService) GetCashbackStatus(userID int) (*CashbackStatus, error) {
+	if !as.CashbackEnabled() {
+		return nil, fmt.Errorf("cashback is not available")
+	}
+
+	start := as.CashbackPeriodStart(time.Now())
+	status := &CashbackStatus{Percent: as.config.CashbackPercent, Start: start, End: as.cashbackPeriodEnd(start)}
+
+	optedInAt, ok, err := as.db.GetPromotionOptIn(userID, PromoCashback)
+	if err != nil || !ok {
+		return status, err
+	}
+
+	status.OptedIn = true
+	if status.NetLoss, err = as.netLoss(userID, maxTime(start, optedInAt), status.End); err != nil {
+		return nil, err
+	}
+	status.Estimate = status.NetLoss * int64(status.Percent) / 100
+
+	return status, nil
+}
+
+// Pays cashback for the last period that ended before now to every opted-in
+// player. Periods are only paid once, so running it repeatedly is safe.
+func (as *AuthService) SettleCashback(now time.Time) ([]CashbackPayout, error) {
+	if !as.CashbackEnabled() {
+		return nil, nil
+	}
+
+	end := as.CashbackPeriodStart(now)
+	start := as.CashbackPeriodStart(end.Add(-time.Second))
+
+	optIns, err := as.db.ListPromotionOptIns(PromoCashback)
+	if err != nil {
+		return nil, err
+	}
+
+	var payouts []CashbackPayout
+	for _, o := range optIns {
+		// Only losses made while opted in count
+		from := maxTime(start, o.OptedInAt)
+		if !from.Before(end) {
+			continue
+		}
+
+		loss, err := as.netLoss(o.UserID, from, end)
+		if err != nil {
+			return payouts, err
+		}
+		amount := loss * int64(as.config.CashbackPercent) / 100
+		if amount <= 0 {
+			continue
+		}
+
+		note := fmt.Sprintf("%s %d%% of $%.2f lost %s", PromoCashback, as.config.CashbackPercent,
+			float64(loss)/100, start.Format("2006-01-02"))
+		balance, paid, err := as.db.PayPromotion(o.UserID, PromoCashback, start, amount, note)
+		if err != nil {
+			return payouts, err
+		}
+		if paid {
+			payouts = append(payouts, CashbackPayout{UserID: o.UserID, Amount: amount, Balance: balance})
+		}
+	}
+
+	return payouts, nil
+}
+
+// Net gaming losses from the ledger between two times; wins count as zero
+func (as *AuthService) netLoss(userID int, from, to time.Time) (int64, error) {
+	net, err := as.db.SumTransactionsBetween(userID, from, to, gameTxTypes...)
+	if err != nil {
+		return 0, err
+	}
+	return max(-net, 0), nil
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,88 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func TestSettleCashback(t *testing.T) {
+	auth := setupConfiguredAuthService(t, AuthConfig{CashbackPercent: 10, CashbackPeriod: LimitWeekly})
+
+	loser, err := auth.RegisterUser("cashloser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	bystander, err := auth.RegisterUser("cashbystander", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := auth.SetCashbackOptIn(loser.ID, true); err != nil {
+		t.Fatalf("SetCashbackOptIn() error = %v", err)
+	}
+
+	// Lose $100 and win $20 of it back; the bystander loses without opting in
+	for _, entry := range []struct {
+		userID int
+		delta  int64
+		txType string
+	}{
+		{loser.ID, -10000, vault.TxBet},
+		{loser.ID, 2000, vault.TxPayout},
+		{bystander.ID, -10000, vault.TxBet},
+	} {
+		if _, err := auth.AdjustBalance(entry.userID, entry.delta, entry.txType); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+	}
+
+	status, err := auth.GetCashbackStatus(loser.ID)
+	if err != nil {
+		t.Fatalf("GetCashbackStatus() error = %v", err)
+	}
+	if !status.OptedIn || status.NetLoss != 8000 || status.Estimate != 800 {
+		t.Errorf("GetCashbackStatus() = %+v, want 8000 lost and 800 estimated", status)
+	}
+
+	// Settling a week from now covers the current period
+	nextWeek := time.Now().Add(7 * 24 * time.Hour)
+	payouts, err := auth.SettleCashback(nextWeek)
+	if err != nil {
+		t.Fatalf("SettleCashback() error = %v", err)
+	}
+	if len(payouts) != 1 || payouts[0].UserID != loser.ID || payouts[0].Amount != 800 || payouts[0].Balance != 992800 {
+		t.Errorf("SettleCashback() = %+v, want 800 paid to the loser leaving 992800", payouts)
+	}
+
+	if payouts, err := auth.SettleCashback(nextWeek); err != nil || len(payouts) != 0 {
+		t.Errorf("Second SettleCashback() = %+v, %v, want nothing paid", payouts, err)
+	}
+}
+
+func TestCashbackPeriodStart(t *testing.T) {
+	weekly := setupConfiguredAuthService(t, AuthConfig{CashbackPercent: 10, CashbackPeriod: LimitWeekly})
+	daily := setupConfiguredAuthService(t, AuthConfig{CashbackPercent: 10, CashbackPeriod: LimitDaily})
+
+	// A Sunday afternoon
+	sunday := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+	if got := weekly.CashbackPeriodStart(sunday); !got.Equal(time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Weekly CashbackPeriodStart() = %v, want Monday 2024-03-04", got)
+	}
+	if got := daily.CashbackPeriodStart(sunday); !got.Equal(time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Daily CashbackPeriodStart() = %v, want 2024-03-10", got)
+	}
+}
+
+func TestCashbackDisabled(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	if err := auth.SetCashbackOptIn(1, true); err == nil {
+		t.Error("SetCashbackOptIn() should fail when cashback is disabled")
+	}
+	if payouts, err := auth.SettleCashback(time.Now()); err != nil || payouts != nil {
+		t.Errorf("SettleCashback() = %v, %v, want a no-op", payouts, err)
+	}
+}
@@ -0,0 +1,107 @@
+package security
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Chat message and mute limits
+const (
+	MaxChatLength = 200 // Characters
+	MaxChatMute   = 30 * 24 * time.Hour
+)
+
+// ChatFilter rewrites a chat message before delivery (e.g. masking profanity)
+// or rejects it with an error shown to the sender
+type ChatFilter func(message string) (string, error)
+
+// Registers the filter PrepareChat runs every message through
+func (as *AuthService) SetChatFilter(filter ChatFilter) {
+	as.chatFilter = filter
+}
+
+// Masks each of words wherever it appears as a whole word, ignoring case
+func WordFilter(words []string) ChatFilter {
+	var quoted []string
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			quoted = append(quoted, regexp.QuoteMeta(w))
+		}
+	}
+	if len(quoted) == 0 {
+		return func(message string) (string, error) { return message, nil }
+	}
+
+	pattern := regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)
+	return func(message string) (string, error) {
+		return pattern.ReplaceAllStringFunc(message, func(w string) string {
+			return strings.Repeat("*", len([]rune(w)))
+		}), nil
+	}
+}
+
+// Checks that the user may chat and returns the message as it should be
+// delivered: control characters stripped and the chat filter applied
+func (as *AuthService) PrepareChat(userID int, message string) (string, error) {
+	message = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, message))
+
+	if message == "" {
+		return "", fmt.Errorf("message is empty")
+	}
+	if len([]rune(message)) > MaxChatLength {
+		return "", fmt.Errorf("messages must be at most %d characters", MaxChatLength)
+	}
+
+	until, err := as.db.ChatMutedUntil(userID)
+	if err != nil {
+		return "", err
+	}
+	if time.Now().Before(until) {
+		return "", fmt.Errorf("you are muted in chat until %s", until.Local().Format("2006-01-02 15:04"))
+	}
+
+	if as.chatFilter != nil {
+		return as.chatFilter(message)
+	}
+	return message, nil
+}
+
+// Stops a user from chatting for duration; actor is recorded in the audit log
+func (as *AuthService) MuteChat(actor string, userID int, duration time.Duration, ip string) (time.Time, error) {
+	if duration <= 0 || duration > MaxChatMute {
+		return time.Time{}, fmt.Errorf("mutes must last between 1 minute and %d days", int(MaxChatMute.Hours()/24))
+	}
+
+	until := time.Now().Add(duration)
+	if err := as.db.MuteChat(userID, until, actor); err != nil {
+		return time.Time{}, err
+	}
+
+	as.db.RecordAuditEvent(userID, vault.AuditChatMute, ip, fmt.Sprintf("for %s by %s", duration, actor))
+
+	return until, nil
+}
+
+func (as *AuthService) UnmuteChat(actor string, userID int, ip string) error {
+	removed, err := as.db.UnmuteChat(userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("user is not muted")
+	}
+
+	as.db.RecordAuditEvent(userID, vault.AuditChatUnmute, ip, "by "+actor)
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package security
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrepareChat(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("chatuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	got, err := auth.PrepareChat(user.ID, "  good\x07 luck  ")
+	if err != nil || got != "good luck" {
+		t.Errorf("PrepareChat() = %q, %v, want %q", got, err, "good luck")
+	}
+
+	for _, message := range []string{"", "   ", strings.Repeat("a", MaxChatLength+1)} {
+		if _, err := auth.PrepareChat(user.ID, message); err == nil {
+			t.Errorf("PrepareChat(%q) should fail", message)
+		}
+	}
+
+	auth.SetChatFilter(WordFilter([]string{"darn", " heck "}))
+	got, err = auth.PrepareChat(user.ID, "Darn it, what the heck, darned")
+	if err != nil || got != "**** it, what the ****, darned" {
+		t.Errorf("PrepareChat() with filter = %q, %v", got, err)
+	}
+}
+
+func TestMuteChat(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("muteduser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	for _, d := range []time.Duration{0, MaxChatMute + time.Hour} {
+		if _, err := auth.MuteChat("admin", user.ID, d, ""); err == nil {
+			t.Errorf("MuteChat(%s) should fail", d)
+		}
+	}
+
+	if _, err := auth.MuteChat("admin", user.ID, time.Hour, ""); err != nil {
+		t.Fatalf("MuteChat() error = %v", err)
+	}
+	if _, err := auth.PrepareChat(user.ID, "hello"); err == nil {
+		t.Error("PrepareChat() should fail while muted")
+	}
+
+	if err := auth.UnmuteChat("admin", user.ID, ""); err != nil {
+		t.Fatalf("UnmuteChat() error = %v", err)
+	}
+	if _, err := auth.PrepareChat(user.ID, "hello"); err != nil {
+		t.Errorf("PrepareChat() after unmute error = %v", err)
+	}
+	if err := auth.UnmuteChat("admin", user.ID, ""); err == nil {
+		t.Error("UnmuteChat() should fail when not muted")
+	}
+}
@@ -0,0 +1,38 @@
+package security
+
+import (
+	"fmt"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Adds play money to a balance, within the deposit limits. Returns the new
+// balance.
+func (as *AuthService) Deposit(userID int, amount int64) (int64, error) {
+	if err := checkMoveLimits("deposit", amount, as.config.DepositMin, as.config.DepositMax); err != nil {
+		return 0, err
+	}
+	return as.db.AdjustBalance(userID, amount, vault.TxDeposit)
+}
+
+// Takes play money off a balance, within the withdrawal limits. Returns the
+// new balance.
+func (as *AuthService) Withdraw(userID int, amount int64) (int64, error) {
+	if err := checkMoveLimits("withdrawal", amount, as.config.WithdrawMin, as.config.WithdrawMax); err != nil {
+		return 0, err
+	}
+	return as.db.AdjustBalance(userID, -amount, vault.TxWithdrawal)
+}
+
+func checkMoveLimits(kind string, amount, minAmount, maxAmount int64) error {
+	if maxAmount <= 0 {
+		return fmt.Errorf("%ss are not available", kind)
+	}
+	if amount <= 0 {
+		return fmt.Errorf("%s amount must be positive", kind)
+	}
+	if amount < minAmount || amount > maxAmount {
+		return fmt.Errorf("%s must be between $%.2f and $%.2f", kind, float64(minAmount)/100, float64(maxAmount)/100)
+	}
+	return nil
+}
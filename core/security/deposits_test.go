@@ -0,0 +1,81 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func TestDepositAndWithdraw(t *testing.T) {
+	auth := setupConfiguredAuthService(t, AuthConfig{
+		DepositMin: 100, DepositMax: 50000,
+		WithdrawMin: 500, WithdrawMax: 20000,
+	})
+
+	user, err := auth.RegisterUser("alice", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	balance, err := auth.Deposit(user.ID, 25000)
+	if err != nil || balance != 1025000 {
+		t.Fatalf("Deposit() = %d, %v, want 1025000", balance, err)
+	}
+	if _, err := auth.Deposit(user.ID, 50); err == nil {
+		t.Error("Deposit() should reject amounts under the minimum")
+	}
+	if _, err := auth.Deposit(user.ID, 50001); err == nil {
+		t.Error("Deposit() should reject amounts over the maximum")
+	}
+
+	balance, err = auth.Withdraw(user.ID, 20000)
+	if err != nil || balance != 1005000 {
+		t.Fatalf("Withdraw() = %d, %v, want 1005000", balance, err)
+	}
+	if _, err := auth.Withdraw(user.ID, 400); err == nil {
+		t.Error("Withdraw() should reject amounts under the minimum")
+	}
+	if _, err := auth.Withdraw(user.ID, -1000); err == nil {
+		t.Error("Withdraw() should reject negative amounts")
+	}
+
+	txs, err := auth.db.ListTransactions(user.ID, 10)
+	if err != nil {
+		t.Fatalf("ListTransactions() error = %v", err)
+	}
+	if len(txs) != 2 || txs[0].Type != vault.TxWithdrawal || txs[0].Amount != -20000 || txs[1].Type != vault.TxDeposit || txs[1].Amount != 25000 {
+		t.Errorf("ListTransactions() = %+v, want the withdrawal then the deposit", txs)
+	}
+}
+
+func TestWithdrawCannotOverdraw(t *testing.T) {
+	auth := setupConfiguredAuthService(t, AuthConfig{WithdrawMin: 100, WithdrawMax: 10000000})
+
+	user, err := auth.RegisterUser("alice", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := auth.Withdraw(user.ID, 1000100); err == nil {
+		t.Error("Withdraw() should not take a balance below zero")
+	}
+	if balance, err := auth.Withdraw(user.ID, 1000000); err != nil || balance != 0 {
+		t.Errorf("Withdraw() = %d, %v, want the whole balance", balance, err)
+	}
+}
+
+func TestDepositsDisabled(t *testing.T) {
+	auth := setupConfiguredAuthService(t, AuthConfig{})
+
+	user, err := auth.RegisterUser("alice", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := auth.Deposit(user.ID, 1000); err == nil {
+		t.Error("Deposit() should fail when deposits are off")
+	}
+	if _, err := auth.Withdraw(user.ID, 1000); err == nil {
+		t.Error("Withdraw() should fail when withdrawals are off")
+	}
+}
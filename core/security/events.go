@@ -0,0 +1,164 @@
+package security
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// What an event boosts during settlement
+const (
+	EventXP        = "xp"        // XP earned per wager
+	EventPoints    = "points"    // Comp points earned per wager
+	EventBlackjack = "blackjack" // Winnings on a natural blackjack
+)
+
+var EventKinds = []string{EventXP, EventPoints, EventBlackjack}
+
+// Limits on what an admin can schedule
+const (
+	MaxEventMultiplier = 1000 // Percent, so at most 10x
+	MaxEventDuration   = 14 * 24 * time.Hour
+	MaxEventLeadTime   = 90 * 24 * time.Hour // How far ahead an event can be scheduled
+	MaxEventNameLength = 40
+)
+
+// Schedules an event boosting kind to multiplier percent from start for duration
+func (as *AuthService) CreateEvent(actor, name, kind string, multiplier int, start time.Time, duration time.Duration) (*vault.Event, error) {
+	name = strings.TrimSpace(name)
+	kind = strings.ToLower(kind)
+
+	if name == "" || len(name) > MaxEventNameLength {
+		return nil, fmt.Errorf("event name must be 1-%d characters", MaxEventNameLength)
+	}
+	if !slices.Contains(EventKinds, kind) {
+		return nil, fmt.Errorf("event kind must be one of %s", strings.Join(EventKinds, ", "))
+	}
+	if multiplier <= 100 || multiplier > MaxEventMultiplier {
+		return nil, fmt.Errorf("multiplier must be above 1x and at most %dx", MaxEventMultiplier/100)
+	}
+	if duration <= 0 || duration > MaxEventDuration {
+		return nil, fmt.Errorf("events must last between 1 hour and %d days", int(MaxEventDuration.Hours()/24))
+	}
+	if start.After(time.Now().Add(MaxEventLeadTime)) {
+		return nil, fmt.Errorf("events can be scheduled at most %d days ahead", int(MaxEventLeadTime.Hours()/24))
+	}
+
+	event, err := as.db.CreateEvent(name, kind, multiplier, start, start.Add(duration), actor)
+	if err != nil {
+		return nil, err
+	}
+
+	as.db.RecordAuditEvent(0, vault.AuditEventCreate, "", fmt.Sprintf("#%d %q %s x%.2f from %s for %s by %s",
+		event.ID, name, kind, float64(multiplier)/100, start.UTC().Format("2006-01-02 15:04"), duration, actor))
+
+	return event, nil
+}
+
+// Ends a running event or drops a scheduled one
+func (as *AuthService) CancelEvent(actor string, id int64) error {
+	if err := as.db.CancelEvent(id, time.Now()); err != nil {
+		return err
+	}
+
+	as.db.RecordAuditEvent(0, vault.AuditEventCancel, "", fmt.Sprintf("#%d by %s", id, actor))
+	return nil
+}
+
+// Lists running and upcoming events
+func (as *AuthService) ListEvents() ([]*vault.Event, error) {
+	return as.db.ListEvents(time.Now())
+}
+
+// Returns the multiplier percent for kind at now and the event providing it.
+// Overlapping events don't stack; the biggest boost wins. With no event
+// running it returns 100 and a nil event.
+func (as *AuthService) EventMultiplier(kind string, now time.Time) (int, *vault.Event, error) {
+	events, err := as.db.ListEvents(now)
+	if err != nil {
+		return 100, nil, err
+	}
+
+	multiplier, best := 100, (*vault.Event)(nil)
+	for _, e := range events {
+		if e.Kind == kind && e.ActiveAt(now) && e.Multiplier > multiplier {
+			multiplier, best = e.Multiplier, e
+		}
+	}
+
+	return multiplier, best, nil
+}
+
+// Scales a settlement amount by the running event for kind, if any
+func (as *AuthService) applyEvent(kind string, amount int64) int64 {
+	multiplier, _, err := as.EventMultiplier(kind, time.Now())
+	if err != nil {
+		return amount
+	}
+	return amount * int64(multiplier) / 100
+}
+
+// Pays the extra share of a natural blackjack's winnings while a blackjack
+// event runs. Returns the bonus (zero without an event), the event and the
+// balance after it.
+func (as *AuthService) AwardEventBlackjackBonus(userID int, winnings int64) (int64, *vault.Event, int64, error) {
+	multiplier, event, err := as.EventMultiplier(EventBlackjack, time.Now())
+	if err != nil || event == nil || winnings <= 0 {
+		return 0, nil, 0, err
+	}
+
+	bonus := winnings * int64(multiplier-100) / 100
+	if bonus <= 0 {
+		return 0, nil, 0, nil
+	}
+
+	balance, err := as.db.AdjustBalanceWithMetadata(userID, bonus, vault.TxBonus, "event: "+event.Name)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+
+	return bonus, event, balance, nil
+}
+
+// Claims the start and end announcements that are due. Each is returned once
+// even with several servers sharing the database.
+func (as *AuthService) DueEventAnnouncements(now time.Time) (started, ended []*vault.Event, err error) {
+	events, err := as.db.ListUnannouncedEvents(now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, e := range events {
+		over := !now.Before(e.EndsAt)
+
+		if !e.StartAnnounced {
+			ok, err := as.db.MarkEventAnnounced(e.ID, false)
+			if err != nil {
+				return started, ended, err
+			}
+			if over {
+				// Ran entirely while nobody was announcing, e.g. the server was down
+				as.db.MarkEventAnnounced(e.ID, true)
+				continue
+			}
+			if ok {
+				started = append(started, e)
+			}
+		}
+
+		if over && !e.EndAnnounced {
+			ok, err := as.db.MarkEventAnnounced(e.ID, true)
+			if err != nil {
+				return started, ended, err
+			}
+			if ok {
+				ended = append(ended, e)
+			}
+		}
+	}
+
+	return started, ended, nil
+}
@@ -0,0 +1,148 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func TestCreateEventValidation(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	now := time.Now()
+	tests := []struct {
+		name       string
+		kind       string
+		multiplier int
+		start      time.Time
+		duration   time.Duration
+	}{
+		{"", EventXP, 200, now, time.Hour},
+		{"Bad kind", "slots", 200, now, time.Hour},
+		{"No boost", EventXP, 100, now, time.Hour},
+		{"Too big", EventXP, MaxEventMultiplier + 1, now, time.Hour},
+		{"Too long", EventXP, 200, now, MaxEventDuration + time.Hour},
+		{"Too far", EventXP, 200, now.Add(MaxEventLeadTime + time.Hour), time.Hour},
+	}
+	for _, tt := range tests {
+		if _, err := auth.CreateEvent("admin", tt.name, tt.kind, tt.multiplier, tt.start, tt.duration); err == nil {
+			t.Errorf("CreateEvent(%q, %s, %d) should fail", tt.name, tt.kind, tt.multiplier)
+		}
+	}
+
+	event, err := auth.CreateEvent("admin", "Double XP Weekend", "XP", 200, now, 48*time.Hour)
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if event.Kind != EventXP {
+		t.Errorf("Kind = %q, want %q", event.Kind, EventXP)
+	}
+}
+
+func TestEventBoostsSettlement(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("eventuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	// Without an event a blackjack earns nothing extra
+	if bonus, _, _, err := auth.AwardEventBlackjackBonus(user.ID, 1500); err != nil || bonus != 0 {
+		t.Errorf("AwardEventBlackjackBonus() = %d, %v, want 0 without an event", bonus, err)
+	}
+
+	now := time.Now()
+	for _, e := range []struct {
+		kind       string
+		multiplier int
+	}{
+		{EventXP, 200},
+		{EventXP, 150}, // Overlapping events don't stack
+		{EventPoints, 300},
+		{EventBlackjack, 150},
+	} {
+		if _, err := auth.CreateEvent("admin", "Boost", e.kind, e.multiplier, now.Add(-time.Minute), time.Hour); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+	}
+
+	progress, _, err := auth.AwardWagerXP(user.ID, 10000)
+	if err != nil {
+		t.Fatalf("AwardWagerXP() error = %v", err)
+	}
+	if progress.XP != 200 {
+		t.Errorf("XP = %d, want 200 (100 doubled)", progress.XP)
+	}
+
+	points, err := auth.AwardWagerPoints(user.ID, 10000)
+	if err != nil {
+		t.Fatalf("AwardWagerPoints() error = %v", err)
+	}
+	if points != 30 {
+		t.Errorf("Points = %d, want 30 (10 tripled)", points)
+	}
+
+	bonus, event, balance, err := auth.AwardEventBlackjackBonus(user.ID, 1500)
+	if err != nil {
+		t.Fatalf("AwardEventBlackjackBonus() error = %v", err)
+	}
+	if bonus != 750 || event == nil || balance != user.Balance+750 {
+		t.Errorf("AwardEventBlackjackBonus() = %d, %v, %d, want 750 bonus", bonus, event, balance)
+	}
+
+	txs, err := auth.db.ListTransactions(user.ID, 1)
+	if err != nil {
+		t.Fatalf("ListTransactions() error = %v", err)
+	}
+	if len(txs) != 1 || txs[0].Type != vault.TxBonus || txs[0].Metadata != "event: Boost" {
+		t.Errorf("Ledger = %+v, want an event bonus entry", txs)
+	}
+}
+
+func TestDueEventAnnouncements(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	now := time.Now()
+	event, err := auth.CreateEvent("admin", "Double XP", EventXP, 200, now.Add(time.Hour), 2*time.Hour)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if started, ended, _ := auth.DueEventAnnouncements(now); len(started)+len(ended) != 0 {
+		t.Errorf("Nothing should be announced before the start, got %d/%d", len(started), len(ended))
+	}
+
+	started, ended, err := auth.DueEventAnnouncements(now.Add(90 * time.Minute))
+	if err != nil {
+		t.Fatalf("DueEventAnnouncements() error = %v", err)
+	}
+	if len(started) != 1 || started[0].ID != event.ID || len(ended) != 0 {
+		t.Errorf("DueEventAnnouncements(during) = %d started, %d ended, want 1, 0", len(started), len(ended))
+	}
+
+	// Each announcement is only handed out once
+	if started, _, _ := auth.DueEventAnnouncements(now.Add(91 * time.Minute)); len(started) != 0 {
+		t.Error("The start announcement should only be returned once")
+	}
+
+	started, ended, err = auth.DueEventAnnouncements(now.Add(4 * time.Hour))
+	if err != nil {
+		t.Fatalf("DueEventAnnouncements() error = %v", err)
+	}
+	if len(started) != 0 || len(ended) != 1 {
+		t.Errorf("DueEventAnnouncements(after) = %d started, %d ended, want 0, 1", len(started), len(ended))
+	}
+
+	// An event that came and went unannounced is skipped entirely
+	if _, err := auth.CreateEvent("admin", "Missed", EventPoints, 200, now.Add(-3*time.Hour), time.Hour); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if started, ended, _ := auth.DueEventAnnouncements(now.Add(5 * time.Hour)); len(started)+len(ended) != 0 {
+		t.Errorf("A missed event should not be announced, got %d/%d", len(started), len(ended))
+	}
+}
@@ -0,0 +1,123 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Features an admin can switch on and off while the server runs
+const (
+	FeatureTables    = "tables"    // Sitting at, joining and hosting multiplayer tables
+	FeatureJackpot   = "jackpot"   // Feeding and paying the progressive jackpot
+	FeatureCashback  = "cashback"  // Joining and paying the loss cashback promotion
+	FeatureDaily     = "daily"     // The DAILY bonus
+	FeatureRebuy     = "rebuy"     // REBUY for players who can't cover a bet
+	FeaturePromos    = "promos"    // Redeeming promo codes
+	FeatureShop      = "shop"      // Buying from the shop
+	FeatureReferrals = "referrals" // Referral codes and their bonuses
+	FeatureStreaks   = "streaks"   // Win streak bonuses
+	FeatureEvents    = "events"    // Bonuses from scheduled events
+	FeatureTransfers = "transfers" // Sending chips to other players
+)
+
+// Feature describes a feature flag
+type Feature struct {
+	Name        string
+	Description string
+	Default     bool // Whether it is on until an admin sets it
+	key         string
+}
+
+// Every feature flag. New features can ship switched off and be turned on
+// with ADMIN FEATURE once they are ready.
+var Features = []Feature{
+	{Name: FeatureTables, Description: "Multiplayer tables", Default: true},
+	{Name: FeatureJackpot, Description: "Progressive jackpot", Default: true},
+	{Name: FeatureCashback, Description: "Loss cashback promotion", Default: true},
+	{Name: FeatureDaily, Description: "Daily bonus", Default: true},
+	{Name: FeatureRebuy, Description: "Rebuys", Default: true},
+	{Name: FeaturePromos, Description: "Promo codes", Default: true},
+	{Name: FeatureShop, Description: "Shop purchases", Default: true},
+	{Name: FeatureReferrals, Description: "Referral bonuses", Default: true},
+	{Name: FeatureStreaks, Description: "Win streak bonuses", Default: true},
+	{Name: FeatureEvents, Description: "Event bonuses", Default: true},
+	// Kept under the setting ADMIN TRANSFERS has always used
+	{Name: FeatureTransfers, Description: "Player transfers", Default: true, key: "transfers_enabled"},
+}
+
+func findFeature(name string) (Feature, bool) {
+	for _, f := range Features {
+		if strings.EqualFold(f.Name, name) {
+			return f, true
+		}
+	}
+	return Feature{}, false
+}
+
+func (f Feature) settingKey() string {
+	if f.key != "" {
+		return f.key
+	}
+	return "feature_" + f.Name
+}
+
+// Reports whether a feature is on. Flags are read from the database once and
+// cached; an unknown feature is off.
+func (as *AuthService) FeatureEnabled(name string) (bool, error) {
+	f, ok := findFeature(name)
+	if !ok {
+		return false, nil
+	}
+
+	as.featuresMu.RLock()
+	enabled, cached := as.features[f.Name]
+	as.featuresMu.RUnlock()
+	if cached {
+		return enabled, nil
+	}
+
+	value, ok, err := as.db.GetSetting(f.settingKey())
+	if err != nil {
+		return false, err
+	}
+	enabled = f.Default
+	if ok {
+		enabled = value == "1"
+	}
+
+	as.featuresMu.Lock()
+	defer as.featuresMu.Unlock()
+	if as.features == nil {
+		as.features = make(map[string]bool)
+	}
+	as.features[f.Name] = enabled
+	return enabled, nil
+}
+
+// Switches a feature on or off for everyone
+func (as *AuthService) SetFeature(actor, name string, enabled bool) error {
+	f, ok := findFeature(name)
+	if !ok {
+		return fmt.Errorf("unknown feature %q, see ADMIN FEATURES", name)
+	}
+
+	value, state := "0", "disabled"
+	if enabled {
+		value, state = "1", "enabled"
+	}
+	if err := as.db.SetSetting(f.settingKey(), value); err != nil {
+		return err
+	}
+
+	as.featuresMu.Lock()
+	if as.features == nil {
+		as.features = make(map[string]bool)
+	}
+	as.features[f.Name] = enabled
+	as.featuresMu.Unlock()
+
+	as.db.RecordAuditEvent(0, vault.AuditSetting, "", fmt.Sprintf("feature %s %s by %s", f.Name, state, actor))
+	return nil
+}
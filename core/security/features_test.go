@@ -0,0 +1,60 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func TestFeatureFlags(t *testing.T) {
+	auth, db := setupTokenAuthService(t)
+
+	for _, f := range Features {
+		if enabled, err := auth.FeatureEnabled(f.Name); err != nil || enabled != f.Default {
+			t.Errorf("FeatureEnabled(%s) = %v, %v, want %v", f.Name, enabled, err, f.Default)
+		}
+	}
+	if enabled, _ := auth.FeatureEnabled("nope"); enabled {
+		t.Error("FeatureEnabled() should report an unknown feature as off")
+	}
+	if err := auth.SetFeature("console", "nope", true); err == nil {
+		t.Error("SetFeature() should reject an unknown feature")
+	}
+
+	if err := auth.SetFeature("console", "SHOP", false); err != nil {
+		t.Fatalf("SetFeature() error = %v", err)
+	}
+	if enabled, _ := auth.FeatureEnabled(FeatureShop); enabled {
+		t.Error("FeatureEnabled() should report the shop as off after disabling it")
+	}
+
+	// A fresh service reads the flag back from the database
+	fresh := NewAuthService(db)
+	if enabled, _ := fresh.FeatureEnabled(FeatureShop); enabled {
+		t.Error("a disabled feature should stay off across restarts")
+	}
+
+	events, err := db.AuditEventsBetween(0, time.Now().Add(-time.Hour), time.Now().Add(time.Hour), 0)
+	if err != nil {
+		t.Fatalf("AuditEventsBetween() error = %v", err)
+	}
+	found := false
+	for _, e := range events {
+		found = found || (e.Type == vault.AuditSetting && e.Detail == "feature shop disabled by console")
+	}
+	if !found {
+		t.Error("SetFeature() should be audited")
+	}
+}
+
+func TestTransfersFeature(t *testing.T) {
+	auth, _ := setupTokenAuthService(t)
+
+	if err := auth.SetTransfersEnabled("console", false); err != nil {
+		t.Fatalf("SetTransfersEnabled() error = %v", err)
+	}
+	if enabled, _ := auth.FeatureEnabled(FeatureTransfers); enabled {
+		t.Error("ADMIN TRANSFERS OFF should switch off the transfers feature")
+	}
+}
@@ -0,0 +1,41 @@
+package security
+
+// User settings behind the big-win feed
+const (
+	settingFeed          = "win_feed"           // "off" stops big wins being pushed to the player
+	settingFeedAnonymous = "win_feed_anonymous" // "on" hides the player's name in others' feeds
+)
+
+// FeedSettings is how a player takes part in the big-win feed
+type FeedSettings struct {
+	Receive   bool // Sees other players' big wins
+	Anonymous bool // Shown as "A player" when they win big
+}
+
+func (as *AuthService) GetFeedSettings(userID int) (*FeedSettings, error) {
+	receive, _, err := as.db.GetUserSetting(userID, settingFeed)
+	if err != nil {
+		return nil, err
+	}
+	anonymous, _, err := as.db.GetUserSetting(userID, settingFeedAnonymous)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FeedSettings{Receive: receive != "off", Anonymous: anonymous == "on"}, nil
+}
+
+func (as *AuthService) SetFeedReceive(userID int, receive bool) error {
+	return as.db.SetUserSetting(userID, settingFeed, onOff(receive))
+}
+
+func (as *AuthService) SetFeedAnonymous(userID int, anonymous bool) error {
+	return as.db.SetUserSetting(userID, settingFeedAnonymous, onOff(anonymous))
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,30 @@
+package security
+
+import "testing"
+
+func TestFeedSettings(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("feeduser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	settings, err := auth.GetFeedSettings(user.ID)
+	if err != nil || !settings.Receive || settings.Anonymous {
+		t.Fatalf("GetFeedSettings() = %+v, %v, want receiving and named by default", settings, err)
+	}
+
+	if err := auth.SetFeedReceive(user.ID, false); err != nil {
+		t.Fatalf("SetFeedReceive() error = %v", err)
+	}
+	if err := auth.SetFeedAnonymous(user.ID, true); err != nil {
+		t.Fatalf("SetFeedAnonymous() error = %v", err)
+	}
+
+	settings, err = auth.GetFeedSettings(user.ID)
+	if err != nil || settings.Receive || !settings.Anonymous {
+		t.Errorf("GetFeedSettings() = %+v, %v, want opted out and anonymous", settings, err)
+	}
+}
@@ -0,0 +1,81 @@
+package security
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Saves a new solo hand and takes its bet into escrow with it. Returns the
+// player's balance.
+func (as *AuthService) StartGame(userID int, game string, bet int64, state string) (int64, error) {
+	if bet <= 0 {
+		return 0, fmt.Errorf("invalid bet amount")
+	}
+	return as.db.StartActiveGame(userID, game, bet, state)
+}
+
+// Saves the state of a solo hand after a move, adding stake to its escrowed
+// bet (zero unless the player doubled). Returns the player's balance.
+func (as *AuthService) SaveGame(userID int, stake int64, state string) (int64, error) {
+	if stake < 0 {
+		return 0, fmt.Errorf("invalid stake")
+	}
+	return as.db.UpdateActiveGame(userID, stake, state)
+}
+
+// Returns the player's unfinished solo hand, or nil
+func (as *AuthService) ActiveGame(userID int) (*vault.ActiveGame, error) {
+	return as.db.GetActiveGame(userID)
+}
+
+// Pays out a finished solo hand and closes it, failing if it was already
+// closed. The player's stats are saved with it when given. Returns the
+// player's balance.
+func (as *AuthService) SettleGame(userID int, wagered, payout int64, result, hands string, stats *vault.UserStats) (int64, error) {
+	return as.db.SettleActiveGame(userID, wagered, payout, result, hands, stats)
+}
+
+// Closes an unfinished solo hand and returns its bet. Returns the player's
+// balance and the amount refunded.
+func (as *AuthService) RefundGame(userID int) (int64, int64, error) {
+	return as.db.RefundActiveGame(userID)
+}
+
+// Lists the solo hands nobody has played for at least idle
+func (as *AuthService) AbandonedGames(idle time.Duration) ([]*vault.ActiveGame, error) {
+	return as.db.ListActiveGamesBefore(time.Now().Add(-idle))
+}
+
+// Takes a shared-table bet from the player's balance into escrow. Returns the
+// hold's ID and the player's balance.
+func (as *AuthService) HoldBet(userID int, game, ref string, bet int64) (int64, int64, error) {
+	if bet <= 0 {
+		return 0, 0, fmt.Errorf("invalid bet amount")
+	}
+	return as.db.HoldBet(userID, game, ref, bet)
+}
+
+// Moves delta more of the player's balance into a held bet, or back out of it
+// when negative. Returns the player's balance.
+func (as *AuthService) AdjustHold(holdID, delta int64) (int64, error) {
+	return as.db.AdjustHold(holdID, delta)
+}
+
+// Pays out a held bet and closes it, failing if it was already closed. The
+// player's stats are saved with it when given. Returns the player's balance.
+func (as *AuthService) SettleHold(holdID, payout int64, result, hands string, stats *vault.UserStats) (int64, error) {
+	return as.db.SettleHold(holdID, payout, result, hands, stats)
+}
+
+// Returns a held bet in full. Returns the player's balance and the amount
+// refunded.
+func (as *AuthService) ReleaseHold(holdID int64) (int64, int64, error) {
+	return as.db.ReleaseHold(holdID)
+}
+
+// Lists every bet still held in escrow
+func (as *AuthService) HeldBets() ([]*vault.BetHold, error) {
+	return as.db.ListHolds()
+}
@@ -0,0 +1,79 @@
+package security
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSoloGameEscrow(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("escrower", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := auth.StartGame(user.ID, GameBlackjack, 0, "dealt"); err == nil {
+		t.Error("StartGame() should refuse a zero bet")
+	}
+	if _, err := auth.StartGame(user.ID, GameBlackjack, 1000, "dealt"); err != nil {
+		t.Fatalf("StartGame() error = %v", err)
+	}
+	if _, err := auth.SaveGame(user.ID, -500, "hit"); err == nil {
+		t.Error("SaveGame() should refuse a negative stake")
+	}
+	if _, err := auth.SaveGame(user.ID, 0, "hit"); err != nil {
+		t.Fatalf("SaveGame() error = %v", err)
+	}
+
+	if g, err := auth.ActiveGame(user.ID); err != nil || g == nil || g.State != "hit" {
+		t.Errorf("ActiveGame() = %v, %v, want the saved hand", g, err)
+	}
+
+	// Nothing has sat for an hour yet
+	if games, err := auth.AbandonedGames(time.Hour); err != nil || len(games) != 0 {
+		t.Errorf("AbandonedGames() = %d, %v, want none", len(games), err)
+	}
+
+	balance, refunded, err := auth.RefundGame(user.ID)
+	if err != nil || refunded != 1000 || balance != user.Balance {
+		t.Errorf("RefundGame() = %d, %d, %v, want %d, 1000", balance, refunded, err, user.Balance)
+	}
+	if _, err := auth.SettleGame(user.ID, 1000, 2000, "", "", nil); err == nil {
+		t.Error("SettleGame() should fail once the hand was refunded")
+	}
+}
+
+func TestHeldBets(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("holder", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, _, err := auth.HoldBet(user.ID, GameBlackjack, "T1", 0); err == nil {
+		t.Error("HoldBet() should refuse a zero bet")
+	}
+	id, _, err := auth.HoldBet(user.ID, GameBlackjack, "T1", 1000)
+	if err != nil {
+		t.Fatalf("HoldBet() error = %v", err)
+	}
+	if _, err := auth.AdjustHold(id, 1000); err != nil {
+		t.Fatalf("AdjustHold() error = %v", err)
+	}
+
+	if holds, err := auth.HeldBets(); err != nil || len(holds) != 1 || holds[0].Amount != 2000 {
+		t.Errorf("HeldBets() = %v, %v, want one 2000 bet", holds, err)
+	}
+
+	balance, err := auth.SettleHold(id, 4000, "", "", nil)
+	if err != nil || balance != user.Balance+2000 {
+		t.Errorf("SettleHold() = %d, %v, want %d", balance, err, user.Balance+2000)
+	}
+	if _, _, err := auth.ReleaseHold(id); err == nil {
+		t.Error("ReleaseHold() should fail once the bet was settled")
+	}
+}
@@ -0,0 +1,66 @@
+package security
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Guest usernames start with this prefix, which registered users can't take
+const GuestPrefix = "guest_"
+
+func isGuestName(username string) bool {
+	return strings.HasPrefix(strings.ToLower(username), GuestPrefix)
+}
+
+func (as *AuthService) GuestsEnabled() bool {
+	return as.config.GuestBalance > 0
+}
+
+// Creates a temporary guest account and logs it in. Guests count toward the
+// per-IP signup limit.
+func (as *AuthService) LoginGuest(ip string) (string, *vault.User, error) {
+	if !as.GuestsEnabled() {
+		return "", nil, fmt.Errorf("guest play is not available")
+	}
+
+	if err := as.checkSignupRate(ip); err != nil {
+		return "", nil, err
+	}
+
+	var user *vault.User
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		b := make([]byte, 4)
+		if _, err = rand.Read(b); err != nil {
+			return "", nil, fmt.Errorf("failed to generate guest name: %w", err)
+		}
+		if user, err = as.db.CreateGuestUser(GuestPrefix+hex.EncodeToString(b), as.config.GuestBalance); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	as.db.RecordAuditEvent(user.ID, vault.AuditSignup, ip, "guest")
+
+	sessionID, err := as.startSession(user.ID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return sessionID, user, nil
+}
+
+// Purges guests idle for longer than the configured timeout, except those in keep
+func (as *AuthService) PurgeIdleGuests(keep []int) (int, error) {
+	if as.config.GuestIdleTimeout <= 0 {
+		return 0, nil
+	}
+	return as.db.PurgeGuests(time.Now().Add(-as.config.GuestIdleTimeout), keep)
+}
@@ -0,0 +1,57 @@
+package security
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoginGuest(t *testing.T) {
+	auth := setupConfiguredAuthService(t, AuthConfig{GuestBalance: 100000, GuestIdleTimeout: time.Hour})
+
+	sessionID, guest, err := auth.LoginGuest("10.0.0.1")
+	if err != nil {
+		t.Fatalf("LoginGuest() error = %v", err)
+	}
+	if !guest.IsGuest || !strings.HasPrefix(guest.Username, GuestPrefix) || guest.Balance != 100000 {
+		t.Errorf("LoginGuest() user = %+v, want a guest with 100000", guest)
+	}
+
+	user, err := auth.ValidateSession(sessionID)
+	if err != nil || user.ID != guest.ID {
+		t.Errorf("ValidateSession() = %v, %v, want the guest", user, err)
+	}
+
+	// Guests have no password to log in with
+	if _, _, err := auth.LoginUser(guest.Username, ""); err == nil {
+		t.Error("LoginUser() should not work for guest accounts")
+	}
+
+	member, err := auth.RegisterUser("member", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := auth.CheckTransfer(member.ID, guest.Username, 100); err == nil {
+		t.Error("CheckTransfer() should refuse transfers to guests")
+	}
+	if _, err := auth.CheckTransfer(guest.ID, member.Username, 100); err == nil {
+		t.Error("CheckTransfer() should refuse transfers from guests")
+	}
+
+	// A fresh guest isn't idle yet
+	if purged, err := auth.PurgeIdleGuests(nil); err != nil || purged != 0 {
+		t.Errorf("PurgeIdleGuests() = %d, %v, want 0", purged, err)
+	}
+}
+
+func TestGuestNamesReserved(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	if _, err := auth.RegisterUser("Guest_1234", "testpassword456"); err == nil {
+		t.Error("RegisterUser() should reject the guest prefix")
+	}
+	if _, _, err := auth.LoginGuest(""); err == nil {
+		t.Error("LoginGuest() should fail when guests are disabled")
+	}
+}
@@ -0,0 +1,33 @@
+package security
+
+import (
+	"fmt"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Most rounds HISTORY shows on one page
+const MaxHistoryRounds = 50
+
+// Returns one page of a player's settled rounds, newest first, with the
+// number of pages. Pages start at 1.
+func (as *AuthService) GameHistory(userID, perPage, page int) ([]*vault.GameRound, int, error) {
+	if perPage < 1 || perPage > MaxHistoryRounds {
+		return nil, 0, fmt.Errorf("you can list between 1 and %d rounds", MaxHistoryRounds)
+	}
+	if page < 1 {
+		return nil, 0, fmt.Errorf("invalid page")
+	}
+
+	count, err := as.db.CountGameRounds(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	pages := (count + perPage - 1) / perPage
+
+	rounds, err := as.db.ListGameRounds(userID, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	return rounds, pages, nil
+}
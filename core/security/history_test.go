@@ -0,0 +1,37 @@
+package security
+
+import "testing"
+
+func TestGameHistory(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("historian", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := auth.SettleRound(user.ID, GameBlackjack, 1000, 0, "Loss", "", nil); err != nil {
+			t.Fatalf("SettleRound() error = %v", err)
+		}
+	}
+
+	if _, _, err := auth.GameHistory(user.ID, 0, 1); err == nil {
+		t.Error("GameHistory() should refuse zero rounds")
+	}
+	if _, _, err := auth.GameHistory(user.ID, MaxHistoryRounds+1, 1); err == nil {
+		t.Error("GameHistory() should refuse more than MaxHistoryRounds")
+	}
+	if _, _, err := auth.GameHistory(user.ID, 2, 0); err == nil {
+		t.Error("GameHistory() should refuse page 0")
+	}
+
+	rounds, pages, err := auth.GameHistory(user.ID, 2, 3)
+	if err != nil {
+		t.Fatalf("GameHistory() error = %v", err)
+	}
+	if len(rounds) != 1 || pages != 3 {
+		t.Errorf("GameHistory(2, 3) = %d rounds of %d pages, want 1 of 3", len(rounds), pages)
+	}
+}
@@ -0,0 +1,41 @@
+package security
+
+import "github.com/alessandrosisniegas/casino/core/vault"
+
+// Game names used for house accounting of rounds
+const (
+	GameBlackjack = "blackjack"
+	GameBaccarat  = "baccarat"
+	GameHiLo      = "hilo"
+	GameHoldem    = "holdem"
+	GameKeno      = "keno"
+)
+
+// Lists accounts whose balance doesn't match their ledger
+func (as *AuthService) ReconcileBalances() ([]*vault.BalanceMismatch, error) {
+	return as.db.BalanceMismatches()
+}
+
+// Pays out a finished round and records it in the house's totals and the
+// player's history with the hands it ended on, saving their stats with it
+// when given
+func (as *AuthService) SettleRound(userID int, game string, wagered, payout int64, result, hands string, stats *vault.UserStats) (int64, error) {
+	return as.db.SettleRound(userID, game, wagered, payout, result, hands, stats)
+}
+
+// Returns the house position per game along with the combined total
+func (as *AuthService) HouseReport() ([]*vault.HouseStats, *vault.HouseStats, error) {
+	games, err := as.db.ListHouseStats()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	total := &vault.HouseStats{Game: "total"}
+	for _, g := range games {
+		total.Rounds += g.Rounds
+		total.Wagered += g.Wagered
+		total.Paid += g.Paid
+	}
+
+	return games, total, nil
+}
@@ -0,0 +1,45 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func TestHouseReport(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("houseuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	rounds := []struct {
+		game            string
+		wagered, payout int64
+	}{
+		{GameBlackjack, 1000, 2000},
+		{GameBlackjack, 1000, 0},
+		{"other", 500, 0},
+	}
+	for _, r := range rounds {
+		if _, err := auth.AdjustBalance(user.ID, -r.wagered, vault.TxBet); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+		if _, err := auth.SettleRound(user.ID, r.game, r.wagered, r.payout, "", "", nil); err != nil {
+			t.Fatalf("SettleRound() error = %v", err)
+		}
+	}
+
+	games, total, err := auth.HouseReport()
+	if err != nil {
+		t.Fatalf("HouseReport() error = %v", err)
+	}
+	if len(games) != 2 {
+		t.Errorf("HouseReport() returned %d games, want 2", len(games))
+	}
+	if total.Rounds != 3 || total.Wagered != 2500 || total.Paid != 2000 || total.Hold() != 500 {
+		t.Errorf("HouseReport() total = %+v, want 3 rounds holding 500", total)
+	}
+}
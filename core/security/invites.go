@@ -0,0 +1,45 @@
+package security
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+const MaxInviteUses = 1000
+
+// Generates a short, human-typeable invite code
+func GenerateInviteCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate invite code: %w", err)
+	}
+	return strings.TrimRight(base32.StdEncoding.EncodeToString(b), "="), nil
+}
+
+// Creates an invite code usable maxUses times; a zero ttl never expires
+func (as *AuthService) CreateInviteCode(createdBy string, maxUses int, ttl time.Duration) (*vault.InviteCode, error) {
+	if maxUses < 1 || maxUses > MaxInviteUses {
+		return nil, fmt.Errorf("uses must be between 1 and %d", MaxInviteUses)
+	}
+
+	code, err := GenerateInviteCode()
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	return as.db.CreateInviteCode(code, createdBy, maxUses, expiresAt)
+}
+
+func (as *AuthService) ListInviteCodes() ([]*vault.InviteCode, error) {
+	return as.db.ListInviteCodes()
+}
@@ -0,0 +1,87 @@
+package security
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func setupConfiguredAuthService(t *testing.T, config AuthConfig) *AuthService {
+	db, err := vault.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewAuthServiceWithConfig(db, config)
+}
+
+func TestRegisterRequiresInviteCode(t *testing.T) {
+	auth := setupConfiguredAuthService(t, AuthConfig{RequireInviteCode: true})
+
+	if _, err := auth.RegisterUser("newplayer", "testpassword456"); err == nil {
+		t.Error("RegisterUser() should fail without an invite code")
+	}
+
+	invite, err := auth.CreateInviteCode("console", 1, 0)
+	if err != nil {
+		t.Fatalf("CreateInviteCode() error = %v", err)
+	}
+
+	opts := RegisterOptions{InviteCode: invite.Code}
+	if _, err := auth.RegisterUserWithOptions("newplayer", "testpassword456", opts); err != nil {
+		t.Fatalf("RegisterUserWithOptions() error = %v", err)
+	}
+
+	if _, err := auth.RegisterUserWithOptions("another", "testpassword456", opts); err == nil {
+		t.Error("RegisterUserWithOptions() should fail once the invite is used up")
+	}
+
+	// A failed signup must not burn an invite use
+	invite, err = auth.CreateInviteCode("console", 1, 0)
+	if err != nil {
+		t.Fatalf("CreateInviteCode() error = %v", err)
+	}
+	if _, err := auth.RegisterUserWithOptions("newplayer", "testpassword456", RegisterOptions{InviteCode: invite.Code}); err == nil {
+		t.Fatal("RegisterUserWithOptions() should fail for a taken username")
+	}
+	if _, err := auth.RegisterUserWithOptions("thirdone", "testpassword456", RegisterOptions{InviteCode: invite.Code}); err != nil {
+		t.Errorf("RegisterUserWithOptions() error = %v, invite should still be unused", err)
+	}
+}
+
+func TestSignupRateLimitPerIP(t *testing.T) {
+	auth := setupConfiguredAuthService(t, AuthConfig{SignupsPerIPPerDay: 2})
+
+	for _, name := range []string{"player_one", "player_two"} {
+		if _, err := auth.RegisterUserWithOptions(name, "testpassword456", RegisterOptions{IP: "10.0.0.1"}); err != nil {
+			t.Fatalf("RegisterUserWithOptions(%s) error = %v", name, err)
+		}
+	}
+
+	if _, err := auth.RegisterUserWithOptions("player_three", "testpassword456", RegisterOptions{IP: "10.0.0.1"}); err == nil {
+		t.Error("RegisterUserWithOptions() should be throttled after the per-IP limit")
+	}
+
+	if _, err := auth.RegisterUserWithOptions("player_three", "testpassword456", RegisterOptions{IP: "10.0.0.2"}); err != nil {
+		t.Errorf("RegisterUserWithOptions() from another IP error = %v", err)
+	}
+}
+
+func TestGenerateInviteCode(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		code, err := GenerateInviteCode()
+		if err != nil {
+			t.Fatalf("GenerateInviteCode() error = %v", err)
+		}
+		if len(code) != 8 {
+			t.Errorf("GenerateInviteCode() = %s, want 8 characters", code)
+		}
+		if seen[code] {
+			t.Errorf("GenerateInviteCode() returned duplicate %s", code)
+		}
+		seen[code] = true
+	}
+}
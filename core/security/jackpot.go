@@ -0,0 +1,34 @@
+package security
+
+import "fmt"
+
+// The shared progressive jackpot pool
+const jackpotPool = "main"
+
+func (as *AuthService) JackpotEnabled() bool {
+	return as.config.JackpotPercent > 0
+}
+
+// Returns the current jackpot amount
+func (as *AuthService) Jackpot() (int64, error) {
+	if !as.JackpotEnabled() {
+		return 0, fmt.Errorf("the jackpot is not enabled")
+	}
+	return as.db.GetJackpot(jackpotPool, as.config.JackpotSeed)
+}
+
+// Pays the configured share of a wager into the jackpot and returns the new amount
+func (as *AuthService) ContributeJackpot(wagered int64) (int64, error) {
+	if !as.JackpotEnabled() {
+		return 0, nil
+	}
+	return as.db.ContributeJackpot(jackpotPool, wagered*int64(as.config.JackpotPercent)/100, as.config.JackpotSeed)
+}
+
+// Pays the whole jackpot to a user, returning the amount won and their new balance
+func (as *AuthService) AwardJackpot(userID int) (won, balance int64, err error) {
+	if !as.JackpotEnabled() {
+		return 0, 0, fmt.Errorf("the jackpot is not enabled")
+	}
+	return as.db.AwardJackpot(jackpotPool, userID, as.config.JackpotSeed)
+}
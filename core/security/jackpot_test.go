@@ -0,0 +1,44 @@
+package security
+
+import "testing"
+
+func TestJackpot(t *testing.T) {
+	auth := setupConfiguredAuthService(t, AuthConfig{JackpotPercent: 1, JackpotSeed: 100000})
+
+	user, err := auth.RegisterUser("jackpotuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	total, err := auth.ContributeJackpot(10000)
+	if err != nil {
+		t.Fatalf("ContributeJackpot() error = %v", err)
+	}
+	if total != 100100 {
+		t.Errorf("ContributeJackpot($100) = %d, want 100100", total)
+	}
+
+	won, balance, err := auth.AwardJackpot(user.ID)
+	if err != nil {
+		t.Fatalf("AwardJackpot() error = %v", err)
+	}
+	if won != 100100 || balance != 1100100 {
+		t.Errorf("AwardJackpot() = %d, %d, want 100100 leaving 1100100", won, balance)
+	}
+
+	if amount, err := auth.Jackpot(); err != nil || amount != 100000 {
+		t.Errorf("Jackpot() after a win = %d, %v, want the seed", amount, err)
+	}
+}
+
+func TestJackpotDisabled(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	if total, err := auth.ContributeJackpot(10000); err != nil || total != 0 {
+		t.Errorf("ContributeJackpot() = %d, %v, want a no-op", total, err)
+	}
+	if _, err := auth.Jackpot(); err == nil {
+		t.Error("Jackpot() should fail when disabled")
+	}
+}
@@ -0,0 +1,42 @@
+package security
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Most players LEADERBOARD shows on one page
+const MaxLeaderboardPlayers = 50
+
+// Leaderboard categories in the order LEADERBOARD lists them
+var LeaderboardCategories = []string{vault.LeaderboardBalance, vault.LeaderboardNet, vault.LeaderboardWinRate, vault.LeaderboardBiggestWin}
+
+// Returns one page of a leaderboard, best first, with the number of pages.
+// Pages start at 1.
+func (as *AuthService) Leaderboard(category string, perPage, page int) ([]*vault.LeaderboardEntry, int, error) {
+	category = strings.ToLower(category)
+	if !slices.Contains(LeaderboardCategories, category) {
+		return nil, 0, fmt.Errorf("leaderboard must be one of %s", strings.Join(LeaderboardCategories, ", "))
+	}
+	if perPage < 1 || perPage > MaxLeaderboardPlayers {
+		return nil, 0, fmt.Errorf("you can list between 1 and %d players", MaxLeaderboardPlayers)
+	}
+	if page < 1 {
+		return nil, 0, fmt.Errorf("invalid page")
+	}
+
+	count, err := as.db.CountLeaderboard(category)
+	if err != nil {
+		return nil, 0, err
+	}
+	pages := (count + perPage - 1) / perPage
+
+	entries, err := as.db.Leaderboard(category, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, pages, nil
+}
@@ -0,0 +1,32 @@
+package security
+
+import "testing"
+
+func TestLeaderboard(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	for _, name := range []string{"alice", "bob", "carol"} {
+		if _, err := auth.RegisterUser(name, "testpassword456"); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+	}
+
+	if _, _, err := auth.Leaderboard("luck", 10, 1); err == nil {
+		t.Error("Leaderboard() should refuse an unknown category")
+	}
+	if _, _, err := auth.Leaderboard("balance", MaxLeaderboardPlayers+1, 1); err == nil {
+		t.Error("Leaderboard() should refuse more than MaxLeaderboardPlayers")
+	}
+	if _, _, err := auth.Leaderboard("balance", 2, 0); err == nil {
+		t.Error("Leaderboard() should refuse page 0")
+	}
+
+	entries, pages, err := auth.Leaderboard("BALANCE", 2, 2)
+	if err != nil {
+		t.Fatalf("Leaderboard() error = %v", err)
+	}
+	if len(entries) != 1 || pages != 2 || entries[0].Rank != 3 {
+		t.Errorf("Leaderboard(2, 2) = %d players of %d pages, want the third of 2 pages", len(entries), pages)
+	}
+}
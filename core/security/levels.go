@@ -0,0 +1,123 @@
+package security
+
+import "math"
+
+// Cents wagered per experience point ($1 = 1 XP)
+const CentsPerXP = 100
+
+// VIPTier is a band of levels with the perks it unlocks
+type VIPTier struct {
+	ID       string
+	Name     string
+	MinLevel int
+
+	// Perks as percentages of the base value
+	DailyBonusPercent int
+	MaxBetPercent     int
+}
+
+// Tiers in ascending order
+var VIPTiers = []VIPTier{
+	{ID: "bronze", Name: "Bronze", MinLevel: 1, DailyBonusPercent: 100, MaxBetPercent: 100},
+	{ID: "silver", Name: "Silver", MinLevel: 5, DailyBonusPercent: 150, MaxBetPercent: 100},
+	{ID: "gold", Name: "Gold", MinLevel: 10, DailyBonusPercent: 200, MaxBetPercent: 150},
+	{ID: "platinum", Name: "Platinum", MinLevel: 20, DailyBonusPercent: 300, MaxBetPercent: 200},
+	{ID: "diamond", Name: "Diamond", MinLevel: 30, DailyBonusPercent: 500, MaxBetPercent: 300},
+}
+
+// Scales a table's maximum bet by the tier's perk
+func (t VIPTier) MaxBet(base int64) int64 {
+	return base * int64(t.MaxBetPercent) / 100
+}
+
+// Progress describes where a player stands on the level curve
+type Progress struct {
+	XP        int64
+	Level     int
+	Tier      VIPTier
+	LevelXP   int64 // XP at which the current level started
+	NextLevel int64 // XP needed for the next level
+}
+
+// Level n starts at 100*(n-1)^2 XP, so each level takes a little longer
+func LevelForXP(xp int64) int {
+	if xp <= 0 {
+		return 1
+	}
+	level := 1 + int(math.Sqrt(float64(xp)/100))
+	// Guard against float rounding at exact boundaries
+	for XPForLevel(level+1) <= xp {
+		level++
+	}
+	for level > 1 && XPForLevel(level) > xp {
+		level--
+	}
+	return level
+}
+
+func XPForLevel(level int) int64 {
+	n := int64(level - 1)
+	return 100 * n * n
+}
+
+// Returns the highest tier unlocked at the given level
+func TierForLevel(level int) VIPTier {
+	tier := VIPTiers[0]
+	for _, t := range VIPTiers {
+		if level >= t.MinLevel {
+			tier = t
+		}
+	}
+	return tier
+}
+
+func progressFor(xp int64) *Progress {
+	level := LevelForXP(xp)
+	return &Progress{
+		XP:        xp,
+		Level:     level,
+		Tier:      TierForLevel(level),
+		LevelXP:   XPForLevel(level),
+		NextLevel: XPForLevel(level + 1),
+	}
+}
+
+func (as *AuthService) GetProgress(userID int) (*Progress, error) {
+	stats, err := as.db.GetUserStats(userID)
+	if err != nil {
+		return nil, err
+	}
+	return progressFor(stats.XP), nil
+}
+
+// Awards XP for a settled wager, boosted by a running XP event. leveledUp is
+// true when the player reached a new level.
+func (as *AuthService) AwardWagerXP(userID int, wagered int64) (progress *Progress, leveledUp bool, err error) {
+	xp := as.applyEvent(EventXP, wagered/CentsPerXP)
+	if xp <= 0 {
+		p, err := as.GetProgress(userID)
+		return p, false, err
+	}
+
+	total, storedLevel, err := as.db.AddXP(userID, xp)
+	if err != nil {
+		return nil, false, err
+	}
+
+	progress = progressFor(total)
+	if progress.Level != storedLevel {
+		if err := as.db.SetLevel(userID, progress.Level, progress.Tier.ID); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return progress, progress.Level > storedLevel, nil
+}
+
+func (as *AuthService) userTier(userID int) (VIPTier, error) {
+	stats, err := as.db.GetUserStats(userID)
+	if err != nil {
+		return VIPTier{}, err
+	}
+	return TierForLevel(stats.Level), nil
+}
@@ -0,0 +1,77 @@
+package security
+
+import "testing"
+
+func TestLevelForXP(t *testing.T) {
+	tests := []struct {
+		xp   int64
+		want int
+	}{
+		{0, 1},
+		{99, 1},
+		{100, 2},
+		{399, 2},
+		{400, 3},
+		{8100, 10},
+	}
+
+	for _, tt := range tests {
+		if got := LevelForXP(tt.xp); got != tt.want {
+			t.Errorf("LevelForXP(%d) = %d, want %d", tt.xp, got, tt.want)
+		}
+	}
+}
+
+func TestTierForLevel(t *testing.T) {
+	tests := []struct {
+		level int
+		want  string
+	}{
+		{1, "bronze"},
+		{4, "bronze"},
+		{5, "silver"},
+		{19, "gold"},
+		{50, "diamond"},
+	}
+
+	for _, tt := range tests {
+		if got := TierForLevel(tt.level); got.ID != tt.want {
+			t.Errorf("TierForLevel(%d) = %s, want %s", tt.level, got.ID, tt.want)
+		}
+	}
+}
+
+func TestAwardWagerXP(t *testing.T) {
+	auth := setupConfiguredAuthService(t, AuthConfig{DailyBonus: 10000})
+
+	user, err := auth.RegisterUser("xpuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	progress, leveledUp, err := auth.AwardWagerXP(user.ID, 5000)
+	if err != nil {
+		t.Fatalf("AwardWagerXP() error = %v", err)
+	}
+	if progress.XP != 50 || progress.Level != 1 || leveledUp {
+		t.Errorf("AwardWagerXP($50) = %+v, %v, want 50 XP at level 1", progress, leveledUp)
+	}
+
+	// $1600 more puts the player at 1650 XP, level 5 (Silver)
+	progress, leveledUp, err = auth.AwardWagerXP(user.ID, 160000)
+	if err != nil {
+		t.Fatalf("AwardWagerXP() error = %v", err)
+	}
+	if progress.Level != 5 || progress.Tier.ID != "silver" || !leveledUp {
+		t.Errorf("AwardWagerXP($1600) = %+v, %v, want a level up to 5", progress, leveledUp)
+	}
+
+	// Silver players get 150% of the daily bonus
+	amount, _, err := auth.ClaimDailyBonus(user.ID)
+	if err != nil {
+		t.Fatalf("ClaimDailyBonus() error = %v", err)
+	}
+	if amount != 15000 {
+		t.Errorf("ClaimDailyBonus() amount = %d, want 15000", amount)
+	}
+}
@@ -0,0 +1,116 @@
+package security
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+const (
+	LimitDaily  = "daily"
+	LimitWeekly = "weekly"
+	LimitLoss   = "loss"
+	LimitWager  = "wager"
+)
+
+// Limits use rolling windows ending at the time of the bet
+var limitWindows = map[string]time.Duration{
+	LimitDaily:  24 * time.Hour,
+	LimitWeekly: 7 * 24 * time.Hour,
+}
+
+func (as *AuthService) GetLimits(userID int) (*vault.UserLimits, error) {
+	return as.db.GetUserLimits(userID)
+}
+
+// Sets one limit; amount 0 removes it. Players may only tighten their own
+// limits, raising or removing one needs an admin.
+func (as *AuthService) SetLimit(userID int, period, kind string, amount int64, byAdmin bool) error {
+	if _, ok := limitWindows[period]; !ok {
+		return fmt.Errorf("period must be %s or %s", LimitDaily, LimitWeekly)
+	}
+	if kind != LimitLoss && kind != LimitWager {
+		return fmt.Errorf("limit type must be %s or %s", LimitLoss, LimitWager)
+	}
+	if amount < 0 {
+		return fmt.Errorf("limit cannot be negative")
+	}
+
+	limits, err := as.db.GetUserLimits(userID)
+	if err != nil {
+		return err
+	}
+
+	field := limitField(limits, period, kind)
+	if !byAdmin && (amount == 0 || (*field != 0 && amount > *field)) {
+		return fmt.Errorf("limits can only be lowered; ask an admin to raise or remove them")
+	}
+
+	*field = amount
+	return as.db.SetUserLimits(limits)
+}
+
+// Returns an error describing the first limit the bet would break
+func (as *AuthService) CheckBetLimits(userID int, amount int64) error {
+	limits, err := as.db.GetUserLimits(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, period := range []string{LimitDaily, LimitWeekly} {
+		wagerLimit := *limitField(limits, period, LimitWager)
+		lossLimit := *limitField(limits, period, LimitLoss)
+		if wagerLimit == 0 && lossLimit == 0 {
+			continue
+		}
+
+		since := time.Now().Add(-limitWindows[period])
+
+		// Bets are negative in the ledger and refunds give part of them back
+		staked, err := as.db.SumTransactions(userID, since, vault.TxBet, vault.TxRefund)
+		if err != nil {
+			return err
+		}
+		wagered := -staked
+
+		if wagerLimit > 0 && wagered+amount > wagerLimit {
+			return fmt.Errorf("bet would exceed your %s wager limit of $%.2f ($%.2f remaining)",
+				period, float64(wagerLimit)/100, float64(max(wagerLimit-wagered, 0))/100)
+		}
+
+		if lossLimit > 0 {
+			net, err := as.db.SumTransactions(userID, since, vault.TxBet, vault.TxRefund, vault.TxPayout)
+			if err != nil {
+				return err
+			}
+			lost := max(-net, 0)
+
+			// Assume the worst case where the whole stake is lost
+			if lost+amount > lossLimit {
+				return fmt.Errorf("bet would exceed your %s loss limit of $%.2f ($%.2f remaining)",
+					period, float64(lossLimit)/100, float64(max(lossLimit-lost, 0))/100)
+			}
+		}
+	}
+
+	return nil
+}
+
+func limitField(limits *vault.UserLimits, period, kind string) *int64 {
+	switch {
+	case period == LimitDaily && kind == LimitLoss:
+		return &limits.DailyLoss
+	case period == LimitWeekly && kind == LimitLoss:
+		return &limits.WeeklyLoss
+	case period == LimitDaily && kind == LimitWager:
+		return &limits.DailyWager
+	default:
+		return &limits.WeeklyWager
+	}
+}
+
+// Applies delta to the balance and writes the ledger entry atomically
+func (as *AuthService) AdjustBalance(userID int, delta int64, txType string) (int64, error) {
+	return as.db.AdjustBalance(userID, delta, txType)
+}
@@ -0,0 +1,97 @@
+package security
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func TestSetLimitOnlyTightensForPlayers(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("limituser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := auth.SetLimit(user.ID, LimitDaily, LimitLoss, 10000, false); err != nil {
+		t.Fatalf("SetLimit() error = %v", err)
+	}
+
+	if err := auth.SetLimit(user.ID, LimitDaily, LimitLoss, 5000, false); err != nil {
+		t.Errorf("SetLimit() lowering error = %v", err)
+	}
+
+	if err := auth.SetLimit(user.ID, LimitDaily, LimitLoss, 20000, false); err == nil {
+		t.Error("SetLimit() should not let a player raise a limit")
+	}
+
+	if err := auth.SetLimit(user.ID, LimitDaily, LimitLoss, 0, false); err == nil {
+		t.Error("SetLimit() should not let a player remove a limit")
+	}
+
+	if err := auth.SetLimit(user.ID, LimitDaily, LimitLoss, 0, true); err != nil {
+		t.Errorf("SetLimit() admin removal error = %v", err)
+	}
+
+	if err := auth.SetLimit(user.ID, "monthly", LimitLoss, 100, false); err == nil {
+		t.Error("SetLimit() should reject unknown period")
+	}
+
+	if err := auth.SetLimit(user.ID, LimitDaily, "deposit", 100, false); err == nil {
+		t.Error("SetLimit() should reject unknown limit type")
+	}
+}
+
+func TestCheckBetLimits(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("limituser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := auth.CheckBetLimits(user.ID, 500000); err != nil {
+		t.Errorf("CheckBetLimits() with no limits error = %v", err)
+	}
+
+	if err := auth.SetLimit(user.ID, LimitDaily, LimitWager, 20000, false); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := auth.SetLimit(user.ID, LimitWeekly, LimitLoss, 6000, false); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	// Lose $40, then win back $20 on a $20 bet
+	if _, err := auth.AdjustBalance(user.ID, -4000, vault.TxBet); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := auth.AdjustBalance(user.ID, -2000, vault.TxBet); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := auth.AdjustBalance(user.ID, 4000, vault.TxPayout); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	// Wagered $60 and net loss is $20
+	if err := auth.CheckBetLimits(user.ID, 4000); err != nil {
+		t.Errorf("CheckBetLimits() within limits error = %v", err)
+	}
+
+	err = auth.CheckBetLimits(user.ID, 4500)
+	if err == nil || !strings.Contains(err.Error(), "weekly loss limit") {
+		t.Errorf("CheckBetLimits() error = %v, want weekly loss limit error", err)
+	}
+
+	if err := auth.SetLimit(user.ID, LimitWeekly, LimitLoss, 0, true); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	err = auth.CheckBetLimits(user.ID, 15000)
+	if err == nil || !strings.Contains(err.Error(), "daily wager limit") {
+		t.Errorf("CheckBetLimits() error = %v, want daily wager limit error", err)
+	}
+}
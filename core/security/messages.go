@@ -0,0 +1,146 @@
+package security
+
+import (
+	"fmt"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Most messages held for an offline player; later ones are refused until they log in
+const MaxStoredMessages = 50
+
+// Checks that the sender may message the named player and returns the
+// recipient and the message as it should be delivered. Messages go through
+// the same cleaning, mute check and filter as chat.
+func (as *AuthService) PrepareMessage(senderID int, recipientName, text string) (*vault.User, string, error) {
+	recipient, err := as.db.GetUserByUsername(recipientName)
+	if err != nil {
+		return nil, "", fmt.Errorf("user not found")
+	}
+	if recipient.ID == senderID {
+		return nil, "", fmt.Errorf("you can't message yourself")
+	}
+
+	if blocked, err := as.db.IsBlocked(senderID, recipient.ID); err != nil {
+		return nil, "", err
+	} else if blocked {
+		return nil, "", fmt.Errorf("you have blocked %s, UNBLOCK them first", recipient.Username)
+	}
+	if blocked, err := as.db.IsBlocked(recipient.ID, senderID); err != nil {
+		return nil, "", err
+	} else if blocked {
+		return nil, "", fmt.Errorf("%s is not accepting messages from you", recipient.Username)
+	}
+
+	message, err := as.PrepareChat(senderID, text)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return recipient, message, nil
+}
+
+// Holds a prepared message for a recipient who is offline
+func (as *AuthService) StoreMessage(senderID, recipientID int, message string) error {
+	count, err := as.db.CountStoredMessages(recipientID)
+	if err != nil {
+		return err
+	}
+	if count >= MaxStoredMessages {
+		return fmt.Errorf("their inbox is full, try again once they're online")
+	}
+
+	return as.db.StoreMessage(senderID, recipientID, message)
+}
+
+// Returns and clears the messages held for a user while they were offline
+func (as *AuthService) TakeStoredMessages(userID int) ([]*vault.DirectMessage, error) {
+	return as.db.TakeStoredMessages(userID)
+}
+
+// Stops the named player messaging the user
+func (as *AuthService) BlockUser(userID int, name string) (*vault.User, error) {
+	blocked, err := as.db.GetUserByUsername(name)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	if blocked.ID == userID {
+		return nil, fmt.Errorf("you can't block yourself")
+	}
+
+	if err := as.db.BlockUser(userID, blocked.ID); err != nil {
+		return nil, err
+	}
+	return blocked, nil
+}
+
+func (as *AuthService) UnblockUser(userID int, name string) (*vault.User, error) {
+	blocked, err := as.db.GetUserByUsername(name)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	removed, err := as.db.UnblockUser(userID, blocked.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return nil, fmt.Errorf("%s is not blocked", blocked.Username)
+	}
+	return blocked, nil
+}
+
+func (as *AuthService) ListBlockedUsers(userID int) ([]string, error) {
+	return as.db.ListBlockedUsers(userID)
+}
+
+// Hides the named player's chat and reactions from the user; unlike a block
+// it still lets them send direct messages
+func (as *AuthService) MuteUser(userID int, name string) (*vault.User, error) {
+	muted, err := as.db.GetUserByUsername(name)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	if muted.ID == userID {
+		return nil, fmt.Errorf("you can't mute yourself")
+	}
+
+	if err := as.db.MuteUser(userID, muted.ID); err != nil {
+		return nil, err
+	}
+	return muted, nil
+}
+
+func (as *AuthService) UnmuteUser(userID int, name string) (*vault.User, error) {
+	muted, err := as.db.GetUserByUsername(name)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	removed, err := as.db.UnmuteUser(userID, muted.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return nil, fmt.Errorf("%s is not muted", muted.Username)
+	}
+	return muted, nil
+}
+
+func (as *AuthService) ListMutedUsers(userID int) ([]string, error) {
+	return as.db.ListMutedUsers(userID)
+}
+
+// Returns the users who don't want to see the sender's chat, having blocked or muted them
+func (as *AuthService) IgnoredBy(senderID int) (map[int]bool, error) {
+	ids, err := as.db.ListUsersIgnoring(senderID)
+	if err != nil {
+		return nil, err
+	}
+
+	ignoring := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		ignoring[id] = true
+	}
+	return ignoring, nil
+}
@@ -0,0 +1,107 @@
+package security
+
+import "testing"
+
+func TestPrepareMessage(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	alice, err := auth.RegisterUser("alicemsg", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	bob, err := auth.RegisterUser("bobmsg", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	recipient, message, err := auth.PrepareMessage(alice.ID, "bobmsg", " hi bob ")
+	if err != nil || recipient.ID != bob.ID || message != "hi bob" {
+		t.Errorf("PrepareMessage() = %v, %q, %v", recipient, message, err)
+	}
+
+	for _, name := range []string{"nobody", "alicemsg"} {
+		if _, _, err := auth.PrepareMessage(alice.ID, name, "hi"); err == nil {
+			t.Errorf("PrepareMessage(%q) should fail", name)
+		}
+	}
+
+	// A block stops messages both ways
+	if _, err := auth.BlockUser(bob.ID, "alicemsg"); err != nil {
+		t.Fatalf("BlockUser() error = %v", err)
+	}
+	if _, _, err := auth.PrepareMessage(alice.ID, "bobmsg", "hi"); err == nil {
+		t.Error("PrepareMessage() to a player who blocked the sender should fail")
+	}
+	if _, _, err := auth.PrepareMessage(bob.ID, "alicemsg", "hi"); err == nil {
+		t.Error("PrepareMessage() to a blocked player should fail")
+	}
+
+	if _, err := auth.UnblockUser(bob.ID, "alicemsg"); err != nil {
+		t.Fatalf("UnblockUser() error = %v", err)
+	}
+	if _, _, err := auth.PrepareMessage(alice.ID, "bobmsg", "hi"); err != nil {
+		t.Errorf("PrepareMessage() after unblock error = %v", err)
+	}
+	if _, err := auth.BlockUser(bob.ID, "bobmsg"); err == nil {
+		t.Error("BlockUser() of yourself should fail")
+	}
+}
+
+func TestStoreMessageCap(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	alice, _ := auth.RegisterUser("alicecap", "testpassword456")
+	bob, _ := auth.RegisterUser("bobcap", "testpassword456")
+
+	for i := 0; i < MaxStoredMessages; i++ {
+		if err := auth.StoreMessage(alice.ID, bob.ID, "hello"); err != nil {
+			t.Fatalf("StoreMessage() #%d error = %v", i+1, err)
+		}
+	}
+	if err := auth.StoreMessage(alice.ID, bob.ID, "hello"); err == nil {
+		t.Error("StoreMessage() past the cap should fail")
+	}
+
+	messages, err := auth.TakeStoredMessages(bob.ID)
+	if err != nil || len(messages) != MaxStoredMessages {
+		t.Errorf("TakeStoredMessages() = %d messages, %v, want %d", len(messages), err, MaxStoredMessages)
+	}
+	if err := auth.StoreMessage(alice.ID, bob.ID, "hello"); err != nil {
+		t.Errorf("StoreMessage() after delivery error = %v", err)
+	}
+}
+
+func TestMuteUser(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	alice, _ := auth.RegisterUser("alicemute", "testpassword456")
+	bob, _ := auth.RegisterUser("bobmute", "testpassword456")
+
+	if _, err := auth.MuteUser(alice.ID, "alicemute"); err == nil {
+		t.Error("MuteUser() of yourself should fail")
+	}
+	if _, err := auth.MuteUser(alice.ID, "bobmute"); err != nil {
+		t.Fatalf("MuteUser() error = %v", err)
+	}
+
+	if ignoring, err := auth.IgnoredBy(bob.ID); err != nil || !ignoring[alice.ID] {
+		t.Errorf("IgnoredBy(bob) = %v, %v, want alice", ignoring, err)
+	}
+	// A mute hides chat but still lets direct messages through
+	if _, _, err := auth.PrepareMessage(bob.ID, "alicemute", "hi"); err != nil {
+		t.Errorf("PrepareMessage() from a muted player error = %v", err)
+	}
+
+	if _, err := auth.UnmuteUser(alice.ID, "bobmute"); err != nil {
+		t.Fatalf("UnmuteUser() error = %v", err)
+	}
+	if ignoring, err := auth.IgnoredBy(bob.ID); err != nil || ignoring[alice.ID] {
+		t.Errorf("IgnoredBy(bob) after unmute = %v, %v, want nobody", ignoring, err)
+	}
+	if _, err := auth.UnmuteUser(alice.ID, "bobmute"); err == nil {
+		t.Error("UnmuteUser() should fail when not muted")
+	}
+}
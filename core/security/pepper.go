@@ -0,0 +1,171 @@
+package security
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Peppered hashes are stored as $pepper$v<version>$<bcrypt hash>; plain bcrypt
+// hashes from before a pepper was configured are still accepted
+const pepperPrefix = "$pepper$v"
+
+// PepperSet holds every known pepper by version; new hashes use Current
+type PepperSet struct {
+	Current int
+	Keys    map[int][]byte
+}
+
+func NewPepperSet(version int, secret []byte) *PepperSet {
+	return &PepperSet{Current: version, Keys: map[int][]byte{version: secret}}
+}
+
+// Loads a keyfile of "<version> <secret>" lines; the highest version becomes current
+func LoadPepperFile(path string) (*PepperSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pepper file: %w", err)
+	}
+	defer f.Close()
+
+	set := &PepperSet{Keys: make(map[int][]byte)}
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("pepper file line %d: want \"<version> <secret>\"", lineNo)
+		}
+		version, err := strconv.Atoi(fields[0])
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("pepper file line %d: invalid version %q", lineNo, fields[0])
+		}
+
+		set.Keys[version] = []byte(fields[1])
+		if version > set.Current {
+			set.Current = version
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pepper file: %w", err)
+	}
+	if len(set.Keys) == 0 {
+		return nil, fmt.Errorf("pepper file %s has no keys", path)
+	}
+
+	return set, nil
+}
+
+// HMACs the password with the pepper so bcrypt's 72-byte limit is never hit
+func (p *PepperSet) pepper(version int, password string) ([]byte, error) {
+	key, ok := p.Keys[version]
+	if !ok {
+		return nil, fmt.Errorf("unknown pepper version %d", version)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(password))
+	return []byte(base64.StdEncoding.EncodeToString(mac.Sum(nil))), nil
+}
+
+func (p *PepperSet) Hash(password string) (string, error) {
+	if err := ValidatePassword(password); err != nil {
+		return "", err
+	}
+
+	peppered, err := p.pepper(p.Current, password)
+	if err != nil {
+		return "", err
+	}
+
+	bytes, err := bcrypt.GenerateFromPassword(peppered, bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return fmt.Sprintf("%s%d$%s", pepperPrefix, p.Current, bytes), nil
+}
+
+// Verifies a peppered or legacy hash and reports whether it should be
+// re-hashed with the current pepper
+func (p *PepperSet) Verify(password, hash string) (needsRehash bool, err error) {
+	version, inner, peppered := parsePepperedHash(hash)
+	if !peppered {
+		if err := VerifyPassword(password, hash); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	pepperedPassword, err := p.pepper(version, password)
+	if err != nil {
+		return false, fmt.Errorf("invalid password")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(inner), pepperedPassword); err != nil {
+		return false, fmt.Errorf("invalid password")
+	}
+
+	return version != p.Current, nil
+}
+
+func parsePepperedHash(hash string) (version int, inner string, ok bool) {
+	if !strings.HasPrefix(hash, pepperPrefix) {
+		return 0, "", false
+	}
+
+	rest := hash[len(pepperPrefix):]
+	idx := strings.Index(rest, "$")
+	if idx < 0 {
+		return 0, "", false
+	}
+
+	version, err := strconv.Atoi(rest[:idx])
+	if err != nil {
+		return 0, "", false
+	}
+
+	return version, rest[idx+1:], true
+}
+
+// Hashes with the configured pepper, or plain bcrypt when none is set
+func (as *AuthService) hashPassword(password string) (string, error) {
+	if as.config.Peppers != nil {
+		return as.config.Peppers.Hash(password)
+	}
+	return HashPassword(password)
+}
+
+// Verifies a password and upgrades its stored hash to the current pepper version
+func (as *AuthService) verifyPassword(userID int, password, hash string) error {
+	if as.config.Peppers == nil {
+		if _, _, peppered := parsePepperedHash(hash); peppered {
+			// Peppered hashes can't be checked once the pepper is removed
+			return fmt.Errorf("invalid password")
+		}
+		return VerifyPassword(password, hash)
+	}
+
+	needsRehash, err := as.config.Peppers.Verify(password, hash)
+	if err != nil {
+		return err
+	}
+
+	if needsRehash {
+		if newHash, err := as.config.Peppers.Hash(password); err == nil {
+			as.db.UpdateUserPassword(userID, newHash)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPepperSetHashAndVerify(t *testing.T) {
+	peppers := NewPepperSet(1, []byte("pepper-one"))
+
+	hash, err := peppers.Hash("testpassword")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if !strings.HasPrefix(hash, "$pepper$v1$") {
+		t.Errorf("Hash() = %s, want $pepper$v1$ prefix", hash)
+	}
+
+	rehash, err := peppers.Verify("testpassword", hash)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if rehash {
+		t.Error("Verify() should not ask to rehash a current-version hash")
+	}
+
+	if _, err := peppers.Verify("wrongpassword", hash); err == nil {
+		t.Error("Verify() should fail for a wrong password")
+	}
+
+	// The hash alone is useless without the pepper
+	other := NewPepperSet(1, []byte("pepper-two"))
+	if _, err := other.Verify("testpassword", hash); err == nil {
+		t.Error("Verify() should fail with a different pepper")
+	}
+}
+
+func TestPepperSetLegacyAndRotation(t *testing.T) {
+	legacy, err := HashPassword("testpassword")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	v1 := NewPepperSet(1, []byte("pepper-one"))
+	rehash, err := v1.Verify("testpassword", legacy)
+	if err != nil || !rehash {
+		t.Errorf("Verify(legacy) = (%v, %v), want (true, nil)", rehash, err)
+	}
+
+	oldHash, err := v1.Hash("testpassword")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	v2 := &PepperSet{Current: 2, Keys: map[int][]byte{1: []byte("pepper-one"), 2: []byte("pepper-two")}}
+	rehash, err = v2.Verify("testpassword", oldHash)
+	if err != nil || !rehash {
+		t.Errorf("Verify(v1 hash with v2 current) = (%v, %v), want (true, nil)", rehash, err)
+	}
+}
+
+func TestLoadPepperFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pepper.keys")
+	content := "# rotated yearly\n1 first-secret\n3 third-secret\n2 second-secret\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	peppers, err := LoadPepperFile(path)
+	if err != nil {
+		t.Fatalf("LoadPepperFile() error = %v", err)
+	}
+	if peppers.Current != 3 || len(peppers.Keys) != 3 || string(peppers.Keys[2]) != "second-secret" {
+		t.Errorf("LoadPepperFile() = %+v, want 3 keys with version 3 current", peppers)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.keys")
+	if err := os.WriteFile(bad, []byte("x secret\n"), 0600); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := LoadPepperFile(bad); err == nil {
+		t.Error("LoadPepperFile() should reject an invalid version")
+	}
+}
+
+func TestLoginUpgradesPepperVersion(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	// Account created before any pepper was configured
+	user, err := auth.RegisterUser("pepperuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	peppered := NewAuthServiceWithConfig(auth.db, AuthConfig{Peppers: NewPepperSet(1, []byte("pepper-one"))})
+	if _, _, err := peppered.LoginUser("pepperuser", "testpassword456"); err != nil {
+		t.Fatalf("LoginUser() error = %v", err)
+	}
+
+	stored, err := auth.db.GetUserByID(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if !strings.HasPrefix(stored.Password, "$pepper$v1$") {
+		t.Errorf("stored hash = %s, want upgrade to pepper v1", stored.Password)
+	}
+
+	// Without the pepper the upgraded hash can no longer be verified
+	if _, _, err := auth.LoginUser("pepperuser", "testpassword456"); err == nil {
+		t.Error("LoginUser() without pepper should fail for a peppered hash")
+	}
+}
@@ -0,0 +1,45 @@
+package security
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Ranges PROFIT can report, in days
+var ProfitRanges = []int{7, 30}
+
+// Ledger entries that make up a player's result at the tables; bonuses,
+// transfers and adjustments are left out
+var gameTxTypes = []string{vault.TxBet, vault.TxRefund, vault.TxPayout, vault.TxJackpot}
+
+// Returns the user's net game result for each of the last days UTC days,
+// oldest first and ending today, with zero for days without play
+func (as *AuthService) ProfitHistory(userID, days int) ([]*vault.DailyNet, error) {
+	if !slices.Contains(ProfitRanges, days) {
+		return nil, fmt.Errorf("range must be 7 or 30 days")
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	start := today.AddDate(0, 0, -(days - 1))
+
+	sums, err := as.db.SumTransactionsByDay(userID, start, gameTxTypes...)
+	if err != nil {
+		return nil, err
+	}
+
+	byDay := make(map[string]int64, len(sums))
+	for _, s := range sums {
+		byDay[s.Day] = s.Net
+	}
+
+	history := make([]*vault.DailyNet, days)
+	for i := range history {
+		day := start.AddDate(0, 0, i).Format("2006-01-02")
+		history[i] = &vault.DailyNet{Day: day, Net: byDay[day]}
+	}
+
+	return history, nil
+}
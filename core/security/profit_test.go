@@ -0,0 +1,56 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func TestProfitHistory(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("profituser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	// A losing and a winning round today; the bonus isn't game profit
+	for _, entry := range []struct {
+		delta  int64
+		txType string
+	}{
+		{-1000, vault.TxBet},
+		{-2000, vault.TxBet},
+		{4000, vault.TxPayout},
+		{5000, vault.TxBonus},
+	} {
+		if _, err := auth.AdjustBalance(user.ID, entry.delta, entry.txType); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+	}
+
+	history, err := auth.ProfitHistory(user.ID, 7)
+	if err != nil {
+		t.Fatalf("ProfitHistory() error = %v", err)
+	}
+	if len(history) != 7 {
+		t.Fatalf("ProfitHistory() returned %d days, want 7", len(history))
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	last := history[len(history)-1]
+	if last.Day != today || last.Net != 1000 {
+		t.Errorf("Last day = %+v, want %s with 1000", last, today)
+	}
+	for _, d := range history[:len(history)-1] {
+		if d.Net != 0 {
+			t.Errorf("Day %s net = %d, want 0", d.Day, d.Net)
+		}
+	}
+
+	if _, err := auth.ProfitHistory(user.ID, 365); err == nil {
+		t.Error("ProfitHistory() should reject an unsupported range")
+	}
+}
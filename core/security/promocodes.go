@@ -0,0 +1,69 @@
+package security
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+const MaxPromoUses = 10000
+
+var promoCodePattern = regexp.MustCompile(`^[A-Z0-9]{4,20}$`)
+
+// Creates a promo code worth value cents, redeemable by up to maxUses players.
+// An empty code is generated; a zero ttl never expires.
+func (as *AuthService) CreatePromoCode(actor, code string, value int64, maxUses int, ttl time.Duration) (*vault.PromoCode, error) {
+	if value <= 0 {
+		return nil, fmt.Errorf("value must be greater than zero")
+	}
+	if maxUses < 1 || maxUses > MaxPromoUses {
+		return nil, fmt.Errorf("uses must be between 1 and %d", MaxPromoUses)
+	}
+
+	code = strings.ToUpper(code)
+	if code == "" {
+		var err error
+		if code, err = GenerateInviteCode(); err != nil {
+			return nil, err
+		}
+	} else if !promoCodePattern.MatchString(code) {
+		return nil, fmt.Errorf("promo codes must be 4-20 letters or digits")
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	promo, err := as.db.CreatePromoCode(code, value, actor, maxUses, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	as.db.RecordAuditEvent(0, vault.AuditPromoCreate, "",
+		fmt.Sprintf("%s worth $%.2f x%d by %s", promo.Code, float64(value)/100, maxUses, actor))
+
+	return promo, nil
+}
+
+func (as *AuthService) ListPromoCodes() ([]*vault.PromoCode, error) {
+	return as.db.ListPromoCodes()
+}
+
+// Credits a promo code to a user; successful and failed attempts are both audited
+func (as *AuthService) RedeemPromoCode(userID int, code, ip string) (value, balance int64, err error) {
+	code = strings.ToUpper(code)
+
+	value, balance, err = as.db.RedeemPromoCode(code, userID)
+	if err != nil {
+		as.db.RecordAuditEvent(userID, vault.AuditPromoFailed, ip, fmt.Sprintf("%s: %s", code, err.Error()))
+		return 0, 0, err
+	}
+
+	as.db.RecordAuditEvent(userID, vault.AuditPromoRedeem, ip, fmt.Sprintf("%s worth $%.2f", code, float64(value)/100))
+
+	return value, balance, nil
+}
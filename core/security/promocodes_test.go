@@ -0,0 +1,80 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func TestRedeemPromoCode(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("promouser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	promo, err := auth.CreatePromoCode("admin", "spring24", 5000, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePromoCode() error = %v", err)
+	}
+	if promo.Code != "SPRING24" {
+		t.Errorf("CreatePromoCode() code = %q, want SPRING24", promo.Code)
+	}
+
+	value, balance, err := auth.RedeemPromoCode(user.ID, "Spring24", "10.0.0.1")
+	if err != nil || value != 5000 || balance != 1005000 {
+		t.Errorf("RedeemPromoCode() = %d, %d, %v, want 5000 leaving 1005000", value, balance, err)
+	}
+
+	if _, _, err := auth.RedeemPromoCode(user.ID, "SPRING24", "10.0.0.1"); err == nil {
+		t.Error("RedeemPromoCode() should only work once per user")
+	}
+
+	events, err := auth.db.ListAuditEvents(user.ID, 10)
+	if err != nil {
+		t.Fatalf("ListAuditEvents() error = %v", err)
+	}
+	var redeemed, failed int
+	for _, e := range events {
+		switch e.Type {
+		case vault.AuditPromoRedeem:
+			redeemed++
+		case vault.AuditPromoFailed:
+			failed++
+		}
+	}
+	if redeemed != 1 || failed != 1 {
+		t.Errorf("Audit log has %d redemptions and %d failures, want 1 and 1", redeemed, failed)
+	}
+}
+
+func TestCreatePromoCodeValidation(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	tests := []struct {
+		name    string
+		code    string
+		value   int64
+		uses    int
+		wantErr bool
+	}{
+		{"generated code", "", 1000, 1, false},
+		{"zero value", "FREEBIE", 0, 1, true},
+		{"zero uses", "FREEBIE", 1000, 0, true},
+		{"too many uses", "FREEBIE", 1000, MaxPromoUses + 1, true},
+		{"short code", "ABC", 1000, 1, true},
+		{"punctuation", "FREE-CHIPS", 1000, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := auth.CreatePromoCode("admin", tt.code, tt.value, tt.uses, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreatePromoCode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
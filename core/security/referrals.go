@@ -0,0 +1,123 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// ReferralPayout describes a referral bonus paid to both accounts
+type ReferralPayout struct {
+	ReferrerID      int
+	ReferredID      int
+	Amount          int64
+	ReferrerBalance int64
+	ReferredBalance int64
+}
+
+func (as *AuthService) ReferralsEnabled() bool {
+	return as.config.ReferralBonus > 0
+}
+
+// Returns the user's referral code, creating one the first time it is asked for
+func (as *AuthService) ReferralCode(userID int) (string, error) {
+	if !as.ReferralsEnabled() {
+		return "", fmt.Errorf("referrals are not available")
+	}
+
+	if code, ok, err := as.db.GetReferralCode(userID); err != nil || ok {
+		return code, err
+	}
+
+	code, err := GenerateInviteCode()
+	if err != nil {
+		return "", err
+	}
+	if err := as.db.CreateReferralCode(userID, code); err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+func (as *AuthService) ListReferrals(userID int) ([]*vault.Referral, error) {
+	return as.db.ListReferrals(userID)
+}
+
+// Resolves a referral code supplied at signup to the referring user
+func (as *AuthService) referrer(code string) (int, error) {
+	if !as.ReferralsEnabled() {
+		return 0, fmt.Errorf("referrals are not available")
+	}
+	return as.db.GetReferralCodeOwner(strings.ToUpper(code))
+}
+
+// Links a new account to its referrer. Signups from an address the referrer
+// has logged in from are recorded but never paid.
+func (as *AuthService) recordReferral(referrerID, referredID int, code, ip string) {
+	status, note := vault.ReferralPending, ""
+	if ip != "" {
+		if seen, err := as.db.HasLoginIP(referrerID, ip); err == nil && seen {
+			status, note = vault.ReferralRejected, "signup from the referrer's address"
+		}
+	}
+
+	if err := as.db.CreateReferral(referrerID, referredID, strings.ToUpper(code), status, ip, note); err != nil {
+		return
+	}
+	if status == vault.ReferralRejected {
+		as.db.RecordAuditEvent(referredID, vault.AuditReferral, ip, fmt.Sprintf("rejected referral by user %d: %s", referrerID, note))
+	}
+}
+
+// Pays the referral bonus once a referred player has wagered enough. Returns
+// nil when there is nothing to pay yet.
+func (as *AuthService) CheckReferral(userID int) (*ReferralPayout, error) {
+	if !as.ReferralsEnabled() {
+		return nil, nil
+	}
+
+	referral, ok, err := as.db.GetReferral(userID)
+	if err != nil || !ok || referral.Status != vault.ReferralPending {
+		return nil, err
+	}
+
+	// Bets are negative in the ledger and refunds give part of them back
+	staked, err := as.db.SumTransactions(userID, referral.CreatedAt, vault.TxBet, vault.TxRefund)
+	if err != nil {
+		return nil, err
+	}
+	if -staked < as.config.ReferralWager {
+		return nil, nil
+	}
+
+	// Both accounts playing from one address looks like a player referring themselves
+	shared, err := as.db.SharesLoginIP(referral.ReferrerID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		note := "referrer and referred account share a login address"
+		if err := as.db.RejectReferral(userID, note); err != nil {
+			return nil, err
+		}
+		as.db.RecordAuditEvent(userID, vault.AuditReferral, "", fmt.Sprintf("rejected referral by user %d: %s", referral.ReferrerID, note))
+		return nil, nil
+	}
+
+	referrerBalance, referredBalance, paid, err := as.db.PayReferral(userID, as.config.ReferralBonus)
+	if err != nil || !paid {
+		return nil, err
+	}
+	as.db.RecordAuditEvent(userID, vault.AuditReferral, "", fmt.Sprintf("paid $%.2f to user %d and user %d",
+		float64(as.config.ReferralBonus)/100, referral.ReferrerID, userID))
+
+	return &ReferralPayout{
+		ReferrerID:      referral.ReferrerID,
+		ReferredID:      userID,
+		Amount:          as.config.ReferralBonus,
+		ReferrerBalance: referrerBalance,
+		ReferredBalance: referredBalance,
+	}, nil
+}
@@ -0,0 +1,113 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func TestReferralBonus(t *testing.T) {
+	auth := setupConfiguredAuthService(t, AuthConfig{ReferralBonus: 2500, ReferralWager: 10000})
+
+	referrer, err := auth.RegisterUser("referrer", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	code, err := auth.ReferralCode(referrer.ID)
+	if err != nil {
+		t.Fatalf("ReferralCode() error = %v", err)
+	}
+	if again, _ := auth.ReferralCode(referrer.ID); again != code {
+		t.Errorf("ReferralCode() changed from %q to %q", code, again)
+	}
+
+	if _, err := auth.RegisterUserWithOptions("badcode", "testpassword456", RegisterOptions{ReferralCode: "NOTACODE"}); err == nil {
+		t.Error("RegisterUserWithOptions() should reject an unknown referral code")
+	}
+
+	referred, err := auth.RegisterUserWithOptions("referred", "testpassword456", RegisterOptions{IP: "10.0.0.2", ReferralCode: code})
+	if err != nil {
+		t.Fatalf("RegisterUserWithOptions() error = %v", err)
+	}
+
+	if _, err := auth.AdjustBalance(referred.ID, -5000, vault.TxBet); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if payout, err := auth.CheckReferral(referred.ID); err != nil || payout != nil {
+		t.Errorf("CheckReferral() below the wager threshold = %+v, %v, want nothing", payout, err)
+	}
+
+	if _, err := auth.AdjustBalance(referred.ID, -5000, vault.TxBet); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	payout, err := auth.CheckReferral(referred.ID)
+	if err != nil || payout == nil {
+		t.Fatalf("CheckReferral() = %+v, %v, want a payout", payout, err)
+	}
+	if payout.ReferrerID != referrer.ID || payout.ReferrerBalance != 1002500 || payout.ReferredBalance != 992500 {
+		t.Errorf("CheckReferral() = %+v, want $25 to both accounts", payout)
+	}
+
+	if payout, err := auth.CheckReferral(referred.ID); err != nil || payout != nil {
+		t.Errorf("Second CheckReferral() = %+v, %v, want nothing", payout, err)
+	}
+}
+
+func TestReferralSameIPRejected(t *testing.T) {
+	auth := setupConfiguredAuthService(t, AuthConfig{ReferralBonus: 2500, ReferralWager: 100})
+
+	referrer, err := auth.RegisterUser("sameipreferrer", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, _, err := auth.LoginUserFromIP("sameipreferrer", "testpassword456", "10.0.0.9"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	code, err := auth.ReferralCode(referrer.ID)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	referred, err := auth.RegisterUserWithOptions("sameipreferred", "testpassword456", RegisterOptions{IP: "10.0.0.9", ReferralCode: code})
+	if err != nil {
+		t.Fatalf("RegisterUserWithOptions() error = %v", err)
+	}
+	if _, err := auth.AdjustBalance(referred.ID, -1000, vault.TxBet); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if payout, err := auth.CheckReferral(referred.ID); err != nil || payout != nil {
+		t.Errorf("CheckReferral() = %+v, %v, want no payout for a same-IP signup", payout, err)
+	}
+
+	referrals, err := auth.ListReferrals(referrer.ID)
+	if err != nil || len(referrals) != 1 || referrals[0].Status != vault.ReferralRejected {
+		t.Errorf("ListReferrals() = %v, %v, want one rejected referral", referrals, err)
+	}
+}
+
+func TestReferralSharedLoginRejected(t *testing.T) {
+	auth := setupConfiguredAuthService(t, AuthConfig{ReferralBonus: 2500, ReferralWager: 100})
+
+	referrer, err := auth.RegisterUser("sharedreferrer", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	code, _ := auth.ReferralCode(referrer.ID)
+
+	referred, err := auth.RegisterUserWithOptions("sharedreferred", "testpassword456", RegisterOptions{IP: "10.0.0.3", ReferralCode: code})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	// Both accounts later log in from the same address
+	auth.LoginUserFromIP("sharedreferrer", "testpassword456", "10.0.0.4")
+	auth.LoginUserFromIP("sharedreferred", "testpassword456", "10.0.0.4")
+	if _, err := auth.AdjustBalance(referred.ID, -1000, vault.TxBet); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if payout, err := auth.CheckReferral(referred.ID); err != nil || payout != nil {
+		t.Errorf("CheckReferral() = %+v, %v, want no payout when logins share an address", payout, err)
+	}
+}
@@ -0,0 +1,55 @@
+package security
+
+import (
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Files the daily report for each UTC day finished since the last one filed,
+// or for yesterday when none has been, and returns them oldest first
+func (as *AuthService) FileDailyReports(now time.Time) ([]*vault.DailyReport, error) {
+	today := utcDay(now)
+	day := today.AddDate(0, 0, -1)
+
+	latest, err := as.db.LatestDailyReportDay()
+	if err != nil {
+		return nil, err
+	}
+	if latest != "" {
+		last, err := time.Parse("2006-01-02", latest)
+		if err != nil {
+			return nil, err
+		}
+		day = last.AddDate(0, 0, 1)
+	}
+
+	var filed []*vault.DailyReport
+	for ; day.Before(today); day = day.AddDate(0, 0, 1) {
+		report, err := as.db.BuildDailyReport(day)
+		if err != nil {
+			return filed, err
+		}
+		if err := as.db.SaveDailyReport(report); err != nil {
+			return filed, err
+		}
+		filed = append(filed, report)
+	}
+	return filed, nil
+}
+
+// Returns the report for the UTC day holding day: the one filed when there is
+// one, otherwise built from the activity recorded so far
+func (as *AuthService) DailyReport(day time.Time) (*vault.DailyReport, error) {
+	report, err := as.db.GetDailyReport(utcDay(day).Format("2006-01-02"))
+	if err != nil || report != nil {
+		return report, err
+	}
+	return as.db.BuildDailyReport(day)
+}
+
+// Returns midnight UTC at the start of t's day
+func utcDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,50 @@
+package security
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFileDailyReports(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("reportuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := auth.AdjustBalance(user.ID, -1000, "bet"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := auth.SettleRound(user.ID, GameBlackjack, 1000, 0, "", "", nil); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	now := time.Now()
+	filed, err := auth.FileDailyReports(now)
+	if err != nil {
+		t.Fatalf("FileDailyReports() error = %v", err)
+	}
+	yesterday := now.UTC().AddDate(0, 0, -1).Format("2006-01-02")
+	if len(filed) != 1 || filed[0].Day != yesterday {
+		t.Fatalf("FileDailyReports() = %+v, want only yesterday's", filed)
+	}
+	if again, err := auth.FileDailyReports(now); err != nil || len(again) != 0 {
+		t.Errorf("FileDailyReports() again = %+v, %v, want nothing new", again, err)
+	}
+
+	// Today's activity is reported live until the day is filed
+	today, err := auth.DailyReport(now)
+	if err != nil || today.Wagered != 1000 || today.ActivePlayers != 1 {
+		t.Errorf("DailyReport(today) = %+v, %v, want $10 wagered by one player", today, err)
+	}
+
+	// Days missed while the server was down are caught up
+	filed, err = auth.FileDailyReports(now.AddDate(0, 0, 2))
+	if err != nil || len(filed) != 2 {
+		t.Fatalf("FileDailyReports() two days later = %+v, %v, want two reports", filed, err)
+	}
+	if filed[0].Wagered != 1000 || filed[0].Signups != 1 {
+		t.Errorf("Filed report = %+v, want today's activity", filed[0])
+	}
+}
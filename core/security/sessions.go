@@ -0,0 +1,30 @@
+package security
+
+import (
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// SessionStore keeps login sessions. The database is the default; a shared
+// store such as vault.RedisSessionStore lets several servers behind a load
+// balancer accept each other's sessions. GetSession fails for a session that
+// doesn't exist or has expired.
+type SessionStore interface {
+	CreateSession(sessionID string, userID int, expiresAt time.Time) error
+	GetSession(sessionID string) (*vault.Session, error)
+	DeleteSession(sessionID string) error
+	DeleteUserSessions(userID int) error
+	CleanupExpiredSessions() error
+}
+
+// Moves sessions to another store. Sessions already in the old one are not
+// carried over, so it's meant to be called at startup.
+func (as *AuthService) SetSessionStore(store SessionStore) {
+	as.sessions = store
+}
+
+// Removes expired sessions from the session store
+func (as *AuthService) CleanupExpiredSessions() error {
+	return as.sessions.CleanupExpiredSessions()
+}
@@ -0,0 +1,96 @@
+package security
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// memorySessions is a SessionStore kept in a map
+type memorySessions struct {
+	mu       sync.Mutex
+	sessions map[string]*vault.Session
+}
+
+func (m *memorySessions) CreateSession(sessionID string, userID int, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[sessionID] = &vault.Session{ID: sessionID, UserID: userID, CreatedAt: time.Now(), ExpiresAt: expiresAt}
+	return nil
+}
+
+func (m *memorySessions) GetSession(sessionID string) (*vault.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[sessionID]
+	if !ok || IsSessionExpired(s.ExpiresAt) {
+		return nil, fmt.Errorf("session not found or expired")
+	}
+	return s, nil
+}
+
+func (m *memorySessions) DeleteSession(sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, sessionID)
+	return nil
+}
+
+func (m *memorySessions) DeleteUserSessions(userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, s := range m.sessions {
+		if s.UserID == userID {
+			delete(m.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (m *memorySessions) CleanupExpiredSessions() error {
+	return nil
+}
+
+func TestSessionStore(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	store := &memorySessions{sessions: map[string]*vault.Session{}}
+	auth.SetSessionStore(store)
+
+	if _, err := auth.RegisterUser("storeuser", "testpassword456"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	sessionID, user, err := auth.LoginUser("storeuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("LoginUser() error = %v", err)
+	}
+	if _, ok := store.sessions[sessionID]; !ok {
+		t.Fatal("LoginUser() should create the session in the session store")
+	}
+
+	// A second server sharing the store accepts the session
+	other := NewAuthService(auth.db)
+	other.SetSessionStore(store)
+	if got, err := other.ValidateSession(sessionID); err != nil || got.ID != user.ID {
+		t.Errorf("ValidateSession() on another server = %v, %v", got, err)
+	}
+
+	if err := auth.LogoutUser(sessionID); err != nil {
+		t.Fatalf("LogoutUser() error = %v", err)
+	}
+	if _, err := other.ValidateSession(sessionID); err == nil {
+		t.Error("a logged out session should be rejected everywhere")
+	}
+
+	sessionID, _, _ = auth.LoginUser("storeuser", "testpassword456")
+	if _, err := auth.BanUser("console", user.ID, time.Hour, "", ""); err != nil {
+		t.Fatalf("BanUser() error = %v", err)
+	}
+	if _, ok := store.sessions[sessionID]; ok {
+		t.Error("BanUser() should end the user's sessions in the session store")
+	}
+}
@@ -0,0 +1,192 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Wagered cents per comp point earned
+const CentsPerCompPoint = 1000
+
+// Cosmetic slots; each shows one equipped item
+const (
+	SlotCardBack   = "card_back"
+	SlotTableTheme = "table_theme"
+	SlotTitle      = "title"
+)
+
+// ShopItem is something comp points (or, for cosmetics, chips) can buy:
+// a chip bundle, or a cosmetic the player keeps and can equip
+type ShopItem struct {
+	ID    string
+	Name  string
+	Cost  int64  // In comp points; zero if it can't be redeemed
+	Price int64  // In chip cents for BUY; zero if it can't be bought with chips
+	Chips int64  // Cents credited; zero for cosmetics
+	Slot  string // Cosmetic slot; empty for chip bundles
+}
+
+func (item ShopItem) Cosmetic() bool {
+	return item.Slot != ""
+}
+
+var ShopItems = []ShopItem{
+	{ID: "chips_10", Name: "$10 in chips", Cost: 100, Chips: 1000},
+	{ID: "chips_100", Name: "$100 in chips", Cost: 900, Chips: 10000},
+	{ID: "card_back_classic", Name: "Classic card back", Slot: SlotCardBack},
+	{ID: "card_back_red", Name: "Red card back", Cost: 300, Price: 25000, Slot: SlotCardBack},
+	{ID: "card_back_gold", Name: "Gold card back", Cost: 500, Price: 50000, Slot: SlotCardBack},
+	{ID: "table_green", Name: "Green felt table", Slot: SlotTableTheme},
+	{ID: "table_blue", Name: "Blue felt table", Cost: 300, Price: 25000, Slot: SlotTableTheme},
+	{ID: "table_red", Name: "Red felt table", Cost: 300, Price: 25000, Slot: SlotTableTheme},
+	{ID: "table_purple", Name: "Purple felt table", Cost: 500, Price: 50000, Slot: SlotTableTheme},
+	{ID: "title_high_roller", Name: "High Roller title", Cost: 2000, Slot: SlotTitle},
+}
+
+// Cosmetics every player has and wears until they equip something else
+var DefaultCosmetics = map[string]string{
+	SlotCardBack:   "card_back_classic",
+	SlotTableTheme: "table_green",
+}
+
+func FindShopItem(id string) (ShopItem, bool) {
+	for _, item := range ShopItems {
+		if strings.EqualFold(item.ID, id) {
+			return item, true
+		}
+	}
+	return ShopItem{}, false
+}
+
+func isDefaultCosmetic(item ShopItem) bool {
+	return DefaultCosmetics[item.Slot] == item.ID
+}
+
+// Accrues comp points for a settled wager, boosted by a running points event,
+// and returns the user's point balance
+func (as *AuthService) AwardWagerPoints(userID int, wagered int64) (int64, error) {
+	points := as.applyEvent(EventPoints, wagered/CentsPerCompPoint)
+	if points <= 0 {
+		user, err := as.db.GetUserByID(userID)
+		if err != nil {
+			return 0, err
+		}
+		return user.Points, nil
+	}
+	return as.db.AdjustPoints(userID, points, vault.PointsEarned, "")
+}
+
+// Exchanges comp points for a shop item, returning the points left and the
+// chip balance after the redemption
+func (as *AuthService) Redeem(userID int, itemID string) (item ShopItem, points, balance int64, err error) {
+	item, ok := FindShopItem(itemID)
+	if !ok {
+		return ShopItem{}, 0, 0, fmt.Errorf("unknown item %q", itemID)
+	}
+	if isDefaultCosmetic(item) {
+		return ShopItem{}, 0, 0, fmt.Errorf("%s is free, use EQUIP %s", item.Name, item.ID)
+	}
+	if item.Cost == 0 {
+		return ShopItem{}, 0, 0, fmt.Errorf("%s can't be redeemed with points", item.Name)
+	}
+
+	if item.Cosmetic() {
+		if points, err = as.db.RedeemPointsForItem(userID, item.Cost, item.ID); err != nil {
+			return ShopItem{}, 0, 0, err
+		}
+		user, err := as.db.GetUserByID(userID)
+		if err != nil {
+			return ShopItem{}, 0, 0, err
+		}
+		return item, points, user.Balance, nil
+	}
+
+	if points, balance, err = as.db.RedeemPointsForChips(userID, item.Cost, item.Chips, item.ID); err != nil {
+		return ShopItem{}, 0, 0, err
+	}
+	return item, points, balance, nil
+}
+
+// Buys a cosmetic with chips and returns the new balance
+func (as *AuthService) Buy(userID int, itemID string) (ShopItem, int64, error) {
+	item, ok := FindShopItem(itemID)
+	if !ok {
+		return ShopItem{}, 0, fmt.Errorf("unknown item %q", itemID)
+	}
+	if isDefaultCosmetic(item) {
+		return ShopItem{}, 0, fmt.Errorf("%s is free, use EQUIP %s", item.Name, item.ID)
+	}
+	if item.Price == 0 {
+		return ShopItem{}, 0, fmt.Errorf("%s can't be bought with chips", item.Name)
+	}
+
+	balance, err := as.db.BuyItem(userID, item.Price, item.ID)
+	if err != nil {
+		return ShopItem{}, 0, err
+	}
+	return item, balance, nil
+}
+
+// Lists the cosmetic items a user owns
+func (as *AuthService) ListItems(userID int) ([]string, error) {
+	return as.db.ListUserItems(userID)
+}
+
+// Equips an owned cosmetic (or a slot's default) in its slot
+func (as *AuthService) Equip(userID int, itemID string) (ShopItem, error) {
+	item, ok := FindShopItem(itemID)
+	if !ok {
+		return ShopItem{}, fmt.Errorf("unknown item %q", itemID)
+	}
+	if !item.Cosmetic() {
+		return ShopItem{}, fmt.Errorf("%s can't be equipped", item.Name)
+	}
+
+	if !isDefaultCosmetic(item) {
+		owned, err := as.db.HasUserItem(userID, item.ID)
+		if err != nil {
+			return ShopItem{}, err
+		}
+		if !owned {
+			return ShopItem{}, fmt.Errorf("you don't own %s", item.Name)
+		}
+	}
+
+	if err := as.db.EquipItem(userID, item.Slot, item.ID); err != nil {
+		return ShopItem{}, err
+	}
+	return item, nil
+}
+
+// Profile is what other parts of the system (and clients) need to present a player
+type Profile struct {
+	Username string
+	Progress *Progress
+	Equipped map[string]string // Slot to item ID, defaults filled in
+}
+
+func (as *AuthService) GetProfile(userID int) (*Profile, error) {
+	user, err := as.db.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress, err := as.GetProgress(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	equipped, err := as.db.GetEquippedItems(userID)
+	if err != nil {
+		return nil, err
+	}
+	for slot, item := range DefaultCosmetics {
+		if equipped[slot] == "" {
+			equipped[slot] = item
+		}
+	}
+
+	return &Profile{Username: user.Username, Progress: progress, Equipped: equipped}, nil
+}
@@ -0,0 +1,101 @@
+package security
+
+import "testing"
+
+func TestRedeem(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("shopuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, _, _, err := auth.Redeem(user.ID, "chips_10"); err == nil {
+		t.Error("Redeem() should fail without enough points")
+	}
+
+	// $1000 wagered earns 100 points
+	points, err := auth.AwardWagerPoints(user.ID, 100000)
+	if err != nil || points != 100 {
+		t.Fatalf("AwardWagerPoints() = %d, %v, want 100", points, err)
+	}
+	if points, err := auth.AwardWagerPoints(user.ID, 500); err != nil || points != 100 {
+		t.Errorf("AwardWagerPoints() for a small bet = %d, %v, want 100 unchanged", points, err)
+	}
+
+	item, points, balance, err := auth.Redeem(user.ID, "CHIPS_10")
+	if err != nil {
+		t.Fatalf("Redeem() error = %v", err)
+	}
+	if item.ID != "chips_10" || points != 0 || balance != user.Balance+1000 {
+		t.Errorf("Redeem() = %s, %d points, %d, want chips_10, 0 points, %d", item.ID, points, balance, user.Balance+1000)
+	}
+
+	if _, _, _, err := auth.Redeem(user.ID, "nonexistent"); err == nil {
+		t.Error("Redeem() should reject unknown items")
+	}
+
+	if _, err := auth.AwardWagerPoints(user.ID, 500000); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, points, _, err = auth.Redeem(user.ID, "card_back_gold"); err != nil || points != 0 {
+		t.Errorf("Redeem(cosmetic) = %d, %v, want 0 points left", points, err)
+	}
+
+	items, err := auth.ListItems(user.ID)
+	if err != nil || len(items) != 1 || items[0] != "card_back_gold" {
+		t.Errorf("ListItems() = %v, %v, want [card_back_gold]", items, err)
+	}
+}
+
+func TestBuyAndEquip(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("cosmeticuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	profile, err := auth.GetProfile(user.ID)
+	if err != nil {
+		t.Fatalf("GetProfile() error = %v", err)
+	}
+	if profile.Equipped[SlotCardBack] != "card_back_classic" || profile.Equipped[SlotTableTheme] != "table_green" {
+		t.Errorf("GetProfile() equipped = %v, want the defaults", profile.Equipped)
+	}
+
+	if _, err := auth.Equip(user.ID, "table_blue"); err == nil {
+		t.Error("Equip() should refuse an item the user doesn't own")
+	}
+	if _, _, err := auth.Buy(user.ID, "title_high_roller"); err == nil {
+		t.Error("Buy() should refuse items that are only available for points")
+	}
+	if _, _, err := auth.Buy(user.ID, "table_green"); err == nil {
+		t.Error("Buy() should refuse default cosmetics")
+	}
+
+	item, balance, err := auth.Buy(user.ID, "table_blue")
+	if err != nil || item.ID != "table_blue" || balance != user.Balance-item.Price {
+		t.Errorf("Buy() = %s, %d, %v, want table_blue for $%d", item.ID, balance, err, item.Price/100)
+	}
+	if _, err := auth.Equip(user.ID, "table_blue"); err != nil {
+		t.Errorf("Equip() error = %v", err)
+	}
+	if _, err := auth.Equip(user.ID, "chips_10"); err == nil {
+		t.Error("Equip() should refuse chip bundles")
+	}
+
+	profile, err = auth.GetProfile(user.ID)
+	if err != nil {
+		t.Fatalf("GetProfile() error = %v", err)
+	}
+	if profile.Equipped[SlotTableTheme] != "table_blue" || profile.Equipped[SlotCardBack] != "card_back_classic" {
+		t.Errorf("GetProfile() equipped = %v, want table_blue and the default card back", profile.Equipped)
+	}
+
+	if _, err := auth.Equip(user.ID, "table_green"); err != nil {
+		t.Errorf("Equip() of a default cosmetic error = %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package security
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Parses a comma-separated list of win streak lengths such as "3,5,10"
+func ParseStreakMilestones(s string) ([]int64, error) {
+	var milestones []int64
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || n < 2 {
+			return nil, fmt.Errorf("invalid streak milestone %q", part)
+		}
+		milestones = append(milestones, n)
+	}
+	slices.Sort(milestones)
+	return slices.Compact(milestones), nil
+}
+
+// Credits the streak bonus when a win streak reaches one of the configured
+// milestones. The amount is zero when the streak isn't a milestone.
+func (as *AuthService) AwardStreakBonus(userID int, streak int64) (amount, balance int64, err error) {
+	if as.config.StreakBonus <= 0 || !slices.Contains(as.config.StreakMilestones, streak) {
+		return 0, 0, nil
+	}
+
+	note := fmt.Sprintf("win streak %d", streak)
+	if balance, err = as.db.AdjustBalanceWithMetadata(userID, as.config.StreakBonus, vault.TxBonus, note); err != nil {
+		return 0, 0, err
+	}
+
+	return as.config.StreakBonus, balance, nil
+}
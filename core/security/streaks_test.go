@@ -0,0 +1,49 @@
+package security
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseStreakMilestones(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []int64
+		wantErr bool
+	}{
+		{"3,5,10", []int64{3, 5, 10}, false},
+		{" 10, 3 ,3", []int64{3, 10}, false},
+		{"", nil, false},
+		{"1", nil, true},
+		{"3,x", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseStreakMilestones(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseStreakMilestones(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !slices.Equal(got, tt.want) {
+			t.Errorf("ParseStreakMilestones(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestAwardStreakBonus(t *testing.T) {
+	auth := setupConfiguredAuthService(t, AuthConfig{StreakBonus: 500, StreakMilestones: []int64{3, 5}})
+
+	user, err := auth.RegisterUser("streakuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if amount, _, err := auth.AwardStreakBonus(user.ID, 4); err != nil || amount != 0 {
+		t.Errorf("AwardStreakBonus(4) = %d, %v, want nothing", amount, err)
+	}
+
+	amount, balance, err := auth.AwardStreakBonus(user.ID, 5)
+	if err != nil || amount != 500 || balance != 1000500 {
+		t.Errorf("AwardStreakBonus(5) = %d, %d, %v, want 500 leaving 1000500", amount, balance, err)
+	}
+}
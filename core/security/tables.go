@@ -0,0 +1,33 @@
+package security
+
+import (
+	"fmt"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Most past table hands REVIEW shows at once
+const MaxReviewHands = 20
+
+// Keeps a finished multiplayer round for REVIEW
+func (as *AuthService) RecordTableHand(hand *vault.TableHand) error {
+	return as.db.SaveTableHand(hand)
+}
+
+// Returns the last n table hands the user was dealt into, newest first
+func (as *AuthService) ReviewHands(userID, n int) ([]*vault.TableHand, error) {
+	if n < 1 || n > MaxReviewHands {
+		return nil, fmt.Errorf("you can review between 1 and %d hands", MaxReviewHands)
+	}
+	return as.db.ListTableHands(userID, n)
+}
+
+// Audits an admin freezing a multiplayer table
+func (as *AuthService) LogTableFreeze(actor, tableID, reason, ip string) {
+	as.db.RecordAuditEvent(0, vault.AuditTableFreeze, ip, fmt.Sprintf("%s frozen by %s: %s", tableID, actor, reason))
+}
+
+// Audits an admin reopening a frozen table
+func (as *AuthService) LogTableThaw(actor, tableID, ip string) {
+	as.db.RecordAuditEvent(0, vault.AuditTableThaw, ip, fmt.Sprintf("%s reopened by %s", tableID, actor))
+}
@@ -0,0 +1,51 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func TestReviewHands(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	user, err := auth.RegisterUser("reviewer", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		hand := &vault.TableHand{TableID: "low-1", Dealer: "[K♠] [7♥]", DealerValue: 17, Seats: []*vault.TableHandSeat{
+			{UserID: user.ID, Username: "reviewer", Cards: "[10♠] [9♥]", Value: 19, Bet: 500, Payout: 1000, Result: "Win"},
+		}}
+		if err := auth.RecordTableHand(hand); err != nil {
+			t.Fatalf("RecordTableHand() error = %v", err)
+		}
+	}
+
+	if hands, err := auth.ReviewHands(user.ID, 2); err != nil || len(hands) != 2 {
+		t.Errorf("ReviewHands(2) = %d hands, %v, want 2", len(hands), err)
+	}
+	for _, n := range []int{0, MaxReviewHands + 1} {
+		if _, err := auth.ReviewHands(user.ID, n); err == nil {
+			t.Errorf("ReviewHands(%d) should fail", n)
+		}
+	}
+}
+
+func TestLogTableFreeze(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	since := time.Now().Add(-time.Minute)
+	auth.LogTableFreeze("admin", "low-1", "collusion report", "10.0.0.1")
+	auth.LogTableThaw("admin", "low-1", "10.0.0.1")
+
+	for _, eventType := range []string{vault.AuditTableFreeze, vault.AuditTableThaw} {
+		if n, err := auth.db.CountAuditEventsByIP(eventType, "10.0.0.1", since); err != nil || n != 1 {
+			t.Errorf("CountAuditEventsByIP(%s) = %d, %v, want 1", eventType, n, err)
+		}
+	}
+}
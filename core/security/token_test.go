@@ -0,0 +1,246 @@
+package security
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+func setupTokenAuthService(t testing.TB) (*AuthService, *vault.DB) {
+	db, err := vault.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewAuthServiceWithConfig(db, AuthConfig{TokenSecret: []byte("test-secret")}), db
+}
+
+func TestTokenSignerRoundTrip(t *testing.T) {
+	signer := NewTokenSigner([]byte("secret"))
+
+	token, claims, err := signer.Issue(42, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	if !IsSignedToken(token) {
+		t.Errorf("IsSignedToken(%s) = false, want true", token)
+	}
+
+	verified, err := signer.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	if verified.UserID != 42 || verified.ID != claims.ID || !verified.ExpiresAt.Equal(claims.ExpiresAt) {
+		t.Errorf("Verify() = %+v, want %+v", verified, claims)
+	}
+}
+
+func TestTokenSignerRejectsTampering(t *testing.T) {
+	signer := NewTokenSigner([]byte("secret"))
+
+	token, _, err := signer.Issue(42, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	parts := strings.Split(token, ".")
+	parts[2] = "43" // Change the user ID
+	if _, err := signer.Verify(strings.Join(parts, ".")); err == nil {
+		t.Error("Verify() should reject a token with a modified payload")
+	}
+
+	other := NewTokenSigner([]byte("other-secret"))
+	if _, err := other.Verify(token); err == nil {
+		t.Error("Verify() should reject a token signed with another secret")
+	}
+
+	expired, _, err := signer.Issue(42, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if _, err := signer.Verify(expired); err == nil {
+		t.Error("Verify() should reject an expired token")
+	}
+
+	for _, bad := range []string{"", "st1", "st1.a.b.c", "not-a-token"} {
+		if _, err := signer.Verify(bad); err == nil {
+			t.Errorf("Verify(%q) should fail", bad)
+		}
+	}
+}
+
+func TestTokenLoginAndLogout(t *testing.T) {
+	auth, db := setupTokenAuthService(t)
+
+	if _, err := auth.RegisterUser("tokenuser", "testpassword456"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	token, user, err := auth.LoginUser("tokenuser", "testpassword456")
+	if err != nil {
+		t.Fatalf("LoginUser() error = %v", err)
+	}
+
+	if !IsSignedToken(token) {
+		t.Fatalf("LoginUser() returned %s, want a signed token", token)
+	}
+
+	validated, err := auth.ValidateSession(token)
+	if err != nil {
+		t.Fatalf("ValidateSession() error = %v", err)
+	}
+	if validated.ID != user.ID {
+		t.Errorf("ValidateSession() user ID = %d, want %d", validated.ID, user.ID)
+	}
+
+	if err := auth.LogoutUser(token); err != nil {
+		t.Fatalf("LogoutUser() error = %v", err)
+	}
+
+	if _, err := auth.ValidateSession(token); err == nil {
+		t.Error("ValidateSession() should fail after logout")
+	}
+
+	// A fresh service with the same secret must still honor the revocation
+	restarted := NewAuthServiceWithConfig(db, AuthConfig{TokenSecret: []byte("test-secret")})
+	if _, err := restarted.ValidateSession(token); err == nil {
+		t.Error("ValidateSession() should fail for a token revoked before restart")
+	}
+}
+
+func BenchmarkSessionUserIDDatabase(b *testing.B) {
+	db, err := vault.NewDB(filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	auth := NewAuthService(db)
+	if _, err := auth.RegisterUser("benchuser", "testpassword456"); err != nil {
+		b.Fatalf("Setup failed: %v", err)
+	}
+	sessionID, _, err := auth.LoginUser("benchuser", "testpassword456")
+	if err != nil {
+		b.Fatalf("Setup failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := auth.SessionUserID(sessionID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSessionUserIDSignedToken(b *testing.B) {
+	auth, _ := setupTokenAuthService(b)
+	if _, err := auth.RegisterUser("benchuser", "testpassword456"); err != nil {
+		b.Fatalf("Setup failed: %v", err)
+	}
+	token, _, err := auth.LoginUser("benchuser", "testpassword456")
+	if err != nil {
+		b.Fatalf("Setup failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := auth.SessionUserID(token); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestTokenValidationCache(t *testing.T) {
+	auth, db := setupTokenAuthService(t)
+
+	user, err := auth.RegisterUser("cacheduser", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	token, _, err := auth.LoginUser("cacheduser", "testpassword456")
+	if err != nil {
+		t.Fatalf("LoginUser() error = %v", err)
+	}
+	if _, err := auth.ValidateSession(token); err != nil {
+		t.Fatalf("ValidateSession() error = %v", err)
+	}
+
+	// A ban made behind the service's back shows once the cached copy is stale
+	if err := db.BanUser(user.ID, time.Time{}, "elsewhere", "admin"); err != nil {
+		t.Fatalf("BanUser() error = %v", err)
+	}
+	if _, err := auth.ValidateSession(token); err != nil {
+		t.Errorf("ValidateSession() within the cache lifetime error = %v", err)
+	}
+	auth.sessionUsersMu.Lock()
+	cached := auth.sessionUsers[user.ID]
+	cached.read = cached.read.Add(-sessionCacheTTL)
+	auth.sessionUsers[user.ID] = cached
+	auth.sessionUsersMu.Unlock()
+	if _, err := auth.ValidateSession(token); err == nil {
+		t.Error("ValidateSession() should fail once the cached account is stale and banned")
+	}
+
+	// Bans through the service take effect at once
+	if err := auth.UnbanUser("admin", user.ID, ""); err != nil {
+		t.Fatalf("UnbanUser() error = %v", err)
+	}
+	if _, err := auth.ValidateSession(token); err != nil {
+		t.Errorf("ValidateSession() after unban error = %v", err)
+	}
+	if _, err := auth.BanUser("admin", user.ID, time.Hour, "", ""); err != nil {
+		t.Fatalf("BanUser() error = %v", err)
+	}
+	if _, err := auth.ValidateSession(token); err == nil {
+		t.Error("ValidateSession() should fail straight after a ban")
+	}
+}
+
+// ValidateSession is what each command that re-checks its login runs
+func BenchmarkValidateSessionDatabase(b *testing.B) {
+	db, err := vault.NewDB(filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	auth := NewAuthService(db)
+	if _, err := auth.RegisterUser("benchuser", "testpassword456"); err != nil {
+		b.Fatalf("Setup failed: %v", err)
+	}
+	sessionID, _, err := auth.LoginUser("benchuser", "testpassword456")
+	if err != nil {
+		b.Fatalf("Setup failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := auth.ValidateSession(sessionID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidateSessionSignedToken(b *testing.B) {
+	auth, _ := setupTokenAuthService(b)
+	if _, err := auth.RegisterUser("benchuser", "testpassword456"); err != nil {
+		b.Fatalf("Setup failed: %v", err)
+	}
+	token, _, err := auth.LoginUser("benchuser", "testpassword456")
+	if err != nil {
+		b.Fatalf("Setup failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := auth.ValidateSession(token); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
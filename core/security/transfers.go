@@ -0,0 +1,80 @@
+package security
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alessandrosisniegas/casino/core/vault"
+)
+
+// Transfers are on unless an admin has switched them off
+func (as *AuthService) TransfersEnabled() (bool, error) {
+	return as.FeatureEnabled(FeatureTransfers)
+}
+
+func (as *AuthService) SetTransfersEnabled(actor string, enabled bool) error {
+	return as.SetFeature(actor, FeatureTransfers, enabled)
+}
+
+// Validates a transfer without moving any money, returning the recipient
+func (as *AuthService) CheckTransfer(fromID int, toUsername string, amount int64) (*vault.User, error) {
+	enabled, err := as.TransfersEnabled()
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, fmt.Errorf("transfers are currently disabled")
+	}
+
+	if amount <= 0 {
+		return nil, fmt.Errorf("transfer amount must be positive")
+	}
+
+	to, err := as.db.GetUserByUsername(toUsername)
+	if err != nil {
+		return nil, err
+	}
+	if to.ID == fromID {
+		return nil, fmt.Errorf("cannot transfer to yourself")
+	}
+
+	from, err := as.db.GetUserByID(fromID)
+	if err != nil {
+		return nil, err
+	}
+	// Guest chips stay with guests so throwaway accounts can't feed real ones
+	if from.IsGuest || to.IsGuest {
+		return nil, fmt.Errorf("guest accounts can't send or receive transfers")
+	}
+	if from.Balance < amount {
+		return nil, fmt.Errorf("insufficient balance")
+	}
+
+	if limit := as.config.TransferDailyLimit; limit > 0 {
+		sent, err := as.db.SumTransactions(fromID, time.Now().Add(-24*time.Hour), vault.TxTransferOut)
+		if err != nil {
+			return nil, err
+		}
+		if -sent+amount > limit {
+			return nil, fmt.Errorf("transfer would exceed the daily limit of $%.2f ($%.2f remaining)",
+				float64(limit)/100, float64(max(limit+sent, 0))/100)
+		}
+	}
+
+	return to, nil
+}
+
+// Sends chips to another player, re-running CheckTransfer first. Returns the sender's new balance.
+func (as *AuthService) Transfer(fromID int, toUsername string, amount int64) (int64, *vault.User, error) {
+	to, err := as.CheckTransfer(fromID, toUsername, amount)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	balance, err := as.db.Transfer(fromID, to.ID, amount, as.config.TransferDailyLimit, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return balance, to, nil
+}
@@ -0,0 +1,65 @@
+package security
+
+import "testing"
+
+func TestTransfer(t *testing.T) {
+	auth := setupConfiguredAuthService(t, AuthConfig{TransferDailyLimit: 50000})
+
+	alice, err := auth.RegisterUser("alice", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := auth.RegisterUser("bob", "testpassword456"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := auth.CheckTransfer(alice.ID, "nobody", 100); err == nil {
+		t.Error("CheckTransfer() should reject an unknown recipient")
+	}
+	if _, err := auth.CheckTransfer(alice.ID, "alice", 100); err == nil {
+		t.Error("CheckTransfer() should reject sending to yourself")
+	}
+
+	balance, to, err := auth.Transfer(alice.ID, "bob", 20000)
+	if err != nil {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	if balance != 980000 || to.Username != "bob" {
+		t.Errorf("Transfer() = %d, %s, want 980000 to bob", balance, to.Username)
+	}
+
+	if _, err := auth.CheckTransfer(alice.ID, "bob", 40000); err == nil {
+		t.Error("CheckTransfer() should enforce the daily limit")
+	}
+}
+
+func TestTransfersToggle(t *testing.T) {
+	auth, cleanup := setupTestAuthService(t)
+	defer cleanup()
+
+	alice, err := auth.RegisterUser("alice", "testpassword456")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := auth.RegisterUser("bob", "testpassword456"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if enabled, err := auth.TransfersEnabled(); err != nil || !enabled {
+		t.Fatalf("TransfersEnabled() = %v, %v, want enabled by default", enabled, err)
+	}
+
+	if err := auth.SetTransfersEnabled("console", false); err != nil {
+		t.Fatalf("SetTransfersEnabled() error = %v", err)
+	}
+	if _, _, err := auth.Transfer(alice.ID, "bob", 100); err == nil {
+		t.Error("Transfer() should fail while transfers are disabled")
+	}
+
+	if err := auth.SetTransfersEnabled("console", true); err != nil {
+		t.Fatalf("SetTransfersEnabled() error = %v", err)
+	}
+	if _, _, err := auth.Transfer(alice.ID, "bob", 100); err != nil {
+		t.Errorf("Transfer() after re-enabling error = %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package vault
+
+import (
+	"fmt"
+	"time"
+)
+
+// Records an achievement; unlocked is false if the user already had it
+func (db *DB) UnlockAchievement(userID int, achievement string) (bool, error) {
+	result, err := db.conn.Exec(`INSERT OR IGNORE INTO user_achievements (user_id, achievement) VALUES (?, ?)`,
+		userID, achievement)
+	if err != nil {
+		return false, fmt.Errorf("failed to unlock achievement: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to unlock achievement: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// Returns the user's achievements keyed by ID with their unlock times
+func (db *DB) ListAchievements(userID int) (map[string]time.Time, error) {
+	rows, err := db.conn.Query(`SELECT achievement, unlocked_at FROM user_achievements WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list achievements: %w", err)
+	}
+	defer rows.Close()
+
+	unlocked := make(map[string]time.Time)
+	for rows.Next() {
+		var id string
+		var at time.Time
+		if err := rows.Scan(&id, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan achievement: %w", err)
+		}
+		unlocked[id] = at
+	}
+
+	return unlocked, rows.Err()
+}
@@ -0,0 +1,30 @@
+package vault
+
+import "testing"
+
+func TestUnlockAchievement(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("achiever", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	unlocked, err := db.UnlockAchievement(user.ID, "first_win")
+	if err != nil || !unlocked {
+		t.Fatalf("UnlockAchievement() = %v, %v, want a new unlock", unlocked, err)
+	}
+
+	if unlocked, err := db.UnlockAchievement(user.ID, "first_win"); err != nil || unlocked {
+		t.Errorf("Second UnlockAchievement() = %v, %v, want already unlocked", unlocked, err)
+	}
+
+	achievements, err := db.ListAchievements(user.ID)
+	if err != nil {
+		t.Fatalf("ListAchievements() error = %v", err)
+	}
+	if _, ok := achievements["first_win"]; !ok || len(achievements) != 1 {
+		t.Errorf("ListAchievements() = %v, want only first_win", achievements)
+	}
+}
@@ -0,0 +1,185 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ActiveGame is a solo hand in progress, saved along with the bet it holds so
+// that it survives a crash or restart. The bet has already been taken from the
+// player's balance and is only paid out or refunded when the hand is closed.
+type ActiveGame struct {
+	UserID    int       `json:"user_id"`
+	Game      string    `json:"game"`
+	Bet       int64     `json:"bet"`
+	State     string    `json:"state"` // The hand as serialized by the game engine
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Saves a new hand and takes its bet from the player's balance in one
+// transaction. A player has at most one hand in progress. Returns the balance.
+func (db *DB) StartActiveGame(userID int, game string, bet int64, state string) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO active_games (user_id, game, bet, state) VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id) DO NOTHING`, userID, game, bet, state)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save game: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return 0, fmt.Errorf("failed to save game: %w", err)
+	} else if n == 0 {
+		return 0, fmt.Errorf("you already have a hand in progress")
+	}
+
+	balance, err := adjustGameBalanceTx(tx, userID, -bet, TxBet, game, "")
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit game: %w", err)
+	}
+	return balance, nil
+}
+
+// Saves the new state of a hand in progress, taking stake (e.g. for a double
+// down) from the player's balance and adding it to the bet in the same
+// transaction. Returns the balance.
+func (db *DB) UpdateActiveGame(userID int, stake int64, state string) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var game string
+	if err := tx.QueryRow(`UPDATE active_games SET bet = bet + ?, state = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = ? RETURNING game`, stake, state, userID).Scan(&game); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("no hand in progress")
+		}
+		return 0, fmt.Errorf("failed to save game: %w", err)
+	}
+
+	var balance int64
+	if stake != 0 {
+		if balance, err = adjustGameBalanceTx(tx, userID, -stake, TxBet, game, ""); err != nil {
+			return 0, err
+		}
+	} else if err := tx.QueryRow(`SELECT balance FROM users WHERE id = ?`, userID).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("failed to read user balance: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit game: %w", err)
+	}
+	return balance, nil
+}
+
+// Returns the user's hand in progress, or nil when they have none
+func (db *DB) GetActiveGame(userID int) (*ActiveGame, error) {
+	g, err := scanActiveGame(db.conn.QueryRow(`SELECT `+activeGameColumns+` FROM active_games WHERE user_id = ?`, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game: %w", err)
+	}
+	return g, nil
+}
+
+// Lists the hands last played before the cutoff, oldest first
+func (db *DB) ListActiveGamesBefore(cutoff time.Time) ([]*ActiveGame, error) {
+	rows, err := db.conn.Query(`SELECT `+activeGameColumns+` FROM active_games WHERE updated_at < ?
+		ORDER BY updated_at`, sqlTime(cutoff))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list games: %w", err)
+	}
+	defer rows.Close()
+
+	var games []*ActiveGame
+	for rows.Next() {
+		g, err := scanActiveGame(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan game: %w", err)
+		}
+		games = append(games, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list games: %w", err)
+	}
+	return games, nil
+}
+
+// Closes a finished hand: removes it and settles the round as SettleRound
+// does, in one transaction, so a hand is never paid twice. Returns the balance.
+func (db *DB) SettleActiveGame(userID int, wagered, payout int64, result, hands string, stats *UserStats) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var game string
+	if err := tx.QueryRow(`DELETE FROM active_games WHERE user_id = ? RETURNING game`, userID).Scan(&game); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("no hand in progress")
+		}
+		return 0, fmt.Errorf("failed to close game: %w", err)
+	}
+
+	balance, err := settleRoundTx(tx, userID, game, wagered, payout, result, hands, stats)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit settlement: %w", err)
+	}
+	return balance, nil
+}
+
+// Closes a hand without playing it out, returning its whole bet to the
+// player. Returns the balance and the amount refunded.
+func (db *DB) RefundActiveGame(userID int) (int64, int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var bet int64
+	var game string
+	if err := tx.QueryRow(`DELETE FROM active_games WHERE user_id = ? RETURNING bet, game`, userID).Scan(&bet, &game); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, 0, fmt.Errorf("no hand in progress")
+		}
+		return 0, 0, fmt.Errorf("failed to close game: %w", err)
+	}
+
+	balance, err := adjustGameBalanceTx(tx, userID, bet, TxRefund, game, "abandoned hand")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit refund: %w", err)
+	}
+	return balance, bet, nil
+}
+
+const activeGameColumns = `user_id, game, bet, state, updated_at`
+
+func scanActiveGame(row interface{ Scan(...interface{}) error }) (*ActiveGame, error) {
+	var g ActiveGame
+	if err := row.Scan(&g.UserID, &g.Game, &g.Bet, &g.State, &g.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
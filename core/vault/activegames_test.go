@@ -0,0 +1,146 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActiveGames(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("gameuser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if g, err := db.GetActiveGame(user.ID); err != nil || g != nil {
+		t.Fatalf("GetActiveGame() = %v, %v, want nil", g, err)
+	}
+
+	balance, err := db.StartActiveGame(user.ID, "blackjack", 1000, "dealt")
+	if err != nil {
+		t.Fatalf("StartActiveGame() error = %v", err)
+	}
+	if balance != 999000 {
+		t.Errorf("StartActiveGame() balance = %d, want 999000", balance)
+	}
+	if _, err := db.StartActiveGame(user.ID, "blackjack", 1000, "again"); err == nil {
+		t.Error("StartActiveGame() should refuse a second hand")
+	}
+
+	// Doubling takes the stake and adds it to the bet
+	balance, err = db.UpdateActiveGame(user.ID, 1000, "doubled")
+	if err != nil {
+		t.Fatalf("UpdateActiveGame() error = %v", err)
+	}
+	if balance != 998000 {
+		t.Errorf("UpdateActiveGame() balance = %d, want 998000", balance)
+	}
+
+	g, err := db.GetActiveGame(user.ID)
+	if err != nil || g == nil {
+		t.Fatalf("GetActiveGame() = %v, %v", g, err)
+	}
+	if g.Game != "blackjack" || g.Bet != 2000 || g.State != "doubled" {
+		t.Errorf("GetActiveGame() = %+v", g)
+	}
+
+	games, err := db.ListActiveGamesBefore(time.Now().Add(time.Minute))
+	if err != nil || len(games) != 1 {
+		t.Fatalf("ListActiveGamesBefore() = %v, %v, want one game", games, err)
+	}
+	if games, _ := db.ListActiveGamesBefore(time.Now().Add(-time.Hour)); len(games) != 0 {
+		t.Errorf("ListActiveGamesBefore() should skip recent hands, got %d", len(games))
+	}
+
+	balance, err = db.SettleActiveGame(user.ID, 2000, 4000, "", "", nil)
+	if err != nil {
+		t.Fatalf("SettleActiveGame() error = %v", err)
+	}
+	if balance != 1002000 {
+		t.Errorf("SettleActiveGame() balance = %d, want 1002000", balance)
+	}
+	if _, err := db.SettleActiveGame(user.ID, 2000, 4000, "", "", nil); err == nil {
+		t.Error("SettleActiveGame() should never pay a hand twice")
+	}
+	if _, err := db.UpdateActiveGame(user.ID, 0, "gone"); err == nil {
+		t.Error("UpdateActiveGame() should fail once the hand is closed")
+	}
+
+	stats, err := db.ListHouseStats()
+	if err != nil || len(stats) != 1 || stats[0].Wagered != 2000 || stats[0].Paid != 4000 {
+		t.Errorf("ListHouseStats() = %v, %v, want the settled hand", stats, err)
+	}
+}
+
+func TestRefundActiveGame(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("refunduser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, _, err := db.RefundActiveGame(user.ID); err == nil {
+		t.Error("RefundActiveGame() should fail without a hand")
+	}
+	if _, err := db.StartActiveGame(user.ID, "blackjack", 2500, "dealt"); err != nil {
+		t.Fatalf("StartActiveGame() error = %v", err)
+	}
+
+	balance, refunded, err := db.RefundActiveGame(user.ID)
+	if err != nil {
+		t.Fatalf("RefundActiveGame() error = %v", err)
+	}
+	if balance != 1000000 || refunded != 2500 {
+		t.Errorf("RefundActiveGame() = %d, %d, want 1000000, 2500", balance, refunded)
+	}
+	if g, _ := db.GetActiveGame(user.ID); g != nil {
+		t.Error("RefundActiveGame() should remove the hand")
+	}
+
+	if _, err := db.StartActiveGame(user.ID, "blackjack", 2000000, "dealt"); err == nil {
+		t.Error("StartActiveGame() should refuse a bet above the balance")
+	}
+	if g, _ := db.GetActiveGame(user.ID); g != nil {
+		t.Error("a refused bet should not leave a hand behind")
+	}
+}
+
+func TestSettleActiveGameSavesStats(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("statsuser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := db.StartActiveGame(user.ID, "blackjack", 1000, "dealt"); err != nil {
+		t.Fatalf("StartActiveGame() error = %v", err)
+	}
+
+	stats, err := db.GetUserStats(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserStats() error = %v", err)
+	}
+	stats.GamesPlayed++
+	stats.GamesWon++
+	stats.TotalBet += 1000
+	if _, err := db.SettleActiveGame(user.ID, 1000, 2000, "Win", "", stats); err != nil {
+		t.Fatalf("SettleActiveGame() error = %v", err)
+	}
+	if saved, _ := db.GetUserStats(user.ID); saved.GamesPlayed != 1 || saved.GamesWon != 1 || saved.TotalBet != 1000 {
+		t.Errorf("GetUserStats() = %+v, want the stats saved with the round", saved)
+	}
+
+	// A hand that can't be settled leaves the stats alone
+	stats.GamesPlayed++
+	if _, err := db.SettleActiveGame(user.ID, 1000, 2000, "Win", "", stats); err == nil {
+		t.Fatal("SettleActiveGame() should fail once the hand is closed")
+	}
+	if saved, _ := db.GetUserStats(user.ID); saved.GamesPlayed != 1 {
+		t.Errorf("GamesPlayed = %d after a failed settlement, want 1", saved.GamesPlayed)
+	}
+}
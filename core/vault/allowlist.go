@@ -0,0 +1,57 @@
+package vault
+
+import "fmt"
+
+func (db *DB) AddAllowedIP(userID int, cidr string) error {
+	query := `INSERT OR IGNORE INTO ip_allowlist (user_id, cidr) VALUES (?, ?)`
+	if _, err := db.conn.Exec(query, userID, cidr); err != nil {
+		return fmt.Errorf("failed to add allowed ip: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) RemoveAllowedIP(userID int, cidr string) error {
+	query := `DELETE FROM ip_allowlist WHERE user_id = ? AND cidr = ?`
+	result, err := db.conn.Exec(query, userID, cidr)
+	if err != nil {
+		return fmt.Errorf("failed to remove allowed ip: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to remove allowed ip: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s is not in your allowlist", cidr)
+	}
+
+	return nil
+}
+
+func (db *DB) ListAllowedIPs(userID int) ([]string, error) {
+	query := `SELECT cidr FROM ip_allowlist WHERE user_id = ? ORDER BY created_at, cidr`
+	rows, err := db.conn.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list allowed ips: %w", err)
+	}
+	defer rows.Close()
+
+	var cidrs []string
+	for rows.Next() {
+		var cidr string
+		if err := rows.Scan(&cidr); err != nil {
+			return nil, fmt.Errorf("failed to scan allowed ip: %w", err)
+		}
+		cidrs = append(cidrs, cidr)
+	}
+
+	return cidrs, rows.Err()
+}
+
+func (db *DB) ClearAllowedIPs(userID int) error {
+	query := `DELETE FROM ip_allowlist WHERE user_id = ?`
+	if _, err := db.conn.Exec(query, userID); err != nil {
+		return fmt.Errorf("failed to clear allowed ips: %w", err)
+	}
+	return nil
+}
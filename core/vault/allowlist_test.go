@@ -0,0 +1,45 @@
+package vault
+
+import "testing"
+
+func TestAllowedIPs(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("allowuser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	for _, cidr := range []string{"10.0.0.0/8", "192.168.1.5/32", "10.0.0.0/8"} {
+		if err := db.AddAllowedIP(user.ID, cidr); err != nil {
+			t.Fatalf("AddAllowedIP(%s) error = %v", cidr, err)
+		}
+	}
+
+	cidrs, err := db.ListAllowedIPs(user.ID)
+	if err != nil {
+		t.Fatalf("ListAllowedIPs() error = %v", err)
+	}
+	if len(cidrs) != 2 {
+		t.Errorf("ListAllowedIPs() = %v, want 2 unique entries", cidrs)
+	}
+
+	if err := db.RemoveAllowedIP(user.ID, "10.0.0.0/8"); err != nil {
+		t.Errorf("RemoveAllowedIP() error = %v", err)
+	}
+	if err := db.RemoveAllowedIP(user.ID, "10.0.0.0/8"); err == nil {
+		t.Error("RemoveAllowedIP() should fail for a missing entry")
+	}
+
+	if err := db.ClearAllowedIPs(user.ID); err != nil {
+		t.Fatalf("ClearAllowedIPs() error = %v", err)
+	}
+	cidrs, err = db.ListAllowedIPs(user.ID)
+	if err != nil {
+		t.Fatalf("ListAllowedIPs() error = %v", err)
+	}
+	if len(cidrs) != 0 {
+		t.Errorf("ListAllowedIPs() after clear = %v, want empty", cidrs)
+	}
+}
@@ -0,0 +1,94 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type APIKey struct {
+	ID         string       `json:"id"`
+	UserID     int          `json:"user_id"`
+	Name       string       `json:"name"`
+	KeyHash    string       `json:"-"`
+	Scope      string       `json:"scope"`
+	CreatedAt  time.Time    `json:"created_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+}
+
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt.Valid
+}
+
+func (db *DB) CreateAPIKey(id string, userID int, name, keyHash, scope string) (*APIKey, error) {
+	query := `INSERT INTO api_keys (id, user_id, name, key_hash, scope) VALUES (?, ?, ?, ?, ?)`
+	if _, err := db.conn.Exec(query, id, userID, name, keyHash, scope); err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+	return db.GetAPIKey(id)
+}
+
+func (db *DB) GetAPIKey(id string) (*APIKey, error) {
+	query := `SELECT id, user_id, name, key_hash, scope, created_at, last_used_at, revoked_at FROM api_keys WHERE id = ?`
+	row := db.conn.QueryRow(query, id)
+
+	var key APIKey
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.KeyHash, &key.Scope, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("api key not found")
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return &key, nil
+}
+
+func (db *DB) ListAPIKeys(userID int) ([]*APIKey, error) {
+	query := `SELECT id, user_id, name, key_hash, scope, created_at, last_used_at, revoked_at
+			  FROM api_keys WHERE user_id = ? ORDER BY created_at, id`
+	rows, err := db.conn.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*APIKey
+	for rows.Next() {
+		var key APIKey
+		if err := rows.Scan(&key.ID, &key.UserID, &key.Name, &key.KeyHash, &key.Scope, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, &key)
+	}
+
+	return keys, rows.Err()
+}
+
+// Revokes a key owned by userID; revoking someone else's key reports not found
+func (db *DB) RevokeAPIKey(userID int, id string) error {
+	query := `UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? AND revoked_at IS NULL`
+	result, err := db.conn.Exec(query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("api key not found")
+	}
+
+	return nil
+}
+
+func (db *DB) TouchAPIKey(id string) error {
+	query := `UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?`
+	if _, err := db.conn.Exec(query, id); err != nil {
+		return fmt.Errorf("failed to update api key: %w", err)
+	}
+	return nil
+}
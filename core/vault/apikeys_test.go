@@ -0,0 +1,61 @@
+package vault
+
+import "testing"
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("botowner", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	key, err := db.CreateAPIKey("abc123", user.ID, "dashboard", "somehash", "read")
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+
+	if key.UserID != user.ID || key.Name != "dashboard" || key.Scope != "read" {
+		t.Errorf("CreateAPIKey() = %+v, unexpected fields", key)
+	}
+
+	if key.IsRevoked() {
+		t.Error("New key should not be revoked")
+	}
+
+	if err := db.TouchAPIKey("abc123"); err != nil {
+		t.Fatalf("TouchAPIKey() error = %v", err)
+	}
+
+	keys, err := db.ListAPIKeys(user.ID)
+	if err != nil {
+		t.Fatalf("ListAPIKeys() error = %v", err)
+	}
+	if len(keys) != 1 {
+		t.Fatalf("ListAPIKeys() returned %d keys, want 1", len(keys))
+	}
+	if !keys[0].LastUsedAt.Valid {
+		t.Error("TouchAPIKey() did not set last_used_at")
+	}
+
+	if err := db.RevokeAPIKey(user.ID+1, "abc123"); err == nil {
+		t.Error("RevokeAPIKey() should fail for another user's key")
+	}
+
+	if err := db.RevokeAPIKey(user.ID, "abc123"); err != nil {
+		t.Fatalf("RevokeAPIKey() error = %v", err)
+	}
+
+	revoked, err := db.GetAPIKey("abc123")
+	if err != nil {
+		t.Fatalf("GetAPIKey() error = %v", err)
+	}
+	if !revoked.IsRevoked() {
+		t.Error("Key should be revoked")
+	}
+
+	if err := db.RevokeAPIKey(user.ID, "abc123"); err == nil {
+		t.Error("RevokeAPIKey() should fail for an already revoked key")
+	}
+}
@@ -0,0 +1,146 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Audit event types
+const (
+	AuditLogin       = "login"
+	AuditNewIPLogin  = "new_ip_login"
+	AuditSignup      = "signup"
+	AuditAdminAdjust = "admin_adjustment"
+	AuditRoleChange  = "role_change"
+	AuditSetting     = "setting_change"
+	AuditPromoCreate = "promo_create"
+	AuditPromoRedeem = "promo_redeem"
+	AuditPromoFailed = "promo_failed"
+	AuditReferral    = "referral"
+	AuditEventCreate = "event_create"
+	AuditEventCancel = "event_cancel"
+	AuditChatMute    = "chat_mute"
+	AuditChatUnmute  = "chat_unmute"
+	AuditTableFreeze = "table_freeze"
+	AuditTableThaw   = "table_thaw"
+	AuditKick        = "kick"
+	AuditBan         = "ban"
+	AuditUnban       = "unban"
+	AuditExport      = "export"
+)
+
+type AuditEvent struct {
+	ID        int64     `json:"id"`
+	UserID    int       `json:"user_id"` // 0 for events not tied to a user
+	Type      string    `json:"type"`
+	IP        string    `json:"ip"`
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (db *DB) RecordAuditEvent(userID int, eventType, ip, detail string) error {
+	var uid interface{}
+	if userID != 0 {
+		uid = userID
+	}
+
+	query := `INSERT INTO audit_events (user_id, type, ip, detail) VALUES (?, ?, ?, ?)`
+	if _, err := db.conn.Exec(query, uid, eventType, ip, detail); err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) ListAuditEvents(userID int, limit int) ([]*AuditEvent, error) {
+	query := `SELECT id, user_id, type, ip, detail, created_at FROM audit_events
+			  WHERE user_id = ? ORDER BY id DESC LIMIT ?`
+	rows, err := db.conn.Query(query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*AuditEvent
+	for rows.Next() {
+		var e AuditEvent
+		var uid sql.NullInt64
+		if err := rows.Scan(&e.ID, &uid, &e.Type, &e.IP, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		e.UserID = int(uid.Int64)
+		events = append(events, &e)
+	}
+
+	return events, rows.Err()
+}
+
+// Lists audit events recorded in [since, until), oldest first, for one user or
+// every user when userID is 0. A positive limit caps how many are returned.
+func (db *DB) AuditEventsBetween(userID int, since, until time.Time, limit int) ([]*AuditEvent, error) {
+	query := `SELECT id, user_id, type, ip, detail, created_at FROM audit_events WHERE created_at >= ? AND created_at < ?`
+	args := []interface{}{sqlTime(since), sqlTime(until)}
+	if userID != 0 {
+		query += ` AND user_id = ?`
+		args = append(args, userID)
+	}
+	query += ` ORDER BY id`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*AuditEvent
+	for rows.Next() {
+		var e AuditEvent
+		var uid sql.NullInt64
+		if err := rows.Scan(&e.ID, &uid, &e.Type, &e.IP, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		e.UserID = int(uid.Int64)
+		events = append(events, &e)
+	}
+
+	return events, rows.Err()
+}
+
+// Records a login address and reports whether the account had used it before
+// and whether the account had any previous login addresses at all
+func (db *DB) RecordLoginIP(userID int, ip string) (seenBefore, hadPrevious bool, err error) {
+	var count int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM login_ips WHERE user_id = ?`, userID).Scan(&count); err != nil {
+		return false, false, fmt.Errorf("failed to read login ips: %w", err)
+	}
+
+	result, err := db.conn.Exec(`UPDATE login_ips SET last_seen = CURRENT_TIMESTAMP WHERE user_id = ? AND ip = ?`, userID, ip)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to update login ip: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return true, count > 0, nil
+	}
+
+	if _, err := db.conn.Exec(`INSERT INTO login_ips (user_id, ip) VALUES (?, ?)`, userID, ip); err != nil {
+		return false, false, fmt.Errorf("failed to record login ip: %w", err)
+	}
+
+	return false, count > 0, nil
+}
+
+// Counts events of a type from an IP since a point in time
+func (db *DB) CountAuditEventsByIP(eventType, ip string, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM audit_events WHERE type = ? AND ip = ? AND created_at >= ?`
+
+	var count int
+	if err := db.conn.QueryRow(query, eventType, ip, sqlTime(since)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count audit events: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,103 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuditEvents(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("audituser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := db.RecordAuditEvent(user.ID, AuditLogin, "10.0.0.1", "password"); err != nil {
+		t.Fatalf("RecordAuditEvent() error = %v", err)
+	}
+	if err := db.RecordAuditEvent(user.ID, AuditNewIPLogin, "10.0.0.2", "password"); err != nil {
+		t.Fatalf("RecordAuditEvent() error = %v", err)
+	}
+	if err := db.RecordAuditEvent(0, "server_start", "", ""); err != nil {
+		t.Fatalf("RecordAuditEvent() without user error = %v", err)
+	}
+
+	events, err := db.ListAuditEvents(user.ID, 10)
+	if err != nil {
+		t.Fatalf("ListAuditEvents() error = %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("ListAuditEvents() returned %d events, want 2", len(events))
+	}
+	if events[0].Type != AuditNewIPLogin || events[0].IP != "10.0.0.2" {
+		t.Errorf("Latest event = %+v, want new_ip_login from 10.0.0.2", events[0])
+	}
+}
+
+func TestAuditEventsBetween(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("exportuser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	for _, eventType := range []string{AuditLogin, AuditSignup} {
+		if err := db.RecordAuditEvent(user.ID, eventType, "10.0.0.1", ""); err != nil {
+			t.Fatalf("RecordAuditEvent() error = %v", err)
+		}
+	}
+	if err := db.RecordAuditEvent(0, "server_start", "", ""); err != nil {
+		t.Fatalf("RecordAuditEvent() error = %v", err)
+	}
+
+	since, until := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	all, err := db.AuditEventsBetween(0, since, until, 0)
+	if err != nil || len(all) != 3 {
+		t.Fatalf("AuditEventsBetween(all users) = %d events, %v, want 3", len(all), err)
+	}
+	if all[0].Type != AuditLogin || all[2].UserID != 0 {
+		t.Errorf("AuditEventsBetween() = %+v, want oldest first", all)
+	}
+
+	mine, err := db.AuditEventsBetween(user.ID, since, until, 1)
+	if err != nil || len(mine) != 1 || mine[0].Type != AuditLogin {
+		t.Errorf("AuditEventsBetween(user, limit 1) = %+v, %v, want the login", mine, err)
+	}
+
+	if none, err := db.AuditEventsBetween(0, until, until.Add(time.Hour), 0); err != nil || len(none) != 0 {
+		t.Errorf("AuditEventsBetween(future) = %+v, %v, want none", none, err)
+	}
+}
+
+func TestRecordLoginIP(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("audituser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	tests := []struct {
+		ip                      string
+		seenBefore, hadPrevious bool
+	}{
+		{"10.0.0.1", false, false},
+		{"10.0.0.1", true, true},
+		{"10.0.0.2", false, true},
+		{"10.0.0.2", true, true},
+	}
+
+	for i, tt := range tests {
+		seen, had, err := db.RecordLoginIP(user.ID, tt.ip)
+		if err != nil {
+			t.Fatalf("RecordLoginIP() error = %v", err)
+		}
+		if seen != tt.seenBefore || had != tt.hadPrevious {
+			t.Errorf("login %d from %s: RecordLoginIP() = (%v, %v), want (%v, %v)", i, tt.ip, seen, had, tt.seenBefore, tt.hadPrevious)
+		}
+	}
+}
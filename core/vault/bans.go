@@ -0,0 +1,100 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Ban is an account suspension; Until is unset for a permanent ban
+type Ban struct {
+	UserID    int          `json:"user_id"`
+	Username  string       `json:"username"`
+	Until     sql.NullTime `json:"until"`
+	Reason    string       `json:"reason"`
+	BannedBy  string       `json:"banned_by"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// Whether the ban is still in force at now
+func (b *Ban) Active(now time.Time) bool {
+	return !b.Until.Valid || now.Before(b.Until.Time)
+}
+
+const banColumns = `b.user_id, u.username, b.banned_until, b.reason, b.banned_by, b.created_at`
+
+func scanBan(row interface{ Scan(...interface{}) error }) (*Ban, error) {
+	var ban Ban
+	err := row.Scan(&ban.UserID, &ban.Username, &ban.Until, &ban.Reason, &ban.BannedBy, &ban.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &ban, nil
+}
+
+// Suspends a user until a point in time, or for good when until is zero,
+// replacing any earlier ban
+func (db *DB) BanUser(userID int, until time.Time, reason, bannedBy string) error {
+	var expiry interface{}
+	if !until.IsZero() {
+		expiry = sqlTime(until)
+	}
+
+	query := `INSERT INTO user_bans (user_id, banned_until, reason, banned_by) VALUES (?, ?, ?, ?)
+			  ON CONFLICT(user_id) DO UPDATE SET banned_until = excluded.banned_until, reason = excluded.reason,
+			  banned_by = excluded.banned_by, created_at = CURRENT_TIMESTAMP`
+	if _, err := db.conn.Exec(query, userID, expiry, reason, bannedBy); err != nil {
+		return fmt.Errorf("failed to ban user: %w", err)
+	}
+	return nil
+}
+
+// Lifts a user's ban; reports whether there was one in force
+func (db *DB) UnbanUser(userID int) (bool, error) {
+	ban, err := db.GetBan(userID)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := db.conn.Exec(`DELETE FROM user_bans WHERE user_id = ?`, userID); err != nil {
+		return false, fmt.Errorf("failed to unban user: %w", err)
+	}
+	return ban != nil && ban.Active(time.Now()), nil
+}
+
+// Returns the user's ban, or nil when they were never banned. The ban may
+// already have expired.
+func (db *DB) GetBan(userID int) (*Ban, error) {
+	query := `SELECT ` + banColumns + ` FROM user_bans b JOIN users u ON u.id = b.user_id WHERE b.user_id = ?`
+	ban, err := scanBan(db.conn.QueryRow(query, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ban: %w", err)
+	}
+	return ban, nil
+}
+
+// Lists the bans still in force, the most recent first
+func (db *DB) ListBans() ([]*Ban, error) {
+	query := `SELECT ` + banColumns + ` FROM user_bans b JOIN users u ON u.id = b.user_id
+			  WHERE b.banned_until IS NULL OR b.banned_until > ?
+			  ORDER BY b.created_at DESC, u.username`
+	rows, err := db.conn.Query(query, sqlTime(time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bans: %w", err)
+	}
+	defer rows.Close()
+
+	var bans []*Ban
+	for rows.Next() {
+		ban, err := scanBan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ban: %w", err)
+		}
+		bans = append(bans, ban)
+	}
+
+	return bans, rows.Err()
+}
@@ -0,0 +1,94 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBans(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("rowdy", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	other, err := db.CreateUser("cheater", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	if ban, err := db.GetBan(user.ID); err != nil || ban != nil {
+		t.Fatalf("GetBan() = %v, %v, want nil", ban, err)
+	}
+
+	until := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	if err := db.BanUser(user.ID, until, "spamming chat", "admin"); err != nil {
+		t.Fatalf("BanUser() error = %v", err)
+	}
+	if err := db.BanUser(other.ID, time.Time{}, "", "admin"); err != nil {
+		t.Fatalf("BanUser() error = %v", err)
+	}
+
+	ban, err := db.GetBan(user.ID)
+	if err != nil || ban == nil {
+		t.Fatalf("GetBan() = %v, %v", ban, err)
+	}
+	if !ban.Until.Valid || !ban.Until.Time.Equal(until) || ban.Reason != "spamming chat" || ban.Username != "rowdy" {
+		t.Errorf("GetBan() = %+v, want rowdy until %v for spamming chat", ban, until)
+	}
+	if !ban.Active(time.Now()) || ban.Active(until.Add(time.Second)) {
+		t.Error("Active() should hold only until the ban ends")
+	}
+
+	permanent, err := db.GetBan(other.ID)
+	if err != nil || permanent == nil || permanent.Until.Valid || !permanent.Active(time.Now().AddDate(10, 0, 0)) {
+		t.Errorf("GetBan() = %+v, %v, want a permanent ban", permanent, err)
+	}
+
+	// An expired ban is no longer listed
+	if err := db.BanUser(user.ID, time.Now().Add(-time.Minute), "old", "admin"); err != nil {
+		t.Fatalf("BanUser() error = %v", err)
+	}
+	bans, err := db.ListBans()
+	if err != nil {
+		t.Fatalf("ListBans() error = %v", err)
+	}
+	if len(bans) != 1 || bans[0].UserID != other.ID {
+		t.Errorf("ListBans() = %+v, want only cheater", bans)
+	}
+
+	if removed, err := db.UnbanUser(user.ID); err != nil || removed {
+		t.Errorf("UnbanUser() of an expired ban = %v, %v, want false", removed, err)
+	}
+	if removed, err := db.UnbanUser(other.ID); err != nil || !removed {
+		t.Errorf("UnbanUser() = %v, %v, want true", removed, err)
+	}
+	if ban, err := db.GetBan(other.ID); err != nil || ban != nil {
+		t.Errorf("GetBan() after UnbanUser() = %v, %v, want nil", ban, err)
+	}
+}
+
+func TestDeleteUserSessions(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("sessions", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	for _, id := range []string{"one", "two"} {
+		if err := db.CreateSession(id, user.ID, time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("CreateSession() error = %v", err)
+		}
+	}
+
+	if err := db.DeleteUserSessions(user.ID); err != nil {
+		t.Fatalf("DeleteUserSessions() error = %v", err)
+	}
+	for _, id := range []string{"one", "two"} {
+		if _, err := db.GetSession(id); err == nil {
+			t.Errorf("GetSession(%q) should fail after DeleteUserSessions()", id)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Credits a bonus if the user's last claim was at or before notAfter, recording
+// the claim time and the ledger entry in one transaction. claimed is false when
+// the cooldown hasn't passed yet.
+func (db *DB) ClaimDailyBonus(userID int, amount int64, notAfter time.Time) (balance int64, claimed bool, err error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO daily_bonus (user_id, last_claimed) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET last_claimed = excluded.last_claimed
+		WHERE daily_bonus.last_claimed <= ?`, userID, sqlTime(time.Now()), sqlTime(notAfter))
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to record bonus claim: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to record bonus claim: %w", err)
+	}
+	if affected == 0 {
+		return 0, false, nil
+	}
+
+	if balance, err = adjustBalanceTx(tx, userID, amount, TxBonus, ""); err != nil {
+		return 0, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("failed to commit bonus claim: %w", err)
+	}
+
+	return balance, true, nil
+}
+
+// Returns when the user last claimed the daily bonus; ok is false if never
+func (db *DB) GetLastBonusClaim(userID int) (lastClaimed time.Time, ok bool, err error) {
+	err = db.conn.QueryRow(`SELECT last_claimed FROM daily_bonus WHERE user_id = ?`, userID).Scan(&lastClaimed)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get bonus claim: %w", err)
+	}
+
+	return lastClaimed, true, nil
+}
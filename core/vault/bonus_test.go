@@ -0,0 +1,55 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClaimDailyBonus(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("bonususer", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, ok, err := db.GetLastBonusClaim(user.ID); err != nil || ok {
+		t.Fatalf("GetLastBonusClaim() = %v, %v, want no claim", ok, err)
+	}
+
+	dayAgo := time.Now().Add(-24 * time.Hour)
+
+	balance, claimed, err := db.ClaimDailyBonus(user.ID, 10000, dayAgo)
+	if err != nil || !claimed {
+		t.Fatalf("ClaimDailyBonus() = %v, %v, want a claim", claimed, err)
+	}
+	if balance != 1010000 {
+		t.Errorf("ClaimDailyBonus() balance = %d, want 1010000", balance)
+	}
+
+	if _, claimed, err := db.ClaimDailyBonus(user.ID, 10000, dayAgo); err != nil || claimed {
+		t.Errorf("Second ClaimDailyBonus() = %v, %v, want refused", claimed, err)
+	}
+
+	last, ok, err := db.GetLastBonusClaim(user.ID)
+	if err != nil || !ok {
+		t.Fatalf("GetLastBonusClaim() = %v, %v", ok, err)
+	}
+	if time.Since(last) > time.Minute {
+		t.Errorf("GetLastBonusClaim() = %v, want about now", last)
+	}
+
+	// A cutoff in the future behaves as if the cooldown has passed
+	if _, claimed, err := db.ClaimDailyBonus(user.ID, 10000, time.Now().Add(time.Minute)); err != nil || !claimed {
+		t.Errorf("ClaimDailyBonus() after cooldown = %v, %v, want a claim", claimed, err)
+	}
+
+	bonuses, err := db.SumTransactions(user.ID, dayAgo, TxBonus)
+	if err != nil {
+		t.Fatalf("SumTransactions() error = %v", err)
+	}
+	if bonuses != 20000 {
+		t.Errorf("SumTransactions(bonus) = %d, want 20000", bonuses)
+	}
+}
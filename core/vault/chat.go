@@ -0,0 +1,47 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Mutes a user in chat until a point in time, replacing any earlier mute
+func (db *DB) MuteChat(userID int, until time.Time, mutedBy string) error {
+	query := `INSERT INTO chat_mutes (user_id, muted_until, muted_by) VALUES (?, ?, ?)
+			  ON CONFLICT(user_id) DO UPDATE SET muted_until = excluded.muted_until,
+			  muted_by = excluded.muted_by, created_at = CURRENT_TIMESTAMP`
+	if _, err := db.conn.Exec(query, userID, sqlTime(until), mutedBy); err != nil {
+		return fmt.Errorf("failed to mute user: %w", err)
+	}
+	return nil
+}
+
+// Lifts a user's chat mute; reports whether there was one
+func (db *DB) UnmuteChat(userID int) (bool, error) {
+	result, err := db.conn.Exec(`DELETE FROM chat_mutes WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unmute user: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to unmute user: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// Returns when the user's chat mute ends; zero when they were never muted.
+// The time may already have passed.
+func (db *DB) ChatMutedUntil(userID int) (time.Time, error) {
+	var until time.Time
+	err := db.conn.QueryRow(`SELECT muted_until FROM chat_mutes WHERE user_id = ?`, userID).Scan(&until)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get chat mute: %w", err)
+	}
+
+	return until, nil
+}
@@ -0,0 +1,45 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChatMute(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("chatty", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	if until, err := db.ChatMutedUntil(user.ID); err != nil || !until.IsZero() {
+		t.Fatalf("ChatMutedUntil() = %v, %v, want zero", until, err)
+	}
+
+	until := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	if err := db.MuteChat(user.ID, until, "admin"); err != nil {
+		t.Fatalf("MuteChat() error = %v", err)
+	}
+	// Muting again replaces the earlier mute
+	until = until.Add(time.Hour)
+	if err := db.MuteChat(user.ID, until, "admin"); err != nil {
+		t.Fatalf("MuteChat() error = %v", err)
+	}
+
+	got, err := db.ChatMutedUntil(user.ID)
+	if err != nil || !got.Equal(until) {
+		t.Errorf("ChatMutedUntil() = %v, %v, want %v", got, err, until)
+	}
+
+	if removed, err := db.UnmuteChat(user.ID); err != nil || !removed {
+		t.Errorf("UnmuteChat() = %v, %v, want true", removed, err)
+	}
+	if removed, err := db.UnmuteChat(user.ID); err != nil || removed {
+		t.Errorf("UnmuteChat() again = %v, %v, want false", removed, err)
+	}
+	if got, err := db.ChatMutedUntil(user.ID); err != nil || !got.IsZero() {
+		t.Errorf("ChatMutedUntil() after unmute = %v, %v, want zero", got, err)
+	}
+}
@@ -0,0 +1,141 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Event is an admin-scheduled promotion that boosts part of settlement while
+// it runs, e.g. double XP for a weekend
+type Event struct {
+	ID             int64     `json:"id"`
+	Name           string    `json:"name"`
+	Kind           string    `json:"kind"`
+	Multiplier     int       `json:"multiplier"` // Percent of the normal value, 200 doubles it
+	StartsAt       time.Time `json:"starts_at"`
+	EndsAt         time.Time `json:"ends_at"`
+	CreatedBy      string    `json:"created_by"`
+	StartAnnounced bool      `json:"start_announced"`
+	EndAnnounced   bool      `json:"end_announced"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Reports whether the event is running at t
+func (e *Event) ActiveAt(t time.Time) bool {
+	return !t.Before(e.StartsAt) && t.Before(e.EndsAt)
+}
+
+const eventColumns = `id, name, kind, multiplier, starts_at, ends_at, created_by, start_announced, end_announced, created_at`
+
+func scanEvent(row interface{ Scan(...interface{}) error }) (*Event, error) {
+	var e Event
+	err := row.Scan(&e.ID, &e.Name, &e.Kind, &e.Multiplier, &e.StartsAt, &e.EndsAt, &e.CreatedBy,
+		&e.StartAnnounced, &e.EndAnnounced, &e.CreatedAt)
+	return &e, err
+}
+
+func (db *DB) CreateEvent(name, kind string, multiplier int, startsAt, endsAt time.Time, createdBy string) (*Event, error) {
+	query := `INSERT INTO events (name, kind, multiplier, starts_at, ends_at, created_by) VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := db.conn.Exec(query, name, kind, multiplier, sqlTime(startsAt), sqlTime(endsAt), createdBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event: %w", err)
+	}
+
+	return db.GetEvent(id)
+}
+
+func (db *DB) GetEvent(id int64) (*Event, error) {
+	event, err := scanEvent(db.conn.QueryRow(`SELECT `+eventColumns+` FROM events WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("event not found")
+		}
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+
+	return event, nil
+}
+
+// Lists events that end after since, soonest first
+func (db *DB) ListEvents(since time.Time) ([]*Event, error) {
+	return db.queryEvents(`SELECT `+eventColumns+` FROM events WHERE ends_at > ? ORDER BY starts_at, id`, sqlTime(since))
+}
+
+// Lists events whose start or end has passed but hasn't been announced yet
+func (db *DB) ListUnannouncedEvents(now time.Time) ([]*Event, error) {
+	return db.queryEvents(`SELECT `+eventColumns+` FROM events
+		WHERE (start_announced = 0 AND starts_at <= ?) OR (end_announced = 0 AND ends_at <= ?)
+		ORDER BY starts_at, id`, sqlTime(now), sqlTime(now))
+}
+
+func (db *DB) queryEvents(query string, args ...interface{}) ([]*Event, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// Marks an event's start (or end) as announced. Returns false when it already
+// was, so each announcement goes out once.
+func (db *DB) MarkEventAnnounced(id int64, end bool) (bool, error) {
+	column := "start_announced"
+	if end {
+		column = "end_announced"
+	}
+
+	result, err := db.conn.Exec(`UPDATE events SET `+column+` = 1 WHERE id = ? AND `+column+` = 0`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark event announced: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark event announced: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// Ends an event at now, or drops it entirely if it hasn't started yet
+func (db *DB) CancelEvent(id int64, now time.Time) error {
+	// SET expressions all see the old row, so the starts_at checks use the original start
+	t := sqlTime(now)
+	result, err := db.conn.Exec(`UPDATE events SET
+		starts_at = CASE WHEN starts_at > ? THEN ? ELSE starts_at END,
+		start_announced = CASE WHEN starts_at > ? THEN 1 ELSE start_announced END,
+		end_announced = CASE WHEN starts_at > ? THEN 1 ELSE end_announced END,
+		ends_at = ?
+		WHERE id = ? AND ends_at > ?`,
+		t, t, t, t, t, id, t)
+	if err != nil {
+		return fmt.Errorf("failed to cancel event: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to cancel event: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("event not found or already over")
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventLifecycle(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	running, err := db.CreateEvent("Double XP", "xp", 200, now.Add(-time.Hour), now.Add(time.Hour), "admin")
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if running.Multiplier != 200 || !running.ActiveAt(now) {
+		t.Errorf("CreateEvent() = %+v, want an active 200%% event", running)
+	}
+
+	upcoming, err := db.CreateEvent("Blackjack Boost", "blackjack", 150, now.Add(24*time.Hour), now.Add(48*time.Hour), "admin")
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if _, err := db.CreateEvent("Old", "points", 300, now.Add(-48*time.Hour), now.Add(-24*time.Hour), "admin"); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+
+	events, err := db.ListEvents(now)
+	if err != nil {
+		t.Fatalf("ListEvents() error = %v", err)
+	}
+	if len(events) != 2 || events[0].ID != running.ID || events[1].ID != upcoming.ID {
+		t.Errorf("ListEvents() = %+v, want the running then the upcoming event", events)
+	}
+
+	// The running event needs a start announcement, the old one both
+	due, err := db.ListUnannouncedEvents(now)
+	if err != nil {
+		t.Fatalf("ListUnannouncedEvents() error = %v", err)
+	}
+	if len(due) != 2 {
+		t.Fatalf("ListUnannouncedEvents() returned %d events, want 2", len(due))
+	}
+
+	marked, err := db.MarkEventAnnounced(running.ID, false)
+	if err != nil || !marked {
+		t.Fatalf("MarkEventAnnounced() = %v, %v, want true", marked, err)
+	}
+	if marked, _ := db.MarkEventAnnounced(running.ID, false); marked {
+		t.Error("MarkEventAnnounced() should only succeed once")
+	}
+
+	// Cancelling ends a running event now and drops an upcoming one silently
+	if err := db.CancelEvent(running.ID, now); err != nil {
+		t.Fatalf("CancelEvent() error = %v", err)
+	}
+	if err := db.CancelEvent(upcoming.ID, now); err != nil {
+		t.Fatalf("CancelEvent() error = %v", err)
+	}
+	if err := db.CancelEvent(running.ID, now); err == nil {
+		t.Error("CancelEvent() should reject an event that is already over")
+	}
+
+	cancelled, err := db.GetEvent(running.ID)
+	if err != nil {
+		t.Fatalf("GetEvent() error = %v", err)
+	}
+	if cancelled.ActiveAt(now) || !cancelled.EndsAt.Equal(now) {
+		t.Errorf("Cancelled event ends at %v, want %v", cancelled.EndsAt, now)
+	}
+
+	dropped, err := db.GetEvent(upcoming.ID)
+	if err != nil {
+		t.Fatalf("GetEvent() error = %v", err)
+	}
+	if !dropped.StartAnnounced || !dropped.EndAnnounced {
+		t.Error("A cancelled upcoming event should never be announced")
+	}
+
+	if events, _ := db.ListEvents(now); len(events) != 0 {
+		t.Errorf("ListEvents() after cancelling = %d events, want 0", len(events))
+	}
+}
@@ -0,0 +1,173 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Per-user rows removed along with a purged guest. The ledger, rounds and
+// table hands are kept under the emptied account, and the audit log is kept
+// detached from it. Referrals the guest made belong to the referred user.
+var guestDataTables = []struct{ table, column string }{
+	{"sessions", "user_id"},
+	{"user_stats", "user_id"},
+	{"user_limits", "user_id"},
+	{"api_keys", "user_id"},
+	{"point_transactions", "user_id"},
+	{"user_achievements", "user_id"},
+	{"user_games", "user_id"},
+	{"user_items", "user_id"},
+	{"user_equipped", "user_id"},
+	{"promotion_optins", "user_id"},
+	{"promotion_payouts", "user_id"},
+	{"promo_redemptions", "user_id"},
+	{"referral_codes", "user_id"},
+	{"referrals", "referred_id"},
+	{"daily_bonus", "user_id"},
+	{"ip_allowlist", "user_id"},
+	{"login_ips", "user_id"},
+	{"chat_mutes", "user_id"},
+	{"user_bans", "user_id"},
+	{"direct_messages", "sender_id"},
+	{"direct_messages", "recipient_id"},
+	{"user_blocks", "user_id"},
+	{"user_blocks", "blocked_id"},
+	{"user_mutes", "user_id"},
+	{"user_mutes", "muted_id"},
+	{"user_settings", "user_id"},
+}
+
+// Creates a guest account with no password; it can only be reached through
+// the session issued when it is created
+func (db *DB) CreateGuestUser(username string, balance int64) (*User, error) {
+	result, err := db.conn.Exec(`INSERT INTO users (username, password, balance, is_guest) VALUES (?, '', ?, 1)`, username, balance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create guest: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user ID: %w", err)
+	}
+
+	if err := db.initUserStats(int(id)); err != nil {
+		return nil, fmt.Errorf("failed to initialize user stats: %w", err)
+	}
+
+	return db.GetUserByID(int(id))
+}
+
+// Purges guest accounts with no activity since idleSince, skipping the IDs in
+// keep (e.g. guests still connected). Bets they left in escrow are refunded
+// first. Returns how many were purged.
+func (db *DB) PurgeGuests(idleSince time.Time, keep []int) (int, error) {
+	rows, err := db.conn.Query(`SELECT id FROM users WHERE is_guest = 1 AND purged_at IS NULL AND updated_at < ?`, sqlTime(idleSince))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list idle guests: %w", err)
+	}
+
+	skip := make(map[int]bool, len(keep))
+	for _, id := range keep {
+		skip[id] = true
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan guest: %w", err)
+		}
+		if !skip[id] {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to list idle guests: %w", err)
+	}
+
+	for _, id := range ids {
+		if err := db.purgeGuest(id); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(ids), nil
+}
+
+func (db *DB) purgeGuest(userID int) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := refundGuestBetsTx(tx, userID); err != nil {
+		return err
+	}
+	for _, t := range guestDataTables {
+		if _, err := tx.Exec(`DELETE FROM `+t.table+` WHERE `+t.column+` = ?`, userID); err != nil {
+			return fmt.Errorf("failed to purge guest %s: %w", t.table, err)
+		}
+	}
+	if _, err := tx.Exec(`UPDATE audit_events SET user_id = NULL WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to purge guest audit_events: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE users SET purged_at = CURRENT_TIMESTAMP WHERE id = ? AND is_guest = 1`, userID); err != nil {
+		return fmt.Errorf("failed to purge guest: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit guest purge: %w", err)
+	}
+
+	return nil
+}
+
+// Returns a guest's held bets and any saved hand's bet to its balance, as
+// ReleaseHold and RefundActiveGame do
+func refundGuestBetsTx(tx *sql.Tx, userID int) error {
+	rows, err := tx.Query(`SELECT id FROM bet_holds WHERE user_id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list guest bets: %w", err)
+	}
+	var holds []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan guest bet: %w", err)
+		}
+		holds = append(holds, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list guest bets: %w", err)
+	}
+
+	for _, id := range holds {
+		hold, err := deleteHoldTx(tx, id)
+		if err != nil {
+			return err
+		}
+		if _, err := adjustGameBalanceTx(tx, userID, hold.Amount, TxRefund, hold.Game, "purged guest"); err != nil {
+			return err
+		}
+	}
+
+	var bet int64
+	var game string
+	err = tx.QueryRow(`DELETE FROM active_games WHERE user_id = ? RETURNING bet, game`, userID).Scan(&bet, &game)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to close guest game: %w", err)
+	}
+	if _, err := adjustGameBalanceTx(tx, userID, bet, TxRefund, game, "abandoned hand"); err != nil {
+		return err
+	}
+	return nil
+}
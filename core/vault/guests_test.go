@@ -0,0 +1,86 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPurgeGuests(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	idle, err := db.CreateGuestUser("guest_idle", 100000)
+	if err != nil {
+		t.Fatalf("CreateGuestUser() error = %v", err)
+	}
+	if !idle.IsGuest || idle.Balance != 100000 {
+		t.Errorf("CreateGuestUser() = %+v, want a guest with 100000", idle)
+	}
+	connected, err := db.CreateGuestUser("guest_connected", 100000)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	member, err := db.CreateUser("member", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := db.AdjustBalance(idle.ID, -1000, TxBet); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := db.RecordAuditEvent(idle.ID, "login", "10.0.0.1", "guest"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, _, err := db.HoldBet(idle.ID, "blackjack", "table-1", 500); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := db.StartActiveGame(idle.ID, "hilo", 200, "{}"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := db.CreateReferral(idle.ID, member.ID, "GUESTCODE", "pending", "10.0.0.2", ""); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	// Everything counts as idle when the cutoff is in the future
+	purged, err := db.PurgeGuests(time.Now().Add(time.Hour), []int{connected.ID})
+	if err != nil {
+		t.Fatalf("PurgeGuests() error = %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("PurgeGuests() = %d, want 1", purged)
+	}
+
+	// The held bet and the saved hand are refunded, and the ledger is kept
+	got, err := db.GetUserByID(idle.ID)
+	if err != nil || got.Balance != 99000 {
+		t.Errorf("GetUserByID() = %+v, %v, want the purged guest kept with 99000", got, err)
+	}
+	if holds, _ := db.ListHolds(); len(holds) != 0 {
+		t.Errorf("Purged guest still has %d bets held", len(holds))
+	}
+	if game, _ := db.GetActiveGame(idle.ID); game != nil {
+		t.Error("Purged guest still has a hand in progress")
+	}
+	if txs, _ := db.ListTransactions(idle.ID, 10); len(txs) != 5 {
+		t.Errorf("Purged guest has %d transactions, want all 5 kept", len(txs))
+	}
+	if breaks, _ := db.LedgerBreaks(idle.ID); len(breaks) != 0 {
+		t.Errorf("LedgerBreaks() = %d entries after the purge, want none", len(breaks))
+	}
+	if _, ok, _ := db.GetReferral(member.ID); !ok {
+		t.Error("The referral of a registered user must not be purged with its referrer")
+	}
+	if _, err := db.GetUserByID(connected.ID); err != nil {
+		t.Errorf("Connected guest should be kept: %v", err)
+	}
+	if _, err := db.GetUserByID(member.ID); err != nil {
+		t.Errorf("Registered users must never be purged: %v", err)
+	}
+
+	if purged, err := db.PurgeGuests(time.Now().Add(-time.Hour), nil); err != nil || purged != 0 {
+		t.Errorf("PurgeGuests() with a past cutoff = %d, %v, want 0", purged, err)
+	}
+	if purged, err := db.PurgeGuests(time.Now().Add(time.Hour), []int{connected.ID}); err != nil || purged != 0 {
+		t.Errorf("PurgeGuests() again = %d, %v, want 0 for a guest already purged", purged, err)
+	}
+}
@@ -0,0 +1,50 @@
+package vault
+
+import (
+	"fmt"
+	"time"
+)
+
+// GameRound is one settled round in a player's game history
+type GameRound struct {
+	ID        int64     `json:"id"`
+	UserID    int       `json:"user_id"`
+	Game      string    `json:"game"`
+	Bet       int64     `json:"bet"`
+	Payout    int64     `json:"payout"`
+	Result    string    `json:"result"`
+	Hands     string    `json:"hands"` // The cards or numbers the round ended on
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Lists a player's settled rounds newest first, skipping the newest offset
+func (db *DB) ListGameRounds(userID, limit, offset int) ([]*GameRound, error) {
+	rows, err := db.conn.Query(`SELECT id, user_id, game, bet, payout, result, hands, created_at FROM game_rounds
+		WHERE user_id = ? ORDER BY id DESC LIMIT ? OFFSET ?`, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list game history: %w", err)
+	}
+	defer rows.Close()
+
+	var rounds []*GameRound
+	for rows.Next() {
+		var r GameRound
+		if err := rows.Scan(&r.ID, &r.UserID, &r.Game, &r.Bet, &r.Payout, &r.Result, &r.Hands, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan game round: %w", err)
+		}
+		rounds = append(rounds, &r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list game history: %w", err)
+	}
+	return rounds, nil
+}
+
+// Counts a player's settled rounds
+func (db *DB) CountGameRounds(userID int) (int, error) {
+	var count int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM game_rounds WHERE user_id = ?`, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count game history: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,43 @@
+package vault
+
+import "testing"
+
+func TestGameRounds(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("historian", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	other, err := db.CreateUser("bystander", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	for i, result := range []string{"Loss", "Win", "Push"} {
+		if _, err := db.SettleRound(user.ID, "blackjack", 1000, int64(i)*1000, result, "Player [10♠] [Q♥] (20)", nil); err != nil {
+			t.Fatalf("SettleRound() error = %v", err)
+		}
+	}
+	if _, err := db.SettleRound(other.ID, "blackjack", 500, 0, "Bust", "", nil); err != nil {
+		t.Fatalf("SettleRound() error = %v", err)
+	}
+
+	if count, err := db.CountGameRounds(user.ID); err != nil || count != 3 {
+		t.Errorf("CountGameRounds() = %d, %v, want 3", count, err)
+	}
+
+	rounds, err := db.ListGameRounds(user.ID, 2, 0)
+	if err != nil || len(rounds) != 2 {
+		t.Fatalf("ListGameRounds() = %v, %v, want two rounds", rounds, err)
+	}
+	if r := rounds[0]; r.Result != "Push" || r.Bet != 1000 || r.Payout != 2000 || r.Game != "blackjack" || r.Hands != "Player [10♠] [Q♥] (20)" {
+		t.Errorf("ListGameRounds()[0] = %+v, want the newest round", r)
+	}
+
+	rounds, err = db.ListGameRounds(user.ID, 2, 2)
+	if err != nil || len(rounds) != 1 || rounds[0].Result != "Loss" {
+		t.Errorf("ListGameRounds() second page = %v, %v, want the oldest round", rounds, err)
+	}
+}
@@ -0,0 +1,163 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// BetHold is a stake moved out of a player's balance when the bet is placed
+// and held in escrow until its round is settled or the bet refunded. Solo hands
+// hold their bet with the saved hand instead (see ActiveGame).
+type BetHold struct {
+	ID        int64     `json:"id"`
+	UserID    int       `json:"user_id"`
+	Game      string    `json:"game"`
+	Ref       string    `json:"ref"` // Where the bet was placed, e.g. a table ID
+	Amount    int64     `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Takes a bet from the player's balance into a new hold in one transaction.
+// Returns the hold's ID and the balance.
+func (db *DB) HoldBet(userID int, game, ref string, amount int64) (int64, int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	balance, err := adjustGameBalanceTx(tx, userID, -amount, TxBet, game, "")
+	if err != nil {
+		return 0, 0, err
+	}
+	result, err := tx.Exec(`INSERT INTO bet_holds (user_id, game, ref, amount) VALUES (?, ?, ?, ?)`, userID, game, ref, amount)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to hold bet: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to hold bet: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit bet: %w", err)
+	}
+	return id, balance, nil
+}
+
+// Moves delta more from the player's balance into a hold (e.g. for a double
+// down), or back out of it when negative. Returns the balance.
+func (db *DB) AdjustHold(holdID, delta int64) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID int
+	var game string
+	if err := tx.QueryRow(`UPDATE bet_holds SET amount = amount + ? WHERE id = ? AND amount + ? >= 0 RETURNING user_id, game`,
+		delta, holdID, delta).Scan(&userID, &game); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("bet not found")
+		}
+		return 0, fmt.Errorf("failed to update bet: %w", err)
+	}
+
+	txType := TxBet
+	if delta < 0 {
+		txType = TxRefund
+	}
+	balance, err := adjustGameBalanceTx(tx, userID, -delta, txType, game, "")
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit bet: %w", err)
+	}
+	return balance, nil
+}
+
+// Settles a round from a hold: removes it and settles its amount as the wager
+// as SettleRound does, in one transaction, so a bet is never paid twice.
+// Returns the balance.
+func (db *DB) SettleHold(holdID, payout int64, result, hands string, stats *UserStats) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hold, err := deleteHoldTx(tx, holdID)
+	if err != nil {
+		return 0, err
+	}
+	balance, err := settleRoundTx(tx, hold.UserID, hold.Game, hold.Amount, payout, result, hands, stats)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit settlement: %w", err)
+	}
+	return balance, nil
+}
+
+// Refunds a hold in full. Returns the balance and the amount refunded.
+func (db *DB) ReleaseHold(holdID int64) (int64, int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hold, err := deleteHoldTx(tx, holdID)
+	if err != nil {
+		return 0, 0, err
+	}
+	balance, err := adjustGameBalanceTx(tx, hold.UserID, hold.Amount, TxRefund, hold.Game, "")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit refund: %w", err)
+	}
+	return balance, hold.Amount, nil
+}
+
+// Lists every bet still held, oldest first
+func (db *DB) ListHolds() ([]*BetHold, error) {
+	rows, err := db.conn.Query(`SELECT id, user_id, game, ref, amount, created_at FROM bet_holds ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bets: %w", err)
+	}
+	defer rows.Close()
+
+	var holds []*BetHold
+	for rows.Next() {
+		var h BetHold
+		if err := rows.Scan(&h.ID, &h.UserID, &h.Game, &h.Ref, &h.Amount, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan bet: %w", err)
+		}
+		holds = append(holds, &h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list bets: %w", err)
+	}
+	return holds, nil
+}
+
+func deleteHoldTx(tx *sql.Tx, holdID int64) (*BetHold, error) {
+	hold := BetHold{ID: holdID}
+	if err := tx.QueryRow(`DELETE FROM bet_holds WHERE id = ? RETURNING user_id, game, amount`, holdID).
+		Scan(&hold.UserID, &hold.Game, &hold.Amount); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("bet not found")
+		}
+		return nil, fmt.Errorf("failed to close bet: %w", err)
+	}
+	return &hold, nil
+}
@@ -0,0 +1,88 @@
+package vault
+
+import "testing"
+
+func TestBetHolds(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("holduser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	id, balance, err := db.HoldBet(user.ID, "blackjack", "T1", 1000)
+	if err != nil {
+		t.Fatalf("HoldBet() error = %v", err)
+	}
+	if balance != 999000 {
+		t.Errorf("HoldBet() balance = %d, want 999000", balance)
+	}
+
+	// Doubling moves more into the hold, a failed double moves it back
+	if balance, err = db.AdjustHold(id, 1000); err != nil || balance != 998000 {
+		t.Errorf("AdjustHold(+1000) = %d, %v, want 998000", balance, err)
+	}
+	if balance, err = db.AdjustHold(id, -500); err != nil || balance != 998500 {
+		t.Errorf("AdjustHold(-500) = %d, %v, want 998500", balance, err)
+	}
+	if _, err := db.AdjustHold(id, -5000); err == nil {
+		t.Error("AdjustHold() should not return more than is held")
+	}
+
+	holds, err := db.ListHolds()
+	if err != nil || len(holds) != 1 {
+		t.Fatalf("ListHolds() = %v, %v, want one hold", holds, err)
+	}
+	if h := holds[0]; h.ID != id || h.UserID != user.ID || h.Game != "blackjack" || h.Ref != "T1" || h.Amount != 1500 {
+		t.Errorf("ListHolds() = %+v", h)
+	}
+
+	balance, err = db.SettleHold(id, 3000, "", "", nil)
+	if err != nil {
+		t.Fatalf("SettleHold() error = %v", err)
+	}
+	if balance != 1001500 {
+		t.Errorf("SettleHold() balance = %d, want 1001500", balance)
+	}
+	if _, err := db.SettleHold(id, 3000, "", "", nil); err == nil {
+		t.Error("SettleHold() should never pay a bet twice")
+	}
+	if _, _, err := db.ReleaseHold(id); err == nil {
+		t.Error("ReleaseHold() should fail once the bet is settled")
+	}
+
+	stats, err := db.ListHouseStats()
+	if err != nil || len(stats) != 1 || stats[0].Wagered != 1500 || stats[0].Paid != 3000 {
+		t.Errorf("ListHouseStats() = %v, %v, want the settled bet", stats, err)
+	}
+}
+
+func TestReleaseHold(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("releaseuser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	id, _, err := db.HoldBet(user.ID, "blackjack", "T1", 2500)
+	if err != nil {
+		t.Fatalf("HoldBet() error = %v", err)
+	}
+	balance, refunded, err := db.ReleaseHold(id)
+	if err != nil {
+		t.Fatalf("ReleaseHold() error = %v", err)
+	}
+	if balance != 1000000 || refunded != 2500 {
+		t.Errorf("ReleaseHold() = %d, %d, want 1000000, 2500", balance, refunded)
+	}
+
+	if _, _, err := db.HoldBet(user.ID, "blackjack", "T1", 2000000); err == nil {
+		t.Error("HoldBet() should refuse a bet above the balance")
+	}
+	if holds, _ := db.ListHolds(); len(holds) != 0 {
+		t.Errorf("ListHolds() = %d holds, want none", len(holds))
+	}
+}
@@ -0,0 +1,164 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// HouseStats is the house's running position for one game, in cents
+type HouseStats struct {
+	Game    string `json:"game"`
+	Rounds  int64  `json:"rounds"`
+	Wagered int64  `json:"wagered"`
+	Paid    int64  `json:"paid"`
+}
+
+// Net amount the house has kept
+func (h *HouseStats) Hold() int64 {
+	return h.Wagered - h.Paid
+}
+
+// Share of wagers the house kept, as a percentage
+func (h *HouseStats) HoldPercent() float64 {
+	if h.Wagered == 0 {
+		return 0
+	}
+	return float64(h.Hold()) / float64(h.Wagered) * 100
+}
+
+// Settles a finished round: credits the payout to the player, adds the round
+// to the house's (for registered players) and the player's per-game totals and
+// to the player's game history with the hands it ended on, and saves their
+// stats when given, in one transaction. Returns the player's balance.
+func (db *DB) SettleRound(userID int, game string, wagered, payout int64, result, hands string, stats *UserStats) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	balance, err := settleRoundTx(tx, userID, game, wagered, payout, result, hands, stats)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit settlement: %w", err)
+	}
+
+	return balance, nil
+}
+
+// Plays out a round decided in one go: takes the bet from the player's
+// balance and settles the round as SettleRound does, in one transaction, so
+// the bet, payout and stats can't be left out of step by a crash. Fails
+// without a trace when the balance can't cover the bet. Returns the balance.
+func (db *DB) SettleGame(userID int, game string, bet, payout int64, result, hands string, stats *UserStats) (int64, error) {
+	if bet <= 0 {
+		return 0, fmt.Errorf("invalid bet amount")
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := adjustGameBalanceTx(tx, userID, -bet, TxBet, game, ""); err != nil {
+		return 0, err
+	}
+	balance, err := settleRoundTx(tx, userID, game, bet, payout, result, hands, stats)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit settlement: %w", err)
+	}
+
+	return balance, nil
+}
+
+func settleRoundTx(tx *sql.Tx, userID int, game string, wagered, payout int64, result, hands string, stats *UserStats) (int64, error) {
+	var balance int64
+	var guest bool
+	if err := tx.QueryRow(`SELECT balance, is_guest FROM users WHERE id = ?`, userID).Scan(&balance, &guest); err != nil {
+		return 0, fmt.Errorf("failed to read user balance: %w", err)
+	}
+	if payout > 0 {
+		var err error
+		if balance, err = adjustGameBalanceTx(tx, userID, payout, TxPayout, game, ""); err != nil {
+			return 0, err
+		}
+	}
+
+	// Guests play for play money, which the house's totals leave out
+	if !guest {
+		if _, err := tx.Exec(`INSERT INTO house_stats (game, rounds, wagered, paid) VALUES (?, 1, ?, ?)
+			ON CONFLICT(game) DO UPDATE SET rounds = rounds + 1, wagered = wagered + excluded.wagered,
+			paid = paid + excluded.paid, updated_at = CURRENT_TIMESTAMP`, game, wagered, payout); err != nil {
+			return 0, fmt.Errorf("failed to update house stats: %w", err)
+		}
+		if err := recordHouseDayTx(tx, userID, game, wagered, payout); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := tx.Exec(`INSERT INTO user_games (user_id, game, rounds) VALUES (?, ?, 1)
+		ON CONFLICT(user_id, game) DO UPDATE SET rounds = rounds + 1`, userID, game); err != nil {
+		return 0, fmt.Errorf("failed to update user games: %w", err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO game_rounds (user_id, game, bet, payout, result, hands) VALUES (?, ?, ?, ?, ?, ?)`,
+		userID, game, wagered, payout, result, hands); err != nil {
+		return 0, fmt.Errorf("failed to record game history: %w", err)
+	}
+
+	if stats != nil {
+		if err := updateUserStats(tx, stats); err != nil {
+			return 0, err
+		}
+	}
+
+	return balance, nil
+}
+
+func (db *DB) ListHouseStats() ([]*HouseStats, error) {
+	rows, err := db.conn.Query(`SELECT game, rounds, wagered, paid FROM house_stats ORDER BY game`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list house stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []*HouseStats
+	for rows.Next() {
+		var h HouseStats
+		if err := rows.Scan(&h.Game, &h.Rounds, &h.Wagered, &h.Paid); err != nil {
+			return nil, fmt.Errorf("failed to scan house stats: %w", err)
+		}
+		stats = append(stats, &h)
+	}
+
+	return stats, rows.Err()
+}
+
+// Returns how many rounds the user has played of each game
+func (db *DB) GetUserGames(userID int) (map[string]int64, error) {
+	rows, err := db.conn.Query(`SELECT game, rounds FROM user_games WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user games: %w", err)
+	}
+	defer rows.Close()
+
+	games := make(map[string]int64)
+	for rows.Next() {
+		var game string
+		var rounds int64
+		if err := rows.Scan(&game, &rounds); err != nil {
+			return nil, fmt.Errorf("failed to scan user games: %w", err)
+		}
+		games[game] = rounds
+	}
+
+	return games, rows.Err()
+}
@@ -0,0 +1,157 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSettleRound(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("houseuser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	// A lost round and a won round at even money
+	if _, err := db.AdjustBalance(user.ID, -1000, TxBet); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	balance, err := db.SettleRound(user.ID, "blackjack", 1000, 0, "", "", nil)
+	if err != nil {
+		t.Fatalf("SettleRound() error = %v", err)
+	}
+	if balance != 999000 {
+		t.Errorf("SettleRound() balance = %d, want 999000", balance)
+	}
+
+	if _, err := db.AdjustBalance(user.ID, -500, TxBet); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	balance, err = db.SettleRound(user.ID, "blackjack", 500, 1000, "", "", nil)
+	if err != nil {
+		t.Fatalf("SettleRound() error = %v", err)
+	}
+	if balance != 999500 {
+		t.Errorf("SettleRound() balance = %d, want 999500", balance)
+	}
+
+	stats, err := db.ListHouseStats()
+	if err != nil {
+		t.Fatalf("ListHouseStats() error = %v", err)
+	}
+	if len(stats) != 1 {
+		t.Fatalf("ListHouseStats() returned %d games, want 1", len(stats))
+	}
+
+	h := stats[0]
+	if h.Game != "blackjack" || h.Rounds != 2 || h.Wagered != 1500 || h.Paid != 1000 {
+		t.Errorf("House stats = %+v, want 2 rounds, 1500 wagered, 1000 paid", h)
+	}
+	if h.Hold() != 500 {
+		t.Errorf("Hold() = %d, want 500", h.Hold())
+	}
+}
+
+func TestGetUserGames(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("gamesuser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	for _, game := range []string{"blackjack", "blackjack", "keno"} {
+		if _, err := db.SettleRound(user.ID, game, 0, 0, "", "", nil); err != nil {
+			t.Fatalf("SettleRound() error = %v", err)
+		}
+	}
+
+	games, err := db.GetUserGames(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserGames() error = %v", err)
+	}
+	if games["blackjack"] != 2 || games["keno"] != 1 {
+		t.Errorf("GetUserGames() = %v, want 2 blackjack and 1 keno", games)
+	}
+}
+
+func TestSettleRoundGuest(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	guest, err := db.CreateGuestUser("guest_house", 100000)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := db.AdjustBalance(guest.ID, -1000, TxBet); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	balance, err := db.SettleRound(guest.ID, "blackjack", 1000, 2500, "Blackjack", "", nil)
+	if err != nil {
+		t.Fatalf("SettleRound() error = %v", err)
+	}
+	if balance != 101500 {
+		t.Errorf("SettleRound() balance = %d, want 101500", balance)
+	}
+
+	if stats, _ := db.ListHouseStats(); len(stats) != 0 {
+		t.Errorf("ListHouseStats() = %d games after a guest's round, want none", len(stats))
+	}
+	if games, _ := db.dailyHouseStats(time.Now().UTC().Format("2006-01-02")); len(games) != 0 {
+		t.Errorf("Daily house stats have %d games after a guest's round, want none", len(games))
+	}
+	if rounds, _ := db.ListGameRounds(guest.ID, 10, 0); len(rounds) != 1 {
+		t.Errorf("ListGameRounds() = %d rounds, want the guest's round kept in its history", len(rounds))
+	}
+}
+
+func TestSettleGame(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("houseuser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	stats, err := db.GetUserStats(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserStats() error = %v", err)
+	}
+	stats.GamesPlayed, stats.GamesWon, stats.TotalBet, stats.TotalWon = 1, 1, 1000, 2500
+
+	balance, err := db.SettleGame(user.ID, "keno", 1000, 2500, "Win", "", stats)
+	if err != nil {
+		t.Fatalf("SettleGame() error = %v", err)
+	}
+	if balance != 1001500 {
+		t.Errorf("SettleGame() balance = %d, want 1001500", balance)
+	}
+
+	saved, err := db.GetUserStats(user.ID)
+	if err != nil || saved.GamesWon != 1 || saved.TotalWon != 2500 {
+		t.Errorf("GetUserStats() = %+v, %v, want the stats saved with the round", saved, err)
+	}
+	if rounds, err := db.ListGameRounds(user.ID, 10, 0); err != nil || len(rounds) != 1 || rounds[0].Payout != 2500 {
+		t.Errorf("ListGameRounds() = %+v, %v, want the round", rounds, err)
+	}
+	txs, err := db.ListTransactions(user.ID, 10)
+	if err != nil || len(txs) != 2 || txs[1].Amount != -1000 || txs[0].Amount != 2500 {
+		t.Errorf("ListTransactions() = %+v, %v, want the bet then the payout", txs, err)
+	}
+
+	// A bet the balance can't cover settles nothing
+	if _, err := db.SettleGame(user.ID, "keno", 2000000, 0, "Loss", "", nil); err == nil {
+		t.Error("SettleGame() should reject a bet larger than the balance")
+	}
+	if rounds, _ := db.ListGameRounds(user.ID, 10, 0); len(rounds) != 1 {
+		t.Errorf("ListGameRounds() = %d rounds after a rejected bet, want 1", len(rounds))
+	}
+	if house, _ := db.ListHouseStats(); len(house) != 1 || house[0].Rounds != 1 {
+		t.Errorf("ListHouseStats() = %+v, want only the settled round", house)
+	}
+}
@@ -0,0 +1,96 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type InviteCode struct {
+	Code      string       `json:"code"`
+	CreatedBy string       `json:"created_by"`
+	MaxUses   int          `json:"max_uses"`
+	Uses      int          `json:"uses"`
+	ExpiresAt sql.NullTime `json:"expires_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// Creates an invite code; a zero expiresAt means the code never expires
+func (db *DB) CreateInviteCode(code, createdBy string, maxUses int, expiresAt time.Time) (*InviteCode, error) {
+	var expiry interface{}
+	if !expiresAt.IsZero() {
+		expiry = sqlTime(expiresAt)
+	}
+
+	query := `INSERT INTO invite_codes (code, created_by, max_uses, expires_at) VALUES (?, ?, ?, ?)`
+	if _, err := db.conn.Exec(query, code, createdBy, maxUses, expiry); err != nil {
+		return nil, fmt.Errorf("failed to create invite code: %w", err)
+	}
+
+	return db.GetInviteCode(code)
+}
+
+func (db *DB) GetInviteCode(code string) (*InviteCode, error) {
+	query := `SELECT code, created_by, max_uses, uses, expires_at, created_at FROM invite_codes WHERE code = ?`
+	row := db.conn.QueryRow(query, code)
+
+	var invite InviteCode
+	err := row.Scan(&invite.Code, &invite.CreatedBy, &invite.MaxUses, &invite.Uses, &invite.ExpiresAt, &invite.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("invite code not found")
+		}
+		return nil, fmt.Errorf("failed to get invite code: %w", err)
+	}
+
+	return &invite, nil
+}
+
+func (db *DB) ListInviteCodes() ([]*InviteCode, error) {
+	query := `SELECT code, created_by, max_uses, uses, expires_at, created_at FROM invite_codes ORDER BY created_at DESC, code`
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invite codes: %w", err)
+	}
+	defer rows.Close()
+
+	var invites []*InviteCode
+	for rows.Next() {
+		var invite InviteCode
+		if err := rows.Scan(&invite.Code, &invite.CreatedBy, &invite.MaxUses, &invite.Uses, &invite.ExpiresAt, &invite.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan invite code: %w", err)
+		}
+		invites = append(invites, &invite)
+	}
+
+	return invites, rows.Err()
+}
+
+// Atomically consumes one use of a code if it is still valid
+func (db *DB) UseInviteCode(code string) error {
+	query := `UPDATE invite_codes SET uses = uses + 1
+			  WHERE code = ? AND uses < max_uses AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)`
+	result, err := db.conn.Exec(query, code)
+	if err != nil {
+		return fmt.Errorf("failed to use invite code: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to use invite code: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("invalid or expired invite code")
+	}
+
+	return nil
+}
+
+// Gives back a use taken by UseInviteCode when the signup fails afterwards
+func (db *DB) ReleaseInviteCode(code string) error {
+	query := `UPDATE invite_codes SET uses = uses - 1 WHERE code = ? AND uses > 0`
+	if _, err := db.conn.Exec(query, code); err != nil {
+		return fmt.Errorf("failed to release invite code: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInviteCodeUses(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	invite, err := db.CreateInviteCode("ABCD1234", "console", 2, time.Time{})
+	if err != nil {
+		t.Fatalf("CreateInviteCode() error = %v", err)
+	}
+	if invite.MaxUses != 2 || invite.Uses != 0 || invite.ExpiresAt.Valid {
+		t.Errorf("CreateInviteCode() = %+v, want 2 unused uses and no expiry", invite)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := db.UseInviteCode("ABCD1234"); err != nil {
+			t.Fatalf("UseInviteCode() use %d error = %v", i+1, err)
+		}
+	}
+
+	if err := db.UseInviteCode("ABCD1234"); err == nil {
+		t.Error("UseInviteCode() should fail once all uses are taken")
+	}
+
+	if err := db.ReleaseInviteCode("ABCD1234"); err != nil {
+		t.Fatalf("ReleaseInviteCode() error = %v", err)
+	}
+	if err := db.UseInviteCode("ABCD1234"); err != nil {
+		t.Errorf("UseInviteCode() after release error = %v", err)
+	}
+
+	if err := db.UseInviteCode("MISSING"); err == nil {
+		t.Error("UseInviteCode() should fail for unknown code")
+	}
+}
+
+func TestExpiredInviteCode(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if _, err := db.CreateInviteCode("OLDCODE", "console", 5, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("CreateInviteCode() error = %v", err)
+	}
+
+	if err := db.UseInviteCode("OLDCODE"); err == nil {
+		t.Error("UseInviteCode() should fail for an expired code")
+	}
+
+	invites, err := db.ListInviteCodes()
+	if err != nil {
+		t.Fatalf("ListInviteCodes() error = %v", err)
+	}
+	if len(invites) != 1 || !invites[0].ExpiresAt.Valid {
+		t.Errorf("ListInviteCodes() = %+v, want one expiring code", invites)
+	}
+}
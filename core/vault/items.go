@@ -0,0 +1,88 @@
+package vault
+
+import "fmt"
+
+// Buys an item with chips; each item can only be owned once
+func (db *DB) BuyItem(userID int, price int64, item string) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT OR IGNORE INTO user_items (user_id, item) VALUES (?, ?)`, userID, item)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add item: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return 0, fmt.Errorf("you already own this item")
+	}
+
+	balance, err := adjustBalanceTx(tx, userID, -price, TxPurchase, item)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit purchase: %w", err)
+	}
+
+	return balance, nil
+}
+
+func (db *DB) HasUserItem(userID int, item string) (bool, error) {
+	var count int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM user_items WHERE user_id = ? AND item = ?`, userID, item).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to read items: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Sets the item shown in a cosmetic slot
+func (db *DB) EquipItem(userID int, slot, item string) error {
+	_, err := db.conn.Exec(`INSERT INTO user_equipped (user_id, slot, item) VALUES (?, ?, ?)
+		ON CONFLICT(user_id, slot) DO UPDATE SET item = excluded.item`, userID, slot, item)
+	if err != nil {
+		return fmt.Errorf("failed to equip item: %w", err)
+	}
+	return nil
+}
+
+// Returns the equipped item per cosmetic slot; empty slots are left out
+func (db *DB) GetEquippedItems(userID int) (map[string]string, error) {
+	rows, err := db.conn.Query(`SELECT slot, item FROM user_equipped WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get equipped items: %w", err)
+	}
+	defer rows.Close()
+
+	equipped := make(map[string]string)
+	for rows.Next() {
+		var slot, item string
+		if err := rows.Scan(&slot, &item); err != nil {
+			return nil, fmt.Errorf("failed to scan equipped item: %w", err)
+		}
+		equipped[slot] = item
+	}
+
+	return equipped, rows.Err()
+}
+
+func (db *DB) ListUserItems(userID int) ([]string, error) {
+	rows, err := db.conn.Query(`SELECT item FROM user_items WHERE user_id = ? ORDER BY acquired_at, item`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []string
+	for rows.Next() {
+		var item string
+		if err := rows.Scan(&item); err != nil {
+			return nil, fmt.Errorf("failed to scan item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
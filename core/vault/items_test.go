@@ -0,0 +1,39 @@
+package vault
+
+import "testing"
+
+func TestBuyAndEquipItems(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("cosmeticuser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	balance, err := db.BuyItem(user.ID, 25000, "table_blue")
+	if err != nil || balance != 975000 {
+		t.Errorf("BuyItem() = %d, %v, want 975000", balance, err)
+	}
+	if _, err := db.BuyItem(user.ID, 25000, "table_blue"); err == nil {
+		t.Error("BuyItem() should refuse an item the user already owns")
+	}
+	if _, err := db.BuyItem(user.ID, 2000000, "card_back_gold"); err == nil {
+		t.Error("BuyItem() should fail without enough chips")
+	}
+	if owned, err := db.HasUserItem(user.ID, "card_back_gold"); err != nil || owned {
+		t.Errorf("HasUserItem() after a failed purchase = %v, %v, want false", owned, err)
+	}
+
+	if err := db.EquipItem(user.ID, "table_theme", "table_green"); err != nil {
+		t.Fatalf("EquipItem() error = %v", err)
+	}
+	if err := db.EquipItem(user.ID, "table_theme", "table_blue"); err != nil {
+		t.Fatalf("EquipItem() error = %v", err)
+	}
+
+	equipped, err := db.GetEquippedItems(user.ID)
+	if err != nil || len(equipped) != 1 || equipped["table_theme"] != "table_blue" {
+		t.Errorf("GetEquippedItems() = %v, %v, want table_theme=table_blue", equipped, err)
+	}
+}
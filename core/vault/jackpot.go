@@ -0,0 +1,87 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Returns a jackpot pool's current amount, or seed if nothing has been paid in yet
+func (db *DB) GetJackpot(pool string, seed int64) (int64, error) {
+	var amount int64
+	err := db.conn.QueryRow(`SELECT amount FROM jackpots WHERE pool = ?`, pool).Scan(&amount)
+	if err == sql.ErrNoRows {
+		return seed, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get jackpot: %w", err)
+	}
+	return amount, nil
+}
+
+// Adds to a jackpot pool, starting it at seed the first time, and returns the new amount
+func (db *DB) ContributeJackpot(pool string, amount, seed int64) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO jackpots (pool, amount) VALUES (?, ?)
+		ON CONFLICT(pool) DO UPDATE SET amount = amount + ?, updated_at = CURRENT_TIMESTAMP`,
+		pool, seed+amount, amount); err != nil {
+		return 0, fmt.Errorf("failed to contribute to jackpot: %w", err)
+	}
+
+	var total int64
+	if err := tx.QueryRow(`SELECT amount FROM jackpots WHERE pool = ?`, pool).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to get jackpot: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit jackpot: %w", err)
+	}
+
+	return total, nil
+}
+
+// Pays the whole pool to a user and resets it to seed in one transaction.
+// The payout is also booked against the house under the pool's name.
+func (db *DB) AwardJackpot(pool string, userID int, seed int64) (won, balance int64, err error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`SELECT amount FROM jackpots WHERE pool = ?`, pool).Scan(&won)
+	if err == sql.ErrNoRows {
+		won = seed
+	} else if err != nil {
+		return 0, 0, fmt.Errorf("failed to get jackpot: %w", err)
+	}
+
+	if balance, err = adjustBalanceTx(tx, userID, won, TxJackpot, pool); err != nil {
+		return 0, 0, err
+	}
+
+	if _, err := tx.Exec(`INSERT INTO jackpots (pool, amount) VALUES (?, ?)
+		ON CONFLICT(pool) DO UPDATE SET amount = excluded.amount, updated_at = CURRENT_TIMESTAMP`,
+		pool, seed); err != nil {
+		return 0, 0, fmt.Errorf("failed to reset jackpot: %w", err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO house_stats (game, rounds, wagered, paid) VALUES (?, 1, 0, ?)
+		ON CONFLICT(game) DO UPDATE SET rounds = rounds + 1, paid = paid + excluded.paid,
+		updated_at = CURRENT_TIMESTAMP`, "jackpot_"+pool, won); err != nil {
+		return 0, 0, fmt.Errorf("failed to update house stats: %w", err)
+	}
+	if err := recordHouseDayTx(tx, userID, "jackpot_"+pool, 0, won); err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit jackpot award: %w", err)
+	}
+
+	return won, balance, nil
+}
@@ -0,0 +1,48 @@
+package vault
+
+import "testing"
+
+func TestJackpotLifecycle(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("jackpotuser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if amount, err := db.GetJackpot("main", 100000); err != nil || amount != 100000 {
+		t.Errorf("GetJackpot() on a new pool = %d, %v, want the seed", amount, err)
+	}
+
+	if _, err := db.ContributeJackpot("main", 50, 100000); err != nil {
+		t.Fatalf("ContributeJackpot() error = %v", err)
+	}
+	total, err := db.ContributeJackpot("main", 150, 100000)
+	if err != nil {
+		t.Fatalf("ContributeJackpot() error = %v", err)
+	}
+	if total != 100200 {
+		t.Errorf("ContributeJackpot() total = %d, want 100200", total)
+	}
+
+	won, balance, err := db.AwardJackpot("main", user.ID, 100000)
+	if err != nil {
+		t.Fatalf("AwardJackpot() error = %v", err)
+	}
+	if won != 100200 || balance != 1100200 {
+		t.Errorf("AwardJackpot() = %d, %d, want 100200 leaving 1100200", won, balance)
+	}
+
+	if amount, err := db.GetJackpot("main", 100000); err != nil || amount != 100000 {
+		t.Errorf("GetJackpot() after award = %d, %v, want reset to seed", amount, err)
+	}
+
+	stats, err := db.ListHouseStats()
+	if err != nil {
+		t.Fatalf("ListHouseStats() error = %v", err)
+	}
+	if len(stats) != 1 || stats[0].Game != "jackpot_main" || stats[0].Paid != 100200 {
+		t.Errorf("ListHouseStats() = %+v, want the jackpot payout booked", stats)
+	}
+}
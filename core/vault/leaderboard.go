@@ -0,0 +1,98 @@
+package vault
+
+import "fmt"
+
+// Leaderboard categories
+const (
+	LeaderboardBalance    = "balance"
+	LeaderboardNet        = "net"
+	LeaderboardWinRate    = "winrate"
+	LeaderboardBiggestWin = "biggest"
+)
+
+// How each category ranks players, best first. Win rate only ranks players
+// with at least LeaderboardMinRounds rounds, so one lucky hand can't top it.
+var leaderboardOrder = map[string]string{
+	LeaderboardBalance:    `u.balance`,
+	LeaderboardNet:        `s.total_won - s.total_bet`,
+	LeaderboardWinRate:    `CAST(s.games_won AS REAL) / s.games_played`,
+	LeaderboardBiggestWin: `s.biggest_win`,
+}
+
+const LeaderboardMinRounds = 20
+
+// LeaderboardEntry is one player's place on a leaderboard with the figures
+// every category ranks by
+type LeaderboardEntry struct {
+	Rank        int    `json:"rank"`
+	UserID      int    `json:"user_id"`
+	Username    string `json:"username"`
+	Balance     int64  `json:"balance"`
+	Net         int64  `json:"net"` // Won less wagered, in cents
+	GamesPlayed int64  `json:"games_played"`
+	GamesWon    int64  `json:"games_won"`
+	BiggestWin  int64  `json:"biggest_win"`
+}
+
+// Share of rounds won, as a percentage
+func (e *LeaderboardEntry) WinRate() float64 {
+	if e.GamesPlayed == 0 {
+		return 0
+	}
+	return float64(e.GamesWon) / float64(e.GamesPlayed) * 100
+}
+
+// Players a category ranks: everyone but guests, and for win rate only those
+// with enough rounds
+func leaderboardFilter(category string) string {
+	filter := `u.is_guest = 0`
+	if category == LeaderboardWinRate {
+		filter += fmt.Sprintf(` AND s.games_played >= %d`, LeaderboardMinRounds)
+	}
+	return filter
+}
+
+// Lists players best first in a category, skipping the best offset. Ties go
+// to the older account.
+func (db *DB) Leaderboard(category string, limit, offset int) ([]*LeaderboardEntry, error) {
+	order, ok := leaderboardOrder[category]
+	if !ok {
+		return nil, fmt.Errorf("unknown leaderboard %q", category)
+	}
+
+	rows, err := db.conn.Query(`SELECT u.id, u.username, u.balance, s.total_won - s.total_bet, s.games_played, s.games_won, s.biggest_win
+		FROM users u JOIN user_stats s ON s.user_id = u.id
+		WHERE `+leaderboardFilter(category)+`
+		ORDER BY `+order+` DESC, u.id LIMIT ? OFFSET ?`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*LeaderboardEntry
+	for rows.Next() {
+		e := LeaderboardEntry{Rank: offset + len(entries) + 1}
+		if err := rows.Scan(&e.UserID, &e.Username, &e.Balance, &e.Net, &e.GamesPlayed, &e.GamesWon, &e.BiggestWin); err != nil {
+			return nil, fmt.Errorf("failed to scan leaderboard entry: %w", err)
+		}
+		entries = append(entries, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list leaderboard: %w", err)
+	}
+	return entries, nil
+}
+
+// Counts the players a category ranks
+func (db *DB) CountLeaderboard(category string) (int, error) {
+	if _, ok := leaderboardOrder[category]; !ok {
+		return 0, fmt.Errorf("unknown leaderboard %q", category)
+	}
+
+	var count int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM users u JOIN user_stats s ON s.user_id = u.id
+		WHERE ` + leaderboardFilter(category)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count leaderboard: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,78 @@
+package vault
+
+import "testing"
+
+func TestLeaderboard(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	players := []struct {
+		name                         string
+		balance                      int64
+		played, won, bet, paid, best int64
+	}{
+		{"steady", 1200000, 40, 30, 40000, 60000, 5000},
+		{"lucky", 900000, 1, 1, 1000, 100000, 99000},
+		{"rich", 5000000, 25, 5, 30000, 10000, 2000},
+	}
+	for _, p := range players {
+		user, err := db.CreateUser(p.name, "hashedpassword123")
+		if err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+		if err := db.UpdateUserBalance(user.ID, p.balance); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+		if err := db.UpdateUserStats(&UserStats{UserID: user.ID, GamesPlayed: p.played, GamesWon: p.won,
+			TotalBet: p.bet, TotalWon: p.paid, BiggestWin: p.best}); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+	}
+	if _, err := db.CreateGuestUser("guest-1", 90000000); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	for _, c := range []struct {
+		category string
+		want     []string
+	}{
+		{LeaderboardBalance, []string{"rich", "steady", "lucky"}},
+		{LeaderboardNet, []string{"lucky", "steady", "rich"}},
+		{LeaderboardWinRate, []string{"steady", "rich"}}, // lucky hasn't played enough
+		{LeaderboardBiggestWin, []string{"lucky", "steady", "rich"}},
+	} {
+		entries, err := db.Leaderboard(c.category, 10, 0)
+		if err != nil {
+			t.Fatalf("Leaderboard(%s) error = %v", c.category, err)
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Username)
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("Leaderboard(%s) = %v, want %v", c.category, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] || entries[i].Rank != i+1 {
+				t.Errorf("Leaderboard(%s) = %v, want %v", c.category, got, c.want)
+				break
+			}
+		}
+		if count, err := db.CountLeaderboard(c.category); err != nil || count != len(c.want) {
+			t.Errorf("CountLeaderboard(%s) = %d, %v, want %d", c.category, count, err, len(c.want))
+		}
+	}
+
+	page, err := db.Leaderboard(LeaderboardBalance, 1, 1)
+	if err != nil || len(page) != 1 || page[0].Username != "steady" || page[0].Rank != 2 {
+		t.Errorf("Leaderboard() second page = %+v, %v, want steady in second place", page, err)
+	}
+	if e := page[0]; e.Net != 20000 || e.WinRate() != 75 {
+		t.Errorf("Leaderboard() entry = %+v with win rate %v, want net 20000 at 75%%", e, e.WinRate())
+	}
+
+	if _, err := db.Leaderboard("luckiest", 10, 0); err == nil {
+		t.Error("Leaderboard() should reject an unknown category")
+	}
+}
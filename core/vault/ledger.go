@@ -0,0 +1,293 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Ledger transaction types
+const (
+	TxBet         = "bet"
+	TxPayout      = "payout"
+	TxRefund      = "refund"
+	TxAdjustment  = "adjustment"
+	TxBonus       = "bonus"
+	TxRebuy       = "rebuy"
+	TxTransferOut = "transfer_out"
+	TxTransferIn  = "transfer_in"
+	TxJackpot     = "jackpot"
+	TxRedeem      = "redeem"
+	TxPromotion   = "promotion"
+	TxReferral    = "referral"
+	TxPurchase    = "purchase"
+	TxDeposit     = "deposit"
+	TxWithdrawal  = "withdrawal"
+)
+
+type Transaction struct {
+	ID           int64     `json:"id"`
+	UserID       int       `json:"user_id"`
+	Type         string    `json:"type"`
+	Amount       int64     `json:"amount"` // Signed, in cents: negative for debits
+	BalanceAfter int64     `json:"balance_after"`
+	GameID       string    `json:"game_id"` // Game a bet, payout or refund belongs to
+	Metadata     string    `json:"metadata"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Formats a time the way SQLite's CURRENT_TIMESTAMP does so range filters compare correctly
+func sqlTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// AdjustBalance applies delta to a user's balance and records it in the ledger
+// in one transaction. Debits that would make the balance negative are rejected.
+func (db *DB) AdjustBalance(userID int, delta int64, txType string) (int64, error) {
+	return db.AdjustBalanceWithMetadata(userID, delta, txType, "")
+}
+
+// Like AdjustBalance but stores a free-form note (e.g. an operator's reason) with the entry
+func (db *DB) AdjustBalanceWithMetadata(userID int, delta int64, txType, metadata string) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	balance, err := adjustBalanceTx(tx, userID, delta, txType, metadata)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit balance adjustment: %w", err)
+	}
+
+	return balance, nil
+}
+
+func adjustBalanceTx(tx *sql.Tx, userID int, delta int64, txType, metadata string) (int64, error) {
+	return adjustGameBalanceTx(tx, userID, delta, txType, "", metadata)
+}
+
+// Like adjustBalanceTx for entries that belong to a round of a game
+func adjustGameBalanceTx(tx *sql.Tx, userID int, delta int64, txType, gameID, metadata string) (int64, error) {
+	result, err := tx.Exec(`UPDATE users SET balance = balance + ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND balance + ? >= 0`, delta, userID, delta)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update user balance: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to update user balance: %w", err)
+	}
+	if affected == 0 {
+		return 0, fmt.Errorf("insufficient balance")
+	}
+
+	var balance int64
+	if err := tx.QueryRow(`SELECT balance FROM users WHERE id = ?`, userID).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("failed to read user balance: %w", err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO transactions (user_id, type, amount, balance_after, game_id, metadata) VALUES (?, ?, ?, ?, ?, ?)`,
+		userID, txType, delta, balance, gameID, metadata); err != nil {
+		return 0, fmt.Errorf("failed to record transaction: %w", err)
+	}
+
+	return balance, nil
+}
+
+// Sums ledger amounts of the given types for a user since a point in time
+func (db *DB) SumTransactions(userID int, since time.Time, types ...string) (int64, error) {
+	return db.SumTransactionsBetween(userID, since, time.Time{}, types...)
+}
+
+// Like SumTransactions but only counts entries before until; a zero until has no upper bound
+func (db *DB) SumTransactionsBetween(userID int, since, until time.Time, types ...string) (int64, error) {
+	query := `SELECT COALESCE(SUM(amount), 0) FROM transactions WHERE user_id = ? AND created_at >= ?`
+	args := []interface{}{userID, sqlTime(since)}
+	if !until.IsZero() {
+		query += ` AND created_at < ?`
+		args = append(args, sqlTime(until))
+	}
+	if len(types) > 0 {
+		query += ` AND type IN (?` + repeatPlaceholders(len(types)-1) + `)`
+		for _, t := range types {
+			args = append(args, t)
+		}
+	}
+
+	var sum int64
+	if err := db.conn.QueryRow(query, args...).Scan(&sum); err != nil {
+		return 0, fmt.Errorf("failed to sum transactions: %w", err)
+	}
+
+	return sum, nil
+}
+
+// DailyNet is the sum of a user's ledger entries on one UTC day, in cents
+type DailyNet struct {
+	Day string `json:"day"` // YYYY-MM-DD
+	Net int64  `json:"net"`
+}
+
+// Sums ledger amounts of the given types per UTC day since a point in time,
+// oldest first; days without entries are omitted
+func (db *DB) SumTransactionsByDay(userID int, since time.Time, types ...string) ([]*DailyNet, error) {
+	query := `SELECT date(created_at) AS day, SUM(amount) FROM transactions WHERE user_id = ? AND created_at >= ?`
+	args := []interface{}{userID, sqlTime(since)}
+	if len(types) > 0 {
+		query += ` AND type IN (?` + repeatPlaceholders(len(types)-1) + `)`
+		for _, t := range types {
+			args = append(args, t)
+		}
+	}
+	query += ` GROUP BY day ORDER BY day`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum transactions by day: %w", err)
+	}
+	defer rows.Close()
+
+	var days []*DailyNet
+	for rows.Next() {
+		var d DailyNet
+		if err := rows.Scan(&d.Day, &d.Net); err != nil {
+			return nil, fmt.Errorf("failed to scan daily sum: %w", err)
+		}
+		days = append(days, &d)
+	}
+
+	return days, rows.Err()
+}
+
+const transactionColumns = `id, user_id, type, amount, balance_after, game_id, metadata, created_at`
+
+func (db *DB) ListTransactions(userID int, limit int) ([]*Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions
+			  WHERE user_id = ? ORDER BY id DESC LIMIT ?`
+	rows, err := db.conn.Query(query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var txs []*Transaction
+	for rows.Next() {
+		var t Transaction
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Type, &t.Amount, &t.BalanceAfter, &t.GameID, &t.Metadata, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		txs = append(txs, &t)
+	}
+
+	return txs, rows.Err()
+}
+
+// Lists ledger entries made in [since, until), oldest first, for one user or
+// every user when userID is 0. A positive limit caps how many are returned.
+func (db *DB) TransactionsBetween(userID int, since, until time.Time, limit int) ([]*Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions
+			  WHERE created_at >= ? AND created_at < ?`
+	args := []interface{}{sqlTime(since), sqlTime(until)}
+	if userID != 0 {
+		query += ` AND user_id = ?`
+		args = append(args, userID)
+	}
+	query += ` ORDER BY id`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var txs []*Transaction
+	for rows.Next() {
+		var t Transaction
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Type, &t.Amount, &t.BalanceAfter, &t.GameID, &t.Metadata, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		txs = append(txs, &t)
+	}
+
+	return txs, rows.Err()
+}
+
+// Lists the ledger entries of one user, or of every user when userID is 0,
+// whose balance after doesn't follow from the entry before them: a sign the
+// balance was changed outside the ledger in between. Oldest first.
+func (db *DB) LedgerBreaks(userID int) ([]*Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM (SELECT *, LAG(balance_after) OVER (PARTITION BY user_id ORDER BY id) AS before
+			  FROM transactions`
+	var args []interface{}
+	if userID != 0 {
+		query += ` WHERE user_id = ?`
+		args = append(args, userID)
+	}
+	query += `) WHERE before IS NOT NULL AND before + amount != balance_after ORDER BY id`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to audit ledger: %w", err)
+	}
+	defer rows.Close()
+
+	var txs []*Transaction
+	for rows.Next() {
+		var t Transaction
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Type, &t.Amount, &t.BalanceAfter, &t.GameID, &t.Metadata, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		txs = append(txs, &t)
+	}
+
+	return txs, rows.Err()
+}
+
+// BalanceMismatch is an account whose balance isn't what its ledger says
+type BalanceMismatch struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Balance  int64  `json:"balance"`
+	Ledger   int64  `json:"ledger"` // Balance after the latest ledger entry
+}
+
+// Lists accounts whose balance differs from the balance their latest ledger
+// entry left; accounts without entries are skipped
+func (db *DB) BalanceMismatches() ([]*BalanceMismatch, error) {
+	rows, err := db.conn.Query(`SELECT u.id, u.username, u.balance, t.balance_after FROM users u
+		JOIN transactions t ON t.id = (SELECT MAX(id) FROM transactions WHERE user_id = u.id)
+		WHERE u.balance != t.balance_after ORDER BY u.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile balances: %w", err)
+	}
+	defer rows.Close()
+
+	var mismatches []*BalanceMismatch
+	for rows.Next() {
+		var m BalanceMismatch
+		if err := rows.Scan(&m.UserID, &m.Username, &m.Balance, &m.Ledger); err != nil {
+			return nil, fmt.Errorf("failed to scan balance mismatch: %w", err)
+		}
+		mismatches = append(mismatches, &m)
+	}
+
+	return mismatches, rows.Err()
+}
+
+func repeatPlaceholders(n int) string {
+	s := ""
+	for i := 0; i < n; i++ {
+		s += ", ?"
+	}
+	return s
+}
@@ -0,0 +1,324 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdjustBalance(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("ledgeruser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	balance, err := db.AdjustBalance(user.ID, -5000, TxBet)
+	if err != nil {
+		t.Fatalf("AdjustBalance() error = %v", err)
+	}
+	if balance != 995000 {
+		t.Errorf("AdjustBalance() balance = %d, want 995000", balance)
+	}
+
+	balance, err = db.AdjustBalance(user.ID, 10000, TxPayout)
+	if err != nil {
+		t.Fatalf("AdjustBalance() error = %v", err)
+	}
+	if balance != 1005000 {
+		t.Errorf("AdjustBalance() balance = %d, want 1005000", balance)
+	}
+
+	// Overdrawing must fail and leave no trace in the ledger
+	if _, err := db.AdjustBalance(user.ID, -2000000, TxBet); err == nil {
+		t.Error("AdjustBalance() should reject a debit larger than the balance")
+	}
+
+	txs, err := db.ListTransactions(user.ID, 10)
+	if err != nil {
+		t.Fatalf("ListTransactions() error = %v", err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("ListTransactions() returned %d rows, want 2", len(txs))
+	}
+	if txs[0].Type != TxPayout || txs[0].Amount != 10000 || txs[0].BalanceAfter != 1005000 {
+		t.Errorf("Latest transaction = %+v, want payout of 10000 leaving 1005000", txs[0])
+	}
+
+	stored, err := db.GetUserByID(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if stored.Balance != 1005000 {
+		t.Errorf("Stored balance = %d, want 1005000", stored.Balance)
+	}
+}
+
+func TestSumTransactions(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("ledgeruser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	for _, adj := range []struct {
+		delta  int64
+		txType string
+	}{{-1000, TxBet}, {-2000, TxBet}, {1500, TxPayout}, {500, TxRefund}} {
+		if _, err := db.AdjustBalance(user.ID, adj.delta, adj.txType); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+	}
+
+	since := time.Now().Add(-time.Hour)
+
+	bets, err := db.SumTransactions(user.ID, since, TxBet)
+	if err != nil {
+		t.Fatalf("SumTransactions() error = %v", err)
+	}
+	if bets != -3000 {
+		t.Errorf("SumTransactions(bet) = %d, want -3000", bets)
+	}
+
+	all, err := db.SumTransactions(user.ID, since)
+	if err != nil {
+		t.Fatalf("SumTransactions() error = %v", err)
+	}
+	if all != -1000 {
+		t.Errorf("SumTransactions(all) = %d, want -1000", all)
+	}
+
+	future, err := db.SumTransactions(user.ID, time.Now().Add(time.Hour), TxBet)
+	if err != nil {
+		t.Fatalf("SumTransactions() error = %v", err)
+	}
+	if future != 0 {
+		t.Errorf("SumTransactions(future) = %d, want 0", future)
+	}
+}
+
+func TestTransactionsBetween(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("exportledger", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	other, err := db.CreateUser("otherledger", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	for _, id := range []int{user.ID, other.ID, user.ID} {
+		if _, err := db.AdjustBalance(id, -1000, TxBet); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+	}
+
+	since, until := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	all, err := db.TransactionsBetween(0, since, until, 0)
+	if err != nil || len(all) != 3 {
+		t.Fatalf("TransactionsBetween(all users) = %d entries, %v, want 3", len(all), err)
+	}
+	if all[0].UserID != user.ID || all[1].UserID != other.ID {
+		t.Errorf("TransactionsBetween() = %+v, want oldest first", all)
+	}
+
+	mine, err := db.TransactionsBetween(user.ID, since, until, 0)
+	if err != nil || len(mine) != 2 || mine[1].BalanceAfter != 998000 {
+		t.Errorf("TransactionsBetween(user) = %+v, %v, want two bets", mine, err)
+	}
+
+	if capped, err := db.TransactionsBetween(0, since, until, 2); err != nil || len(capped) != 2 {
+		t.Errorf("TransactionsBetween(limit 2) = %d entries, %v, want 2", len(capped), err)
+	}
+}
+
+func TestBalanceMismatches(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("reconciled", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := db.CreateUser("noledger", "hashedpassword123"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := db.AdjustBalance(user.ID, -1000, TxBet); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if mismatches, err := db.BalanceMismatches(); err != nil || len(mismatches) != 0 {
+		t.Fatalf("BalanceMismatches() = %+v, %v, want none", mismatches, err)
+	}
+
+	// A balance changed behind the ledger's back
+	if err := db.UpdateUserBalance(user.ID, 5000); err != nil {
+		t.Fatalf("UpdateUserBalance() error = %v", err)
+	}
+	mismatches, err := db.BalanceMismatches()
+	if err != nil || len(mismatches) != 1 {
+		t.Fatalf("BalanceMismatches() = %+v, %v, want one", mismatches, err)
+	}
+	if m := mismatches[0]; m.UserID != user.ID || m.Balance != 5000 || m.Ledger != 999000 {
+		t.Errorf("BalanceMismatches() = %+v, want balance 5000 against ledger 999000", m)
+	}
+}
+
+func TestAdjustBalanceWithMetadata(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("ledgeruser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := db.AdjustBalanceWithMetadata(user.ID, 2500, TxAdjustment, "console: goodwill credit"); err != nil {
+		t.Fatalf("AdjustBalanceWithMetadata() error = %v", err)
+	}
+
+	txs, err := db.ListTransactions(user.ID, 1)
+	if err != nil {
+		t.Fatalf("ListTransactions() error = %v", err)
+	}
+	if len(txs) != 1 || txs[0].Type != TxAdjustment || txs[0].Metadata != "console: goodwill credit" {
+		t.Errorf("ListTransactions() = %+v, want adjustment with note", txs)
+	}
+}
+
+func TestSumTransactionsByDay(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("ledgeruser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	for _, entry := range []struct {
+		delta  int64
+		txType string
+	}{
+		{-1000, TxBet},
+		{2000, TxPayout},
+		{-500, TxBet},
+		{10000, TxBonus},
+	} {
+		if _, err := db.AdjustBalance(user.ID, entry.delta, entry.txType); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+	}
+
+	// Move the first bet to yesterday
+	yesterday := time.Now().UTC().AddDate(0, 0, -1)
+	if _, err := db.conn.Exec(`UPDATE transactions SET created_at = ? WHERE id = (SELECT MIN(id) FROM transactions WHERE user_id = ?)`,
+		sqlTime(yesterday), user.ID); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	days, err := db.SumTransactionsByDay(user.ID, time.Now().AddDate(0, 0, -7), TxBet, TxPayout)
+	if err != nil {
+		t.Fatalf("SumTransactionsByDay() error = %v", err)
+	}
+	if len(days) != 2 {
+		t.Fatalf("SumTransactionsByDay() returned %d days, want 2", len(days))
+	}
+	if days[0].Day != yesterday.Format("2006-01-02") || days[0].Net != -1000 {
+		t.Errorf("First day = %+v, want %s with -1000", days[0], yesterday.Format("2006-01-02"))
+	}
+	if days[1].Net != 1500 {
+		t.Errorf("Second day net = %d, want 1500 (bonus excluded)", days[1].Net)
+	}
+
+	recent, err := db.SumTransactionsByDay(user.ID, time.Now().Add(-time.Hour), TxBet, TxPayout)
+	if err != nil {
+		t.Fatalf("SumTransactionsByDay() error = %v", err)
+	}
+	if len(recent) != 1 {
+		t.Errorf("SumTransactionsByDay(last hour) returned %d days, want 1", len(recent))
+	}
+}
+
+func TestGameEntriesCarryTheirGame(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("ledgeruser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	hold, _, err := db.HoldBet(user.ID, "keno", "main", 1000)
+	if err != nil {
+		t.Fatalf("HoldBet() error = %v", err)
+	}
+	if _, err := db.AdjustHold(hold, 500); err != nil {
+		t.Fatalf("AdjustHold() error = %v", err)
+	}
+	if _, err := db.SettleHold(hold, 3000, "Win", "", nil); err != nil {
+		t.Fatalf("SettleHold() error = %v", err)
+	}
+	if _, err := db.AdjustBalance(user.ID, 100, TxBonus); err != nil {
+		t.Fatalf("AdjustBalance() error = %v", err)
+	}
+
+	txs, err := db.ListTransactions(user.ID, 10)
+	if err != nil || len(txs) != 4 {
+		t.Fatalf("ListTransactions() = %d entries, %v, want 4", len(txs), err)
+	}
+	if txs[0].GameID != "" {
+		t.Errorf("Bonus entry game = %q, want none", txs[0].GameID)
+	}
+	for _, tx := range txs[1:] {
+		if tx.GameID != "keno" {
+			t.Errorf("%s entry game = %q, want keno", tx.Type, tx.GameID)
+		}
+	}
+}
+
+func TestLedgerBreaks(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("ledgeruser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	other, err := db.CreateUser("otheruser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	for _, id := range []int{user.ID, other.ID, user.ID} {
+		if _, err := db.AdjustBalance(id, -1000, TxBet); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+	}
+
+	if breaks, err := db.LedgerBreaks(0); err != nil || len(breaks) != 0 {
+		t.Fatalf("LedgerBreaks() = %+v, %v, want none", breaks, err)
+	}
+
+	// A balance changed behind the ledger's back breaks the entry after it
+	if err := db.UpdateUserBalance(user.ID, 5000); err != nil {
+		t.Fatalf("UpdateUserBalance() error = %v", err)
+	}
+	if _, err := db.AdjustBalance(user.ID, 2000, TxPayout); err != nil {
+		t.Fatalf("AdjustBalance() error = %v", err)
+	}
+
+	breaks, err := db.LedgerBreaks(user.ID)
+	if err != nil || len(breaks) != 1 {
+		t.Fatalf("LedgerBreaks(user) = %+v, %v, want one", breaks, err)
+	}
+	if b := breaks[0]; b.Type != TxPayout || b.BalanceAfter != 7000 {
+		t.Errorf("LedgerBreaks() = %+v, want the payout leaving 7000", b)
+	}
+	if breaks, err := db.LedgerBreaks(other.ID); err != nil || len(breaks) != 0 {
+		t.Errorf("LedgerBreaks(other) = %+v, %v, want none", breaks, err)
+	}
+}
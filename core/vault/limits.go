@@ -0,0 +1,41 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// UserLimits holds responsible-gaming limits in cents; zero means no limit
+type UserLimits struct {
+	UserID      int   `json:"user_id"`
+	DailyLoss   int64 `json:"daily_loss"`
+	WeeklyLoss  int64 `json:"weekly_loss"`
+	DailyWager  int64 `json:"daily_wager"`
+	WeeklyWager int64 `json:"weekly_wager"`
+}
+
+func (db *DB) GetUserLimits(userID int) (*UserLimits, error) {
+	query := `SELECT user_id, daily_loss, weekly_loss, daily_wager, weekly_wager FROM user_limits WHERE user_id = ?`
+	row := db.conn.QueryRow(query, userID)
+
+	limits := UserLimits{UserID: userID}
+	err := row.Scan(&limits.UserID, &limits.DailyLoss, &limits.WeeklyLoss, &limits.DailyWager, &limits.WeeklyWager)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get user limits: %w", err)
+	}
+
+	return &limits, nil
+}
+
+func (db *DB) SetUserLimits(limits *UserLimits) error {
+	query := `INSERT INTO user_limits (user_id, daily_loss, weekly_loss, daily_wager, weekly_wager)
+			  VALUES (?, ?, ?, ?, ?)
+			  ON CONFLICT(user_id) DO UPDATE SET
+			  daily_loss = excluded.daily_loss, weekly_loss = excluded.weekly_loss,
+			  daily_wager = excluded.daily_wager, weekly_wager = excluded.weekly_wager`
+	_, err := db.conn.Exec(query, limits.UserID, limits.DailyLoss, limits.WeeklyLoss, limits.DailyWager, limits.WeeklyWager)
+	if err != nil {
+		return fmt.Errorf("failed to set user limits: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package vault
+
+import "testing"
+
+func TestUserLimits(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("limituser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	limits, err := db.GetUserLimits(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserLimits() error = %v", err)
+	}
+	if *limits != (UserLimits{UserID: user.ID}) {
+		t.Errorf("GetUserLimits() = %+v, want no limits", limits)
+	}
+
+	limits.DailyLoss = 5000
+	limits.WeeklyWager = 100000
+	if err := db.SetUserLimits(limits); err != nil {
+		t.Fatalf("SetUserLimits() error = %v", err)
+	}
+
+	limits.DailyLoss = 2500
+	if err := db.SetUserLimits(limits); err != nil {
+		t.Fatalf("SetUserLimits() update error = %v", err)
+	}
+
+	stored, err := db.GetUserLimits(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserLimits() error = %v", err)
+	}
+	if stored.DailyLoss != 2500 || stored.WeeklyWager != 100000 {
+		t.Errorf("GetUserLimits() = %+v, want daily loss 2500 and weekly wager 100000", stored)
+	}
+}
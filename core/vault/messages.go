@@ -0,0 +1,179 @@
+package vault
+
+import (
+	"fmt"
+	"time"
+)
+
+// DirectMessage is a message held for a player who was offline when it was sent
+type DirectMessage struct {
+	ID          int64     `json:"id"`
+	SenderID    int       `json:"sender_id"`
+	SenderName  string    `json:"sender_name"`
+	RecipientID int       `json:"recipient_id"`
+	Body        string    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Stores a message until the recipient next logs in
+func (db *DB) StoreMessage(senderID, recipientID int, body string) error {
+	query := `INSERT INTO direct_messages (sender_id, recipient_id, body) VALUES (?, ?, ?)`
+	if _, err := db.conn.Exec(query, senderID, recipientID, body); err != nil {
+		return fmt.Errorf("failed to store message: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) CountStoredMessages(recipientID int) (int, error) {
+	var count int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM direct_messages WHERE recipient_id = ?`, recipientID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count messages: %w", err)
+	}
+	return count, nil
+}
+
+// Returns the messages stored for a user, oldest first, and deletes them
+func (db *DB) TakeStoredMessages(recipientID int) ([]*DirectMessage, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT m.id, m.sender_id, u.username, m.recipient_id, m.body, m.created_at
+		FROM direct_messages m JOIN users u ON u.id = m.sender_id
+		WHERE m.recipient_id = ? ORDER BY m.id`, recipientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*DirectMessage
+	for rows.Next() {
+		var m DirectMessage
+		if err := rows.Scan(&m.ID, &m.SenderID, &m.SenderName, &m.RecipientID, &m.Body, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	rows.Close()
+
+	if _, err := tx.Exec(`DELETE FROM direct_messages WHERE recipient_id = ?`, recipientID); err != nil {
+		return nil, fmt.Errorf("failed to delete messages: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+func (db *DB) BlockUser(userID, blockedID int) error {
+	query := `INSERT OR IGNORE INTO user_blocks (user_id, blocked_id) VALUES (?, ?)`
+	if _, err := db.conn.Exec(query, userID, blockedID); err != nil {
+		return fmt.Errorf("failed to block user: %w", err)
+	}
+	return nil
+}
+
+// Removes a block; reports whether there was one
+func (db *DB) UnblockUser(userID, blockedID int) (bool, error) {
+	result, err := db.conn.Exec(`DELETE FROM user_blocks WHERE user_id = ? AND blocked_id = ?`, userID, blockedID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unblock user: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to unblock user: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// Reports whether userID has blocked blockedID
+func (db *DB) IsBlocked(userID, blockedID int) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM user_blocks WHERE user_id = ? AND blocked_id = ?`
+	if err := db.conn.QueryRow(query, userID, blockedID).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check block: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Lists the usernames a user has blocked, alphabetically
+func (db *DB) ListBlockedUsers(userID int) ([]string, error) {
+	return db.listUsernames(`SELECT u.username FROM user_blocks b JOIN users u ON u.id = b.blocked_id
+		WHERE b.user_id = ? ORDER BY u.username`, userID)
+}
+
+// Hides another user's chat from userID without blocking their messages
+func (db *DB) MuteUser(userID, mutedID int) error {
+	query := `INSERT OR IGNORE INTO user_mutes (user_id, muted_id) VALUES (?, ?)`
+	if _, err := db.conn.Exec(query, userID, mutedID); err != nil {
+		return fmt.Errorf("failed to mute user: %w", err)
+	}
+	return nil
+}
+
+// Removes a mute; reports whether there was one
+func (db *DB) UnmuteUser(userID, mutedID int) (bool, error) {
+	result, err := db.conn.Exec(`DELETE FROM user_mutes WHERE user_id = ? AND muted_id = ?`, userID, mutedID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unmute user: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to unmute user: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// Lists the usernames a user has muted, alphabetically
+func (db *DB) ListMutedUsers(userID int) ([]string, error) {
+	return db.listUsernames(`SELECT u.username FROM user_mutes m JOIN users u ON u.id = m.muted_id
+		WHERE m.user_id = ? ORDER BY u.username`, userID)
+}
+
+// Returns the IDs of users who have blocked or muted userID
+func (db *DB) ListUsersIgnoring(userID int) ([]int, error) {
+	rows, err := db.conn.Query(`SELECT user_id FROM user_blocks WHERE blocked_id = ?
+		UNION SELECT user_id FROM user_mutes WHERE muted_id = ?`, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ignoring users: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan ignoring user: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (db *DB) listUsernames(query string, args ...interface{}) ([]string, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
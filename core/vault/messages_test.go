@@ -0,0 +1,105 @@
+package vault
+
+import "testing"
+
+func TestStoredMessages(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	alice, _ := db.CreateUser("alice", "hash")
+	bob, _ := db.CreateUser("bob", "hash")
+
+	for _, body := range []string{"first", "second"} {
+		if err := db.StoreMessage(alice.ID, bob.ID, body); err != nil {
+			t.Fatalf("StoreMessage() error = %v", err)
+		}
+	}
+	if count, err := db.CountStoredMessages(bob.ID); err != nil || count != 2 {
+		t.Errorf("CountStoredMessages() = %d, %v, want 2", count, err)
+	}
+
+	messages, err := db.TakeStoredMessages(bob.ID)
+	if err != nil {
+		t.Fatalf("TakeStoredMessages() error = %v", err)
+	}
+	if len(messages) != 2 || messages[0].Body != "first" || messages[0].SenderName != "alice" {
+		t.Errorf("TakeStoredMessages() = %+v, want alice's two messages in order", messages)
+	}
+
+	// Taking messages removes them
+	if messages, err := db.TakeStoredMessages(bob.ID); err != nil || len(messages) != 0 {
+		t.Errorf("TakeStoredMessages() again = %+v, %v, want none", messages, err)
+	}
+}
+
+func TestUserBlocks(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	alice, _ := db.CreateUser("alice", "hash")
+	bob, _ := db.CreateUser("bob", "hash")
+
+	if err := db.BlockUser(alice.ID, bob.ID); err != nil {
+		t.Fatalf("BlockUser() error = %v", err)
+	}
+	// Blocking twice is harmless
+	if err := db.BlockUser(alice.ID, bob.ID); err != nil {
+		t.Fatalf("BlockUser() again error = %v", err)
+	}
+
+	if blocked, err := db.IsBlocked(alice.ID, bob.ID); err != nil || !blocked {
+		t.Errorf("IsBlocked(alice, bob) = %v, %v, want true", blocked, err)
+	}
+	if blocked, err := db.IsBlocked(bob.ID, alice.ID); err != nil || blocked {
+		t.Errorf("IsBlocked(bob, alice) = %v, %v, want false", blocked, err)
+	}
+	if names, err := db.ListBlockedUsers(alice.ID); err != nil || len(names) != 1 || names[0] != "bob" {
+		t.Errorf("ListBlockedUsers() = %v, %v, want [bob]", names, err)
+	}
+
+	if removed, err := db.UnblockUser(alice.ID, bob.ID); err != nil || !removed {
+		t.Errorf("UnblockUser() = %v, %v, want true", removed, err)
+	}
+	if removed, err := db.UnblockUser(alice.ID, bob.ID); err != nil || removed {
+		t.Errorf("UnblockUser() again = %v, %v, want false", removed, err)
+	}
+}
+
+func TestUserMutes(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	alice, _ := db.CreateUser("alice", "hash")
+	bob, _ := db.CreateUser("bob", "hash")
+	carol, _ := db.CreateUser("carol", "hash")
+
+	if err := db.MuteUser(alice.ID, carol.ID); err != nil {
+		t.Fatalf("MuteUser() error = %v", err)
+	}
+	if err := db.BlockUser(bob.ID, carol.ID); err != nil {
+		t.Fatalf("BlockUser() error = %v", err)
+	}
+	// Muting and blocking the same player lists them once
+	if err := db.MuteUser(bob.ID, carol.ID); err != nil {
+		t.Fatalf("MuteUser() error = %v", err)
+	}
+
+	if names, err := db.ListMutedUsers(alice.ID); err != nil || len(names) != 1 || names[0] != "carol" {
+		t.Errorf("ListMutedUsers() = %v, %v, want [carol]", names, err)
+	}
+
+	ids, err := db.ListUsersIgnoring(carol.ID)
+	if err != nil || len(ids) != 2 {
+		t.Errorf("ListUsersIgnoring(carol) = %v, %v, want alice and bob", ids, err)
+	}
+	if ids, err := db.ListUsersIgnoring(alice.ID); err != nil || len(ids) != 0 {
+		t.Errorf("ListUsersIgnoring(alice) = %v, %v, want none", ids, err)
+	}
+
+	if removed, err := db.UnmuteUser(alice.ID, carol.ID); err != nil || !removed {
+		t.Errorf("UnmuteUser() = %v, %v, want true", removed, err)
+	}
+	if removed, err := db.UnmuteUser(alice.ID, carol.ID); err != nil || removed {
+		t.Errorf("UnmuteUser() again = %v, %v, want false", removed, err)
+	}
+}
@@ -0,0 +1,550 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// A versioned schema change. up moves the database to version from the one
+// before and down takes it back; a change without down can't be rolled back.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+	down    func(tx *sql.Tx) error
+}
+
+// Every schema change, oldest first. New changes go on the end with the next
+// version; one that has shipped is never edited, since databases that ran it
+// won't run it again.
+var migrations = []migration{
+	{1, "initial schema", createTables, nil},
+	{2, "columns added to the initial schema", addLateColumns, dropLateColumns},
+	{3, "purged guest accounts", addPurgedAt, dropPurgedAt},
+}
+
+// Tables and indexes as they stood before versioned migrations. They're
+// created only if missing, so databases from before then adopt version 1
+// as they are.
+var initialSchema = []string{
+	`CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username TEXT UNIQUE NOT NULL,
+		password TEXT NOT NULL,
+		balance INTEGER NOT NULL DEFAULT 1000000,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS sessions (
+		id TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		expires_at DATETIME NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS user_stats (
+		user_id INTEGER PRIMARY KEY,
+		games_played INTEGER DEFAULT 0,
+		games_won INTEGER DEFAULT 0,
+		games_lost INTEGER DEFAULT 0,
+		total_bet INTEGER DEFAULT 0,
+		total_won INTEGER DEFAULT 0,
+		biggest_win INTEGER DEFAULT 0,
+		biggest_loss INTEGER DEFAULT 0,
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS revoked_tokens (
+		id TEXT PRIMARY KEY,
+		expires_at DATETIME NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS api_keys (
+		id TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		key_hash TEXT NOT NULL,
+		scope TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		last_used_at DATETIME,
+		revoked_at DATETIME,
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS transactions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		amount INTEGER NOT NULL,
+		balance_after INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS user_limits (
+		user_id INTEGER PRIMARY KEY,
+		daily_loss INTEGER NOT NULL DEFAULT 0,
+		weekly_loss INTEGER NOT NULL DEFAULT 0,
+		daily_wager INTEGER NOT NULL DEFAULT 0,
+		weekly_wager INTEGER NOT NULL DEFAULT 0,
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS user_achievements (
+		user_id INTEGER NOT NULL,
+		achievement TEXT NOT NULL,
+		unlocked_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, achievement),
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS user_games (
+		user_id INTEGER NOT NULL,
+		game TEXT NOT NULL,
+		rounds INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (user_id, game),
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS point_transactions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		amount INTEGER NOT NULL,
+		balance_after INTEGER NOT NULL,
+		metadata TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS user_items (
+		user_id INTEGER NOT NULL,
+		item TEXT NOT NULL,
+		acquired_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, item),
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS user_equipped (
+		user_id INTEGER NOT NULL,
+		slot TEXT NOT NULL,
+		item TEXT NOT NULL,
+		PRIMARY KEY (user_id, slot),
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS jackpots (
+		pool TEXT PRIMARY KEY,
+		amount INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS house_stats (
+		game TEXT PRIMARY KEY,
+		rounds INTEGER NOT NULL DEFAULT 0,
+		wagered INTEGER NOT NULL DEFAULT 0,
+		paid INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS house_daily (
+		day TEXT NOT NULL,
+		game TEXT NOT NULL,
+		rounds INTEGER NOT NULL DEFAULT 0,
+		wagered INTEGER NOT NULL DEFAULT 0,
+		paid INTEGER NOT NULL DEFAULT 0,
+		biggest_win INTEGER NOT NULL DEFAULT 0,
+		biggest_winner INTEGER,
+		PRIMARY KEY (day, game)
+	)`,
+	`CREATE TABLE IF NOT EXISTS daily_reports (
+		day TEXT PRIMARY KEY,
+		signups INTEGER NOT NULL,
+		guests INTEGER NOT NULL,
+		active_players INTEGER NOT NULL,
+		wagered INTEGER NOT NULL,
+		paid INTEGER NOT NULL,
+		biggest_win INTEGER NOT NULL,
+		biggest_winner TEXT NOT NULL,
+		biggest_win_game TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS user_settings (
+		user_id INTEGER NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, key),
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS promotion_optins (
+		user_id INTEGER NOT NULL,
+		promotion TEXT NOT NULL,
+		opted_in_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, promotion),
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS promotion_payouts (
+		user_id INTEGER NOT NULL,
+		promotion TEXT NOT NULL,
+		period_start DATETIME NOT NULL,
+		amount INTEGER NOT NULL,
+		paid_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, promotion, period_start),
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS daily_bonus (
+		user_id INTEGER PRIMARY KEY,
+		last_claimed DATETIME NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS ip_allowlist (
+		user_id INTEGER NOT NULL,
+		cidr TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, cidr),
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS audit_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER,
+		type TEXT NOT NULL,
+		ip TEXT NOT NULL DEFAULT '',
+		detail TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS login_ips (
+		user_id INTEGER NOT NULL,
+		ip TEXT NOT NULL,
+		first_seen DATETIME DEFAULT CURRENT_TIMESTAMP,
+		last_seen DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, ip),
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS invite_codes (
+		code TEXT PRIMARY KEY,
+		created_by TEXT NOT NULL,
+		max_uses INTEGER NOT NULL DEFAULT 1,
+		uses INTEGER NOT NULL DEFAULT 0,
+		expires_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS referral_codes (
+		code TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL UNIQUE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS referrals (
+		referred_id INTEGER PRIMARY KEY,
+		referrer_id INTEGER NOT NULL,
+		code TEXT NOT NULL,
+		status TEXT NOT NULL,
+		signup_ip TEXT NOT NULL DEFAULT '',
+		note TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		resolved_at DATETIME,
+		FOREIGN KEY (referred_id) REFERENCES users (id),
+		FOREIGN KEY (referrer_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS promo_codes (
+		code TEXT PRIMARY KEY,
+		value INTEGER NOT NULL,
+		created_by TEXT NOT NULL,
+		max_uses INTEGER NOT NULL DEFAULT 1,
+		uses INTEGER NOT NULL DEFAULT 0,
+		expires_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS promo_redemptions (
+		code TEXT NOT NULL,
+		user_id INTEGER NOT NULL,
+		redeemed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (code, user_id),
+		FOREIGN KEY (code) REFERENCES promo_codes (code),
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		multiplier INTEGER NOT NULL,
+		starts_at DATETIME NOT NULL,
+		ends_at DATETIME NOT NULL,
+		created_by TEXT NOT NULL,
+		start_announced INTEGER NOT NULL DEFAULT 0,
+		end_announced INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS chat_mutes (
+		user_id INTEGER PRIMARY KEY,
+		muted_until DATETIME NOT NULL,
+		muted_by TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS user_bans (
+		user_id INTEGER PRIMARY KEY,
+		banned_until DATETIME,
+		reason TEXT NOT NULL DEFAULT '',
+		banned_by TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS direct_messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		sender_id INTEGER NOT NULL,
+		recipient_id INTEGER NOT NULL,
+		body TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (sender_id) REFERENCES users (id),
+		FOREIGN KEY (recipient_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS user_blocks (
+		user_id INTEGER NOT NULL,
+		blocked_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, blocked_id),
+		FOREIGN KEY (user_id) REFERENCES users (id),
+		FOREIGN KEY (blocked_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS user_mutes (
+		user_id INTEGER NOT NULL,
+		muted_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, muted_id),
+		FOREIGN KEY (user_id) REFERENCES users (id),
+		FOREIGN KEY (muted_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS table_hands (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		table_id TEXT NOT NULL,
+		dealer TEXT NOT NULL,
+		dealer_value INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS table_hand_seats (
+		hand_id INTEGER NOT NULL,
+		seat INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		username TEXT NOT NULL,
+		cards TEXT NOT NULL,
+		value INTEGER NOT NULL,
+		bet INTEGER NOT NULL,
+		payout INTEGER NOT NULL,
+		result TEXT NOT NULL,
+		PRIMARY KEY (hand_id, seat),
+		FOREIGN KEY (hand_id) REFERENCES table_hands (id),
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS bet_holds (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		game TEXT NOT NULL,
+		ref TEXT NOT NULL DEFAULT '',
+		amount INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS active_games (
+		user_id INTEGER PRIMARY KEY,
+		game TEXT NOT NULL,
+		bet INTEGER NOT NULL,
+		state TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS game_rounds (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		game TEXT NOT NULL,
+		bet INTEGER NOT NULL,
+		payout INTEGER NOT NULL,
+		result TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_referrals_referrer ON referrals(referrer_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions(expires_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_transactions_user_created ON transactions(user_id, created_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_point_transactions_user ON point_transactions(user_id, created_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_events_user_id ON audit_events(user_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_direct_messages_recipient ON direct_messages(recipient_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_user_blocks_blocked ON user_blocks(blocked_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_user_mutes_muted ON user_mutes(muted_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_table_hand_seats_user ON table_hand_seats(user_id, hand_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_events_type_ip ON audit_events(type, ip, created_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_game_rounds_user ON game_rounds(user_id, id)`,
+}
+
+// Columns added to the initial schema before versioned migrations. Older
+// databases may have any of them already.
+var lateColumns = []struct{ table, column, definition string }{
+	{"users", "is_admin", "INTEGER NOT NULL DEFAULT 0"},
+	{"users", "comp_points", "INTEGER NOT NULL DEFAULT 0"},
+	{"users", "is_guest", "INTEGER NOT NULL DEFAULT 0"},
+	{"transactions", "metadata", "TEXT NOT NULL DEFAULT ''"},
+	{"transactions", "game_id", "TEXT NOT NULL DEFAULT ''"},
+	{"game_rounds", "hands", "TEXT NOT NULL DEFAULT ''"},
+	{"user_stats", "rebuys", "INTEGER NOT NULL DEFAULT 0"},
+	{"user_stats", "rebuy_total", "INTEGER NOT NULL DEFAULT 0"},
+	{"user_stats", "win_streak", "INTEGER NOT NULL DEFAULT 0"},
+	{"user_stats", "best_win_streak", "INTEGER NOT NULL DEFAULT 0"},
+	{"user_stats", "loss_streak", "INTEGER NOT NULL DEFAULT 0"},
+	{"user_stats", "best_loss_streak", "INTEGER NOT NULL DEFAULT 0"},
+	{"user_stats", "xp", "INTEGER NOT NULL DEFAULT 0"},
+	{"user_stats", "level", "INTEGER NOT NULL DEFAULT 1"},
+	{"user_stats", "vip_tier", "TEXT NOT NULL DEFAULT 'bronze'"},
+}
+
+func createTables(tx *sql.Tx) error {
+	for _, query := range initialSchema {
+		if _, err := tx.Exec(query); err != nil {
+			return fmt.Errorf("failed to execute query '%s': %w", query, err)
+		}
+	}
+	return nil
+}
+
+func addLateColumns(tx *sql.Tx) error {
+	for _, c := range lateColumns {
+		if err := ensureColumn(tx, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dropLateColumns(tx *sql.Tx) error {
+	for i := len(lateColumns) - 1; i >= 0; i-- {
+		c := lateColumns[i]
+		if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", c.table, c.column)); err != nil {
+			return fmt.Errorf("failed to drop column %s.%s: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
+
+// Guests are purged by clearing out their account rather than deleting it,
+// so the ledger and round history they left keep pointing at a user
+func addPurgedAt(tx *sql.Tx) error {
+	return ensureColumn(tx, "users", "purged_at", "DATETIME")
+}
+
+func dropPurgedAt(tx *sql.Tx) error {
+	if _, err := tx.Exec(`ALTER TABLE users DROP COLUMN purged_at`); err != nil {
+		return fmt.Errorf("failed to drop column users.purged_at: %w", err)
+	}
+	return nil
+}
+
+// Adds a column unless the table has it already
+func ensureColumn(tx *sql.Tx, table, column, definition string) error {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			return fmt.Errorf("failed to inspect table %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	rows.Close()
+
+	query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)
+	if _, err := tx.Exec(query); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// The version this build migrates databases to
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// The version the database is at, 0 before any migration has run
+func (db *DB) SchemaVersion() (int, error) {
+	var version int
+	if err := db.conn.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Brings the database up to the latest version. A database from a newer
+// build is refused rather than run against a schema this one doesn't know.
+func (db *DB) migrate() error {
+	if _, err := db.conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > LatestSchemaVersion() {
+		return fmt.Errorf("database is at schema version %d but this build only knows up to %d", version, LatestSchemaVersion())
+	}
+	return db.MigrateTo(LatestSchemaVersion())
+}
+
+// Runs migrations up or down until the database is at version. Each runs in
+// its own transaction with its schema_migrations row, so a failure leaves the
+// database at the last version that completed.
+func (db *DB) MigrateTo(version int) error {
+	if version < 0 || version > LatestSchemaVersion() {
+		return fmt.Errorf("unknown schema version %d", version)
+	}
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version > current && m.version <= version {
+			if err := db.runMigration(m, m.up, `INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.version, m.name); err != nil {
+				return err
+			}
+		}
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.version <= current && m.version > version {
+			if m.down == nil {
+				return fmt.Errorf("migration %d (%s) can't be rolled back", m.version, m.name)
+			}
+			if err := db.runMigration(m, m.down, `DELETE FROM schema_migrations WHERE version = ?`, m.version); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (db *DB) runMigration(m migration, step func(tx *sql.Tx) error, record string, args ...interface{}) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := step(tx); err != nil {
+		return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+	}
+	if _, err := tx.Exec(record, args...); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.version, err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,70 @@
+package vault
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNewDBMigratesToLatest(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	version, err := db.SchemaVersion()
+	if err != nil || version != LatestSchemaVersion() {
+		t.Errorf("SchemaVersion() = %d, %v, want %d", version, err, LatestSchemaVersion())
+	}
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("alice", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := db.MigrateTo(1); err != nil {
+		t.Fatalf("MigrateTo(1) error = %v", err)
+	}
+	if version, _ := db.SchemaVersion(); version != 1 {
+		t.Errorf("SchemaVersion() = %d after rolling back, want 1", version)
+	}
+	if _, err := db.conn.Exec(`UPDATE users SET is_admin = 1`); err == nil {
+		t.Error("Rolling back should drop the later columns")
+	}
+
+	if err := db.MigrateTo(LatestSchemaVersion()); err != nil {
+		t.Fatalf("MigrateTo(latest) error = %v", err)
+	}
+	got, err := db.GetUserByID(user.ID)
+	if err != nil || got.Username != "alice" || got.IsAdmin {
+		t.Errorf("GetUserByID() = %+v, %v, want alice kept through the rollback", got, err)
+	}
+
+	if err := db.MigrateTo(0); err == nil {
+		t.Error("MigrateTo(0) should refuse to roll back the initial schema")
+	}
+	if err := db.MigrateTo(LatestSchemaVersion() + 1); err == nil {
+		t.Error("MigrateTo() should reject an unknown version")
+	}
+}
+
+func TestNewDBRefusesNewerSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := NewDB(dbPath)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	_, err = db.conn.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, 'from the future')`, LatestSchemaVersion()+1)
+	db.Close()
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if db, err := NewDB(dbPath); err == nil {
+		db.Close()
+		t.Error("NewDB() should refuse a database from a newer build")
+	}
+}
@@ -0,0 +1,143 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Comp point transaction types
+const (
+	PointsEarned   = "earned"
+	PointsRedeemed = "redeemed"
+)
+
+// PointTransaction is a comp point ledger entry, kept apart from the chip ledger
+type PointTransaction struct {
+	ID           int64     `json:"id"`
+	UserID       int       `json:"user_id"`
+	Type         string    `json:"type"`
+	Amount       int64     `json:"amount"`
+	BalanceAfter int64     `json:"balance_after"`
+	Metadata     string    `json:"metadata"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Applies delta to a user's comp points and records it, rejecting a negative result
+func (db *DB) AdjustPoints(userID int, delta int64, txType, metadata string) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	points, err := adjustPointsTx(tx, userID, delta, txType, metadata)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit points: %w", err)
+	}
+
+	return points, nil
+}
+
+func adjustPointsTx(tx *sql.Tx, userID int, delta int64, txType, metadata string) (int64, error) {
+	result, err := tx.Exec(`UPDATE users SET comp_points = comp_points + ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND comp_points + ? >= 0`, delta, userID, delta)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update comp points: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to update comp points: %w", err)
+	}
+	if affected == 0 {
+		return 0, fmt.Errorf("not enough comp points")
+	}
+
+	var points int64
+	if err := tx.QueryRow(`SELECT comp_points FROM users WHERE id = ?`, userID).Scan(&points); err != nil {
+		return 0, fmt.Errorf("failed to read comp points: %w", err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO point_transactions (user_id, type, amount, balance_after, metadata) VALUES (?, ?, ?, ?, ?)`,
+		userID, txType, delta, points, metadata); err != nil {
+		return 0, fmt.Errorf("failed to record point transaction: %w", err)
+	}
+
+	return points, nil
+}
+
+// Spends points on chips; both sides are written in one transaction
+func (db *DB) RedeemPointsForChips(userID int, cost, chips int64, item string) (points, balance int64, err error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if points, err = adjustPointsTx(tx, userID, -cost, PointsRedeemed, item); err != nil {
+		return 0, 0, err
+	}
+	if balance, err = adjustBalanceTx(tx, userID, chips, TxRedeem, item); err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit redemption: %w", err)
+	}
+
+	return points, balance, nil
+}
+
+// Spends points on an item the user keeps; each item can only be owned once
+func (db *DB) RedeemPointsForItem(userID int, cost int64, item string) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT OR IGNORE INTO user_items (user_id, item) VALUES (?, ?)`, userID, item)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add item: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return 0, fmt.Errorf("you already own this item")
+	}
+
+	points, err := adjustPointsTx(tx, userID, -cost, PointsRedeemed, item)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit redemption: %w", err)
+	}
+
+	return points, nil
+}
+
+func (db *DB) ListPointTransactions(userID int, limit int) ([]*PointTransaction, error) {
+	query := `SELECT id, user_id, type, amount, balance_after, metadata, created_at FROM point_transactions
+			  WHERE user_id = ? ORDER BY id DESC LIMIT ?`
+	rows, err := db.conn.Query(query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list point transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var txs []*PointTransaction
+	for rows.Next() {
+		var t PointTransaction
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Type, &t.Amount, &t.BalanceAfter, &t.Metadata, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan point transaction: %w", err)
+		}
+		txs = append(txs, &t)
+	}
+
+	return txs, rows.Err()
+}
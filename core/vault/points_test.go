@@ -0,0 +1,64 @@
+package vault
+
+import "testing"
+
+func TestCompPoints(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("pointsuser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	points, err := db.AdjustPoints(user.ID, 300, PointsEarned, "")
+	if err != nil {
+		t.Fatalf("AdjustPoints() error = %v", err)
+	}
+	if points != 300 {
+		t.Errorf("AdjustPoints() = %d, want 300", points)
+	}
+
+	if _, _, err := db.RedeemPointsForChips(user.ID, 500, 1000, "chips_10"); err == nil {
+		t.Error("RedeemPointsForChips() should fail without enough points")
+	}
+
+	points, balance, err := db.RedeemPointsForChips(user.ID, 100, 1000, "chips_10")
+	if err != nil {
+		t.Fatalf("RedeemPointsForChips() error = %v", err)
+	}
+	if points != 200 || balance != 1001000 {
+		t.Errorf("RedeemPointsForChips() = %d, %d, want 200 points and 1001000", points, balance)
+	}
+
+	if points, err = db.RedeemPointsForItem(user.ID, 150, "card_back_gold"); err != nil || points != 50 {
+		t.Errorf("RedeemPointsForItem() = %d, %v, want 50 points left", points, err)
+	}
+	if _, err := db.AdjustPoints(user.ID, 500, PointsEarned, ""); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := db.RedeemPointsForItem(user.ID, 150, "card_back_gold"); err == nil {
+		t.Error("RedeemPointsForItem() should refuse an item the user already owns")
+	}
+
+	items, err := db.ListUserItems(user.ID)
+	if err != nil || len(items) != 1 || items[0] != "card_back_gold" {
+		t.Errorf("ListUserItems() = %v, %v, want [card_back_gold]", items, err)
+	}
+
+	stored, err := db.GetUserByID(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if stored.Points != 550 {
+		t.Errorf("Stored points = %d, want 550", stored.Points)
+	}
+
+	txs, err := db.ListPointTransactions(user.ID, 10)
+	if err != nil {
+		t.Fatalf("ListPointTransactions() error = %v", err)
+	}
+	if len(txs) != 4 || txs[0].Type != PointsEarned || txs[1].Metadata != "card_back_gold" {
+		t.Errorf("ListPointTransactions() = %+v, want 4 entries newest first", txs)
+	}
+}
@@ -0,0 +1,36 @@
+package vault
+
+import "fmt"
+
+// Adds experience to a user and returns the new total along with the stored level
+func (db *DB) AddXP(userID int, xp int64) (int64, int, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE user_stats SET xp = xp + ? WHERE user_id = ?`, xp, userID); err != nil {
+		return 0, 0, fmt.Errorf("failed to add xp: %w", err)
+	}
+
+	var total int64
+	var level int
+	if err := tx.QueryRow(`SELECT xp, level FROM user_stats WHERE user_id = ?`, userID).Scan(&total, &level); err != nil {
+		return 0, 0, fmt.Errorf("failed to read xp: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit xp: %w", err)
+	}
+
+	return total, level, nil
+}
+
+func (db *DB) SetLevel(userID, level int, vipTier string) error {
+	query := `UPDATE user_stats SET level = ?, vip_tier = ? WHERE user_id = ?`
+	if _, err := db.conn.Exec(query, level, vipTier, userID); err != nil {
+		return fmt.Errorf("failed to set level: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package vault
+
+import "testing"
+
+func TestAddXPAndSetLevel(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("xpuser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	total, level, err := db.AddXP(user.ID, 150)
+	if err != nil {
+		t.Fatalf("AddXP() error = %v", err)
+	}
+	if total != 150 || level != 1 {
+		t.Errorf("AddXP() = %d, %d, want 150 at level 1", total, level)
+	}
+
+	if err := db.SetLevel(user.ID, 2, "silver"); err != nil {
+		t.Fatalf("SetLevel() error = %v", err)
+	}
+
+	if total, level, err = db.AddXP(user.ID, 50); err != nil || total != 200 || level != 2 {
+		t.Errorf("AddXP() = %d, %d, %v, want 200 at level 2", total, level, err)
+	}
+
+	stats, err := db.GetUserStats(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserStats() error = %v", err)
+	}
+	if stats.XP != 200 || stats.Level != 2 || stats.VIPTier != "silver" {
+		t.Errorf("GetUserStats() progression = %d/%d/%s, want 200/2/silver", stats.XP, stats.Level, stats.VIPTier)
+	}
+}
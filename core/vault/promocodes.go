@@ -0,0 +1,120 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type PromoCode struct {
+	Code      string       `json:"code"`
+	Value     int64        `json:"value"` // Chips in cents credited per redemption
+	CreatedBy string       `json:"created_by"`
+	MaxUses   int          `json:"max_uses"`
+	Uses      int          `json:"uses"`
+	ExpiresAt sql.NullTime `json:"expires_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+const promoCodeColumns = `code, value, created_by, max_uses, uses, expires_at, created_at`
+
+func scanPromoCode(row interface{ Scan(...interface{}) error }) (*PromoCode, error) {
+	var promo PromoCode
+	err := row.Scan(&promo.Code, &promo.Value, &promo.CreatedBy, &promo.MaxUses, &promo.Uses, &promo.ExpiresAt, &promo.CreatedAt)
+	return &promo, err
+}
+
+// Creates a promo code; a zero expiresAt means the code never expires
+func (db *DB) CreatePromoCode(code string, value int64, createdBy string, maxUses int, expiresAt time.Time) (*PromoCode, error) {
+	var expiry interface{}
+	if !expiresAt.IsZero() {
+		expiry = sqlTime(expiresAt)
+	}
+
+	query := `INSERT INTO promo_codes (code, value, created_by, max_uses, expires_at) VALUES (?, ?, ?, ?, ?)`
+	if _, err := db.conn.Exec(query, code, value, createdBy, maxUses, expiry); err != nil {
+		return nil, fmt.Errorf("failed to create promo code: %w", err)
+	}
+
+	return db.GetPromoCode(code)
+}
+
+func (db *DB) GetPromoCode(code string) (*PromoCode, error) {
+	promo, err := scanPromoCode(db.conn.QueryRow(`SELECT `+promoCodeColumns+` FROM promo_codes WHERE code = ?`, code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("promo code not found")
+		}
+		return nil, fmt.Errorf("failed to get promo code: %w", err)
+	}
+
+	return promo, nil
+}
+
+func (db *DB) ListPromoCodes() ([]*PromoCode, error) {
+	rows, err := db.conn.Query(`SELECT ` + promoCodeColumns + ` FROM promo_codes ORDER BY created_at DESC, code`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list promo codes: %w", err)
+	}
+	defer rows.Close()
+
+	var promos []*PromoCode
+	for rows.Next() {
+		promo, err := scanPromoCode(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan promo code: %w", err)
+		}
+		promos = append(promos, promo)
+	}
+
+	return promos, rows.Err()
+}
+
+// Redeems a promo code for a user: records the redemption, consumes a use and
+// credits the value in one transaction. Each user can redeem a code only once.
+func (db *DB) RedeemPromoCode(code string, userID int) (value, balance int64, err error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`SELECT value FROM promo_codes WHERE code = ?`, code).Scan(&value)
+	if err == sql.ErrNoRows {
+		return 0, 0, fmt.Errorf("invalid or expired promo code")
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read promo code: %w", err)
+	}
+
+	result, err := tx.Exec(`INSERT OR IGNORE INTO promo_redemptions (code, user_id) VALUES (?, ?)`, code, userID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to record redemption: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return 0, 0, fmt.Errorf("you have already redeemed this promo code")
+	}
+
+	result, err = tx.Exec(`UPDATE promo_codes SET uses = uses + 1
+		WHERE code = ? AND uses < max_uses AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)`, code)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to use promo code: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to use promo code: %w", err)
+	}
+	if affected == 0 {
+		return 0, 0, fmt.Errorf("invalid or expired promo code")
+	}
+
+	if balance, err = adjustBalanceTx(tx, userID, value, TxPromotion, "promo "+code); err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit redemption: %w", err)
+	}
+
+	return value, balance, nil
+}
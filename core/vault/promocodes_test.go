@@ -0,0 +1,66 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRedeemPromoCode(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	first, err := db.CreateUser("promofirst", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	second, err := db.CreateUser("promosecond", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	third, err := db.CreateUser("promothird", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := db.CreatePromoCode("WELCOME", 2500, "console", 2, time.Time{}); err != nil {
+		t.Fatalf("CreatePromoCode() error = %v", err)
+	}
+
+	value, balance, err := db.RedeemPromoCode("WELCOME", first.ID)
+	if err != nil || value != 2500 || balance != 1002500 {
+		t.Errorf("RedeemPromoCode() = %d, %d, %v, want 2500 leaving 1002500", value, balance, err)
+	}
+
+	if _, _, err := db.RedeemPromoCode("WELCOME", first.ID); err == nil {
+		t.Error("RedeemPromoCode() should refuse a second redemption by the same user")
+	}
+
+	if _, _, err := db.RedeemPromoCode("WELCOME", second.ID); err != nil {
+		t.Errorf("RedeemPromoCode() for a second user error = %v", err)
+	}
+
+	if _, _, err := db.RedeemPromoCode("WELCOME", third.ID); err == nil {
+		t.Error("RedeemPromoCode() should fail once max uses is reached")
+	}
+
+	promo, err := db.GetPromoCode("WELCOME")
+	if err != nil || promo.Uses != 2 {
+		t.Errorf("GetPromoCode() uses = %v, %v, want 2", promo, err)
+	}
+
+	if _, err := db.CreatePromoCode("EXPIRED", 100, "console", 5, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, _, err := db.RedeemPromoCode("EXPIRED", third.ID); err == nil {
+		t.Error("RedeemPromoCode() should reject an expired code")
+	}
+
+	if _, _, err := db.RedeemPromoCode("UNKNOWN", third.ID); err == nil {
+		t.Error("RedeemPromoCode() should reject an unknown code")
+	}
+
+	promos, err := db.ListPromoCodes()
+	if err != nil || len(promos) != 2 {
+		t.Errorf("ListPromoCodes() = %d codes, %v, want 2", len(promos), err)
+	}
+}
@@ -0,0 +1,97 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PromotionOptIn records when a user joined a promotion
+type PromotionOptIn struct {
+	UserID    int       `json:"user_id"`
+	OptedInAt time.Time `json:"opted_in_at"`
+}
+
+// Opts a user into a promotion; opting in again keeps the original time
+func (db *DB) OptInPromotion(userID int, promotion string) error {
+	_, err := db.conn.Exec(`INSERT OR IGNORE INTO promotion_optins (user_id, promotion) VALUES (?, ?)`, userID, promotion)
+	if err != nil {
+		return fmt.Errorf("failed to opt in: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) OptOutPromotion(userID int, promotion string) error {
+	_, err := db.conn.Exec(`DELETE FROM promotion_optins WHERE user_id = ? AND promotion = ?`, userID, promotion)
+	if err != nil {
+		return fmt.Errorf("failed to opt out: %w", err)
+	}
+	return nil
+}
+
+// Returns when the user opted into a promotion; ok is false if they haven't
+func (db *DB) GetPromotionOptIn(userID int, promotion string) (optedInAt time.Time, ok bool, err error) {
+	err = db.conn.QueryRow(`SELECT opted_in_at FROM promotion_optins WHERE user_id = ? AND promotion = ?`,
+		userID, promotion).Scan(&optedInAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get opt-in: %w", err)
+	}
+
+	return optedInAt, true, nil
+}
+
+func (db *DB) ListPromotionOptIns(promotion string) ([]PromotionOptIn, error) {
+	rows, err := db.conn.Query(`SELECT user_id, opted_in_at FROM promotion_optins WHERE promotion = ? ORDER BY user_id`, promotion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list opt-ins: %w", err)
+	}
+	defer rows.Close()
+
+	var optIns []PromotionOptIn
+	for rows.Next() {
+		var o PromotionOptIn
+		if err := rows.Scan(&o.UserID, &o.OptedInAt); err != nil {
+			return nil, fmt.Errorf("failed to scan opt-in: %w", err)
+		}
+		optIns = append(optIns, o)
+	}
+
+	return optIns, rows.Err()
+}
+
+// Credits a promotion payout for one period, at most once per user and period.
+// paid is false when the period was already paid out.
+func (db *DB) PayPromotion(userID int, promotion string, periodStart time.Time, amount int64, metadata string) (balance int64, paid bool, err error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT OR IGNORE INTO promotion_payouts (user_id, promotion, period_start, amount) VALUES (?, ?, ?, ?)`,
+		userID, promotion, sqlTime(periodStart), amount)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to record promotion payout: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to record promotion payout: %w", err)
+	}
+	if affected == 0 {
+		return 0, false, nil
+	}
+
+	if balance, err = adjustBalanceTx(tx, userID, amount, TxPromotion, metadata); err != nil {
+		return 0, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("failed to commit promotion payout: %w", err)
+	}
+
+	return balance, true, nil
+}
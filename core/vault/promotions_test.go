@@ -0,0 +1,70 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPromotions(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("promouser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, ok, err := db.GetPromotionOptIn(user.ID, "cashback"); err != nil || ok {
+		t.Errorf("GetPromotionOptIn() before opting in = %v, %v, want not opted in", ok, err)
+	}
+
+	if err := db.OptInPromotion(user.ID, "cashback"); err != nil {
+		t.Fatalf("OptInPromotion() error = %v", err)
+	}
+	optIns, err := db.ListPromotionOptIns("cashback")
+	if err != nil || len(optIns) != 1 || optIns[0].UserID != user.ID {
+		t.Errorf("ListPromotionOptIns() = %v, %v, want the user", optIns, err)
+	}
+
+	period := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	balance, paid, err := db.PayPromotion(user.ID, "cashback", period, 500, "cashback")
+	if err != nil || !paid || balance != 1000500 {
+		t.Errorf("PayPromotion() = %d, %v, %v, want 1000500 paid", balance, paid, err)
+	}
+	if _, paid, err := db.PayPromotion(user.ID, "cashback", period, 500, "cashback"); err != nil || paid {
+		t.Errorf("Second PayPromotion() = %v, %v, want already paid", paid, err)
+	}
+
+	txs, err := db.ListTransactions(user.ID, 10)
+	if err != nil || len(txs) != 1 || txs[0].Type != TxPromotion {
+		t.Errorf("ListTransactions() = %v, %v, want one promotion entry", txs, err)
+	}
+
+	if err := db.OptOutPromotion(user.ID, "cashback"); err != nil {
+		t.Fatalf("OptOutPromotion() error = %v", err)
+	}
+	if _, ok, _ := db.GetPromotionOptIn(user.ID, "cashback"); ok {
+		t.Error("GetPromotionOptIn() after opting out should be false")
+	}
+}
+
+func TestSumTransactionsBetween(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("sumuser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := db.AdjustBalance(user.ID, -1000, TxBet); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	hourAgo := time.Now().Add(-time.Hour)
+	if sum, err := db.SumTransactionsBetween(user.ID, hourAgo, time.Now().Add(time.Hour), TxBet); err != nil || sum != -1000 {
+		t.Errorf("SumTransactionsBetween() = %d, %v, want -1000", sum, err)
+	}
+	if sum, err := db.SumTransactionsBetween(user.ID, hourAgo.Add(-time.Hour), hourAgo, TxBet); err != nil || sum != 0 {
+		t.Errorf("SumTransactionsBetween() before the bet = %d, %v, want 0", sum, err)
+	}
+}
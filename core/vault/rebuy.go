@@ -0,0 +1,50 @@
+package vault
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tops a user's balance up to topUpTo, provided fewer than maxRebuys rebuys were
+// made since the given time. The ledger entry and rebuy stats are written in the
+// same transaction so concurrent requests can't exceed the allowance.
+func (db *DB) Rebuy(userID int, topUpTo int64, maxRebuys int, since time.Time) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM transactions WHERE user_id = ? AND type = ? AND created_at >= ?`,
+		userID, TxRebuy, sqlTime(since)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count rebuys: %w", err)
+	}
+	if count >= maxRebuys {
+		return 0, fmt.Errorf("rebuy limit of %d reached", maxRebuys)
+	}
+
+	var balance int64
+	if err := tx.QueryRow(`SELECT balance FROM users WHERE id = ?`, userID).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("failed to read user balance: %w", err)
+	}
+	amount := topUpTo - balance
+	if amount <= 0 {
+		return 0, fmt.Errorf("balance is already at least the rebuy amount")
+	}
+
+	if balance, err = adjustBalanceTx(tx, userID, amount, TxRebuy, ""); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(`UPDATE user_stats SET rebuys = rebuys + 1, rebuy_total = rebuy_total + ? WHERE user_id = ?`,
+		amount, userID); err != nil {
+		return 0, fmt.Errorf("failed to update user stats: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit rebuy: %w", err)
+	}
+
+	return balance, nil
+}
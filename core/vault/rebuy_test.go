@@ -0,0 +1,55 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRebuy(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("rebuyuser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := db.Rebuy(user.ID, 10000, 2, time.Now().Add(-24*time.Hour)); err == nil {
+		t.Error("Rebuy() should refuse a balance above the top-up amount")
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+
+	// Lose down to $2.50, rebuy, then lose everything and rebuy again
+	for i, loss := range []int64{999750, 10000} {
+		if _, err := db.AdjustBalance(user.ID, -loss, TxBet); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+
+		balance, err := db.Rebuy(user.ID, 10000, 2, since)
+		if err != nil {
+			t.Fatalf("Rebuy() #%d error = %v", i+1, err)
+		}
+		if balance != 10000 {
+			t.Errorf("Rebuy() #%d balance = %d, want 10000", i+1, balance)
+		}
+	}
+
+	if _, err := db.AdjustBalance(user.ID, -10000, TxBet); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := db.Rebuy(user.ID, 10000, 2, since); err == nil {
+		t.Error("Rebuy() should enforce the rebuy allowance")
+	}
+
+	stats, err := db.GetUserStats(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserStats() error = %v", err)
+	}
+	if stats.Rebuys != 2 || stats.RebuyTotal != 9750+10000 {
+		t.Errorf("Rebuy stats = %d/%d, want 2/%d", stats.Rebuys, stats.RebuyTotal, 9750+10000)
+	}
+	if stats.GamesPlayed != 0 || stats.TotalWon != 0 {
+		t.Error("Rebuy() should not touch game results")
+	}
+}
@@ -0,0 +1,310 @@
+package vault
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Time allowed to connect to Redis and for each command
+const redisTimeout = 5 * time.Second
+
+// Prefix of every key the casino writes to Redis
+const redisKeyPrefix = "casino:"
+
+// RedisSessionStore keeps login sessions in Redis instead of the database, so
+// several servers behind a load balancer accept each other's sessions. Each
+// session is a key that Redis expires along with it, and each user has a set
+// of their session IDs so they can all be ended at once.
+type RedisSessionStore struct {
+	addr     string
+	tls      *tls.Config
+	username string
+	password string
+	db       int
+
+	mu   sync.Mutex // One command at a time on the connection
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// Connects to the Redis server at a redis:// or rediss:// (TLS) URL, e.g.
+// redis://:password@10.0.0.5:6379/2
+func NewRedisSessionStore(rawURL string) (*RedisSessionStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("invalid Redis URL: scheme must be redis or rediss")
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		host = "localhost"
+	}
+	port := u.Port()
+	if port == "" {
+		port = "6379"
+	}
+	store := &RedisSessionStore{addr: net.JoinHostPort(host, port)}
+	if u.Scheme == "rediss" {
+		store.tls = &tls.Config{ServerName: host}
+	}
+	if u.User != nil {
+		store.username = u.User.Username()
+		store.password, _ = u.User.Password()
+	}
+	if path := strings.TrimPrefix(u.Path, "/"); path != "" {
+		if store.db, err = strconv.Atoi(path); err != nil {
+			return nil, fmt.Errorf("invalid Redis URL: database must be a number")
+		}
+	}
+
+	if _, err := store.do("PING"); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (r *RedisSessionStore) CreateSession(sessionID string, userID int, expiresAt time.Time) error {
+	secs := int64(time.Until(expiresAt).Seconds()) + 1
+	if secs < 1 {
+		// Redis refuses a zero expiry; GetSession rejects it as expired anyway
+		secs = 1
+	}
+	ttl := strconv.FormatInt(secs, 10)
+	value := fmt.Sprintf("%d %d %d", userID, time.Now().Unix(), expiresAt.Unix())
+
+	if _, err := r.do("SET", sessionKey(sessionID), value, "EX", ttl); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	// Sessions all last as long, so the newest outlives the rest of the set
+	if _, err := r.do("SADD", userSessionsKey(userID), sessionID); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	if _, err := r.do("EXPIRE", userSessionsKey(userID), ttl); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisSessionStore) GetSession(sessionID string) (*Session, error) {
+	reply, err := r.do("GET", sessionKey(sessionID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return nil, fmt.Errorf("session not found or expired")
+	}
+
+	var userID int
+	var created, expires int64
+	if _, err := fmt.Sscanf(value, "%d %d %d", &userID, &created, &expires); err != nil {
+		return nil, fmt.Errorf("failed to get session: malformed entry")
+	}
+	session := &Session{ID: sessionID, UserID: userID, CreatedAt: time.Unix(created, 0), ExpiresAt: time.Unix(expires, 0)}
+	if !time.Now().Before(session.ExpiresAt) {
+		return nil, fmt.Errorf("session not found or expired")
+	}
+	return session, nil
+}
+
+func (r *RedisSessionStore) DeleteSession(sessionID string) error {
+	session, err := r.GetSession(sessionID)
+	if err == nil {
+		if _, err := r.do("SREM", userSessionsKey(session.UserID), sessionID); err != nil {
+			return fmt.Errorf("failed to delete session: %w", err)
+		}
+	}
+	if _, err := r.do("DEL", sessionKey(sessionID)); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// Ends every session of a user
+func (r *RedisSessionStore) DeleteUserSessions(userID int) error {
+	reply, err := r.do("SMEMBERS", userSessionsKey(userID))
+	if err != nil {
+		return fmt.Errorf("failed to delete sessions: %w", err)
+	}
+	members, _ := reply.([]interface{})
+
+	args := []string{"DEL", userSessionsKey(userID)}
+	for _, m := range members {
+		if id, ok := m.(string); ok {
+			args = append(args, sessionKey(id))
+		}
+	}
+	if _, err := r.do(args...); err != nil {
+		return fmt.Errorf("failed to delete sessions: %w", err)
+	}
+	return nil
+}
+
+// Redis expires sessions by itself
+func (r *RedisSessionStore) CleanupExpiredSessions() error {
+	return nil
+}
+
+func (r *RedisSessionStore) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn = nil
+	return err
+}
+
+func sessionKey(sessionID string) string {
+	return redisKeyPrefix + "session:" + sessionID
+}
+
+func userSessionsKey(userID int) string {
+	return fmt.Sprintf("%suser_sessions:%d", redisKeyPrefix, userID)
+}
+
+// redisError is an error reply from the server, as opposed to a failed connection
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// Runs a command, connecting first if needed. A command that fails on a
+// connection left over from earlier (e.g. closed by the server while idle) is
+// retried once on a fresh one. Replies are strings, int64s, nil or slices of
+// those.
+func (r *RedisSessionStore) do(args ...string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reused := r.conn != nil
+	reply, err := r.roundTrip(args)
+	if err != nil && reused {
+		if _, ok := err.(redisError); !ok {
+			reply, err = r.roundTrip(args)
+		}
+	}
+	return reply, err
+}
+
+func (r *RedisSessionStore) roundTrip(args []string) (interface{}, error) {
+	if r.conn == nil {
+		if err := r.connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	r.conn.SetDeadline(time.Now().Add(redisTimeout))
+	reply, err := r.send(args)
+	if _, ok := err.(redisError); err != nil && !ok {
+		// The connection is in an unknown state, start over next time
+		r.conn.Close()
+		r.conn = nil
+	}
+	return reply, err
+}
+
+func (r *RedisSessionStore) connect() error {
+	dialer := &net.Dialer{Timeout: redisTimeout}
+	var conn net.Conn
+	var err error
+	if r.tls != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", r.addr, r.tls)
+	} else {
+		conn, err = dialer.Dial("tcp", r.addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	r.conn = conn
+	r.rd = bufio.NewReader(conn)
+	conn.SetDeadline(time.Now().Add(redisTimeout))
+
+	var setup [][]string
+	switch {
+	case r.username != "" && r.password != "":
+		setup = append(setup, []string{"AUTH", r.username, r.password})
+	case r.password != "":
+		setup = append(setup, []string{"AUTH", r.password})
+	}
+	if r.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(r.db)})
+	}
+	for _, args := range setup {
+		if _, err := r.send(args); err != nil {
+			conn.Close()
+			r.conn = nil
+			return fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+	}
+	return nil
+}
+
+// Writes a command as an array of bulk strings and reads its reply
+func (r *RedisSessionStore) send(args []string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(r.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return readRedisReply(r.rd)
+}
+
+func readRedisReply(rd *bufio.Reader) (interface{}, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readRedisReply(rd); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
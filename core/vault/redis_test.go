@@ -0,0 +1,244 @@
+package vault
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis speaks just enough of the Redis protocol for RedisSessionStore
+type fakeRedis struct {
+	ln       net.Listener
+	password string
+
+	mu      sync.Mutex
+	strings map[string]string
+	sets    map[string]map[string]bool
+	expires map[string]time.Time
+	conns   []net.Conn
+}
+
+func startFakeRedis(t *testing.T, password string) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	f := &fakeRedis{ln: ln, password: password, strings: map[string]string{},
+		sets: map[string]map[string]bool{}, expires: map[string]time.Time{}}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.conns = append(f.conns, conn)
+			f.mu.Unlock()
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) url() string {
+	if f.password != "" {
+		return fmt.Sprintf("redis://:%s@%s/3", f.password, f.ln.Addr())
+	}
+	return "redis://" + f.ln.Addr().String()
+}
+
+// Hangs up on every client, as a restarted Redis would
+func (f *fakeRedis) dropConnections() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.conns {
+		c.Close()
+	}
+	f.conns = nil
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		reply, err := readRedisReply(rd)
+		if err != nil {
+			return
+		}
+		items, _ := reply.([]interface{})
+		var args []string
+		for _, item := range items {
+			args = append(args, item.(string))
+		}
+		if len(args) == 0 {
+			return
+		}
+
+		cmd := strings.ToUpper(args[0])
+		if !authed && cmd != "AUTH" {
+			fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		if cmd == "AUTH" {
+			if args[len(args)-1] != f.password {
+				fmt.Fprint(conn, "-WRONGPASS invalid password\r\n")
+				continue
+			}
+			authed = true
+		}
+		fmt.Fprint(conn, f.run(cmd, args[1:]))
+	}
+}
+
+func (f *fakeRedis) run(cmd string, args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key, at := range f.expires {
+		if time.Now().After(at) {
+			delete(f.strings, key)
+			delete(f.sets, key)
+			delete(f.expires, key)
+		}
+	}
+
+	switch cmd {
+	case "AUTH", "SELECT":
+		return "+OK\r\n"
+	case "PING":
+		return "+PONG\r\n"
+	case "SET":
+		f.strings[args[0]] = args[1]
+		if len(args) == 4 && strings.ToUpper(args[2]) == "EX" {
+			secs, _ := strconv.Atoi(args[3])
+			f.expires[args[0]] = time.Now().Add(time.Duration(secs) * time.Second)
+		}
+		return "+OK\r\n"
+	case "GET":
+		v, ok := f.strings[args[0]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	case "EXPIRE":
+		secs, _ := strconv.Atoi(args[1])
+		f.expires[args[0]] = time.Now().Add(time.Duration(secs) * time.Second)
+		return ":1\r\n"
+	case "SADD":
+		if f.sets[args[0]] == nil {
+			f.sets[args[0]] = map[string]bool{}
+		}
+		f.sets[args[0]][args[1]] = true
+		return ":1\r\n"
+	case "SREM":
+		delete(f.sets[args[0]], args[1])
+		return ":1\r\n"
+	case "SMEMBERS":
+		out := fmt.Sprintf("*%d\r\n", len(f.sets[args[0]]))
+		for m := range f.sets[args[0]] {
+			out += fmt.Sprintf("$%d\r\n%s\r\n", len(m), m)
+		}
+		return out
+	case "DEL":
+		for _, key := range args {
+			delete(f.strings, key)
+			delete(f.sets, key)
+			delete(f.expires, key)
+		}
+		return fmt.Sprintf(":%d\r\n", len(args))
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func TestRedisSessionStore(t *testing.T) {
+	f := startFakeRedis(t, "s3cret")
+	store, err := NewRedisSessionStore(f.url())
+	if err != nil {
+		t.Fatalf("NewRedisSessionStore() error = %v", err)
+	}
+	defer store.Close()
+
+	expires := time.Now().Add(time.Hour)
+	for _, id := range []string{"one", "two"} {
+		if err := store.CreateSession(id, 7, expires); err != nil {
+			t.Fatalf("CreateSession(%s) error = %v", id, err)
+		}
+	}
+	if err := store.CreateSession("other", 8, expires); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	session, err := store.GetSession("one")
+	if err != nil {
+		t.Fatalf("GetSession() error = %v", err)
+	}
+	if session.ID != "one" || session.UserID != 7 || session.ExpiresAt.Unix() != expires.Unix() {
+		t.Errorf("GetSession() = %+v", session)
+	}
+	if _, err := store.GetSession("missing"); err == nil {
+		t.Error("GetSession() should fail for an unknown session")
+	}
+
+	if err := store.DeleteSession("one"); err != nil {
+		t.Fatalf("DeleteSession() error = %v", err)
+	}
+	if _, err := store.GetSession("one"); err == nil {
+		t.Error("GetSession() should fail after DeleteSession()")
+	}
+
+	if err := store.DeleteUserSessions(7); err != nil {
+		t.Fatalf("DeleteUserSessions() error = %v", err)
+	}
+	if _, err := store.GetSession("two"); err == nil {
+		t.Error("DeleteUserSessions() should end every session of the user")
+	}
+	if _, err := store.GetSession("other"); err != nil {
+		t.Errorf("DeleteUserSessions() ended another user's session: %v", err)
+	}
+
+	// A dropped connection is replaced on the next command
+	f.dropConnections()
+	if _, err := store.GetSession("other"); err != nil {
+		t.Errorf("GetSession() after reconnect error = %v", err)
+	}
+}
+
+func TestRedisSessionStoreExpiry(t *testing.T) {
+	f := startFakeRedis(t, "")
+	store, err := NewRedisSessionStore(f.url())
+	if err != nil {
+		t.Fatalf("NewRedisSessionStore() error = %v", err)
+	}
+	defer store.Close()
+
+	// Expired by the time it's read, even if Redis still holds the key
+	if err := store.CreateSession("old", 1, time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if _, err := store.GetSession("old"); err == nil {
+		t.Error("GetSession() should fail for an expired session")
+	}
+}
+
+func TestNewRedisSessionStoreErrors(t *testing.T) {
+	f := startFakeRedis(t, "s3cret")
+
+	for _, url := range []string{
+		"http://localhost:6379",
+		"redis://localhost:6379/db",
+		"redis://:wrong@" + f.ln.Addr().String(),
+		"redis://" + f.ln.Addr().String(), // No password
+	} {
+		if store, err := NewRedisSessionStore(url); err == nil {
+			store.Close()
+			t.Errorf("NewRedisSessionStore(%q) should fail", url)
+		}
+	}
+}
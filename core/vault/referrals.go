@@ -0,0 +1,178 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Referral states
+const (
+	ReferralPending  = "pending"
+	ReferralPaid     = "paid"
+	ReferralRejected = "rejected"
+)
+
+type Referral struct {
+	ReferredID int          `json:"referred_id"`
+	ReferrerID int          `json:"referrer_id"`
+	Code       string       `json:"code"`
+	Status     string       `json:"status"`
+	SignupIP   string       `json:"signup_ip"`
+	Note       string       `json:"note"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ResolvedAt sql.NullTime `json:"resolved_at"`
+}
+
+const referralColumns = `referred_id, referrer_id, code, status, signup_ip, note, created_at, resolved_at`
+
+func scanReferral(row interface{ Scan(...interface{}) error }) (*Referral, error) {
+	var r Referral
+	err := row.Scan(&r.ReferredID, &r.ReferrerID, &r.Code, &r.Status, &r.SignupIP, &r.Note, &r.CreatedAt, &r.ResolvedAt)
+	return &r, err
+}
+
+// Returns a user's referral code; ok is false if they don't have one yet
+func (db *DB) GetReferralCode(userID int) (code string, ok bool, err error) {
+	err = db.conn.QueryRow(`SELECT code FROM referral_codes WHERE user_id = ?`, userID).Scan(&code)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get referral code: %w", err)
+	}
+
+	return code, true, nil
+}
+
+func (db *DB) CreateReferralCode(userID int, code string) error {
+	if _, err := db.conn.Exec(`INSERT INTO referral_codes (code, user_id) VALUES (?, ?)`, code, userID); err != nil {
+		return fmt.Errorf("failed to create referral code: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) GetReferralCodeOwner(code string) (int, error) {
+	var userID int
+	err := db.conn.QueryRow(`SELECT user_id FROM referral_codes WHERE code = ?`, code).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("invalid referral code")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get referral code: %w", err)
+	}
+
+	return userID, nil
+}
+
+func (db *DB) CreateReferral(referrerID, referredID int, code, status, signupIP, note string) error {
+	query := `INSERT INTO referrals (referred_id, referrer_id, code, status, signup_ip, note) VALUES (?, ?, ?, ?, ?, ?)`
+	if _, err := db.conn.Exec(query, referredID, referrerID, code, status, signupIP, note); err != nil {
+		return fmt.Errorf("failed to create referral: %w", err)
+	}
+	return nil
+}
+
+// Returns how a user was referred; ok is false if they signed up without a code
+func (db *DB) GetReferral(referredID int) (referral *Referral, ok bool, err error) {
+	referral, err = scanReferral(db.conn.QueryRow(`SELECT `+referralColumns+` FROM referrals WHERE referred_id = ?`, referredID))
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get referral: %w", err)
+	}
+
+	return referral, true, nil
+}
+
+// Lists the accounts a user has referred, newest first
+func (db *DB) ListReferrals(referrerID int) ([]*Referral, error) {
+	rows, err := db.conn.Query(`SELECT `+referralColumns+` FROM referrals WHERE referrer_id = ? ORDER BY created_at DESC, referred_id DESC`, referrerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list referrals: %w", err)
+	}
+	defer rows.Close()
+
+	var referrals []*Referral
+	for rows.Next() {
+		r, err := scanReferral(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan referral: %w", err)
+		}
+		referrals = append(referrals, r)
+	}
+
+	return referrals, rows.Err()
+}
+
+// Marks a pending referral as rejected so it is never paid
+func (db *DB) RejectReferral(referredID int, note string) error {
+	_, err := db.conn.Exec(`UPDATE referrals SET status = ?, note = ?, resolved_at = CURRENT_TIMESTAMP
+		WHERE referred_id = ? AND status = ?`, ReferralRejected, note, referredID, ReferralPending)
+	if err != nil {
+		return fmt.Errorf("failed to reject referral: %w", err)
+	}
+	return nil
+}
+
+// Pays the referral bonus to both accounts in one transaction. paid is false
+// when the referral was no longer pending.
+func (db *DB) PayReferral(referredID int, bonus int64) (referrerBalance, referredBalance int64, paid bool, err error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE referrals SET status = ?, resolved_at = CURRENT_TIMESTAMP
+		WHERE referred_id = ? AND status = ?`, ReferralPaid, referredID, ReferralPending)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("failed to update referral: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("failed to update referral: %w", err)
+	}
+	if affected == 0 {
+		return 0, 0, false, nil
+	}
+
+	var referrerID int
+	if err := tx.QueryRow(`SELECT referrer_id FROM referrals WHERE referred_id = ?`, referredID).Scan(&referrerID); err != nil {
+		return 0, 0, false, fmt.Errorf("failed to read referral: %w", err)
+	}
+
+	if referrerBalance, err = adjustBalanceTx(tx, referrerID, bonus, TxReferral, fmt.Sprintf("referred user %d", referredID)); err != nil {
+		return 0, 0, false, err
+	}
+	if referredBalance, err = adjustBalanceTx(tx, referredID, bonus, TxReferral, fmt.Sprintf("referred by user %d", referrerID)); err != nil {
+		return 0, 0, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, false, fmt.Errorf("failed to commit referral bonus: %w", err)
+	}
+
+	return referrerBalance, referredBalance, true, nil
+}
+
+// Reports whether a user has ever logged in from ip
+func (db *DB) HasLoginIP(userID int, ip string) (bool, error) {
+	var count int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM login_ips WHERE user_id = ? AND ip = ?`, userID, ip).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to read login ips: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Reports whether two users have logged in from a common address
+func (db *DB) SharesLoginIP(userA, userB int) (bool, error) {
+	var count int
+	err := db.conn.QueryRow(`SELECT COUNT(*) FROM login_ips a JOIN login_ips b ON a.ip = b.ip
+		WHERE a.user_id = ? AND b.user_id = ?`, userA, userB).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to compare login ips: %w", err)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,72 @@
+package vault
+
+import "testing"
+
+func TestReferrals(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	referrer, err := db.CreateUser("referrer", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	referred, err := db.CreateUser("referred", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := db.CreateReferralCode(referrer.ID, "FRIEND01"); err != nil {
+		t.Fatalf("CreateReferralCode() error = %v", err)
+	}
+	if code, ok, err := db.GetReferralCode(referrer.ID); err != nil || !ok || code != "FRIEND01" {
+		t.Errorf("GetReferralCode() = %q, %v, %v, want FRIEND01", code, ok, err)
+	}
+	if owner, err := db.GetReferralCodeOwner("FRIEND01"); err != nil || owner != referrer.ID {
+		t.Errorf("GetReferralCodeOwner() = %d, %v, want %d", owner, err, referrer.ID)
+	}
+
+	if err := db.CreateReferral(referrer.ID, referred.ID, "FRIEND01", ReferralPending, "10.0.0.2", ""); err != nil {
+		t.Fatalf("CreateReferral() error = %v", err)
+	}
+
+	referrerBalance, referredBalance, paid, err := db.PayReferral(referred.ID, 2500)
+	if err != nil || !paid || referrerBalance != 1002500 || referredBalance != 1002500 {
+		t.Errorf("PayReferral() = %d, %d, %v, %v, want both credited", referrerBalance, referredBalance, paid, err)
+	}
+	if _, _, paid, err := db.PayReferral(referred.ID, 2500); err != nil || paid {
+		t.Errorf("Second PayReferral() = %v, %v, want no payment", paid, err)
+	}
+
+	referral, ok, err := db.GetReferral(referred.ID)
+	if err != nil || !ok || referral.Status != ReferralPaid || !referral.ResolvedAt.Valid {
+		t.Errorf("GetReferral() = %+v, %v, %v, want a paid referral", referral, ok, err)
+	}
+
+	referrals, err := db.ListReferrals(referrer.ID)
+	if err != nil || len(referrals) != 1 {
+		t.Errorf("ListReferrals() = %d, %v, want 1", len(referrals), err)
+	}
+}
+
+func TestSharesLoginIP(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	a, _ := db.CreateUser("ipusera", "hashedpassword123")
+	b, _ := db.CreateUser("ipuserb", "hashedpassword123")
+
+	db.RecordLoginIP(a.ID, "10.0.0.1")
+	db.RecordLoginIP(b.ID, "10.0.0.2")
+
+	if shared, err := db.SharesLoginIP(a.ID, b.ID); err != nil || shared {
+		t.Errorf("SharesLoginIP() = %v, %v, want false", shared, err)
+	}
+
+	db.RecordLoginIP(b.ID, "10.0.0.1")
+	if shared, err := db.SharesLoginIP(a.ID, b.ID); err != nil || !shared {
+		t.Errorf("SharesLoginIP() after a shared login = %v, %v, want true", shared, err)
+	}
+	if has, err := db.HasLoginIP(a.ID, "10.0.0.2"); err != nil || has {
+		t.Errorf("HasLoginIP() = %v, %v, want false", has, err)
+	}
+}
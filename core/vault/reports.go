@@ -0,0 +1,144 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// DailyReport is the digest of one UTC day's activity
+type DailyReport struct {
+	Day            string        `json:"day"` // YYYY-MM-DD
+	Signups        int           `json:"signups"`
+	Guests         int           `json:"guests"`         // Guest accounts created, not counting any already purged
+	ActivePlayers  int           `json:"active_players"` // Players who placed a bet
+	Wagered        int64         `json:"wagered"`
+	Paid           int64         `json:"paid"`
+	Games          []*HouseStats `json:"games"`          // Rounds, wagers and payouts per game
+	BiggestWin     int64         `json:"biggest_win"`    // Largest payout net of its bet
+	BiggestWinner  string        `json:"biggest_winner"` // Empty when nobody won
+	BiggestWinGame string        `json:"biggest_win_game"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+// Net amount the house kept over the day
+func (r *DailyReport) Hold() int64 {
+	return r.Wagered - r.Paid
+}
+
+// Adds a settled round to the day's per-game totals, keeping the biggest win.
+// Days are UTC, like CURRENT_TIMESTAMP.
+func recordHouseDayTx(tx *sql.Tx, userID int, game string, wagered, payout int64) error {
+	win := max(payout-wagered, 0)
+	_, err := tx.Exec(`INSERT INTO house_daily (day, game, rounds, wagered, paid, biggest_win, biggest_winner)
+		VALUES (date('now'), ?, 1, ?, ?, ?, ?)
+		ON CONFLICT(day, game) DO UPDATE SET rounds = rounds + 1, wagered = wagered + excluded.wagered,
+		paid = paid + excluded.paid,
+		biggest_winner = CASE WHEN excluded.biggest_win > biggest_win THEN excluded.biggest_winner ELSE biggest_winner END,
+		biggest_win = MAX(biggest_win, excluded.biggest_win)`, game, wagered, payout, win, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update daily house stats: %w", err)
+	}
+	return nil
+}
+
+// Computes the digest of the UTC day holding day from the database as it is now
+func (db *DB) BuildDailyReport(day time.Time) (*DailyReport, error) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 1)
+	report := &DailyReport{Day: start.Format("2006-01-02")}
+
+	err := db.conn.QueryRow(`SELECT COALESCE(SUM(is_guest = 0), 0), COALESCE(SUM(is_guest = 1), 0) FROM users
+		WHERE created_at >= ? AND created_at < ?`, sqlTime(start), sqlTime(end)).Scan(&report.Signups, &report.Guests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count signups: %w", err)
+	}
+
+	err = db.conn.QueryRow(`SELECT COUNT(DISTINCT user_id) FROM transactions WHERE type = ? AND created_at >= ? AND created_at < ?`,
+		TxBet, sqlTime(start), sqlTime(end)).Scan(&report.ActivePlayers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active players: %w", err)
+	}
+
+	if report.Games, err = db.dailyHouseStats(report.Day); err != nil {
+		return nil, err
+	}
+	for _, h := range report.Games {
+		report.Wagered += h.Wagered
+		report.Paid += h.Paid
+	}
+
+	err = db.conn.QueryRow(`SELECT h.biggest_win, COALESCE(u.username, ''), h.game FROM house_daily h
+		LEFT JOIN users u ON u.id = h.biggest_winner WHERE h.day = ? AND h.biggest_win > 0
+		ORDER BY h.biggest_win DESC LIMIT 1`, report.Day).Scan(&report.BiggestWin, &report.BiggestWinner, &report.BiggestWinGame)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get biggest win: %w", err)
+	}
+
+	return report, nil
+}
+
+// Files a report, replacing any earlier one for the same day
+func (db *DB) SaveDailyReport(r *DailyReport) error {
+	_, err := db.conn.Exec(`INSERT INTO daily_reports (day, signups, guests, active_players, wagered, paid,
+		biggest_win, biggest_winner, biggest_win_game) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(day) DO UPDATE SET signups = excluded.signups, guests = excluded.guests,
+		active_players = excluded.active_players, wagered = excluded.wagered, paid = excluded.paid,
+		biggest_win = excluded.biggest_win, biggest_winner = excluded.biggest_winner,
+		biggest_win_game = excluded.biggest_win_game, created_at = CURRENT_TIMESTAMP`,
+		r.Day, r.Signups, r.Guests, r.ActivePlayers, r.Wagered, r.Paid, r.BiggestWin, r.BiggestWinner, r.BiggestWinGame)
+	if err != nil {
+		return fmt.Errorf("failed to save daily report: %w", err)
+	}
+	return nil
+}
+
+// Returns the report filed for a day (YYYY-MM-DD), or nil when there is none
+func (db *DB) GetDailyReport(day string) (*DailyReport, error) {
+	var r DailyReport
+	err := db.conn.QueryRow(`SELECT day, signups, guests, active_players, wagered, paid, biggest_win,
+		biggest_winner, biggest_win_game, created_at FROM daily_reports WHERE day = ?`, day).Scan(
+		&r.Day, &r.Signups, &r.Guests, &r.ActivePlayers, &r.Wagered, &r.Paid, &r.BiggestWin,
+		&r.BiggestWinner, &r.BiggestWinGame, &r.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily report: %w", err)
+	}
+
+	// The per-game breakdown is kept by day anyway
+	games, err := db.dailyHouseStats(day)
+	if err != nil {
+		return nil, err
+	}
+	r.Games = games
+	return &r, nil
+}
+
+// Returns the day of the most recent report filed, or "" when none has been
+func (db *DB) LatestDailyReportDay() (string, error) {
+	var day sql.NullString
+	if err := db.conn.QueryRow(`SELECT MAX(day) FROM daily_reports`).Scan(&day); err != nil {
+		return "", fmt.Errorf("failed to get latest daily report: %w", err)
+	}
+	return day.String, nil
+}
+
+func (db *DB) dailyHouseStats(day string) ([]*HouseStats, error) {
+	rows, err := db.conn.Query(`SELECT game, rounds, wagered, paid FROM house_daily WHERE day = ? ORDER BY game`, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily house stats: %w", err)
+	}
+	defer rows.Close()
+
+	var games []*HouseStats
+	for rows.Next() {
+		var h HouseStats
+		if err := rows.Scan(&h.Game, &h.Rounds, &h.Wagered, &h.Paid); err != nil {
+			return nil, fmt.Errorf("failed to scan daily house stats: %w", err)
+		}
+		games = append(games, &h)
+	}
+	return games, rows.Err()
+}
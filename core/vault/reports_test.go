@@ -0,0 +1,73 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDailyReport(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	alice, err := db.CreateUser("reportalice", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	bob, err := db.CreateUser("reportbob", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if _, err := db.CreateGuestUser("guest_report", 100000); err != nil {
+		t.Fatalf("CreateGuestUser() error = %v", err)
+	}
+
+	for _, round := range []struct {
+		user        int
+		bet, payout int64
+	}{{alice.ID, 1000, 2500}, {bob.ID, 5000, 10000}, {bob.ID, 2000, 0}} {
+		if _, err := db.AdjustBalance(round.user, -round.bet, TxBet); err != nil {
+			t.Fatalf("AdjustBalance() error = %v", err)
+		}
+		if _, err := db.SettleRound(round.user, "blackjack", round.bet, round.payout, "", "", nil); err != nil {
+			t.Fatalf("SettleRound() error = %v", err)
+		}
+	}
+
+	report, err := db.BuildDailyReport(time.Now())
+	if err != nil {
+		t.Fatalf("BuildDailyReport() error = %v", err)
+	}
+	if report.Signups != 2 || report.Guests != 1 || report.ActivePlayers != 2 {
+		t.Errorf("BuildDailyReport() = %d signups, %d guests, %d active, want 2, 1, 2", report.Signups, report.Guests, report.ActivePlayers)
+	}
+	if report.Wagered != 8000 || report.Paid != 12500 || report.Hold() != -4500 {
+		t.Errorf("BuildDailyReport() wagered %d, paid %d, want 8000 and 12500", report.Wagered, report.Paid)
+	}
+	if len(report.Games) != 1 || report.Games[0].Rounds != 3 {
+		t.Errorf("BuildDailyReport() games = %+v, want three blackjack rounds", report.Games)
+	}
+	if report.BiggestWin != 5000 || report.BiggestWinner != "reportbob" || report.BiggestWinGame != "blackjack" {
+		t.Errorf("BuildDailyReport() biggest win = %d by %q at %q, want 5000 by reportbob", report.BiggestWin, report.BiggestWinner, report.BiggestWinGame)
+	}
+
+	if day, err := db.LatestDailyReportDay(); err != nil || day != "" {
+		t.Fatalf("LatestDailyReportDay() = %q, %v, want none", day, err)
+	}
+	if err := db.SaveDailyReport(report); err != nil {
+		t.Fatalf("SaveDailyReport() error = %v", err)
+	}
+	if day, err := db.LatestDailyReportDay(); err != nil || day != report.Day {
+		t.Errorf("LatestDailyReportDay() = %q, %v, want %q", day, err, report.Day)
+	}
+
+	filed, err := db.GetDailyReport(report.Day)
+	if err != nil || filed == nil {
+		t.Fatalf("GetDailyReport() = %v, %v", filed, err)
+	}
+	if filed.Wagered != 8000 || filed.BiggestWinner != "reportbob" || len(filed.Games) != 1 {
+		t.Errorf("GetDailyReport() = %+v, want the saved report", filed)
+	}
+	if missing, err := db.GetDailyReport("2000-01-01"); err != nil || missing != nil {
+		t.Errorf("GetDailyReport(unfiled) = %v, %v, want nil", missing, err)
+	}
+}
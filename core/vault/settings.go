@@ -0,0 +1,50 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Returns a server setting changed at runtime; ok is false when it was never set
+func (db *DB) GetSetting(key string) (value string, ok bool, err error) {
+	err = db.conn.QueryRow(`SELECT value FROM settings WHERE key = ?`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get setting: %w", err)
+	}
+
+	return value, true, nil
+}
+
+func (db *DB) SetSetting(key, value string) error {
+	query := `INSERT INTO settings (key, value) VALUES (?, ?)
+			  ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP`
+	if _, err := db.conn.Exec(query, key, value); err != nil {
+		return fmt.Errorf("failed to set setting: %w", err)
+	}
+	return nil
+}
+
+// Returns one of a user's own settings; ok is false when they never set it
+func (db *DB) GetUserSetting(userID int, key string) (value string, ok bool, err error) {
+	err = db.conn.QueryRow(`SELECT value FROM user_settings WHERE user_id = ? AND key = ?`, userID, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get user setting: %w", err)
+	}
+
+	return value, true, nil
+}
+
+func (db *DB) SetUserSetting(userID int, key, value string) error {
+	query := `INSERT INTO user_settings (user_id, key, value) VALUES (?, ?, ?)
+			  ON CONFLICT(user_id, key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP`
+	if _, err := db.conn.Exec(query, userID, key, value); err != nil {
+		return fmt.Errorf("failed to set user setting: %w", err)
+	}
+	return nil
+}
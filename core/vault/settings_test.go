@@ -0,0 +1,51 @@
+package vault
+
+import "testing"
+
+func TestSettings(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if _, ok, err := db.GetSetting("transfers_enabled"); err != nil || ok {
+		t.Fatalf("GetSetting() = %v, %v, want unset", ok, err)
+	}
+
+	for _, value := range []string{"0", "1"} {
+		if err := db.SetSetting("transfers_enabled", value); err != nil {
+			t.Fatalf("SetSetting() error = %v", err)
+		}
+
+		got, ok, err := db.GetSetting("transfers_enabled")
+		if err != nil || !ok || got != value {
+			t.Errorf("GetSetting() = %q, %v, %v, want %q", got, ok, err, value)
+		}
+	}
+}
+
+func TestUserSettings(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	alice, _ := db.CreateUser("alice", "hash")
+	bob, _ := db.CreateUser("bob", "hash")
+
+	if _, ok, err := db.GetUserSetting(alice.ID, "feed"); err != nil || ok {
+		t.Fatalf("GetUserSetting() = %v, %v, want unset", ok, err)
+	}
+
+	for _, value := range []string{"off", "on"} {
+		if err := db.SetUserSetting(alice.ID, "feed", value); err != nil {
+			t.Fatalf("SetUserSetting() error = %v", err)
+		}
+
+		got, ok, err := db.GetUserSetting(alice.ID, "feed")
+		if err != nil || !ok || got != value {
+			t.Errorf("GetUserSetting() = %q, %v, %v, want %q", got, ok, err, value)
+		}
+	}
+
+	// Settings belong to one user
+	if _, ok, err := db.GetUserSetting(bob.ID, "feed"); err != nil || ok {
+		t.Errorf("GetUserSetting() for another user = %v, %v, want unset", ok, err)
+	}
+}
@@ -3,6 +3,9 @@ package vault
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -13,6 +16,9 @@ type User struct {
 	Username  string    `json:"username"`
 	Password  string    `json:"-"`
 	Balance   int64     `json:"balance"` // Balance in cents
+	Points    int64     `json:"comp_points"`
+	IsAdmin   bool      `json:"is_admin"`
+	IsGuest   bool      `json:"is_guest"` // Temporary GUEST account, purged when idle
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -33,22 +39,73 @@ type UserStats struct {
 	TotalWon    int64 `json:"total_won"`
 	BiggestWin  int64 `json:"biggest_win"`
 	BiggestLoss int64 `json:"biggest_loss"`
+	// Current runs of consecutive wins and losses and the longest ever; pushes
+	// leave them unchanged
+	WinStreak      int64 `json:"win_streak"`
+	BestWinStreak  int64 `json:"best_win_streak"`
+	LossStreak     int64 `json:"loss_streak"`
+	BestLossStreak int64 `json:"best_loss_streak"`
+
+	// Rescue top-ups are kept apart from game results so they don't skew win/loss figures
+	Rebuys     int64 `json:"rebuys"`
+	RebuyTotal int64 `json:"rebuy_total"`
+
+	// Progression, changed only by AddXP and SetLevel
+	XP      int64  `json:"xp"`
+	Level   int    `json:"level"`
+	VIPTier string `json:"vip_tier"`
 }
 
 type DB struct {
 	conn *sql.DB
 }
 
+// DBOptions tunes the SQLite connection
+type DBOptions struct {
+	// Write-ahead logging, so readers don't wait on the writer. Off leaves
+	// the file in whatever journal mode it already has.
+	WAL bool
+	// How long a statement waits for a lock before failing with SQLITE_BUSY
+	BusyTimeout time.Duration
+	// Enforce FOREIGN KEY constraints, so rows can't be left pointing at a
+	// user that no longer exists
+	ForeignKeys bool
+}
+
+// The options NewDB opens with
+func DefaultDBOptions() DBOptions {
+	return DBOptions{WAL: true, BusyTimeout: 5 * time.Second, ForeignKeys: true}
+}
+
 func NewDB(filepath string) (*DB, error) {
-	conn, err := sql.Open("sqlite3", filepath)
+	return NewDBWithOptions(filepath, DefaultDBOptions())
+}
+
+func NewDBWithOptions(filepath string, opts DBOptions) (*DB, error) {
+	// Set through the DSN so every connection in the pool gets them
+	params := url.Values{}
+	params.Set("_busy_timeout", strconv.FormatInt(opts.BusyTimeout.Milliseconds(), 10))
+	params.Set("_foreign_keys", "0")
+	if opts.ForeignKeys {
+		params.Set("_foreign_keys", "1")
+	}
+	if opts.WAL {
+		params.Set("_journal_mode", "WAL")
+	}
+	sep := "?"
+	if strings.Contains(filepath, "?") {
+		sep = "&"
+	}
+
+	conn, err := sql.Open("sqlite3", filepath+sep+params.Encode())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	db := &DB{conn: conn}
-	if err := db.initTables(); err != nil {
+	if err := db.migrate(); err != nil {
 		conn.Close()
-		return nil, fmt.Errorf("failed to initialize tables: %w", err)
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
 	return db, nil
@@ -58,47 +115,6 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
-func (db *DB) initTables() error {
-	queries := []string{
-		`CREATE TABLE IF NOT EXISTS users (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			username TEXT UNIQUE NOT NULL,
-			password TEXT NOT NULL,
-			balance INTEGER NOT NULL DEFAULT 1000000,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)`,
-		`CREATE TABLE IF NOT EXISTS sessions (
-			id TEXT PRIMARY KEY,
-			user_id INTEGER NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			expires_at DATETIME NOT NULL,
-			FOREIGN KEY (user_id) REFERENCES users (id)
-		)`,
-		`CREATE TABLE IF NOT EXISTS user_stats (
-			user_id INTEGER PRIMARY KEY,
-			games_played INTEGER DEFAULT 0,
-			games_won INTEGER DEFAULT 0,
-			games_lost INTEGER DEFAULT 0,
-			total_bet INTEGER DEFAULT 0,
-			total_won INTEGER DEFAULT 0,
-			biggest_win INTEGER DEFAULT 0,
-			biggest_loss INTEGER DEFAULT 0,
-			FOREIGN KEY (user_id) REFERENCES users (id)
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions(expires_at)`,
-	}
-
-	for _, query := range queries {
-		if _, err := db.conn.Exec(query); err != nil {
-			return fmt.Errorf("failed to execute query '%s': %w", query, err)
-		}
-	}
-
-	return nil
-}
-
 func (db *DB) CreateUser(username, hashedPassword string) (*User, error) {
 	query := `INSERT INTO users (username, password) VALUES (?, ?)`
 	result, err := db.conn.Exec(query, username, hashedPassword)
@@ -118,12 +134,11 @@ func (db *DB) CreateUser(username, hashedPassword string) (*User, error) {
 	return db.GetUserByID(int(id))
 }
 
-func (db *DB) GetUserByUsername(username string) (*User, error) {
-	query := `SELECT id, username, password, balance, created_at, updated_at FROM users WHERE username = ?`
-	row := db.conn.QueryRow(query, username)
+const userColumns = `id, username, password, balance, comp_points, is_admin, is_guest, created_at, updated_at`
 
+func scanUser(row interface{ Scan(...interface{}) error }) (*User, error) {
 	var user User
-	err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Balance, &user.CreatedAt, &user.UpdatedAt)
+	err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Balance, &user.Points, &user.IsAdmin, &user.IsGuest, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("user not found")
@@ -134,20 +149,23 @@ func (db *DB) GetUserByUsername(username string) (*User, error) {
 	return &user, nil
 }
 
+func (db *DB) GetUserByUsername(username string) (*User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = ?`
+	return scanUser(db.conn.QueryRow(query, username))
+}
+
 func (db *DB) GetUserByID(id int) (*User, error) {
-	query := `SELECT id, username, password, balance, created_at, updated_at FROM users WHERE id = ?`
-	row := db.conn.QueryRow(query, id)
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = ?`
+	return scanUser(db.conn.QueryRow(query, id))
+}
 
-	var user User
-	err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Balance, &user.CreatedAt, &user.UpdatedAt)
+func (db *DB) SetUserAdmin(userID int, isAdmin bool) error {
+	query := `UPDATE users SET is_admin = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	_, err := db.conn.Exec(query, isAdmin, userID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
-		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return fmt.Errorf("failed to update user role: %w", err)
 	}
-
-	return &user, nil
+	return nil
 }
 
 func (db *DB) UpdateUserBalance(userID int, newBalance int64) error {
@@ -159,6 +177,15 @@ func (db *DB) UpdateUserBalance(userID int, newBalance int64) error {
 	return nil
 }
 
+func (db *DB) UpdateUserPassword(userID int, hashedPassword string) error {
+	query := `UPDATE users SET password = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	_, err := db.conn.Exec(query, hashedPassword, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user password: %w", err)
+	}
+	return nil
+}
+
 func (db *DB) CreateSession(sessionID string, userID int, expiresAt time.Time) error {
 	query := `INSERT INTO sessions (id, user_id, expires_at) VALUES (?, ?, ?)`
 	_, err := db.conn.Exec(query, sessionID, userID, expiresAt)
@@ -193,6 +220,14 @@ func (db *DB) DeleteSession(sessionID string) error {
 	return nil
 }
 
+// Ends every database session of a user
+func (db *DB) DeleteUserSessions(userID int) error {
+	if _, err := db.conn.Exec(`DELETE FROM sessions WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete sessions: %w", err)
+	}
+	return nil
+}
+
 func (db *DB) CleanupExpiredSessions() error {
 	query := `DELETE FROM sessions WHERE expires_at <= CURRENT_TIMESTAMP`
 	_, err := db.conn.Exec(query)
@@ -202,6 +237,46 @@ func (db *DB) CleanupExpiredSessions() error {
 	return nil
 }
 
+// Records a logged-out signed token so it stays rejected until it would have expired
+func (db *DB) RevokeToken(tokenID string, expiresAt time.Time) error {
+	query := `INSERT OR REPLACE INTO revoked_tokens (id, expires_at) VALUES (?, ?)`
+	_, err := db.conn.Exec(query, tokenID, expiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) ListRevokedTokens() (map[string]time.Time, error) {
+	query := `SELECT id, expires_at FROM revoked_tokens WHERE expires_at > CURRENT_TIMESTAMP`
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revoked tokens: %w", err)
+	}
+	defer rows.Close()
+
+	revoked := make(map[string]time.Time)
+	for rows.Next() {
+		var id string
+		var expiresAt time.Time
+		if err := rows.Scan(&id, &expiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan revoked token: %w", err)
+		}
+		revoked[id] = expiresAt
+	}
+
+	return revoked, rows.Err()
+}
+
+func (db *DB) CleanupRevokedTokens() error {
+	query := `DELETE FROM revoked_tokens WHERE expires_at <= CURRENT_TIMESTAMP`
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to cleanup revoked tokens: %w", err)
+	}
+	return nil
+}
+
 func (db *DB) initUserStats(userID int) error {
 	query := `INSERT INTO user_stats (user_id) VALUES (?)`
 	_, err := db.conn.Exec(query, userID)
@@ -212,13 +287,15 @@ func (db *DB) initUserStats(userID int) error {
 }
 
 func (db *DB) GetUserStats(userID int) (*UserStats, error) {
-	query := `SELECT user_id, games_played, games_won, games_lost, total_bet, total_won, biggest_win, biggest_loss 
+	query := `SELECT user_id, games_played, games_won, games_lost, total_bet, total_won, biggest_win, biggest_loss,
+			  win_streak, best_win_streak, loss_streak, best_loss_streak, rebuys, rebuy_total, xp, level, vip_tier
 			  FROM user_stats WHERE user_id = ?`
 	row := db.conn.QueryRow(query, userID)
 
 	var stats UserStats
 	err := row.Scan(&stats.UserID, &stats.GamesPlayed, &stats.GamesWon, &stats.GamesLost,
-		&stats.TotalBet, &stats.TotalWon, &stats.BiggestWin, &stats.BiggestLoss)
+		&stats.TotalBet, &stats.TotalWon, &stats.BiggestWin, &stats.BiggestLoss,
+		&stats.WinStreak, &stats.BestWinStreak, &stats.LossStreak, &stats.BestLossStreak, &stats.Rebuys, &stats.RebuyTotal, &stats.XP, &stats.Level, &stats.VIPTier)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("user stats not found")
@@ -229,13 +306,39 @@ func (db *DB) GetUserStats(userID int) (*UserStats, error) {
 	return &stats, nil
 }
 
+// Extends the win streak and ends any losing run
+func (stats *UserStats) RecordWin() {
+	stats.WinStreak++
+	stats.LossStreak = 0
+	stats.BestWinStreak = max(stats.BestWinStreak, stats.WinStreak)
+}
+
+// Extends the losing streak and ends any winning run
+func (stats *UserStats) RecordLoss() {
+	stats.LossStreak++
+	stats.WinStreak = 0
+	stats.BestLossStreak = max(stats.BestLossStreak, stats.LossStreak)
+}
+
+// Saves game results; rebuy and progression columns have their own writers
 func (db *DB) UpdateUserStats(stats *UserStats) error {
+	return updateUserStats(db.conn, stats)
+}
+
+// The database or a transaction
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func updateUserStats(conn execer, stats *UserStats) error {
 	query := `UPDATE user_stats SET 
 			  games_played = ?, games_won = ?, games_lost = ?, 
-			  total_bet = ?, total_won = ?, biggest_win = ?, biggest_loss = ?
+			  total_bet = ?, total_won = ?, biggest_win = ?, biggest_loss = ?,
+			  win_streak = ?, best_win_streak = ?, loss_streak = ?, best_loss_streak = ?
 			  WHERE user_id = ?`
-	_, err := db.conn.Exec(query, stats.GamesPlayed, stats.GamesWon, stats.GamesLost,
-		stats.TotalBet, stats.TotalWon, stats.BiggestWin, stats.BiggestLoss, stats.UserID)
+	_, err := conn.Exec(query, stats.GamesPlayed, stats.GamesWon, stats.GamesLost,
+		stats.TotalBet, stats.TotalWon, stats.BiggestWin, stats.BiggestLoss,
+		stats.WinStreak, stats.BestWinStreak, stats.LossStreak, stats.BestLossStreak, stats.UserID)
 	if err != nil {
 		return fmt.Errorf("failed to update user stats: %w", err)
 	}
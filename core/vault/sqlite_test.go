@@ -1,6 +1,7 @@
 package vault
 
 import (
+	"database/sql"
 	"os"
 	"path/filepath"
 	"testing"
@@ -219,3 +220,131 @@ func TestUserStats(t *testing.T) {
 		t.Errorf("Updated GamesWon = %v, want 3", updatedStats.GamesWon)
 	}
 }
+
+func TestStreaks(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("streakuser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	stats, err := db.GetUserStats(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserStats() error = %v", err)
+	}
+
+	for _, won := range []bool{true, true, true, false, false, true} {
+		if won {
+			stats.RecordWin()
+		} else {
+			stats.RecordLoss()
+		}
+	}
+	if err := db.UpdateUserStats(stats); err != nil {
+		t.Fatalf("UpdateUserStats() error = %v", err)
+	}
+
+	stored, err := db.GetUserStats(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserStats() error = %v", err)
+	}
+	if stored.WinStreak != 1 || stored.BestWinStreak != 3 || stored.LossStreak != 0 || stored.BestLossStreak != 2 {
+		t.Errorf("Streaks = win %d (best %d), loss %d (best %d), want 1 (3), 0 (2)",
+			stored.WinStreak, stored.BestWinStreak, stored.LossStreak, stored.BestLossStreak)
+	}
+}
+
+func TestSetUserAdmin(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	user, err := db.CreateUser("adminuser", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if user.IsAdmin {
+		t.Error("CreateUser() should not create admins")
+	}
+
+	if err := db.SetUserAdmin(user.ID, true); err != nil {
+		t.Fatalf("SetUserAdmin() error = %v", err)
+	}
+
+	stored, err := db.GetUserByUsername("adminuser")
+	if err != nil {
+		t.Fatalf("GetUserByUsername() error = %v", err)
+	}
+	if !stored.IsAdmin {
+		t.Error("GetUserByUsername() IsAdmin = false after SetUserAdmin(true)")
+	}
+}
+
+func TestNewDBUpgradesExistingSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+
+	// A users table from before the is_admin column existed
+	conn, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	_, err = conn.Exec(`CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username TEXT UNIQUE NOT NULL,
+		password TEXT NOT NULL,
+		balance INTEGER NOT NULL DEFAULT 1000000,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	); INSERT INTO users (username, password) VALUES ('olduser', 'hash')`)
+	conn.Close()
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	db, err := NewDB(dbPath)
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	user, err := db.GetUserByUsername("olduser")
+	if err != nil {
+		t.Fatalf("GetUserByUsername() error = %v", err)
+	}
+	if user.IsAdmin {
+		t.Error("Upgraded user should not be an admin")
+	}
+}
+
+func TestNewDBOptions(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	var journal string
+	var busy, foreignKeys int
+	if err := db.conn.QueryRow(`PRAGMA journal_mode`).Scan(&journal); err != nil || journal != "wal" {
+		t.Errorf("journal_mode = %q, %v, want wal", journal, err)
+	}
+	if err := db.conn.QueryRow(`PRAGMA busy_timeout`).Scan(&busy); err != nil || busy != 5000 {
+		t.Errorf("busy_timeout = %d, %v, want 5000", busy, err)
+	}
+	if err := db.conn.QueryRow(`PRAGMA foreign_keys`).Scan(&foreignKeys); err != nil || foreignKeys != 1 {
+		t.Errorf("foreign_keys = %d, %v, want 1", foreignKeys, err)
+	}
+	if _, err := db.conn.Exec(`INSERT INTO sessions (id, user_id, expires_at) VALUES ('orphan', 999, ?)`, time.Now()); err == nil {
+		t.Error("A session for a missing user should be rejected")
+	}
+
+	loose, err := NewDBWithOptions(filepath.Join(t.TempDir(), "loose.db"), DBOptions{BusyTimeout: time.Second})
+	if err != nil {
+		t.Fatalf("NewDBWithOptions() error = %v", err)
+	}
+	defer loose.Close()
+	if err := loose.conn.QueryRow(`PRAGMA foreign_keys`).Scan(&foreignKeys); err != nil || foreignKeys != 0 {
+		t.Errorf("foreign_keys = %d, %v, want 0 when turned off", foreignKeys, err)
+	}
+	if err := loose.conn.QueryRow(`PRAGMA busy_timeout`).Scan(&busy); err != nil || busy != 1000 {
+		t.Errorf("busy_timeout = %d, %v, want 1000", busy, err)
+	}
+}
@@ -0,0 +1,120 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TableHand is one finished round at a multiplayer table
+type TableHand struct {
+	ID          int64            `json:"id"`
+	TableID     string           `json:"table_id"`
+	Dealer      string           `json:"dealer"`
+	DealerValue int              `json:"dealer_value"`
+	Seats       []*TableHandSeat `json:"seats"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// TableHandSeat is one player's hand in a TableHand
+type TableHandSeat struct {
+	Seat     int    `json:"seat"`
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Cards    string `json:"cards"`
+	Value    int    `json:"value"`
+	Bet      int64  `json:"bet"`
+	Payout   int64  `json:"payout"`
+	Result   string `json:"result"`
+}
+
+// Records a finished table round and every seat dealt into it
+func (db *DB) SaveTableHand(hand *TableHand) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO table_hands (table_id, dealer, dealer_value) VALUES (?, ?, ?)`,
+		hand.TableID, hand.Dealer, hand.DealerValue)
+	if err != nil {
+		return fmt.Errorf("failed to save table hand: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to save table hand: %w", err)
+	}
+
+	for _, s := range hand.Seats {
+		query := `INSERT INTO table_hand_seats (hand_id, seat, user_id, username, cards, value, bet, payout, result)
+				  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		if _, err := tx.Exec(query, id, s.Seat, s.UserID, s.Username, s.Cards, s.Value, s.Bet, s.Payout, s.Result); err != nil {
+			return fmt.Errorf("failed to save table hand seat: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit table hand: %w", err)
+	}
+
+	hand.ID = id
+	return nil
+}
+
+// Returns the last table hands a user was dealt into, newest first, with
+// every seat of each hand
+func (db *DB) ListTableHands(userID, limit int) ([]*TableHand, error) {
+	rows, err := db.conn.Query(`SELECT h.id, h.table_id, h.dealer, h.dealer_value, h.created_at
+		FROM table_hands h JOIN table_hand_seats s ON s.hand_id = h.id
+		WHERE s.user_id = ? ORDER BY h.id DESC LIMIT ?`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list table hands: %w", err)
+	}
+	defer rows.Close()
+
+	var hands []*TableHand
+	byID := make(map[int64]*TableHand)
+	for rows.Next() {
+		var h TableHand
+		if err := rows.Scan(&h.ID, &h.TableID, &h.Dealer, &h.DealerValue, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan table hand: %w", err)
+		}
+		hands = append(hands, &h)
+		byID[h.ID] = &h
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list table hands: %w", err)
+	}
+	rows.Close()
+
+	if len(hands) == 0 {
+		return hands, nil
+	}
+
+	ids := make([]any, 0, len(hands))
+	for _, h := range hands {
+		ids = append(ids, h.ID)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	seatRows, err := db.conn.Query(`SELECT hand_id, seat, user_id, username, cards, value, bet, payout, result
+		FROM table_hand_seats WHERE hand_id IN (`+placeholders+`) ORDER BY hand_id, seat`, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list table hand seats: %w", err)
+	}
+	defer seatRows.Close()
+
+	for seatRows.Next() {
+		var handID int64
+		var s TableHandSeat
+		if err := seatRows.Scan(&handID, &s.Seat, &s.UserID, &s.Username, &s.Cards, &s.Value, &s.Bet, &s.Payout, &s.Result); err != nil {
+			return nil, fmt.Errorf("failed to scan table hand seat: %w", err)
+		}
+		byID[handID].Seats = append(byID[handID].Seats, &s)
+	}
+	if err := seatRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list table hand seats: %w", err)
+	}
+
+	return hands, nil
+}
@@ -0,0 +1,44 @@
+package vault
+
+import "testing"
+
+func TestTableHands(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	alice, _ := db.CreateUser("alice", "hash")
+	bob, _ := db.CreateUser("bob", "hash")
+
+	for i, dealer := range []string{"[K♠] [7♥]", "[9♦] [9♣]"} {
+		hand := &TableHand{TableID: "low-1", Dealer: dealer, DealerValue: 17 + i, Seats: []*TableHandSeat{
+			{Seat: 0, UserID: alice.ID, Username: "alice", Cards: "[10♠] [9♥]", Value: 19, Bet: 500, Payout: 1000, Result: "Win"},
+		}}
+		if i == 1 {
+			hand.Seats = append(hand.Seats, &TableHandSeat{Seat: 2, UserID: bob.ID, Username: "bob", Cards: "[5♠] [6♥]", Value: 11, Bet: 100, Result: "Loss"})
+		}
+		if err := db.SaveTableHand(hand); err != nil {
+			t.Fatalf("SaveTableHand() error = %v", err)
+		}
+		if hand.ID == 0 {
+			t.Errorf("SaveTableHand() didn't set the hand ID")
+		}
+	}
+
+	hands, err := db.ListTableHands(alice.ID, 10)
+	if err != nil {
+		t.Fatalf("ListTableHands() error = %v", err)
+	}
+	if len(hands) != 2 || hands[0].Dealer != "[9♦] [9♣]" || len(hands[0].Seats) != 2 || len(hands[1].Seats) != 1 {
+		t.Fatalf("ListTableHands(alice) = %+v, want both hands newest first with every seat", hands)
+	}
+	if s := hands[0].Seats[1]; s.Username != "bob" || s.Seat != 2 || s.Result != "Loss" {
+		t.Errorf("ListTableHands() seat = %+v, want bob's hand", s)
+	}
+
+	if hands, err := db.ListTableHands(alice.ID, 1); err != nil || len(hands) != 1 {
+		t.Errorf("ListTableHands(alice, 1) = %d hands, %v, want 1", len(hands), err)
+	}
+	if hands, err := db.ListTableHands(bob.ID, 10); err != nil || len(hands) != 1 {
+		t.Errorf("ListTableHands(bob) = %d hands, %v, want the one he played", len(hands), err)
+	}
+}
@@ -0,0 +1,59 @@
+package vault
+
+import (
+	"fmt"
+	"time"
+)
+
+// Moves amount from one user to another, writing both ledger entries in a
+// single transaction. dailyLimit caps what the sender may send since the given
+// time; zero means no limit. Returns the sender's new balance.
+func (db *DB) Transfer(fromID, toID int, amount, dailyLimit int64, since time.Time) (int64, error) {
+	if fromID == toID {
+		return 0, fmt.Errorf("cannot transfer to yourself")
+	}
+	if amount <= 0 {
+		return 0, fmt.Errorf("transfer amount must be positive")
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if dailyLimit > 0 {
+		var sent int64
+		if err := tx.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM transactions WHERE user_id = ? AND type = ? AND created_at >= ?`,
+			fromID, TxTransferOut, sqlTime(since)).Scan(&sent); err != nil {
+			return 0, fmt.Errorf("failed to sum transfers: %w", err)
+		}
+		// Outgoing transfers are stored as negative amounts
+		if -sent+amount > dailyLimit {
+			return 0, fmt.Errorf("transfer would exceed the daily limit of $%.2f ($%.2f remaining)",
+				float64(dailyLimit)/100, float64(max(dailyLimit+sent, 0))/100)
+		}
+	}
+
+	var fromName, toName string
+	if err := tx.QueryRow(`SELECT username FROM users WHERE id = ?`, fromID).Scan(&fromName); err != nil {
+		return 0, fmt.Errorf("user not found")
+	}
+	if err := tx.QueryRow(`SELECT username FROM users WHERE id = ?`, toID).Scan(&toName); err != nil {
+		return 0, fmt.Errorf("user not found")
+	}
+
+	balance, err := adjustBalanceTx(tx, fromID, -amount, TxTransferOut, "to "+toName)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := adjustBalanceTx(tx, toID, amount, TxTransferIn, "from "+fromName); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transfer: %w", err)
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,57 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransfer(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	alice, err := db.CreateUser("alice", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	bob, err := db.CreateUser("bob", "hashedpassword123")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+
+	balance, err := db.Transfer(alice.ID, bob.ID, 30000, 50000, since)
+	if err != nil {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	if balance != 970000 {
+		t.Errorf("Transfer() balance = %d, want 970000", balance)
+	}
+
+	if _, err := db.Transfer(alice.ID, bob.ID, 30000, 50000, since); err == nil {
+		t.Error("Transfer() should enforce the daily limit")
+	}
+	if _, err := db.Transfer(alice.ID, alice.ID, 100, 0, since); err == nil {
+		t.Error("Transfer() should reject sending to yourself")
+	}
+	if _, err := db.Transfer(alice.ID, bob.ID, 5000000, 0, since); err == nil {
+		t.Error("Transfer() should reject overdrawing the sender")
+	}
+
+	// A failed transfer must not leave half of it behind
+	stored, err := db.GetUserByID(bob.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if stored.Balance != 1030000 {
+		t.Errorf("Recipient balance = %d, want 1030000", stored.Balance)
+	}
+
+	txs, err := db.ListTransactions(bob.ID, 10)
+	if err != nil {
+		t.Fatalf("ListTransactions() error = %v", err)
+	}
+	if len(txs) != 1 || txs[0].Type != TxTransferIn || txs[0].Metadata != "from alice" {
+		t.Errorf("Recipient ledger = %+v, want one transfer from alice", txs)
+	}
+}
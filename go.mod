@@ -7,6 +7,13 @@ require (
 	github.com/mattn/go-sqlite3 v1.14.24
 	golang.org/x/crypto v0.29.0
 	golang.org/x/term v0.26.0
+	google.golang.org/grpc v1.68.2
+	google.golang.org/protobuf v1.35.2
 )
 
-require golang.org/x/sys v0.27.0 // indirect
+require (
+	golang.org/x/net v0.29.0 // indirect
+	golang.org/x/sys v0.27.0 // indirect
+	golang.org/x/text v0.20.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240903143218-8af14fe29dc1 // indirect
+)